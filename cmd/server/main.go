@@ -23,6 +23,7 @@ import (
 	"cold-backend/internal/health"
 	"cold-backend/internal/middleware"
 	"cold-backend/internal/monitoring"
+	"cold-backend/internal/payment"
 	"cold-backend/internal/repositories"
 	"cold-backend/internal/services"
 	"cold-backend/internal/sms"
@@ -421,6 +422,21 @@ func main() {
 	onlineTransactionRepo := repositories.NewOnlineTransactionRepository(pool)
 	pendingSettingChangeRepo := repositories.NewPendingSettingChangeRepository(pool)
 	totpRepo := repositories.NewTOTPRepository(pool)
+	rentTariffRepo := repositories.NewRentTariffRepository(pool)
+	rateContractRepo := repositories.NewRateContractRepository(pool)
+	interestRepo := repositories.NewInterestRepository(pool)
+	cropLoanRepo := repositories.NewCropLoanRepository(pool)
+	thockLienRepo := repositories.NewThockLienRepository(pool)
+	accountingRepo := repositories.NewAccountingRepository(pool)
+	tallyRepo := repositories.NewTallyRepository(pool)
+	accountingPeriodRepo := repositories.NewAccountingPeriodRepository(pool)
+	ledgerIntegrityRepo := repositories.NewLedgerIntegrityRepository(pool)
+	cashSessionRepo := repositories.NewCashSessionRepository(pool)
+	bankStatementRepo := repositories.NewBankStatementRepository(pool)
+	paymentAllocationRepo := repositories.NewPaymentAllocationRepository(pool)
+	walletRepo := repositories.NewWalletRepository(pool)
+	onlineRefundRepo := repositories.NewOnlineRefundRepository(pool)
+	paymentLinkRepo := repositories.NewPaymentLinkRepository(pool)
 
 	// Initialize middleware (needed for both modes)
 	authMiddleware := middleware.NewAuthMiddleware(jwtManager, userRepo)
@@ -456,6 +472,10 @@ func main() {
 		otpService.SetSettingRepo(systemSettingRepo)
 		otpService.SetActivityLogRepo(customerActivityLogRepo)
 
+		// Initialize rent tariff service (rate cards - single source of rent rates)
+		rentTariffService := services.NewRentTariffService(rentTariffRepo, systemSettingRepo)
+		rentTariffService.SetContractRepo(rateContractRepo) // Apply negotiated customer rates
+
 		// Initialize customer portal service
 		customerPortalService := services.NewCustomerPortalService(
 			customerRepo,
@@ -467,6 +487,8 @@ func main() {
 			gatePassPickupRepo,
 			ledgerRepo,
 		)
+		customerPortalService.SetTariffService(rentTariffService)
+		customerPortalService.SetWalletService(services.NewWalletService(walletRepo, customerRepo))
 
 		// Initialize customer portal handler
 		customerPortalHandler := handlers.NewCustomerPortalHandler(
@@ -475,7 +497,7 @@ func main() {
 			jwtManager,
 		)
 
-		// Initialize Razorpay service and handler for online payments
+		// Initialize online payment service (gateway chosen in settings) and handler
 		razorpayService := services.NewRazorpayService(
 			cfg.Razorpay.KeyID,
 			cfg.Razorpay.KeySecret,
@@ -486,10 +508,26 @@ func main() {
 			customerRepo,
 			systemSettingRepo,
 		)
+		portalLedgerService := services.NewLedgerService(ledgerRepo)
+		portalLedgerService.SetAccountingService(services.NewAccountingService(accountingRepo))
+		portalLedgerService.SetAllocationService(services.NewPaymentAllocationService(paymentAllocationRepo))
+		razorpayService.SetLedgerService(portalLedgerService) // Journal and allocate online payments
+		razorpayService.SetRefundRepo(onlineRefundRepo)       // refund.processed / refund.failed webhooks
+		razorpayService.SetPaymentLinkRepo(paymentLinkRepo)
+		if cfg.FakePaymentGatewaySecret != "" {
+			log.Println("[Payment] Test payment gateway enabled - do not use in production")
+			razorpayService.SetFakeGateway(payment.NewFakeGateway(cfg.FakePaymentGatewaySecret))
+		}
 		razorpayHandler := handlers.NewRazorpayHandler(razorpayService, customerRepo)
 
+		// Initialize payment link checkout (public /pay/{token} pages)
+		paymentLinkService := services.NewPaymentLinkService(paymentLinkRepo, customerRepo, systemSettingRepo)
+		paymentLinkService.SetRazorpayService(razorpayService)
+		paymentLinkService.SetPortalService(customerPortalService)
+		paymentLinkHandler := handlers.NewPaymentLinkHandler(paymentLinkService, nil)
+
 		// Create customer router
-		router := h.NewCustomerRouter(customerPortalHandler, pageHandler, healthHandler, authMiddleware, razorpayHandler)
+		router := h.NewCustomerRouter(customerPortalHandler, pageHandler, healthHandler, authMiddleware, razorpayHandler, paymentLinkHandler)
 
 		// Wrap with panic recovery and metrics middleware
 		handler = middleware.PanicRecovery(middleware.MetricsMiddleware(corsMiddleware(router)))
@@ -506,12 +544,34 @@ func main() {
 		printerService := services.NewPrinterService()
 		printerHandler := handlers.NewPrinterHandler(printerService)
 		roomEntryService := services.NewRoomEntryService(roomEntryRepo, roomEntryGatarRepo, entryRepo, entryEventRepo, printerService)
+		warehouseLayoutService := services.NewWarehouseLayoutService(repositories.NewWarehouseLayoutRepository(pool))
+		roomEntryService.SetLayoutService(warehouseLayoutService)
+		roomEntryService.SetSettingRepo(systemSettingRepo)
 		systemSettingService := services.NewSystemSettingService(systemSettingRepo)
 		rentPaymentService := services.NewRentPaymentService(rentPaymentRepo)
 		invoiceService := services.NewInvoiceService(invoiceRepo)
 		gatePassService := services.NewGatePassService(gatePassRepo, entryRepo, entryEventRepo, gatePassPickupRepo, roomEntryRepo)
 		ledgerService := services.NewLedgerService(ledgerRepo)
+		accountingService := services.NewAccountingService(accountingRepo)
+		ledgerService.SetAccountingService(accountingService) // Post ledger entries to the double-entry journal
+		accountingPeriodService := services.NewAccountingPeriodService(accountingPeriodRepo)
+		ledgerService.SetPeriodService(accountingPeriodService) // Lock closed periods
+		accountingService.SetPeriodService(accountingPeriodService)
+		rentPaymentService.SetLedgerService(ledgerService) // Cheques credit the ledger on clearance
+		rentPaymentService.SetSettingRepo(systemSettingRepo)
+		rentPaymentService.SetCustomerRepo(customerRepo)
 		debtService := services.NewDebtService(debtRequestRepo, ledgerService)
+		rentTariffService := services.NewRentTariffService(rentTariffRepo, systemSettingRepo)
+		rentTariffService.SetContractRepo(rateContractRepo) // Apply negotiated customer rates
+		rentChargeService := services.NewRentChargeService(rentTariffService, entryRepo, roomEntryRepo, gatePassRepo, gatePassPickupRepo, customerRepo, ledgerService, systemSettingRepo)
+		gatePassService.SetRentChargeService(rentChargeService) // Post rent CHARGE per pickup lot
+		cropLoanService := services.NewCropLoanService(cropLoanRepo, entryRepo, roomEntryRepo, gatePassPickupRepo, debtRequestRepo, ledgerService, systemSettingRepo)
+		gatePassService.SetCropLoanService(cropLoanService) // Block release of pledged stock
+		debtService.SetCropLoanService(cropLoanService)
+		gatePassService.SetLienRepo(thockLienRepo) // Bags under bank lien cannot be withdrawn
+		paymentAllocationService := services.NewPaymentAllocationService(paymentAllocationRepo)
+		ledgerService.SetAllocationService(paymentAllocationService) // Allocate payments to thock charges FIFO
+		debtService.SetAllocationService(paymentAllocationService)   // Gate pass checks the thock's own balance
 
 		// Initialize SMS logging and notification service
 		smsLogRepo := repositories.NewSMSLogRepository(pool)
@@ -587,12 +647,14 @@ func main() {
 		customerHandler.SetLedgerRepo(ledgerRepo) // Cascade phone changes to ledger
 		entryHandler := handlers.NewEntryHandler(entryService, entryEditLogRepo, entryManagementLogRepo, adminActionLogRepo)
 		roomEntryHandler := handlers.NewRoomEntryHandler(roomEntryService, roomEntryEditLogRepo)
+		roomEntryHandler.SetAllocationService(services.NewSlotAllocationService(warehouseLayoutService, entryRepo, roomEntryGatarRepo))
 		entryEventHandler := handlers.NewEntryEventHandler(entryEventRepo)
 		systemSettingHandler := handlers.NewSystemSettingHandler(systemSettingService)
 		entryHandler.SetSettingService(systemSettingService) // Wire SettingService for skip thock ranges
 		rentPaymentHandler := handlers.NewRentPaymentHandler(rentPaymentService, ledgerService, adminActionLogRepo)
 		rentPaymentHandler.SetNotificationService(notificationService)
 		rentPaymentHandler.SetCustomerService(customerService)
+		rentPaymentHandler.SetAllocationService(paymentAllocationService)
 		invoiceHandler := handlers.NewInvoiceHandler(invoiceService)
 		loginLogHandler := handlers.NewLoginLogHandler(loginLogRepo)
 		// Set OTP repo for customer login logs in admin panel
@@ -667,16 +729,87 @@ func main() {
 
 		// Initialize report service (bulk PDF/CSV export with parallel processing)
 		reportService := services.NewReportService(pool, customerRepo, entryRepo, roomEntryRepo, rentPaymentRepo, systemSettingRepo)
+		reportService.SetTariffService(rentTariffService)
+		reportService.SetCashSessionRepo(cashSessionRepo)
 		reportHandler := handlers.NewReportHandler(reportService)
 
 		// Initialize account handler (optimized single-call endpoint for Account Management)
 		accountHandler := handlers.NewAccountHandler(pool, entryRepo, roomEntryRepo, rentPaymentRepo, gatePassRepo, systemSettingRepo, ledgerRepo)
+		accountHandler.SetTariffService(rentTariffService)
+		accountHandler.SetRentChargeService(rentChargeService)
+
+		// Initialize rent tariff handler (rate card management)
+		rentTariffHandler := handlers.NewRentTariffHandler(rentTariffService, entryRepo, adminActionLogRepo)
+		rentChargeHandler := handlers.NewRentChargeHandler(rentChargeService, adminActionLogRepo)
+
+		// Initialize rate contract handler (customer negotiated rates)
+		rateContractService := services.NewRateContractService(rateContractRepo, customerRepo, familyMemberRepo)
+		rateContractHandler := handlers.NewRateContractHandler(rateContractService, adminActionLogRepo)
+
+		// Initialize interest service (monthly interest on overdue balances)
+		interestService := services.NewInterestService(interestRepo, ledgerService, systemSettingRepo)
+		interestService.Start()
+		defer interestService.Stop()
+		interestHandler := handlers.NewInterestHandler(interestService, adminActionLogRepo)
+
+		// Initialize crop loan handler (advances against stored stock)
+		cropLoanService.Start()
+		defer cropLoanService.Stop()
+		cropLoanHandler := handlers.NewCropLoanHandler(cropLoanService, adminActionLogRepo)
+
+		// Initialize bank lien handler (warehouse receipts for pledged stock)
+		thockLienService := services.NewThockLienService(thockLienRepo, entryRepo, roomEntryRepo, gatePassPickupRepo)
+		thockLienHandler := handlers.NewThockLienHandler(thockLienService, adminActionLogRepo)
+
+		// Initialize accounting handler (chart of accounts, journals, financial statements)
+		accountingHandler := handlers.NewAccountingHandler(accountingService, adminActionLogRepo)
+
+		// Initialize Tally handler (XML vouchers and ledger masters for the CA)
+		tallyService := services.NewTallyService(tallyRepo, accountingRepo, customerRepo, systemSettingRepo)
+		tallyHandler := handlers.NewTallyHandler(tallyService, adminActionLogRepo)
+
+		// Initialize ledger integrity checker (nightly running-balance and payment cross-check)
+		ledgerIntegrityService := services.NewLedgerIntegrityService(ledgerIntegrityRepo, systemSettingRepo)
+		if metricsRepo != nil {
+			ledgerIntegrityService.SetAlertRepo(metricsRepo)
+		}
+		ledgerIntegrityService.Start()
+		defer ledgerIntegrityService.Stop()
+		ledgerIntegrityHandler := handlers.NewLedgerIntegrityHandler(ledgerIntegrityService, adminActionLogRepo)
+
+		// Initialize cash session handler (cash drawer open/close per accountant)
+		cashSessionService := services.NewCashSessionService(cashSessionRepo)
+		cashSessionHandler := handlers.NewCashSessionHandler(cashSessionService, adminActionLogRepo)
+
+		// Initialize bank statement handler (statement import and reconciliation queue)
+		bankStatementService := services.NewBankStatementService(bankStatementRepo, rentPaymentService, ledgerService, customerRepo)
+		bankStatementHandler := handlers.NewBankStatementHandler(bankStatementService, adminActionLogRepo)
+
+		// Initialize payment allocation handler (per-thock outstanding and manual allocation)
+		paymentAllocationHandler := handlers.NewPaymentAllocationHandler(paymentAllocationService, adminActionLogRepo)
+
+		// Initialize wallet handler (advance deposits and season-end refunds)
+		walletService := services.NewWalletService(walletRepo, customerRepo)
+		walletService.SetLedgerService(ledgerService)
+		walletHandler := handlers.NewWalletHandler(walletService, adminActionLogRepo)
 
 		// Initialize entry room handler (optimized single-call endpoint for Entry Room page)
 		entryRoomHandler := handlers.NewEntryRoomHandler(pool, entryRepo, roomEntryRepo, customerRepo, guardEntryRepo)
 
 		// Initialize room visualization handler (visual storage occupancy map)
-		roomVisualizationHandler := handlers.NewRoomVisualizationHandler(pool)
+		roomVisualizationHandler := handlers.NewRoomVisualizationHandler(pool, warehouseLayoutService)
+
+		// Initialize gatar maintenance (bag-turning, ventilation and inspection schedules)
+		gatarMaintenanceService := services.NewGatarMaintenanceService(repositories.NewGatarMaintenanceRepository(pool), warehouseLayoutService)
+		gatarMaintenanceHandler := handlers.NewGatarMaintenanceHandler(gatarMaintenanceService, adminActionLogRepo)
+		roomVisualizationHandler.SetMaintenanceService(gatarMaintenanceService)
+
+		// Initialize warehouse layout handler (rooms, floors and gatars editable by admins)
+		warehouseLayoutHandler := handlers.NewWarehouseLayoutHandler(warehouseLayoutService, adminActionLogRepo)
+
+		// Initialize gatar movement handler (stock shifting between gatars with a per-gatar ledger)
+		gatarMovementService := services.NewGatarMovementService(repositories.NewGatarMovementRepository(pool), roomEntryGatarRepo, entryEventRepo, warehouseLayoutService, systemSettingRepo)
+		gatarMovementHandler := handlers.NewGatarMovementHandler(gatarMovementService)
 
 		// Initialize customer activity log handler (for admin to view customer portal logs)
 		customerActivityLogRepo := repositories.NewCustomerActivityLogRepository(pool)
@@ -710,7 +843,26 @@ func main() {
 			customerRepo,
 			systemSettingRepo,
 		)
+		razorpayService.SetLedgerService(ledgerService)
+		razorpayService.SetRefundRepo(onlineRefundRepo) // Refunds through the transaction's gateway
+		razorpayService.SetPaymentLinkRepo(paymentLinkRepo)
+		if cfg.FakePaymentGatewaySecret != "" {
+			log.Println("[Payment] Test payment gateway enabled - do not use in production")
+			razorpayService.SetFakeGateway(payment.NewFakeGateway(cfg.FakePaymentGatewaySecret))
+		}
 		razorpayHandler := handlers.NewRazorpayHandler(razorpayService, customerRepo)
+		razorpayHandler.SetAdminActionRepo(adminActionLogRepo)
+
+		// Initialize payment link service and handler (links sent with balance reminders)
+		paymentLinkService := services.NewPaymentLinkService(paymentLinkRepo, customerRepo, systemSettingRepo)
+		paymentLinkService.SetSMSService(employeeSMSService)
+		smsHandler.SetPaymentLinkService(paymentLinkService)
+		paymentLinkHandler := handlers.NewPaymentLinkHandler(paymentLinkService, adminActionLogRepo)
+
+		// Initialize settlement reconciliation (gateway payouts checked against online payments)
+		gatewaySettlementRepo := repositories.NewGatewaySettlementRepository(pool)
+		settlementService := services.NewSettlementService(gatewaySettlementRepo, onlineTransactionRepo, razorpayService, systemSettingRepo)
+		settlementHandler := handlers.NewSettlementHandler(settlementService, adminActionLogRepo)
 
 		// Initialize pending setting change handler (dual admin approval for sensitive settings)
 		pendingSettingHandler := handlers.NewPendingSettingHandler(
@@ -720,12 +872,15 @@ func main() {
 			totpService,
 		)
 
+		// Initialize accounting period handler (period close, reopen needs a second admin)
+		accountingPeriodHandler := handlers.NewAccountingPeriodHandler(accountingPeriodService, userRepo, totpService, adminActionLogRepo)
+
 		// Initialize point-in-time restore service and handler
 		restoreService := services.NewRestoreService(pool, connStr)
 		restoreHandler := handlers.NewRestoreHandler(restoreService)
 
 		// Create employee router
-		router := h.NewRouter(userHandler, authHandler, customerHandler, entryHandler, roomEntryHandler, entryEventHandler, systemSettingHandler, rentPaymentHandler, invoiceHandler, loginLogHandler, roomEntryEditLogHandler, entryEditLogHandler, entryManagementLogHandler, adminActionLogHandler, gatePassHandler, seasonHandler, guardEntryHandler, tokenColorHandler, pageHandler, healthHandler, authMiddleware, operationModeMiddleware, monitoringHandler, apiLoggingMiddleware, nodeProvisioningHandler, deploymentHandler, reportHandler, accountHandler, entryRoomHandler, roomVisualizationHandler, setupHandler, ledgerHandler, debtHandler, mergeHistoryHandler, customerActivityLogHandler, smsHandler, familyMemberHandler, razorpayHandler, pendingSettingHandler, totpHandler, restoreHandler, printerHandler)
+		router := h.NewRouter(userHandler, authHandler, customerHandler, entryHandler, roomEntryHandler, entryEventHandler, systemSettingHandler, rentPaymentHandler, invoiceHandler, loginLogHandler, roomEntryEditLogHandler, entryEditLogHandler, entryManagementLogHandler, adminActionLogHandler, gatePassHandler, seasonHandler, guardEntryHandler, tokenColorHandler, pageHandler, healthHandler, authMiddleware, operationModeMiddleware, monitoringHandler, apiLoggingMiddleware, nodeProvisioningHandler, deploymentHandler, reportHandler, accountHandler, entryRoomHandler, roomVisualizationHandler, setupHandler, ledgerHandler, debtHandler, mergeHistoryHandler, customerActivityLogHandler, smsHandler, familyMemberHandler, razorpayHandler, pendingSettingHandler, totpHandler, restoreHandler, printerHandler, rentTariffHandler, rentChargeHandler, rateContractHandler, interestHandler, cropLoanHandler, thockLienHandler, accountingHandler, tallyHandler, accountingPeriodHandler, ledgerIntegrityHandler, cashSessionHandler, bankStatementHandler, paymentAllocationHandler, walletHandler, paymentLinkHandler, settlementHandler, warehouseLayoutHandler, gatarMovementHandler, gatarMaintenanceHandler)
 
 		// Add gallery routes if enabled
 		if cfg.G.Enabled {
@@ -54,6 +54,10 @@ type Config struct {
 		KeySecret     string `mapstructure:"key_secret"`
 		WebhookSecret string `mapstructure:"webhook_secret"`
 	} `mapstructure:"razorpay"`
+
+	// FakePaymentGatewaySecret enables the in-memory test payment gateway (payment_gateway = fake).
+	// Only for local and staging testing - never set in production.
+	FakePaymentGatewaySecret string `mapstructure:"fake_payment_gateway_secret"`
 }
 
 func Load() *Config {
@@ -136,6 +140,9 @@ func Load() *Config {
 	if webhookSecret := os.Getenv("RAZORPAY_WEBHOOK_SECRET"); webhookSecret != "" {
 		cfg.Razorpay.WebhookSecret = webhookSecret
 	}
+	if secret := os.Getenv("FAKE_PAYMENT_GATEWAY_SECRET"); secret != "" {
+		cfg.FakePaymentGatewaySecret = secret
+	}
 
 	return &cfg
 }
@@ -14,6 +14,7 @@ import (
 	"cold-backend/internal/middleware"
 	"cold-backend/internal/models"
 	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -26,13 +27,15 @@ const (
 
 // AccountHandler handles account management endpoints
 type AccountHandler struct {
-	DB              *pgxpool.Pool
-	EntryRepo       *repositories.EntryRepository
-	RoomEntryRepo   *repositories.RoomEntryRepository
-	RentPaymentRepo *repositories.RentPaymentRepository
-	GatePassRepo    *repositories.GatePassRepository
-	SettingsRepo    *repositories.SystemSettingRepository
-	LedgerRepo      *repositories.LedgerRepository
+	DB                *pgxpool.Pool
+	EntryRepo         *repositories.EntryRepository
+	RoomEntryRepo     *repositories.RoomEntryRepository
+	RentPaymentRepo   *repositories.RentPaymentRepository
+	GatePassRepo      *repositories.GatePassRepository
+	SettingsRepo      *repositories.SystemSettingRepository
+	LedgerRepo        *repositories.LedgerRepository
+	TariffService     *services.RentTariffService
+	RentChargeService *services.RentChargeService
 }
 
 // LedgerPayment represents a payment from the ledger (for display)
@@ -89,6 +92,10 @@ type ThockInfo struct {
 	Quantity         int     `json:"quantity"`
 	QtyDisplay       string  `json:"qty_display,omitempty"`
 	Rent             float64 `json:"rent"`
+	RatePerItem      float64 `json:"rate_per_item"`
+	RateSource       string  `json:"rate_source,omitempty"` // contract, tariff or default
+	ContractID       *int    `json:"contract_id,omitempty"` // Negotiated rate contract applied
+	ContractName     string  `json:"contract_name,omitempty"`
 	Date             string  `json:"date"`
 	Type             string  `json:"type"` // "incoming" or "outgoing"
 }
@@ -132,6 +139,7 @@ func NewAccountHandler(
 		GatePassRepo:    gatePassRepo,
 		SettingsRepo:    settingsRepo,
 		LedgerRepo:      ledgerRepo,
+		TariffService:   services.NewRentTariffService(nil, settingsRepo), // Default rate only until SetTariffService
 	}
 
 	// Register pre-warm callback for account summary
@@ -146,6 +154,17 @@ func NewAccountHandler(
 	return h
 }
 
+// SetTariffService sets the rent tariff service used to price thocks
+func (h *AccountHandler) SetTariffService(tariffService *services.RentTariffService) {
+	h.TariffService = tariffService
+}
+
+// SetRentChargeService makes the summary take rent from the CHARGE entries posted per
+// pickup, while rent_charge_on_pickup is enabled, instead of pricing stored quantity
+func (h *AccountHandler) SetRentChargeService(rentChargeService *services.RentChargeService) {
+	h.RentChargeService = rentChargeService
+}
+
 // GetAccountSummary returns all data needed for account management in ONE request
 // This eliminates 5+ sequential API calls from the frontend
 // Uses Redis cache with 10 minute TTL for fast responses
@@ -192,6 +211,7 @@ func (h *AccountHandler) GetAccountSummary(w http.ResponseWriter, r *http.Reques
 // UsedDebtRequest represents a used debt request for credit tracking
 type UsedDebtRequest struct {
 	CustomerPhone     string
+	ThockNumber       string
 	RequestedQuantity int
 }
 
@@ -207,14 +227,13 @@ func (h *AccountHandler) generateAccountSummary(ctx context.Context) (*AccountSu
 		familyMemberMap     map[int]map[string]string // customer_id -> name -> relation
 		ledgerCredits       map[string]float64        // phone -> total credits from ledger
 		ledgerPayments      map[string][]repositories.PaymentHistoryItem // phone -> payment history
-		rentPerItem         float64
+		rateCard            *services.RentRateCard
 		wg                  sync.WaitGroup
 		entriesErr          error
 		roomErr             error
 		paymentsErr         error
 		gatePassErr         error
 		debtErr             error
-		settingsErr         error
 		ledgerCreditsErr    error
 		ledgerPaymentsErr   error
 	)
@@ -251,23 +270,11 @@ func (h *AccountHandler) generateAccountSummary(ctx context.Context) (*AccountSu
 		usedDebtRequests, debtErr = h.getUsedDebtRequests(ctx)
 	}()
 
-	// Fetch rent per item setting
+	// Fetch rent rate cards (tariffs + default rent per item)
 	go func() {
 		defer wg.Done()
-		setting, err := h.SettingsRepo.Get(ctx, "rent_per_item")
-		if err != nil {
-			settingsErr = err
-			return
-		}
-		if setting != nil {
-			// Try to parse as number - the value might be stored as a string like "10" or as JSON
-			var val float64
-			if err := json.Unmarshal([]byte(setting.SettingValue), &val); err != nil {
-				// Try parsing as plain string number
-				fmt.Sscanf(setting.SettingValue, "%f", &val)
-			}
-			rentPerItem = val
-		}
+		// A rate card that fails to load still carries the default rate
+		rateCard, _ = h.TariffService.LoadRateCard(ctx)
 	}()
 
 	// Fetch all family members to get relations
@@ -327,10 +334,8 @@ func (h *AccountHandler) generateAccountSummary(ctx context.Context) (*AccountSu
 	if paymentsErr != nil {
 		return nil, fmt.Errorf("failed to load payments: %w", paymentsErr)
 	}
-	// Gate pass, debt, settings, and ledger errors are non-fatal
-	if settingsErr != nil {
-		rentPerItem = 0
-	}
+	// Gate pass, debt, and ledger errors are non-fatal
+	rentPerItem := rateCard.DefaultRate
 	if gatePassErr != nil {
 		completedGatePasses = []CompletedGatePass{}
 	}
@@ -344,10 +349,40 @@ func (h *AccountHandler) generateAccountSummary(ctx context.Context) (*AccountSu
 		ledgerPayments = make(map[string][]repositories.PaymentHistoryItem)
 	}
 
+	// With rent charged per pickup the ledger holds the rent: each thock's rent is what
+	// has been charged to it, and stock taken out on credit is already in those charges
+	var thockCharged map[string]float64
+	var gatePassCharged map[int]float64
+	if h.RentChargeService != nil && h.LedgerRepo != nil && h.RentChargeService.IsChargeOnPickupEnabled(ctx) {
+		var err error
+		if thockCharged, err = h.LedgerRepo.GetAllChargesByThock(ctx); err != nil {
+			return nil, fmt.Errorf("failed to load rent charges: %w", err)
+		}
+		if gatePassCharged, err = h.LedgerRepo.GetAllPickupChargesByGatePass(ctx); err != nil {
+			return nil, fmt.Errorf("failed to load pickup charges: %w", err)
+		}
+		usedDebtRequests = []UsedDebtRequest{}
+	}
+
+	// Resolve rent rate per thock from the rate cards and customer contracts
+	thockRate := make(map[string]float64)
+	thockQuote := make(map[string]models.RentTariffQuote)
+	for _, entry := range entries {
+		quote := rateCard.QuoteEntry(entry)
+		thockQuote[entry.ThockNumber] = quote
+		thockRate[entry.ThockNumber] = quote.SeasonRate()
+	}
+	rateForThock := func(thockNumber string) float64 {
+		if rate, ok := thockRate[thockNumber]; ok {
+			return rate
+		}
+		return rentPerItem
+	}
+
 	// Build credit map from used debt requests (items taken on credit that are still owed)
 	creditByPhone := make(map[string]float64)
 	for _, dr := range usedDebtRequests {
-		creditByPhone[dr.CustomerPhone] += float64(dr.RequestedQuantity) * rentPerItem
+		creditByPhone[dr.CustomerPhone] += float64(dr.RequestedQuantity) * rateForThock(dr.ThockNumber)
 	}
 
 	// Build thock stored quantity map from room entries
@@ -377,7 +412,12 @@ func (h *AccountHandler) generateAccountSummary(ctx context.Context) (*AccountSu
 		customer := customerMap[phone]
 		storedQty := thockStoredQty[entry.ThockNumber]
 		expectedQty := entry.ExpectedQuantity
-		rent := float64(storedQty) * rentPerItem
+		quote := thockQuote[entry.ThockNumber]
+		rate := thockRate[entry.ThockNumber]
+		rent := float64(storedQty) * rate
+		if thockCharged != nil {
+			rent = thockCharged[entry.ThockNumber]
+		}
 
 		qtyDisplay := ""
 		if expectedQty != storedQty {
@@ -391,6 +431,10 @@ func (h *AccountHandler) generateAccountSummary(ctx context.Context) (*AccountSu
 			Quantity:         storedQty,
 			QtyDisplay:       qtyDisplay,
 			Rent:             rent,
+			RatePerItem:      rate,
+			RateSource:       quote.Source,
+			ContractID:       quote.ContractID,
+			ContractName:     quote.ContractName,
 			Date:             entry.CreatedAt.Format("02/01/2006"),
 			Type:             "incoming",
 		})
@@ -410,13 +454,18 @@ func (h *AccountHandler) generateAccountSummary(ctx context.Context) (*AccountSu
 		if quantity == 0 {
 			quantity = gp.RequestedQty
 		}
-		rent := float64(quantity) * rentPerItem
+		rate := rateForThock(gp.ThockNumber)
+		rent := float64(quantity) * rate
+		if gatePassCharged != nil {
+			rent = gatePassCharged[gp.ID]
+		}
 
 		customer.Thocks = append(customer.Thocks, ThockInfo{
 			ID:          gp.ID,
 			ThockNumber: gp.ThockNumber,
 			Quantity:    -quantity, // Negative for outgoing
 			Rent:        -rent,
+			RatePerItem: rate,
 			Date:        gp.CompletedAt.Format("02/01/2006"),
 			Type:        "outgoing",
 		})
@@ -443,6 +492,9 @@ func (h *AccountHandler) generateAccountSummary(ctx context.Context) (*AccountSu
 		} else {
 			// Fallback: sum from rent payments
 			for _, p := range customer.Payments {
+				if p.PaymentMode == models.PaymentModeCheque && p.ClearanceStatus != models.ChequeStatusCleared {
+					continue
+				}
 				customer.TotalPaid += p.AmountPaid
 			}
 		}
@@ -458,6 +510,7 @@ func (h *AccountHandler) generateAccountSummary(ctx context.Context) (*AccountSu
 		familyMemberThocks := make(map[string][]ThockInfo)
 		familyMemberQty := make(map[string]int)
 		familyMemberRent := make(map[string]float64)
+		familyMemberQuoted := make(map[string]float64) // stored quantity at the quoted rate
 
 		for _, thock := range customer.Thocks {
 			fmName := thock.FamilyMemberName
@@ -468,6 +521,7 @@ func (h *AccountHandler) generateAccountSummary(ctx context.Context) (*AccountSu
 			if thock.Type == "incoming" {
 				familyMemberQty[fmName] += thock.Quantity
 				familyMemberRent[fmName] += thock.Rent
+				familyMemberQuoted[fmName] += float64(thock.Quantity) * thock.RatePerItem
 			}
 		}
 
@@ -518,9 +572,14 @@ func (h *AccountHandler) generateAccountSummary(ctx context.Context) (*AccountSu
 			}
 
 			// Calculate canTakeOut: items paid for minus items already picked up
+			// Priced at the family member's average rate across their thocks
+			fmRate := rentPerItem
+			if familyMemberQty[fmName] > 0 {
+				fmRate = familyMemberQuoted[fmName] / float64(familyMemberQty[fmName])
+			}
 			fmCanTakeOut := 0
-			if rentPerItem > 0 {
-				itemsPaidFor := int(fmPaid / rentPerItem)
+			if fmRate > 0 {
+				itemsPaidFor := int(fmPaid / fmRate)
 				fmCanTakeOut = itemsPaidFor - fmOutgoing
 				if fmCanTakeOut < 0 {
 					fmCanTakeOut = 0
@@ -662,7 +721,7 @@ func (h *AccountHandler) getCompletedGatePasses(ctx context.Context) ([]Complete
 // getUsedDebtRequests fetches used debt requests (items taken on credit)
 func (h *AccountHandler) getUsedDebtRequests(ctx context.Context) ([]UsedDebtRequest, error) {
 	query := `
-		SELECT customer_phone, requested_quantity
+		SELECT customer_phone, thock_number, requested_quantity
 		FROM debt_requests
 		WHERE status = 'used'
 	`
@@ -676,7 +735,7 @@ func (h *AccountHandler) getUsedDebtRequests(ctx context.Context) ([]UsedDebtReq
 	var results []UsedDebtRequest
 	for rows.Next() {
 		var dr UsedDebtRequest
-		if err := rows.Scan(&dr.CustomerPhone, &dr.RequestedQuantity); err != nil {
+		if err := rows.Scan(&dr.CustomerPhone, &dr.ThockNumber, &dr.RequestedQuantity); err != nil {
 			return nil, err
 		}
 		results = append(results, dr)
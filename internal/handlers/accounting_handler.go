@@ -0,0 +1,256 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+	"cold-backend/internal/timeutil"
+
+	"github.com/gorilla/mux"
+)
+
+// AccountingHandler handles chart of accounts, journal and financial statement endpoints
+type AccountingHandler struct {
+	Service         *services.AccountingService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewAccountingHandler(service *services.AccountingService, adminActionRepo *repositories.AdminActionLogRepository) *AccountingHandler {
+	return &AccountingHandler{
+		Service:         service,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+// ListAccounts returns the chart of accounts
+// GET /api/accounting/accounts?active=true
+func (h *AccountingHandler) ListAccounts(w http.ResponseWriter, r *http.Request) {
+	accounts, err := h.Service.ListAccounts(r.Context(), r.URL.Query().Get("active") == "true")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if accounts == nil {
+		accounts = []*models.Account{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(accounts)
+}
+
+// CreateAccount adds an account to the chart of accounts (admin only)
+// POST /api/accounting/accounts
+func (h *AccountingHandler) CreateAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CreateAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	account, err := h.Service.CreateAccount(r.Context(), &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "CREATE", "account", &account.ID, fmt.Sprintf("Created account %s %s (%s)", account.Code, account.Name, account.AccountType))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(account)
+}
+
+// ListJournals returns journal entries
+// GET /api/accounting/journals?account=1000&start_date=YYYY-MM-DD&end_date=YYYY-MM-DD&limit=100&offset=0
+func (h *AccountingHandler) ListJournals(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := &models.JournalFilter{AccountCode: q.Get("account")}
+	filter.Limit, _ = strconv.Atoi(q.Get("limit"))
+	filter.Offset, _ = strconv.Atoi(q.Get("offset"))
+	if t, err := timeutil.ParseInIST("2006-01-02", q.Get("start_date")); err == nil {
+		filter.StartDate = &t
+	}
+	if t, err := timeutil.ParseInIST("2006-01-02", q.Get("end_date")); err == nil {
+		t = timeutil.EndOfDay(t)
+		filter.EndDate = &t
+	}
+
+	journals, err := h.Service.ListJournals(r.Context(), filter)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if journals == nil {
+		journals = []*models.JournalEntry{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(journals)
+}
+
+// GetJournal returns a journal entry with its lines
+// GET /api/accounting/journals/{id}
+func (h *AccountingHandler) GetJournal(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid journal ID", http.StatusBadRequest)
+		return
+	}
+
+	journal, err := h.Service.GetJournal(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Journal entry not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(journal)
+}
+
+// CreateJournal records a manual journal (expenses, capital, adjustments)
+// POST /api/accounting/journals
+func (h *AccountingHandler) CreateJournal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CreateJournalRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	journal, err := h.Service.CreateManualJournal(r.Context(), &req, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "CREATE", "journal_entry", &journal.ID, fmt.Sprintf("Manual journal: %s", journal.Description))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(journal)
+}
+
+// GetTrialBalance returns the trial balance
+// GET /api/accounting/trial-balance?as_of=YYYY-MM-DD (defaults to today)
+func (h *AccountingHandler) GetTrialBalance(w http.ResponseWriter, r *http.Request) {
+	asOf, ok := parseReportDate(w, r.URL.Query().Get("as_of"), timeutil.Now())
+	if !ok {
+		return
+	}
+
+	tb, err := h.Service.TrialBalance(r.Context(), timeutil.EndOfDay(asOf))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tb)
+}
+
+// GetProfitAndLoss returns income less expenses for a period
+// GET /api/accounting/profit-loss?from=YYYY-MM-DD&to=YYYY-MM-DD (defaults to the current month)
+func (h *AccountingHandler) GetProfitAndLoss(w http.ResponseWriter, r *http.Request) {
+	now := timeutil.Now()
+	from, ok := parseReportDate(w, r.URL.Query().Get("from"), now.AddDate(0, 0, 1-now.Day()))
+	if !ok {
+		return
+	}
+	to, ok := parseReportDate(w, r.URL.Query().Get("to"), now)
+	if !ok {
+		return
+	}
+
+	pl, err := h.Service.ProfitAndLoss(r.Context(), timeutil.StartOfDay(from), timeutil.EndOfDay(to))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pl)
+}
+
+// GetBalanceSheet returns the balance sheet
+// GET /api/accounting/balance-sheet?as_of=YYYY-MM-DD (defaults to today)
+func (h *AccountingHandler) GetBalanceSheet(w http.ResponseWriter, r *http.Request) {
+	asOf, ok := parseReportDate(w, r.URL.Query().Get("as_of"), timeutil.Now())
+	if !ok {
+		return
+	}
+
+	bs, err := h.Service.BalanceSheet(r.Context(), timeutil.EndOfDay(asOf))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(bs)
+}
+
+// SyncLedger posts any ledger entries missing from the journal (admin only)
+// POST /api/accounting/sync
+func (h *AccountingHandler) SyncLedger(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	result, err := h.Service.SyncLedger(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.logAction(r, userID, "UPDATE", "journal_entry", nil, fmt.Sprintf("Synced ledger to journal: %d posted, %d skipped", result.Posted, result.Skipped))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// logAction records an accounting action in the admin action log
+func (h *AccountingHandler) logAction(r *http.Request, userID int, actionType, targetType string, targetID *int, description string) {
+	if h.AdminActionRepo == nil {
+		return
+	}
+	h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+		AdminUserID: userID,
+		ActionType:  actionType,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Description: description,
+	})
+}
+
+// parseReportDate parses an optional YYYY-MM-DD query value (IST), writing a 400 on bad input
+func parseReportDate(w http.ResponseWriter, value string, def time.Time) (time.Time, bool) {
+	if value == "" {
+		return def, true
+	}
+	t, err := timeutil.ParseInIST("2006-01-02", value)
+	if err != nil {
+		http.Error(w, "Invalid date. Use YYYY-MM-DD", http.StatusBadRequest)
+		return time.Time{}, false
+	}
+	return t, true
+}
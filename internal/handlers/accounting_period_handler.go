@@ -0,0 +1,281 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+
+	"github.com/gorilla/mux"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// AccountingPeriodHandler handles period close, balance snapshots and reopen approvals
+type AccountingPeriodHandler struct {
+	Service         *services.AccountingPeriodService
+	UserRepo        *repositories.UserRepository
+	TOTPService     *services.TOTPService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewAccountingPeriodHandler(service *services.AccountingPeriodService, userRepo *repositories.UserRepository, totpService *services.TOTPService, adminActionRepo *repositories.AdminActionLogRepository) *AccountingPeriodHandler {
+	return &AccountingPeriodHandler{
+		Service:         service,
+		UserRepo:        userRepo,
+		TOTPService:     totpService,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+// ListPeriods returns the twelve months of a fiscal year with their close status
+// GET /api/accounting-periods?fiscal_year=2025-26 (defaults to the current fiscal year)
+func (h *AccountingPeriodHandler) ListPeriods(w http.ResponseWriter, r *http.Request) {
+	periods, err := h.Service.ListPeriods(r.Context(), r.URL.Query().Get("fiscal_year"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	lockedThrough, _ := h.Service.LockedThrough(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"periods":        periods,
+		"locked_through": lockedThrough,
+	})
+}
+
+// GetPeriodBalances returns the customer balance snapshot taken at close
+// GET /api/accounting-periods/{id}/balances
+func (h *AccountingPeriodHandler) GetPeriodBalances(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid period ID", http.StatusBadRequest)
+		return
+	}
+
+	period, err := h.Service.GetPeriod(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Period not found", http.StatusNotFound)
+		return
+	}
+	balances, err := h.Service.GetBalances(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if balances == nil {
+		balances = []models.AccountingPeriodBalance{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"period":   period,
+		"balances": balances,
+	})
+}
+
+// ClosePeriods closes every open month up to the requested month or fiscal year end
+// POST /api/accounting-periods/close
+func (h *AccountingPeriodHandler) ClosePeriods(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.ClosePeriodRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	closed, err := h.Service.ClosePeriods(r.Context(), &req, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, p := range closed {
+		h.logAction(r, userID, "CLOSE", "accounting_period", &p.ID,
+			fmt.Sprintf("Closed accounting period %s (FY %s)", p.StartDate.Format("January 2006"), p.FiscalYear))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(closed)
+}
+
+// RequestReopen asks another admin to reopen the latest closed period
+// POST /api/accounting-periods/{id}/reopen
+func (h *AccountingPeriodHandler) RequestReopen(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid period ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreatePeriodReopenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	request, err := h.Service.RequestReopen(r.Context(), id, req.Reason, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "REQUEST", "accounting_period", &id,
+		fmt.Sprintf("Requested reopen of %s: %s", request.PeriodStart.Format("January 2006"), request.Reason))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Reopen request created. Awaiting approval from another admin.",
+		"request": request,
+	})
+}
+
+// ListReopenRequests returns reopen requests
+// GET /api/accounting-periods/reopen-requests?status=pending
+func (h *AccountingPeriodHandler) ListReopenRequests(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	requests, err := h.Service.ListReopenRequests(r.Context(), r.URL.Query().Get("status"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if requests == nil {
+		requests = []*models.PeriodReopenRequest{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(requests)
+}
+
+// ApproveReopen reopens a period after a second admin confirms with password (and 2FA if enabled)
+// POST /api/accounting-periods/reopen-requests/{id}/approve
+func (h *AccountingPeriodHandler) ApproveReopen(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid request ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ApproveSettingChangeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Password == "" {
+		http.Error(w, "Password is required for approval", http.StatusBadRequest)
+		return
+	}
+
+	// Verify approver's password
+	user, err := h.UserRepo.Get(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "User not found", http.StatusNotFound)
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
+		http.Error(w, "Invalid password", http.StatusUnauthorized)
+		return
+	}
+
+	// If approver has 2FA enabled, verify TOTP code
+	if user.TOTPEnabled {
+		if req.TOTPCode == "" {
+			http.Error(w, "2FA code is required for approval", http.StatusBadRequest)
+			return
+		}
+		valid, err := h.TOTPService.Verify(r.Context(), userID, req.TOTPCode, getIPAddress(r))
+		if err != nil {
+			if _, ok := err.(*services.TOTPError); ok {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			http.Error(w, "2FA verification failed", http.StatusInternalServerError)
+			return
+		}
+		if !valid {
+			http.Error(w, "Invalid 2FA code", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	request, err := h.Service.ApproveReopen(r.Context(), id, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "APPROVE", "accounting_period", &request.PeriodID,
+		fmt.Sprintf("Approved reopen of %s requested by %s", request.PeriodStart.Format("January 2006"), request.RequestedByName))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(request)
+}
+
+// RejectReopen rejects a reopen request
+// POST /api/accounting-periods/reopen-requests/{id}/reject
+func (h *AccountingPeriodHandler) RejectReopen(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid request ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.RejectSettingChangeRequest
+	json.NewDecoder(r.Body).Decode(&req)
+
+	if err := h.Service.RejectReopen(r.Context(), id, userID, req.Reason); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Reopen request rejected",
+	})
+}
+
+// logAction records a period action in the admin action log
+func (h *AccountingPeriodHandler) logAction(r *http.Request, userID int, actionType, targetType string, targetID *int, description string) {
+	if h.AdminActionRepo == nil {
+		return
+	}
+	h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+		AdminUserID: userID,
+		ActionType:  actionType,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Description: description,
+	})
+}
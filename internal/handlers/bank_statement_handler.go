@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// BankStatementHandler handles bank statement imports and the reconciliation queue
+type BankStatementHandler struct {
+	Service         *services.BankStatementService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewBankStatementHandler(service *services.BankStatementService, adminActionRepo *repositories.AdminActionLogRepository) *BankStatementHandler {
+	return &BankStatementHandler{
+		Service:         service,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+// ImportStatement uploads a bank statement (.csv or .xlsx) and matches its credits
+// POST /api/bank-statements/import (multipart form, field "file")
+func (h *BankStatementHandler) ImportStatement(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		http.Error(w, "Failed to parse form: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "No file uploaded", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, "Failed to read file", http.StatusBadRequest)
+		return
+	}
+
+	imp, lines, err := h.Service.Import(r.Context(), header.Filename, data, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if lines == nil {
+		lines = []*models.BankStatementLine{}
+	}
+
+	h.logAction(r, userID, "IMPORT", "bank_statement_import", &imp.ID,
+		fmt.Sprintf("Imported bank statement %s: %d credits, %d matched, %d unmatched, %d duplicates",
+			imp.FileName, imp.CreditCount, imp.MatchedCount, imp.UnmatchedCount, imp.DuplicateCount))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"import": imp,
+		"lines":  lines,
+	})
+}
+
+// ListImports returns recent statement imports
+// GET /api/bank-statements/imports?limit=30
+func (h *BankStatementHandler) ListImports(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	imports, err := h.Service.ListImports(r.Context(), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if imports == nil {
+		imports = []*models.BankStatementImport{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(imports)
+}
+
+// ListLines returns statement credits; status=unmatched is the reconciliation queue
+// GET /api/bank-statements/lines?status=unmatched&import_id=3
+func (h *BankStatementHandler) ListLines(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.BankLineStatusMatched, models.BankLineStatusUnmatched, models.BankLineStatusAssigned, models.BankLineStatusIgnored:
+	default:
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+	importID, _ := strconv.Atoi(r.URL.Query().Get("import_id"))
+
+	lines, err := h.Service.ListLines(r.Context(), status, importID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if lines == nil {
+		lines = []*models.BankStatementLine{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lines)
+}
+
+// AssignLine assigns an unmatched credit to a customer and posts it to their ledger
+// POST /api/bank-statements/lines/{id}/assign
+func (h *BankStatementHandler) AssignLine(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid line ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.AssignBankLineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	line, err := h.Service.Assign(r.Context(), id, &req, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "ASSIGN", "bank_statement_line", &line.ID,
+		fmt.Sprintf("Assigned bank credit ₹%.2f of %s to %s (%s)",
+			line.Amount, line.TxnDate.Format("02 Jan 2006"), line.CustomerName, line.CustomerPhone))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(line)
+}
+
+// IgnoreLine removes a credit that is not a customer payment from the queue
+// POST /api/bank-statements/lines/{id}/ignore
+func (h *BankStatementHandler) IgnoreLine(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid line ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.IgnoreBankLineRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	line, err := h.Service.Ignore(r.Context(), id, req.Notes, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "IGNORE", "bank_statement_line", &line.ID,
+		fmt.Sprintf("Ignored bank credit ₹%.2f of %s: %s", line.Amount, line.TxnDate.Format("02 Jan 2006"), line.Notes))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(line)
+}
+
+// logAction records a reconciliation action in the admin action log
+func (h *BankStatementHandler) logAction(r *http.Request, userID int, actionType, targetType string, targetID *int, description string) {
+	if h.AdminActionRepo == nil {
+		return
+	}
+	h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+		AdminUserID: userID,
+		ActionType:  actionType,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Description: description,
+	})
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+	"cold-backend/internal/timeutil"
+
+	"github.com/gorilla/mux"
+)
+
+// CashSessionHandler handles cash drawer open, close and daily closing review
+type CashSessionHandler struct {
+	Service         *services.CashSessionService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewCashSessionHandler(service *services.CashSessionService, adminActionRepo *repositories.AdminActionLogRepository) *CashSessionHandler {
+	return &CashSessionHandler{
+		Service:         service,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+// GetCurrentSession returns the caller's open drawer with live totals
+// GET /api/cash-sessions/current
+func (h *CashSessionHandler) GetCurrentSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	session, err := h.Service.GetCurrent(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"session": session,
+	})
+}
+
+// OpenSession opens the caller's drawer with an opening float
+// POST /api/cash-sessions/open
+func (h *CashSessionHandler) OpenSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.OpenCashSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.Service.Open(r.Context(), userID, req.OpeningFloat)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "OPEN", &session.ID,
+		fmt.Sprintf("Opened cash session with float ₹%.2f", session.OpeningFloat))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(session)
+}
+
+// CloseSession closes the caller's drawer with a denomination count
+// POST /api/cash-sessions/close
+func (h *CashSessionHandler) CloseSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CloseCashSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.Service.Close(r.Context(), userID, &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	description := fmt.Sprintf("Closed cash session: expected ₹%.2f, counted ₹%.2f", session.ExpectedCash, *session.CountedCash)
+	if *session.Difference != 0 {
+		description += fmt.Sprintf(", difference ₹%.2f", *session.Difference)
+	}
+	h.logAction(r, userID, "CLOSE", &session.ID, description)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// ListSessions returns drawer sessions for a day. Admins see every employee;
+// others only see their own.
+// GET /api/cash-sessions?date=YYYY-MM-DD&user_id=5
+func (h *CashSessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	date := timeutil.Now()
+	if v := r.URL.Query().Get("date"); v != "" {
+		var err error
+		if date, err = timeutil.ParseInIST("2006-01-02", v); err != nil {
+			http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	filterUser, _ := strconv.Atoi(r.URL.Query().Get("user_id"))
+	if role, _ := middleware.GetRoleFromContext(r.Context()); role != "admin" {
+		filterUser = userID
+	}
+
+	sessions, err := h.Service.List(r.Context(), timeutil.StartOfDay(date), timeutil.EndOfDay(date), filterUser)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if sessions == nil {
+		sessions = []*models.CashSession{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sessions)
+}
+
+// GetSession returns a session with the payments taken in it
+// GET /api/cash-sessions/{id}
+func (h *CashSessionHandler) GetSession(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	session, payments, err := h.Service.Get(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Cash session not found", http.StatusNotFound)
+		return
+	}
+	if role, _ := middleware.GetRoleFromContext(r.Context()); role != "admin" && session.UserID != userID {
+		http.Error(w, "Forbidden", http.StatusForbidden)
+		return
+	}
+	if payments == nil {
+		payments = []*models.RentPayment{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"session":  session,
+		"payments": payments,
+	})
+}
+
+// logAction records a cash session action in the admin action log
+func (h *CashSessionHandler) logAction(r *http.Request, userID int, actionType string, targetID *int, description string) {
+	if h.AdminActionRepo == nil {
+		return
+	}
+	h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+		AdminUserID: userID,
+		ActionType:  actionType,
+		TargetType:  "cash_session",
+		TargetID:    targetID,
+		Description: description,
+	})
+}
@@ -0,0 +1,206 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+	"cold-backend/internal/timeutil"
+
+	"github.com/gorilla/mux"
+)
+
+// CropLoanHandler handles crop loan (advance against stored stock) endpoints
+type CropLoanHandler struct {
+	Service         *services.CropLoanService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewCropLoanHandler(service *services.CropLoanService, adminActionRepo *repositories.AdminActionLogRepository) *CropLoanHandler {
+	return &CropLoanHandler{
+		Service:         service,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+// ListLoans returns crop loans
+// GET /api/crop-loans?status=active&phone=X&thock_number=Y
+func (h *CropLoanHandler) ListLoans(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	loans, err := h.Service.ListLoans(r.Context(), q.Get("status"), q.Get("phone"), q.Get("thock_number"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if loans == nil {
+		loans = []*models.CropLoan{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(loans)
+}
+
+// GetLoan returns a loan with its repayments
+// GET /api/crop-loans/{id}
+func (h *CropLoanHandler) GetLoan(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid loan ID", http.StatusBadRequest)
+		return
+	}
+
+	detail, err := h.Service.GetLoan(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Crop loan not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(detail)
+}
+
+// GetEligibility returns the loan-to-value position of a thock
+// GET /api/crop-loans/eligibility?thock_number=X
+func (h *CropLoanHandler) GetEligibility(w http.ResponseWriter, r *http.Request) {
+	eligibility, err := h.Service.GetEligibility(r.Context(), r.URL.Query().Get("thock_number"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(eligibility)
+}
+
+// CreateLoan disburses a loan against a thock
+// POST /api/crop-loans
+func (h *CropLoanHandler) CreateLoan(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CreateCropLoanRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	loan, err := h.Service.Disburse(r.Context(), &req, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "CREATE", &loan.ID,
+		fmt.Sprintf("Disbursed crop loan of ₹%.2f to %s against thock %s (%d bags)", loan.Principal, loan.CustomerName, loan.ThockNumber, loan.PledgedQuantity))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(loan)
+}
+
+// ListRepayments returns a loan's repayments
+// GET /api/crop-loans/{id}/repayments
+func (h *CropLoanHandler) ListRepayments(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid loan ID", http.StatusBadRequest)
+		return
+	}
+
+	repayments, err := h.Service.ListRepayments(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if repayments == nil {
+		repayments = []*models.CropLoanRepayment{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(repayments)
+}
+
+// Repay records a repayment (interest first, then principal)
+// POST /api/crop-loans/{id}/repayments
+func (h *CropLoanHandler) Repay(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid loan ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CropLoanRepaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	loan, repayment, err := h.Service.Repay(r.Context(), id, &req, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "UPDATE", &id,
+		fmt.Sprintf("Crop loan #%d repayment ₹%.2f (interest ₹%.2f, principal ₹%.2f) - %s",
+			id, repayment.Amount, repayment.InterestPortion, repayment.PrincipalPortion, loan.Status))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"loan":      loan,
+		"repayment": repayment,
+	})
+}
+
+// AccrueInterest posts interest on all active loans up to today
+// POST /api/crop-loans/accrue
+func (h *CropLoanHandler) AccrueInterest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	count, total, err := h.Service.AccrueAll(r.Context(), timeutil.StartOfDay(timeutil.Now()), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	h.logAction(r, userID, "UPDATE", nil, fmt.Sprintf("Posted crop loan interest on %d loans: ₹%.2f", count, total))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"loan_count":     count,
+		"total_interest": total,
+	})
+}
+
+// logAction records a crop loan action in the admin action log
+func (h *CropLoanHandler) logAction(r *http.Request, userID int, actionType string, loanID *int, description string) {
+	if h.AdminActionRepo == nil {
+		return
+	}
+	h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+		AdminUserID: userID,
+		ActionType:  actionType,
+		TargetType:  "crop_loan",
+		TargetID:    loanID,
+		Description: description,
+	})
+}
@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// GatarMaintenanceHandler handles bag-turning, ventilation and inspection schedules
+type GatarMaintenanceHandler struct {
+	Service         *services.GatarMaintenanceService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewGatarMaintenanceHandler(service *services.GatarMaintenanceService, adminActionRepo *repositories.AdminActionLogRepository) *GatarMaintenanceHandler {
+	return &GatarMaintenanceHandler{
+		Service:         service,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+// ListSchedules returns all maintenance schedules
+// GET /api/gatar-maintenance/schedules
+func (h *GatarMaintenanceHandler) ListSchedules(w http.ResponseWriter, r *http.Request) {
+	schedules, err := h.Service.ListSchedules(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedules)
+}
+
+// CreateSchedule schedules a task for a room, floor or gatar (admin only)
+// POST /api/gatar-maintenance/schedules
+func (h *GatarMaintenanceHandler) CreateSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var schedule models.GatarMaintenanceSchedule
+	if err := json.NewDecoder(r.Body).Decode(&schedule); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.CreateSchedule(r.Context(), &schedule, userID); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.logAction(r, userID, "CREATE", fmt.Sprintf("Scheduled %s every %d days for %s",
+		schedule.Task, schedule.IntervalDays, scheduleScope(&schedule)))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// UpdateSchedule changes a schedule's interval, crew, notes or active flag (admin only)
+// PUT /api/gatar-maintenance/schedules/{id}
+func (h *GatarMaintenanceHandler) UpdateSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	var update models.GatarMaintenanceSchedule
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	schedule, err := h.Service.UpdateSchedule(r.Context(), id, &update)
+	if err != nil {
+		if errors.Is(err, services.ErrMaintenanceScheduleNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.logAction(r, userID, "UPDATE", fmt.Sprintf("Updated %s schedule for %s: every %d days, active=%t",
+		schedule.Task, scheduleScope(schedule), schedule.IntervalDays, schedule.Active))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schedule)
+}
+
+// DeleteSchedule removes a schedule (admin only)
+// DELETE /api/gatar-maintenance/schedules/{id}
+func (h *GatarMaintenanceHandler) DeleteSchedule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid schedule ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.DeleteSchedule(r.Context(), id); err != nil {
+		if errors.Is(err, services.ErrMaintenanceScheduleNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	h.logAction(r, userID, "DELETE", fmt.Sprintf("Removed maintenance schedule %d", id))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Schedule removed"})
+}
+
+// GetStatus returns when each stocked gatar is next due, soonest first
+// GET /api/gatar-maintenance/status?room=1&floor=2&overdue=true
+func (h *GatarMaintenanceHandler) GetStatus(w http.ResponseWriter, r *http.Request) {
+	statuses, err := h.Service.Status(r.Context(), r.URL.Query().Get("room"), r.URL.Query().Get("floor"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if r.URL.Query().Get("overdue") == "true" {
+		overdue := []*models.GatarMaintenanceStatus{}
+		for _, s := range statuses {
+			if s.Overdue {
+				overdue = append(overdue, s)
+			}
+		}
+		statuses = overdue
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(statuses)
+}
+
+// Complete records a task done in a gatar, with the quality observed
+// POST /api/gatar-maintenance/complete
+func (h *GatarMaintenanceHandler) Complete(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CompleteMaintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	logs, err := h.Service.Complete(r.Context(), &req, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrNoGatarStock) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(logs)
+}
+
+// ListLogs returns the maintenance log of a gatar (or all gatars), newest first
+// GET /api/gatar-maintenance/logs?gatar=112&limit=100
+func (h *GatarMaintenanceHandler) ListLogs(w http.ResponseWriter, r *http.Request) {
+	gatarNo := 0
+	if v := r.URL.Query().Get("gatar"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			http.Error(w, "Invalid gatar number", http.StatusBadRequest)
+			return
+		}
+		gatarNo = n
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	logs, err := h.Service.ListLogs(r.Context(), gatarNo, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(logs)
+}
+
+// scheduleScope describes where a schedule applies
+func scheduleScope(s *models.GatarMaintenanceSchedule) string {
+	switch {
+	case s.GatarNo != 0:
+		return fmt.Sprintf("gatar %d (room %s floor %s)", s.GatarNo, s.RoomNo, s.Floor)
+	case s.Floor != "":
+		return fmt.Sprintf("room %s floor %s", s.RoomNo, s.Floor)
+	default:
+		return fmt.Sprintf("room %s", s.RoomNo)
+	}
+}
+
+func (h *GatarMaintenanceHandler) logAction(r *http.Request, userID int, actionType, description string) {
+	if h.AdminActionRepo == nil {
+		return
+	}
+	h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+		AdminUserID: userID,
+		ActionType:  actionType,
+		TargetType:  "gatar_maintenance",
+		Description: description,
+	})
+}
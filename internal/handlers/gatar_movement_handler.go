@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cold-backend/internal/cache"
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// GatarMovementHandler handles stock shifting (palti) between gatars
+type GatarMovementHandler struct {
+	Service *services.GatarMovementService
+}
+
+func NewGatarMovementHandler(service *services.GatarMovementService) *GatarMovementHandler {
+	return &GatarMovementHandler{Service: service}
+}
+
+// Transfer moves bags of a thock from one gatar to another
+// POST /api/gatar-movements
+func (h *GatarMovementHandler) Transfer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.GatarTransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	movement, err := h.Service.Transfer(r.Context(), &req, userID)
+	if err != nil {
+		if errors.Is(err, repositories.ErrGatarStockNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cache.InvalidateRoomCache(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(movement)
+}
+
+// GetGatarLedger returns a gatar's stock and its movements in and out
+// GET /api/gatar-movements/gatar/{gatar_no}?from=2026-01-01&to=2026-03-31&limit=100
+func (h *GatarMovementHandler) GetGatarLedger(w http.ResponseWriter, r *http.Request) {
+	gatarNo, err := strconv.Atoi(mux.Vars(r)["gatar_no"])
+	if err != nil || gatarNo <= 0 {
+		http.Error(w, "Invalid gatar number", http.StatusBadRequest)
+		return
+	}
+	from, ok := parseReportDate(w, r.URL.Query().Get("from"), time.Time{})
+	if !ok {
+		return
+	}
+	to, ok := parseReportDate(w, r.URL.Query().Get("to"), time.Time{})
+	if !ok {
+		return
+	}
+	if !to.IsZero() {
+		to = to.AddDate(0, 0, 1) // Include the whole "to" day
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	ledger, err := h.Service.Ledger(r.Context(), gatarNo, from, to, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ledger)
+}
+
+// ListByThock returns a thock's movements between gatars
+// GET /api/gatar-movements?thock_number=X&limit=100
+func (h *GatarMovementHandler) ListByThock(w http.ResponseWriter, r *http.Request) {
+	thockNumber := r.URL.Query().Get("thock_number")
+	if thockNumber == "" {
+		http.Error(w, "thock_number is required", http.StatusBadRequest)
+		return
+	}
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	movements, err := h.Service.ListByThock(r.Context(), thockNumber, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(movements)
+}
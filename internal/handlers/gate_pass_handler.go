@@ -3,6 +3,7 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -204,7 +205,8 @@ func (h *GatePassHandler) RecordPickup(w http.ResponseWriter, r *http.Request) {
 	}
 
 	err := h.Service.RecordPickup(context.Background(), &req, userID)
-	if err != nil {
+	chargeErr := errors.Is(err, services.ErrPickupNotCharged)
+	if err != nil && !chargeErr {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -227,6 +229,14 @@ func (h *GatePassHandler) RecordPickup(w http.ResponseWriter, r *http.Request) {
 	})
 
 	w.Header().Set("Content-Type", "application/json")
+	if chargeErr {
+		// The pickup went through - report the missing charge without inviting a retry
+		json.NewEncoder(w).Encode(map[string]string{
+			"message":      "Pickup recorded, but rent could not be charged - post it from Rent Charges",
+			"charge_error": err.Error(),
+		})
+		return
+	}
 	json.NewEncoder(w).Encode(map[string]string{"message": "Pickup recorded successfully"})
 }
 
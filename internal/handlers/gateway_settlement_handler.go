@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/payment"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+	"cold-backend/internal/timeutil"
+)
+
+// SettlementHandler handles payment gateway settlement imports and the settlement report
+type SettlementHandler struct {
+	Service         *services.SettlementService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewSettlementHandler(service *services.SettlementService, adminActionRepo *repositories.AdminActionLogRepository) *SettlementHandler {
+	return &SettlementHandler{
+		Service:         service,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+// ImportReport uploads a Razorpay settlement recon report (.csv) and matches its payments
+// POST /api/settlements/import (multipart form, field "file")
+func (h *SettlementHandler) ImportReport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		http.Error(w, "Failed to parse form: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		http.Error(w, "No file uploaded", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		http.Error(w, "Failed to read file", http.StatusBadRequest)
+		return
+	}
+
+	imp, lines, err := h.Service.ImportCSV(r.Context(), header.Filename, data, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.writeImport(w, r, userID, imp, lines)
+}
+
+// FetchReport fetches a day's settlements from the gateway API (defaults to yesterday)
+// POST /api/settlements/fetch
+func (h *SettlementHandler) FetchReport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.FetchSettlementsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Gateway == "" {
+		req.Gateway = payment.GatewayRazorpay
+	}
+	day, ok := parseReportDate(w, req.Date, timeutil.Now().AddDate(0, 0, -1))
+	if !ok {
+		return
+	}
+
+	imp, lines, err := h.Service.Fetch(r.Context(), req.Gateway, day, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.writeImport(w, r, userID, imp, lines)
+}
+
+func (h *SettlementHandler) writeImport(w http.ResponseWriter, r *http.Request, userID int, imp *models.GatewaySettlementImport, lines []*models.GatewaySettlementLine) {
+	if lines == nil {
+		lines = []*models.GatewaySettlementLine{}
+	}
+
+	if h.AdminActionRepo != nil {
+		h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+			AdminUserID: userID,
+			ActionType:  "IMPORT",
+			TargetType:  "gateway_settlement_import",
+			TargetID:    &imp.ID,
+			Description: fmt.Sprintf("Imported %s settlements %s: %d lines, %d matched, %d flagged, %d duplicates",
+				payment.DisplayName(imp.Gateway), imp.FileName, imp.LineCount, imp.MatchedCount, imp.FlaggedCount, imp.DuplicateCount),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"import": imp,
+		"lines":  lines,
+	})
+}
+
+// ListImports returns recent settlement imports
+// GET /api/settlements/imports?limit=30
+func (h *SettlementHandler) ListImports(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	imports, err := h.Service.ListImports(r.Context(), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if imports == nil {
+		imports = []*models.GatewaySettlementImport{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(imports)
+}
+
+// ListLines returns settlement lines; status=short or unmatched lists the flagged ones
+// GET /api/settlements/lines?status=short&import_id=3
+func (h *SettlementHandler) ListLines(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.SettlementStatusMatched, models.SettlementStatusShort, models.SettlementStatusUnmatched, models.SettlementStatusAdjustment:
+	default:
+		http.Error(w, "Invalid status", http.StatusBadRequest)
+		return
+	}
+	importID, _ := strconv.Atoi(r.URL.Query().Get("import_id"))
+
+	lines, err := h.Service.ListLines(r.Context(), status, importID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if lines == nil {
+		lines = []*models.GatewaySettlementLine{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lines)
+}
+
+// GetReport returns fees deducted against fees charged, and short, unmatched and missing
+// settlements for payments in a period
+// GET /api/settlements/report?gateway=razorpay&from=YYYY-MM-DD&to=YYYY-MM-DD (defaults to the last 30 days)
+func (h *SettlementHandler) GetReport(w http.ResponseWriter, r *http.Request) {
+	gateway := r.URL.Query().Get("gateway")
+	if gateway == "" {
+		gateway = payment.GatewayRazorpay
+	}
+	now := timeutil.Now()
+	from, ok := parseReportDate(w, r.URL.Query().Get("from"), now.AddDate(0, 0, -30))
+	if !ok {
+		return
+	}
+	to, ok := parseReportDate(w, r.URL.Query().Get("to"), now)
+	if !ok {
+		return
+	}
+
+	report, err := h.Service.Report(r.Context(), gateway, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,184 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+	"cold-backend/internal/timeutil"
+
+	"github.com/gorilla/mux"
+)
+
+// InterestHandler handles interest accrual endpoints
+type InterestHandler struct {
+	Service         *services.InterestService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewInterestHandler(service *services.InterestService, adminActionRepo *repositories.AdminActionLogRepository) *InterestHandler {
+	return &InterestHandler{
+		Service:         service,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+// GetConfig returns the current interest settings
+// GET /api/interest/config
+func (h *InterestHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(h.Service.GetConfig(r.Context()))
+}
+
+// Preview shows the interest each debtor would be charged for a month
+// GET /api/interest/preview?period=YYYY-MM (defaults to last month)
+func (h *InterestHandler) Preview(w http.ResponseWriter, r *http.Request) {
+	period := r.URL.Query().Get("period")
+	if period == "" {
+		now := timeutil.Now()
+		period = now.AddDate(0, 0, -now.Day()).Format("2006-01")
+	}
+
+	preview, err := h.Service.Preview(r.Context(), period)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
+
+// Post posts the interest for a month to the ledger
+// POST /api/interest/post
+func (h *InterestHandler) Post(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.PostInterestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Period == "" {
+		http.Error(w, "period is required (YYYY-MM)", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.Service.Post(r.Context(), req.Period, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "CREATE", "interest_run", result.RunID,
+		fmt.Sprintf("Posted interest for %s: %d customers, ₹%.2f", req.Period, result.CustomerCount, result.TotalInterest))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// ListRuns returns all posted interest months
+// GET /api/interest/runs
+func (h *InterestHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	runs, err := h.Service.ListRuns(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if runs == nil {
+		runs = []*models.InterestRun{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runs)
+}
+
+// ListWaivers returns interest waivers
+// GET /api/interest/waivers?active=true
+func (h *InterestHandler) ListWaivers(w http.ResponseWriter, r *http.Request) {
+	waivers, err := h.Service.ListWaivers(r.Context(), r.URL.Query().Get("active") == "true")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if waivers == nil {
+		waivers = []*models.InterestWaiver{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(waivers)
+}
+
+// CreateWaiver exempts a customer from interest
+// POST /api/interest/waivers
+func (h *InterestHandler) CreateWaiver(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CreateInterestWaiverRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	waiver, err := h.Service.CreateWaiver(r.Context(), &req, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "CREATE", "interest_waiver", &waiver.ID,
+		fmt.Sprintf("Waived interest for %s: %s", waiver.CustomerPhone, waiver.Reason))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(waiver)
+}
+
+// DeactivateWaiver ends an interest waiver
+// DELETE /api/interest/waivers/{id}
+func (h *InterestHandler) DeactivateWaiver(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid waiver ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.DeactivateWaiver(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	h.logAction(r, userID, "DELETE", "interest_waiver", &id, fmt.Sprintf("Ended interest waiver #%d", id))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Interest waiver ended"})
+}
+
+// logAction records an interest action in the admin action log
+func (h *InterestHandler) logAction(r *http.Request, userID int, actionType, targetType string, targetID *int, description string) {
+	if h.AdminActionRepo == nil {
+		return
+	}
+	h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+		AdminUserID: userID,
+		ActionType:  actionType,
+		TargetType:  targetType,
+		TargetID:    targetID,
+		Description: description,
+	})
+}
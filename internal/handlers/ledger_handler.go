@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"cold-backend/internal/middleware"
@@ -80,7 +81,7 @@ func (h *LedgerHandler) GetCustomerBalance(w http.ResponseWriter, r *http.Reques
 	json.NewEncoder(w).Encode(response)
 }
 
-// GetCustomerSummary returns balance summary for a customer
+// GetCustomerSummary returns balance summary for a customer (voided pairs hidden unless include_voided=true)
 // GET /api/ledger/summary/{phone}
 func (h *LedgerHandler) GetCustomerSummary(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -92,7 +93,7 @@ func (h *LedgerHandler) GetCustomerSummary(w http.ResponseWriter, r *http.Reques
 		return
 	}
 
-	summary, err := h.LedgerService.GetCustomerSummary(ctx, phone)
+	summary, err := h.LedgerService.GetCustomerSummary(ctx, phone, r.URL.Query().Get("include_voided") == "true")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -165,6 +166,12 @@ func (h *LedgerHandler) GetAuditTrail(w http.ResponseWriter, r *http.Request) {
 		paymentType := ""
 		if e.EntryType == models.LedgerEntryTypePayment {
 			paymentType = "Cash"
+			switch e.PaymentMode {
+			case models.PaymentModeCheque:
+				paymentType = "Cheque"
+			case models.PaymentModeNEFT, models.PaymentModeRTGS, models.PaymentModeUPI:
+				paymentType = strings.ToUpper(e.PaymentMode)
+			}
 		} else if e.EntryType == models.LedgerEntryTypeOnlinePayment {
 			paymentType = "Online"
 		}
@@ -183,6 +190,10 @@ func (h *LedgerHandler) GetAuditTrail(w http.ResponseWriter, r *http.Request) {
 			CreatedByName:   e.CreatedByName,
 			PaymentType:     paymentType,
 			Remarks:         e.Notes,
+			ReversalOfID:    e.ReversalOfID,
+			ReversedByID:    e.ReversedByID,
+			Voided:          e.VoidedAt != nil,
+			VoidReason:      e.VoidReason,
 		}
 	}
 
@@ -190,7 +201,7 @@ func (h *LedgerHandler) GetAuditTrail(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(auditEntries)
 }
 
-// GetDebtors returns customers with outstanding balance (admin only)
+// GetDebtors returns customers with outstanding balance (admin only, voided pairs hidden unless include_voided=true)
 // GET /api/ledger/debtors
 func (h *LedgerHandler) GetDebtors(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -208,7 +219,7 @@ func (h *LedgerHandler) GetDebtors(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	debtors, err := h.LedgerService.GetDebtors(ctx)
+	debtors, err := h.LedgerService.GetDebtors(ctx, r.URL.Query().Get("include_voided") == "true")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -230,7 +241,7 @@ func (h *LedgerHandler) GetDebtors(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// GetAllBalances returns balance summaries for all customers (admin only)
+// GetAllBalances returns balance summaries for all customers (admin only, voided pairs hidden unless include_voided=true)
 // GET /api/ledger/balances
 func (h *LedgerHandler) GetAllBalances(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -248,7 +259,7 @@ func (h *LedgerHandler) GetAllBalances(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	summaries, err := h.LedgerService.GetAllCustomerBalances(ctx)
+	summaries, err := h.LedgerService.GetAllCustomerBalances(ctx, r.URL.Query().Get("include_voided") == "true")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -295,6 +306,40 @@ func (h *LedgerHandler) CreateEntry(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(entry)
 }
 
+// VoidEntry voids a ledger entry by posting an equal and opposite reversal (admin only)
+// POST /api/ledger/entry/{id}/void
+func (h *LedgerHandler) VoidEntry(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	userID, ok := middleware.GetUserIDFromContext(ctx)
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid entry ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.VoidLedgerEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	reversal, err := h.LedgerService.VoidEntry(ctx, id, req.Reason, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(reversal)
+}
+
 // GetTotalsByType returns sum of amounts by entry type (admin dashboard)
 // GET /api/ledger/totals
 func (h *LedgerHandler) GetTotalsByType(w http.ResponseWriter, r *http.Request) {
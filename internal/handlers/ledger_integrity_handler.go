@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// LedgerIntegrityHandler runs ledger integrity checks and serves their reports
+type LedgerIntegrityHandler struct {
+	Service         *services.LedgerIntegrityService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewLedgerIntegrityHandler(service *services.LedgerIntegrityService, adminActionRepo *repositories.AdminActionLogRepository) *LedgerIntegrityHandler {
+	return &LedgerIntegrityHandler{
+		Service:         service,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+// RunCheck recomputes running balances, cross-checks payment totals and stores the report
+// POST /api/ledger-integrity/check
+func (h *LedgerIntegrityHandler) RunCheck(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.RunLedgerIntegrityRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	run, err := h.Service.Run(r.Context(), req.Repair, models.LedgerIntegrityTriggerManual, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if run.RepairedEntries > 0 && h.AdminActionRepo != nil {
+		h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+			AdminUserID: userID,
+			ActionType:  "REPAIR",
+			TargetType:  "ledger_integrity_run",
+			TargetID:    &run.ID,
+			Description: fmt.Sprintf("Repaired running balances on %d ledger entries (integrity run #%d)", run.RepairedEntries, run.ID),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
+
+// ListRuns returns past integrity checks, newest first
+// GET /api/ledger-integrity/runs?limit=30
+func (h *LedgerIntegrityHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	runs, err := h.Service.ListRuns(r.Context(), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if runs == nil {
+		runs = []*models.LedgerIntegrityRun{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(runs)
+}
+
+// GetRun returns the discrepancy report of one check
+// GET /api/ledger-integrity/runs/{id}
+func (h *LedgerIntegrityHandler) GetRun(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid run ID", http.StatusBadRequest)
+		return
+	}
+
+	run, err := h.Service.GetRun(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Integrity run not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
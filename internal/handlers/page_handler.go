@@ -156,6 +156,11 @@ func (h *PageHandler) CustomerPortalDashboardPage(w http.ResponseWriter, r *http
 	h.templates.ExecuteTemplate(w, "customer_portal_dashboard.html", nil)
 }
 
+// PaymentLinkPage serves the checkout page payment links open (/pay/{token})
+func (h *PageHandler) PaymentLinkPage(w http.ResponseWriter, r *http.Request) {
+	h.templates.ExecuteTemplate(w, "payment_link.html", nil)
+}
+
 // InfrastructureMonitoringPage serves infrastructure monitoring page
 func (h *PageHandler) InfrastructureMonitoringPage(w http.ResponseWriter, r *http.Request) {
 	h.templates.ExecuteTemplate(w, "infrastructure_monitoring.html", nil)
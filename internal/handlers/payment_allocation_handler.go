@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// PaymentAllocationHandler shows and edits how payments are split across thock charges
+type PaymentAllocationHandler struct {
+	Service         *services.PaymentAllocationService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewPaymentAllocationHandler(service *services.PaymentAllocationService, adminActionRepo *repositories.AdminActionLogRepository) *PaymentAllocationHandler {
+	return &PaymentAllocationHandler{
+		Service:         service,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+// GetCustomerSummary returns a customer's charged, paid and outstanding amounts per thock
+// GET /api/allocations/customer/{phone}
+func (h *PaymentAllocationHandler) GetCustomerSummary(w http.ResponseWriter, r *http.Request) {
+	phone := mux.Vars(r)["phone"]
+	if phone == "" {
+		http.Error(w, "Customer phone required", http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.Service.GetCustomerSummary(r.Context(), phone)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// GetPaymentAllocations returns the charges a payment ledger entry has been applied to
+// GET /api/allocations/payment/{id}
+func (h *PaymentAllocationHandler) GetPaymentAllocations(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ledger entry ID", http.StatusBadRequest)
+		return
+	}
+
+	allocations, err := h.Service.GetPaymentAllocations(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if allocations == nil {
+		allocations = []*models.PaymentAllocation{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(allocations)
+}
+
+// AllocatePayment manually allocates a payment to thocks or charges; the rest goes FIFO
+// POST /api/allocations/payment/{id}
+func (h *PaymentAllocationHandler) AllocatePayment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid ledger entry ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.AllocatePaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	allocations, err := h.Service.AllocatePayment(r.Context(), id, &req, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if allocations == nil {
+		allocations = []*models.PaymentAllocation{}
+	}
+
+	if h.AdminActionRepo != nil {
+		h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+			AdminUserID: userID,
+			ActionType:  "ALLOCATE",
+			TargetType:  "ledger_entry",
+			TargetID:    &id,
+			Description: fmt.Sprintf("Manually allocated payment #%d across %d charges", id, len(allocations)),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(allocations)
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// PaymentLinkHandler sends payment links to debtors (employee server) and takes payments
+// through them (public /pay/{token} routes on the customer portal)
+type PaymentLinkHandler struct {
+	Service         *services.PaymentLinkService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewPaymentLinkHandler(service *services.PaymentLinkService, adminActionRepo *repositories.AdminActionLogRepository) *PaymentLinkHandler {
+	return &PaymentLinkHandler{
+		Service:         service,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+func (h *PaymentLinkHandler) logAction(r *http.Request, userID int, actionType string, targetID int, description string) {
+	if h.AdminActionRepo == nil {
+		return
+	}
+	h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+		AdminUserID: userID,
+		ActionType:  actionType,
+		TargetType:  "payment_link",
+		TargetID:    &targetID,
+		Description: description,
+	})
+}
+
+// linkError writes a payment link error with a matching status code
+func linkError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrPaymentLinkNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, services.ErrPaymentLinkClosed):
+		http.Error(w, err.Error(), http.StatusGone)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// ListLatest returns each customer's most recent payment link (debtors list status)
+// GET /api/payment-links/latest
+func (h *PaymentLinkHandler) ListLatest(w http.ResponseWriter, r *http.Request) {
+	links, err := h.Service.ListLatest(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if links == nil {
+		links = []*models.PaymentLink{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"enabled": h.Service.Enabled(r.Context()),
+		"links":   links,
+	})
+}
+
+// SendLink sends one customer a payment link for their outstanding amount
+// POST /api/payment-links
+func (h *PaymentLinkHandler) SendLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.SendPaymentLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.CustomerPhone == "" || req.Amount <= 0 {
+		http.Error(w, "Customer phone and a positive amount are required", http.StatusBadRequest)
+		return
+	}
+
+	link, err := h.Service.SendLink(r.Context(), req.CustomerPhone, req.Amount, req.Message, userID)
+	if link == nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h.logAction(r, userID, "SEND_PAYMENT_LINK", link.ID,
+		fmt.Sprintf("Sent payment link for ₹%.2f to %s (%s)", link.Amount, link.CustomerName, link.CustomerPhone))
+	if err != nil {
+		// Link was created but the message did not go out; staff can share the URL
+		log.Printf("[PaymentLink] %v", err)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadGateway)
+		json.NewEncoder(w).Encode(map[string]interface{}{"error": err.Error(), "link": link})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(link)
+}
+
+// CancelLink cancels an unpaid payment link
+// POST /api/payment-links/{id}/cancel
+func (h *PaymentLinkHandler) CancelLink(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid link ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.Cancel(r.Context(), id); err != nil {
+		linkError(w, err)
+		return
+	}
+	h.logAction(r, userID, "CANCEL_PAYMENT_LINK", id, fmt.Sprintf("Cancelled payment link #%d", id))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+}
+
+// GetCheckout returns a payment link's amount and gateway details (public, token is the credential)
+// GET /api/pay/{token}
+func (h *PaymentLinkHandler) GetCheckout(w http.ResponseWriter, r *http.Request) {
+	checkout, err := h.Service.Checkout(r.Context(), mux.Vars(r)["token"])
+	if err != nil {
+		linkError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(checkout)
+}
+
+// CreateOrder starts checkout for a payment link
+// POST /api/pay/{token}/create-order
+func (h *PaymentLinkHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
+	response, err := h.Service.CreateOrder(r.Context(), mux.Vars(r)["token"])
+	if err != nil {
+		log.Printf("[PaymentLink] CreateOrder error: %v", err)
+		linkError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// VerifyPayment verifies a payment link checkout
+// POST /api/pay/{token}/verify
+func (h *PaymentLinkHandler) VerifyPayment(w http.ResponseWriter, r *http.Request) {
+	var req models.VerifyPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.RazorpayOrderID == "" {
+		http.Error(w, "Missing order ID", http.StatusBadRequest)
+		return
+	}
+
+	tx, err := h.Service.VerifyPayment(r.Context(), mux.Vars(r)["token"], &req)
+	if err != nil {
+		log.Printf("[PaymentLink] VerifyPayment error: %v", err)
+		linkError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":     true,
+		"message":     "Payment verified successfully",
+		"transaction": tx,
+	})
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"cold-backend/internal/cache"
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// RateContractHandler handles customer negotiated rate contract endpoints
+type RateContractHandler struct {
+	Service         *services.RateContractService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewRateContractHandler(service *services.RateContractService, adminActionRepo *repositories.AdminActionLogRepository) *RateContractHandler {
+	return &RateContractHandler{
+		Service:         service,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+// ListContracts returns rate contracts
+// GET /api/rate-contracts?customer_id=X&status=active
+func (h *RateContractHandler) ListContracts(w http.ResponseWriter, r *http.Request) {
+	customerID, _ := strconv.Atoi(r.URL.Query().Get("customer_id"))
+	status := r.URL.Query().Get("status")
+
+	contracts, err := h.Service.ListContracts(r.Context(), customerID, status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if contracts == nil {
+		contracts = []*models.RateContract{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contracts)
+}
+
+// GetContract returns a single rate contract
+// GET /api/rate-contracts/{id}
+func (h *RateContractHandler) GetContract(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid contract ID", http.StatusBadRequest)
+		return
+	}
+
+	contract, err := h.Service.GetContract(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Rate contract not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contract)
+}
+
+// CreateContract proposes a negotiated rate (pending until approved by an admin)
+// POST /api/rate-contracts
+func (h *RateContractHandler) CreateContract(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CreateRateContractRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	contract, err := h.Service.CreateContract(r.Context(), &req, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "CREATE", &contract.ID, fmt.Sprintf("Proposed rate contract '%s' for %s at ₹%.2f per item", contract.Name, contract.CustomerName, contract.RatePerItem))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(contract)
+}
+
+// ApproveContract activates a pending contract (admin only)
+// PUT /api/rate-contracts/{id}/approve
+func (h *RateContractHandler) ApproveContract(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid contract ID", http.StatusBadRequest)
+		return
+	}
+
+	contract, err := h.Service.ApproveContract(r.Context(), id, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "APPROVE", &id, fmt.Sprintf("Approved rate contract '%s' for %s at ₹%.2f per item", contract.Name, contract.CustomerName, contract.RatePerItem))
+	cache.InvalidateSettingCaches(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contract)
+}
+
+// RejectContract rejects a pending contract (admin only)
+// PUT /api/rate-contracts/{id}/reject
+func (h *RateContractHandler) RejectContract(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, "REJECT", h.Service.RejectContract)
+}
+
+// RevokeContract stops an active contract from applying (admin only)
+// PUT /api/rate-contracts/{id}/revoke
+func (h *RateContractHandler) RevokeContract(w http.ResponseWriter, r *http.Request) {
+	h.changeStatus(w, r, "REVOKE", h.Service.RevokeContract)
+}
+
+// changeStatus handles reject/revoke, which both take a reason
+func (h *RateContractHandler) changeStatus(w http.ResponseWriter, r *http.Request, actionType string,
+	apply func(ctx context.Context, id int, reason string) (*models.RateContract, error)) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid contract ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.RateContractStatusRequest
+	json.NewDecoder(r.Body).Decode(&req)
+
+	contract, err := apply(r.Context(), id, req.Reason)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, actionType, &id, fmt.Sprintf("Rate contract '%s' for %s %s: %s", contract.Name, contract.CustomerName, contract.Status, req.Reason))
+	cache.InvalidateSettingCaches(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(contract)
+}
+
+// logAction records a contract change in the admin action log
+func (h *RateContractHandler) logAction(r *http.Request, userID int, actionType string, contractID *int, description string) {
+	if h.AdminActionRepo == nil {
+		return
+	}
+	h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+		AdminUserID: userID,
+		ActionType:  actionType,
+		TargetType:  "rate_contract",
+		TargetID:    contractID,
+		Description: description,
+	})
+}
@@ -11,13 +11,17 @@ import (
 
 	"cold-backend/internal/middleware"
 	"cold-backend/internal/models"
+	"cold-backend/internal/payment"
 	"cold-backend/internal/repositories"
 	"cold-backend/internal/services"
+
+	"github.com/gorilla/mux"
 )
 
 type RazorpayHandler struct {
-	Service      *services.RazorpayService
-	CustomerRepo *repositories.CustomerRepository
+	Service         *services.RazorpayService
+	CustomerRepo    *repositories.CustomerRepository
+	AdminActionRepo *repositories.AdminActionLogRepository
 }
 
 func NewRazorpayHandler(service *services.RazorpayService, customerRepo *repositories.CustomerRepository) *RazorpayHandler {
@@ -27,6 +31,24 @@ func NewRazorpayHandler(service *services.RazorpayService, customerRepo *reposit
 	}
 }
 
+// SetAdminActionRepo logs refund requests and approvals to the admin action log
+func (h *RazorpayHandler) SetAdminActionRepo(adminActionRepo *repositories.AdminActionLogRepository) {
+	h.AdminActionRepo = adminActionRepo
+}
+
+func (h *RazorpayHandler) logRefundAction(r *http.Request, userID int, actionType string, refund *models.OnlineRefund, description string) {
+	if h.AdminActionRepo == nil {
+		return
+	}
+	h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+		AdminUserID: userID,
+		ActionType:  actionType,
+		TargetType:  "online_refund",
+		TargetID:    &refund.ID,
+		Description: description,
+	})
+}
+
 // CheckPaymentStatus returns whether online payments are enabled and fee info
 // GET /api/payment/status
 func (h *RazorpayHandler) CheckPaymentStatus(w http.ResponseWriter, r *http.Request) {
@@ -35,7 +57,7 @@ func (h *RazorpayHandler) CheckPaymentStatus(w http.ResponseWriter, r *http.Requ
 	json.NewEncoder(w).Encode(status)
 }
 
-// CreateOrder creates a Razorpay order for payment
+// CreateOrder creates an order at the active payment gateway
 // POST /api/payment/create-order
 func (h *RazorpayHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	// Get customer ID from context (set by auth middleware)
@@ -81,7 +103,7 @@ func (h *RazorpayHandler) CreateOrder(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(response)
 }
 
-// VerifyPayment verifies the payment after Razorpay callback
+// VerifyPayment verifies the payment after the checkout callback
 // POST /api/payment/verify
 func (h *RazorpayHandler) VerifyPayment(w http.ResponseWriter, r *http.Request) {
 	// Get customer ID from context
@@ -99,8 +121,8 @@ func (h *RazorpayHandler) VerifyPayment(w http.ResponseWriter, r *http.Request)
 	}
 
 	// Validate
-	if req.RazorpayOrderID == "" || req.RazorpayPaymentID == "" || req.RazorpaySignature == "" {
-		http.Error(w, "Missing required fields", http.StatusBadRequest)
+	if req.RazorpayOrderID == "" {
+		http.Error(w, "Missing order ID", http.StatusBadRequest)
 		return
 	}
 
@@ -154,41 +176,36 @@ func (h *RazorpayHandler) GetMyTransactions(w http.ResponseWriter, r *http.Reque
 	json.NewEncoder(w).Encode(transactions)
 }
 
-// HandleWebhook processes Razorpay webhook events
+// HandleWebhook processes payment gateway webhook events. The legacy route without a
+// gateway is Razorpay's.
 // POST /api/payment/webhook
+// POST /api/payment/webhook/{gateway}
 func (h *RazorpayHandler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+	gateway := mux.Vars(r)["gateway"]
+	if gateway == "" {
+		gateway = payment.GatewayRazorpay
+	}
+
 	// Read body
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
-		log.Printf("[Razorpay] Failed to read webhook body: %v", err)
+		log.Printf("[Payment] Failed to read %s webhook body: %v", gateway, err)
 		http.Error(w, "Failed to read body", http.StatusBadRequest)
 		return
 	}
 
 	// Verify signature
-	signature := r.Header.Get("X-Razorpay-Signature")
-	if !h.Service.VerifyWebhookSignature(r.Context(), body, signature) {
-		log.Printf("[Razorpay] Invalid webhook signature")
+	if !h.Service.VerifyWebhook(r.Context(), gateway, body, r.Header) {
+		log.Printf("[Payment] Invalid %s webhook signature", gateway)
 		http.Error(w, "Invalid signature", http.StatusUnauthorized)
 		return
 	}
 
-	// Parse payload
-	var payload map[string]interface{}
-	if err := json.Unmarshal(body, &payload); err != nil {
-		log.Printf("[Razorpay] Failed to parse webhook: %v", err)
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
-		return
-	}
-
-	event, _ := payload["event"].(string)
-	payloadData, _ := payload["payload"].(map[string]interface{})
-
-	log.Printf("[Razorpay] Received webhook: %s", event)
+	log.Printf("[Payment] Received %s webhook", gateway)
 
 	// Process webhook
-	if err := h.Service.ProcessWebhook(r.Context(), event, payloadData); err != nil {
-		log.Printf("[Razorpay] Webhook processing error: %v", err)
+	if err := h.Service.ProcessWebhook(r.Context(), gateway, body); err != nil {
+		log.Printf("[Payment] %s webhook processing error: %v", gateway, err)
 		// Return 200 anyway to prevent retries for known errors
 	}
 
@@ -303,3 +320,130 @@ func (h *RazorpayHandler) ReconcilePayments(w http.ResponseWriter, r *http.Reque
 		"message":    fmt.Sprintf("Reconciled %d transactions", count),
 	})
 }
+
+// ListRefunds returns online refunds, filtered by status and transaction (admin/accountant)
+// GET /api/admin/online-transactions/refunds?status=pending_approval&transaction_id=12
+func (h *RazorpayHandler) ListRefunds(w http.ResponseWriter, r *http.Request) {
+	transactionID := 0
+	if t := r.URL.Query().Get("transaction_id"); t != "" {
+		n, err := strconv.Atoi(t)
+		if err != nil {
+			http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+			return
+		}
+		transactionID = n
+	}
+
+	refunds, err := h.Service.ListRefunds(r.Context(), r.URL.Query().Get("status"), transactionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if refunds == nil {
+		refunds = []*models.OnlineRefund{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(refunds)
+}
+
+// RequestRefund refunds part or all of an online payment through its gateway (admin/accountant)
+// POST /api/admin/online-transactions/{id}/refund
+func (h *RazorpayHandler) RequestRefund(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid transaction ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateOnlineRefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	refund, err := h.Service.RequestRefund(r.Context(), id, &req, userID)
+	if err != nil {
+		log.Printf("[Razorpay] Refund error for transaction %d: %v", id, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logRefundAction(r, userID, "REFUND_REQUESTED", refund,
+		fmt.Sprintf("Requested online refund of ₹%.2f for %s (%s): %s [%s]", refund.Amount, refund.CustomerName, refund.CustomerPhone, refund.Reason, refund.Status))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(refund)
+}
+
+// ApproveRefund sends a refund above the approval threshold to the gateway (admin only)
+// POST /api/admin/online-transactions/refunds/{id}/approve
+func (h *RazorpayHandler) ApproveRefund(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid refund ID", http.StatusBadRequest)
+		return
+	}
+
+	refund, err := h.Service.ApproveRefund(r.Context(), id, userID)
+	if err != nil {
+		log.Printf("[Razorpay] Refund approval error for refund %d: %v", id, err)
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logRefundAction(r, userID, "REFUND_APPROVED", refund,
+		fmt.Sprintf("Approved online refund of ₹%.2f for %s (%s)", refund.Amount, refund.CustomerName, refund.CustomerPhone))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(refund)
+}
+
+// RejectRefund turns down a refund above the approval threshold (admin only)
+// POST /api/admin/online-transactions/refunds/{id}/reject
+func (h *RazorpayHandler) RejectRefund(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid refund ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	refund, err := h.Service.RejectRefund(r.Context(), id, userID, req.Reason)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logRefundAction(r, userID, "REFUND_REJECTED", refund,
+		fmt.Sprintf("Rejected online refund of ₹%.2f for %s (%s): %s", refund.Amount, refund.CustomerName, refund.CustomerPhone, req.Reason))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(refund)
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// RentChargeHandler handles duration-based rent charge endpoints
+type RentChargeHandler struct {
+	Service         *services.RentChargeService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewRentChargeHandler(service *services.RentChargeService, adminActionRepo *repositories.AdminActionLogRepository) *RentChargeHandler {
+	return &RentChargeHandler{
+		Service:         service,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+// GetPickupCharge shows the rent calculated for a pickup and whether it is already posted
+// GET /api/rent-charges/pickups/{id}
+func (h *RentChargeHandler) GetPickupCharge(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid pickup ID", http.StatusBadRequest)
+		return
+	}
+
+	charge, err := h.Service.PreviewPickupCharge(r.Context(), id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(charge)
+}
+
+// PostPickupCharge posts the rent for a pickup to the ledger (no-op if already posted)
+// POST /api/rent-charges/pickups/{id}
+func (h *RentChargeHandler) PostPickupCharge(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid pickup ID", http.StatusBadRequest)
+		return
+	}
+
+	charge, err := h.Service.ChargePickup(r.Context(), id, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !charge.AlreadyCharged && charge.LedgerEntryID != nil && h.AdminActionRepo != nil {
+		h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+			AdminUserID: userID,
+			ActionType:  "CREATE",
+			TargetType:  "rent_charge",
+			TargetID:    charge.LedgerEntryID,
+			Description: fmt.Sprintf("Posted rent ₹%.2f for pickup #%d (thock %s, %d items)", charge.Amount, id, charge.ThockNumber, charge.Quantity),
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(charge)
+}
+
+// EstimateCharge calculates the rent for taking items of a thock out on a date
+// GET /api/rent-charges/estimate?thock_number=X&quantity=N&date=YYYY-MM-DD
+func (h *RentChargeHandler) EstimateCharge(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	thockNumber := q.Get("thock_number")
+	if thockNumber == "" {
+		http.Error(w, "thock_number is required", http.StatusBadRequest)
+		return
+	}
+	quantity, err := strconv.Atoi(q.Get("quantity"))
+	if err != nil || quantity <= 0 {
+		http.Error(w, "quantity must be a positive number", http.StatusBadRequest)
+		return
+	}
+
+	var removedAt time.Time
+	if dateStr := q.Get("date"); dateStr != "" {
+		removedAt, err = time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+			return
+		}
+	}
+
+	charge, err := h.Service.EstimateCharge(r.Context(), thockNumber, quantity, removedAt)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(charge)
+}
@@ -3,9 +3,12 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"cold-backend/internal/cache"
 	"cold-backend/internal/middleware"
@@ -21,6 +24,7 @@ type RentPaymentHandler struct {
 	LedgerService       *services.LedgerService
 	NotificationService *services.NotificationService
 	CustomerService     *services.CustomerService
+	AllocationService   *services.PaymentAllocationService
 	AdminActionRepo     *repositories.AdminActionLogRepository
 }
 
@@ -37,6 +41,11 @@ func (h *RentPaymentHandler) SetNotificationService(notifService *services.Notif
 	h.NotificationService = notifService
 }
 
+// SetAllocationService enables manual thock allocation when recording a payment
+func (h *RentPaymentHandler) SetAllocationService(allocationService *services.PaymentAllocationService) {
+	h.AllocationService = allocationService
+}
+
 // SetCustomerService sets the customer service for S/O lookup
 func (h *RentPaymentHandler) SetCustomerService(customerService *services.CustomerService) {
 	h.CustomerService = customerService
@@ -68,6 +77,14 @@ func (h *RentPaymentHandler) CreatePayment(w http.ResponseWriter, r *http.Reques
 		http.Error(w, "Amount paid cannot exceed total rent", http.StatusBadRequest)
 		return
 	}
+	var allocated float64
+	for _, a := range req.Allocations {
+		allocated += a.Amount
+	}
+	if allocated > req.AmountPaid+0.005 {
+		http.Error(w, "Thock allocations cannot exceed amount paid", http.StatusBadRequest)
+		return
+	}
 
 	payment := &models.RentPayment{
 		EntryID:           req.EntryID,
@@ -78,15 +95,27 @@ func (h *RentPaymentHandler) CreatePayment(w http.ResponseWriter, r *http.Reques
 		Balance:           req.Balance, // Use client-provided cumulative balance
 		ProcessedByUserID: userID,
 		Notes:             req.Notes,
+		FamilyMemberID:    req.FamilyMemberID,
+		FamilyMemberName:  req.FamilyMemberName,
 	}
+	if err := h.Service.ApplyPaymentMode(payment, &req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	// Cheques are only credited to the ledger once they clear
+	received := payment.PaymentMode != models.PaymentModeCheque
 
 	if err := h.Service.CreatePayment(context.Background(), payment); err != nil {
+		if errors.Is(err, repositories.ErrNoOpenCashSession) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	// Create ledger entry for payment
-	if h.LedgerService != nil && payment.AmountPaid > 0 {
+	if h.LedgerService != nil && payment.AmountPaid > 0 && received {
 		// Lookup customer S/O for ledger entry
 		customerSO := ""
 		if h.CustomerService != nil {
@@ -108,14 +137,27 @@ func (h *RentPaymentHandler) CreatePayment(w http.ResponseWriter, r *http.Reques
 			FamilyMemberName: req.FamilyMemberName,
 			CreatedByUserID:  userID,
 			Notes:            req.Notes,
+			PaymentMode:      payment.PaymentMode,
 		}
 		// Create ledger entry (don't fail the payment if this fails)
-		_, _ = h.LedgerService.CreateEntry(r.Context(), ledgerEntry)
+		entry, err := h.LedgerService.CreateEntry(r.Context(), ledgerEntry)
+		if err == nil && h.AllocationService != nil && len(req.Allocations) > 0 {
+			if _, err := h.AllocationService.AllocatePayment(r.Context(), entry.ID, &models.AllocatePaymentRequest{Allocations: req.Allocations}, userID); err != nil {
+				log.Printf("[RentPayment] Manual allocation of payment %d failed, allocated FIFO instead: %v", payment.ID, err)
+			}
+		}
 	}
 
 	// Log payment creation
 	description := fmt.Sprintf("Payment received: ₹%.2f from %s (%s) - Balance: ₹%.2f",
 		payment.AmountPaid, req.CustomerName, req.CustomerPhone, payment.Balance)
+	switch payment.PaymentMode {
+	case models.PaymentModeCheque:
+		description += fmt.Sprintf(" | Cheque %s (%s), pending clearance", payment.ChequeNumber, payment.BankName)
+	case models.PaymentModeCash:
+	default:
+		description += fmt.Sprintf(" | %s UTR %s", strings.ToUpper(payment.PaymentMode), payment.UTRNumber)
+	}
 	if req.Notes != "" {
 		description += " | Notes: " + req.Notes
 	}
@@ -131,7 +173,7 @@ func (h *RentPaymentHandler) CreatePayment(w http.ResponseWriter, r *http.Reques
 	cache.InvalidatePaymentCaches(r.Context())
 
 	// Send payment SMS notification (non-blocking)
-	if h.NotificationService != nil && payment.AmountPaid > 0 && req.CustomerPhone != "" {
+	if h.NotificationService != nil && payment.AmountPaid > 0 && received && req.CustomerPhone != "" {
 		go func() {
 			customer := &models.Customer{
 				Name:  req.CustomerName,
@@ -238,3 +280,115 @@ func (h *RentPaymentHandler) GetPaymentByReceiptNumber(w http.ResponseWriter, r
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(payment)
 }
+
+// ListCheques returns cheque payments by clearance status
+// GET /api/rent-payments/cheques?status=pending
+func (h *RentPaymentHandler) ListCheques(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	switch status {
+	case "", models.ChequeStatusPending, models.ChequeStatusCleared, models.ChequeStatusBounced:
+	default:
+		http.Error(w, "Invalid status. Use pending, cleared or bounced", http.StatusBadRequest)
+		return
+	}
+
+	cheques, err := h.Service.ListCheques(r.Context(), status)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if cheques == nil {
+		cheques = []*models.RentPayment{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(cheques)
+}
+
+// ClearCheque marks a pending cheque as cleared and credits the customer's ledger
+// POST /api/rent-payments/{id}/clear
+func (h *RentPaymentHandler) ClearCheque(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid payment ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ClearChequeRequest
+	if r.ContentLength > 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	payment, err := h.Service.ClearCheque(r.Context(), id, req.ClearingDate, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+		AdminUserID: userID,
+		ActionType:  "CHEQUE_CLEARED",
+		TargetType:  "rent_payment",
+		TargetID:    &payment.ID,
+		Description: fmt.Sprintf("Cheque %s (%s) for ₹%.2f from %s cleared",
+			payment.ChequeNumber, payment.BankName, payment.AmountPaid, payment.CustomerName),
+	})
+	cache.InvalidatePaymentCaches(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
+
+// BounceCheque marks a cheque as bounced, reverses its credit if it had cleared and charges the penalty
+// POST /api/rent-payments/{id}/bounce
+func (h *RentPaymentHandler) BounceCheque(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "User ID not found in context", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid payment ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.BounceChequeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	payment, err := h.Service.BounceCheque(r.Context(), id, &req, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	description := fmt.Sprintf("Cheque %s (%s) for ₹%.2f from %s bounced: %s",
+		payment.ChequeNumber, payment.BankName, payment.AmountPaid, payment.CustomerName, payment.BounceReason)
+	if payment.BouncePenalty != nil && *payment.BouncePenalty > 0 {
+		description += fmt.Sprintf(" | Penalty ₹%.2f", *payment.BouncePenalty)
+	}
+	h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+		AdminUserID: userID,
+		ActionType:  "CHEQUE_BOUNCED",
+		TargetType:  "rent_payment",
+		TargetID:    &payment.ID,
+		Description: description,
+	})
+	cache.InvalidatePaymentCaches(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(payment)
+}
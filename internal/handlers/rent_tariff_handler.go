@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cold-backend/internal/cache"
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// RentTariffHandler handles rent rate card endpoints
+type RentTariffHandler struct {
+	Service         *services.RentTariffService
+	EntryRepo       *repositories.EntryRepository
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewRentTariffHandler(service *services.RentTariffService, entryRepo *repositories.EntryRepository, adminActionRepo *repositories.AdminActionLogRepository) *RentTariffHandler {
+	return &RentTariffHandler{
+		Service:         service,
+		EntryRepo:       entryRepo,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+// ListTariffs returns all rate cards with the current default rate and season
+// GET /api/rent-tariffs?active=true
+func (h *RentTariffHandler) ListTariffs(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	activeOnly := r.URL.Query().Get("active") == "true"
+
+	tariffs, err := h.Service.ListTariffs(ctx, activeOnly)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if tariffs == nil {
+		tariffs = []*models.RentTariff{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tariffs":      tariffs,
+		"default_rate": h.Service.GetDefaultRate(ctx),
+		"season":       h.Service.GetCurrentSeason(ctx),
+	})
+}
+
+// GetTariff returns a single rate card
+// GET /api/rent-tariffs/{id}
+func (h *RentTariffHandler) GetTariff(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid tariff ID", http.StatusBadRequest)
+		return
+	}
+
+	tariff, err := h.Service.GetTariff(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Rent tariff not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tariff)
+}
+
+// CreateTariff creates a new rate card (admin only)
+// POST /api/rent-tariffs
+func (h *RentTariffHandler) CreateTariff(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CreateRentTariffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tariff, err := h.Service.CreateTariff(r.Context(), &req, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "CREATE", &tariff.ID, fmt.Sprintf("Created rent tariff '%s' at ₹%.2f per item", tariff.Name, tariff.RatePerItem))
+	cache.InvalidateSettingCaches(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(tariff)
+}
+
+// UpdateTariff updates an existing rate card (admin only)
+// PUT /api/rent-tariffs/{id}
+func (h *RentTariffHandler) UpdateTariff(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid tariff ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.CreateRentTariffRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	tariff, err := h.Service.UpdateTariff(r.Context(), id, &req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "UPDATE", &tariff.ID, fmt.Sprintf("Updated rent tariff '%s' to ₹%.2f per item", tariff.Name, tariff.RatePerItem))
+	cache.InvalidateSettingCaches(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tariff)
+}
+
+// DeactivateTariff disables a rate card (admin only)
+// DELETE /api/rent-tariffs/{id}
+func (h *RentTariffHandler) DeactivateTariff(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid tariff ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.DeactivateTariff(r.Context(), id); err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	h.logAction(r, userID, "DELETE", &id, fmt.Sprintf("Deactivated rent tariff #%d", id))
+	cache.InvalidateSettingCaches(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Rent tariff deactivated"})
+}
+
+// QuoteRate resolves the rent rate for a thock or for explicit criteria
+// GET /api/rent-tariffs/quote?thock_number=X
+// GET /api/rent-tariffs/quote?thock_category=seed&variety=Chipsona 1&bag_size=50kg&date=YYYY-MM-DD&season=2025-26
+func (h *RentTariffHandler) QuoteRate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+
+	var quote models.RentTariffQuote
+	if thockNumber := q.Get("thock_number"); thockNumber != "" {
+		entry, err := h.EntryRepo.GetByThockNumber(ctx, thockNumber)
+		if err != nil {
+			http.Error(w, "Thock not found", http.StatusNotFound)
+			return
+		}
+		quote = h.Service.QuoteEntry(ctx, entry)
+	} else {
+		query := models.RentTariffQuery{
+			ThockCategory: q.Get("thock_category"),
+			Variety:       q.Get("variety"),
+			BagSize:       q.Get("bag_size"),
+			Season:        q.Get("season"),
+		}
+		if dateStr := q.Get("date"); dateStr != "" {
+			date, err := time.Parse("2006-01-02", dateStr)
+			if err != nil {
+				http.Error(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+				return
+			}
+			query.Date = date
+		}
+		quote = h.Service.Quote(ctx, query)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(quote)
+}
+
+// logAction records a rate card change in the admin action log
+func (h *RentTariffHandler) logAction(r *http.Request, userID int, actionType string, tariffID *int, description string) {
+	if h.AdminActionRepo == nil {
+		return
+	}
+	h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+		AdminUserID: userID,
+		ActionType:  actionType,
+		TargetType:  "rent_tariff",
+		TargetID:    tariffID,
+		Description: description,
+	})
+}
@@ -102,8 +102,7 @@ func (h *ReportHandler) GetSingleCustomerPDF(w http.ResponseWriter, r *http.Requ
 	ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
 	defer cancel()
 
-	rentRate, _ := h.Service.GetRentRate(ctx)
-	data, err := h.Service.GetCustomerReportData(ctx, phone, rentRate)
+	data, err := h.Service.GetCustomerReportData(ctx, phone, h.Service.GetRateCard(ctx))
 	if err != nil {
 		http.Error(w, fmt.Sprintf("Customer not found: %v", err), http.StatusNotFound)
 		return
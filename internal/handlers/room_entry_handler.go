@@ -16,8 +16,9 @@ import (
 )
 
 type RoomEntryHandler struct {
-	Service     *services.RoomEntryService
-	EditLogRepo *repositories.RoomEntryEditLogRepository
+	Service           *services.RoomEntryService
+	EditLogRepo       *repositories.RoomEntryEditLogRepository
+	AllocationService *services.SlotAllocationService
 }
 
 func NewRoomEntryHandler(s *services.RoomEntryService, editLogRepo *repositories.RoomEntryEditLogRepository) *RoomEntryHandler {
@@ -27,6 +28,37 @@ func NewRoomEntryHandler(s *services.RoomEntryService, editLogRepo *repositories
 	}
 }
 
+// SetAllocationService enables gatar suggestions for incoming thocks
+func (h *RoomEntryHandler) SetAllocationService(allocationService *services.SlotAllocationService) {
+	h.AllocationService = allocationService
+}
+
+// SuggestGatars proposes gatars for an entry's bags (default: the bags not yet stored)
+// GET /api/room-entries/suggest?entry_id=123&quantity=120&limit=3
+func (h *RoomEntryHandler) SuggestGatars(w http.ResponseWriter, r *http.Request) {
+	if h.AllocationService == nil {
+		http.Error(w, "Gatar suggestions are not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	entryID, err := strconv.Atoi(r.URL.Query().Get("entry_id"))
+	if err != nil {
+		http.Error(w, "Invalid entry ID", http.StatusBadRequest)
+		return
+	}
+	quantity, _ := strconv.Atoi(r.URL.Query().Get("quantity"))
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	suggestions, err := h.AllocationService.Suggest(r.Context(), entryID, quantity, limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(suggestions)
+}
+
 func (h *RoomEntryHandler) CreateRoomEntry(w http.ResponseWriter, r *http.Request) {
 	var req models.CreateRoomEntryRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
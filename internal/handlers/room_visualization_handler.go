@@ -1,30 +1,42 @@
 package handlers
 
 import (
+	"context"
 	"encoding/json"
+	"math"
 	"net/http"
 	"strconv"
 	"strings"
 
 	"cold-backend/internal/cache"
+	"cold-backend/internal/models"
 	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
 // RoomVisualizationHandler handles room visualization endpoints
 type RoomVisualizationHandler struct {
-	DB              *pgxpool.Pool
-	GatarRepository *repositories.RoomEntryGatarRepository
+	DB                 *pgxpool.Pool
+	GatarRepository    *repositories.RoomEntryGatarRepository
+	LayoutService      *services.WarehouseLayoutService
+	MaintenanceService *services.GatarMaintenanceService
 }
 
 // NewRoomVisualizationHandler creates a new room visualization handler
-func NewRoomVisualizationHandler(db *pgxpool.Pool) *RoomVisualizationHandler {
+func NewRoomVisualizationHandler(db *pgxpool.Pool, layoutService *services.WarehouseLayoutService) *RoomVisualizationHandler {
 	return &RoomVisualizationHandler{
 		DB:              db,
 		GatarRepository: repositories.NewRoomEntryGatarRepository(db),
+		LayoutService:   layoutService,
 	}
 }
 
+// SetMaintenanceService enables flagging gatars overdue for turning, ventilation or inspection
+func (h *RoomVisualizationHandler) SetMaintenanceService(maintenanceService *services.GatarMaintenanceService) {
+	h.MaintenanceService = maintenanceService
+}
+
 // FloorStats contains statistics for a single floor
 type FloorStats struct {
 	Floor          string `json:"floor"`
@@ -32,20 +44,24 @@ type FloorStats struct {
 	TotalGatars    int    `json:"total_gatars"`
 	TotalQuantity  int    `json:"total_qty"`
 	EntryCount     int    `json:"entry_count"`
+	OverdueGatars  int    `json:"overdue_gatars"` // Stocked gatars overdue for maintenance
 }
 
 // RoomStats contains statistics for a single room
 type RoomStats struct {
-	RoomNo string       `json:"room_no"`
-	Floors []FloorStats `json:"floors"`
+	RoomNo   string       `json:"room_no"`
+	Name     string       `json:"name"`
+	RoomType string       `json:"room_type"`
+	Floors   []FloorStats `json:"floors"`
 }
 
 // VisualizationSummary contains overall summary
 type VisualizationSummary struct {
-	TotalQuantity    int `json:"total_qty"`
-	OccupiedGatars   int `json:"occupied_gatars"`
-	TotalGatars      int `json:"total_gatars"`
-	TotalEntryCount  int `json:"total_entry_count"`
+	TotalQuantity   int `json:"total_qty"`
+	OccupiedGatars  int `json:"occupied_gatars"`
+	TotalGatars     int `json:"total_gatars"`
+	TotalEntryCount int `json:"total_entry_count"`
+	OverdueGatars   int `json:"overdue_gatars"`
 }
 
 // RoomVisualizationResponse is the response for GetRoomStats
@@ -64,10 +80,11 @@ type GatarItem struct {
 
 // GatarInfo represents a single gatar's data
 type GatarInfo struct {
-	Gatar    string      `json:"gatar"`
-	Occupied bool        `json:"occupied"`
-	Items    []GatarItem `json:"items"`
-	TotalQty int         `json:"total_qty"`
+	Gatar              string      `json:"gatar"`
+	Occupied           bool        `json:"occupied"`
+	Items              []GatarItem `json:"items"`
+	TotalQty           int         `json:"total_qty"`
+	MaintenanceOverdue []string    `json:"maintenance_overdue,omitempty"` // Overdue tasks (turning, ventilation, inspection)
 }
 
 // GatarOccupancyResponse is the response for GetGatarOccupancy
@@ -77,45 +94,6 @@ type GatarOccupancyResponse struct {
 	Gatars  []GatarInfo `json:"gatars"`
 }
 
-// Gatar ranges for each room/floor (from room-config-1.html)
-var gatarRanges = map[string]map[string]struct{ Start, End, Total int }{
-	"1": {
-		"0": {1, 140, 140},
-		"1": {141, 280, 140},
-		"2": {281, 420, 140},
-		"3": {421, 560, 140},
-		"4": {561, 680, 120},
-	},
-	"2": {
-		"0": {681, 820, 140},
-		"1": {821, 960, 140},
-		"2": {961, 1100, 140},
-		"3": {1101, 1240, 140},
-		"4": {1241, 1360, 120},
-	},
-	"3": {
-		"0": {1361, 1500, 140},
-		"1": {1501, 1640, 140},
-		"2": {1641, 1780, 140},
-		"3": {1781, 1920, 140},
-		"4": {1921, 2040, 120},
-	},
-	"4": {
-		"0": {2041, 2120, 140}, // Room 4 Floor 0: 80 + 60 split range = 140 total
-		"1": {2121, 2260, 140},
-		"2": {2261, 2400, 140},
-		"3": {2401, 2540, 140},
-		"4": {2601, 2720, 120},
-	},
-	"G": {
-		"0": {2727, 2756, 30},
-		"1": {2757, 2784, 28},
-		"2": {2785, 2812, 28},
-		"3": {2813, 2840, 28},
-		"4": {2841, 2868, 28},
-	},
-}
-
 // GetRoomStats returns aggregated statistics for all rooms and floors
 func (h *RoomVisualizationHandler) GetRoomStats(w http.ResponseWriter, r *http.Request) {
 	// Prevent browser caching
@@ -129,7 +107,13 @@ func (h *RoomVisualizationHandler) GetRoomStats(w http.ResponseWriter, r *http.R
 	if cached, found := cache.GetCachedRoomStats(ctx); found {
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Set("X-Cache", "HIT")
-		w.Write(cached)
+		w.Write(h.withOverdueFloors(ctx, cached))
+		return
+	}
+
+	layout, err := h.LayoutService.Layout(ctx)
+	if err != nil {
+		http.Error(w, "Failed to load warehouse layout: "+err.Error(), http.StatusInternalServerError)
 		return
 	}
 
@@ -169,18 +153,9 @@ func (h *RoomVisualizationHandler) GetRoomStats(w http.ResponseWriter, r *http.R
 			statsMap[roomNo] = make(map[string]FloorStats)
 		}
 
-		// Get total gatars for this room/floor
-		totalGatars := 0
-		if ranges, ok := gatarRanges[roomNo]; ok {
-			if floorRange, ok := ranges[floor]; ok {
-				totalGatars = floorRange.Total
-			}
-		}
-
 		statsMap[roomNo][floor] = FloorStats{
 			Floor:          floor,
 			OccupiedGatars: occupiedGatars,
-			TotalGatars:    totalGatars,
 			TotalQuantity:  totalQty,
 			EntryCount:     entryCount,
 		}
@@ -190,23 +165,15 @@ func (h *RoomVisualizationHandler) GetRoomStats(w http.ResponseWriter, r *http.R
 	var rooms []RoomStats
 	var totalQty, totalOccupied, totalGatars, totalEntries int
 
-	// Process rooms in order (including Room 4 and Gallery)
-	for _, roomNo := range []string{"1", "2", "3", "4", "G"} {
+	// Process rooms and floors in layout order
+	for _, room := range layout.Rooms {
 		var floors []FloorStats
 
-		// Process floors in order (0-4)
-		for floor := 0; floor <= 4; floor++ {
-			floorStr := strconv.Itoa(floor)
+		for _, f := range room.Floors {
+			floorStr := f.Floor
+			floorTotalGatars := f.TotalGatars()
 
-			// Get total gatars for this floor
-			floorTotalGatars := 0
-			if ranges, ok := gatarRanges[roomNo]; ok {
-				if floorRange, ok := ranges[floorStr]; ok {
-					floorTotalGatars = floorRange.Total
-				}
-			}
-
-			if stats, ok := statsMap[roomNo][floorStr]; ok {
+			if stats, ok := statsMap[room.RoomNo][floorStr]; ok {
 				stats.TotalGatars = floorTotalGatars
 				floors = append(floors, stats)
 				totalQty += stats.TotalQuantity
@@ -226,8 +193,10 @@ func (h *RoomVisualizationHandler) GetRoomStats(w http.ResponseWriter, r *http.R
 		}
 
 		rooms = append(rooms, RoomStats{
-			RoomNo: roomNo,
-			Floors: floors,
+			RoomNo:   room.RoomNo,
+			Name:     room.Name,
+			RoomType: room.RoomType,
+			Floors:   floors,
 		})
 	}
 
@@ -247,7 +216,7 @@ func (h *RoomVisualizationHandler) GetRoomStats(w http.ResponseWriter, r *http.R
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("X-Cache", "MISS")
-	w.Write(jsonData)
+	w.Write(h.withOverdueFloors(ctx, jsonData))
 }
 
 // GetGatarOccupancy returns detailed gatar-level data for a specific room/floor
@@ -267,13 +236,18 @@ func (h *RoomVisualizationHandler) GetGatarOccupancy(w http.ResponseWriter, r *h
 		return
 	}
 
-	// Validate room and floor
-	if _, ok := gatarRanges[roomNo]; !ok {
+	// Validate room and floor against the warehouse layout
+	layout, err := h.LayoutService.Layout(ctx)
+	if err != nil {
+		http.Error(w, "Failed to load warehouse layout: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if layout.Room(roomNo) == nil {
 		http.Error(w, "Invalid room number", http.StatusBadRequest)
 		return
 	}
-	floorRange, ok := gatarRanges[roomNo][floor]
-	if !ok {
+	layoutFloor := layout.Floor(roomNo, floor)
+	if layoutFloor == nil {
 		http.Error(w, "Invalid floor number", http.StatusBadRequest)
 		return
 	}
@@ -282,7 +256,7 @@ func (h *RoomVisualizationHandler) GetGatarOccupancy(w http.ResponseWriter, r *h
 	if cached, found := cache.GetCachedFloorData(ctx, roomNo, floor); found {
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Set("X-Cache", "HIT")
-		w.Write(cached)
+		w.Write(h.withOverdueGatars(ctx, roomNo, floor, cached))
 		return
 	}
 
@@ -337,19 +311,10 @@ func (h *RoomVisualizationHandler) GetGatarOccupancy(w http.ResponseWriter, r *h
 		}
 
 		// Parse quantity_breakdown (comma-separated, e.g., "25, 24, 24, 24, 16, 21")
-		var breakdownValues []int
-		if quantityBreakdown != "" {
-			parts := strings.Split(quantityBreakdown, ",")
-			for _, p := range parts {
-				p = strings.TrimSpace(p)
-				if val, err := strconv.Atoi(p); err == nil {
-					breakdownValues = append(breakdownValues, val)
-				}
-			}
-		}
+		breakdownValues := models.ParseQuantityBreakdown(quantityBreakdown)
 
 		// Calculate per-gatar quantity distribution
-		gatarQuantities := distributeQuantity(quantity, cleanGatars, breakdownValues)
+		gatarQuantities := distributeQuantity(quantity, cleanGatars, breakdownValues, layout)
 
 		// Add items to each gatar with their distributed quantity
 		for i, g := range cleanGatars {
@@ -367,9 +332,9 @@ func (h *RoomVisualizationHandler) GetGatarOccupancy(w http.ResponseWriter, r *h
 		}
 	}
 
-	// Build response with all gatars in range
+	// Build response with all gatars on the floor
 	var gatars []GatarInfo
-	for g := floorRange.Start; g <= floorRange.End; g++ {
+	for _, g := range layoutFloor.Gatars() {
 		gStr := strconv.Itoa(g)
 		items := gatarItems[gStr]
 		gatars = append(gatars, GatarInfo{
@@ -392,7 +357,74 @@ func (h *RoomVisualizationHandler) GetGatarOccupancy(w http.ResponseWriter, r *h
 
 	w.Header().Set("Content-Type", "application/json")
 	w.Header().Set("X-Cache", "MISS")
-	w.Write(jsonData)
+	w.Write(h.withOverdueGatars(ctx, roomNo, floor, jsonData))
+}
+
+// withOverdueFloors adds each floor's count of gatars overdue for maintenance to a room
+// stats response. Overdue-ness changes with the clock, so it is added after the cache
+// rather than stored in it. The response is returned unchanged if it can't be worked out.
+func (h *RoomVisualizationHandler) withOverdueFloors(ctx context.Context, data []byte) []byte {
+	if h.MaintenanceService == nil {
+		return data
+	}
+	statuses, err := h.MaintenanceService.Status(ctx, "", "")
+	if err != nil {
+		return data
+	}
+	var response RoomVisualizationResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return data
+	}
+
+	overdue := make(map[string]map[int]bool) // room/floor -> gatars
+	for _, s := range statuses {
+		if s.Overdue {
+			key := s.RoomNo + "/" + s.Floor
+			if overdue[key] == nil {
+				overdue[key] = make(map[int]bool)
+			}
+			overdue[key][s.GatarNo] = true
+		}
+	}
+	response.Summary.OverdueGatars = 0
+	for i := range response.Rooms {
+		for j := range response.Rooms[i].Floors {
+			f := &response.Rooms[i].Floors[j]
+			f.OverdueGatars = len(overdue[response.Rooms[i].RoomNo+"/"+f.Floor])
+			response.Summary.OverdueGatars += f.OverdueGatars
+		}
+	}
+
+	if marked, err := json.Marshal(response); err == nil {
+		return marked
+	}
+	return data
+}
+
+// withOverdueGatars adds the overdue maintenance tasks of each gatar to a floor's gatar
+// occupancy response (after the cache, like withOverdueFloors)
+func (h *RoomVisualizationHandler) withOverdueGatars(ctx context.Context, roomNo, floor string, data []byte) []byte {
+	if h.MaintenanceService == nil {
+		return data
+	}
+	overdue, err := h.MaintenanceService.Overdue(ctx, roomNo, floor)
+	if err != nil {
+		return data
+	}
+	var response GatarOccupancyResponse
+	if err := json.Unmarshal(data, &response); err != nil {
+		return data
+	}
+
+	for i := range response.Gatars {
+		g, _ := strconv.Atoi(response.Gatars[i].Gatar)
+		response.Gatars[i].MaintenanceOverdue = overdue[g]
+	}
+
+	if marked, err := json.Marshal(response); err == nil {
+		return marked
+	}
+	return data
 }
 
 // GetGatarDetails returns details for a specific gatar
@@ -410,6 +442,12 @@ func (h *RoomVisualizationHandler) GetGatarDetails(w http.ResponseWriter, r *htt
 		return
 	}
 
+	layout, err := h.LayoutService.Layout(ctx)
+	if err != nil {
+		http.Error(w, "Failed to load warehouse layout: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	// Query to get all items in this gatar (handle comma-separated gate_no)
 	// Uses array membership check instead of LIKE for better index usage
 	query := `
@@ -494,19 +532,10 @@ func (h *RoomVisualizationHandler) GetGatarDetails(w http.ResponseWriter, r *htt
 		// If this gatar is in the list, calculate its distributed quantity
 		if gatarIndex >= 0 {
 			// Parse quantity_breakdown
-			var breakdownValues []int
-			if d.QuantityBreakdown != "" {
-				parts := strings.Split(d.QuantityBreakdown, ",")
-				for _, p := range parts {
-					p = strings.TrimSpace(p)
-					if val, err := strconv.Atoi(p); err == nil {
-						breakdownValues = append(breakdownValues, val)
-					}
-				}
-			}
+			breakdownValues := models.ParseQuantityBreakdown(d.QuantityBreakdown)
 
 			// Calculate distribution for all gatars
-			distribution := distributeQuantity(d.Quantity, cleanGatars, breakdownValues)
+			distribution := distributeQuantity(d.Quantity, cleanGatars, breakdownValues, layout)
 			d.DistributedQty = distribution[gatarIndex]
 			totalDistributedQty += d.DistributedQty
 
@@ -524,97 +553,41 @@ func (h *RoomVisualizationHandler) GetGatarDetails(w http.ResponseWriter, r *htt
 }
 
 // Helper function to get room/floor from gatar number
-func getRoomFloorFromGatar(gatarNum int) (string, string) {
-	for roomNo, floors := range gatarRanges {
-		for floor, r := range floors {
-			if gatarNum >= r.Start && gatarNum <= r.End {
-				return roomNo, floor
-			}
-		}
-	}
-	return "", ""
+func getRoomFloorFromGatar(layout *models.WarehouseLayout, gatarNum int) (string, string) {
+	return layout.Locate(gatarNum)
 }
 
-// distributeQuantity distributes total bags across gatars based on breakdown
-// Logic:
-// 1. If only 1 gatar: all bags go to that gatar
-// 2. If breakdown count matches gatar count: map 1:1
-// 3. If breakdown has more items than gatars: distribute breakdown items sequentially
-//    (each gatar gets bags until ~200 capacity, then overflow to next)
-// 4. Fallback: divide total evenly across gatars
-func distributeQuantity(totalQty int, gatars []string, breakdown []int) []int {
-	numGatars := len(gatars)
-	result := make([]int, numGatars)
-
-	if numGatars == 0 {
-		return result
-	}
-
-	// Case 1: Single gatar - all bags go to it
-	if numGatars == 1 {
-		result[0] = totalQty
-		return result
+// distributeQuantity distributes total bags across gatars based on breakdown, with each
+// gatar's capacity from the layout (see models.DistributeQuantity)
+func distributeQuantity(totalQty int, gatars []string, breakdown []int, layout *models.WarehouseLayout) []int {
+	capacities := make([]int, len(gatars))
+	for i, g := range gatars {
+		capacities[i] = gatarCapacity(layout, g)
 	}
+	return models.DistributeQuantity(totalQty, capacities, breakdown)
+}
 
-	// Case 2: Breakdown count matches gatar count - direct 1:1 mapping
-	if len(breakdown) == numGatars {
-		for i, val := range breakdown {
-			result[i] = val
-		}
-		return result
-	}
-
-	// Case 3: More breakdown items than gatars - distribute sequentially
-	// Each gatar has ~200 bag capacity, fill in order
-	if len(breakdown) > numGatars {
-		const gatarCapacity = 200
-		currentGatar := 0
-		currentGatarBags := 0
-
-		for _, bags := range breakdown {
-			// If current gatar would overflow, try to fit what we can
-			remainingCapacity := gatarCapacity - currentGatarBags
-
-			if bags <= remainingCapacity || currentGatar == numGatars-1 {
-				// Fits in current gatar OR this is the last gatar (must take overflow)
-				result[currentGatar] += bags
-				currentGatarBags += bags
-			} else {
-				// Split between current and next gatar
-				result[currentGatar] += remainingCapacity
-				currentGatar++
-				if currentGatar < numGatars {
-					result[currentGatar] += bags - remainingCapacity
-					currentGatarBags = bags - remainingCapacity
-				}
-			}
-
-			// Move to next gatar if current is at capacity
-			if currentGatarBags >= gatarCapacity && currentGatar < numGatars-1 {
-				currentGatar++
-				currentGatarBags = 0
-			}
-		}
-		return result
+// utilizationPct returns used as a percentage of capacity, to one decimal
+func utilizationPct(used, capacity int) float64 {
+	if capacity <= 0 {
+		return 0
 	}
+	return math.Round(float64(used)*1000/float64(capacity)) / 10
+}
 
-	// Case 4: Fewer breakdown items than gatars OR no breakdown - divide evenly
-	baseQty := totalQty / numGatars
-	remainder := totalQty % numGatars
-
-	for i := 0; i < numGatars; i++ {
-		result[i] = baseQty
-		// Distribute remainder across first few gatars
-		if i < remainder {
-			result[i]++
+// gatarCapacity returns a gatar's capacity from the layout (the default if it is not in it)
+func gatarCapacity(layout *models.WarehouseLayout, gatar string) int {
+	if g, err := strconv.Atoi(gatar); err == nil && layout != nil {
+		if capacity := layout.Capacity(g); capacity > 0 {
+			return capacity
 		}
 	}
-
-	return result
+	return models.DefaultGatarCapacity
 }
 
 // GetPerGatarStock returns per-gatar stock data from the room_entry_gatars table
-// This endpoint uses the new per-gatar quantity tracking system
+// This endpoint uses the new per-gatar quantity tracking system, with each gatar's
+// capacity and utilization from the warehouse layout
 func (h *RoomVisualizationHandler) GetPerGatarStock(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Cache-Control", "no-cache, no-store, must-revalidate")
 	w.Header().Set("Pragma", "no-cache")
@@ -637,32 +610,65 @@ func (h *RoomVisualizationHandler) GetPerGatarStock(w http.ResponseWriter, r *ht
 		return
 	}
 
+	layout, err := h.LayoutService.Layout(ctx)
+	if err != nil {
+		http.Error(w, "Failed to load warehouse layout: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
 	// Convert to response format
 	type GatarStockResponse struct {
-		GatarNo     int    `json:"gatar_no"`
-		Quantity    int    `json:"quantity"`
-		Variety     string `json:"variety"`
-		ThockNumber string `json:"thock_number"`
-		Quality     string `json:"quality"`
+		GatarNo            int      `json:"gatar_no"`
+		Quantity           int      `json:"quantity"`
+		Variety            string   `json:"variety"`
+		ThockNumber        string   `json:"thock_number"`
+		Quality            string   `json:"quality"`
+		Capacity           int      `json:"capacity"`
+		Free               int      `json:"free"` // Negative when over capacity
+		UtilizationPct     float64  `json:"utilization_pct"`
+		MaintenanceOverdue []string `json:"maintenance_overdue,omitempty"` // Overdue tasks (turning, ventilation, inspection)
+	}
+
+	var overdue map[int][]string
+	if h.MaintenanceService != nil {
+		overdue, _ = h.MaintenanceService.Overdue(ctx, roomNo, floor)
 	}
 
 	var response []GatarStockResponse
+	var usedBags int
 	for _, s := range stocks {
+		capacity := gatarCapacity(layout, strconv.Itoa(s.GatarNo))
 		response = append(response, GatarStockResponse{
-			GatarNo:     s.GatarNo,
-			Quantity:    s.Quantity,
-			Variety:     s.Variety,
-			ThockNumber: s.ThockNumber,
-			Quality:     s.Quality,
+			GatarNo:            s.GatarNo,
+			Quantity:           s.Quantity,
+			Variety:            s.Variety,
+			ThockNumber:        s.ThockNumber,
+			Quality:            s.Quality,
+			Capacity:           capacity,
+			Free:               capacity - s.Quantity,
+			UtilizationPct:     utilizationPct(s.Quantity, capacity),
+			MaintenanceOverdue: overdue[s.GatarNo],
 		})
+		usedBags += s.Quantity
+	}
+
+	// Floor capacity counts every gatar on the floor, stocked or not
+	floorCapacity := 0
+	if f := layout.Floor(roomNo, floor); f != nil {
+		for _, g := range f.Gatars() {
+			floorCapacity += layout.Capacity(g)
+		}
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
-		"room_no": roomNo,
-		"floor":   floor,
-		"gatars":  response,
-		"count":   len(response),
+		"room_no":         roomNo,
+		"floor":           floor,
+		"gatars":          response,
+		"count":           len(response),
+		"capacity":        floorCapacity,
+		"used":            usedBags,
+		"utilization_pct": utilizationPct(usedBags, floorCapacity),
 	})
 }
 
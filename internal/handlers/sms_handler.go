@@ -3,18 +3,22 @@ package handlers
 import (
 	"encoding/json"
 	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 
+	"cold-backend/internal/middleware"
 	"cold-backend/internal/models"
 	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
 	"cold-backend/internal/sms"
 )
 
 type SMSHandler struct {
-	SMSLogRepo    *repositories.SMSLogRepository
-	SettingRepo   *repositories.SystemSettingRepository
-	SMSService    sms.SMSProvider
+	SMSLogRepo         *repositories.SMSLogRepository
+	SettingRepo        *repositories.SystemSettingRepository
+	SMSService         sms.SMSProvider
+	PaymentLinkService *services.PaymentLinkService
 }
 
 func NewSMSHandler(
@@ -29,6 +33,11 @@ func NewSMSHandler(
 	}
 }
 
+// SetPaymentLinkService adds a payment link for the outstanding amount to payment reminders
+func (h *SMSHandler) SetPaymentLinkService(paymentLinkService *services.PaymentLinkService) {
+	h.PaymentLinkService = paymentLinkService
+}
+
 // ListLogs returns paginated SMS logs
 func (h *SMSHandler) ListLogs(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
@@ -232,8 +241,13 @@ func (h *SMSHandler) SendPaymentReminders(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	// Reminders carry a payment link when links are enabled
+	withLinks := h.PaymentLinkService != nil && h.PaymentLinkService.Enabled(ctx)
+	userID, _ := middleware.GetUserIDFromContext(ctx)
+
 	success := 0
 	failed := 0
+	links := 0
 
 	for _, c := range customers {
 		phone := c["phone"].(string)
@@ -246,6 +260,15 @@ func (h *SMSHandler) SendPaymentReminders(w http.ResponseWriter, r *http.Request
 		if message == "" {
 			message = fmt.Sprintf("Dear %s, your pending balance at Cold Storage is Rs.%.2f. Please clear the dues at your earliest. Thank you!", name, balance)
 		}
+		if withLinks {
+			link, err := h.PaymentLinkService.CreateLink(ctx, phone, balance, userID)
+			if err != nil {
+				log.Printf("[SMS] Failed to create payment link for %s: %v", phone, err)
+			} else {
+				message += " Pay online: " + link.URL
+				links++
+			}
+		}
 
 		err := h.SMSService.SendSMS(phone, message, models.SMSTypePaymentReminder, customerID)
 		if err != nil {
@@ -261,7 +284,8 @@ func (h *SMSHandler) SendPaymentReminders(w http.ResponseWriter, r *http.Request
 		"total":   len(customers),
 		"sent":    success,
 		"failed":  failed,
-		"message": fmt.Sprintf("Sent %d reminders, %d failed", success, failed),
+		"links":   links,
+		"message": fmt.Sprintf("Sent %d reminders (%d with payment links), %d failed", success, links, failed),
 	})
 }
 
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// TallyHandler handles Tally Prime XML export endpoints
+type TallyHandler struct {
+	Service         *services.TallyService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewTallyHandler(service *services.TallyService, adminActionRepo *repositories.AdminActionLogRepository) *TallyHandler {
+	return &TallyHandler{
+		Service:         service,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+// ListExports returns previous Tally export batches
+// GET /api/tally/exports?limit=50
+func (h *TallyHandler) ListExports(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	exports, err := h.Service.ListExports(r.Context(), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if exports == nil {
+		exports = []*models.TallyExport{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(exports)
+}
+
+// CreateExport exports ledger entries in a date range as Tally vouchers and marks them exported
+// POST /api/tally/exports
+func (h *TallyHandler) CreateExport(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CreateTallyExportRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	export, data, err := h.Service.CreateExport(r.Context(), &req, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if h.AdminActionRepo != nil {
+		h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+			AdminUserID: userID,
+			ActionType:  "EXPORT",
+			TargetType:  "tally_export",
+			TargetID:    &export.ID,
+			Description: fmt.Sprintf("Exported %d vouchers to Tally (%s to %s)", export.VoucherCount, req.FromDate, req.ToDate),
+		})
+	}
+
+	w.Header().Set("X-Tally-Export-ID", strconv.Itoa(export.ID))
+	writeTallyXML(w, tallyExportFilename(export), data)
+}
+
+// DownloadExport re-downloads the XML of an earlier export batch
+// GET /api/tally/exports/{id}/download
+func (h *TallyHandler) DownloadExport(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid export ID", http.StatusBadRequest)
+		return
+	}
+
+	export, data, err := h.Service.DownloadExport(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Export not found", http.StatusNotFound)
+		return
+	}
+
+	writeTallyXML(w, tallyExportFilename(export), data)
+}
+
+// ExportMasters returns ledger masters for all customers and accounts
+// GET /api/tally/masters
+func (h *TallyHandler) ExportMasters(w http.ResponseWriter, r *http.Request) {
+	data, err := h.Service.ExportMasters(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeTallyXML(w, "tally_masters.xml", data)
+}
+
+func tallyExportFilename(export *models.TallyExport) string {
+	return fmt.Sprintf("tally_vouchers_%s_to_%s.xml", export.FromDate.Format("2006-01-02"), export.ToDate.Format("2006-01-02"))
+}
+
+func writeTallyXML(w http.ResponseWriter, filename string, data []byte) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	w.Write(data)
+}
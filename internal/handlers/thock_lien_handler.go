@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// ThockLienHandler handles bank lien and warehouse receipt endpoints
+type ThockLienHandler struct {
+	Service         *services.ThockLienService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewThockLienHandler(service *services.ThockLienService, adminActionRepo *repositories.AdminActionLogRepository) *ThockLienHandler {
+	return &ThockLienHandler{
+		Service:         service,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+// ListLiens returns bank liens
+// GET /api/liens?status=active&thock_number=X&phone=Y
+func (h *ThockLienHandler) ListLiens(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	liens, err := h.Service.ListLiens(r.Context(), q.Get("status"), q.Get("thock_number"), q.Get("phone"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if liens == nil {
+		liens = []*models.ThockLien{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(liens)
+}
+
+// GetLien returns a single lien
+// GET /api/liens/{id}
+func (h *ThockLienHandler) GetLien(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid lien ID", http.StatusBadRequest)
+		return
+	}
+
+	lien, err := h.Service.GetLien(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Lien not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lien)
+}
+
+// CreateLien marks bags of a thock as pledged to a bank
+// POST /api/liens
+func (h *ThockLienHandler) CreateLien(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.CreateThockLienRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	lien, err := h.Service.CreateLien(r.Context(), &req, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "CREATE", &lien.ID,
+		fmt.Sprintf("Marked lien %s: %d bags of thock %s pledged to %s", lien.ReceiptNumber, lien.PledgedQuantity, lien.ThockNumber, lien.BankName))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(lien)
+}
+
+// ReleaseLien releases a lien after the bank's release letter (admin only)
+// PUT /api/liens/{id}/release
+func (h *ThockLienHandler) ReleaseLien(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid lien ID", http.StatusBadRequest)
+		return
+	}
+
+	var req models.ReleaseThockLienRequest
+	json.NewDecoder(r.Body).Decode(&req)
+
+	lien, err := h.Service.ReleaseLien(r.Context(), id, &req, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	h.logAction(r, userID, "UPDATE", &id,
+		fmt.Sprintf("Released lien %s on thock %s (%s): %s", lien.ReceiptNumber, lien.ThockNumber, lien.BankName, req.ReleaseReference))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(lien)
+}
+
+// DownloadReceipt returns the warehouse receipt PDF for a lien
+// GET /api/liens/{id}/receipt
+func (h *ThockLienHandler) DownloadReceipt(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "Invalid lien ID", http.StatusBadRequest)
+		return
+	}
+
+	lien, err := h.Service.GetLien(r.Context(), id)
+	if err != nil {
+		http.Error(w, "Lien not found", http.StatusNotFound)
+		return
+	}
+
+	pdfData, err := h.Service.GenerateReceiptPDF(lien)
+	if err != nil {
+		http.Error(w, "Failed to generate PDF: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	filename := fmt.Sprintf("warehouse_receipt_%s.pdf", lien.ReceiptNumber)
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s\"", filename))
+	w.Write(pdfData)
+}
+
+// VerifyReceipt lets a bank check a warehouse receipt by its verification code (public)
+// GET /api/verify-receipt/{code}
+func (h *ThockLienHandler) VerifyReceipt(w http.ResponseWriter, r *http.Request) {
+	result, err := h.Service.VerifyReceipt(r.Context(), mux.Vars(r)["code"])
+	if err != nil {
+		http.Error(w, "Failed to verify receipt", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !result.Valid {
+		w.WriteHeader(http.StatusNotFound)
+	}
+	json.NewEncoder(w).Encode(result)
+}
+
+// logAction records a lien change in the admin action log
+func (h *ThockLienHandler) logAction(r *http.Request, userID int, actionType string, lienID *int, description string) {
+	if h.AdminActionRepo == nil {
+		return
+	}
+	h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+		AdminUserID: userID,
+		ActionType:  actionType,
+		TargetType:  "thock_lien",
+		TargetID:    lienID,
+		Description: description,
+	})
+}
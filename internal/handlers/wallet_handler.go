@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"cold-backend/internal/cache"
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// WalletHandler takes customer advance deposits and refunds their unused balance
+type WalletHandler struct {
+	Service         *services.WalletService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewWalletHandler(service *services.WalletService, adminActionRepo *repositories.AdminActionLogRepository) *WalletHandler {
+	return &WalletHandler{
+		Service:         service,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+func (h *WalletHandler) logAction(r *http.Request, userID int, actionType string, targetID int, description string) {
+	if h.AdminActionRepo == nil {
+		return
+	}
+	h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+		AdminUserID: userID,
+		ActionType:  actionType,
+		TargetType:  "ledger_entry",
+		TargetID:    &targetID,
+		Description: description,
+	})
+}
+
+// ListBalances returns customers with an unused wallet balance (season-end refund list)
+// GET /api/wallet/balances
+func (h *WalletHandler) ListBalances(w http.ResponseWriter, r *http.Request) {
+	wallets, err := h.Service.ListBalances(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if wallets == nil {
+		wallets = []*models.Wallet{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wallets)
+}
+
+// GetCustomerWallet returns a customer's wallet balance and statement
+// GET /api/wallet/customer/{phone}
+func (h *WalletHandler) GetCustomerWallet(w http.ResponseWriter, r *http.Request) {
+	phone := mux.Vars(r)["phone"]
+	if phone == "" {
+		http.Error(w, "Customer phone required", http.StatusBadRequest)
+		return
+	}
+
+	wallet, err := h.Service.GetWallet(r.Context(), phone, true)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if wallet == nil {
+		wallet = &models.Wallet{CustomerPhone: phone}
+	}
+	if wallet.Statement == nil {
+		wallet.Statement = []models.WalletStatementLine{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(wallet)
+}
+
+// Deposit records an advance deposit into a customer's wallet
+// POST /api/wallet/deposit
+func (h *WalletHandler) Deposit(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.WalletDepositRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.Service.Deposit(r.Context(), &req, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cache.InvalidatePaymentCaches(r.Context())
+
+	h.logAction(r, userID, "WALLET_DEPOSIT", entry.ID,
+		fmt.Sprintf("Wallet deposit of ₹%.2f (%s) for %s (%s)", entry.Credit, entry.PaymentMode, entry.CustomerName, entry.CustomerPhone))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
+
+// Refund pays back part or all of a customer's unused wallet balance
+// POST /api/wallet/refund
+func (h *WalletHandler) Refund(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req models.WalletRefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	entry, err := h.Service.Refund(r.Context(), &req, userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	cache.InvalidatePaymentCaches(r.Context())
+
+	h.logAction(r, userID, "WALLET_REFUND", entry.ID,
+		fmt.Sprintf("Wallet refund of ₹%.2f (%s) to %s (%s)", entry.Debit, entry.PaymentMode, entry.CustomerName, entry.CustomerPhone))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(entry)
+}
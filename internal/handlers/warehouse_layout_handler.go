@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"cold-backend/internal/cache"
+	"cold-backend/internal/middleware"
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// WarehouseLayoutHandler handles the warehouse layout (rooms, floors and gatars)
+type WarehouseLayoutHandler struct {
+	Service         *services.WarehouseLayoutService
+	AdminActionRepo *repositories.AdminActionLogRepository
+}
+
+func NewWarehouseLayoutHandler(service *services.WarehouseLayoutService, adminActionRepo *repositories.AdminActionLogRepository) *WarehouseLayoutHandler {
+	return &WarehouseLayoutHandler{
+		Service:         service,
+		AdminActionRepo: adminActionRepo,
+	}
+}
+
+// GetLayout returns all rooms with their floors, gatar ranges and capacities
+// GET /api/warehouse-layout
+func (h *WarehouseLayoutHandler) GetLayout(w http.ResponseWriter, r *http.Request) {
+	layout, err := h.Service.Layout(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(layout)
+}
+
+// GetGrid returns the layout as room -> floor -> floor plan, as the room pages draw it
+// GET /api/warehouse-layout/grid
+func (h *WarehouseLayoutHandler) GetGrid(w http.ResponseWriter, r *http.Request) {
+	layout, err := h.Service.Layout(r.Context())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(layout.Grid())
+}
+
+// SaveRoom creates or replaces a room with its floors and gatar ranges (admin only)
+// PUT /api/warehouse-layout/rooms/{room_no}
+func (h *WarehouseLayoutHandler) SaveRoom(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var room models.LayoutRoom
+	if err := json.NewDecoder(r.Body).Decode(&room); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	room.RoomNo = mux.Vars(r)["room_no"]
+
+	saved, err := h.Service.SaveRoom(r.Context(), &room)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	gatars := 0
+	for _, f := range saved.Floors {
+		gatars += f.TotalGatars()
+	}
+	h.logAction(r, userID, "UPDATE", fmt.Sprintf("Saved warehouse room %s (%s, %s): %d floors, %d gatars",
+		saved.RoomNo, saved.Name, saved.RoomType, len(saved.Floors), gatars))
+	cache.InvalidateRoomCache(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// DeleteRoom removes a room that holds no stock (admin only)
+// DELETE /api/warehouse-layout/rooms/{room_no}
+func (h *WarehouseLayoutHandler) DeleteRoom(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	roomNo := mux.Vars(r)["room_no"]
+	if err := h.Service.DeleteRoom(r.Context(), roomNo); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrLayoutRoomNotFound) {
+			status = http.StatusNotFound
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	h.logAction(r, userID, "DELETE", fmt.Sprintf("Removed warehouse room %s", roomNo))
+	cache.InvalidateRoomCache(r.Context())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Room removed"})
+}
+
+// SetGatarCapacity sets how many bags a gatar holds; 0 resets it to its floor's (admin only)
+// PUT /api/warehouse-layout/gatars/{gatar_no}/capacity
+func (h *WarehouseLayoutHandler) SetGatarCapacity(w http.ResponseWriter, r *http.Request) {
+	userID, ok := middleware.GetUserIDFromContext(r.Context())
+	if !ok {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	gatarNo, err := strconv.Atoi(mux.Vars(r)["gatar_no"])
+	if err != nil {
+		http.Error(w, "Invalid gatar number", http.StatusBadRequest)
+		return
+	}
+
+	var req models.SetGatarCapacityRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.Service.SetGatarCapacity(r.Context(), gatarNo, req.Capacity); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	desc := fmt.Sprintf("Set gatar %d capacity to %d bags", gatarNo, req.Capacity)
+	if req.Capacity == 0 {
+		desc = fmt.Sprintf("Reset gatar %d capacity to its floor's", gatarNo)
+	}
+	h.logAction(r, userID, "UPDATE", desc)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"gatar_no": gatarNo,
+		"capacity": req.Capacity,
+	})
+}
+
+// logAction records a layout change in the admin action log
+func (h *WarehouseLayoutHandler) logAction(r *http.Request, userID int, actionType, description string) {
+	if h.AdminActionRepo == nil {
+		return
+	}
+	h.AdminActionRepo.CreateActionLog(r.Context(), &models.AdminActionLog{
+		AdminUserID: userID,
+		ActionType:  actionType,
+		TargetType:  "warehouse_layout",
+		Description: description,
+	})
+}
@@ -55,6 +55,25 @@ func NewRouter(
 	totpHandler *handlers.TOTPHandler,
 	restoreHandler *handlers.RestoreHandler,
 	printerHandler *handlers.PrinterHandler,
+	rentTariffHandler *handlers.RentTariffHandler,
+	rentChargeHandler *handlers.RentChargeHandler,
+	rateContractHandler *handlers.RateContractHandler,
+	interestHandler *handlers.InterestHandler,
+	cropLoanHandler *handlers.CropLoanHandler,
+	thockLienHandler *handlers.ThockLienHandler,
+	accountingHandler *handlers.AccountingHandler,
+	tallyHandler *handlers.TallyHandler,
+	accountingPeriodHandler *handlers.AccountingPeriodHandler,
+	ledgerIntegrityHandler *handlers.LedgerIntegrityHandler,
+	cashSessionHandler *handlers.CashSessionHandler,
+	bankStatementHandler *handlers.BankStatementHandler,
+	paymentAllocationHandler *handlers.PaymentAllocationHandler,
+	walletHandler *handlers.WalletHandler,
+	paymentLinkHandler *handlers.PaymentLinkHandler,
+	settlementHandler *handlers.SettlementHandler,
+	warehouseLayoutHandler *handlers.WarehouseLayoutHandler,
+	gatarMovementHandler *handlers.GatarMovementHandler,
+	gatarMaintenanceHandler *handlers.GatarMaintenanceHandler,
 ) *mux.Router {
 	r := mux.NewRouter()
 
@@ -242,6 +261,7 @@ func NewRouter(
 	roomEntriesAPI.HandleFunc("", operationModeMiddleware.RequireLoadingMode(
 		authMiddleware.RequireRole("employee", "admin")(http.HandlerFunc(roomEntryHandler.CreateRoomEntry)),
 	).ServeHTTP).Methods("POST")
+	roomEntriesAPI.HandleFunc("/suggest", roomEntryHandler.SuggestGatars).Methods("GET") // Gatar suggestions for an incoming thock
 	roomEntriesAPI.HandleFunc("/{id}", roomEntryHandler.GetRoomEntry).Methods("GET")
 	roomEntriesAPI.HandleFunc("/{id}", operationModeMiddleware.RequireLoadingMode(
 		authMiddleware.RequireRole("employee", "admin")(http.HandlerFunc(roomEntryHandler.UpdateRoomEntry)),
@@ -260,6 +280,9 @@ func NewRouter(
 	rentPaymentsAPI.HandleFunc("/entry/{entry_id}", authMiddleware.RequireAccountantAccess(http.HandlerFunc(rentPaymentHandler.GetPaymentsByEntry)).ServeHTTP).Methods("GET")
 	rentPaymentsAPI.HandleFunc("/phone", authMiddleware.RequireAccountantAccess(http.HandlerFunc(rentPaymentHandler.GetPaymentsByPhone)).ServeHTTP).Methods("GET")
 	rentPaymentsAPI.HandleFunc("/receipt/{receipt_number}", authMiddleware.RequireAccountantAccess(http.HandlerFunc(rentPaymentHandler.GetPaymentByReceiptNumber)).ServeHTTP).Methods("GET")
+	rentPaymentsAPI.HandleFunc("/cheques", authMiddleware.RequireAccountantAccess(http.HandlerFunc(rentPaymentHandler.ListCheques)).ServeHTTP).Methods("GET")
+	rentPaymentsAPI.HandleFunc("/{id}/clear", authMiddleware.RequireAccountantAccess(http.HandlerFunc(rentPaymentHandler.ClearCheque)).ServeHTTP).Methods("POST")
+	rentPaymentsAPI.HandleFunc("/{id}/bounce", authMiddleware.RequireAccountantAccess(http.HandlerFunc(rentPaymentHandler.BounceCheque)).ServeHTTP).Methods("POST")
 
 	// Protected API routes - Invoices (employees and admins can create, all can view)
 	invoicesAPI := r.PathPrefix("/api/invoices").Subrouter()
@@ -618,8 +641,207 @@ func NewRouter(
 		ledgerAPI.HandleFunc("/debtors", authMiddleware.RequireAccountantAccess(http.HandlerFunc(ledgerHandler.GetDebtors)).ServeHTTP).Methods("GET")
 		ledgerAPI.HandleFunc("/balances", authMiddleware.RequireAccountantAccess(http.HandlerFunc(ledgerHandler.GetAllBalances)).ServeHTTP).Methods("GET")
 		ledgerAPI.HandleFunc("/totals", authMiddleware.RequireAccountantAccess(http.HandlerFunc(ledgerHandler.GetTotalsByType)).ServeHTTP).Methods("GET")
-		// Admin only - create manual entries
+		// Admin only - create manual entries and void wrong ones
 		ledgerAPI.HandleFunc("/entry", authMiddleware.RequireAdmin(http.HandlerFunc(ledgerHandler.CreateEntry)).ServeHTTP).Methods("POST")
+		ledgerAPI.HandleFunc("/entry/{id}/void", authMiddleware.RequireAdmin(http.HandlerFunc(ledgerHandler.VoidEntry)).ServeHTTP).Methods("POST")
+	}
+
+	// Protected API routes - Rent Tariffs (rate cards)
+	if rentTariffHandler != nil {
+		tariffAPI := r.PathPrefix("/api/rent-tariffs").Subrouter()
+		tariffAPI.Use(authMiddleware.Authenticate)
+		// Any authenticated user can look up the rate for a thock
+		tariffAPI.HandleFunc("/quote", rentTariffHandler.QuoteRate).Methods("GET")
+		tariffAPI.HandleFunc("", authMiddleware.RequireAccountantAccess(http.HandlerFunc(rentTariffHandler.ListTariffs)).ServeHTTP).Methods("GET")
+		tariffAPI.HandleFunc("/{id}", authMiddleware.RequireAccountantAccess(http.HandlerFunc(rentTariffHandler.GetTariff)).ServeHTTP).Methods("GET")
+		// Admin only - manage rate cards
+		tariffAPI.HandleFunc("", authMiddleware.RequireAdmin(http.HandlerFunc(rentTariffHandler.CreateTariff)).ServeHTTP).Methods("POST")
+		tariffAPI.HandleFunc("/{id}", authMiddleware.RequireAdmin(http.HandlerFunc(rentTariffHandler.UpdateTariff)).ServeHTTP).Methods("PUT")
+		tariffAPI.HandleFunc("/{id}", authMiddleware.RequireAdmin(http.HandlerFunc(rentTariffHandler.DeactivateTariff)).ServeHTTP).Methods("DELETE")
+	}
+
+	// Protected API routes - Rent Charges (duration-based rent per pickup)
+	if rentChargeHandler != nil {
+		rentChargeAPI := r.PathPrefix("/api/rent-charges").Subrouter()
+		rentChargeAPI.Use(authMiddleware.Authenticate)
+		rentChargeAPI.HandleFunc("/estimate", rentChargeHandler.EstimateCharge).Methods("GET")
+		rentChargeAPI.HandleFunc("/pickups/{id}", authMiddleware.RequireAccountantAccess(http.HandlerFunc(rentChargeHandler.GetPickupCharge)).ServeHTTP).Methods("GET")
+		rentChargeAPI.HandleFunc("/pickups/{id}", authMiddleware.RequireAccountantAccess(http.HandlerFunc(rentChargeHandler.PostPickupCharge)).ServeHTTP).Methods("POST")
+	}
+
+	// Protected API routes - Rate Contracts (customer negotiated rates)
+	if rateContractHandler != nil {
+		contractAPI := r.PathPrefix("/api/rate-contracts").Subrouter()
+		contractAPI.Use(authMiddleware.Authenticate)
+		// Accountants can view and propose contracts
+		contractAPI.HandleFunc("", authMiddleware.RequireAccountantAccess(http.HandlerFunc(rateContractHandler.ListContracts)).ServeHTTP).Methods("GET")
+		contractAPI.HandleFunc("", authMiddleware.RequireAccountantAccess(http.HandlerFunc(rateContractHandler.CreateContract)).ServeHTTP).Methods("POST")
+		contractAPI.HandleFunc("/{id}", authMiddleware.RequireAccountantAccess(http.HandlerFunc(rateContractHandler.GetContract)).ServeHTTP).Methods("GET")
+		// Admin only - approval workflow
+		contractAPI.HandleFunc("/{id}/approve", authMiddleware.RequireAdmin(http.HandlerFunc(rateContractHandler.ApproveContract)).ServeHTTP).Methods("PUT")
+		contractAPI.HandleFunc("/{id}/reject", authMiddleware.RequireAdmin(http.HandlerFunc(rateContractHandler.RejectContract)).ServeHTTP).Methods("PUT")
+		contractAPI.HandleFunc("/{id}/revoke", authMiddleware.RequireAdmin(http.HandlerFunc(rateContractHandler.RevokeContract)).ServeHTTP).Methods("PUT")
+	}
+
+	// Protected API routes - Interest on overdue balances
+	if interestHandler != nil {
+		interestAPI := r.PathPrefix("/api/interest").Subrouter()
+		interestAPI.Use(authMiddleware.Authenticate)
+		interestAPI.Use(authMiddleware.RequireAccountantAccess)
+		interestAPI.HandleFunc("/config", interestHandler.GetConfig).Methods("GET")
+		interestAPI.HandleFunc("/preview", interestHandler.Preview).Methods("GET")
+		interestAPI.HandleFunc("/post", interestHandler.Post).Methods("POST")
+		interestAPI.HandleFunc("/runs", interestHandler.ListRuns).Methods("GET")
+		interestAPI.HandleFunc("/waivers", interestHandler.ListWaivers).Methods("GET")
+		interestAPI.HandleFunc("/waivers", interestHandler.CreateWaiver).Methods("POST")
+		interestAPI.HandleFunc("/waivers/{id}", interestHandler.DeactivateWaiver).Methods("DELETE")
+	}
+
+	// Protected API routes - Crop loans against stored stock
+	if cropLoanHandler != nil {
+		cropLoanAPI := r.PathPrefix("/api/crop-loans").Subrouter()
+		cropLoanAPI.Use(authMiddleware.Authenticate)
+		cropLoanAPI.Use(authMiddleware.RequireAccountantAccess)
+		cropLoanAPI.HandleFunc("", cropLoanHandler.ListLoans).Methods("GET")
+		cropLoanAPI.HandleFunc("", cropLoanHandler.CreateLoan).Methods("POST")
+		cropLoanAPI.HandleFunc("/eligibility", cropLoanHandler.GetEligibility).Methods("GET")
+		cropLoanAPI.HandleFunc("/accrue", cropLoanHandler.AccrueInterest).Methods("POST")
+		cropLoanAPI.HandleFunc("/{id}", cropLoanHandler.GetLoan).Methods("GET")
+		cropLoanAPI.HandleFunc("/{id}/repayments", cropLoanHandler.ListRepayments).Methods("GET")
+		cropLoanAPI.HandleFunc("/{id}/repayments", cropLoanHandler.Repay).Methods("POST")
+	}
+
+	// Protected API routes - Bank liens and warehouse receipts
+	if thockLienHandler != nil {
+		lienAPI := r.PathPrefix("/api/liens").Subrouter()
+		lienAPI.Use(authMiddleware.Authenticate)
+		lienAPI.HandleFunc("", authMiddleware.RequireAccountantAccess(http.HandlerFunc(thockLienHandler.ListLiens)).ServeHTTP).Methods("GET")
+		lienAPI.HandleFunc("", authMiddleware.RequireAccountantAccess(http.HandlerFunc(thockLienHandler.CreateLien)).ServeHTTP).Methods("POST")
+		lienAPI.HandleFunc("/{id}", authMiddleware.RequireAccountantAccess(http.HandlerFunc(thockLienHandler.GetLien)).ServeHTTP).Methods("GET")
+		lienAPI.HandleFunc("/{id}/receipt", authMiddleware.RequireAccountantAccess(http.HandlerFunc(thockLienHandler.DownloadReceipt)).ServeHTTP).Methods("GET")
+		// Only admins can release stock back to the depositor
+		lienAPI.HandleFunc("/{id}/release", authMiddleware.RequireAdmin(http.HandlerFunc(thockLienHandler.ReleaseLien)).ServeHTTP).Methods("PUT")
+
+		// Public API - Banks verify warehouse receipts (rate limited)
+		r.HandleFunc("/api/verify-receipt/{code}", middleware.APIRateLimiter.Middleware(http.HandlerFunc(thockLienHandler.VerifyReceipt)).ServeHTTP).Methods("GET")
+	}
+
+	// Protected API routes - Double-entry accounts, journals and financial statements
+	if accountingHandler != nil {
+		accountingAPI := r.PathPrefix("/api/accounting").Subrouter()
+		accountingAPI.Use(authMiddleware.Authenticate)
+		accountingAPI.HandleFunc("/accounts", authMiddleware.RequireAccountantAccess(http.HandlerFunc(accountingHandler.ListAccounts)).ServeHTTP).Methods("GET")
+		accountingAPI.HandleFunc("/journals", authMiddleware.RequireAccountantAccess(http.HandlerFunc(accountingHandler.ListJournals)).ServeHTTP).Methods("GET")
+		accountingAPI.HandleFunc("/journals", authMiddleware.RequireAccountantAccess(http.HandlerFunc(accountingHandler.CreateJournal)).ServeHTTP).Methods("POST")
+		accountingAPI.HandleFunc("/journals/{id}", authMiddleware.RequireAccountantAccess(http.HandlerFunc(accountingHandler.GetJournal)).ServeHTTP).Methods("GET")
+		accountingAPI.HandleFunc("/trial-balance", authMiddleware.RequireAccountantAccess(http.HandlerFunc(accountingHandler.GetTrialBalance)).ServeHTTP).Methods("GET")
+		accountingAPI.HandleFunc("/profit-loss", authMiddleware.RequireAccountantAccess(http.HandlerFunc(accountingHandler.GetProfitAndLoss)).ServeHTTP).Methods("GET")
+		accountingAPI.HandleFunc("/balance-sheet", authMiddleware.RequireAccountantAccess(http.HandlerFunc(accountingHandler.GetBalanceSheet)).ServeHTTP).Methods("GET")
+		// Only admins can change the chart of accounts or backfill postings
+		accountingAPI.HandleFunc("/accounts", authMiddleware.RequireAdmin(http.HandlerFunc(accountingHandler.CreateAccount)).ServeHTTP).Methods("POST")
+		accountingAPI.HandleFunc("/sync", authMiddleware.RequireAdmin(http.HandlerFunc(accountingHandler.SyncLedger)).ServeHTTP).Methods("POST")
+	}
+
+	// Protected API routes - Tally Prime XML export
+	if tallyHandler != nil {
+		tallyAPI := r.PathPrefix("/api/tally").Subrouter()
+		tallyAPI.Use(authMiddleware.Authenticate)
+		tallyAPI.Use(authMiddleware.RequireAccountantAccess)
+		tallyAPI.HandleFunc("/exports", tallyHandler.ListExports).Methods("GET")
+		tallyAPI.HandleFunc("/exports", tallyHandler.CreateExport).Methods("POST")
+		tallyAPI.HandleFunc("/exports/{id}/download", tallyHandler.DownloadExport).Methods("GET")
+		tallyAPI.HandleFunc("/masters", tallyHandler.ExportMasters).Methods("GET")
+	}
+
+	// Protected API routes - Accounting period close and reopen approvals
+	if accountingPeriodHandler != nil {
+		periodAPI := r.PathPrefix("/api/accounting-periods").Subrouter()
+		periodAPI.Use(authMiddleware.Authenticate)
+		periodAPI.HandleFunc("", authMiddleware.RequireAccountantAccess(http.HandlerFunc(accountingPeriodHandler.ListPeriods)).ServeHTTP).Methods("GET")
+		periodAPI.HandleFunc("/{id}/balances", authMiddleware.RequireAccountantAccess(http.HandlerFunc(accountingPeriodHandler.GetPeriodBalances)).ServeHTTP).Methods("GET")
+		// Admin only - closing locks the ledger; reopening needs a second admin's approval
+		periodAPI.HandleFunc("/close", authMiddleware.RequireAdmin(http.HandlerFunc(accountingPeriodHandler.ClosePeriods)).ServeHTTP).Methods("POST")
+		periodAPI.HandleFunc("/{id}/reopen", authMiddleware.RequireAdmin(http.HandlerFunc(accountingPeriodHandler.RequestReopen)).ServeHTTP).Methods("POST")
+		periodAPI.HandleFunc("/reopen-requests", authMiddleware.RequireAdmin(http.HandlerFunc(accountingPeriodHandler.ListReopenRequests)).ServeHTTP).Methods("GET")
+		periodAPI.HandleFunc("/reopen-requests/{id}/approve", authMiddleware.RequireAdmin(http.HandlerFunc(accountingPeriodHandler.ApproveReopen)).ServeHTTP).Methods("POST")
+		periodAPI.HandleFunc("/reopen-requests/{id}/reject", authMiddleware.RequireAdmin(http.HandlerFunc(accountingPeriodHandler.RejectReopen)).ServeHTTP).Methods("POST")
+	}
+
+	// Protected API routes - Ledger integrity checks (admin only)
+	if ledgerIntegrityHandler != nil {
+		integrityAPI := r.PathPrefix("/api/ledger-integrity").Subrouter()
+		integrityAPI.Use(authMiddleware.Authenticate)
+		integrityAPI.Use(authMiddleware.RequireRole("admin"))
+		integrityAPI.HandleFunc("/check", ledgerIntegrityHandler.RunCheck).Methods("POST")
+		integrityAPI.HandleFunc("/runs", ledgerIntegrityHandler.ListRuns).Methods("GET")
+		integrityAPI.HandleFunc("/runs/{id}", ledgerIntegrityHandler.GetRun).Methods("GET")
+	}
+
+	// Protected API routes - Cash drawer sessions (accountants open and close their own drawer)
+	if cashSessionHandler != nil {
+		cashAPI := r.PathPrefix("/api/cash-sessions").Subrouter()
+		cashAPI.Use(authMiddleware.Authenticate)
+		cashAPI.Use(authMiddleware.RequireAccountantAccess)
+		cashAPI.HandleFunc("", cashSessionHandler.ListSessions).Methods("GET")
+		cashAPI.HandleFunc("/current", cashSessionHandler.GetCurrentSession).Methods("GET")
+		cashAPI.HandleFunc("/open", cashSessionHandler.OpenSession).Methods("POST")
+		cashAPI.HandleFunc("/close", cashSessionHandler.CloseSession).Methods("POST")
+		cashAPI.HandleFunc("/{id}", cashSessionHandler.GetSession).Methods("GET")
+	}
+
+	// Protected API routes - Bank statement import and reconciliation queue
+	if bankStatementHandler != nil {
+		bankAPI := r.PathPrefix("/api/bank-statements").Subrouter()
+		bankAPI.Use(authMiddleware.Authenticate)
+		bankAPI.Use(authMiddleware.RequireAccountantAccess)
+		bankAPI.HandleFunc("/import", bankStatementHandler.ImportStatement).Methods("POST")
+		bankAPI.HandleFunc("/imports", bankStatementHandler.ListImports).Methods("GET")
+		bankAPI.HandleFunc("/lines", bankStatementHandler.ListLines).Methods("GET")
+		bankAPI.HandleFunc("/lines/{id}/assign", bankStatementHandler.AssignLine).Methods("POST")
+		bankAPI.HandleFunc("/lines/{id}/ignore", bankStatementHandler.IgnoreLine).Methods("POST")
+	}
+
+	// Protected API routes - Payment allocation to thocks
+	if paymentAllocationHandler != nil {
+		allocAPI := r.PathPrefix("/api/allocations").Subrouter()
+		allocAPI.Use(authMiddleware.Authenticate)
+		allocAPI.Use(authMiddleware.RequireAccountantAccess)
+		allocAPI.HandleFunc("/customer/{phone}", paymentAllocationHandler.GetCustomerSummary).Methods("GET")
+		allocAPI.HandleFunc("/payment/{id}", paymentAllocationHandler.GetPaymentAllocations).Methods("GET")
+		allocAPI.HandleFunc("/payment/{id}", paymentAllocationHandler.AllocatePayment).Methods("POST")
+	}
+
+	// Protected API routes - Customer wallet (advance deposits)
+	if walletHandler != nil {
+		walletAPI := r.PathPrefix("/api/wallet").Subrouter()
+		walletAPI.Use(authMiddleware.Authenticate)
+		walletAPI.Use(authMiddleware.RequireAccountantAccess)
+		walletAPI.HandleFunc("/balances", walletHandler.ListBalances).Methods("GET")
+		walletAPI.HandleFunc("/customer/{phone}", walletHandler.GetCustomerWallet).Methods("GET")
+		walletAPI.HandleFunc("/deposit", walletHandler.Deposit).Methods("POST")
+		walletAPI.HandleFunc("/refund", walletHandler.Refund).Methods("POST")
+	}
+
+	// Protected API routes - Payment links sent to debtors
+	if paymentLinkHandler != nil {
+		linkAPI := r.PathPrefix("/api/payment-links").Subrouter()
+		linkAPI.Use(authMiddleware.Authenticate)
+		linkAPI.Use(authMiddleware.RequireAccountantAccess)
+		linkAPI.HandleFunc("/latest", paymentLinkHandler.ListLatest).Methods("GET")
+		linkAPI.HandleFunc("", paymentLinkHandler.SendLink).Methods("POST")
+		linkAPI.HandleFunc("/{id}/cancel", paymentLinkHandler.CancelLink).Methods("POST")
+	}
+
+	// Protected API routes - Payment gateway settlement reconciliation
+	if settlementHandler != nil {
+		settlementAPI := r.PathPrefix("/api/settlements").Subrouter()
+		settlementAPI.Use(authMiddleware.Authenticate)
+		settlementAPI.Use(authMiddleware.RequireAccountantAccess)
+		settlementAPI.HandleFunc("/import", settlementHandler.ImportReport).Methods("POST")
+		settlementAPI.HandleFunc("/fetch", settlementHandler.FetchReport).Methods("POST")
+		settlementAPI.HandleFunc("/imports", settlementHandler.ListImports).Methods("GET")
+		settlementAPI.HandleFunc("/lines", settlementHandler.ListLines).Methods("GET")
+		settlementAPI.HandleFunc("/report", settlementHandler.GetReport).Methods("GET")
 	}
 
 	// Protected API routes - Debt Requests (debt approval workflow)
@@ -652,6 +874,10 @@ func NewRouter(
 		onlineTxAPI.HandleFunc("", authMiddleware.RequireAccountantAccess(http.HandlerFunc(razorpayHandler.GetAllTransactions)).ServeHTTP).Methods("GET")
 		onlineTxAPI.HandleFunc("/summary", authMiddleware.RequireAccountantAccess(http.HandlerFunc(razorpayHandler.GetTransactionSummary)).ServeHTTP).Methods("GET")
 		onlineTxAPI.HandleFunc("/reconcile", authMiddleware.RequireRole("admin")(http.HandlerFunc(razorpayHandler.ReconcilePayments)).ServeHTTP).Methods("POST")
+		onlineTxAPI.HandleFunc("/refunds", authMiddleware.RequireAccountantAccess(http.HandlerFunc(razorpayHandler.ListRefunds)).ServeHTTP).Methods("GET")
+		onlineTxAPI.HandleFunc("/refunds/{id}/approve", authMiddleware.RequireRole("admin")(http.HandlerFunc(razorpayHandler.ApproveRefund)).ServeHTTP).Methods("POST")
+		onlineTxAPI.HandleFunc("/refunds/{id}/reject", authMiddleware.RequireRole("admin")(http.HandlerFunc(razorpayHandler.RejectRefund)).ServeHTTP).Methods("POST")
+		onlineTxAPI.HandleFunc("/{id}/refund", authMiddleware.RequireAccountantAccess(http.HandlerFunc(razorpayHandler.RequestRefund)).ServeHTTP).Methods("POST")
 	}
 
 	// Protected API routes - Pending Setting Changes (dual admin approval for sensitive settings)
@@ -719,6 +945,46 @@ func NewRouter(
 		vizAPI.HandleFunc("/gatar-search", roomVisualizationHandler.SearchByGatar).Methods("GET")
 	}
 
+	// Protected API routes - Warehouse layout (rooms, floors, gatar ranges and capacities)
+	if warehouseLayoutHandler != nil {
+		layoutAPI := r.PathPrefix("/api/warehouse-layout").Subrouter()
+		layoutAPI.Use(authMiddleware.Authenticate)
+		// Any authenticated user can read the layout (room entry and floor plan pages)
+		layoutAPI.HandleFunc("", warehouseLayoutHandler.GetLayout).Methods("GET")
+		layoutAPI.HandleFunc("/grid", warehouseLayoutHandler.GetGrid).Methods("GET")
+		// Admin only - edit the layout
+		layoutAPI.HandleFunc("/rooms/{room_no}", authMiddleware.RequireAdmin(http.HandlerFunc(warehouseLayoutHandler.SaveRoom)).ServeHTTP).Methods("PUT")
+		layoutAPI.HandleFunc("/rooms/{room_no}", authMiddleware.RequireAdmin(http.HandlerFunc(warehouseLayoutHandler.DeleteRoom)).ServeHTTP).Methods("DELETE")
+		layoutAPI.HandleFunc("/gatars/{gatar_no}/capacity", authMiddleware.RequireAdmin(http.HandlerFunc(warehouseLayoutHandler.SetGatarCapacity)).ServeHTTP).Methods("PUT")
+	}
+
+	// Protected API routes - Gatar movements (stock shifting between gatars)
+	if gatarMovementHandler != nil {
+		movementAPI := r.PathPrefix("/api/gatar-movements").Subrouter()
+		movementAPI.Use(authMiddleware.Authenticate)
+		// All authenticated users can view the movement ledgers
+		movementAPI.HandleFunc("", gatarMovementHandler.ListByThock).Methods("GET")
+		movementAPI.HandleFunc("/gatar/{gatar_no}", gatarMovementHandler.GetGatarLedger).Methods("GET")
+		// Moving stock - employees and admins
+		movementAPI.HandleFunc("", authMiddleware.RequireRole("employee", "admin")(http.HandlerFunc(gatarMovementHandler.Transfer)).ServeHTTP).Methods("POST")
+	}
+
+	// Protected API routes - Gatar maintenance (bag-turning, ventilation and inspection)
+	if gatarMaintenanceHandler != nil {
+		maintenanceAPI := r.PathPrefix("/api/gatar-maintenance").Subrouter()
+		maintenanceAPI.Use(authMiddleware.Authenticate)
+		// All authenticated users can view schedules, due gatars and logs
+		maintenanceAPI.HandleFunc("/schedules", gatarMaintenanceHandler.ListSchedules).Methods("GET")
+		maintenanceAPI.HandleFunc("/status", gatarMaintenanceHandler.GetStatus).Methods("GET")
+		maintenanceAPI.HandleFunc("/logs", gatarMaintenanceHandler.ListLogs).Methods("GET")
+		// Recording completed work - employees and admins
+		maintenanceAPI.HandleFunc("/complete", authMiddleware.RequireRole("employee", "admin")(http.HandlerFunc(gatarMaintenanceHandler.Complete)).ServeHTTP).Methods("POST")
+		// Admin only - manage schedules
+		maintenanceAPI.HandleFunc("/schedules", authMiddleware.RequireAdmin(http.HandlerFunc(gatarMaintenanceHandler.CreateSchedule)).ServeHTTP).Methods("POST")
+		maintenanceAPI.HandleFunc("/schedules/{id}", authMiddleware.RequireAdmin(http.HandlerFunc(gatarMaintenanceHandler.UpdateSchedule)).ServeHTTP).Methods("PUT")
+		maintenanceAPI.HandleFunc("/schedules/{id}", authMiddleware.RequireAdmin(http.HandlerFunc(gatarMaintenanceHandler.DeleteSchedule)).ServeHTTP).Methods("DELETE")
+	}
+
 	// Health endpoints (basic health for K8s probes, detailed requires auth)
 	r.HandleFunc("/health", healthHandler.BasicHealth).Methods("GET")
 	r.HandleFunc("/health/ready", healthHandler.ReadinessHealth).Methods("GET")
@@ -738,6 +1004,7 @@ func NewCustomerRouter(
 	healthHandler *handlers.HealthHandler,
 	authMiddleware *middleware.AuthMiddleware,
 	razorpayHandler *handlers.RazorpayHandler,
+	paymentLinkHandler *handlers.PaymentLinkHandler,
 ) *mux.Router {
 	r := mux.NewRouter()
 
@@ -783,9 +1050,18 @@ func NewCustomerRouter(
 		customerAPI.HandleFunc("/payment/transactions", razorpayHandler.GetMyTransactions).Methods("GET")
 	}
 
-	// Razorpay webhook (no JWT auth - uses signature verification)
+	// Payment gateway webhooks (no JWT auth - uses signature verification)
 	if razorpayHandler != nil {
-		r.HandleFunc("/api/payment/webhook", razorpayHandler.HandleWebhook).Methods("POST")
+		r.HandleFunc("/api/payment/webhook", razorpayHandler.HandleWebhook).Methods("POST") // Razorpay
+		r.HandleFunc("/api/payment/webhook/{gateway}", razorpayHandler.HandleWebhook).Methods("POST")
+	}
+
+	// Payment links (no login - the link token is the credential)
+	if paymentLinkHandler != nil {
+		r.HandleFunc("/pay/{token}", pageHandler.PaymentLinkPage).Methods("GET")
+		r.HandleFunc("/api/pay/{token}", paymentLinkHandler.GetCheckout).Methods("GET")
+		r.HandleFunc("/api/pay/{token}/create-order", paymentLinkHandler.CreateOrder).Methods("POST")
+		r.HandleFunc("/api/pay/{token}/verify", paymentLinkHandler.VerifyPayment).Methods("POST")
 	}
 
 	// Health endpoints - only basic health for K8s probes on customer portal
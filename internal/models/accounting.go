@@ -0,0 +1,147 @@
+package models
+
+import "time"
+
+// Account types
+const (
+	AccountTypeAsset     = "asset"
+	AccountTypeLiability = "liability"
+	AccountTypeEquity    = "equity"
+	AccountTypeIncome    = "income"
+	AccountTypeExpense   = "expense"
+)
+
+// System account codes used by automatic postings
+const (
+	AccountCodeCash             = "1000"
+	AccountCodeBank             = "1010"
+	AccountCodeRazorpayClearing = "1020"
+	AccountCodeReceivables      = "1100"
+	AccountCodeOwnerCapital     = "3000"
+	AccountCodeRentIncome       = "4000"
+	AccountCodeInterestIncome   = "4010"
+	AccountCodeDiscounts        = "5000"
+	AccountCodeGatewayCharges   = "5010"
+)
+
+// Journal source types
+const (
+	JournalSourceLedgerEntry = "ledger_entry"
+	JournalSourceManual      = "manual"
+)
+
+// Account is an entry in the chart of accounts
+type Account struct {
+	ID          int       `json:"id"`
+	Code        string    `json:"code"`
+	Name        string    `json:"name"`
+	AccountType string    `json:"account_type"`
+	IsSystem    bool      `json:"is_system"`
+	IsActive    bool      `json:"is_active"`
+	Description string    `json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// CreateAccountRequest adds an account to the chart of accounts
+type CreateAccountRequest struct {
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	AccountType string `json:"account_type"`
+	Description string `json:"description"`
+}
+
+// JournalEntry is a balanced double-entry posting
+type JournalEntry struct {
+	ID              int           `json:"id"`
+	EntryDate       time.Time     `json:"entry_date"`
+	Description     string        `json:"description"`
+	SourceType      string        `json:"source_type"`
+	SourceID        *int          `json:"source_id,omitempty"`
+	CustomerPhone   string        `json:"customer_phone,omitempty"`
+	CreatedByUserID int           `json:"created_by_user_id"`
+	CreatedByName   string        `json:"created_by_name,omitempty"`
+	CreatedAt       time.Time     `json:"created_at"`
+	Lines           []JournalLine `json:"lines"`
+}
+
+// JournalLine is one debit or credit within a journal entry
+type JournalLine struct {
+	ID          int     `json:"id"`
+	AccountID   int     `json:"account_id"`
+	AccountCode string  `json:"account_code"`
+	AccountName string  `json:"account_name"`
+	Debit       float64 `json:"debit"`
+	Credit      float64 `json:"credit"`
+	Description string  `json:"description,omitempty"`
+}
+
+// CreateJournalRequest records a manual journal (expenses, capital, adjustments)
+type CreateJournalRequest struct {
+	EntryDate   string                   `json:"entry_date"` // YYYY-MM-DD, defaults to today
+	Description string                   `json:"description"`
+	Lines       []CreateJournalLineInput `json:"lines"`
+}
+
+// CreateJournalLineInput is a manual journal line, identified by account code
+type CreateJournalLineInput struct {
+	AccountCode string  `json:"account_code"`
+	Debit       float64 `json:"debit"`
+	Credit      float64 `json:"credit"`
+	Description string  `json:"description"`
+}
+
+// JournalFilter filters the journal list
+type JournalFilter struct {
+	AccountCode string
+	StartDate   *time.Time
+	EndDate     *time.Time
+	Limit       int
+	Offset      int
+}
+
+// AccountBalance is an account's debit and credit totals for a period
+type AccountBalance struct {
+	Account
+	Debit   float64 `json:"debit"`
+	Credit  float64 `json:"credit"`
+	Balance float64 `json:"balance"` // In the account's normal direction (debit for assets/expenses)
+}
+
+// TrialBalance lists every account's totals; total debits equal total credits
+type TrialBalance struct {
+	AsOf        time.Time        `json:"as_of"`
+	Accounts    []AccountBalance `json:"accounts"`
+	TotalDebit  float64          `json:"total_debit"`
+	TotalCredit float64          `json:"total_credit"`
+	Balanced    bool             `json:"balanced"`
+}
+
+// ProfitAndLoss is income less expenses for a period
+type ProfitAndLoss struct {
+	From          time.Time        `json:"from"`
+	To            time.Time        `json:"to"`
+	Income        []AccountBalance `json:"income"`
+	Expenses      []AccountBalance `json:"expenses"`
+	TotalIncome   float64          `json:"total_income"`
+	TotalExpenses float64          `json:"total_expenses"`
+	NetProfit     float64          `json:"net_profit"`
+}
+
+// BalanceSheet is assets against liabilities and equity at a date
+type BalanceSheet struct {
+	AsOf             time.Time        `json:"as_of"`
+	Assets           []AccountBalance `json:"assets"`
+	Liabilities      []AccountBalance `json:"liabilities"`
+	Equity           []AccountBalance `json:"equity"`
+	RetainedEarnings float64          `json:"retained_earnings"` // Net profit to date not yet closed to equity
+	TotalAssets      float64          `json:"total_assets"`
+	TotalLiabilities float64          `json:"total_liabilities"`
+	TotalEquity      float64          `json:"total_equity"` // Includes retained earnings
+	Balanced         bool             `json:"balanced"`
+}
+
+// JournalSyncResult reports ledger entries posted by a journal backfill
+type JournalSyncResult struct {
+	Posted  int `json:"posted"`
+	Skipped int `json:"skipped"` // Entries with no money movement (e.g. DEBT_APPROVAL)
+}
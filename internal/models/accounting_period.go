@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+// Accounting period statuses
+const (
+	AccountingPeriodStatusClosed = "closed"
+	AccountingPeriodStatusOpen   = "open" // Reopened after approval
+)
+
+// Period reopen request statuses
+const (
+	PeriodReopenStatusPending  = "pending"
+	PeriodReopenStatusApproved = "approved"
+	PeriodReopenStatusRejected = "rejected"
+	PeriodReopenStatusExpired  = "expired"
+)
+
+// AccountingPeriod is one month of the April-March fiscal year
+type AccountingPeriod struct {
+	ID                  int        `json:"id"`
+	FiscalYear          string     `json:"fiscal_year"`   // e.g. 2025-26
+	PeriodNumber        int        `json:"period_number"` // 1 = April ... 12 = March
+	StartDate           time.Time  `json:"start_date"`
+	EndDate             time.Time  `json:"end_date"`
+	Status              string     `json:"status"`
+	ClosedAt            *time.Time `json:"closed_at,omitempty"`
+	ClosedByUserID      *int       `json:"closed_by_user_id,omitempty"`
+	ClosedByName        string     `json:"closed_by_name,omitempty"`
+	ReopenedAt          *time.Time `json:"reopened_at,omitempty"`
+	ReopenedByUserID    *int       `json:"reopened_by_user_id,omitempty"`
+	CustomerCount       int        `json:"customer_count"` // Balances in the closing snapshot
+	TotalClosingBalance float64    `json:"total_closing_balance"`
+}
+
+// AccountingPeriodBalance is a customer's balance snapshot at period close
+type AccountingPeriodBalance struct {
+	PeriodID       int     `json:"period_id"`
+	CustomerPhone  string  `json:"customer_phone"`
+	CustomerName   string  `json:"customer_name"`
+	TotalDebit     float64 `json:"total_debit"`
+	TotalCredit    float64 `json:"total_credit"`
+	ClosingBalance float64 `json:"closing_balance"`
+}
+
+// ClosePeriodRequest closes every open month up to and including Period (YYYY-MM),
+// or the whole fiscal year when FiscalYear (e.g. 2025-26) is given instead
+type ClosePeriodRequest struct {
+	Period     string `json:"period,omitempty"`
+	FiscalYear string `json:"fiscal_year,omitempty"`
+}
+
+// PeriodReopenRequest asks a second admin to reopen a closed period
+type PeriodReopenRequest struct {
+	ID              int        `json:"id"`
+	PeriodID        int        `json:"period_id"`
+	FiscalYear      string     `json:"fiscal_year,omitempty"`
+	PeriodStart     time.Time  `json:"period_start"`
+	Reason          string     `json:"reason"`
+	RequestedBy     int        `json:"requested_by"`
+	RequestedByName string     `json:"requested_by_name,omitempty"`
+	RequestedAt     time.Time  `json:"requested_at"`
+	Status          string     `json:"status"`
+	ReviewedBy      *int       `json:"reviewed_by,omitempty"`
+	ReviewedByName  string     `json:"reviewed_by_name,omitempty"`
+	ReviewedAt      *time.Time `json:"reviewed_at,omitempty"`
+	RejectionReason string     `json:"rejection_reason,omitempty"`
+	ExpiresAt       time.Time  `json:"expires_at"`
+}
+
+// CreatePeriodReopenRequest is the body for requesting a reopen
+type CreatePeriodReopenRequest struct {
+	Reason string `json:"reason"`
+}
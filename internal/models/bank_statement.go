@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Bank statement line statuses
+const (
+	BankLineStatusMatched   = "matched"   // Reconciled automatically on import
+	BankLineStatusUnmatched = "unmatched" // Waiting in the reconciliation queue
+	BankLineStatusAssigned  = "assigned"  // Assigned to a customer by the accountant
+	BankLineStatusIgnored   = "ignored"   // Not a customer payment (interest, transfers, ...)
+)
+
+// Bank statement match types
+const (
+	BankMatchOnlineTransaction = "online_transaction"
+	BankMatchRentPayment       = "rent_payment"
+	BankMatchCheque            = "cheque"
+	BankMatchManual            = "manual"
+)
+
+// BankStatementImport is one uploaded statement file
+type BankStatementImport struct {
+	ID               int       `json:"id"`
+	FileName         string    `json:"file_name"`
+	CreditCount      int       `json:"credit_count"`
+	DuplicateCount   int       `json:"duplicate_count"`
+	MatchedCount     int       `json:"matched_count"`
+	UnmatchedCount   int       `json:"unmatched_count"`
+	ImportedByUserID int       `json:"imported_by_user_id"`
+	ImportedByName   string    `json:"imported_by_name,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// BankStatementLine is a credit from an imported statement
+type BankStatementLine struct {
+	ID                  int        `json:"id"`
+	ImportID            int        `json:"import_id"`
+	TxnDate             time.Time  `json:"txn_date"`
+	Narration           string     `json:"narration"`
+	Reference           string     `json:"reference,omitempty"`
+	Amount              float64    `json:"amount"`
+	Fingerprint         string     `json:"-"`
+	Status              string     `json:"status"`
+	MatchType           string     `json:"match_type,omitempty"`
+	OnlineTransactionID *int       `json:"online_transaction_id,omitempty"`
+	RentPaymentID       *int       `json:"rent_payment_id,omitempty"`
+	LedgerEntryID       *int       `json:"ledger_entry_id,omitempty"`
+	CustomerPhone       string     `json:"customer_phone,omitempty"`
+	CustomerName        string     `json:"customer_name,omitempty"`
+	SuggestedPhone      string     `json:"suggested_phone,omitempty"` // Customer whose phone appears in the narration
+	SuggestedName       string     `json:"suggested_name,omitempty"`
+	ResolvedByUserID    *int       `json:"resolved_by_user_id,omitempty"`
+	ResolvedAt          *time.Time `json:"resolved_at,omitempty"`
+	Notes               string     `json:"notes,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+// AssignBankLineRequest assigns an unmatched credit to a customer
+type AssignBankLineRequest struct {
+	CustomerPhone    string `json:"customer_phone"`
+	FamilyMemberID   *int   `json:"family_member_id,omitempty"`
+	FamilyMemberName string `json:"family_member_name,omitempty"`
+	Notes            string `json:"notes,omitempty"`
+}
+
+// IgnoreBankLineRequest removes a credit that is not a customer payment from the queue
+type IgnoreBankLineRequest struct {
+	Notes string `json:"notes"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Cash session statuses
+const (
+	CashSessionStatusOpen   = "open"
+	CashSessionStatusClosed = "closed"
+)
+
+// CashDenominationValues are the notes and coins accepted in a closing count
+var CashDenominationValues = []int{2000, 500, 200, 100, 50, 20, 10, 5, 2, 1}
+
+// CashSession is one accountant's cash drawer from opening float to closing count
+type CashSession struct {
+	ID            int                `json:"id"`
+	UserID        int                `json:"user_id"`
+	UserName      string             `json:"user_name,omitempty"`
+	Status        string             `json:"status"`
+	OpeningFloat  float64            `json:"opening_float"`
+	OpenedAt      time.Time          `json:"opened_at"`
+	ClosedAt      *time.Time         `json:"closed_at,omitempty"`
+	PaymentCount  int                `json:"payment_count"`
+	CashReceived  float64            `json:"cash_received"`
+	CashPaidOut   float64            `json:"cash_paid_out"` // Wallet refunds paid in cash
+	ExpectedCash  float64            `json:"expected_cash"`
+	CountedCash   *float64           `json:"counted_cash,omitempty"`
+	Difference    *float64           `json:"difference,omitempty"` // Counted - expected (negative = short)
+	Denominations []CashDenomination `json:"denominations,omitempty"`
+	ClosingNotes  string             `json:"closing_notes,omitempty"`
+}
+
+// CashDenomination is the count of one note or coin value
+type CashDenomination struct {
+	Value int `json:"value"`
+	Count int `json:"count"`
+}
+
+// OpenCashSessionRequest opens the caller's drawer
+type OpenCashSessionRequest struct {
+	OpeningFloat float64 `json:"opening_float"`
+}
+
+// CloseCashSessionRequest closes the caller's drawer with a denomination count
+type CloseCashSessionRequest struct {
+	Denominations []CashDenomination `json:"denominations"`
+	Notes         string             `json:"notes,omitempty"`
+}
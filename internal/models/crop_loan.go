@@ -0,0 +1,88 @@
+package models
+
+import "time"
+
+// Crop loan statuses
+const (
+	CropLoanStatusActive  = "active"
+	CropLoanStatusSettled = "settled"
+)
+
+// CropLoan is a cash advance against a customer's stored stock (thock)
+type CropLoan struct {
+	ID                   int        `json:"id"`
+	CustomerID           int        `json:"customer_id"`
+	CustomerPhone        string     `json:"customer_phone"`
+	CustomerName         string     `json:"customer_name"`
+	CustomerSO           string     `json:"customer_so"`
+	FamilyMemberID       *int       `json:"family_member_id,omitempty"`
+	FamilyMemberName     string     `json:"family_member_name,omitempty"`
+	EntryID              int        `json:"entry_id"`
+	ThockNumber          string     `json:"thock_number"`
+	PledgedQuantity      int        `json:"pledged_quantity"`
+	ValuePerBag          float64    `json:"value_per_bag"`
+	MaxLTVPercent        float64    `json:"max_ltv_percent"`
+	Principal            float64    `json:"principal"`
+	InterestRateMonthly  float64    `json:"interest_rate_monthly"`
+	PrincipalOutstanding float64    `json:"principal_outstanding"`
+	InterestOutstanding  float64    `json:"interest_outstanding"`
+	TotalInterest        float64    `json:"total_interest"`
+	TotalRepaid          float64    `json:"total_repaid"`
+	InterestAccruedTo    time.Time  `json:"interest_accrued_to"`
+	Status               string     `json:"status"`
+	LedgerEntryID        *int       `json:"ledger_entry_id,omitempty"`
+	Notes                string     `json:"notes,omitempty"`
+	DisbursedByUserID    int        `json:"disbursed_by_user_id"`
+	DisbursedByName      string     `json:"disbursed_by_name,omitempty"`
+	DisbursedAt          time.Time  `json:"disbursed_at"`
+	SettledAt            *time.Time `json:"settled_at,omitempty"`
+	CreatedAt            time.Time  `json:"created_at"`
+	UpdatedAt            time.Time  `json:"updated_at"`
+}
+
+// CropLoanRepayment is a repayment against a crop loan
+type CropLoanRepayment struct {
+	ID               int       `json:"id"`
+	LoanID           int       `json:"loan_id"`
+	Amount           float64   `json:"amount"`
+	InterestPortion  float64   `json:"interest_portion"`
+	PrincipalPortion float64   `json:"principal_portion"`
+	LedgerEntryID    *int      `json:"ledger_entry_id,omitempty"`
+	ReceivedByUserID int       `json:"received_by_user_id"`
+	ReceivedByName   string    `json:"received_by_name,omitempty"`
+	Notes            string    `json:"notes,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// CreateCropLoanRequest is used to disburse a loan against a thock
+type CreateCropLoanRequest struct {
+	ThockNumber         string   `json:"thock_number"`
+	Principal           float64  `json:"principal"`
+	PledgedQuantity     int      `json:"pledged_quantity"`      // 0 = all bags currently stored
+	InterestRateMonthly *float64 `json:"interest_rate_monthly"` // nil = loan_interest_rate_monthly setting
+	Notes               string   `json:"notes"`
+}
+
+// CropLoanRepaymentRequest is used to record a repayment
+type CropLoanRepaymentRequest struct {
+	Amount float64 `json:"amount"`
+	Notes  string  `json:"notes"`
+}
+
+// CropLoanEligibility is the loan-to-value position of a thock
+type CropLoanEligibility struct {
+	ThockNumber         string  `json:"thock_number"`
+	StoredQuantity      int     `json:"stored_quantity"` // Bags still in storage
+	ValuePerBag         float64 `json:"value_per_bag"`
+	MaxLTVPercent       float64 `json:"max_ltv_percent"`
+	StockValue          float64 `json:"stock_value"`
+	MaxLoan             float64 `json:"max_loan"`
+	ExistingOutstanding float64 `json:"existing_outstanding"` // Principal still owed on active loans for the thock
+	AvailableLoan       float64 `json:"available_loan"`
+}
+
+// CropLoanDetail is a loan with its repayment history
+type CropLoanDetail struct {
+	Loan       *CropLoan            `json:"loan"`
+	Repayments []*CropLoanRepayment `json:"repayments"`
+}
@@ -15,23 +15,24 @@ const (
 
 // DebtRequest represents a request for item withdrawal when customer has outstanding balance
 type DebtRequest struct {
-	ID                  int               `json:"id"`
-	CustomerPhone       string            `json:"customer_phone"`
-	CustomerName        string            `json:"customer_name"`
-	CustomerSO          string            `json:"customer_so"` // S/O (Son Of / Father's Name)
-	ThockNumber         string            `json:"thock_number"`
-	RequestedQuantity   int               `json:"requested_quantity"`
-	CurrentBalance      float64           `json:"current_balance"` // How much customer owes at time of request
-	RequestedByUserID   int               `json:"requested_by_user_id"`
-	RequestedByName     string            `json:"requested_by_name"`
-	Status              DebtRequestStatus `json:"status"`
-	ApprovedByUserID    *int              `json:"approved_by_user_id"`
-	ApprovedByName      string            `json:"approved_by_name"`
-	ApprovedAt          *time.Time        `json:"approved_at"`
-	RejectionReason     string            `json:"rejection_reason"`
-	GatePassID          *int              `json:"gate_pass_id"` // Linked gate pass after approval is used
-	CreatedAt           time.Time         `json:"created_at"`
-	ExpiresAt           *time.Time        `json:"expires_at"` // Request expires after 24 hours
+	ID                int               `json:"id"`
+	CustomerPhone     string            `json:"customer_phone"`
+	CustomerName      string            `json:"customer_name"`
+	CustomerSO        string            `json:"customer_so"` // S/O (Son Of / Father's Name)
+	ThockNumber       string            `json:"thock_number"`
+	RequestedQuantity int               `json:"requested_quantity"`
+	CurrentBalance    float64           `json:"current_balance"` // How much customer owes at time of request
+	RequestedByUserID int               `json:"requested_by_user_id"`
+	RequestedByName   string            `json:"requested_by_name"`
+	Status            DebtRequestStatus `json:"status"`
+	ApprovedByUserID  *int              `json:"approved_by_user_id"`
+	ApprovedByName    string            `json:"approved_by_name"`
+	ApprovedAt        *time.Time        `json:"approved_at"`
+	RejectionReason   string            `json:"rejection_reason"`
+	GatePassID        *int              `json:"gate_pass_id"` // Linked gate pass after approval is used
+	CreatedAt         time.Time         `json:"created_at"`
+	ExpiresAt         *time.Time        `json:"expires_at"`   // Request expires after 24 hours
+	CropLoanID        *int              `json:"crop_loan_id"` // Crop loan the thock was pledged to when requested
 }
 
 // CreateDebtRequestRequest is used when creating a new debt request
@@ -42,6 +43,7 @@ type CreateDebtRequestRequest struct {
 	ThockNumber       string  `json:"thock_number" validate:"required"`
 	RequestedQuantity int     `json:"requested_quantity" validate:"required,gt=0"`
 	CurrentBalance    float64 `json:"current_balance" validate:"required,gt=0"`
+	CropLoanID        *int    `json:"-"` // Set from the thock's active crop loan
 }
 
 // ApproveDebtRequestRequest is used when approving a debt request
@@ -15,11 +15,13 @@ type Entry struct {
 	ActualQuantity          int        `json:"actual_quantity"` // Sum of room_entries.quantity for this entry
 	ThockCategory           string     `json:"thock_category"`  // 'seed' or 'sell'
 	ThockNumber             string     `json:"thock_number"`
-	Remark                  string     `json:"remark"` // Variety/varieties (comma-separated): Chipsona 1, Chipsona 3, 3797, S4, etc.
-	Status                  string     `json:"status"`                     // 'active', 'transferred', 'deleted'
-	TransferredToCustomerID *int       `json:"transferred_to_customer_id"` // If transferred, points to new customer
-	TransferredAt           *time.Time `json:"transferred_at"`             // When transfer happened
-	DeletedAt               *time.Time `json:"deleted_at,omitempty"`       // Soft delete timestamp
+	Remark                  string     `json:"remark"`                       // Variety/varieties (comma-separated): Chipsona 1, Chipsona 3, 3797, S4, etc.
+	BagSize                 string     `json:"bag_size,omitempty"`           // Bag size (e.g. 50kg) - used for rent tariff lookup
+	Season                  string     `json:"season,omitempty"`             // Storage season the entry came in (current_season when created)
+	Status                  string     `json:"status"`                       // 'active', 'transferred', 'deleted'
+	TransferredToCustomerID *int       `json:"transferred_to_customer_id"`   // If transferred, points to new customer
+	TransferredAt           *time.Time `json:"transferred_at"`               // When transfer happened
+	DeletedAt               *time.Time `json:"deleted_at,omitempty"`         // Soft delete timestamp
 	DeletedByUserID         *int       `json:"deleted_by_user_id,omitempty"` // Who deleted it
 	CreatedByUserID         int        `json:"created_by_user_id"`
 	CreatedByName           string     `json:"created_by_name,omitempty"` // Employee name who created this entry
@@ -38,6 +40,7 @@ type CreateEntryRequest struct {
 	ExpectedQuantity int    `json:"expected_quantity"`
 	ThockCategory    string `json:"thock_category"`
 	Remark           string `json:"remark"` // Variety/varieties (comma-separated)
+	BagSize          string `json:"bag_size,omitempty"`
 }
 
 // UpdateEntryRequest represents the request body for updating an entry
@@ -49,6 +52,7 @@ type UpdateEntryRequest struct {
 	ExpectedQuantity int    `json:"expected_quantity"`
 	Remark           string `json:"remark"`
 	ThockCategory    string `json:"thock_category"`
+	BagSize          string `json:"bag_size,omitempty"`
 }
 
 // ReassignEntryRequest represents the request body for reassigning an entry to a different customer
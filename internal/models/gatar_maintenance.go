@@ -0,0 +1,84 @@
+package models
+
+import "time"
+
+// Gatar maintenance tasks
+const (
+	MaintenanceTaskTurning     = "turning"
+	MaintenanceTaskVentilation = "ventilation"
+	MaintenanceTaskInspection  = "inspection"
+)
+
+// MaintenanceTasks lists the tasks in the order they are shown
+var MaintenanceTasks = []string{MaintenanceTaskTurning, MaintenanceTaskVentilation, MaintenanceTaskInspection}
+
+// Room entry gatar quality codes
+const (
+	QualityNormal  = "N"
+	QualityUnka    = "U"
+	QualityDamaged = "D"
+	QualityGood    = "G"
+)
+
+// GatarMaintenanceSchedule says how often a task is done in a room, one floor of it
+// (Floor set) or a single gatar (GatarNo set)
+type GatarMaintenanceSchedule struct {
+	ID              int       `json:"id"`
+	RoomNo          string    `json:"room_no"`
+	Floor           string    `json:"floor"`    // "" = every floor
+	GatarNo         int       `json:"gatar_no"` // 0 = every gatar on the floor
+	Task            string    `json:"task"`
+	IntervalDays    int       `json:"interval_days"`
+	AssignedCrew    string    `json:"assigned_crew"`
+	Notes           string    `json:"notes"`
+	Active          bool      `json:"active"`
+	CreatedByUserID int       `json:"created_by_user_id"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// GatarMaintenanceLog is a task done on one room entry gatar row
+type GatarMaintenanceLog struct {
+	ID               int       `json:"id"`
+	ScheduleID       int       `json:"schedule_id"`
+	GatarNo          int       `json:"gatar_no"`
+	RoomEntryGatarID int       `json:"room_entry_gatar_id"`
+	RoomEntryID      int       `json:"room_entry_id"`
+	ThockNumber      string    `json:"thock_number"`
+	Task             string    `json:"task"`
+	PreviousQuality  string    `json:"previous_quality"`
+	ObservedQuality  string    `json:"observed_quality"`
+	Crew             string    `json:"crew"`
+	Notes            string    `json:"notes"`
+	DoneByUserID     int       `json:"done_by_user_id"`
+	DoneByName       string    `json:"done_by_name,omitempty"`
+	DoneAt           time.Time `json:"done_at"`
+}
+
+// CompleteMaintenanceRequest records a task done in a gatar. It covers every thock in
+// the gatar unless RoomEntryGatarID picks one. Quality (N/U/D/G) updates that thock's
+// quality code and needs RoomEntryGatarID, as a grade seen on one thock says nothing of
+// the others; blank leaves it as it is.
+type CompleteMaintenanceRequest struct {
+	GatarNo          int    `json:"gatar_no"`
+	Task             string `json:"task"`
+	RoomEntryGatarID int    `json:"room_entry_gatar_id"`
+	Quality          string `json:"quality"`
+	Crew             string `json:"crew"`
+	Notes            string `json:"notes"`
+}
+
+// GatarMaintenanceStatus is when a stocked gatar is next due for a task
+type GatarMaintenanceStatus struct {
+	GatarNo      int        `json:"gatar_no"`
+	RoomNo       string     `json:"room_no"`
+	Floor        string     `json:"floor"`
+	Task         string     `json:"task"`
+	ScheduleID   int        `json:"schedule_id"`
+	IntervalDays int        `json:"interval_days"`
+	AssignedCrew string     `json:"assigned_crew"`
+	LastDoneAt   *time.Time `json:"last_done_at"`
+	DueAt        time.Time  `json:"due_at"`
+	Overdue      bool       `json:"overdue"`
+	DaysOverdue  int        `json:"days_overdue"`
+}
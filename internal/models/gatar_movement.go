@@ -0,0 +1,69 @@
+package models
+
+import "time"
+
+// Gatar movement reasons
+const (
+	MovementReasonVentilation   = "ventilation"
+	MovementReasonRestacking    = "restacking"
+	MovementReasonConsolidation = "consolidation"
+	MovementReasonOther         = "other"
+)
+
+// EventTypeGatarMoved is the entry event written when a thock's bags change gatar
+const EventTypeGatarMoved = "GATAR_MOVED"
+
+// GatarMovement is bags of a thock shifted from one gatar to another (palti)
+type GatarMovement struct {
+	ID              int       `json:"id"`
+	EntryID         int       `json:"entry_id"`
+	ThockNumber     string    `json:"thock_number"`
+	FromRoomEntryID int       `json:"from_room_entry_id"`
+	FromRoomNo      string    `json:"from_room_no"`
+	FromFloor       string    `json:"from_floor"`
+	FromGatar       int       `json:"from_gatar"`
+	ToRoomEntryID   int       `json:"to_room_entry_id"`
+	ToRoomNo        string    `json:"to_room_no"`
+	ToFloor         string    `json:"to_floor"`
+	ToGatar         int       `json:"to_gatar"`
+	Quantity        int       `json:"quantity"`
+	Reason          string    `json:"reason"`
+	Notes           string    `json:"notes"`
+	MovedByUserID   int       `json:"moved_by_user_id"`
+	MovedByName     string    `json:"moved_by_name,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+
+	// Set on the movement returned by a transfer
+	GatarCapacity    []GatarUtilization `json:"gatar_capacity,omitempty"`
+	CapacityWarnings []string           `json:"capacity_warnings,omitempty"`
+}
+
+// GatarTransferRequest moves Quantity bags of a thock from FromGatar to ToGatar. The
+// destination's room and floor come from the warehouse layout.
+type GatarTransferRequest struct {
+	ThockNumber string `json:"thock_number"`
+	FromGatar   int    `json:"from_gatar"`
+	ToGatar     int    `json:"to_gatar"`
+	Quantity    int    `json:"quantity"`
+	Reason      string `json:"reason"`
+	Notes       string `json:"notes"`
+}
+
+// GatarLedger is the movement history of one gatar, newest first. Change is signed
+// from the gatar's side: bags moved in are positive, bags moved out negative.
+type GatarLedger struct {
+	GatarNo   int                `json:"gatar_no"`
+	RoomNo    string             `json:"room_no"`
+	Floor     string             `json:"floor"`
+	Stock     int                `json:"stock"`
+	Capacity  int                `json:"capacity"`
+	Movements []*GatarLedgerLine `json:"movements"`
+}
+
+// GatarLedgerLine is one movement as seen from a gatar
+type GatarLedgerLine struct {
+	*GatarMovement
+	Direction  string `json:"direction"` // in or out
+	OtherGatar int    `json:"other_gatar"`
+	Change     int    `json:"change"`
+}
@@ -0,0 +1,85 @@
+package models
+
+import "time"
+
+// Settlement line statuses
+const (
+	SettlementStatusMatched    = "matched"    // Settled in full (gross and fee as expected)
+	SettlementStatusShort      = "short"      // Settled less than the transaction amount
+	SettlementStatusUnmatched  = "unmatched"  // No successful online transaction for the payment
+	SettlementStatusAdjustment = "adjustment" // Refund or gateway adjustment, for the record
+)
+
+// SettingSettlementGraceDays is how long after capture a payment may stay unsettled
+// before the report flags it missing
+const SettingSettlementGraceDays = "settlement_grace_days"
+
+// GatewaySettlementImport is one settlement report fetched from a gateway or uploaded
+type GatewaySettlementImport struct {
+	ID               int       `json:"id"`
+	Gateway          string    `json:"gateway"`
+	Source           string    `json:"source"` // api, csv
+	FileName         string    `json:"file_name"`
+	LineCount        int       `json:"line_count"`
+	DuplicateCount   int       `json:"duplicate_count"`
+	MatchedCount     int       `json:"matched_count"`
+	FlaggedCount     int       `json:"flagged_count"`
+	ImportedByUserID int       `json:"imported_by_user_id"`
+	ImportedByName   string    `json:"imported_by_name,omitempty"`
+	CreatedAt        time.Time `json:"created_at"`
+}
+
+// GatewaySettlementLine is a payment, refund or adjustment from a settlement report and
+// the online transaction it settles
+type GatewaySettlementLine struct {
+	ID                  int        `json:"id"`
+	ImportID            int        `json:"import_id"`
+	Gateway             string     `json:"gateway"`
+	LineType            string     `json:"line_type"`
+	EntityID            string     `json:"entity_id"`
+	OrderID             string     `json:"order_id,omitempty"`
+	PaymentID           string     `json:"payment_id,omitempty"`
+	SettlementID        string     `json:"settlement_id,omitempty"`
+	SettlementUTR       string     `json:"settlement_utr,omitempty"`
+	Amount              float64    `json:"amount"`
+	Fee                 float64    `json:"fee"` // Including GST
+	Tax                 float64    `json:"tax"`
+	SettledAmount       float64    `json:"settled_amount"`
+	Settled             bool       `json:"settled"`
+	SettledAt           *time.Time `json:"settled_at,omitempty"`
+	OnlineTransactionID *int       `json:"online_transaction_id,omitempty"`
+	ExpectedFee         *float64   `json:"expected_fee,omitempty"`
+	ExpectedAmount      *float64   `json:"expected_amount,omitempty"`
+	Status              string     `json:"status"`
+	Notes               string     `json:"notes,omitempty"`
+	CustomerName        string     `json:"customer_name,omitempty"`
+	CustomerPhone       string     `json:"customer_phone,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+// SettlementReport summarises settlements of payments captured in a period and lists
+// what needs attention
+type SettlementReport struct {
+	From           time.Time                `json:"from"`
+	To             time.Time                `json:"to"`
+	SettledCount   int                      `json:"settled_count"`
+	GrossAmount    float64                  `json:"gross_amount"`
+	FeeDeducted    float64                  `json:"fee_deducted"` // Including GST
+	TaxDeducted    float64                  `json:"tax_deducted"`
+	FeeCharged     float64                  `json:"fee_charged"`    // Fees customers paid on the settled payments
+	FeeDifference  float64                  `json:"fee_difference"` // Deducted less charged
+	SettledAmount  float64                  `json:"settled_amount"`
+	ShortCount     int                      `json:"short_count"`
+	ShortAmount    float64                  `json:"short_amount"`
+	UnmatchedCount int                      `json:"unmatched_count"`
+	MissingCount   int                      `json:"missing_count"`
+	MissingAmount  float64                  `json:"missing_amount"`
+	Flagged        []*GatewaySettlementLine `json:"flagged"`
+	Missing        []*OnlineTransaction     `json:"missing"` // Captured but not settled after the grace period
+}
+
+// FetchSettlementsRequest fetches a day's settlement report from the gateway
+type FetchSettlementsRequest struct {
+	Gateway string `json:"gateway"` // Defaults to razorpay
+	Date    string `json:"date"`    // YYYY-MM-DD, defaults to yesterday
+}
@@ -0,0 +1,87 @@
+package models
+
+import "time"
+
+// Interest methods
+const (
+	InterestMethodSimple   = "simple"   // Interest on overdue rent only
+	InterestMethodCompound = "compound" // Interest on overdue rent and unpaid interest
+)
+
+// InterestConfig holds the interest settings (system_settings interest_*)
+type InterestConfig struct {
+	Enabled            bool    `json:"enabled"`
+	Method             string  `json:"method"`
+	MonthlyRatePercent float64 `json:"monthly_rate_percent"`
+	GraceDays          int     `json:"grace_days"`
+	MinBalance         float64 `json:"min_balance"`
+	AutoPost           bool    `json:"auto_post"`
+}
+
+// InterestLine is the interest calculated for one customer for a period
+type InterestLine struct {
+	CustomerPhone  string  `json:"customer_phone"`
+	CustomerName   string  `json:"customer_name"`
+	CustomerSO     string  `json:"customer_so"`
+	Balance        float64 `json:"balance"`         // Balance at period end
+	OverdueBalance float64 `json:"overdue_balance"` // Balance older than the grace period
+	InterestBase   float64 `json:"interest_base"`   // Amount interest is charged on
+	Interest       float64 `json:"interest"`
+	Waived         bool    `json:"waived"`
+	WaiverReason   string  `json:"waiver_reason,omitempty"`
+	LedgerEntryID  *int    `json:"ledger_entry_id,omitempty"`
+}
+
+// InterestPreview is the interest for a period, before or after posting
+type InterestPreview struct {
+	Period        string         `json:"period"` // YYYY-MM
+	PeriodEnd     time.Time      `json:"period_end"`
+	OverdueBefore time.Time      `json:"overdue_before"` // Charges on or before this date are overdue
+	Config        InterestConfig `json:"config"`
+	Lines         []InterestLine `json:"lines"`
+	CustomerCount int            `json:"customer_count"` // Customers charged (excludes waived/zero)
+	TotalInterest float64        `json:"total_interest"`
+	Posted        bool           `json:"posted"`
+	RunID         *int           `json:"run_id,omitempty"`
+}
+
+// InterestRun records a posted month
+type InterestRun struct {
+	ID                 int       `json:"id"`
+	Period             string    `json:"period"`
+	PeriodEnd          time.Time `json:"period_end"`
+	Method             string    `json:"method"`
+	MonthlyRatePercent float64   `json:"monthly_rate_percent"`
+	GraceDays          int       `json:"grace_days"`
+	CustomerCount      int       `json:"customer_count"`
+	TotalInterest      float64   `json:"total_interest"`
+	PostedByUserID     *int      `json:"posted_by_user_id,omitempty"`
+	PostedByName       string    `json:"posted_by_name,omitempty"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// InterestWaiver exempts a customer from interest for a date range
+type InterestWaiver struct {
+	ID              int        `json:"id"`
+	CustomerPhone   string     `json:"customer_phone"`
+	Reason          string     `json:"reason"`
+	ValidFrom       time.Time  `json:"valid_from"`
+	ValidTo         *time.Time `json:"valid_to,omitempty"`
+	IsActive        bool       `json:"is_active"`
+	CreatedByUserID *int       `json:"created_by_user_id,omitempty"`
+	CreatedByName   string     `json:"created_by_name,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// CreateInterestWaiverRequest is used to waive interest for a customer
+type CreateInterestWaiverRequest struct {
+	CustomerPhone string `json:"customer_phone"`
+	Reason        string `json:"reason"`
+	ValidFrom     string `json:"valid_from"` // YYYY-MM-DD (defaults to today)
+	ValidTo       string `json:"valid_to"`   // YYYY-MM-DD (optional)
+}
+
+// PostInterestRequest is used to post interest for a month
+type PostInterestRequest struct {
+	Period string `json:"period"` // YYYY-MM
+}
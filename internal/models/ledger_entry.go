@@ -12,6 +12,10 @@ const (
 	LedgerEntryTypeRefund        LedgerEntryType = "REFUND"         // Money returned to customer
 	LedgerEntryTypeDebtApproval  LedgerEntryType = "DEBT_APPROVAL"  // Record of admin approving item out on credit
 	LedgerEntryTypeOnlinePayment LedgerEntryType = "ONLINE_PAYMENT" // Online payment via Razorpay (includes UTR)
+	LedgerEntryTypeInterest      LedgerEntryType = "INTEREST"       // Interest on overdue balance or crop loan
+	LedgerEntryTypeLoanDisbursal LedgerEntryType = "LOAN_DISBURSAL" // Crop loan cash advanced to customer
+	LedgerEntryTypeLoanRepayment LedgerEntryType = "LOAN_REPAYMENT" // Crop loan repaid by customer
+	LedgerEntryTypeReversal      LedgerEntryType = "REVERSAL"       // Equal and opposite entry voiding an earlier one
 )
 
 // LedgerEntry represents a single entry in the accounting ledger
@@ -33,6 +37,12 @@ type LedgerEntry struct {
 	CreatedByName    string          `json:"created_by_name"`
 	CreatedAt        time.Time       `json:"created_at"`
 	Notes            string          `json:"notes"`
+	ReversalOfID     *int            `json:"reversal_of_id,omitempty"` // REVERSAL entries: the voided entry
+	ReversedByID     *int            `json:"reversed_by_id,omitempty"` // Voided entries: the REVERSAL entry
+	VoidedAt         *time.Time      `json:"voided_at,omitempty"`
+	VoidReason       string          `json:"void_reason,omitempty"`
+	PaymentMode      string          `json:"payment_mode,omitempty"` // PAYMENT entries: cash, cheque, neft, rtgs, upi
+	ThockNumber      string          `json:"thock_number,omitempty"` // Charges: thock the charge belongs to
 }
 
 // CreateLedgerEntryRequest is used when creating a new ledger entry
@@ -50,6 +60,16 @@ type CreateLedgerEntryRequest struct {
 	FamilyMemberName string          `json:"family_member_name"`
 	CreatedByUserID  int             `json:"created_by_user_id" validate:"required"`
 	Notes            string          `json:"notes"`
+	EntryDate        *time.Time      `json:"entry_date,omitempty"` // Back-dated posting (nil = now); rejected in closed periods
+	ReversalOfID     *int            `json:"-"`                    // Set only by LedgerService.VoidEntry
+	PaymentMode      string          `json:"payment_mode,omitempty"` // PAYMENT entries: cash (default), cheque, neft, rtgs, upi
+	ThockNumber      string          `json:"thock_number,omitempty"` // Charges: thock for per-thock outstanding
+	CashSessionID    *int            `json:"-"`                      // Cash drawer the money went through (wallet postings)
+}
+
+// VoidLedgerEntryRequest voids an entry by posting its reversal
+type VoidLedgerEntryRequest struct {
+	Reason string `json:"reason"`
 }
 
 // LedgerSummary provides summary statistics for a customer
@@ -88,4 +108,8 @@ type AuditEntry struct {
 	CreatedByName   string          `json:"created_by_name"`
 	PaymentType     string          `json:"payment_type"`
 	Remarks         string          `json:"remarks"`
+	ReversalOfID    *int            `json:"reversal_of_id,omitempty"`
+	ReversedByID    *int            `json:"reversed_by_id,omitempty"`
+	Voided          bool            `json:"voided"`
+	VoidReason      string          `json:"void_reason,omitempty"`
 }
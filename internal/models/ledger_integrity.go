@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// Ledger integrity discrepancy types
+const (
+	LedgerDiscrepancyRunningBalance = "running_balance" // Stored running_balance differs from the recomputed one
+	LedgerDiscrepancyRentPayments   = "rent_payments"   // PAYMENT entries do not add up to rent_payments
+	LedgerDiscrepancyOnlinePayments = "online_payments" // ONLINE_PAYMENT entries do not add up to online_transactions
+)
+
+// Ledger integrity run triggers
+const (
+	LedgerIntegrityTriggerManual    = "manual"
+	LedgerIntegrityTriggerScheduled = "scheduled"
+)
+
+// LedgerDiscrepancy is one problem found by the integrity check, per customer
+type LedgerDiscrepancy struct {
+	Type          string  `json:"type"`
+	CustomerPhone string  `json:"customer_phone"`
+	CustomerName  string  `json:"customer_name"`
+	EntryID       *int    `json:"entry_id,omitempty"`    // First ledger entry where the running balance goes wrong
+	EntryCount    int     `json:"entry_count,omitempty"` // Number of entries with a wrong running balance
+	Expected      float64 `json:"expected"`
+	Actual        float64 `json:"actual"`
+	Difference    float64 `json:"difference"`
+	Repaired      bool    `json:"repaired"`
+}
+
+// LedgerIntegrityRun is the stored result of one integrity check
+type LedgerIntegrityRun struct {
+	ID               int                 `json:"id"`
+	Trigger          string              `json:"trigger"`
+	CustomersChecked int                 `json:"customers_checked"`
+	EntriesChecked   int                 `json:"entries_checked"`
+	DiscrepancyCount int                 `json:"discrepancy_count"`
+	RepairedEntries  int                 `json:"repaired_entries"`
+	Discrepancies    []LedgerDiscrepancy `json:"discrepancies"`
+	RunByUserID      *int                `json:"run_by_user_id,omitempty"`
+	RunByName        string              `json:"run_by_name,omitempty"`
+	CreatedAt        time.Time           `json:"created_at"`
+}
+
+// RunLedgerIntegrityRequest is the body for a manual check
+type RunLedgerIntegrityRequest struct {
+	Repair bool `json:"repair"` // Rewrite wrong running balances inside a transaction
+}
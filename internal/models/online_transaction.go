@@ -21,9 +21,11 @@ const (
 	PaymentScopeAccount      PaymentScope = "account"
 )
 
-// OnlineTransaction represents a Razorpay payment transaction
+// OnlineTransaction represents an online payment transaction. The razorpay_* columns
+// hold the order and payment IDs of whichever gateway took the payment.
 type OnlineTransaction struct {
 	ID                int                     `json:"id"`
+	Gateway           string                  `json:"gateway"` // razorpay, cashfree
 	RazorpayOrderID   string                  `json:"razorpay_order_id"`
 	RazorpayPaymentID string                  `json:"razorpay_payment_id,omitempty"`
 	RazorpaySignature string                  `json:"-"` // Don't expose signature in JSON
@@ -61,6 +63,13 @@ type OnlineTransaction struct {
 	RentPaymentID *int `json:"rent_payment_id,omitempty"`
 	LedgerEntryID *int `json:"ledger_entry_id,omitempty"`
 
+	// Refunds
+	RefundedAmount float64 `json:"refunded_amount"`
+	RefundStatus   string  `json:"refund_status,omitempty"` // pending, partial, full
+
+	// Payment link the order was created from
+	PaymentLinkID *int `json:"payment_link_id,omitempty"`
+
 	// Timestamps
 	CreatedAt   time.Time  `json:"created_at"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
@@ -74,11 +83,15 @@ type CreateOnlinePaymentRequest struct {
 	FamilyMemberName string  `json:"family_member_name,omitempty"`
 	ThockNumber      string  `json:"thock_number,omitempty"`
 	PaymentScope     string  `json:"payment_scope" validate:"required,oneof=truck family_member account"`
+	PaymentLinkID    *int    `json:"-"` // Set server-side for orders from a payment link
 }
 
-// CreateOrderResponse is returned to frontend for Razorpay checkout
+// CreateOrderResponse is returned to frontend to open the gateway's checkout. KeyID is
+// the Razorpay key ID or the Cashfree environment; SessionID is Cashfree's payment_session_id.
 type CreateOrderResponse struct {
+	Gateway       string  `json:"gateway"`
 	OrderID       string  `json:"order_id"`
+	SessionID     string  `json:"session_id,omitempty"`
 	Amount        int     `json:"amount"`        // In paise
 	FeeAmount     int     `json:"fee_amount"`    // In paise
 	TotalAmount   int     `json:"total_amount"`  // In paise
@@ -90,16 +103,18 @@ type CreateOrderResponse struct {
 	FeePercent    float64 `json:"fee_percent"`
 }
 
-// VerifyPaymentRequest is sent from frontend after Razorpay callback
+// VerifyPaymentRequest is sent from frontend after the checkout callback. Only Razorpay
+// sends a payment ID and signature; Cashfree payments are confirmed with Cashfree.
 type VerifyPaymentRequest struct {
 	RazorpayOrderID   string `json:"razorpay_order_id" validate:"required"`
-	RazorpayPaymentID string `json:"razorpay_payment_id" validate:"required"`
-	RazorpaySignature string `json:"razorpay_signature" validate:"required"`
+	RazorpayPaymentID string `json:"razorpay_payment_id"`
+	RazorpaySignature string `json:"razorpay_signature"`
 }
 
 // PaymentStatusResponse is returned when checking if online payments are enabled
 type PaymentStatusResponse struct {
 	Enabled    bool    `json:"enabled"`
+	Gateway    string  `json:"gateway"`
 	FeePercent float64 `json:"fee_percent"`
 	KeyID      string  `json:"key_id,omitempty"`
 }
@@ -111,6 +126,56 @@ type RazorpayWebhookPayload struct {
 	CreatedAt int64                  `json:"created_at"`
 }
 
+// Payment modes of REFUND ledger entries paid back through an online gateway - the
+// transaction's gateway name
+const (
+	PaymentModeRazorpay = "razorpay"
+	PaymentModeCashfree = "cashfree"
+)
+
+// IsGatewayPaymentMode reports whether money moved through an online payment gateway
+// (and so through the gateway clearing account)
+func IsGatewayPaymentMode(mode string) bool {
+	return mode == PaymentModeRazorpay || mode == PaymentModeCashfree || mode == "fake" // fake: local test gateway
+}
+
+// Online refund statuses
+const (
+	OnlineRefundPendingApproval = "pending_approval" // Above the approval threshold, waiting for an admin
+	OnlineRefundProcessing      = "processing"       // Sent to the gateway, waiting for refund.processed
+	OnlineRefundProcessed       = "processed"
+	OnlineRefundFailed          = "failed"
+	OnlineRefundRejected        = "rejected"
+)
+
+// OnlineRefund is a full or partial refund of an online payment through its gateway
+type OnlineRefund struct {
+	ID                  int        `json:"id"`
+	OnlineTransactionID int        `json:"online_transaction_id"`
+	Amount              float64    `json:"amount"`
+	Reason              string     `json:"reason"`
+	Status              string     `json:"status"`
+	RazorpayRefundID    string     `json:"razorpay_refund_id,omitempty"` // Refund ID at the gateway
+	FailureReason       string     `json:"failure_reason,omitempty"`
+	LedgerEntryID       *int       `json:"ledger_entry_id,omitempty"`
+	RequestedByUserID   int        `json:"requested_by_user_id"`
+	ApprovedByUserID    *int       `json:"approved_by_user_id,omitempty"`
+	ApprovedAt          *time.Time `json:"approved_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+	ProcessedAt         *time.Time `json:"processed_at,omitempty"`
+
+	// From the transaction
+	RazorpayPaymentID string `json:"razorpay_payment_id"`
+	CustomerPhone     string `json:"customer_phone"`
+	CustomerName      string `json:"customer_name"`
+}
+
+// CreateOnlineRefundRequest asks for a refund of an online payment
+type CreateOnlineRefundRequest struct {
+	Amount float64 `json:"amount"` // 0 refunds everything not yet refunded
+	Reason string  `json:"reason"`
+}
+
 // OnlineTransactionFilter is used for listing/filtering transactions
 type OnlineTransactionFilter struct {
 	CustomerPhone string     `json:"customer_phone,omitempty"`
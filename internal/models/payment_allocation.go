@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Allocation methods
+const (
+	AllocationMethodFIFO   = "fifo"
+	AllocationMethodManual = "manual"
+)
+
+// PaymentAllocation is the part of a payment applied to one charge
+type PaymentAllocation struct {
+	ID                int       `json:"id"`
+	PaymentEntryID    int       `json:"payment_entry_id"`
+	ChargeEntryID     int       `json:"charge_entry_id"`
+	Amount            float64   `json:"amount"`
+	Method            string    `json:"method"`
+	ChargeType        string    `json:"charge_type"`
+	ChargeDescription string    `json:"charge_description"`
+	ChargeDate        time.Time `json:"charge_date"`
+	ThockNumber       string    `json:"thock_number,omitempty"`
+	FamilyMemberName  string    `json:"family_member_name,omitempty"`
+	CreatedAt         time.Time `json:"created_at"`
+}
+
+// ThockOutstanding is what is charged, paid and still owed on one thock
+type ThockOutstanding struct {
+	ThockNumber      string  `json:"thock_number"`
+	FamilyMemberName string  `json:"family_member_name,omitempty"`
+	Charged          float64 `json:"charged"`
+	Paid             float64 `json:"paid"`
+	Outstanding      float64 `json:"outstanding"`
+}
+
+// CustomerAllocationSummary breaks a customer's balance down by thock
+type CustomerAllocationSummary struct {
+	CustomerPhone     string             `json:"customer_phone"`
+	Thocks            []ThockOutstanding `json:"thocks"`
+	OtherOutstanding  float64            `json:"other_outstanding"`  // Charges not tied to a thock (interest, penalties, ...)
+	UnallocatedCredit float64            `json:"unallocated_credit"` // Payments not yet applied to any charge (advance)
+	TotalOutstanding  float64            `json:"total_outstanding"`
+}
+
+// ManualAllocation applies part of a payment to a specific charge, or to a thock's
+// outstanding charges oldest first
+type ManualAllocation struct {
+	ChargeEntryID *int    `json:"charge_entry_id,omitempty"`
+	ThockNumber   string  `json:"thock_number,omitempty"`
+	Amount        float64 `json:"amount"`
+}
+
+// AllocatePaymentRequest replaces a payment's allocations; any remainder is allocated FIFO
+type AllocatePaymentRequest struct {
+	Allocations []ManualAllocation `json:"allocations"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// Payment link statuses. Expired is never stored: a sent or opened link reads as expired
+// once its expires_at has passed.
+const (
+	PaymentLinkStatusSent      = "sent"
+	PaymentLinkStatusOpened    = "opened" // Customer opened the checkout page
+	PaymentLinkStatusPaid      = "paid"
+	PaymentLinkStatusExpired   = "expired"
+	PaymentLinkStatusCancelled = "cancelled" // Replaced by a newer link or cancelled by staff
+)
+
+// Payment link settings
+const (
+	SettingPaymentLinkBaseURL     = "payment_link_base_url" // Customer portal URL; blank disables links
+	SettingPaymentLinkExpiryHours = "payment_link_expiry_hours"
+)
+
+// PaymentLink is a short-lived signed URL into the customer portal checkout for a
+// customer's outstanding amount
+type PaymentLink struct {
+	ID                  int        `json:"id"`
+	Token               string     `json:"-"`
+	URL                 string     `json:"url,omitempty"`
+	CustomerID          int        `json:"customer_id"`
+	CustomerPhone       string     `json:"customer_phone"`
+	CustomerName        string     `json:"customer_name"`
+	Amount              float64    `json:"amount"`
+	Status              string     `json:"status"`
+	OnlineTransactionID *int       `json:"online_transaction_id,omitempty"`
+	CreatedByUserID     *int       `json:"created_by_user_id,omitempty"`
+	ExpiresAt           time.Time  `json:"expires_at"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"`
+	PaidAt              *time.Time `json:"paid_at,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+// IsPayable reports whether the link can still be used to pay
+func (l *PaymentLink) IsPayable() bool {
+	return l.Status == PaymentLinkStatusSent || l.Status == PaymentLinkStatusOpened
+}
+
+// SendPaymentLinkRequest sends a payment link to one customer
+type SendPaymentLinkRequest struct {
+	CustomerPhone string  `json:"customer_phone"`
+	Amount        float64 `json:"amount"`
+	Message       string  `json:"message,omitempty"` // Text before the link; a balance reminder if blank
+}
+
+// PaymentLinkCheckout is what the public /pay/{token} page shows before checkout
+type PaymentLinkCheckout struct {
+	CustomerName string                 `json:"customer_name"`
+	Amount       float64                `json:"amount"` // Link amount, capped at the current outstanding
+	Status       string                 `json:"status"`
+	ExpiresAt    time.Time              `json:"expires_at"`
+	Payment      *PaymentStatusResponse `json:"payment"`
+}
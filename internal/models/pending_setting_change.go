@@ -54,6 +54,7 @@ func IsSensitiveSetting(key string) bool {
 	sensitiveKeys := map[string]bool{
 		"razorpay_key_secret":     true,
 		"razorpay_webhook_secret": true,
+		"cashfree_secret_key":     true,
 	}
 	return sensitiveKeys[key]
 }
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// RateContract is a customer's negotiated rent rate. Once approved it overrides
+// the rate card for the customer's (or one family member's) stock.
+type RateContract struct {
+	ID               int        `json:"id"`
+	Name             string     `json:"name"`
+	CustomerID       int        `json:"customer_id"`
+	CustomerName     string     `json:"customer_name,omitempty"`
+	CustomerPhone    string     `json:"customer_phone,omitempty"`
+	FamilyMemberID   *int       `json:"family_member_id,omitempty"` // nil = all family members
+	FamilyMemberName string     `json:"family_member_name,omitempty"`
+	ThockCategory    string     `json:"thock_category,omitempty"`
+	Variety          string     `json:"variety,omitempty"`
+	BagSize          string     `json:"bag_size,omitempty"`
+	RatePerItem      float64    `json:"rate_per_item"`
+	ExtraMonthRate   *float64   `json:"extra_month_rate,omitempty"` // nil = use rate card
+	ValidFrom        time.Time  `json:"valid_from"`
+	ValidTo          *time.Time `json:"valid_to,omitempty"`
+	Status           string     `json:"status"`
+	StatusReason     string     `json:"status_reason,omitempty"`
+	Notes            string     `json:"notes,omitempty"`
+	CreatedByUserID  *int       `json:"created_by_user_id,omitempty"`
+	CreatedByName    string     `json:"created_by_name,omitempty"`
+	ApprovedByUserID *int       `json:"approved_by_user_id,omitempty"`
+	ApprovedByName   string     `json:"approved_by_name,omitempty"`
+	ApprovedAt       *time.Time `json:"approved_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// CreateRateContractRequest is used to propose a negotiated rate
+type CreateRateContractRequest struct {
+	Name           string   `json:"name"`
+	CustomerID     int      `json:"customer_id"`
+	FamilyMemberID *int     `json:"family_member_id"`
+	ThockCategory  string   `json:"thock_category"`
+	Variety        string   `json:"variety"`
+	BagSize        string   `json:"bag_size"`
+	RatePerItem    float64  `json:"rate_per_item"`
+	ExtraMonthRate *float64 `json:"extra_month_rate"`
+	ValidFrom      string   `json:"valid_from"` // YYYY-MM-DD (defaults to today)
+	ValidTo        string   `json:"valid_to"`   // YYYY-MM-DD (optional)
+	Notes          string   `json:"notes"`
+}
+
+// RateContractStatusRequest carries the reason for rejecting or revoking a contract
+type RateContractStatusRequest struct {
+	Reason string `json:"reason"`
+}
+
+// Rate contract statuses
+const (
+	RateContractPending  = "pending"
+	RateContractActive   = "active"
+	RateContractRejected = "rejected"
+	RateContractRevoked  = "revoked"
+)
@@ -2,6 +2,22 @@ package models
 
 import "time"
 
+// Payment modes for counter payments (online Razorpay payments are tracked separately)
+const (
+	PaymentModeCash   = "cash"
+	PaymentModeCheque = "cheque"
+	PaymentModeNEFT   = "neft"
+	PaymentModeRTGS   = "rtgs"
+	PaymentModeUPI    = "upi" // UPI straight to the bank account
+)
+
+// Cheque clearance statuses
+const (
+	ChequeStatusPending = "pending"
+	ChequeStatusCleared = "cleared"
+	ChequeStatusBounced = "bounced"
+)
+
 type RentPayment struct {
 	ID                 int       `json:"id"`
 	ReceiptNumber      string    `json:"receipt_number"`
@@ -17,7 +33,19 @@ type RentPayment struct {
 	ProcessedByUserID  int       `json:"processed_by_user_id"`
 	ProcessedByName    string    `json:"processed_by_name,omitempty"` // Joined from users table
 	Notes              string    `json:"notes"`
+	CashSessionID      *int      `json:"cash_session_id,omitempty"` // Cash drawer session the payment was taken in
 	CreatedAt          time.Time `json:"created_at"`
+
+	PaymentMode     string     `json:"payment_mode"`
+	ChequeNumber    string     `json:"cheque_number,omitempty"`
+	BankName        string     `json:"bank_name,omitempty"`
+	ChequeDate      *time.Time `json:"cheque_date,omitempty"`
+	UTRNumber       string     `json:"utr_number,omitempty"`       // NEFT/RTGS UTR or UPI reference
+	ClearanceStatus string     `json:"clearance_status,omitempty"` // Cheques only
+	ClearingDate    *time.Time `json:"clearing_date,omitempty"`
+	BouncedAt       *time.Time `json:"bounced_at,omitempty"`
+	BounceReason    string     `json:"bounce_reason,omitempty"`
+	BouncePenalty   *float64   `json:"bounce_penalty,omitempty"`
 }
 
 type CreateRentPaymentRequest struct {
@@ -30,4 +58,23 @@ type CreateRentPaymentRequest struct {
 	AmountPaid       float64 `json:"amount_paid"`
 	Balance          float64 `json:"balance"`
 	Notes            string  `json:"notes"`
+	PaymentMode      string  `json:"payment_mode,omitempty"` // Defaults to cash
+	ChequeNumber     string  `json:"cheque_number,omitempty"`
+	BankName         string  `json:"bank_name,omitempty"`
+	ChequeDate       string  `json:"cheque_date,omitempty"` // YYYY-MM-DD
+	UTRNumber        string  `json:"utr_number,omitempty"`
+
+	// Split of the payment across thocks; FIFO when empty
+	Allocations []ManualAllocation `json:"allocations,omitempty"`
+}
+
+// ClearChequeRequest marks a pending cheque as cleared
+type ClearChequeRequest struct {
+	ClearingDate string `json:"clearing_date,omitempty"` // YYYY-MM-DD, defaults to today
+}
+
+// BounceChequeRequest marks a cheque as bounced
+type BounceChequeRequest struct {
+	Reason  string   `json:"reason"`
+	Penalty *float64 `json:"penalty,omitempty"` // Defaults to the cheque_bounce_penalty setting
 }
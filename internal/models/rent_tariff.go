@@ -0,0 +1,122 @@
+package models
+
+import "time"
+
+// RentTariff is a rent rate card. Empty matching fields apply to all entries.
+type RentTariff struct {
+	ID              int        `json:"id"`
+	Name            string     `json:"name"`
+	ThockCategory   string     `json:"thock_category,omitempty"` // seed, sell or empty for both
+	Variety         string     `json:"variety,omitempty"`
+	BagSize         string     `json:"bag_size,omitempty"`
+	Season          string     `json:"season,omitempty"`
+	RatePerItem     float64    `json:"rate_per_item"`
+	EffectiveFrom   time.Time  `json:"effective_from"`
+	EffectiveTo     *time.Time `json:"effective_to,omitempty"`
+	BillingMode     string     `json:"billing_mode"` // flat, monthly, prorata
+	MinMonths       int        `json:"min_months"`   // Minimum-season slab (monthly/prorata)
+	SeasonEndDate   *time.Time `json:"season_end_date,omitempty"`
+	ExtraMonthRate  float64    `json:"extra_month_rate"` // Per item per month past season end
+	Priority        int        `json:"priority"`
+	IsActive        bool       `json:"is_active"`
+	Notes           string     `json:"notes,omitempty"`
+	CreatedByUserID *int       `json:"created_by_user_id,omitempty"`
+	CreatedByName   string     `json:"created_by_name,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// CreateRentTariffRequest is used to create or update a rate card
+type CreateRentTariffRequest struct {
+	Name           string  `json:"name"`
+	ThockCategory  string  `json:"thock_category"`
+	Variety        string  `json:"variety"`
+	BagSize        string  `json:"bag_size"`
+	Season         string  `json:"season"`
+	RatePerItem    float64 `json:"rate_per_item"`
+	EffectiveFrom  string  `json:"effective_from"` // YYYY-MM-DD (defaults to today)
+	EffectiveTo    string  `json:"effective_to"`   // YYYY-MM-DD (optional)
+	BillingMode    string  `json:"billing_mode"`   // flat (default), monthly, prorata
+	MinMonths      int     `json:"min_months"`
+	SeasonEndDate  string  `json:"season_end_date"` // YYYY-MM-DD (optional)
+	ExtraMonthRate float64 `json:"extra_month_rate"`
+	Priority       int     `json:"priority"`
+	IsActive       *bool   `json:"is_active,omitempty"`
+	Notes          string  `json:"notes"`
+}
+
+// RentTariffQuery describes the stock a rate is being looked up for
+type RentTariffQuery struct {
+	ThockCategory  string    `json:"thock_category"`
+	Variety        string    `json:"variety"` // Comma-separated varieties (entries.remark)
+	BagSize        string    `json:"bag_size"`
+	Date           time.Time `json:"date"`                  // Date stock entered storage
+	Season         string    `json:"season,omitempty"`      // Season stock entered storage (default: current_season)
+	CustomerID     int       `json:"customer_id,omitempty"` // Used to apply negotiated rate contracts
+	FamilyMemberID *int      `json:"family_member_id,omitempty"`
+}
+
+// RentTariffQuote is the resolved rate for a query
+type RentTariffQuote struct {
+	RatePerItem    float64    `json:"rate_per_item"`
+	TariffID       *int       `json:"tariff_id,omitempty"`
+	TariffName     string     `json:"tariff_name"`
+	Source         string     `json:"source"` // "contract", "tariff" or "default"
+	ContractID     *int       `json:"contract_id,omitempty"`
+	ContractName   string     `json:"contract_name,omitempty"`
+	BillingMode    string     `json:"billing_mode"`
+	MinMonths      int        `json:"min_months"`
+	SeasonEndDate  *time.Time `json:"season_end_date,omitempty"`
+	ExtraMonthRate float64    `json:"extra_month_rate"`
+}
+
+// SeasonRate returns the minimum rent per item for a season of storage
+// (the flat rate, or the monthly rate times the minimum-season slab)
+func (q RentTariffQuote) SeasonRate() float64 {
+	if q.BillingMode == RentBillingMonthly || q.BillingMode == RentBillingProrata {
+		months := q.MinMonths
+		if months < 1 {
+			months = 1
+		}
+		return q.RatePerItem * float64(months)
+	}
+	return q.RatePerItem
+}
+
+// Rent billing modes
+const (
+	RentBillingFlat    = "flat"    // Rate covers the whole season
+	RentBillingMonthly = "monthly" // Rate per storage month, part months round up
+	RentBillingProrata = "prorata" // Rate per storage month, charged by day
+)
+
+// RentCharge is the duration-based rent for a lot of bags leaving storage
+type RentCharge struct {
+	PickupID       int       `json:"pickup_id,omitempty"`
+	GatePassID     int       `json:"gate_pass_id,omitempty"`
+	ThockNumber    string    `json:"thock_number"`
+	Quantity       int       `json:"quantity"`
+	StoredAt       time.Time `json:"stored_at"`
+	RemovedAt      time.Time `json:"removed_at"`
+	StorageDays    int       `json:"storage_days"`
+	ChargedMonths  float64   `json:"charged_months"` // Months charged within season (after minimum slab)
+	ExtraMonths    int       `json:"extra_months"`   // Months past season end
+	TariffName     string    `json:"tariff_name"`
+	BillingMode    string    `json:"billing_mode"`
+	RatePerItem    float64   `json:"rate_per_item"`
+	ExtraMonthRate float64   `json:"extra_month_rate"`
+	BaseAmount     float64   `json:"base_amount"`
+	ExtraAmount    float64   `json:"extra_amount"`
+	Amount         float64   `json:"amount"`
+	ContractID     *int      `json:"contract_id,omitempty"`
+	ContractName   string    `json:"contract_name,omitempty"`
+	LedgerEntryID  *int      `json:"ledger_entry_id,omitempty"`
+	AlreadyCharged bool      `json:"already_charged"`
+}
+
+// Rent rate sources
+const (
+	RentRateSourceContract = "contract"
+	RentRateSourceTariff   = "tariff"
+	RentRateSourceDefault  = "default"
+)
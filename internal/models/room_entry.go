@@ -1,6 +1,10 @@
 package models
 
-import "time"
+import (
+	"strconv"
+	"strings"
+	"time"
+)
 
 type RoomEntry struct {
 	ID                int               `json:"id"`
@@ -17,6 +21,10 @@ type RoomEntry struct {
 	UpdatedAt         time.Time         `json:"updated_at"`
 	Variety           string            `json:"variety"` // From joined entries table (entries.remark)
 	Gatars            []RoomEntryGatar  `json:"gatars,omitempty"`
+
+	// Set on create/update: the entry's gatars after it and any gatars it overfills
+	GatarCapacity    []GatarUtilization `json:"gatar_capacity,omitempty"`
+	CapacityWarnings []string           `json:"capacity_warnings,omitempty"`
 }
 
 // RoomEntryGatar represents per-gatar quantity breakdown for a room entry
@@ -61,3 +69,89 @@ type UpdateRoomEntryRequest struct {
 	QuantityBreakdown string       `json:"quantity_breakdown"`
 	Gatars            []GatarInput `json:"gatars,omitempty"` // Per-gatar quantity breakdown
 }
+
+// ParseQuantityBreakdown reads a room entry's quantity_breakdown ("25, 24, 24, 16"),
+// skipping anything that is not a number
+func ParseQuantityBreakdown(breakdown string) []int {
+	var values []int
+	if breakdown == "" {
+		return values
+	}
+	for _, p := range strings.Split(breakdown, ",") {
+		if val, err := strconv.Atoi(strings.TrimSpace(p)); err == nil {
+			values = append(values, val)
+		}
+	}
+	return values
+}
+
+// DistributeQuantity spreads a room entry's bags over its gatars, given each gatar's
+// capacity in gate_no order. A single gatar takes every bag; a breakdown with one count
+// per gatar maps 1:1; a longer breakdown fills the gatars in order up to their capacity,
+// the last gatar taking any overflow; otherwise the bags are split evenly, the first
+// gatars taking the remainder. Migration 050 backfills room_entry_gatars the same way, so keep the two in step.
+func DistributeQuantity(totalQty int, capacities []int, breakdown []int) []int {
+	numGatars := len(capacities)
+	result := make([]int, numGatars)
+
+	if numGatars == 0 {
+		return result
+	}
+
+	// Case 1: Single gatar - all bags go to it
+	if numGatars == 1 {
+		result[0] = totalQty
+		return result
+	}
+
+	// Case 2: Breakdown count matches gatar count - direct 1:1 mapping
+	if len(breakdown) == numGatars {
+		copy(result, breakdown)
+		return result
+	}
+
+	// Case 3: More breakdown items than gatars - distribute sequentially
+	// Fill each gatar up to its capacity in order
+	if len(breakdown) > numGatars {
+		currentGatar := 0
+		currentGatarBags := 0
+
+		for _, bags := range breakdown {
+			// If current gatar would overflow, try to fit what we can
+			remainingCapacity := capacities[currentGatar] - currentGatarBags
+
+			if bags <= remainingCapacity || currentGatar == numGatars-1 {
+				// Fits in current gatar OR this is the last gatar (must take overflow)
+				result[currentGatar] += bags
+				currentGatarBags += bags
+			} else {
+				// Split between current and next gatar
+				result[currentGatar] += remainingCapacity
+				currentGatar++
+				result[currentGatar] += bags - remainingCapacity
+				currentGatarBags = bags - remainingCapacity
+			}
+
+			// Move to next gatar if current is at capacity
+			if currentGatar < numGatars-1 && currentGatarBags >= capacities[currentGatar] {
+				currentGatar++
+				currentGatarBags = 0
+			}
+		}
+		return result
+	}
+
+	// Case 4: Fewer breakdown items than gatars OR no breakdown - divide evenly
+	baseQty := totalQty / numGatars
+	remainder := totalQty % numGatars
+
+	for i := 0; i < numGatars; i++ {
+		result[i] = baseQty
+		// Distribute remainder across first few gatars
+		if i < remainder {
+			result[i]++
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,23 @@
+package models
+
+// SlotSuggestion is a proposed place for a thock: gatars on one floor and the bags each
+// takes. Accepting it fills CreateRoomEntryRequest's room, floor, gate_no and gatars.
+type SlotSuggestion struct {
+	RoomNo    string       `json:"room_no"`
+	Floor     string       `json:"floor"`
+	GateNo    string       `json:"gate_no"`
+	Gatars    []GatarInput `json:"gatars"`
+	Together  bool         `json:"together"`   // Adjacent gatars; false when the thock has to be split up
+	FreeAfter int          `json:"free_after"` // Space left in the suggested gatars
+	Reason    string       `json:"reason"`
+}
+
+// SlotSuggestions are the places proposed for an entry, best first
+type SlotSuggestions struct {
+	EntryID       int               `json:"entry_id"`
+	ThockNumber   string            `json:"thock_number"`
+	ThockCategory string            `json:"thock_category"`
+	Variety       string            `json:"variety"`
+	Quantity      int               `json:"quantity"`
+	Suggestions   []*SlotSuggestion `json:"suggestions"`
+}
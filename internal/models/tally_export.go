@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// TallyExport is one batch of ledger entries exported to Tally Prime as XML vouchers
+type TallyExport struct {
+	ID              int       `json:"id"`
+	FromDate        time.Time `json:"from_date"`
+	ToDate          time.Time `json:"to_date"`
+	VoucherCount    int       `json:"voucher_count"`
+	LedgerCount     int       `json:"ledger_count"` // Customer ledger masters included
+	CreatedByUserID int       `json:"created_by_user_id"`
+	CreatedByName   string    `json:"created_by_name,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// CreateTallyExportRequest is used to export ledger entries in a date range
+type CreateTallyExportRequest struct {
+	FromDate        string `json:"from_date"`        // YYYY-MM-DD
+	ToDate          string `json:"to_date"`          // YYYY-MM-DD
+	IncludeExported bool   `json:"include_exported"` // Also resend entries from earlier batches
+}
+
+// TallyLedgerEntry is a ledger entry with the details needed to build a Tally voucher
+type TallyLedgerEntry struct {
+	LedgerEntry
+	ReceiptNumber string `json:"receipt_number,omitempty"` // Rent payment receipt for PAYMENT entries
+	TallyExportID *int   `json:"tally_export_id,omitempty"`
+	// For REVERSAL entries: the type of the voided entry, whose posting is mirrored
+	ReversedEntryType LedgerEntryType `json:"reversed_entry_type,omitempty"`
+}
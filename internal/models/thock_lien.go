@@ -0,0 +1,70 @@
+package models
+
+import "time"
+
+// Thock lien statuses
+const (
+	ThockLienStatusActive   = "active"
+	ThockLienStatusReleased = "released"
+)
+
+// ThockLien is a bank's pledge on stored stock, backed by a warehouse receipt
+type ThockLien struct {
+	ID                int        `json:"id"`
+	ReceiptNumber     string     `json:"receipt_number"`
+	VerificationCode  string     `json:"verification_code"`
+	EntryID           int        `json:"entry_id"`
+	ThockNumber       string     `json:"thock_number"`
+	CustomerID        int        `json:"customer_id"`
+	CustomerName      string     `json:"customer_name"`
+	CustomerPhone     string     `json:"customer_phone"`
+	CustomerSO        string     `json:"customer_so,omitempty"`
+	CustomerVillage   string     `json:"customer_village,omitempty"`
+	Variety           string     `json:"variety,omitempty"`
+	BankName          string     `json:"bank_name"`
+	BankBranch        string     `json:"bank_branch,omitempty"`
+	LoanAccountNumber string     `json:"loan_account_number,omitempty"`
+	PledgedQuantity   int        `json:"pledged_quantity"`
+	LienAmount        float64    `json:"lien_amount"`
+	Status            string     `json:"status"`
+	Notes             string     `json:"notes,omitempty"`
+	MarkedByUserID    int        `json:"marked_by_user_id"`
+	MarkedByName      string     `json:"marked_by_name,omitempty"`
+	MarkedAt          time.Time  `json:"marked_at"`
+	ReleasedByUserID  *int       `json:"released_by_user_id,omitempty"`
+	ReleasedByName    string     `json:"released_by_name,omitempty"`
+	ReleasedAt        *time.Time `json:"released_at,omitempty"`
+	ReleaseReference  string     `json:"release_reference,omitempty"`
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// CreateThockLienRequest marks a lien on a thock
+type CreateThockLienRequest struct {
+	ThockNumber       string  `json:"thock_number"`
+	BankName          string  `json:"bank_name"`
+	BankBranch        string  `json:"bank_branch"`
+	LoanAccountNumber string  `json:"loan_account_number"`
+	PledgedQuantity   int     `json:"pledged_quantity"`
+	LienAmount        float64 `json:"lien_amount"`
+	Notes             string  `json:"notes"`
+}
+
+// ReleaseThockLienRequest releases a lien once the bank issues a release/NOC
+type ReleaseThockLienRequest struct {
+	ReleaseReference string `json:"release_reference"`
+}
+
+// WarehouseReceiptVerification is the public view of a receipt returned to banks
+type WarehouseReceiptVerification struct {
+	Valid           bool       `json:"valid"`
+	ReceiptNumber   string     `json:"receipt_number,omitempty"`
+	Status          string     `json:"status,omitempty"`
+	BankName        string     `json:"bank_name,omitempty"`
+	CustomerName    string     `json:"customer_name,omitempty"`
+	ThockNumber     string     `json:"thock_number,omitempty"`
+	PledgedQuantity int        `json:"pledged_quantity,omitempty"`
+	LienAmount      float64    `json:"lien_amount,omitempty"`
+	MarkedAt        *time.Time `json:"marked_at,omitempty"`
+	ReleasedAt      *time.Time `json:"released_at,omitempty"`
+}
@@ -0,0 +1,53 @@
+package models
+
+import "time"
+
+// Wallet statement line kinds
+const (
+	WalletLineDeposit = "deposit"
+	WalletLineApplied = "applied" // Drawn down against a charge
+	WalletLineRefund  = "refund"  // Unused balance paid back to the customer
+)
+
+// Wallet is a customer's advance deposit account. Deposits are applied to charges as
+// they are posted; whatever is left can be refunded at season end.
+type Wallet struct {
+	CustomerPhone string                `json:"customer_phone"`
+	CustomerName  string                `json:"customer_name"`
+	Deposited     float64               `json:"deposited"`
+	Applied       float64               `json:"applied"`
+	Refunded      float64               `json:"refunded"`
+	Balance       float64               `json:"balance"`
+	Statement     []WalletStatementLine `json:"statement,omitempty"`
+}
+
+// WalletStatementLine is one movement on a wallet, with the balance after it
+type WalletStatementLine struct {
+	Date           time.Time `json:"date"`
+	Kind           string    `json:"kind"`
+	LedgerEntryID  int       `json:"ledger_entry_id"`  // Deposit, or the charge/refund it was applied to
+	DepositEntryID int       `json:"deposit_entry_id"` // Deposit the money came from
+	Description    string    `json:"description"`
+	ThockNumber    string    `json:"thock_number,omitempty"`
+	Credit         float64   `json:"credit"`
+	Debit          float64   `json:"debit"`
+	Balance        float64   `json:"balance"`
+}
+
+// WalletDepositRequest records money a customer leaves as an advance
+type WalletDepositRequest struct {
+	CustomerPhone string  `json:"customer_phone"`
+	Amount        float64 `json:"amount"`
+	PaymentMode   string  `json:"payment_mode"` // cash (default), neft, rtgs, upi
+	UTRNumber     string  `json:"utr_number"`   // Required for bank transfers
+	Notes         string  `json:"notes"`
+}
+
+// WalletRefundRequest pays back part or all of a wallet balance
+type WalletRefundRequest struct {
+	CustomerPhone string  `json:"customer_phone"`
+	Amount        float64 `json:"amount"`       // 0 refunds the whole balance
+	PaymentMode   string  `json:"payment_mode"` // cash (default), neft, rtgs, upi
+	UTRNumber     string  `json:"utr_number"`
+	Notes         string  `json:"notes"`
+}
@@ -0,0 +1,188 @@
+package models
+
+// Room types: which thock category a room stores
+const (
+	RoomTypeSeed = "seed"
+	RoomTypeSell = "sell"
+)
+
+// DefaultGatarCapacity is the bags per gatar of a floor with no capacity set
+const DefaultGatarCapacity = 200
+
+// SettingGatarOverflowPolicy decides what happens to a room entry that overfills a gatar
+const SettingGatarOverflowPolicy = "gatar_overflow_policy"
+
+// Gatar overflow policies
+const (
+	GatarOverflowWarn  = "warn"  // Save the room entry and return a warning
+	GatarOverflowBlock = "block" // Reject the room entry
+)
+
+// GatarUtilization is a gatar's stock against its capacity
+type GatarUtilization struct {
+	GatarNo  int `json:"gatar_no"`
+	Capacity int `json:"capacity"`
+	Used     int `json:"used"`
+	Free     int `json:"free"` // Negative when the gatar is over capacity
+}
+
+// WarehouseLayout is the storage layout: rooms, their floors and the gatar numbers on
+// each floor. Gatar numbers are unique across the warehouse.
+type WarehouseLayout struct {
+	Rooms      []*LayoutRoom `json:"rooms"`
+	Capacities map[int]int   `json:"gatar_capacities"` // Gatars whose capacity differs from their floor's
+}
+
+// LayoutRoom is a storage room (chamber)
+type LayoutRoom struct {
+	RoomNo    string         `json:"room_no"`
+	Name      string         `json:"name"`
+	RoomType  string         `json:"room_type"` // seed or sell
+	SortOrder int            `json:"sort_order"`
+	Floors    []*LayoutFloor `json:"floors"`
+}
+
+// LayoutFloor is a floor of a room. Its gatars are numbered in one or more ranges laid
+// side by side on the floor plan.
+type LayoutFloor struct {
+	Floor         string             `json:"floor"`
+	GridRows      int                `json:"grid_rows"`
+	GatarCapacity int                `json:"gatar_capacity"` // Bags per gatar
+	Ranges        []LayoutGatarRange `json:"ranges"`
+}
+
+// LayoutGatarRange is a run of consecutive gatar numbers on a floor
+type LayoutGatarRange struct {
+	StartGatar int `json:"start_gatar"`
+	EndGatar   int `json:"end_gatar"`
+	GridCols   int `json:"grid_cols"`
+}
+
+// FloorGrid is a floor in the shape the floor plan pages draw: a single range as
+// start/end, or several ranges side by side
+type FloorGrid struct {
+	Start  int              `json:"start,omitempty"`
+	End    int              `json:"end,omitempty"`
+	Ranges []FloorGridRange `json:"ranges,omitempty"`
+	Cols   int              `json:"cols"`
+	Rows   int              `json:"rows"`
+}
+
+// FloorGridRange is one range of a split floor
+type FloorGridRange struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+	Cols  int `json:"cols"`
+}
+
+// SetGatarCapacityRequest sets a gatar's capacity; 0 resets it to the floor's
+type SetGatarCapacityRequest struct {
+	Capacity int `json:"capacity"`
+}
+
+// Room returns a room of the layout, or nil
+func (l *WarehouseLayout) Room(roomNo string) *LayoutRoom {
+	for _, room := range l.Rooms {
+		if room.RoomNo == roomNo {
+			return room
+		}
+	}
+	return nil
+}
+
+// Floor returns a floor of a room, or nil
+func (l *WarehouseLayout) Floor(roomNo, floor string) *LayoutFloor {
+	room := l.Room(roomNo)
+	if room == nil {
+		return nil
+	}
+	return room.Floor(floor)
+}
+
+// Locate returns the room and floor a gatar is on (empty if it is not in the layout)
+func (l *WarehouseLayout) Locate(gatarNo int) (string, string) {
+	for _, room := range l.Rooms {
+		for _, f := range room.Floors {
+			if f.Contains(gatarNo) {
+				return room.RoomNo, f.Floor
+			}
+		}
+	}
+	return "", ""
+}
+
+// Capacity returns how many bags a gatar holds (0 if it is not in the layout)
+func (l *WarehouseLayout) Capacity(gatarNo int) int {
+	if capacity, ok := l.Capacities[gatarNo]; ok {
+		return capacity
+	}
+	roomNo, floor := l.Locate(gatarNo)
+	if roomNo == "" {
+		return 0
+	}
+	return l.Floor(roomNo, floor).GatarCapacity
+}
+
+// Grid returns the layout as room -> floor -> floor plan
+func (l *WarehouseLayout) Grid() map[string]map[string]*FloorGrid {
+	grid := make(map[string]map[string]*FloorGrid)
+	for _, room := range l.Rooms {
+		floors := make(map[string]*FloorGrid)
+		for _, f := range room.Floors {
+			g := &FloorGrid{Rows: f.GridRows}
+			for _, r := range f.Ranges {
+				g.Cols += r.GridCols
+			}
+			if len(f.Ranges) == 1 {
+				g.Start, g.End = f.Ranges[0].StartGatar, f.Ranges[0].EndGatar
+			} else {
+				for _, r := range f.Ranges {
+					g.Ranges = append(g.Ranges, FloorGridRange{Start: r.StartGatar, End: r.EndGatar, Cols: r.GridCols})
+				}
+			}
+			floors[f.Floor] = g
+		}
+		grid[room.RoomNo] = floors
+	}
+	return grid
+}
+
+// Floor returns a floor of the room, or nil
+func (r *LayoutRoom) Floor(floor string) *LayoutFloor {
+	for _, f := range r.Floors {
+		if f.Floor == floor {
+			return f
+		}
+	}
+	return nil
+}
+
+// Contains reports whether a gatar number is on the floor
+func (f *LayoutFloor) Contains(gatarNo int) bool {
+	for _, r := range f.Ranges {
+		if gatarNo >= r.StartGatar && gatarNo <= r.EndGatar {
+			return true
+		}
+	}
+	return false
+}
+
+// TotalGatars returns the number of gatars on the floor
+func (f *LayoutFloor) TotalGatars() int {
+	total := 0
+	for _, r := range f.Ranges {
+		total += r.EndGatar - r.StartGatar + 1
+	}
+	return total
+}
+
+// Gatars returns the floor's gatar numbers in range order
+func (f *LayoutFloor) Gatars() []int {
+	gatars := make([]int, 0, f.TotalGatars())
+	for _, r := range f.Ranges {
+		for g := r.StartGatar; g <= r.EndGatar; g++ {
+			gatars = append(gatars, g)
+		}
+	}
+	return gatars
+}
@@ -0,0 +1,361 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	cashfreeAPIVersion    = "2023-08-01"
+	cashfreeProductionURL = "https://api.cashfree.com/pg"
+	cashfreeSandboxURL    = "https://sandbox.cashfree.com/pg"
+	cashfreeRefundPrefix  = "refund_"
+)
+
+// CashfreeGateway implements PaymentGateway for Cashfree Payments (PG API 2023-08-01)
+type CashfreeGateway struct {
+	AppID       string
+	SecretKey   string // Also signs webhooks
+	Environment string // "production" or "sandbox"
+	baseURL     string
+	client      *http.Client
+}
+
+// NewCashfreeGateway creates a Cashfree gateway. Any environment other than "sandbox" is production.
+func NewCashfreeGateway(appID, secretKey, environment string) *CashfreeGateway {
+	g := &CashfreeGateway{
+		AppID:       appID,
+		SecretKey:   secretKey,
+		Environment: "production",
+		baseURL:     cashfreeProductionURL,
+		client:      &http.Client{Timeout: 30 * time.Second},
+	}
+	if environment == "sandbox" {
+		g.Environment = "sandbox"
+		g.baseURL = cashfreeSandboxURL
+	}
+	return g
+}
+
+func (g *CashfreeGateway) Name() string {
+	return GatewayCashfree
+}
+
+// CheckoutKey returns the environment the Cashfree JS SDK must run in
+func (g *CashfreeGateway) CheckoutKey() string {
+	return g.Environment
+}
+
+// do calls the Cashfree API and decodes the JSON response into out
+func (g *CashfreeGateway) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, g.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create cashfree request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("x-api-version", cashfreeAPIVersion)
+	req.Header.Set("x-client-id", g.AppID)
+	req.Header.Set("x-client-secret", g.SecretKey)
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cashfree request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		var apiErr struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(respBody, &apiErr)
+		if apiErr.Message == "" {
+			apiErr.Message = string(respBody)
+		}
+		return fmt.Errorf("cashfree API error (status %d): %s", resp.StatusCode, apiErr.Message)
+	}
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return fmt.Errorf("failed to parse cashfree response: %w", err)
+		}
+	}
+	return nil
+}
+
+// CreateOrder creates a Cashfree order. The receipt becomes the order ID, and the
+// portal opens checkout with the returned payment session ID.
+func (g *CashfreeGateway) CreateOrder(ctx context.Context, req *OrderRequest) (*Order, error) {
+	if g.AppID == "" || g.SecretKey == "" {
+		return nil, ErrNotConfigured
+	}
+
+	tags := map[string]string{"customer_id": strconv.Itoa(req.CustomerID)}
+	for k, v := range req.Notes {
+		tags[k] = v
+	}
+
+	var resp struct {
+		OrderID          string `json:"order_id"`
+		PaymentSessionID string `json:"payment_session_id"`
+	}
+	err := g.do(ctx, http.MethodPost, "/orders", map[string]interface{}{
+		"order_id":       req.Receipt,
+		"order_amount":   float64(req.Amount) / 100,
+		"order_currency": "INR",
+		"customer_details": map[string]string{
+			"customer_id":    "cust_" + strconv.Itoa(req.CustomerID),
+			"customer_name":  req.CustomerName,
+			"customer_phone": req.CustomerPhone,
+		},
+		"order_tags": tags,
+	}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cashfree order: %w", err)
+	}
+
+	return &Order{ID: resp.OrderID, Amount: req.Amount, Currency: "INR", SessionID: resp.PaymentSessionID}, nil
+}
+
+// VerifyPayment confirms a checkout with Cashfree itself - the checkout callback carries
+// no signature - and returns the order's payment
+func (g *CashfreeGateway) VerifyPayment(ctx context.Context, orderID, paymentID, signature string) (*PaymentDetails, error) {
+	if g.AppID == "" || g.SecretKey == "" {
+		return nil, ErrNotConfigured
+	}
+	return g.FetchPayment(ctx, orderID, paymentID)
+}
+
+// FetchPayment returns a payment of an order: the one with paymentID when set, otherwise
+// the successful one or else the latest attempt
+func (g *CashfreeGateway) FetchPayment(ctx context.Context, orderID, paymentID string) (*PaymentDetails, error) {
+	var payments []cashfreePaymentEntity
+	if err := g.do(ctx, http.MethodGet, "/orders/"+url.PathEscape(orderID)+"/payments", nil, &payments); err != nil {
+		return nil, fmt.Errorf("failed to fetch cashfree payments: %w", err)
+	}
+
+	var latest *PaymentDetails
+	for _, entity := range payments {
+		p := entity.details(orderID)
+		if paymentID != "" {
+			if p.PaymentID == paymentID {
+				return p, nil
+			}
+			continue
+		}
+		if p.Status == StatusCaptured {
+			return p, nil
+		}
+		if latest == nil {
+			latest = p
+		}
+	}
+	if paymentID != "" {
+		return nil, fmt.Errorf("cashfree payment %s not found on order %s", paymentID, orderID)
+	}
+	if latest == nil {
+		return &PaymentDetails{OrderID: orderID, Status: StatusPending}, nil
+	}
+	return latest, nil
+}
+
+// VerifyWebhook checks the x-webhook-signature header: base64 HMAC-SHA256 of the
+// x-webhook-timestamp header followed by the body, keyed with the secret key
+func (g *CashfreeGateway) VerifyWebhook(body []byte, header http.Header) bool {
+	if g.SecretKey == "" {
+		return false
+	}
+	h := hmac.New(sha256.New, []byte(g.SecretKey))
+	h.Write([]byte(header.Get("x-webhook-timestamp")))
+	h.Write(body)
+	expected := base64.StdEncoding.EncodeToString(h.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(header.Get("x-webhook-signature")))
+}
+
+// ParseWebhook parses payment success/failure/user-dropped and refund status webhooks
+func (g *CashfreeGateway) ParseWebhook(body []byte) (*WebhookEvent, error) {
+	var payload struct {
+		Type string `json:"type"`
+		Data struct {
+			Order struct {
+				OrderID string `json:"order_id"`
+			} `json:"order"`
+			Payment *cashfreePaymentEntity `json:"payment"`
+			Refund  *cashfreeRefundEntity  `json:"refund"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse cashfree webhook: %w", err)
+	}
+
+	event := &WebhookEvent{}
+	switch payload.Type {
+	case "PAYMENT_SUCCESS_WEBHOOK", "PAYMENT_FAILED_WEBHOOK", "PAYMENT_USER_DROPPED_WEBHOOK":
+		if payload.Data.Payment == nil {
+			return nil, fmt.Errorf("missing payment in cashfree webhook")
+		}
+		event.Payment = payload.Data.Payment.details(payload.Data.Order.OrderID)
+		event.Type = EventPaymentFailed
+		if event.Payment.Status == StatusCaptured {
+			event.Type = EventPaymentCaptured
+		}
+	case "REFUND_STATUS_WEBHOOK":
+		if payload.Data.Refund == nil {
+			return nil, fmt.Errorf("missing refund in cashfree webhook")
+		}
+		event.Refund = payload.Data.Refund.refund()
+		switch event.Refund.Status {
+		case RefundProcessed:
+			event.Type = EventRefundProcessed
+		case RefundFailed:
+			event.Type = EventRefundFailed
+		}
+	default:
+		log.Printf("[Cashfree] Unhandled webhook event: %s", payload.Type)
+	}
+	return event, nil
+}
+
+// Refund refunds part or all of an order. Our refund ID goes in refund_id so the refund
+// webhook can be matched even before the Cashfree refund ID is saved.
+func (g *CashfreeGateway) Refund(ctx context.Context, req *RefundRequest) (*Refund, error) {
+	if g.AppID == "" || g.SecretKey == "" {
+		return nil, ErrNotConfigured
+	}
+
+	note := req.Notes["reason"]
+	if len(note) > 100 {
+		note = note[:100]
+	}
+	var resp cashfreeRefundEntity
+	err := g.do(ctx, http.MethodPost, "/orders/"+url.PathEscape(req.OrderID)+"/refunds", map[string]interface{}{
+		"refund_amount": float64(req.Amount) / 100,
+		"refund_id":     cashfreeRefundPrefix + strconv.Itoa(req.ReferenceID),
+		"refund_note":   note,
+		"refund_speed":  "STANDARD",
+	}, &resp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cashfree refund: %w", err)
+	}
+	return resp.refund(), nil
+}
+
+// cashfreeID reads an ID Cashfree sends as either a number or a string
+type cashfreeID string
+
+func (id *cashfreeID) UnmarshalJSON(data []byte) error {
+	*id = cashfreeID(strings.Trim(string(data), `"`))
+	if *id == "null" {
+		*id = ""
+	}
+	return nil
+}
+
+type cashfreePaymentEntity struct {
+	CFPaymentID    cashfreeID `json:"cf_payment_id"`
+	OrderID        string     `json:"order_id"`
+	PaymentStatus  string     `json:"payment_status"`
+	PaymentAmount  float64    `json:"payment_amount"`
+	PaymentMessage string     `json:"payment_message"`
+	PaymentGroup   string     `json:"payment_group"`
+	BankReference  string     `json:"bank_reference"`
+	PaymentMethod  struct {
+		UPI *struct {
+			UPIID string `json:"upi_id"`
+		} `json:"upi"`
+		Card *struct {
+			CardNumber  string `json:"card_number"`
+			CardNetwork string `json:"card_network"`
+		} `json:"card"`
+		Netbanking *struct {
+			BankName string `json:"netbanking_bank_name"`
+		} `json:"netbanking"`
+	} `json:"payment_method"`
+}
+
+func (e *cashfreePaymentEntity) details(orderID string) *PaymentDetails {
+	p := &PaymentDetails{
+		OrderID:   e.OrderID,
+		PaymentID: string(e.CFPaymentID),
+		Amount:    int64(math.Round(e.PaymentAmount * 100)),
+		UTR:       e.BankReference,
+		Method:    e.PaymentGroup,
+	}
+	if p.OrderID == "" {
+		p.OrderID = orderID
+	}
+
+	switch e.PaymentStatus {
+	case "SUCCESS":
+		p.Status = StatusCaptured
+	case "FAILED", "USER_DROPPED", "CANCELLED", "VOID":
+		p.Status = StatusFailed
+		p.FailureReason = e.PaymentMessage
+		if p.FailureReason == "" {
+			p.FailureReason = "Payment " + strings.ToLower(strings.ReplaceAll(e.PaymentStatus, "_", " "))
+		}
+	default: // NOT_ATTEMPTED, PENDING
+		p.Status = StatusPending
+	}
+
+	if m := e.PaymentMethod; m.UPI != nil {
+		p.VPA = m.UPI.UPIID
+	} else if m.Card != nil {
+		if n := len(m.Card.CardNumber); n >= 4 {
+			p.CardLast4 = m.Card.CardNumber[n-4:]
+		}
+		p.CardNetwork = m.Card.CardNetwork
+	} else if m.Netbanking != nil {
+		p.Bank = m.Netbanking.BankName
+	}
+	return p
+}
+
+type cashfreeRefundEntity struct {
+	CFRefundID        cashfreeID `json:"cf_refund_id"`
+	CFPaymentID       cashfreeID `json:"cf_payment_id"`
+	RefundID          string     `json:"refund_id"`
+	RefundStatus      string     `json:"refund_status"`
+	StatusDescription string     `json:"status_description"`
+}
+
+func (e *cashfreeRefundEntity) refund() *Refund {
+	rf := &Refund{
+		ID:        string(e.CFRefundID),
+		PaymentID: string(e.CFPaymentID),
+		Status:    RefundPending,
+	}
+	if id, err := strconv.Atoi(strings.TrimPrefix(e.RefundID, cashfreeRefundPrefix)); err == nil {
+		rf.ReferenceID = id
+	}
+
+	switch e.RefundStatus {
+	case "SUCCESS":
+		rf.Status = RefundProcessed
+	case "CANCELLED":
+		rf.Status = RefundFailed
+		rf.FailureReason = e.StatusDescription
+	}
+	return rf
+}
@@ -0,0 +1,161 @@
+package payment
+
+import (
+	"net/http"
+	"testing"
+)
+
+// A payment success webhook as Cashfree sends it, signed with cfTestSecret at cfTestTimestamp
+const (
+	cfTestSecret    = "cf_test_secret"
+	cfTestTimestamp = "1718000000123"
+	cfTestBody      = `{"type":"PAYMENT_SUCCESS_WEBHOOK","data":{"order":{"order_id":"cold_42"},"payment":{"cf_payment_id":5114910000000,"payment_status":"SUCCESS","payment_amount":1500.5,"payment_group":"upi","bank_reference":"412345678901","payment_method":{"upi":{"upi_id":"farmer@upi"}}}}}`
+	cfTestSignature = "b35fXPz04zOeOis6ha8ng5RM7huxjaNHF6Y0GJUA6mE="
+)
+
+func TestCashfreeVerifyWebhook(t *testing.T) {
+	tests := []struct {
+		name      string
+		secret    string
+		body      string
+		timestamp string
+		signature string
+		want      bool
+	}{
+		{"valid", cfTestSecret, cfTestBody, cfTestTimestamp, cfTestSignature, true},
+		{"tampered body", cfTestSecret, cfTestBody[:len(cfTestBody)-2] + " }", cfTestTimestamp, cfTestSignature, false},
+		{"other timestamp", cfTestSecret, cfTestBody, "1718000000124", cfTestSignature, false},
+		{"missing signature", cfTestSecret, cfTestBody, cfTestTimestamp, "", false},
+		{"other secret", "other_secret", cfTestBody, cfTestTimestamp, cfTestSignature, false},
+		{"no secret configured", "", cfTestBody, cfTestTimestamp, cfTestSignature, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewCashfreeGateway("app_id", tt.secret, "sandbox")
+			header := http.Header{}
+			header.Set("x-webhook-timestamp", tt.timestamp)
+			if tt.signature != "" {
+				header.Set("x-webhook-signature", tt.signature)
+			}
+			if got := g.VerifyWebhook([]byte(tt.body), header); got != tt.want {
+				t.Errorf("VerifyWebhook = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCashfreeParsePaymentWebhook(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantType string
+		want     PaymentDetails
+	}{
+		{
+			name:     "upi success",
+			body:     cfTestBody,
+			wantType: EventPaymentCaptured,
+			want: PaymentDetails{OrderID: "cold_42", PaymentID: "5114910000000", Status: StatusCaptured,
+				Amount: 150050, UTR: "412345678901", Method: "upi", VPA: "farmer@upi"},
+		},
+		{
+			name:     "card failed",
+			body:     `{"type":"PAYMENT_FAILED_WEBHOOK","data":{"order":{"order_id":"cold_43"},"payment":{"cf_payment_id":"885","order_id":"cold_43","payment_status":"FAILED","payment_amount":200,"payment_message":"Card declined by bank","payment_group":"credit_card","payment_method":{"card":{"card_number":"XXXXXXXXXXXX1234","card_network":"visa"}}}}}`,
+			wantType: EventPaymentFailed,
+			want: PaymentDetails{OrderID: "cold_43", PaymentID: "885", Status: StatusFailed, Amount: 20000,
+				Method: "credit_card", FailureReason: "Card declined by bank", CardLast4: "1234", CardNetwork: "visa"},
+		},
+		{
+			name:     "user dropped without a message",
+			body:     `{"type":"PAYMENT_USER_DROPPED_WEBHOOK","data":{"order":{"order_id":"cold_44"},"payment":{"cf_payment_id":886,"payment_status":"USER_DROPPED","payment_amount":99.99,"payment_group":"net_banking","payment_method":{"netbanking":{"netbanking_bank_name":"State Bank of India"}}}}}`,
+			wantType: EventPaymentFailed,
+			want: PaymentDetails{OrderID: "cold_44", PaymentID: "886", Status: StatusFailed, Amount: 9999,
+				Method: "net_banking", FailureReason: "Payment user dropped", Bank: "State Bank of India"},
+		},
+		{
+			name:     "still pending",
+			body:     `{"type":"PAYMENT_FAILED_WEBHOOK","data":{"order":{"order_id":"cold_45"},"payment":{"cf_payment_id":null,"payment_status":"PENDING","payment_amount":10}}}`,
+			wantType: EventPaymentFailed,
+			want:     PaymentDetails{OrderID: "cold_45", Status: StatusPending, Amount: 1000},
+		},
+	}
+	g := NewCashfreeGateway("app_id", cfTestSecret, "sandbox")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := g.ParseWebhook([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("ParseWebhook: %v", err)
+			}
+			if event.Type != tt.wantType || event.Payment == nil {
+				t.Fatalf("event = %+v, want %s with a payment", event, tt.wantType)
+			}
+			if *event.Payment != tt.want {
+				t.Errorf("payment = %+v, want %+v", *event.Payment, tt.want)
+			}
+		})
+	}
+}
+
+func TestCashfreeParseRefundWebhook(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantType string
+		want     Refund
+	}{
+		{
+			name:     "processed",
+			body:     `{"type":"REFUND_STATUS_WEBHOOK","data":{"refund":{"cf_refund_id":"9001","cf_payment_id":5114910000000,"refund_id":"refund_7","refund_status":"SUCCESS"}}}`,
+			wantType: EventRefundProcessed,
+			want:     Refund{ID: "9001", PaymentID: "5114910000000", ReferenceID: 7, Status: RefundProcessed},
+		},
+		{
+			name:     "cancelled",
+			body:     `{"type":"REFUND_STATUS_WEBHOOK","data":{"refund":{"cf_refund_id":9002,"cf_payment_id":"887","refund_id":"refund_8","refund_status":"CANCELLED","status_description":"Refund rejected by bank"}}}`,
+			wantType: EventRefundFailed,
+			want:     Refund{ID: "9002", PaymentID: "887", ReferenceID: 8, Status: RefundFailed, FailureReason: "Refund rejected by bank"},
+		},
+		{
+			name:     "still pending",
+			body:     `{"type":"REFUND_STATUS_WEBHOOK","data":{"refund":{"cf_refund_id":9003,"cf_payment_id":"888","refund_id":"manual","refund_status":"PENDING"}}}`,
+			wantType: "",
+			want:     Refund{ID: "9003", PaymentID: "888", Status: RefundPending},
+		},
+	}
+	g := NewCashfreeGateway("app_id", cfTestSecret, "sandbox")
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			event, err := g.ParseWebhook([]byte(tt.body))
+			if err != nil {
+				t.Fatalf("ParseWebhook: %v", err)
+			}
+			if event.Type != tt.wantType || event.Refund == nil {
+				t.Fatalf("event = %+v, want %q with a refund", event, tt.wantType)
+			}
+			if *event.Refund != tt.want {
+				t.Errorf("refund = %+v, want %+v", *event.Refund, tt.want)
+			}
+		})
+	}
+}
+
+func TestCashfreeParseWebhookErrors(t *testing.T) {
+	g := NewCashfreeGateway("app_id", cfTestSecret, "sandbox")
+	for name, body := range map[string]string{
+		"invalid json":    `{"type":`,
+		"missing payment": `{"type":"PAYMENT_SUCCESS_WEBHOOK","data":{"order":{"order_id":"cold_42"}}}`,
+		"missing refund":  `{"type":"REFUND_STATUS_WEBHOOK","data":{}}`,
+	} {
+		if _, err := g.ParseWebhook([]byte(body)); err == nil {
+			t.Errorf("ParseWebhook with %s succeeded", name)
+		}
+	}
+
+	event, err := g.ParseWebhook([]byte(`{"type":"SETTLEMENT_WEBHOOK","data":{}}`))
+	if err != nil {
+		t.Fatalf("ParseWebhook of an unhandled event: %v", err)
+	}
+	if event.Type != "" || event.Payment != nil || event.Refund != nil {
+		t.Errorf("unhandled event = %+v, want an empty event", event)
+	}
+}
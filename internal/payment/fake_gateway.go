@@ -0,0 +1,281 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// FakeGateway is an in-memory PaymentGateway for running the checkout, webhook and
+// refund paths without a real provider. Orders are paid with Pay (or by the portal
+// confirming checkout with the order's session ID); webhooks for them are built with
+// PaymentWebhook and RefundWebhook.
+type FakeGateway struct {
+	Secret string // Signs checkout callbacks and webhooks
+
+	mu      sync.Mutex
+	seq     int
+	orders  map[string]*fakeOrder
+	refunds map[string]*Refund
+}
+
+type fakeOrder struct {
+	amount  int64
+	payment *PaymentDetails
+}
+
+// NewFakeGateway creates an empty fake gateway
+func NewFakeGateway(secret string) *FakeGateway {
+	return &FakeGateway{
+		Secret:  secret,
+		orders:  make(map[string]*fakeOrder),
+		refunds: make(map[string]*Refund),
+	}
+}
+
+func (g *FakeGateway) Name() string {
+	return GatewayFake
+}
+
+func (g *FakeGateway) CheckoutKey() string {
+	return "fake"
+}
+
+func (g *FakeGateway) sign(data []byte) string {
+	h := hmac.New(sha256.New, []byte(g.Secret))
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (g *FakeGateway) nextID(prefix string) string {
+	g.seq++
+	return fmt.Sprintf("%s_fake_%d", prefix, g.seq)
+}
+
+// CreateOrder records an unpaid order. Its session ID confirms checkout in VerifyPayment.
+func (g *FakeGateway) CreateOrder(ctx context.Context, req *OrderRequest) (*Order, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	id := g.nextID("order")
+	g.orders[id] = &fakeOrder{amount: req.Amount}
+	return &Order{ID: id, Amount: req.Amount, Currency: "INR", SessionID: g.sign([]byte(id))}, nil
+}
+
+// Pay captures an order as if the customer paid it, returning the payment and the
+// checkout signature VerifyPayment expects for it
+func (g *FakeGateway) Pay(orderID string) (*PaymentDetails, string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	p, err := g.pay(orderID)
+	if err != nil {
+		return nil, "", err
+	}
+	return p, g.sign([]byte(orderID + "|" + p.PaymentID)), nil
+}
+
+func (g *FakeGateway) pay(orderID string) (*PaymentDetails, error) {
+	order, ok := g.orders[orderID]
+	if !ok {
+		return nil, fmt.Errorf("fake order %s not found", orderID)
+	}
+	if order.payment == nil || order.payment.Status != StatusCaptured {
+		order.payment = &PaymentDetails{
+			OrderID:   orderID,
+			PaymentID: g.nextID("pay"),
+			Status:    StatusCaptured,
+			Amount:    order.amount,
+			UTR:       g.nextID("utr"),
+			Method:    "upi",
+			VPA:       "customer@fake",
+		}
+	}
+	c := *order.payment
+	return &c, nil
+}
+
+// Fail records a failed payment attempt on an order
+func (g *FakeGateway) Fail(orderID, reason string) (*PaymentDetails, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	order, ok := g.orders[orderID]
+	if !ok {
+		return nil, fmt.Errorf("fake order %s not found", orderID)
+	}
+	order.payment = &PaymentDetails{
+		OrderID:       orderID,
+		PaymentID:     g.nextID("pay"),
+		Status:        StatusFailed,
+		Amount:        order.amount,
+		FailureReason: reason,
+	}
+	c := *order.payment
+	return &c, nil
+}
+
+// VerifyPayment accepts the signature returned by Pay, or - with no payment ID - the
+// order's session ID, which pays the order
+func (g *FakeGateway) VerifyPayment(ctx context.Context, orderID, paymentID, signature string) (*PaymentDetails, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if paymentID == "" {
+		if !hmac.Equal([]byte(g.sign([]byte(orderID))), []byte(signature)) {
+			return nil, ErrInvalidSignature
+		}
+		return g.pay(orderID)
+	}
+	if !hmac.Equal([]byte(g.sign([]byte(orderID+"|"+paymentID))), []byte(signature)) {
+		return nil, ErrInvalidSignature
+	}
+	order, ok := g.orders[orderID]
+	if !ok || order.payment == nil || order.payment.PaymentID != paymentID {
+		return nil, fmt.Errorf("fake payment %s not found", paymentID)
+	}
+	c := *order.payment
+	return &c, nil
+}
+
+// FetchPayment returns an order's payment (pending if it has not been paid)
+func (g *FakeGateway) FetchPayment(ctx context.Context, orderID, paymentID string) (*PaymentDetails, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	order, ok := g.orders[orderID]
+	if !ok {
+		return nil, fmt.Errorf("fake order %s not found", orderID)
+	}
+	if order.payment == nil {
+		return &PaymentDetails{OrderID: orderID, Status: StatusPending, Amount: order.amount}, nil
+	}
+	c := *order.payment
+	return &c, nil
+}
+
+// VerifyWebhook checks the X-Fake-Signature header (hex HMAC of the body)
+func (g *FakeGateway) VerifyWebhook(body []byte, header http.Header) bool {
+	return hmac.Equal([]byte(g.sign(body)), []byte(header.Get("X-Fake-Signature")))
+}
+
+// ParseWebhook parses a webhook built by PaymentWebhook or RefundWebhook
+func (g *FakeGateway) ParseWebhook(body []byte) (*WebhookEvent, error) {
+	event := &WebhookEvent{}
+	if err := json.Unmarshal(body, event); err != nil {
+		return nil, fmt.Errorf("failed to parse fake webhook: %w", err)
+	}
+	return event, nil
+}
+
+// Refund records a pending refund; RefundWebhook settles it. Orders this instance did
+// not create (paid through another server process) are taken as captured.
+func (g *FakeGateway) Refund(ctx context.Context, req *RefundRequest) (*Refund, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if order, ok := g.orders[req.OrderID]; ok && (order.payment == nil || order.payment.Status != StatusCaptured) {
+		return nil, fmt.Errorf("fake order %s has no captured payment", req.OrderID)
+	}
+	rf := &Refund{
+		ID:          g.nextID("rfnd"),
+		PaymentID:   req.PaymentID,
+		ReferenceID: req.ReferenceID,
+		Status:      RefundPending,
+	}
+	g.refunds[rf.ID] = rf
+	c := *rf
+	return &c, nil
+}
+
+// PaymentWebhook returns a signed payment.captured (or payment.failed) webhook for an
+// order's current payment
+func (g *FakeGateway) PaymentWebhook(orderID string) ([]byte, http.Header, error) {
+	p, err := g.FetchPayment(context.Background(), orderID, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	event := &WebhookEvent{Type: EventPaymentFailed, Payment: p}
+	switch p.Status {
+	case StatusCaptured:
+		event.Type = EventPaymentCaptured
+	case StatusPending:
+		return nil, nil, fmt.Errorf("fake order %s has not been paid", orderID)
+	}
+	return g.webhook(event)
+}
+
+// RefundWebhook settles a pending refund and returns its signed refund.processed (or
+// refund.failed) webhook
+func (g *FakeGateway) RefundWebhook(refundID string, processed bool) ([]byte, http.Header, error) {
+	g.mu.Lock()
+	rf, ok := g.refunds[refundID]
+	if ok {
+		rf.Status = RefundFailed
+		rf.FailureReason = "Refund failed at test gateway"
+		if processed {
+			rf.Status = RefundProcessed
+			rf.FailureReason = ""
+		}
+	}
+	g.mu.Unlock()
+	if !ok {
+		return nil, nil, fmt.Errorf("fake refund %s not found", refundID)
+	}
+
+	event := &WebhookEvent{Type: EventRefundFailed, Refund: rf}
+	if processed {
+		event.Type = EventRefundProcessed
+	}
+	return g.webhook(event)
+}
+
+// SettlementRecon settles every captured payment, deducting a 2% fee plus 18% GST on it
+func (g *FakeGateway) SettlementRecon(ctx context.Context, day time.Time) ([]*SettlementLine, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	settledAt := day
+	var lines []*SettlementLine
+	for orderID, order := range g.orders {
+		p := order.payment
+		if p == nil || p.Status != StatusCaptured {
+			continue
+		}
+		fee := p.Amount * 2 / 100
+		tax := fee * 18 / 100
+		lines = append(lines, &SettlementLine{
+			Type:          SettlementPayment,
+			EntityID:      p.PaymentID,
+			OrderID:       orderID,
+			PaymentID:     p.PaymentID,
+			SettlementID:  "setl_fake_" + day.Format("20060102"),
+			SettlementUTR: "FAKEUTR" + day.Format("20060102"),
+			Amount:        p.Amount,
+			Fee:           fee + tax,
+			Tax:           tax,
+			Credit:        p.Amount - fee - tax,
+			Settled:       true,
+			SettledAt:     &settledAt,
+		})
+	}
+	return lines, nil
+}
+
+func (g *FakeGateway) webhook(event *WebhookEvent) ([]byte, http.Header, error) {
+	g.mu.Lock()
+	body, err := json.Marshal(event)
+	g.mu.Unlock()
+	if err != nil {
+		return nil, nil, err
+	}
+	header := http.Header{}
+	header.Set("X-Fake-Signature", g.sign(body))
+	return body, header, nil
+}
@@ -0,0 +1,218 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func createFakeOrder(t *testing.T, g *FakeGateway, amount int64) *Order {
+	t.Helper()
+	order, err := g.CreateOrder(context.Background(), &OrderRequest{Amount: amount, Receipt: "rcpt_1", CustomerPhone: "9999999999"})
+	if err != nil {
+		t.Fatalf("CreateOrder: %v", err)
+	}
+	return order
+}
+
+func TestFakeGatewayCreateOrder(t *testing.T) {
+	g := NewFakeGateway("secret")
+	order := createFakeOrder(t, g, 50000)
+
+	if order.ID == "" || order.SessionID == "" {
+		t.Fatalf("order missing ID or session ID: %+v", order)
+	}
+	if order.Amount != 50000 || order.Currency != "INR" {
+		t.Errorf("order = %d %s, want 50000 INR", order.Amount, order.Currency)
+	}
+
+	p, err := g.FetchPayment(context.Background(), order.ID, "")
+	if err != nil {
+		t.Fatalf("FetchPayment: %v", err)
+	}
+	if p.Status != StatusPending || p.Amount != 50000 {
+		t.Errorf("unpaid order payment = %s %d, want pending 50000", p.Status, p.Amount)
+	}
+
+	if _, err := g.FetchPayment(context.Background(), "order_unknown", ""); err == nil {
+		t.Error("FetchPayment of an unknown order succeeded")
+	}
+}
+
+func TestFakeGatewayVerifyPayment(t *testing.T) {
+	g := NewFakeGateway("secret")
+	order := createFakeOrder(t, g, 25000)
+
+	paid, signature, err := g.Pay(order.ID)
+	if err != nil {
+		t.Fatalf("Pay: %v", err)
+	}
+	if paid.Status != StatusCaptured || paid.Amount != 25000 || paid.UTR == "" {
+		t.Fatalf("paid = %+v, want captured 25000 with a UTR", paid)
+	}
+
+	p, err := g.VerifyPayment(context.Background(), order.ID, paid.PaymentID, signature)
+	if err != nil {
+		t.Fatalf("VerifyPayment: %v", err)
+	}
+	if p.PaymentID != paid.PaymentID || p.Status != StatusCaptured {
+		t.Errorf("verified payment = %s %s, want %s captured", p.PaymentID, p.Status, paid.PaymentID)
+	}
+
+	// Paying again returns the same captured payment
+	again, _, err := g.Pay(order.ID)
+	if err != nil {
+		t.Fatalf("second Pay: %v", err)
+	}
+	if again.PaymentID != paid.PaymentID {
+		t.Errorf("second Pay made payment %s, want %s", again.PaymentID, paid.PaymentID)
+	}
+
+	if _, err := g.VerifyPayment(context.Background(), order.ID, paid.PaymentID, "bad"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyPayment with a bad signature = %v, want ErrInvalidSignature", err)
+	}
+	other := NewFakeGateway("other")
+	if _, err := other.VerifyPayment(context.Background(), order.ID, paid.PaymentID, signature); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyPayment with another secret = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestFakeGatewayVerifyPaymentWithSessionID(t *testing.T) {
+	g := NewFakeGateway("secret")
+	order := createFakeOrder(t, g, 10000)
+
+	p, err := g.VerifyPayment(context.Background(), order.ID, "", order.SessionID)
+	if err != nil {
+		t.Fatalf("VerifyPayment: %v", err)
+	}
+	if p.Status != StatusCaptured || p.PaymentID == "" {
+		t.Errorf("payment = %+v, want captured", p)
+	}
+
+	fetched, err := g.FetchPayment(context.Background(), order.ID, "")
+	if err != nil {
+		t.Fatalf("FetchPayment: %v", err)
+	}
+	if fetched.PaymentID != p.PaymentID || fetched.Status != StatusCaptured {
+		t.Errorf("fetched = %s %s, want %s captured", fetched.PaymentID, fetched.Status, p.PaymentID)
+	}
+
+	if _, err := g.VerifyPayment(context.Background(), order.ID, "", "bad"); !errors.Is(err, ErrInvalidSignature) {
+		t.Errorf("VerifyPayment with a bad session ID = %v, want ErrInvalidSignature", err)
+	}
+}
+
+func TestFakeGatewayPaymentWebhook(t *testing.T) {
+	g := NewFakeGateway("secret")
+	order := createFakeOrder(t, g, 30000)
+
+	if _, _, err := g.PaymentWebhook(order.ID); err == nil {
+		t.Fatal("PaymentWebhook for an unpaid order succeeded")
+	}
+
+	paid, _, err := g.Pay(order.ID)
+	if err != nil {
+		t.Fatalf("Pay: %v", err)
+	}
+	body, header, err := g.PaymentWebhook(order.ID)
+	if err != nil {
+		t.Fatalf("PaymentWebhook: %v", err)
+	}
+	if !g.VerifyWebhook(body, header) {
+		t.Fatal("VerifyWebhook rejected the gateway's own webhook")
+	}
+	event, err := g.ParseWebhook(body)
+	if err != nil {
+		t.Fatalf("ParseWebhook: %v", err)
+	}
+	if event.Type != EventPaymentCaptured || event.Payment == nil {
+		t.Fatalf("event = %+v, want payment.captured with a payment", event)
+	}
+	if event.Payment.OrderID != order.ID || event.Payment.PaymentID != paid.PaymentID || event.Payment.Amount != 30000 {
+		t.Errorf("event payment = %+v, want order %s payment %s of 30000", event.Payment, order.ID, paid.PaymentID)
+	}
+
+	tampered := append([]byte{}, body...)
+	tampered[len(tampered)-2] = ' '
+	if g.VerifyWebhook(tampered, header) {
+		t.Error("VerifyWebhook accepted a tampered body")
+	}
+	header.Del("X-Fake-Signature")
+	if g.VerifyWebhook(body, header) {
+		t.Error("VerifyWebhook accepted an unsigned body")
+	}
+}
+
+func TestFakeGatewayFailedPaymentWebhook(t *testing.T) {
+	g := NewFakeGateway("secret")
+	order := createFakeOrder(t, g, 30000)
+
+	if _, err := g.Fail(order.ID, "card declined"); err != nil {
+		t.Fatalf("Fail: %v", err)
+	}
+	body, header, err := g.PaymentWebhook(order.ID)
+	if err != nil {
+		t.Fatalf("PaymentWebhook: %v", err)
+	}
+	if !g.VerifyWebhook(body, header) {
+		t.Fatal("VerifyWebhook rejected the gateway's own webhook")
+	}
+	event, err := g.ParseWebhook(body)
+	if err != nil {
+		t.Fatalf("ParseWebhook: %v", err)
+	}
+	if event.Type != EventPaymentFailed || event.Payment == nil || event.Payment.FailureReason != "card declined" {
+		t.Errorf("event = %+v, want payment.failed with the failure reason", event)
+	}
+}
+
+func TestFakeGatewayRefundWebhook(t *testing.T) {
+	g := NewFakeGateway("secret")
+	order := createFakeOrder(t, g, 40000)
+
+	req := &RefundRequest{OrderID: order.ID, PaymentID: "pay_x", Amount: 10000, ReferenceID: 7}
+	if _, err := g.Refund(context.Background(), req); err == nil {
+		t.Fatal("Refund of an unpaid order succeeded")
+	}
+
+	paid, _, err := g.Pay(order.ID)
+	if err != nil {
+		t.Fatalf("Pay: %v", err)
+	}
+	req.PaymentID = paid.PaymentID
+	rf, err := g.Refund(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Refund: %v", err)
+	}
+	if rf.Status != RefundPending || rf.ReferenceID != 7 {
+		t.Fatalf("refund = %+v, want pending with reference 7", rf)
+	}
+
+	for _, processed := range []bool{true, false} {
+		body, header, err := g.RefundWebhook(rf.ID, processed)
+		if err != nil {
+			t.Fatalf("RefundWebhook(%t): %v", processed, err)
+		}
+		if !g.VerifyWebhook(body, header) {
+			t.Fatalf("VerifyWebhook rejected refund webhook (%t)", processed)
+		}
+		event, err := g.ParseWebhook(body)
+		if err != nil {
+			t.Fatalf("ParseWebhook: %v", err)
+		}
+		wantType, wantStatus := EventRefundFailed, RefundFailed
+		if processed {
+			wantType, wantStatus = EventRefundProcessed, RefundProcessed
+		}
+		if event.Type != wantType || event.Refund == nil || event.Refund.Status != wantStatus {
+			t.Fatalf("event = %+v, want %s", event, wantType)
+		}
+		if event.Refund.ID != rf.ID || event.Refund.ReferenceID != 7 || event.Refund.PaymentID != paid.PaymentID {
+			t.Errorf("event refund = %+v, want %s for payment %s with reference 7", event.Refund, rf.ID, paid.PaymentID)
+		}
+	}
+
+	if _, _, err := g.RefundWebhook("rfnd_unknown", true); err == nil {
+		t.Error("RefundWebhook of an unknown refund succeeded")
+	}
+}
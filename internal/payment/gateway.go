@@ -0,0 +1,132 @@
+package payment
+
+import (
+	"context"
+	"errors"
+	"net/http"
+)
+
+// Gateway names, stored on each online transaction and selected by the payment_gateway setting
+const (
+	GatewayRazorpay = "razorpay"
+	GatewayCashfree = "cashfree"
+	GatewayFake     = "fake" // In-memory gateway for exercising checkout and webhooks locally
+)
+
+// Payment statuses, normalised across gateways
+const (
+	StatusCaptured = "captured"
+	StatusFailed   = "failed"
+	StatusPending  = "pending" // Customer has not finished paying (or the gateway has not confirmed it yet)
+)
+
+// Refund statuses, normalised across gateways
+const (
+	RefundProcessed = "processed"
+	RefundPending   = "pending"
+	RefundFailed    = "failed"
+)
+
+// Webhook event types, normalised across gateways
+const (
+	EventPaymentCaptured = "payment.captured"
+	EventPaymentFailed   = "payment.failed"
+	EventRefundProcessed = "refund.processed"
+	EventRefundFailed    = "refund.failed"
+)
+
+var (
+	// ErrNotConfigured is returned when a gateway's credentials are missing
+	ErrNotConfigured = errors.New("payment gateway not configured")
+	// ErrInvalidSignature is returned when a checkout callback fails signature verification
+	ErrInvalidSignature = errors.New("invalid payment signature")
+)
+
+// PaymentGateway is an online payment provider. Amounts are in paise.
+type PaymentGateway interface {
+	// Name returns the gateway name stored on transactions (GatewayRazorpay, ...)
+	Name() string
+	// CheckoutKey returns what the portal's checkout script needs to start: the Razorpay
+	// key ID, or the Cashfree environment
+	CheckoutKey() string
+	CreateOrder(ctx context.Context, req *OrderRequest) (*Order, error)
+	// VerifyPayment checks a checkout callback and returns the payment it reports
+	VerifyPayment(ctx context.Context, orderID, paymentID, signature string) (*PaymentDetails, error)
+	// FetchPayment returns a payment's current state; without a payment ID, the order's
+	// latest payment
+	FetchPayment(ctx context.Context, orderID, paymentID string) (*PaymentDetails, error)
+	VerifyWebhook(body []byte, header http.Header) bool
+	ParseWebhook(body []byte) (*WebhookEvent, error)
+	Refund(ctx context.Context, req *RefundRequest) (*Refund, error)
+}
+
+// OrderRequest is an order to collect money from a customer
+type OrderRequest struct {
+	Amount        int64 // Paise
+	Receipt       string
+	CustomerID    int
+	CustomerName  string
+	CustomerPhone string
+	Notes         map[string]string
+}
+
+// Order is an order created at the gateway
+type Order struct {
+	ID        string
+	Amount    int64
+	Currency  string
+	SessionID string // Checkout session for gateways that need one (Cashfree payment_session_id)
+}
+
+// PaymentDetails is a payment as reported by the gateway
+type PaymentDetails struct {
+	OrderID       string `json:"order_id"`
+	PaymentID     string `json:"payment_id"`
+	Status        string `json:"status"`
+	Amount        int64  `json:"amount"`
+	UTR           string `json:"utr,omitempty"`
+	Method        string `json:"method,omitempty"`
+	Bank          string `json:"bank,omitempty"`
+	VPA           string `json:"vpa,omitempty"`
+	CardLast4     string `json:"card_last4,omitempty"`
+	CardNetwork   string `json:"card_network,omitempty"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// RefundRequest refunds part or all of a captured payment
+type RefundRequest struct {
+	OrderID     string
+	PaymentID   string
+	Amount      int64
+	ReferenceID int // Our online_refunds ID, echoed back in refund webhooks
+	Notes       map[string]string
+}
+
+// Refund is a refund as reported by the gateway
+type Refund struct {
+	ID            string `json:"id"`
+	PaymentID     string `json:"payment_id,omitempty"`
+	ReferenceID   int    `json:"reference_id,omitempty"` // 0 if the refund was issued outside the app
+	Status        string `json:"status"`
+	FailureReason string `json:"failure_reason,omitempty"`
+}
+
+// WebhookEvent is a parsed webhook. Payment is set for payment events, Refund for refund
+// events; Type is empty for events the app does not handle.
+type WebhookEvent struct {
+	Type    string          `json:"type"`
+	Payment *PaymentDetails `json:"payment,omitempty"`
+	Refund  *Refund         `json:"refund,omitempty"`
+}
+
+// DisplayName returns a gateway name for descriptions and notes
+func DisplayName(gateway string) string {
+	switch gateway {
+	case GatewayCashfree:
+		return "Cashfree"
+	case GatewayFake:
+		return "Test gateway"
+	default:
+		return "Razorpay"
+	}
+}
@@ -0,0 +1,249 @@
+package payment
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	razorpay "github.com/razorpay/razorpay-go"
+)
+
+// RazorpayGateway implements PaymentGateway for Razorpay
+type RazorpayGateway struct {
+	KeyID         string
+	KeySecret     string
+	WebhookSecret string
+	client        *razorpay.Client
+}
+
+// NewRazorpayGateway creates a Razorpay gateway. Webhooks are accepted unverified when
+// no webhook secret is set.
+func NewRazorpayGateway(keyID, keySecret, webhookSecret string) *RazorpayGateway {
+	return &RazorpayGateway{
+		KeyID:         keyID,
+		KeySecret:     keySecret,
+		WebhookSecret: webhookSecret,
+		client:        razorpay.NewClient(keyID, keySecret),
+	}
+}
+
+func (g *RazorpayGateway) Name() string {
+	return GatewayRazorpay
+}
+
+// CheckoutKey returns the key ID Razorpay Checkout opens with
+func (g *RazorpayGateway) CheckoutKey() string {
+	return g.KeyID
+}
+
+// CreateOrder creates a Razorpay order
+func (g *RazorpayGateway) CreateOrder(ctx context.Context, req *OrderRequest) (*Order, error) {
+	notes := map[string]interface{}{
+		"customer_id":    req.CustomerID,
+		"customer_phone": req.CustomerPhone,
+	}
+	for k, v := range req.Notes {
+		notes[k] = v
+	}
+
+	order, err := g.client.Order.Create(map[string]interface{}{
+		"amount":   req.Amount,
+		"currency": "INR",
+		"receipt":  req.Receipt,
+		"notes":    notes,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create razorpay order: %w", err)
+	}
+
+	orderID, _ := order["id"].(string)
+	if orderID == "" {
+		return nil, fmt.Errorf("razorpay returned an order without an id")
+	}
+	return &Order{ID: orderID, Amount: req.Amount, Currency: "INR"}, nil
+}
+
+// VerifyPayment checks the checkout signature (HMAC of order_id|payment_id with the key
+// secret). A valid signature means the payment is captured; the payment is fetched only
+// for its UTR and method details.
+func (g *RazorpayGateway) VerifyPayment(ctx context.Context, orderID, paymentID, signature string) (*PaymentDetails, error) {
+	if g.KeySecret == "" {
+		return nil, ErrNotConfigured
+	}
+	h := hmac.New(sha256.New, []byte(g.KeySecret))
+	h.Write([]byte(orderID + "|" + paymentID))
+	if !hmac.Equal([]byte(hex.EncodeToString(h.Sum(nil))), []byte(signature)) {
+		return nil, ErrInvalidSignature
+	}
+
+	details, err := g.FetchPayment(ctx, orderID, paymentID)
+	if err != nil {
+		log.Printf("[Razorpay] Failed to fetch payment details: %v", err)
+		details = &PaymentDetails{OrderID: orderID, PaymentID: paymentID}
+	}
+	details.Status = StatusCaptured
+	return details, nil
+}
+
+// FetchPayment fetches a payment, or the latest payment of an order when paymentID is blank
+func (g *RazorpayGateway) FetchPayment(ctx context.Context, orderID, paymentID string) (*PaymentDetails, error) {
+	if paymentID != "" {
+		entity, err := g.client.Payment.Fetch(paymentID, nil, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch razorpay payment: %w", err)
+		}
+		return razorpayPayment(entity), nil
+	}
+
+	result, err := g.client.Order.Payments(orderID, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch razorpay order payments: %w", err)
+	}
+	items, _ := result["items"].([]interface{})
+	var latest *PaymentDetails
+	for _, item := range items {
+		entity, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		p := razorpayPayment(entity)
+		if p.Status == StatusCaptured {
+			return p, nil
+		}
+		if latest == nil {
+			latest = p
+		}
+	}
+	if latest == nil {
+		return &PaymentDetails{OrderID: orderID, Status: StatusPending}, nil
+	}
+	return latest, nil
+}
+
+// VerifyWebhook checks the X-Razorpay-Signature header (hex HMAC of the body with the webhook secret)
+func (g *RazorpayGateway) VerifyWebhook(body []byte, header http.Header) bool {
+	if g.WebhookSecret == "" {
+		return true // Skip verification if not configured
+	}
+	h := hmac.New(sha256.New, []byte(g.WebhookSecret))
+	h.Write(body)
+	expected := hex.EncodeToString(h.Sum(nil))
+	return hmac.Equal([]byte(expected), []byte(header.Get("X-Razorpay-Signature")))
+}
+
+// ParseWebhook parses payment.captured, payment.failed, refund.processed and refund.failed events
+func (g *RazorpayGateway) ParseWebhook(body []byte) (*WebhookEvent, error) {
+	var payload struct {
+		Event   string `json:"event"`
+		Payload map[string]struct {
+			Entity map[string]interface{} `json:"entity"`
+		} `json:"payload"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("failed to parse razorpay webhook: %w", err)
+	}
+
+	event := &WebhookEvent{}
+	switch payload.Event {
+	case EventPaymentCaptured, EventPaymentFailed:
+		entity := payload.Payload["payment"].Entity
+		if entity == nil {
+			return nil, fmt.Errorf("missing payment in razorpay webhook")
+		}
+		event.Type = payload.Event
+		event.Payment = razorpayPayment(entity)
+	case EventRefundProcessed, EventRefundFailed:
+		entity := payload.Payload["refund"].Entity
+		if entity == nil {
+			return nil, fmt.Errorf("missing refund in razorpay webhook")
+		}
+		event.Type = payload.Event
+		event.Refund = razorpayRefund(entity)
+	default:
+		log.Printf("[Razorpay] Unhandled webhook event: %s", payload.Event)
+	}
+	return event, nil
+}
+
+// Refund refunds a captured payment. Razorpay confirms most refunds later through the
+// refund.processed / refund.failed webhooks.
+func (g *RazorpayGateway) Refund(ctx context.Context, req *RefundRequest) (*Refund, error) {
+	notes := map[string]interface{}{"refund_id": strconv.Itoa(req.ReferenceID)}
+	for k, v := range req.Notes {
+		notes[k] = v
+	}
+
+	result, err := g.client.Payment.Refund(req.PaymentID, int(req.Amount), map[string]interface{}{
+		"speed": "normal",
+		"notes": notes,
+	}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create razorpay refund: %w", err)
+	}
+	return razorpayRefund(result), nil
+}
+
+// razorpayPayment reads a Razorpay payment entity
+func razorpayPayment(entity map[string]interface{}) *PaymentDetails {
+	p := &PaymentDetails{}
+	p.OrderID, _ = entity["order_id"].(string)
+	p.PaymentID, _ = entity["id"].(string)
+	p.Method, _ = entity["method"].(string)
+	p.Bank, _ = entity["bank"].(string)
+	p.VPA, _ = entity["vpa"].(string)
+	p.FailureReason, _ = entity["error_description"].(string)
+	if amount, ok := entity["amount"].(float64); ok {
+		p.Amount = int64(amount)
+	}
+
+	switch status, _ := entity["status"].(string); status {
+	case "captured":
+		p.Status = StatusCaptured
+	case "failed":
+		p.Status = StatusFailed
+	default: // created, authorized
+		p.Status = StatusPending
+	}
+
+	if acquirer, ok := entity["acquirer_data"].(map[string]interface{}); ok {
+		for _, key := range []string{"upi_transaction_id", "bank_transaction_id", "rrn"} {
+			if utr, ok := acquirer[key].(string); ok && utr != "" {
+				p.UTR = utr
+				break
+			}
+		}
+	}
+	if card, ok := entity["card"].(map[string]interface{}); ok {
+		p.CardLast4, _ = card["last4"].(string)
+		p.CardNetwork, _ = card["network"].(string)
+	}
+	return p
+}
+
+// razorpayRefund reads a Razorpay refund entity. The refund_id note carries our refund ID.
+func razorpayRefund(entity map[string]interface{}) *Refund {
+	rf := &Refund{Status: RefundPending}
+	rf.ID, _ = entity["id"].(string)
+	rf.PaymentID, _ = entity["payment_id"].(string)
+	rf.FailureReason, _ = entity["error_description"].(string)
+
+	switch status, _ := entity["status"].(string); status {
+	case "processed":
+		rf.Status = RefundProcessed
+	case "failed":
+		rf.Status = RefundFailed
+	}
+
+	if notes, ok := entity["notes"].(map[string]interface{}); ok {
+		if id, err := strconv.Atoi(fmt.Sprint(notes["refund_id"])); err == nil {
+			rf.ReferenceID = id
+		}
+	}
+	return rf
+}
@@ -0,0 +1,213 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Settlement line types
+const (
+	SettlementPayment    = "payment"
+	SettlementRefund     = "refund"
+	SettlementAdjustment = "adjustment" // Disputes, transfers and other gateway adjustments
+)
+
+// SettlementLine is one payment, refund or adjustment in a gateway's settlement report.
+// Amounts are in paise.
+type SettlementLine struct {
+	Type          string
+	EntityID      string // Payment, refund or adjustment ID at the gateway
+	OrderID       string
+	PaymentID     string
+	SettlementID  string
+	SettlementUTR string // Bank reference of the payout
+	Amount        int64  // Gross amount of the payment or refund
+	Fee           int64  // Gateway fee deducted, including GST
+	Tax           int64  // GST part of the fee
+	Credit        int64  // Added to the payout
+	Debit         int64  // Taken from the payout
+	Settled       bool
+	SettledAt     *time.Time
+}
+
+// SettlementReporter is implemented by gateways that can list what they settled on a day
+type SettlementReporter interface {
+	SettlementRecon(ctx context.Context, day time.Time) ([]*SettlementLine, error)
+}
+
+// SettlementRecon returns the transactions Razorpay settled on a day (the combined
+// settlement recon report)
+func (g *RazorpayGateway) SettlementRecon(ctx context.Context, day time.Time) ([]*SettlementLine, error) {
+	if g.KeySecret == "" {
+		return nil, ErrNotConfigured
+	}
+
+	var lines []*SettlementLine
+	for skip := 0; ; skip += 1000 {
+		result, err := g.client.Settlement.Reports(map[string]interface{}{
+			"year":  day.Year(),
+			"month": int(day.Month()),
+			"day":   day.Day(),
+			"count": 1000,
+			"skip":  skip,
+		}, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch razorpay settlement report: %w", err)
+		}
+		items, _ := result["items"].([]interface{})
+		for _, item := range items {
+			if entity, ok := item.(map[string]interface{}); ok {
+				lines = append(lines, razorpaySettlementLine(entity))
+			}
+		}
+		if len(items) < 1000 {
+			return lines, nil
+		}
+	}
+}
+
+// razorpaySettlementLine reads a settlement recon item (amounts in paise)
+func razorpaySettlementLine(item map[string]interface{}) *SettlementLine {
+	str := func(key string) string {
+		s, _ := item[key].(string)
+		return s
+	}
+	paise := func(key string) int64 {
+		f, _ := item[key].(float64)
+		return int64(f)
+	}
+
+	l := &SettlementLine{
+		Type:          settlementType(str("type")),
+		EntityID:      str("entity_id"),
+		OrderID:       str("order_id"),
+		PaymentID:     str("payment_id"),
+		SettlementID:  str("settlement_id"),
+		SettlementUTR: str("settlement_utr"),
+		Amount:        paise("amount"),
+		Fee:           paise("fee"),
+		Tax:           paise("tax"),
+		Credit:        paise("credit"),
+		Debit:         paise("debit"),
+	}
+	l.Settled, _ = item["settled"].(bool)
+	if settledAt, ok := item["settled_at"].(float64); ok && settledAt > 0 {
+		t := time.Unix(int64(settledAt), 0)
+		l.SettledAt = &t
+	}
+	if l.PaymentID == "" && l.Type == SettlementPayment {
+		l.PaymentID = l.EntityID
+	}
+	return l
+}
+
+// ParseRazorpaySettlementCSV reads a settlement recon report downloaded from the Razorpay
+// dashboard. The report has the API's columns (entity_id, type, debit, credit, amount,
+// fee, tax, settled, settled_at, settlement_id, settlement_utr, order_id, payment_id, ...)
+// with amounts in rupees.
+func ParseRazorpaySettlementCSV(data []byte) ([]*SettlementLine, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	if len(rows) < 2 {
+		return nil, errors.New("settlement report is empty")
+	}
+
+	col := make(map[string]int)
+	for i, h := range rows[0] {
+		col[strings.ToLower(strings.TrimSpace(h))] = i
+	}
+	for _, required := range []string{"entity_id", "type", "amount", "fee"} {
+		if _, ok := col[required]; !ok {
+			return nil, fmt.Errorf("not a Razorpay settlement report: missing %s column", required)
+		}
+	}
+
+	var lines []*SettlementLine
+	for _, row := range rows[1:] {
+		get := func(key string) string {
+			i, ok := col[key]
+			if !ok || i >= len(row) {
+				return ""
+			}
+			return strings.TrimSpace(row[i])
+		}
+		paise := func(key string) int64 {
+			f, _ := strconv.ParseFloat(strings.ReplaceAll(get(key), ",", ""), 64)
+			return int64(math.Round(f * 100))
+		}
+
+		if get("entity_id") == "" {
+			continue
+		}
+		l := &SettlementLine{
+			Type:          settlementType(get("type")),
+			EntityID:      get("entity_id"),
+			OrderID:       get("order_id"),
+			PaymentID:     get("payment_id"),
+			SettlementID:  get("settlement_id"),
+			SettlementUTR: get("settlement_utr"),
+			Amount:        paise("amount"),
+			Fee:           paise("fee"),
+			Tax:           paise("tax"),
+			Credit:        paise("credit"),
+			Debit:         paise("debit"),
+		}
+		settled := strings.ToLower(get("settled"))
+		l.Settled = settled == "1" || settled == "true" || settled == "yes"
+		if t, ok := parseReportTime(get("settled_at")); ok {
+			l.SettledAt = &t
+		}
+		if l.PaymentID == "" && l.Type == SettlementPayment {
+			l.PaymentID = l.EntityID
+		}
+		lines = append(lines, l)
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("no transactions found in the settlement report")
+	}
+	return lines, nil
+}
+
+func settlementType(t string) string {
+	switch strings.ToLower(strings.TrimSpace(t)) {
+	case "payment":
+		return SettlementPayment
+	case "refund":
+		return SettlementRefund
+	default:
+		return SettlementAdjustment
+	}
+}
+
+var reportTimeLayouts = []string{
+	"02/01/2006 15:04:05", "02/01/2006 15:04", "02/01/2006",
+	"2006-01-02 15:04:05", "2006-01-02T15:04:05Z07:00", "2006-01-02",
+}
+
+// parseReportTime parses the timestamps in downloaded reports (day-first dates or unix seconds)
+func parseReportTime(v string) (time.Time, bool) {
+	if v == "" {
+		return time.Time{}, false
+	}
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil && secs > 0 {
+		return time.Unix(secs, 0), true
+	}
+	for _, layout := range reportTimeLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
@@ -0,0 +1,294 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AccountingPeriodRepository stores closed periods, their balance snapshots and reopen requests
+type AccountingPeriodRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewAccountingPeriodRepository(db *pgxpool.Pool) *AccountingPeriodRepository {
+	return &AccountingPeriodRepository{DB: db}
+}
+
+const accountingPeriodColumns = `p.id, p.fiscal_year, p.period_number, p.start_date, p.end_date, p.status,
+	p.closed_at, p.closed_by_user_id, COALESCE(u.name, ''), p.reopened_at, p.reopened_by_user_id,
+	(SELECT COUNT(*) FROM accounting_period_balances b WHERE b.period_id = p.id),
+	(SELECT COALESCE(SUM(b.closing_balance), 0) FROM accounting_period_balances b WHERE b.period_id = p.id)`
+
+const accountingPeriodFrom = `FROM accounting_periods p LEFT JOIN users u ON u.id = p.closed_by_user_id`
+
+func scanAccountingPeriod(row pgx.Row) (*models.AccountingPeriod, error) {
+	p := &models.AccountingPeriod{}
+	err := row.Scan(&p.ID, &p.FiscalYear, &p.PeriodNumber, &p.StartDate, &p.EndDate, &p.Status,
+		&p.ClosedAt, &p.ClosedByUserID, &p.ClosedByName, &p.ReopenedAt, &p.ReopenedByUserID,
+		&p.CustomerCount, &p.TotalClosingBalance)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Get returns a period by ID
+func (r *AccountingPeriodRepository) Get(ctx context.Context, id int) (*models.AccountingPeriod, error) {
+	p, err := scanAccountingPeriod(r.DB.QueryRow(ctx, `SELECT `+accountingPeriodColumns+` `+accountingPeriodFrom+` WHERE p.id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get accounting period: %w", err)
+	}
+	return p, nil
+}
+
+// GetLatestClosed returns the closed period with the latest end date (nil if none)
+func (r *AccountingPeriodRepository) GetLatestClosed(ctx context.Context) (*models.AccountingPeriod, error) {
+	p, err := scanAccountingPeriod(r.DB.QueryRow(ctx, `SELECT `+accountingPeriodColumns+` `+accountingPeriodFrom+`
+		WHERE p.status = 'closed' ORDER BY p.end_date DESC LIMIT 1`))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest closed period: %w", err)
+	}
+	return p, nil
+}
+
+// ListByFiscalYear returns the stored periods of a fiscal year in month order
+func (r *AccountingPeriodRepository) ListByFiscalYear(ctx context.Context, fiscalYear string) ([]*models.AccountingPeriod, error) {
+	rows, err := r.DB.Query(ctx, `SELECT `+accountingPeriodColumns+` `+accountingPeriodFrom+`
+		WHERE p.fiscal_year = $1 ORDER BY p.start_date`, fiscalYear)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounting periods: %w", err)
+	}
+	defer rows.Close()
+
+	var periods []*models.AccountingPeriod
+	for rows.Next() {
+		p, err := scanAccountingPeriod(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan accounting period: %w", err)
+		}
+		periods = append(periods, p)
+	}
+	return periods, nil
+}
+
+// GetFirstLedgerDate returns the date of the oldest ledger entry (nil if the ledger is empty)
+func (r *AccountingPeriodRepository) GetFirstLedgerDate(ctx context.Context) (*time.Time, error) {
+	var first *time.Time
+	if err := r.DB.QueryRow(ctx, `SELECT MIN(created_at) FROM ledger_entries`).Scan(&first); err != nil {
+		return nil, fmt.Errorf("failed to get first ledger date: %w", err)
+	}
+	return first, nil
+}
+
+// Close marks a period closed and snapshots every customer's cumulative balance at its end date
+func (r *AccountingPeriodRepository) Close(ctx context.Context, p *models.AccountingPeriod, userID int) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO accounting_periods (fiscal_year, period_number, start_date, end_date, status, closed_at, closed_by_user_id)
+		VALUES ($1, $2, $3, $4, 'closed', NOW(), $5)
+		ON CONFLICT (start_date) DO UPDATE
+		SET status = 'closed', closed_at = NOW(), closed_by_user_id = EXCLUDED.closed_by_user_id
+		RETURNING id, status, closed_at
+	`, p.FiscalYear, p.PeriodNumber, p.StartDate, p.EndDate, userID).Scan(&p.ID, &p.Status, &p.ClosedAt)
+	if err != nil {
+		return fmt.Errorf("failed to close accounting period: %w", err)
+	}
+	p.ClosedByUserID = &userID
+
+	// Re-closing a reopened period replaces its snapshot
+	if _, err := tx.Exec(ctx, `DELETE FROM accounting_period_balances WHERE period_id = $1`, p.ID); err != nil {
+		return fmt.Errorf("failed to clear period balances: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+		INSERT INTO accounting_period_balances (period_id, customer_phone, customer_name, total_debit, total_credit, closing_balance)
+		SELECT $1, customer_phone, (ARRAY_AGG(customer_name ORDER BY id DESC))[1],
+		       SUM(debit), SUM(credit), SUM(debit) - SUM(credit)
+		FROM ledger_entries
+		WHERE created_at < $2::date + 1
+		GROUP BY customer_phone
+	`, p.ID, p.EndDate)
+	if err != nil {
+		return fmt.Errorf("failed to snapshot period balances: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListBalances returns the closing balance snapshot of a period
+func (r *AccountingPeriodRepository) ListBalances(ctx context.Context, periodID int) ([]models.AccountingPeriodBalance, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT period_id, customer_phone, customer_name, total_debit, total_credit, closing_balance
+		FROM accounting_period_balances
+		WHERE period_id = $1
+		ORDER BY customer_name, customer_phone
+	`, periodID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list period balances: %w", err)
+	}
+	defer rows.Close()
+
+	var balances []models.AccountingPeriodBalance
+	for rows.Next() {
+		var b models.AccountingPeriodBalance
+		if err := rows.Scan(&b.PeriodID, &b.CustomerPhone, &b.CustomerName, &b.TotalDebit, &b.TotalCredit, &b.ClosingBalance); err != nil {
+			return nil, fmt.Errorf("failed to scan period balance: %w", err)
+		}
+		balances = append(balances, b)
+	}
+	return balances, nil
+}
+
+const periodReopenColumns = `q.id, q.period_id, p.fiscal_year, p.start_date, q.reason,
+	q.requested_by, COALESCE(ru.name, ''), q.requested_at, q.status,
+	q.reviewed_by, COALESCE(vu.name, ''), q.reviewed_at, COALESCE(q.rejection_reason, ''), q.expires_at`
+
+const periodReopenFrom = `FROM accounting_period_reopen_requests q
+	JOIN accounting_periods p ON p.id = q.period_id
+	LEFT JOIN users ru ON ru.id = q.requested_by
+	LEFT JOIN users vu ON vu.id = q.reviewed_by`
+
+func scanPeriodReopenRequest(row pgx.Row) (*models.PeriodReopenRequest, error) {
+	q := &models.PeriodReopenRequest{}
+	err := row.Scan(&q.ID, &q.PeriodID, &q.FiscalYear, &q.PeriodStart, &q.Reason,
+		&q.RequestedBy, &q.RequestedByName, &q.RequestedAt, &q.Status,
+		&q.ReviewedBy, &q.ReviewedByName, &q.ReviewedAt, &q.RejectionReason, &q.ExpiresAt)
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// CreateReopenRequest records a request to reopen a closed period
+func (r *AccountingPeriodRepository) CreateReopenRequest(ctx context.Context, q *models.PeriodReopenRequest) error {
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO accounting_period_reopen_requests (period_id, reason, requested_by)
+		VALUES ($1, $2, $3)
+		RETURNING id, requested_at, status, expires_at
+	`, q.PeriodID, q.Reason, q.RequestedBy).Scan(&q.ID, &q.RequestedAt, &q.Status, &q.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to create reopen request: %w", err)
+	}
+	return nil
+}
+
+// GetReopenRequest returns a reopen request by ID
+func (r *AccountingPeriodRepository) GetReopenRequest(ctx context.Context, id int) (*models.PeriodReopenRequest, error) {
+	q, err := scanPeriodReopenRequest(r.DB.QueryRow(ctx, `SELECT `+periodReopenColumns+` `+periodReopenFrom+` WHERE q.id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get reopen request: %w", err)
+	}
+	return q, nil
+}
+
+// HasPendingReopen reports whether a period has an unexpired pending reopen request
+func (r *AccountingPeriodRepository) HasPendingReopen(ctx context.Context, periodID int) (bool, error) {
+	var exists bool
+	err := r.DB.QueryRow(ctx, `
+		SELECT EXISTS (
+			SELECT 1 FROM accounting_period_reopen_requests
+			WHERE period_id = $1 AND status = 'pending' AND expires_at > NOW()
+		)`, periodID).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check pending reopen: %w", err)
+	}
+	return exists, nil
+}
+
+// ListReopenRequests returns reopen requests, optionally filtered by status, newest first
+func (r *AccountingPeriodRepository) ListReopenRequests(ctx context.Context, status string, limit int) ([]*models.PeriodReopenRequest, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := r.DB.Query(ctx, `SELECT `+periodReopenColumns+` `+periodReopenFrom+`
+		WHERE ($1 = '' OR q.status = $1)
+		ORDER BY q.requested_at DESC
+		LIMIT $2`, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reopen requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []*models.PeriodReopenRequest
+	for rows.Next() {
+		q, err := scanPeriodReopenRequest(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reopen request: %w", err)
+		}
+		requests = append(requests, q)
+	}
+	return requests, nil
+}
+
+// ApproveReopen approves a pending request and reopens its period in one transaction
+func (r *AccountingPeriodRepository) ApproveReopen(ctx context.Context, id, approverID int) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var periodID int
+	err = tx.QueryRow(ctx, `
+		UPDATE accounting_period_reopen_requests
+		SET status = 'approved', reviewed_by = $2, reviewed_at = NOW()
+		WHERE id = $1 AND status = 'pending' AND expires_at > NOW()
+		RETURNING period_id
+	`, id, approverID).Scan(&periodID)
+	if err == pgx.ErrNoRows {
+		return fmt.Errorf("reopen request not found, expired or already processed")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to approve reopen request: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE accounting_periods
+		SET status = 'open', reopened_at = NOW(), reopened_by_user_id = $2
+		WHERE id = $1
+	`, periodID, approverID)
+	if err != nil {
+		return fmt.Errorf("failed to reopen accounting period: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// RejectReopen rejects a pending reopen request
+func (r *AccountingPeriodRepository) RejectReopen(ctx context.Context, id, reviewerID int, reason string) error {
+	result, err := r.DB.Exec(ctx, `
+		UPDATE accounting_period_reopen_requests
+		SET status = 'rejected', reviewed_by = $2, reviewed_at = NOW(), rejection_reason = $3
+		WHERE id = $1 AND status = 'pending'
+	`, id, reviewerID, reason)
+	if err != nil {
+		return fmt.Errorf("failed to reject reopen request: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("reopen request not found or already processed")
+	}
+	return nil
+}
+
+// ExpireOldReopenRequests marks pending requests past their expiry as expired
+func (r *AccountingPeriodRepository) ExpireOldReopenRequests(ctx context.Context) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE accounting_period_reopen_requests
+		SET status = 'expired'
+		WHERE status = 'pending' AND expires_at <= NOW()
+	`)
+	return err
+}
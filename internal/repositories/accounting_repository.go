@@ -0,0 +1,331 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// AccountingRepository stores the chart of accounts and the double-entry journal
+type AccountingRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewAccountingRepository(db *pgxpool.Pool) *AccountingRepository {
+	return &AccountingRepository{DB: db}
+}
+
+const accountColumns = `a.id, a.code, a.name, a.account_type, a.is_system, a.is_active, COALESCE(a.description, ''), a.created_at`
+
+func scanAccount(row pgx.Row) (*models.Account, error) {
+	a := &models.Account{}
+	err := row.Scan(&a.ID, &a.Code, &a.Name, &a.AccountType, &a.IsSystem, &a.IsActive, &a.Description, &a.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// ListAccounts returns the chart of accounts ordered by code
+func (r *AccountingRepository) ListAccounts(ctx context.Context, activeOnly bool) ([]*models.Account, error) {
+	query := `SELECT ` + accountColumns + ` FROM accounts a WHERE ($1 = FALSE OR a.is_active = TRUE) ORDER BY a.code`
+	rows, err := r.DB.Query(ctx, query, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	}
+	defer rows.Close()
+
+	var accounts []*models.Account
+	for rows.Next() {
+		a, err := scanAccount(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan account: %w", err)
+		}
+		accounts = append(accounts, a)
+	}
+	return accounts, nil
+}
+
+// GetAccountByCode returns an account by its code
+func (r *AccountingRepository) GetAccountByCode(ctx context.Context, code string) (*models.Account, error) {
+	a, err := scanAccount(r.DB.QueryRow(ctx, `SELECT `+accountColumns+` FROM accounts a WHERE a.code = $1`, code))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account %s: %w", code, err)
+	}
+	return a, nil
+}
+
+// CreateAccount adds an account to the chart of accounts
+func (r *AccountingRepository) CreateAccount(ctx context.Context, a *models.Account) error {
+	query := `
+		INSERT INTO accounts (code, name, account_type, is_system, is_active, description)
+		VALUES ($1, $2, $3, FALSE, TRUE, NULLIF($4, ''))
+		RETURNING id, is_system, is_active, created_at
+	`
+	err := r.DB.QueryRow(ctx, query, a.Code, a.Name, a.AccountType, a.Description).
+		Scan(&a.ID, &a.IsSystem, &a.IsActive, &a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create account: %w", err)
+	}
+	return nil
+}
+
+// CreateJournal inserts a journal entry and its lines in one transaction.
+// Returns false (and no error) if the source was already posted.
+func (r *AccountingRepository) CreateJournal(ctx context.Context, j *models.JournalEntry) (bool, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO journal_entries (entry_date, description, source_type, source_id, customer_phone, created_by_user_id)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6)
+		ON CONFLICT (source_type, source_id) WHERE source_id IS NOT NULL DO NOTHING
+		RETURNING id, created_at`,
+		j.EntryDate, j.Description, j.SourceType, j.SourceID, j.CustomerPhone, j.CreatedByUserID,
+	).Scan(&j.ID, &j.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to create journal entry: %w", err)
+	}
+
+	for i := range j.Lines {
+		line := &j.Lines[i]
+		err = tx.QueryRow(ctx, `
+			INSERT INTO journal_lines (journal_entry_id, account_id, debit, credit, description)
+			VALUES ($1, $2, $3, $4, NULLIF($5, ''))
+			RETURNING id`,
+			j.ID, line.AccountID, line.Debit, line.Credit, line.Description,
+		).Scan(&line.ID)
+		if err != nil {
+			return false, fmt.Errorf("failed to create journal line: %w", err)
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return false, fmt.Errorf("failed to commit journal entry: %w", err)
+	}
+	return true, nil
+}
+
+const journalEntryColumns = `
+	j.id, j.entry_date, j.description, j.source_type, j.source_id, COALESCE(j.customer_phone, ''),
+	j.created_by_user_id, COALESCE(u.name, CASE WHEN j.created_by_user_id = 0 THEN 'System' ELSE '' END), j.created_at`
+
+func scanJournalEntry(row pgx.Row) (*models.JournalEntry, error) {
+	j := &models.JournalEntry{}
+	err := row.Scan(&j.ID, &j.EntryDate, &j.Description, &j.SourceType, &j.SourceID, &j.CustomerPhone,
+		&j.CreatedByUserID, &j.CreatedByName, &j.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// GetJournal returns a journal entry with its lines
+func (r *AccountingRepository) GetJournal(ctx context.Context, id int) (*models.JournalEntry, error) {
+	query := `SELECT ` + journalEntryColumns + `
+		FROM journal_entries j
+		LEFT JOIN users u ON j.created_by_user_id = u.id
+		WHERE j.id = $1`
+	j, err := scanJournalEntry(r.DB.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journal entry: %w", err)
+	}
+	if err := r.loadLines(ctx, []*models.JournalEntry{j}); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// GetJournalBySource returns the journal posted for a source record (nil if not posted)
+func (r *AccountingRepository) GetJournalBySource(ctx context.Context, sourceType string, sourceID int) (*models.JournalEntry, error) {
+	query := `SELECT ` + journalEntryColumns + `
+		FROM journal_entries j
+		LEFT JOIN users u ON j.created_by_user_id = u.id
+		WHERE j.source_type = $1 AND j.source_id = $2`
+	j, err := scanJournalEntry(r.DB.QueryRow(ctx, query, sourceType, sourceID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get journal entry by source: %w", err)
+	}
+	if err := r.loadLines(ctx, []*models.JournalEntry{j}); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// ListJournals returns journal entries (newest first) with their lines
+func (r *AccountingRepository) ListJournals(ctx context.Context, filter *models.JournalFilter) ([]*models.JournalEntry, error) {
+	conditions := []string{"1=1"}
+	args := []interface{}{}
+	argNum := 1
+
+	if filter.AccountCode != "" {
+		conditions = append(conditions, fmt.Sprintf(`EXISTS (
+			SELECT 1 FROM journal_lines jl JOIN accounts a ON jl.account_id = a.id
+			WHERE jl.journal_entry_id = j.id AND a.code = $%d)`, argNum))
+		args = append(args, filter.AccountCode)
+		argNum++
+	}
+	if filter.StartDate != nil {
+		conditions = append(conditions, fmt.Sprintf("j.entry_date >= $%d", argNum))
+		args = append(args, filter.StartDate)
+		argNum++
+	}
+	if filter.EndDate != nil {
+		conditions = append(conditions, fmt.Sprintf("j.entry_date <= $%d", argNum))
+		args = append(args, filter.EndDate)
+		argNum++
+	}
+
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	query := fmt.Sprintf(`SELECT %s
+		FROM journal_entries j
+		LEFT JOIN users u ON j.created_by_user_id = u.id
+		WHERE %s
+		ORDER BY j.entry_date DESC, j.id DESC
+		LIMIT $%d OFFSET $%d`, journalEntryColumns, strings.Join(conditions, " AND "), argNum, argNum+1)
+	args = append(args, limit, filter.Offset)
+
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list journal entries: %w", err)
+	}
+	defer rows.Close()
+
+	var journals []*models.JournalEntry
+	for rows.Next() {
+		j, err := scanJournalEntry(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan journal entry: %w", err)
+		}
+		journals = append(journals, j)
+	}
+	rows.Close()
+
+	if err := r.loadLines(ctx, journals); err != nil {
+		return nil, err
+	}
+	return journals, nil
+}
+
+// loadLines fills in the lines of the given journal entries
+func (r *AccountingRepository) loadLines(ctx context.Context, journals []*models.JournalEntry) error {
+	if len(journals) == 0 {
+		return nil
+	}
+	ids := make([]int, len(journals))
+	byID := make(map[int]*models.JournalEntry, len(journals))
+	for i, j := range journals {
+		ids[i] = j.ID
+		byID[j.ID] = j
+		j.Lines = []models.JournalLine{}
+	}
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT jl.journal_entry_id, jl.id, jl.account_id, a.code, a.name, jl.debit, jl.credit, COALESCE(jl.description, '')
+		FROM journal_lines jl
+		JOIN accounts a ON jl.account_id = a.id
+		WHERE jl.journal_entry_id = ANY($1)
+		ORDER BY jl.journal_entry_id, jl.id`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to load journal lines: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var journalID int
+		var line models.JournalLine
+		if err := rows.Scan(&journalID, &line.ID, &line.AccountID, &line.AccountCode, &line.AccountName,
+			&line.Debit, &line.Credit, &line.Description); err != nil {
+			return fmt.Errorf("failed to scan journal line: %w", err)
+		}
+		byID[journalID].Lines = append(byID[journalID].Lines, line)
+	}
+	return nil
+}
+
+// GetAccountBalances returns debit and credit totals per account for journal entries
+// dated within [from, to]. A nil from means since the beginning.
+func (r *AccountingRepository) GetAccountBalances(ctx context.Context, from *time.Time, to time.Time) ([]models.AccountBalance, error) {
+	query := `
+		SELECT ` + accountColumns + `,
+		       COALESCE(SUM(t.debit), 0), COALESCE(SUM(t.credit), 0)
+		FROM accounts a
+		LEFT JOIN (
+			SELECT jl.account_id, jl.debit, jl.credit
+			FROM journal_lines jl
+			JOIN journal_entries j ON jl.journal_entry_id = j.id
+			WHERE ($1::timestamp IS NULL OR j.entry_date >= $1) AND j.entry_date <= $2
+		) t ON t.account_id = a.id
+		GROUP BY a.id
+		ORDER BY a.code
+	`
+	rows, err := r.DB.Query(ctx, query, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account balances: %w", err)
+	}
+	defer rows.Close()
+
+	var balances []models.AccountBalance
+	for rows.Next() {
+		var b models.AccountBalance
+		if err := rows.Scan(&b.ID, &b.Code, &b.Name, &b.AccountType, &b.IsSystem, &b.IsActive, &b.Description, &b.CreatedAt,
+			&b.Debit, &b.Credit); err != nil {
+			return nil, fmt.Errorf("failed to scan account balance: %w", err)
+		}
+		balances = append(balances, b)
+	}
+	return balances, nil
+}
+
+// ListUnpostedLedgerEntries returns money-moving ledger entries after afterID with no journal entry yet (oldest first)
+func (r *AccountingRepository) ListUnpostedLedgerEntries(ctx context.Context, afterID, limit int) ([]models.LedgerEntry, error) {
+	query := `
+		SELECT le.id, le.customer_phone, le.customer_name, le.entry_type, COALESCE(le.description, ''),
+		       le.debit, le.credit, le.reference_id, COALESCE(le.reference_type, ''),
+		       le.created_by_user_id, le.created_at, le.reversal_of_id, COALESCE(le.payment_mode, '')
+		FROM ledger_entries le
+		WHERE le.id > $1
+		  AND (le.debit > 0 OR le.credit > 0)
+		  AND NOT EXISTS (
+			SELECT 1 FROM journal_entries j
+			WHERE j.source_type = 'ledger_entry' AND j.source_id = le.id
+		  )
+		ORDER BY le.id
+		LIMIT $2
+	`
+	rows, err := r.DB.Query(ctx, query, afterID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unposted ledger entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.LedgerEntry
+	for rows.Next() {
+		var e models.LedgerEntry
+		if err := rows.Scan(&e.ID, &e.CustomerPhone, &e.CustomerName, &e.EntryType, &e.Description,
+			&e.Debit, &e.Credit, &e.ReferenceID, &e.ReferenceType,
+			&e.CreatedByUserID, &e.CreatedAt, &e.ReversalOfID, &e.PaymentMode); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
@@ -0,0 +1,313 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// BankStatementRepository stores imported bank statements and looks up the records their credits match
+type BankStatementRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewBankStatementRepository(db *pgxpool.Pool) *BankStatementRepository {
+	return &BankStatementRepository{DB: db}
+}
+
+const bankLineColumns = `id, import_id, txn_date, narration, COALESCE(reference, ''), amount, status,
+	COALESCE(match_type, ''), online_transaction_id, rent_payment_id, ledger_entry_id,
+	COALESCE(customer_phone, ''), COALESCE(customer_name, ''), COALESCE(suggested_phone, ''), COALESCE(suggested_name, ''),
+	resolved_by_user_id, resolved_at, COALESCE(notes, ''), created_at`
+
+func scanBankLine(row pgx.Row) (*models.BankStatementLine, error) {
+	l := &models.BankStatementLine{}
+	err := row.Scan(&l.ID, &l.ImportID, &l.TxnDate, &l.Narration, &l.Reference, &l.Amount, &l.Status,
+		&l.MatchType, &l.OnlineTransactionID, &l.RentPaymentID, &l.LedgerEntryID,
+		&l.CustomerPhone, &l.CustomerName, &l.SuggestedPhone, &l.SuggestedName,
+		&l.ResolvedByUserID, &l.ResolvedAt, &l.Notes, &l.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// CreateImport records an uploaded file
+func (r *BankStatementRepository) CreateImport(ctx context.Context, fileName string, userID int) (int, error) {
+	var id int
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO bank_statement_imports (file_name, imported_by_user_id)
+		VALUES ($1, $2)
+		RETURNING id
+	`, fileName, userID).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create bank statement import: %w", err)
+	}
+	return id, nil
+}
+
+// FinishImport stores the counts of an import once its credits have been matched
+func (r *BankStatementRepository) FinishImport(ctx context.Context, imp *models.BankStatementImport) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE bank_statement_imports
+		SET credit_count = $2, duplicate_count = $3, matched_count = $4, unmatched_count = $5
+		WHERE id = $1
+	`, imp.ID, imp.CreditCount, imp.DuplicateCount, imp.MatchedCount, imp.UnmatchedCount)
+	if err != nil {
+		return fmt.Errorf("failed to update bank statement import: %w", err)
+	}
+	return nil
+}
+
+// ListImports returns recent imports, newest first
+func (r *BankStatementRepository) ListImports(ctx context.Context, limit int) ([]*models.BankStatementImport, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT i.id, i.file_name, i.credit_count, i.duplicate_count, i.matched_count, i.unmatched_count,
+		       COALESCE(i.imported_by_user_id, 0), COALESCE(u.name, ''), i.created_at
+		FROM bank_statement_imports i
+		LEFT JOIN users u ON u.id = i.imported_by_user_id
+		ORDER BY i.created_at DESC, i.id DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bank statement imports: %w", err)
+	}
+	defer rows.Close()
+
+	var imports []*models.BankStatementImport
+	for rows.Next() {
+		i := &models.BankStatementImport{}
+		if err := rows.Scan(&i.ID, &i.FileName, &i.CreditCount, &i.DuplicateCount, &i.MatchedCount, &i.UnmatchedCount,
+			&i.ImportedByUserID, &i.ImportedByName, &i.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan bank statement import: %w", err)
+		}
+		imports = append(imports, i)
+	}
+	return imports, nil
+}
+
+// InsertLine stores a credit. Returns false when the same statement row was imported before.
+func (r *BankStatementRepository) InsertLine(ctx context.Context, line *models.BankStatementLine) (bool, error) {
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO bank_statement_lines (import_id, txn_date, narration, reference, amount, fingerprint)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+		ON CONFLICT (fingerprint) DO NOTHING
+		RETURNING id, status, created_at
+	`, line.ImportID, line.TxnDate, line.Narration, line.Reference, line.Amount, line.Fingerprint).
+		Scan(&line.ID, &line.Status, &line.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to insert bank statement line: %w", err)
+	}
+	return true, nil
+}
+
+// GetLine returns a statement line by ID
+func (r *BankStatementRepository) GetLine(ctx context.Context, id int) (*models.BankStatementLine, error) {
+	l, err := scanBankLine(r.DB.QueryRow(ctx, `SELECT `+bankLineColumns+` FROM bank_statement_lines WHERE id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get bank statement line: %w", err)
+	}
+	return l, nil
+}
+
+// ListLines returns statement lines by status and/or import, oldest transaction first
+func (r *BankStatementRepository) ListLines(ctx context.Context, status string, importID int) ([]*models.BankStatementLine, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT `+bankLineColumns+`
+		FROM bank_statement_lines
+		WHERE ($1 = '' OR status = $1)
+		  AND ($2 = 0 OR import_id = $2)
+		ORDER BY txn_date, id
+	`, status, importID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list bank statement lines: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []*models.BankStatementLine
+	for rows.Next() {
+		l, err := scanBankLine(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan bank statement line: %w", err)
+		}
+		lines = append(lines, l)
+	}
+	return lines, nil
+}
+
+// FindOnlineTransactionByUTR returns a successful online transaction whose UTR is one of
+// refs and whose amount equals the credit, skipping ones already matched to a statement line
+func (r *BankStatementRepository) FindOnlineTransactionByUTR(ctx context.Context, refs []string, amount float64) (*models.OnlineTransaction, error) {
+	tx := &models.OnlineTransaction{}
+	err := r.DB.QueryRow(ctx, `
+		SELECT ot.id, ot.customer_phone, ot.customer_name
+		FROM online_transactions ot
+		WHERE ot.status = 'success'
+		  AND UPPER(ot.utr_number) = ANY($1)
+		  AND (ABS(ot.amount - $2) < 0.01 OR ABS(ot.total_amount - $2) < 0.01)
+		  AND NOT EXISTS (SELECT 1 FROM bank_statement_lines bl WHERE bl.online_transaction_id = ot.id)
+		ORDER BY ot.id
+		LIMIT 1
+	`, refs, amount).Scan(&tx.ID, &tx.CustomerPhone, &tx.CustomerName)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to match online transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// FindRentPaymentByUTR returns a NEFT/RTGS/UPI rent payment whose UTR is one of refs and
+// whose amount equals the credit, skipping ones already matched to a statement line
+func (r *BankStatementRepository) FindRentPaymentByUTR(ctx context.Context, refs []string, amount float64) (*models.RentPayment, error) {
+	p := &models.RentPayment{}
+	err := r.DB.QueryRow(ctx, `
+		SELECT rp.id, rp.customer_phone, rp.customer_name
+		FROM rent_payments rp
+		WHERE rp.payment_mode IN ('neft', 'rtgs', 'upi')
+		  AND UPPER(rp.utr_number) = ANY($1)
+		  AND ABS(rp.amount_paid - $2) < 0.01
+		  AND NOT EXISTS (SELECT 1 FROM bank_statement_lines bl WHERE bl.rent_payment_id = rp.id)
+		ORDER BY rp.id
+		LIMIT 1
+	`, refs, amount).Scan(&p.ID, &p.CustomerPhone, &p.CustomerName)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to match rent payment: %w", err)
+	}
+	return p, nil
+}
+
+// ListPendingChequesByAmount returns pending cheques for exactly the credited amount
+func (r *BankStatementRepository) ListPendingChequesByAmount(ctx context.Context, amount float64) ([]*models.RentPayment, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT id, customer_phone, customer_name, COALESCE(cheque_number, '')
+		FROM rent_payments
+		WHERE payment_mode = 'cheque' AND clearance_status = 'pending'
+		  AND ABS(amount_paid - $1) < 0.01
+		ORDER BY payment_date, id
+	`, amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending cheques: %w", err)
+	}
+	defer rows.Close()
+
+	var cheques []*models.RentPayment
+	for rows.Next() {
+		p := &models.RentPayment{}
+		if err := rows.Scan(&p.ID, &p.CustomerPhone, &p.CustomerName, &p.ChequeNumber); err != nil {
+			return nil, fmt.Errorf("failed to scan pending cheque: %w", err)
+		}
+		cheques = append(cheques, p)
+	}
+	return cheques, nil
+}
+
+// FindCustomersByPhone returns active customers with any of the given phone numbers
+func (r *BankStatementRepository) FindCustomersByPhone(ctx context.Context, phones []string) ([]*models.Customer, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT id, name, phone
+		FROM customers
+		WHERE phone = ANY($1) AND COALESCE(status, 'active') = 'active'
+		ORDER BY id
+	`, phones)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find customers by phone: %w", err)
+	}
+	defer rows.Close()
+
+	var customers []*models.Customer
+	for rows.Next() {
+		c := &models.Customer{}
+		if err := rows.Scan(&c.ID, &c.Name, &c.Phone); err != nil {
+			return nil, fmt.Errorf("failed to scan customer: %w", err)
+		}
+		customers = append(customers, c)
+	}
+	return customers, nil
+}
+
+// SetMatched records the record a credit was reconciled to on import
+func (r *BankStatementRepository) SetMatched(ctx context.Context, line *models.BankStatementLine) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE bank_statement_lines
+		SET status = 'matched', match_type = $2, online_transaction_id = $3, rent_payment_id = $4,
+		    customer_phone = $5, customer_name = $6
+		WHERE id = $1
+	`, line.ID, line.MatchType, line.OnlineTransactionID, line.RentPaymentID, line.CustomerPhone, line.CustomerName)
+	if err != nil {
+		return fmt.Errorf("failed to mark bank statement line matched: %w", err)
+	}
+	return nil
+}
+
+// SetSuggestion records the customer whose phone appears in an unmatched credit's narration
+func (r *BankStatementRepository) SetSuggestion(ctx context.Context, id int, phone, name string) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE bank_statement_lines SET suggested_phone = $2, suggested_name = $3 WHERE id = $1
+	`, id, phone, name)
+	if err != nil {
+		return fmt.Errorf("failed to save customer suggestion: %w", err)
+	}
+	return nil
+}
+
+// Assign claims an unmatched credit for a customer and posts its PAYMENT entry in one
+// transaction, linking the line to the entry. Returns false and writes nothing if the
+// credit was no longer unmatched.
+func (r *BankStatementRepository) Assign(ctx context.Context, id int, phone, name string, userID int, notes string, payment *models.CreateLedgerEntryRequest) (*models.LedgerEntry, bool, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE bank_statement_lines
+		SET status = 'assigned', match_type = 'manual', customer_phone = $2, customer_name = $3,
+		    resolved_by_user_id = $4, resolved_at = CURRENT_TIMESTAMP, notes = NULLIF($5, '')
+		WHERE id = $1 AND status = 'unmatched'
+	`, id, phone, name, userID, notes)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to assign bank statement line: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, false, nil
+	}
+
+	entry, err := insertLedgerEntry(ctx, tx, payment)
+	if err != nil {
+		return nil, false, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE bank_statement_lines SET ledger_entry_id = $2 WHERE id = $1`, id, entry.ID); err != nil {
+		return nil, false, fmt.Errorf("failed to link ledger entry: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to commit bank credit assignment: %w", err)
+	}
+	return entry, true, nil
+}
+
+// MarkIgnored removes an unmatched credit from the queue. Returns false if it was no longer unmatched.
+func (r *BankStatementRepository) MarkIgnored(ctx context.Context, id, userID int, notes string) (bool, error) {
+	tag, err := r.DB.Exec(ctx, `
+		UPDATE bank_statement_lines
+		SET status = 'ignored', resolved_by_user_id = $2, resolved_at = CURRENT_TIMESTAMP, notes = $3
+		WHERE id = $1 AND status = 'unmatched'
+	`, id, userID, notes)
+	if err != nil {
+		return false, fmt.Errorf("failed to ignore bank statement line: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,207 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrNoOpenCashSession is returned when cash is taken or paid out by a user with no open
+// cash drawer, since it could never be reconciled at closing
+var ErrNoOpenCashSession = errors.New("open a cash session before taking or paying out cash")
+
+// lockOpenCashSession returns the user's open session within tx, locked until tx ends so
+// the session cannot close before the cash recorded against it is in
+func lockOpenCashSession(ctx context.Context, tx pgx.Tx, userID int) (int, error) {
+	var id int
+	err := tx.QueryRow(ctx, `SELECT id FROM cash_sessions WHERE user_id = $1 AND status = 'open' FOR UPDATE`, userID).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return 0, ErrNoOpenCashSession
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to get open cash session: %w", err)
+	}
+	return id, nil
+}
+
+// CashSessionRepository stores cash drawer sessions and their closing counts
+type CashSessionRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewCashSessionRepository(db *pgxpool.Pool) *CashSessionRepository {
+	return &CashSessionRepository{DB: db}
+}
+
+// Cash taken and paid out in a session: rent payments plus wallet deposits in, wallet
+// refunds out. Voided wallet postings never moved any cash.
+const cashSessionMovements = `
+	SELECT (SELECT COUNT(*) FROM rent_payments WHERE cash_session_id = %[1]s)
+	       + (SELECT COUNT(*) FROM ledger_entries WHERE cash_session_id = %[1]s AND voided_at IS NULL) AS payment_count,
+	       (SELECT COALESCE(SUM(amount_paid), 0) FROM rent_payments WHERE cash_session_id = %[1]s)
+	       + (SELECT COALESCE(SUM(credit), 0) FROM ledger_entries WHERE cash_session_id = %[1]s AND voided_at IS NULL) AS received,
+	       (SELECT COALESCE(SUM(debit), 0) FROM ledger_entries WHERE cash_session_id = %[1]s AND voided_at IS NULL) AS paid_out
+`
+
+// Open sessions show live totals; closed sessions show the totals frozen at close
+var cashSessionSelect = `
+	SELECT cs.id, cs.user_id, COALESCE(u.name, ''), cs.status, cs.opening_float, cs.opened_at, cs.closed_at,
+	       p.payment_count, COALESCE(cs.cash_received, p.received), COALESCE(cs.cash_paid_out, p.paid_out),
+	       COALESCE(cs.expected_cash, cs.opening_float + p.received - p.paid_out),
+	       cs.counted_cash, cs.difference, cs.denominations, COALESCE(cs.closing_notes, '')
+	FROM cash_sessions cs
+	LEFT JOIN users u ON u.id = cs.user_id
+	LEFT JOIN LATERAL (` + fmt.Sprintf(cashSessionMovements, "cs.id") + `) p ON TRUE
+`
+
+func scanCashSession(row pgx.Row) (*models.CashSession, error) {
+	s := &models.CashSession{}
+	var denominations []byte
+	err := row.Scan(&s.ID, &s.UserID, &s.UserName, &s.Status, &s.OpeningFloat, &s.OpenedAt, &s.ClosedAt,
+		&s.PaymentCount, &s.CashReceived, &s.CashPaidOut, &s.ExpectedCash,
+		&s.CountedCash, &s.Difference, &denominations, &s.ClosingNotes)
+	if err != nil {
+		return nil, err
+	}
+	if len(denominations) > 0 {
+		if err := json.Unmarshal(denominations, &s.Denominations); err != nil {
+			return nil, fmt.Errorf("failed to decode denominations: %w", err)
+		}
+	}
+	return s, nil
+}
+
+// Create opens a session. The unique index rejects a second open session for the user.
+func (r *CashSessionRepository) Create(ctx context.Context, userID int, openingFloat float64) (int, error) {
+	var id int
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO cash_sessions (user_id, opening_float)
+		VALUES ($1, $2)
+		RETURNING id
+	`, userID, openingFloat).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open cash session: %w", err)
+	}
+	return id, nil
+}
+
+// Get returns a session by ID
+func (r *CashSessionRepository) Get(ctx context.Context, id int) (*models.CashSession, error) {
+	s, err := scanCashSession(r.DB.QueryRow(ctx, cashSessionSelect+`WHERE cs.id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cash session: %w", err)
+	}
+	return s, nil
+}
+
+// GetOpenByUser returns the user's open session (nil if none)
+func (r *CashSessionRepository) GetOpenByUser(ctx context.Context, userID int) (*models.CashSession, error) {
+	s, err := scanCashSession(r.DB.QueryRow(ctx, cashSessionSelect+`WHERE cs.user_id = $1 AND cs.status = 'open'`, userID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open cash session: %w", err)
+	}
+	return s, nil
+}
+
+// Close freezes the session totals and records the count. Expected cash is worked
+// out in the same statement so a payment recorded during the close cannot be missed.
+func (r *CashSessionRepository) Close(ctx context.Context, id int, counted float64, denominations []models.CashDenomination, notes string) error {
+	encoded, err := json.Marshal(denominations)
+	if err != nil {
+		return fmt.Errorf("failed to encode denominations: %w", err)
+	}
+
+	tag, err := r.DB.Exec(ctx, `
+		WITH moved AS (`+fmt.Sprintf(cashSessionMovements, "$1")+`)
+		UPDATE cash_sessions cs
+		SET status = 'closed',
+		    closed_at = CURRENT_TIMESTAMP,
+		    cash_received = moved.received,
+		    cash_paid_out = moved.paid_out,
+		    expected_cash = cs.opening_float + moved.received - moved.paid_out,
+		    counted_cash = $2,
+		    difference = $2 - (cs.opening_float + moved.received - moved.paid_out),
+		    denominations = $3,
+		    closing_notes = NULLIF($4, '')
+		FROM moved
+		WHERE cs.id = $1 AND cs.status = 'open'
+	`, id, counted, encoded, notes)
+	if err != nil {
+		return fmt.Errorf("failed to close cash session: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return fmt.Errorf("cash session is not open")
+	}
+	return nil
+}
+
+// List returns sessions opened or closed in [from, to], optionally for one user
+func (r *CashSessionRepository) List(ctx context.Context, from, to time.Time, userID int) ([]*models.CashSession, error) {
+	return r.query(ctx, cashSessionSelect+`
+		WHERE (cs.opened_at BETWEEN $1 AND $2 OR cs.closed_at BETWEEN $1 AND $2)
+		  AND ($3 = 0 OR cs.user_id = $3)
+		ORDER BY cs.opened_at DESC, cs.id DESC`, from, to, userID)
+}
+
+// ListClosed returns sessions closed in [from, to], for the daily summary
+func (r *CashSessionRepository) ListClosed(ctx context.Context, from, to time.Time) ([]*models.CashSession, error) {
+	return r.query(ctx, cashSessionSelect+`
+		WHERE cs.status = 'closed' AND cs.closed_at BETWEEN $1 AND $2
+		ORDER BY u.name, cs.closed_at`, from, to)
+}
+
+func (r *CashSessionRepository) query(ctx context.Context, query string, args ...interface{}) ([]*models.CashSession, error) {
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cash sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []*models.CashSession
+	for rows.Next() {
+		s, err := scanCashSession(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cash session: %w", err)
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, nil
+}
+
+// ListPayments returns the rent payments taken in a session
+func (r *CashSessionRepository) ListPayments(ctx context.Context, sessionID int) ([]*models.RentPayment, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT id, receipt_number, entry_id, family_member_id, COALESCE(family_member_name, ''),
+		       customer_name, customer_phone, total_rent, amount_paid, balance,
+		       payment_date, COALESCE(processed_by_user_id, 0), COALESCE(notes, ''), created_at, cash_session_id
+		FROM rent_payments
+		WHERE cash_session_id = $1
+		ORDER BY payment_date, id
+	`, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list session payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []*models.RentPayment
+	for rows.Next() {
+		p := &models.RentPayment{}
+		if err := rows.Scan(&p.ID, &p.ReceiptNumber, &p.EntryID, &p.FamilyMemberID, &p.FamilyMemberName,
+			&p.CustomerName, &p.CustomerPhone, &p.TotalRent, &p.AmountPaid, &p.Balance,
+			&p.PaymentDate, &p.ProcessedByUserID, &p.Notes, &p.CreatedAt, &p.CashSessionID); err != nil {
+			return nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		payments = append(payments, p)
+	}
+	return payments, nil
+}
@@ -0,0 +1,246 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type CropLoanRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewCropLoanRepository(db *pgxpool.Pool) *CropLoanRepository {
+	return &CropLoanRepository{DB: db}
+}
+
+const cropLoanColumns = `
+	l.id, l.customer_id, l.customer_phone, l.customer_name, COALESCE(l.customer_so, ''),
+	l.family_member_id, COALESCE(l.family_member_name, ''),
+	l.entry_id, l.thock_number, l.pledged_quantity, l.value_per_bag, l.max_ltv_percent,
+	l.principal, l.interest_rate_monthly, l.principal_outstanding, l.interest_outstanding,
+	l.total_interest, l.total_repaid, l.interest_accrued_to,
+	l.status, l.ledger_entry_id, COALESCE(l.notes, ''),
+	l.disbursed_by_user_id, COALESCE(u.name, ''), l.disbursed_at, l.settled_at,
+	l.created_at, l.updated_at`
+
+const cropLoanJoins = `
+	FROM crop_loans l
+	LEFT JOIN users u ON l.disbursed_by_user_id = u.id`
+
+func scanCropLoan(row pgx.Row) (*models.CropLoan, error) {
+	l := &models.CropLoan{}
+	err := row.Scan(
+		&l.ID, &l.CustomerID, &l.CustomerPhone, &l.CustomerName, &l.CustomerSO,
+		&l.FamilyMemberID, &l.FamilyMemberName,
+		&l.EntryID, &l.ThockNumber, &l.PledgedQuantity, &l.ValuePerBag, &l.MaxLTVPercent,
+		&l.Principal, &l.InterestRateMonthly, &l.PrincipalOutstanding, &l.InterestOutstanding,
+		&l.TotalInterest, &l.TotalRepaid, &l.InterestAccruedTo,
+		&l.Status, &l.LedgerEntryID, &l.Notes,
+		&l.DisbursedByUserID, &l.DisbursedByName, &l.DisbursedAt, &l.SettledAt,
+		&l.CreatedAt, &l.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Disburse inserts a new active loan and posts its LOAN_DISBURSAL ledger entry in one
+// transaction. disbursal builds the entry once the loan's ID is known.
+func (r *CropLoanRepository) Disburse(ctx context.Context, l *models.CropLoan, disbursal func(loanID int) *models.CreateLedgerEntryRequest) (*models.LedgerEntry, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	query := `
+		INSERT INTO crop_loans (customer_id, customer_phone, customer_name, customer_so,
+		                        family_member_id, family_member_name,
+		                        entry_id, thock_number, pledged_quantity, value_per_bag, max_ltv_percent,
+		                        principal, interest_rate_monthly, principal_outstanding, interest_accrued_to,
+		                        status, notes, disbursed_by_user_id)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, NULLIF($6, ''), $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, NULLIF($17, ''), $18)
+		RETURNING id, disbursed_at, created_at, updated_at
+	`
+	err = tx.QueryRow(ctx, query,
+		l.CustomerID, l.CustomerPhone, l.CustomerName, l.CustomerSO,
+		l.FamilyMemberID, l.FamilyMemberName,
+		l.EntryID, l.ThockNumber, l.PledgedQuantity, l.ValuePerBag, l.MaxLTVPercent,
+		l.Principal, l.InterestRateMonthly, l.PrincipalOutstanding, l.InterestAccruedTo,
+		l.Status, l.Notes, l.DisbursedByUserID,
+	).Scan(&l.ID, &l.DisbursedAt, &l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create crop loan: %w", err)
+	}
+
+	entry, err := insertLedgerEntry(ctx, tx, disbursal(l.ID))
+	if err != nil {
+		return nil, err
+	}
+	_, err = tx.Exec(ctx, `UPDATE crop_loans SET ledger_entry_id = $2 WHERE id = $1`, l.ID, entry.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to link crop loan ledger entry: %w", err)
+	}
+	l.LedgerEntryID = &entry.ID
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit crop loan: %w", err)
+	}
+	return entry, nil
+}
+
+// Get returns a loan by ID
+func (r *CropLoanRepository) Get(ctx context.Context, id int) (*models.CropLoan, error) {
+	query := `SELECT ` + cropLoanColumns + cropLoanJoins + ` WHERE l.id = $1`
+	l, err := scanCropLoan(r.DB.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get crop loan: %w", err)
+	}
+	return l, nil
+}
+
+// List returns loans, optionally filtered by status, customer phone and thock number
+func (r *CropLoanRepository) List(ctx context.Context, status, customerPhone, thockNumber string) ([]*models.CropLoan, error) {
+	query := `SELECT ` + cropLoanColumns + cropLoanJoins + `
+		WHERE ($1 = '' OR l.status = $1)
+		  AND ($2 = '' OR l.customer_phone = $2)
+		  AND ($3 = '' OR l.thock_number = $3)
+		ORDER BY l.disbursed_at DESC, l.id DESC`
+
+	rows, err := r.DB.Query(ctx, query, status, customerPhone, thockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list crop loans: %w", err)
+	}
+	defer rows.Close()
+
+	var loans []*models.CropLoan
+	for rows.Next() {
+		l, err := scanCropLoan(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan crop loan: %w", err)
+		}
+		loans = append(loans, l)
+	}
+	return loans, nil
+}
+
+// ListActiveByThock returns the active loans pledged against a thock
+func (r *CropLoanRepository) ListActiveByThock(ctx context.Context, thockNumber string) ([]*models.CropLoan, error) {
+	return r.List(ctx, models.CropLoanStatusActive, "", thockNumber)
+}
+
+// Accrue posts a loan's INTEREST ledger entry (nil when nothing is due) and saves its new
+// balances and accrual date in one transaction
+func (r *CropLoanRepository) Accrue(ctx context.Context, l *models.CropLoan, interest *models.CreateLedgerEntryRequest) (*models.LedgerEntry, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var entry *models.LedgerEntry
+	if interest != nil {
+		if entry, err = insertLedgerEntry(ctx, tx, interest); err != nil {
+			return nil, err
+		}
+	}
+	if err := updateCropLoanBalances(ctx, tx, l); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit crop loan interest: %w", err)
+	}
+	return entry, nil
+}
+
+// Repay posts a repayment's LOAN_REPAYMENT ledger entry, records the repayment and saves
+// the loan's new balances and status in one transaction
+func (r *CropLoanRepository) Repay(ctx context.Context, l *models.CropLoan, p *models.CropLoanRepayment, repayment *models.CreateLedgerEntryRequest) (*models.LedgerEntry, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	entry, err := insertLedgerEntry(ctx, tx, repayment)
+	if err != nil {
+		return nil, err
+	}
+	p.LedgerEntryID = &entry.ID
+
+	query := `
+		INSERT INTO crop_loan_repayments (loan_id, amount, interest_portion, principal_portion,
+		                                  ledger_entry_id, received_by_user_id, notes)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''))
+		RETURNING id, created_at
+	`
+	err = tx.QueryRow(ctx, query,
+		p.LoanID, p.Amount, p.InterestPortion, p.PrincipalPortion,
+		p.LedgerEntryID, p.ReceivedByUserID, p.Notes,
+	).Scan(&p.ID, &p.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create crop loan repayment: %w", err)
+	}
+	if err := updateCropLoanBalances(ctx, tx, l); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit crop loan repayment: %w", err)
+	}
+	return entry, nil
+}
+
+// updateCropLoanBalances saves the outstanding amounts, accrual date and status of a loan
+func updateCropLoanBalances(ctx context.Context, tx pgx.Tx, l *models.CropLoan) error {
+	query := `
+		UPDATE crop_loans
+		SET principal_outstanding = $2, interest_outstanding = $3, total_interest = $4, total_repaid = $5,
+		    interest_accrued_to = $6, status = $7, settled_at = $8, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1
+		RETURNING updated_at
+	`
+	err := tx.QueryRow(ctx, query,
+		l.ID, l.PrincipalOutstanding, l.InterestOutstanding, l.TotalInterest, l.TotalRepaid,
+		l.InterestAccruedTo, l.Status, l.SettledAt,
+	).Scan(&l.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update crop loan: %w", err)
+	}
+	return nil
+}
+
+// ListRepayments returns a loan's repayments, newest first
+func (r *CropLoanRepository) ListRepayments(ctx context.Context, loanID int) ([]*models.CropLoanRepayment, error) {
+	query := `
+		SELECT p.id, p.loan_id, p.amount, p.interest_portion, p.principal_portion, p.ledger_entry_id,
+		       p.received_by_user_id, COALESCE(u.name, ''), COALESCE(p.notes, ''), p.created_at
+		FROM crop_loan_repayments p
+		LEFT JOIN users u ON p.received_by_user_id = u.id
+		WHERE p.loan_id = $1
+		ORDER BY p.created_at DESC, p.id DESC
+	`
+	rows, err := r.DB.Query(ctx, query, loanID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list crop loan repayments: %w", err)
+	}
+	defer rows.Close()
+
+	var repayments []*models.CropLoanRepayment
+	for rows.Next() {
+		p := &models.CropLoanRepayment{}
+		if err := rows.Scan(&p.ID, &p.LoanID, &p.Amount, &p.InterestPortion, &p.PrincipalPortion, &p.LedgerEntryID,
+			&p.ReceivedByUserID, &p.ReceivedByName, &p.Notes, &p.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan crop loan repayment: %w", err)
+		}
+		repayments = append(repayments, p)
+	}
+	return repayments, nil
+}
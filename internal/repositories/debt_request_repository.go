@@ -29,8 +29,8 @@ func (r *DebtRequestRepository) Create(ctx context.Context, req *models.CreateDe
 		INSERT INTO debt_requests (
 			customer_phone, customer_name, customer_so, thock_number,
 			requested_quantity, current_balance, requested_by_user_id,
-			requested_by_name, status, expires_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'pending', $9)
+			requested_by_name, status, expires_at, crop_loan_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'pending', $9, $10)
 		RETURNING id, created_at
 	`
 
@@ -46,6 +46,7 @@ func (r *DebtRequestRepository) Create(ctx context.Context, req *models.CreateDe
 		requestedByUserID,
 		requestedByName,
 		expiresAt,
+		req.CropLoanID,
 	).Scan(&id, &createdAt)
 
 	if err != nil {
@@ -65,6 +66,7 @@ func (r *DebtRequestRepository) Create(ctx context.Context, req *models.CreateDe
 		Status:            models.DebtRequestStatusPending,
 		CreatedAt:         createdAt,
 		ExpiresAt:         &expiresAt,
+		CropLoanID:        req.CropLoanID,
 	}, nil
 }
 
@@ -76,7 +78,7 @@ func (r *DebtRequestRepository) GetByID(ctx context.Context, id int) (*models.De
 			requested_by_user_id, COALESCE(requested_by_name, '') as requested_by_name,
 			status, approved_by_user_id, COALESCE(approved_by_name, '') as approved_by_name,
 			approved_at, COALESCE(rejection_reason, '') as rejection_reason,
-			gate_pass_id, created_at, expires_at
+			gate_pass_id, created_at, expires_at, crop_loan_id
 		FROM debt_requests
 		WHERE id = $1
 	`
@@ -93,7 +95,7 @@ func (r *DebtRequestRepository) GetByID(ctx context.Context, id int) (*models.De
 		&d.RequestedByUserID, &d.RequestedByName,
 		&d.Status, &approvedByUserID, &d.ApprovedByName,
 		&approvedAt, &d.RejectionReason,
-		&gatePassID, &d.CreatedAt, &expiresAt,
+		&gatePassID, &d.CreatedAt, &expiresAt, &d.CropLoanID,
 	)
 
 	if err != nil {
@@ -122,7 +124,7 @@ func (r *DebtRequestRepository) GetPending(ctx context.Context) ([]models.DebtRe
 			requested_by_user_id, COALESCE(requested_by_name, '') as requested_by_name,
 			status, approved_by_user_id, COALESCE(approved_by_name, '') as approved_by_name,
 			approved_at, COALESCE(rejection_reason, '') as rejection_reason,
-			gate_pass_id, created_at, expires_at
+			gate_pass_id, created_at, expires_at, crop_loan_id
 		FROM debt_requests
 		WHERE status = 'pending'
 		ORDER BY created_at DESC
@@ -139,7 +141,7 @@ func (r *DebtRequestRepository) GetByCustomer(ctx context.Context, customerPhone
 			requested_by_user_id, COALESCE(requested_by_name, '') as requested_by_name,
 			status, approved_by_user_id, COALESCE(approved_by_name, '') as approved_by_name,
 			approved_at, COALESCE(rejection_reason, '') as rejection_reason,
-			gate_pass_id, created_at, expires_at
+			gate_pass_id, created_at, expires_at, crop_loan_id
 		FROM debt_requests
 		WHERE customer_phone = $1
 		ORDER BY created_at DESC
@@ -156,7 +158,7 @@ func (r *DebtRequestRepository) GetApprovedForCustomerAndThock(ctx context.Conte
 			requested_by_user_id, COALESCE(requested_by_name, '') as requested_by_name,
 			status, approved_by_user_id, COALESCE(approved_by_name, '') as approved_by_name,
 			approved_at, COALESCE(rejection_reason, '') as rejection_reason,
-			gate_pass_id, created_at, expires_at
+			gate_pass_id, created_at, expires_at, crop_loan_id
 		FROM debt_requests
 		WHERE customer_phone = $1 AND thock_number = $2 AND status = 'approved'
 		ORDER BY created_at DESC
@@ -231,7 +233,7 @@ func (r *DebtRequestRepository) GetAll(ctx context.Context, filter *models.DebtR
 			requested_by_user_id, COALESCE(requested_by_name, '') as requested_by_name,
 			status, approved_by_user_id, COALESCE(approved_by_name, '') as approved_by_name,
 			approved_at, COALESCE(rejection_reason, '') as rejection_reason,
-			gate_pass_id, created_at, expires_at
+			gate_pass_id, created_at, expires_at, crop_loan_id
 		FROM debt_requests
 		%s
 		ORDER BY created_at DESC
@@ -385,7 +387,7 @@ func (r *DebtRequestRepository) queryDebtRequestsWithArgs(ctx context.Context, q
 			&d.RequestedByUserID, &d.RequestedByName,
 			&d.Status, &approvedByUserID, &d.ApprovedByName,
 			&approvedAt, &d.RejectionReason,
-			&gatePassID, &d.CreatedAt, &expiresAt,
+			&gatePassID, &d.CreatedAt, &expiresAt, &d.CropLoanID,
 		)
 		if err != nil {
 			return nil, err
@@ -49,7 +49,7 @@ func (r *EntryRepository) CreateWithSkipRanges(ctx context.Context, e *models.En
 				FROM entries
 				WHERE thock_category = $2
 			)
-			INSERT INTO entries(customer_id, phone, name, village, so, expected_quantity, thock_category, thock_number, remark, created_by_user_id, family_member_id, family_member_name)
+			INSERT INTO entries(customer_id, phone, name, village, so, expected_quantity, thock_category, thock_number, remark, created_by_user_id, family_member_id, family_member_name, bag_size, season)
 			SELECT $3, $4, $5, $6, $7, $8::integer, $9::text,
 				CASE WHEN $9::text = 'seed'
 					THEN LPAD(num::text, 4, '0') || '/' || $8::text
@@ -58,7 +58,9 @@ func (r *EntryRepository) CreateWithSkipRanges(ctx context.Context, e *models.En
 				$10,
 				$11,
 				$12,
-				$13
+				$13,
+				$14,
+				(SELECT NULLIF(TRIM(setting_value), '') FROM system_settings WHERE setting_key = 'current_season')
 			FROM next_num
 			RETURNING id, thock_number, created_at, updated_at
 		`
@@ -77,6 +79,7 @@ func (r *EntryRepository) CreateWithSkipRanges(ctx context.Context, e *models.En
 			e.CreatedByUserID,    // $11
 			e.FamilyMemberID,     // $12
 			e.FamilyMemberName,   // $13
+			e.BagSize,            // $14
 		).Scan(&e.ID, &e.ThockNumber, &e.CreatedAt, &e.UpdatedAt)
 	}
 
@@ -123,8 +126,9 @@ func (r *EntryRepository) CreateWithSkipRanges(ctx context.Context, e *models.En
 
 	// Insert with the calculated thock number
 	insertQuery := `
-		INSERT INTO entries(customer_id, phone, name, village, so, expected_quantity, thock_category, thock_number, remark, created_by_user_id, family_member_id, family_member_name)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
+		INSERT INTO entries(customer_id, phone, name, village, so, expected_quantity, thock_category, thock_number, remark, created_by_user_id, family_member_id, family_member_name, bag_size, season)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13,
+		        (SELECT NULLIF(TRIM(setting_value), '') FROM system_settings WHERE setting_key = 'current_season'))
 		RETURNING id, thock_number, created_at, updated_at
 	`
 
@@ -141,6 +145,7 @@ func (r *EntryRepository) CreateWithSkipRanges(ctx context.Context, e *models.En
 		e.CreatedByUserID,    // $10
 		e.FamilyMemberID,     // $11
 		e.FamilyMemberName,   // $12
+		e.BagSize,            // $13
 	).Scan(&e.ID, &e.ThockNumber, &e.CreatedAt, &e.UpdatedAt)
 }
 
@@ -149,13 +154,14 @@ func (r *EntryRepository) Get(ctx context.Context, id int) (*models.Entry, error
 		`SELECT e.id, e.customer_id, e.phone, e.name, e.village, e.so, e.expected_quantity,
 		        COALESCE((SELECT SUM(quantity) FROM room_entries WHERE entry_id = e.id), 0) as actual_quantity,
 		        e.thock_category, e.thock_number, COALESCE(e.remark, '') as remark, e.created_by_user_id, e.created_at, e.updated_at,
-		        e.family_member_id, COALESCE(e.family_member_name, '') as family_member_name
+		        e.family_member_id, COALESCE(e.family_member_name, '') as family_member_name,
+		        COALESCE(e.bag_size, '') as bag_size, COALESCE(e.season, '') as season
          FROM entries e WHERE e.id=$1`, id)
 
 	var entry models.Entry
 	err := row.Scan(&entry.ID, &entry.CustomerID, &entry.Phone, &entry.Name, &entry.Village, &entry.SO,
 		&entry.ExpectedQuantity, &entry.ActualQuantity, &entry.ThockCategory, &entry.ThockNumber, &entry.Remark, &entry.CreatedByUserID,
-		&entry.CreatedAt, &entry.UpdatedAt, &entry.FamilyMemberID, &entry.FamilyMemberName)
+		&entry.CreatedAt, &entry.UpdatedAt, &entry.FamilyMemberID, &entry.FamilyMemberName, &entry.BagSize, &entry.Season)
 	return &entry, err
 }
 
@@ -170,7 +176,8 @@ func (r *EntryRepository) List(ctx context.Context) ([]*models.Entry, error) {
 		        COALESCE(rq.total_qty, 0) as actual_quantity,
 		        e.thock_category, e.thock_number, COALESCE(e.remark, '') as remark,
 		        e.created_by_user_id, COALESCE(u.name, '') as created_by_name, e.created_at, e.updated_at,
-		        e.family_member_id, COALESCE(e.family_member_name, '') as family_member_name
+		        e.family_member_id, COALESCE(e.family_member_name, '') as family_member_name,
+		        COALESCE(e.bag_size, '') as bag_size, COALESCE(e.season, '') as season
          FROM entries e
          LEFT JOIN (
              SELECT entry_id, SUM(quantity) as total_qty
@@ -190,7 +197,7 @@ func (r *EntryRepository) List(ctx context.Context) ([]*models.Entry, error) {
 		var entry models.Entry
 		err := rows.Scan(&entry.ID, &entry.CustomerID, &entry.Phone, &entry.Name, &entry.Village, &entry.SO,
 			&entry.ExpectedQuantity, &entry.ActualQuantity, &entry.ThockCategory, &entry.ThockNumber, &entry.Remark, &entry.CreatedByUserID,
-			&entry.CreatedByName, &entry.CreatedAt, &entry.UpdatedAt, &entry.FamilyMemberID, &entry.FamilyMemberName)
+			&entry.CreatedByName, &entry.CreatedAt, &entry.UpdatedAt, &entry.FamilyMemberID, &entry.FamilyMemberName, &entry.BagSize, &entry.Season)
 		if err != nil {
 			return nil, err
 		}
@@ -207,7 +214,8 @@ func (r *EntryRepository) ListByCustomer(ctx context.Context, customerID int) ([
 		        COALESCE(rq.total_qty, 0) as actual_quantity,
 		        e.thock_category, e.thock_number, COALESCE(e.remark, '') as remark,
 		        e.created_by_user_id, e.created_at, e.updated_at,
-		        e.family_member_id, COALESCE(e.family_member_name, '') as family_member_name
+		        e.family_member_id, COALESCE(e.family_member_name, '') as family_member_name,
+		        COALESCE(e.bag_size, '') as bag_size, COALESCE(e.season, '') as season
          FROM entries e
          LEFT JOIN (
              SELECT entry_id, SUM(quantity) as total_qty
@@ -226,7 +234,7 @@ func (r *EntryRepository) ListByCustomer(ctx context.Context, customerID int) ([
 		var entry models.Entry
 		err := rows.Scan(&entry.ID, &entry.CustomerID, &entry.Phone, &entry.Name, &entry.Village, &entry.SO,
 			&entry.ExpectedQuantity, &entry.ActualQuantity, &entry.ThockCategory, &entry.ThockNumber, &entry.Remark, &entry.CreatedByUserID,
-			&entry.CreatedAt, &entry.UpdatedAt, &entry.FamilyMemberID, &entry.FamilyMemberName)
+			&entry.CreatedAt, &entry.UpdatedAt, &entry.FamilyMemberID, &entry.FamilyMemberName, &entry.BagSize, &entry.Season)
 		if err != nil {
 			return nil, err
 		}
@@ -243,7 +251,8 @@ func (r *EntryRepository) ListSince(ctx context.Context, since string) ([]*model
 		        COALESCE(rq.total_qty, 0) as actual_quantity,
 		        e.thock_category, e.thock_number, COALESCE(e.remark, '') as remark,
 		        e.created_by_user_id, e.created_at, e.updated_at,
-		        e.family_member_id, COALESCE(e.family_member_name, '') as family_member_name
+		        e.family_member_id, COALESCE(e.family_member_name, '') as family_member_name,
+		        COALESCE(e.bag_size, '') as bag_size, COALESCE(e.season, '') as season
          FROM entries e
          LEFT JOIN (
              SELECT entry_id, SUM(quantity) as total_qty
@@ -262,7 +271,7 @@ func (r *EntryRepository) ListSince(ctx context.Context, since string) ([]*model
 		var entry models.Entry
 		err := rows.Scan(&entry.ID, &entry.CustomerID, &entry.Phone, &entry.Name, &entry.Village, &entry.SO,
 			&entry.ExpectedQuantity, &entry.ActualQuantity, &entry.ThockCategory, &entry.ThockNumber, &entry.Remark, &entry.CreatedByUserID,
-			&entry.CreatedAt, &entry.UpdatedAt, &entry.FamilyMemberID, &entry.FamilyMemberName)
+			&entry.CreatedAt, &entry.UpdatedAt, &entry.FamilyMemberID, &entry.FamilyMemberName, &entry.BagSize, &entry.Season)
 		if err != nil {
 			return nil, err
 		}
@@ -317,7 +326,8 @@ func (r *EntryRepository) ListUnassigned(ctx context.Context) ([]*models.Entry,
 		        0 as actual_quantity,
 		        e.thock_category, e.thock_number, COALESCE(e.remark, '') as remark,
 		        e.created_by_user_id, e.created_at, e.updated_at,
-		        e.family_member_id, COALESCE(e.family_member_name, '') as family_member_name
+		        e.family_member_id, COALESCE(e.family_member_name, '') as family_member_name,
+		        COALESCE(e.bag_size, '') as bag_size, COALESCE(e.season, '') as season
          FROM entries e
          LEFT JOIN room_entries re ON e.id = re.entry_id
          WHERE re.id IS NULL AND COALESCE(e.status, 'active') != 'deleted'
@@ -332,7 +342,7 @@ func (r *EntryRepository) ListUnassigned(ctx context.Context) ([]*models.Entry,
 		var entry models.Entry
 		err := rows.Scan(&entry.ID, &entry.CustomerID, &entry.Phone, &entry.Name, &entry.Village, &entry.SO,
 			&entry.ExpectedQuantity, &entry.ActualQuantity, &entry.ThockCategory, &entry.ThockNumber, &entry.Remark, &entry.CreatedByUserID,
-			&entry.CreatedAt, &entry.UpdatedAt, &entry.FamilyMemberID, &entry.FamilyMemberName)
+			&entry.CreatedAt, &entry.UpdatedAt, &entry.FamilyMemberID, &entry.FamilyMemberName, &entry.BagSize, &entry.Season)
 		if err != nil {
 			return nil, err
 		}
@@ -347,13 +357,14 @@ func (r *EntryRepository) GetByThockNumber(ctx context.Context, thockNumber stri
 		`SELECT e.id, e.customer_id, e.phone, e.name, e.village, e.so, e.expected_quantity,
 		        COALESCE((SELECT SUM(quantity) FROM room_entries WHERE entry_id = e.id), 0) as actual_quantity,
 		        e.thock_category, e.thock_number, COALESCE(e.remark, '') as remark, e.created_by_user_id, e.created_at, e.updated_at,
-		        e.family_member_id, COALESCE(e.family_member_name, '') as family_member_name
+		        e.family_member_id, COALESCE(e.family_member_name, '') as family_member_name,
+		        COALESCE(e.bag_size, '') as bag_size, COALESCE(e.season, '') as season
          FROM entries e WHERE e.thock_number=$1`, thockNumber)
 
 	var entry models.Entry
 	err := row.Scan(&entry.ID, &entry.CustomerID, &entry.Phone, &entry.Name, &entry.Village, &entry.SO,
 		&entry.ExpectedQuantity, &entry.ActualQuantity, &entry.ThockCategory, &entry.ThockNumber, &entry.Remark, &entry.CreatedByUserID,
-		&entry.CreatedAt, &entry.UpdatedAt, &entry.FamilyMemberID, &entry.FamilyMemberName)
+		&entry.CreatedAt, &entry.UpdatedAt, &entry.FamilyMemberID, &entry.FamilyMemberName, &entry.BagSize, &entry.Season)
 	return &entry, err
 }
 
@@ -502,7 +513,7 @@ func (r *EntryRepository) Update(ctx context.Context, e *models.Entry, oldCatego
 			)
 			UPDATE entries
 			SET name=$3::text, phone=$4::text, village=$5::text, so=$6::text,
-			    expected_quantity=$7::integer, remark=$8::text, thock_category=$9::text,
+			    expected_quantity=$7::integer, remark=$8::text, thock_category=$9::text, bag_size=$11::text,
 			    thock_number = CASE WHEN $9::text = 'seed'
 			        THEN LPAD((SELECT num FROM next_num)::text, 4, '0') || '/' || $7::integer::text
 			        ELSE (SELECT num FROM next_num)::text || '/' || $7::integer::text
@@ -510,14 +521,14 @@ func (r *EntryRepository) Update(ctx context.Context, e *models.Entry, oldCatego
 			    updated_at=NOW()
 			WHERE id=$10::integer`
 		_, err := r.DB.Exec(ctx, query, baseOffset, e.ThockCategory, e.Name, e.Phone, e.Village, e.SO,
-			e.ExpectedQuantity, e.Remark, e.ThockCategory, e.ID)
+			e.ExpectedQuantity, e.Remark, e.ThockCategory, e.ID, e.BagSize)
 		return err
 	} else if qtyChanged {
 		// Only quantity changed - update the quantity part of thock_number
 		query := `
 			UPDATE entries
 			SET name=$1::text, phone=$2::text, village=$3::text, so=$4::text,
-			    expected_quantity=$5::integer, remark=$6::text, thock_category=$7::text,
+			    expected_quantity=$5::integer, remark=$6::text, thock_category=$7::text, bag_size=$9::text,
 			    thock_number = CASE WHEN thock_category = 'seed'
 			        THEN LPAD(SPLIT_PART(thock_number, '/', 1), 4, '0') || '/' || $5::integer::text
 			        ELSE SPLIT_PART(thock_number, '/', 1) || '/' || $5::integer::text
@@ -525,15 +536,15 @@ func (r *EntryRepository) Update(ctx context.Context, e *models.Entry, oldCatego
 			    updated_at=NOW()
 			WHERE id=$8::integer`
 		_, err := r.DB.Exec(ctx, query, e.Name, e.Phone, e.Village, e.SO,
-			e.ExpectedQuantity, e.Remark, e.ThockCategory, e.ID)
+			e.ExpectedQuantity, e.Remark, e.ThockCategory, e.ID, e.BagSize)
 		return err
 	} else {
 		// No category or quantity change - simple update
 		query := `UPDATE entries SET name=$1::text, phone=$2::text, village=$3::text, so=$4::text,
-		          expected_quantity=$5::integer, remark=$6::text, thock_category=$7::text, updated_at=NOW()
+		          expected_quantity=$5::integer, remark=$6::text, thock_category=$7::text, bag_size=$9::text, updated_at=NOW()
 		          WHERE id=$8::integer`
 		_, err := r.DB.Exec(ctx, query, e.Name, e.Phone, e.Village, e.SO,
-			e.ExpectedQuantity, e.Remark, e.ThockCategory, e.ID)
+			e.ExpectedQuantity, e.Remark, e.ThockCategory, e.ID, e.BagSize)
 		return err
 	}
 }
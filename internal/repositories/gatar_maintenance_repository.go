@@ -0,0 +1,253 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrNoGatarStock is returned when maintenance is recorded on a gatar holding no stock
+var ErrNoGatarStock = errors.New("gatar holds no stock")
+
+type GatarMaintenanceRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewGatarMaintenanceRepository(db *pgxpool.Pool) *GatarMaintenanceRepository {
+	return &GatarMaintenanceRepository{DB: db}
+}
+
+const maintenanceScheduleColumns = `id, room_no, floor, gatar_no, task, interval_days, COALESCE(assigned_crew, ''),
+	COALESCE(notes, ''), active, COALESCE(created_by_user_id, 0), created_at, updated_at`
+
+func scanMaintenanceSchedule(row pgx.Row) (*models.GatarMaintenanceSchedule, error) {
+	s := &models.GatarMaintenanceSchedule{}
+	err := row.Scan(&s.ID, &s.RoomNo, &s.Floor, &s.GatarNo, &s.Task, &s.IntervalDays, &s.AssignedCrew,
+		&s.Notes, &s.Active, &s.CreatedByUserID, &s.CreatedAt, &s.UpdatedAt)
+	return s, err
+}
+
+// ListSchedules returns the schedules by room, floor and gatar
+func (r *GatarMaintenanceRepository) ListSchedules(ctx context.Context, activeOnly bool) ([]*models.GatarMaintenanceSchedule, error) {
+	rows, err := r.DB.Query(ctx, `SELECT `+maintenanceScheduleColumns+`
+		FROM gatar_maintenance_schedules
+		WHERE active OR NOT $1
+		ORDER BY room_no, floor, gatar_no, task`, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance schedules: %w", err)
+	}
+	defer rows.Close()
+
+	var schedules []*models.GatarMaintenanceSchedule
+	for rows.Next() {
+		s, err := scanMaintenanceSchedule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan maintenance schedule: %w", err)
+		}
+		schedules = append(schedules, s)
+	}
+	return schedules, rows.Err()
+}
+
+// GetSchedule returns a schedule, or nil if there is none
+func (r *GatarMaintenanceRepository) GetSchedule(ctx context.Context, id int) (*models.GatarMaintenanceSchedule, error) {
+	s, err := scanMaintenanceSchedule(r.DB.QueryRow(ctx,
+		`SELECT `+maintenanceScheduleColumns+` FROM gatar_maintenance_schedules WHERE id = $1`, id))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get maintenance schedule: %w", err)
+	}
+	return s, nil
+}
+
+// CreateSchedule inserts a schedule
+func (r *GatarMaintenanceRepository) CreateSchedule(ctx context.Context, s *models.GatarMaintenanceSchedule) error {
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO gatar_maintenance_schedules (room_no, floor, gatar_no, task, interval_days, assigned_crew, notes, active, created_by_user_id)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9)
+		RETURNING id, created_at, updated_at
+	`, s.RoomNo, s.Floor, s.GatarNo, s.Task, s.IntervalDays, s.AssignedCrew, s.Notes, s.Active, s.CreatedByUserID,
+	).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create maintenance schedule: %w", err)
+	}
+	return nil
+}
+
+// UpdateSchedule saves a schedule's interval, crew, notes and active flag
+func (r *GatarMaintenanceRepository) UpdateSchedule(ctx context.Context, s *models.GatarMaintenanceSchedule) error {
+	err := r.DB.QueryRow(ctx, `
+		UPDATE gatar_maintenance_schedules
+		SET interval_days = $2, assigned_crew = NULLIF($3, ''), notes = NULLIF($4, ''), active = $5, updated_at = NOW()
+		WHERE id = $1
+		RETURNING updated_at
+	`, s.ID, s.IntervalDays, s.AssignedCrew, s.Notes, s.Active).Scan(&s.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to update maintenance schedule: %w", err)
+	}
+	return nil
+}
+
+// DeleteSchedule removes a schedule; its logs are kept
+func (r *GatarMaintenanceRepository) DeleteSchedule(ctx context.Context, id int) error {
+	if _, err := r.DB.Exec(ctx, `DELETE FROM gatar_maintenance_schedules WHERE id = $1`, id); err != nil {
+		return fmt.Errorf("failed to delete maintenance schedule: %w", err)
+	}
+	return nil
+}
+
+// GetStockedSince returns every gatar holding stock, gate_no-only room entries included,
+// with when its oldest stock came in
+func (r *GatarMaintenanceRepository) GetStockedSince(ctx context.Context) (map[int]time.Time, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT reg.gatar_no, MIN(reg.created_at)
+		FROM `+stockedGatarRows+`
+		GROUP BY reg.gatar_no`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stocked gatars: %w", err)
+	}
+	defer rows.Close()
+
+	stocked := make(map[int]time.Time)
+	for rows.Next() {
+		var gatarNo int
+		var since time.Time
+		if err := rows.Scan(&gatarNo, &since); err != nil {
+			return nil, fmt.Errorf("failed to scan stocked gatar: %w", err)
+		}
+		stocked[gatarNo] = since
+	}
+	return stocked, rows.Err()
+}
+
+// GetLastDone returns when each task was last done in each gatar
+func (r *GatarMaintenanceRepository) GetLastDone(ctx context.Context) (map[int]map[string]time.Time, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT gatar_no, task, MAX(done_at)
+		FROM gatar_maintenance_logs
+		GROUP BY gatar_no, task`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load maintenance history: %w", err)
+	}
+	defer rows.Close()
+
+	lastDone := make(map[int]map[string]time.Time)
+	for rows.Next() {
+		var gatarNo int
+		var task string
+		var doneAt time.Time
+		if err := rows.Scan(&gatarNo, &task, &doneAt); err != nil {
+			return nil, fmt.Errorf("failed to scan maintenance history: %w", err)
+		}
+		if lastDone[gatarNo] == nil {
+			lastDone[gatarNo] = make(map[string]time.Time)
+		}
+		lastDone[gatarNo][task] = doneAt
+	}
+	return lastDone, rows.Err()
+}
+
+// Complete logs a task against the stocked room entry gatar rows of l.GatarNo (only
+// roomEntryGatarID when set) in one transaction, setting their quality to
+// l.ObservedQuality unless it is blank (the service only passes one with
+// roomEntryGatarID). l is the template for each row's log.
+func (r *GatarMaintenanceRepository) Complete(ctx context.Context, l *models.GatarMaintenanceLog, roomEntryGatarID int) ([]*models.GatarMaintenanceLog, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	rows, err := tx.Query(ctx, `
+		SELECT reg.id, reg.room_entry_id, re.thock_number, COALESCE(reg.quality, '')
+		FROM room_entry_gatars reg
+		JOIN room_entries re ON reg.room_entry_id = re.id
+		LEFT JOIN entries e ON re.entry_id = e.id
+		WHERE reg.gatar_no = $1 AND reg.quantity > 0 AND ($2 = 0 OR reg.id = $2)
+		  AND COALESCE(e.status, 'active') != 'deleted'
+		ORDER BY reg.id
+		FOR UPDATE OF reg`, l.GatarNo, roomEntryGatarID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load gatar stock: %w", err)
+	}
+	var logs []*models.GatarMaintenanceLog
+	for rows.Next() {
+		entry := *l
+		if err := rows.Scan(&entry.RoomEntryGatarID, &entry.RoomEntryID, &entry.ThockNumber, &entry.PreviousQuality); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan gatar stock: %w", err)
+		}
+		logs = append(logs, &entry)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to load gatar stock: %w", err)
+	}
+	if len(logs) == 0 {
+		return nil, ErrNoGatarStock
+	}
+
+	for _, entry := range logs {
+		err := tx.QueryRow(ctx, `
+			INSERT INTO gatar_maintenance_logs (schedule_id, gatar_no, room_entry_gatar_id, room_entry_id, thock_number, task,
+			                                    previous_quality, observed_quality, crew, notes, done_by_user_id)
+			VALUES (NULLIF($1, 0), $2, $3, $4, $5, $6, NULLIF($7, ''), NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), $11)
+			RETURNING id, done_at
+		`, entry.ScheduleID, entry.GatarNo, entry.RoomEntryGatarID, entry.RoomEntryID, entry.ThockNumber, entry.Task,
+			entry.PreviousQuality, entry.ObservedQuality, entry.Crew, entry.Notes, entry.DoneByUserID,
+		).Scan(&entry.ID, &entry.DoneAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to log maintenance: %w", err)
+		}
+
+		if entry.ObservedQuality != "" && entry.ObservedQuality != entry.PreviousQuality {
+			_, err = tx.Exec(ctx, `UPDATE room_entry_gatars SET quality = $1, updated_at = NOW() WHERE id = $2`,
+				entry.ObservedQuality, entry.RoomEntryGatarID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to update gatar quality: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit maintenance: %w", err)
+	}
+	return logs, nil
+}
+
+// ListLogs returns a gatar's maintenance log (every gatar when gatarNo is 0), newest first
+func (r *GatarMaintenanceRepository) ListLogs(ctx context.Context, gatarNo, limit int) ([]*models.GatarMaintenanceLog, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT l.id, COALESCE(l.schedule_id, 0), l.gatar_no, COALESCE(l.room_entry_gatar_id, 0), COALESCE(l.room_entry_id, 0),
+		       l.thock_number, l.task, COALESCE(l.previous_quality, ''), COALESCE(l.observed_quality, ''),
+		       COALESCE(l.crew, ''), COALESCE(l.notes, ''), COALESCE(l.done_by_user_id, 0), COALESCE(u.name, ''), l.done_at
+		FROM gatar_maintenance_logs l
+		LEFT JOIN users u ON l.done_by_user_id = u.id
+		WHERE $1 = 0 OR l.gatar_no = $1
+		ORDER BY l.done_at DESC, l.id DESC
+		LIMIT $2`, gatarNo, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list maintenance logs: %w", err)
+	}
+	defer rows.Close()
+
+	var logs []*models.GatarMaintenanceLog
+	for rows.Next() {
+		l := &models.GatarMaintenanceLog{}
+		if err := rows.Scan(&l.ID, &l.ScheduleID, &l.GatarNo, &l.RoomEntryGatarID, &l.RoomEntryID,
+			&l.ThockNumber, &l.Task, &l.PreviousQuality, &l.ObservedQuality,
+			&l.Crew, &l.Notes, &l.DoneByUserID, &l.DoneByName, &l.DoneAt); err != nil {
+			return nil, fmt.Errorf("failed to scan maintenance log: %w", err)
+		}
+		logs = append(logs, l)
+	}
+	return logs, rows.Err()
+}
@@ -0,0 +1,287 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ErrGatarStockNotFound is returned when the source gatar holds none of the thock
+var ErrGatarStockNotFound = errors.New("thock has no stock in that gatar")
+
+type GatarMovementRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewGatarMovementRepository(db *pgxpool.Pool) *GatarMovementRepository {
+	return &GatarMovementRepository{DB: db}
+}
+
+// gatarStockSource is the room entry gatar row a thock's bags are moved out of
+type gatarStockSource struct {
+	GatarRowID  int
+	RoomEntryID int
+	EntryID     int
+	RoomNo      string
+	Floor       string
+	Quantity    int
+	Quality     string
+}
+
+// lockGatarSource locks the thock's largest stock in a gatar
+func lockGatarSource(ctx context.Context, tx pgx.Tx, thockNumber string, gatarNo int) (*gatarStockSource, error) {
+	query := `SELECT reg.id, re.id, re.entry_id, re.room_no, re.floor, reg.quantity, COALESCE(reg.quality, '')
+         FROM room_entry_gatars reg
+         JOIN room_entries re ON reg.room_entry_id = re.id
+         LEFT JOIN entries e ON re.entry_id = e.id
+         WHERE re.thock_number = $1 AND reg.gatar_no = $2 AND reg.quantity > 0
+           AND COALESCE(e.status, 'active') != 'deleted'
+         ORDER BY reg.quantity DESC, reg.id
+         LIMIT 1
+         FOR UPDATE OF reg, re`
+
+	s := &gatarStockSource{}
+	err := tx.QueryRow(ctx, query, thockNumber, gatarNo).Scan(
+		&s.GatarRowID, &s.RoomEntryID, &s.EntryID, &s.RoomNo, &s.Floor, &s.Quantity, &s.Quality)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ErrGatarStockNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find gatar stock: %w", err)
+	}
+	return s, nil
+}
+
+// lockGatar keeps other moves out of a gatar until the transaction ends, rows or not
+func lockGatar(ctx context.Context, tx pgx.Tx, gatarNo int) error {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('gatar:' || $1::text))`, gatarNo); err != nil {
+		return fmt.Errorf("failed to lock gatar %d: %w", gatarNo, err)
+	}
+	return nil
+}
+
+// lockGatarStock locks a gatar's stocked rows and returns the bags it holds, leaving out
+// one room entry (pass 0 to count all)
+func lockGatarStock(ctx context.Context, tx pgx.Tx, gatarNo, excludeRoomEntryID int) (int, error) {
+	rows, err := tx.Query(ctx, `SELECT reg.quantity FROM `+stockedGatarRows+`
+         AND reg.gatar_no = $1 AND reg.room_entry_id != $2
+         FOR UPDATE OF reg`, gatarNo, excludeRoomEntryID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load gatar %d stock: %w", gatarNo, err)
+	}
+	defer rows.Close()
+
+	stored := 0
+	for rows.Next() {
+		var quantity int
+		if err := rows.Scan(&quantity); err != nil {
+			return 0, fmt.Errorf("failed to scan gatar %d stock: %w", gatarNo, err)
+		}
+		stored += quantity
+	}
+	return stored, rows.Err()
+}
+
+// Transfer moves m.Quantity bags of m.ThockNumber from m.FromGatar to m.ToGatar in one
+// transaction. The bags leave the thock's largest row in the source gatar and join the
+// thock's room entry on the destination floor - the same room entry when the floor does
+// not change, otherwise an existing one for the entry on that floor or a new one. Both
+// room entries' quantity, gate_no and quantity_breakdown follow their gatar rows. The
+// movement is recorded in gatar_movements; m is filled in with its IDs and locations.
+//
+// The destination's stock is checked against capacity under lock, so two moves cannot
+// both fill its last space: overfilling it fails when blockOverflow is set and is a
+// warning on m otherwise.
+func (r *GatarMovementRepository) Transfer(ctx context.Context, m *models.GatarMovement, capacity int, blockOverflow bool) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// Lower gatar first, so moves in opposite directions cannot deadlock
+	first, second := m.FromGatar, m.ToGatar
+	if second < first {
+		first, second = second, first
+	}
+	if err := lockGatar(ctx, tx, first); err != nil {
+		return err
+	}
+	if err := lockGatar(ctx, tx, second); err != nil {
+		return err
+	}
+
+	src, err := lockGatarSource(ctx, tx, m.ThockNumber, m.FromGatar)
+	if err != nil {
+		return err
+	}
+	if src.Quantity < m.Quantity {
+		return fmt.Errorf("gatar %d holds only %d bags of thock %s", m.FromGatar, src.Quantity, m.ThockNumber)
+	}
+
+	// Capacity of the destination once the bags are in
+	stored, err := lockGatarStock(ctx, tx, m.ToGatar, 0)
+	if err != nil {
+		return err
+	}
+	u := models.GatarUtilization{GatarNo: m.ToGatar, Capacity: capacity, Used: stored + m.Quantity}
+	u.Free = u.Capacity - u.Used
+	m.GatarCapacity = []models.GatarUtilization{u}
+	if u.Free < 0 {
+		warning := fmt.Sprintf("gatar %d would hold %d bags, %d over its capacity of %d",
+			u.GatarNo, u.Used, -u.Free, u.Capacity)
+		if blockOverflow {
+			return errors.New("gatar capacity exceeded: " + warning)
+		}
+		m.CapacityWarnings = []string{warning}
+	}
+	m.EntryID = src.EntryID
+	m.FromRoomEntryID = src.RoomEntryID
+	m.FromRoomNo = src.RoomNo
+	m.FromFloor = src.Floor
+
+	if src.Quantity == m.Quantity {
+		_, err = tx.Exec(ctx, `DELETE FROM room_entry_gatars WHERE id = $1`, src.GatarRowID)
+	} else {
+		_, err = tx.Exec(ctx,
+			`UPDATE room_entry_gatars SET quantity = quantity - $1, updated_at = NOW() WHERE id = $2`,
+			m.Quantity, src.GatarRowID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to take bags out of gatar %d: %w", m.FromGatar, err)
+	}
+
+	// Destination room entry
+	m.ToRoomEntryID = src.RoomEntryID
+	if m.ToRoomNo != src.RoomNo || m.ToFloor != src.Floor {
+		err = tx.QueryRow(ctx,
+			`SELECT id FROM room_entries
+             WHERE entry_id = $1 AND thock_number = $2 AND room_no = $3 AND floor = $4
+             ORDER BY id LIMIT 1 FOR UPDATE`,
+			src.EntryID, m.ThockNumber, m.ToRoomNo, m.ToFloor).Scan(&m.ToRoomEntryID)
+		if errors.Is(err, pgx.ErrNoRows) {
+			err = tx.QueryRow(ctx,
+				`INSERT INTO room_entries(entry_id, thock_number, room_no, floor, gate_no, remark, quantity, quantity_breakdown, created_by_user_id)
+                 SELECT entry_id, thock_number, $2, $3, '', remark, 0, '', $4
+                 FROM room_entries WHERE id = $1
+                 RETURNING id`,
+				src.RoomEntryID, m.ToRoomNo, m.ToFloor, m.MovedByUserID).Scan(&m.ToRoomEntryID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to find room entry on room %s floor %s: %w", m.ToRoomNo, m.ToFloor, err)
+		}
+
+		_, err = tx.Exec(ctx,
+			`UPDATE room_entries SET quantity = quantity + CASE WHEN id = $2 THEN $1::int ELSE -$1::int END, updated_at = NOW()
+             WHERE id IN ($2, $3)`,
+			m.Quantity, m.ToRoomEntryID, src.RoomEntryID)
+		if err != nil {
+			return fmt.Errorf("failed to move room entry quantity: %w", err)
+		}
+	}
+
+	tag, err := tx.Exec(ctx,
+		`UPDATE room_entry_gatars SET quantity = quantity + $1, updated_at = NOW()
+         WHERE id = (SELECT id FROM room_entry_gatars WHERE room_entry_id = $2 AND gatar_no = $3 ORDER BY id LIMIT 1)`,
+		m.Quantity, m.ToRoomEntryID, m.ToGatar)
+	if err != nil {
+		return fmt.Errorf("failed to put bags in gatar %d: %w", m.ToGatar, err)
+	}
+	if tag.RowsAffected() == 0 {
+		_, err = tx.Exec(ctx,
+			`INSERT INTO room_entry_gatars(room_entry_id, gatar_no, quantity, quality, remark)
+             VALUES($1, $2, $3, NULLIF($4, ''), $5)`,
+			m.ToRoomEntryID, m.ToGatar, m.Quantity, src.Quality, fmt.Sprintf("Moved from gatar %d", m.FromGatar))
+		if err != nil {
+			return fmt.Errorf("failed to put bags in gatar %d: %w", m.ToGatar, err)
+		}
+	}
+
+	// gate_no and quantity_breakdown list the room entry's gatars and their bags
+	_, err = tx.Exec(ctx,
+		`UPDATE room_entries re
+         SET gate_no = COALESCE(g.gate_no, ''), quantity_breakdown = COALESCE(g.breakdown, ''), updated_at = NOW()
+         FROM (SELECT ids.id,
+                      STRING_AGG(reg.gatar_no::text, ', ' ORDER BY reg.gatar_no) AS gate_no,
+                      STRING_AGG(reg.quantity::text, ', ' ORDER BY reg.gatar_no) AS breakdown
+               FROM UNNEST($1::int[]) AS ids(id)
+               LEFT JOIN room_entry_gatars reg ON reg.room_entry_id = ids.id AND reg.quantity > 0
+               GROUP BY ids.id) g
+         WHERE re.id = g.id`,
+		[]int{src.RoomEntryID, m.ToRoomEntryID})
+	if err != nil {
+		return fmt.Errorf("failed to update room entry gatars: %w", err)
+	}
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO gatar_movements(entry_id, thock_number, from_room_entry_id, from_room_no, from_floor, from_gatar,
+                                     to_room_entry_id, to_room_no, to_floor, to_gatar, quantity, reason, notes, moved_by_user_id)
+         VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NULLIF($13, ''), $14)
+         RETURNING id, created_at`,
+		m.EntryID, m.ThockNumber, m.FromRoomEntryID, m.FromRoomNo, m.FromFloor, m.FromGatar,
+		m.ToRoomEntryID, m.ToRoomNo, m.ToFloor, m.ToGatar, m.Quantity, m.Reason, m.Notes, m.MovedByUserID,
+	).Scan(&m.ID, &m.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record gatar movement: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// ListByGatar returns the movements into or out of a gatar, newest first. Zero times
+// leave the range open.
+func (r *GatarMovementRepository) ListByGatar(ctx context.Context, gatarNo int, from, to time.Time, limit int) ([]*models.GatarMovement, error) {
+	return r.list(ctx, `(gm.from_gatar = $1 OR gm.to_gatar = $1)`, gatarNo, from, to, limit)
+}
+
+// ListByThock returns a thock's movements, newest first
+func (r *GatarMovementRepository) ListByThock(ctx context.Context, thockNumber string, limit int) ([]*models.GatarMovement, error) {
+	return r.list(ctx, `gm.thock_number = $1`, thockNumber, time.Time{}, time.Time{}, limit)
+}
+
+func (r *GatarMovementRepository) list(ctx context.Context, where string, arg any, from, to time.Time, limit int) ([]*models.GatarMovement, error) {
+	query := `SELECT gm.id, gm.entry_id, gm.thock_number,
+	                 COALESCE(gm.from_room_entry_id, 0), gm.from_room_no, gm.from_floor, gm.from_gatar,
+	                 COALESCE(gm.to_room_entry_id, 0), gm.to_room_no, gm.to_floor, gm.to_gatar,
+	                 gm.quantity, gm.reason, COALESCE(gm.notes, ''),
+	                 COALESCE(gm.moved_by_user_id, 0), COALESCE(u.name, ''), gm.created_at
+	          FROM gatar_movements gm
+	          LEFT JOIN users u ON gm.moved_by_user_id = u.id
+	          WHERE ` + where + `
+	            AND ($2::timestamp IS NULL OR gm.created_at >= $2)
+	            AND ($3::timestamp IS NULL OR gm.created_at < $3)
+	          ORDER BY gm.created_at DESC, gm.id DESC
+	          LIMIT $4`
+
+	var fromArg, toArg *time.Time
+	if !from.IsZero() {
+		fromArg = &from
+	}
+	if !to.IsZero() {
+		toArg = &to
+	}
+	rows, err := r.DB.Query(ctx, query, arg, fromArg, toArg, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list gatar movements: %w", err)
+	}
+	defer rows.Close()
+
+	var movements []*models.GatarMovement
+	for rows.Next() {
+		m := &models.GatarMovement{}
+		if err := rows.Scan(&m.ID, &m.EntryID, &m.ThockNumber,
+			&m.FromRoomEntryID, &m.FromRoomNo, &m.FromFloor, &m.FromGatar,
+			&m.ToRoomEntryID, &m.ToRoomNo, &m.ToFloor, &m.ToGatar,
+			&m.Quantity, &m.Reason, &m.Notes, &m.MovedByUserID, &m.MovedByName, &m.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan gatar movement: %w", err)
+		}
+		movements = append(movements, m)
+	}
+	return movements, rows.Err()
+}
@@ -65,6 +65,30 @@ func (r *GatePassPickupRepository) GetPickupsByGatePassID(ctx context.Context, g
 	return pickups, rows.Err()
 }
 
+// GetPickupByID retrieves a single pickup
+func (r *GatePassPickupRepository) GetPickupByID(ctx context.Context, id int) (*models.GatePassPickup, error) {
+	query := `
+		SELECT
+			gpp.id, gpp.gate_pass_id, gpp.pickup_quantity, gpp.picked_up_by_user_id,
+			gpp.pickup_time, gpp.room_no, gpp.floor, gpp.remarks, gpp.created_at,
+			COALESCE(u.name, '') as picked_up_by_user_name
+		FROM gate_pass_pickups gpp
+		LEFT JOIN users u ON gpp.picked_up_by_user_id = u.id
+		WHERE gpp.id = $1
+	`
+
+	var pickup models.GatePassPickup
+	err := r.DB.QueryRow(ctx, query, id).Scan(
+		&pickup.ID, &pickup.GatePassID, &pickup.PickupQuantity, &pickup.PickedUpByUserID,
+		&pickup.PickupTime, &pickup.RoomNo, &pickup.Floor, &pickup.Remarks, &pickup.CreatedAt,
+		&pickup.PickedUpByUserName,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &pickup, nil
+}
+
 // GetAllPickups retrieves all pickups with customer and gate pass info for activity log
 func (r *GatePassPickupRepository) GetAllPickups(ctx context.Context) ([]map[string]interface{}, error) {
 	query := `
@@ -690,6 +690,27 @@ func (r *GatePassRepository) GetTotalApprovedQuantityForEntry(ctx context.Contex
 	return totalApproved, nil
 }
 
+// GetOpenQuantityForThock returns the bags of a thock approved on gate passes but not yet
+// picked up, leaving out excludeID (0 = none)
+func (r *GatePassRepository) GetOpenQuantityForThock(ctx context.Context, thockNumber string, excludeID int) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(
+			GREATEST(COALESCE(approved_quantity, requested_quantity) - total_picked_up, 0)
+		), 0)
+		FROM gate_passes
+		WHERE thock_number = $1 AND id <> $2
+		AND status IN ('approved', 'partially_completed')
+	`
+
+	var openQty int
+	err := r.DB.QueryRow(ctx, query, thockNumber, excludeID).Scan(&openQty)
+	if err != nil {
+		return 0, err
+	}
+
+	return openQty, nil
+}
+
 // GetPendingQuantityForEntry calculates the remaining quantity yet to be picked up
 // from pending, approved, and partially_completed gate passes
 // This is used to show accurate "Can Take Out" values
@@ -0,0 +1,244 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// GatewaySettlementRepository stores payment gateway settlement reports and reads the
+// settlement state of online transactions
+type GatewaySettlementRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewGatewaySettlementRepository(db *pgxpool.Pool) *GatewaySettlementRepository {
+	return &GatewaySettlementRepository{DB: db}
+}
+
+const settlementLineColumns = `sl.id, sl.import_id, sl.gateway, sl.line_type, sl.entity_id,
+	COALESCE(sl.order_id, ''), COALESCE(sl.payment_id, ''), COALESCE(sl.settlement_id, ''), COALESCE(sl.settlement_utr, ''),
+	sl.amount, sl.fee, sl.tax, sl.settled_amount, sl.settled, sl.settled_at,
+	sl.online_transaction_id, sl.expected_fee, sl.expected_amount, sl.status, COALESCE(sl.notes, ''),
+	COALESCE(ot.customer_name, ''), COALESCE(ot.customer_phone, ''), sl.created_at`
+
+func scanSettlementLine(row pgx.Row) (*models.GatewaySettlementLine, error) {
+	l := &models.GatewaySettlementLine{}
+	err := row.Scan(&l.ID, &l.ImportID, &l.Gateway, &l.LineType, &l.EntityID,
+		&l.OrderID, &l.PaymentID, &l.SettlementID, &l.SettlementUTR,
+		&l.Amount, &l.Fee, &l.Tax, &l.SettledAmount, &l.Settled, &l.SettledAt,
+		&l.OnlineTransactionID, &l.ExpectedFee, &l.ExpectedAmount, &l.Status, &l.Notes,
+		&l.CustomerName, &l.CustomerPhone, &l.CreatedAt)
+	return l, err
+}
+
+// CreateImport records a fetched or uploaded settlement report
+func (r *GatewaySettlementRepository) CreateImport(ctx context.Context, gateway, source, fileName string, userID int) (int, error) {
+	var id int
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO gateway_settlement_imports (gateway, source, file_name, imported_by_user_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id
+	`, gateway, source, fileName, userID).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create settlement import: %w", err)
+	}
+	return id, nil
+}
+
+// FinishImport stores the counts of an import once its lines have been matched
+func (r *GatewaySettlementRepository) FinishImport(ctx context.Context, imp *models.GatewaySettlementImport) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE gateway_settlement_imports
+		SET line_count = $2, duplicate_count = $3, matched_count = $4, flagged_count = $5
+		WHERE id = $1
+	`, imp.ID, imp.LineCount, imp.DuplicateCount, imp.MatchedCount, imp.FlaggedCount)
+	if err != nil {
+		return fmt.Errorf("failed to update settlement import: %w", err)
+	}
+	return nil
+}
+
+// ListImports returns recent imports, newest first
+func (r *GatewaySettlementRepository) ListImports(ctx context.Context, limit int) ([]*models.GatewaySettlementImport, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT i.id, i.gateway, i.source, i.file_name, i.line_count, i.duplicate_count, i.matched_count, i.flagged_count,
+		       COALESCE(i.imported_by_user_id, 0), COALESCE(u.name, ''), i.created_at
+		FROM gateway_settlement_imports i
+		LEFT JOIN users u ON u.id = i.imported_by_user_id
+		ORDER BY i.created_at DESC, i.id DESC
+		LIMIT $1
+	`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list settlement imports: %w", err)
+	}
+	defer rows.Close()
+
+	var imports []*models.GatewaySettlementImport
+	for rows.Next() {
+		i := &models.GatewaySettlementImport{}
+		if err := rows.Scan(&i.ID, &i.Gateway, &i.Source, &i.FileName, &i.LineCount, &i.DuplicateCount, &i.MatchedCount, &i.FlaggedCount,
+			&i.ImportedByUserID, &i.ImportedByName, &i.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan settlement import: %w", err)
+		}
+		imports = append(imports, i)
+	}
+	return imports, rows.Err()
+}
+
+// InsertLine stores a matched settlement line. A line imported before is replaced while
+// it is flagged (short or unmatched), so a later report can clear it. Returns false when
+// the same gateway transaction was imported before and is not flagged.
+func (r *GatewaySettlementRepository) InsertLine(ctx context.Context, l *models.GatewaySettlementLine) (bool, error) {
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO gateway_settlement_lines (
+			import_id, gateway, line_type, entity_id, order_id, payment_id, settlement_id, settlement_utr,
+			amount, fee, tax, settled_amount, settled, settled_at,
+			online_transaction_id, expected_fee, expected_amount, status, notes
+		)
+		VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''),
+		        $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, NULLIF($19, ''))
+		ON CONFLICT (gateway, line_type, entity_id) DO UPDATE SET
+			import_id = EXCLUDED.import_id, order_id = EXCLUDED.order_id, payment_id = EXCLUDED.payment_id,
+			settlement_id = EXCLUDED.settlement_id, settlement_utr = EXCLUDED.settlement_utr,
+			amount = EXCLUDED.amount, fee = EXCLUDED.fee, tax = EXCLUDED.tax, settled_amount = EXCLUDED.settled_amount,
+			settled = EXCLUDED.settled, settled_at = EXCLUDED.settled_at,
+			online_transaction_id = EXCLUDED.online_transaction_id, expected_fee = EXCLUDED.expected_fee,
+			expected_amount = EXCLUDED.expected_amount, status = EXCLUDED.status, notes = EXCLUDED.notes
+		WHERE gateway_settlement_lines.status IN ('short', 'unmatched')
+		RETURNING id, created_at
+	`, l.ImportID, l.Gateway, l.LineType, l.EntityID, l.OrderID, l.PaymentID, l.SettlementID, l.SettlementUTR,
+		l.Amount, l.Fee, l.Tax, l.SettledAmount, l.Settled, l.SettledAt,
+		l.OnlineTransactionID, l.ExpectedFee, l.ExpectedAmount, l.Status, l.Notes).
+		Scan(&l.ID, &l.CreatedAt)
+	if err == pgx.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to insert settlement line: %w", err)
+	}
+	return true, nil
+}
+
+// ListLines returns settlement lines by status and/or import, in settlement order
+func (r *GatewaySettlementRepository) ListLines(ctx context.Context, status string, importID int) ([]*models.GatewaySettlementLine, error) {
+	return r.queryLines(ctx, `
+		WHERE ($1 = '' OR sl.status = $1)
+		  AND ($2 = 0 OR sl.import_id = $2)
+		ORDER BY COALESCE(sl.settled_at, sl.created_at), sl.id
+	`, status, importID)
+}
+
+// ListFlagged returns the short and unmatched lines of a gateway settled in [from, to)
+func (r *GatewaySettlementRepository) ListFlagged(ctx context.Context, gateway string, from, to time.Time) ([]*models.GatewaySettlementLine, error) {
+	return r.queryLines(ctx, `
+		WHERE sl.gateway = $1 AND sl.status IN ('short', 'unmatched')
+		  AND COALESCE(sl.settled_at, sl.created_at) >= $2 AND COALESCE(sl.settled_at, sl.created_at) < $3
+		ORDER BY COALESCE(sl.settled_at, sl.created_at), sl.id
+	`, gateway, from, to)
+}
+
+// ListFlaggedLines returns every short and unmatched line of a gateway
+func (r *GatewaySettlementRepository) ListFlaggedLines(ctx context.Context, gateway string) ([]*models.GatewaySettlementLine, error) {
+	return r.queryLines(ctx, `
+		WHERE sl.gateway = $1 AND sl.status IN ('short', 'unmatched')
+		ORDER BY sl.id
+	`, gateway)
+}
+
+// UpdateMatch saves the result of matching a stored line again
+func (r *GatewaySettlementRepository) UpdateMatch(ctx context.Context, l *models.GatewaySettlementLine) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE gateway_settlement_lines
+		SET online_transaction_id = $2, expected_fee = $3, expected_amount = $4, status = $5, notes = NULLIF($6, '')
+		WHERE id = $1
+	`, l.ID, l.OnlineTransactionID, l.ExpectedFee, l.ExpectedAmount, l.Status, l.Notes)
+	if err != nil {
+		return fmt.Errorf("failed to update settlement line: %w", err)
+	}
+	return nil
+}
+
+func (r *GatewaySettlementRepository) queryLines(ctx context.Context, where string, args ...interface{}) ([]*models.GatewaySettlementLine, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT `+settlementLineColumns+`
+		FROM gateway_settlement_lines sl
+		LEFT JOIN online_transactions ot ON ot.id = sl.online_transaction_id
+	`+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list settlement lines: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []*models.GatewaySettlementLine
+	for rows.Next() {
+		l, err := scanSettlementLine(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan settlement line: %w", err)
+		}
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
+// Totals fills a report's totals from the payment lines of a gateway settled in [from, to)
+func (r *GatewaySettlementRepository) Totals(ctx context.Context, gateway string, report *models.SettlementReport) error {
+	err := r.DB.QueryRow(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(amount), 0), COALESCE(SUM(fee), 0), COALESCE(SUM(tax), 0),
+		       COALESCE(SUM(expected_fee), 0), COALESCE(SUM(settled_amount), 0),
+		       COUNT(*) FILTER (WHERE status = 'short'),
+		       COALESCE(SUM(expected_amount - settled_amount) FILTER (WHERE status = 'short'), 0),
+		       COUNT(*) FILTER (WHERE status = 'unmatched')
+		FROM gateway_settlement_lines
+		WHERE gateway = $1 AND line_type = 'payment'
+		  AND COALESCE(settled_at, created_at) >= $2 AND COALESCE(settled_at, created_at) < $3
+	`, gateway, report.From, report.To).Scan(
+		&report.SettledCount, &report.GrossAmount, &report.FeeDeducted, &report.TaxDeducted,
+		&report.FeeCharged, &report.SettledAmount,
+		&report.ShortCount, &report.ShortAmount, &report.UnmatchedCount)
+	if err != nil {
+		return fmt.Errorf("failed to total settlements: %w", err)
+	}
+	return nil
+}
+
+// ListUnsettled returns a gateway's successful transactions completed in [from, to) that
+// no settlement report has included
+func (r *GatewaySettlementRepository) ListUnsettled(ctx context.Context, gateway string, from, to time.Time) ([]*models.OnlineTransaction, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT ot.id, ot.gateway, ot.razorpay_order_id, COALESCE(ot.razorpay_payment_id, ''),
+		       ot.customer_id, ot.customer_phone, ot.customer_name,
+		       ot.amount, ot.fee_amount, ot.total_amount, COALESCE(ot.utr_number, ''), ot.status,
+		       ot.created_at, ot.completed_at
+		FROM online_transactions ot
+		WHERE ot.gateway = $1 AND ot.status IN ('success', 'refunded')
+		  AND ot.completed_at >= $2 AND ot.completed_at < $3
+		  AND NOT EXISTS (
+			SELECT 1 FROM gateway_settlement_lines sl
+			WHERE sl.online_transaction_id = ot.id AND sl.line_type = 'payment'
+		  )
+		ORDER BY ot.completed_at
+	`, gateway, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unsettled transactions: %w", err)
+	}
+	defer rows.Close()
+
+	var transactions []*models.OnlineTransaction
+	for rows.Next() {
+		tx := &models.OnlineTransaction{}
+		if err := rows.Scan(&tx.ID, &tx.Gateway, &tx.RazorpayOrderID, &tx.RazorpayPaymentID,
+			&tx.CustomerID, &tx.CustomerPhone, &tx.CustomerName,
+			&tx.Amount, &tx.FeeAmount, &tx.TotalAmount, &tx.UTRNumber, &tx.Status,
+			&tx.CreatedAt, &tx.CompletedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan unsettled transaction: %w", err)
+		}
+		transactions = append(transactions, tx)
+	}
+	return transactions, rows.Err()
+}
@@ -0,0 +1,226 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type InterestRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewInterestRepository(db *pgxpool.Pool) *InterestRepository {
+	return &InterestRepository{DB: db}
+}
+
+// OverdueBalance is a customer's ledger position used to calculate interest
+type OverdueBalance struct {
+	CustomerPhone   string
+	CustomerName    string
+	CustomerSO      string
+	Balance         float64 // All debits - credits up to period end
+	OverdueCharges  float64 // Non-interest debits on or before the overdue cutoff
+	OverdueInterest float64 // Interest debits on or before the overdue cutoff
+	Credits         float64 // All credits up to period end
+}
+
+// GetOverdueBalances returns every customer with a positive balance at periodEnd,
+// split into charges and interest that were already overdue at cutoff.
+// Crop loan entries are left out - loans accrue their own interest - as are voided pairs.
+func (r *InterestRepository) GetOverdueBalances(ctx context.Context, cutoff, periodEnd time.Time) ([]OverdueBalance, error) {
+	query := `
+		SELECT
+			customer_phone,
+			MAX(customer_name) as customer_name,
+			COALESCE(MAX(customer_so), '') as customer_so,
+			COALESCE(SUM(debit) - SUM(credit), 0) as balance,
+			COALESCE(SUM(debit) FILTER (WHERE created_at <= $1 AND entry_type <> 'INTEREST'), 0) as overdue_charges,
+			COALESCE(SUM(debit) FILTER (WHERE created_at <= $1 AND entry_type = 'INTEREST'), 0) as overdue_interest,
+			COALESCE(SUM(credit), 0) as credits
+		FROM ledger_entries
+		WHERE created_at <= $2 AND COALESCE(reference_type, '') <> 'crop_loan'
+		  AND voided_at IS NULL AND reversal_of_id IS NULL
+		GROUP BY customer_phone
+		HAVING SUM(debit) - SUM(credit) > 0
+		ORDER BY balance DESC
+	`
+
+	rows, err := r.DB.Query(ctx, query, cutoff, periodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get overdue balances: %w", err)
+	}
+	defer rows.Close()
+
+	var balances []OverdueBalance
+	for rows.Next() {
+		var b OverdueBalance
+		if err := rows.Scan(&b.CustomerPhone, &b.CustomerName, &b.CustomerSO,
+			&b.Balance, &b.OverdueCharges, &b.OverdueInterest, &b.Credits); err != nil {
+			return nil, err
+		}
+		balances = append(balances, b)
+	}
+	return balances, nil
+}
+
+// PostRun records an interest run and posts its INTEREST ledger entries in one
+// transaction: either the whole month is posted or nothing is. Each entry is linked to
+// the run, whose totals are taken from the entries. Fails if the period was already posted.
+func (r *InterestRepository) PostRun(ctx context.Context, run *models.InterestRun, entries []*models.CreateLedgerEntryRequest) ([]*models.LedgerEntry, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO interest_runs (period, period_end, method, monthly_rate_percent, grace_days,
+		                           customer_count, total_interest, posted_by_user_id)
+		VALUES ($1, $2, $3, $4, $5, 0, 0, $6)
+		RETURNING id, created_at
+	`, run.Period, run.PeriodEnd, run.Method, run.MonthlyRatePercent, run.GraceDays, run.PostedByUserID,
+	).Scan(&run.ID, &run.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create interest run: %w", err)
+	}
+
+	posted := make([]*models.LedgerEntry, 0, len(entries))
+	run.CustomerCount, run.TotalInterest = 0, 0
+	for _, entry := range entries {
+		entry.ReferenceID = &run.ID
+		created, err := insertLedgerEntry(ctx, tx, entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to post interest for %s: %w", entry.CustomerPhone, err)
+		}
+		posted = append(posted, created)
+		run.CustomerCount++
+		run.TotalInterest += created.Debit
+	}
+
+	_, err = tx.Exec(ctx, `UPDATE interest_runs SET customer_count = $2, total_interest = $3 WHERE id = $1`,
+		run.ID, run.CustomerCount, run.TotalInterest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update interest run: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit interest run: %w", err)
+	}
+	return posted, nil
+}
+
+const interestRunColumns = `
+	ir.id, ir.period, ir.period_end, ir.method, ir.monthly_rate_percent, ir.grace_days,
+	ir.customer_count, ir.total_interest, ir.posted_by_user_id, COALESCE(u.name, ''), ir.created_at`
+
+func scanInterestRun(row pgx.Row) (*models.InterestRun, error) {
+	run := &models.InterestRun{}
+	err := row.Scan(&run.ID, &run.Period, &run.PeriodEnd, &run.Method, &run.MonthlyRatePercent, &run.GraceDays,
+		&run.CustomerCount, &run.TotalInterest, &run.PostedByUserID, &run.PostedByName, &run.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// GetRunByPeriod returns the run for a period (nil if the period has not been posted)
+func (r *InterestRepository) GetRunByPeriod(ctx context.Context, period string) (*models.InterestRun, error) {
+	query := `SELECT ` + interestRunColumns + `
+		FROM interest_runs ir
+		LEFT JOIN users u ON ir.posted_by_user_id = u.id
+		WHERE ir.period = $1`
+	run, err := scanInterestRun(r.DB.QueryRow(ctx, query, period))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get interest run: %w", err)
+	}
+	return run, nil
+}
+
+// ListRuns returns all posted months, newest first
+func (r *InterestRepository) ListRuns(ctx context.Context) ([]*models.InterestRun, error) {
+	query := `SELECT ` + interestRunColumns + `
+		FROM interest_runs ir
+		LEFT JOIN users u ON ir.posted_by_user_id = u.id
+		ORDER BY ir.period DESC`
+
+	rows, err := r.DB.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interest runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*models.InterestRun
+	for rows.Next() {
+		run, err := scanInterestRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan interest run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
+
+// CreateWaiver exempts a customer from interest
+func (r *InterestRepository) CreateWaiver(ctx context.Context, w *models.InterestWaiver) error {
+	query := `
+		INSERT INTO interest_waivers (customer_phone, reason, valid_from, valid_to, is_active, created_by_user_id)
+		VALUES ($1, $2, $3, $4, TRUE, $5)
+		RETURNING id, is_active, created_at
+	`
+	err := r.DB.QueryRow(ctx, query,
+		w.CustomerPhone, w.Reason, w.ValidFrom, w.ValidTo, w.CreatedByUserID,
+	).Scan(&w.ID, &w.IsActive, &w.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create interest waiver: %w", err)
+	}
+	return nil
+}
+
+// ListWaivers returns waivers, optionally only active ones
+func (r *InterestRepository) ListWaivers(ctx context.Context, activeOnly bool) ([]*models.InterestWaiver, error) {
+	query := `
+		SELECT w.id, w.customer_phone, w.reason, w.valid_from, w.valid_to, w.is_active,
+		       w.created_by_user_id, COALESCE(u.name, ''), w.created_at
+		FROM interest_waivers w
+		LEFT JOIN users u ON w.created_by_user_id = u.id
+		WHERE ($1 = FALSE OR w.is_active = TRUE)
+		ORDER BY w.created_at DESC
+	`
+	rows, err := r.DB.Query(ctx, query, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list interest waivers: %w", err)
+	}
+	defer rows.Close()
+
+	var waivers []*models.InterestWaiver
+	for rows.Next() {
+		w := &models.InterestWaiver{}
+		if err := rows.Scan(&w.ID, &w.CustomerPhone, &w.Reason, &w.ValidFrom, &w.ValidTo, &w.IsActive,
+			&w.CreatedByUserID, &w.CreatedByName, &w.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan interest waiver: %w", err)
+		}
+		waivers = append(waivers, w)
+	}
+	return waivers, nil
+}
+
+// DeactivateWaiver ends a waiver
+func (r *InterestRepository) DeactivateWaiver(ctx context.Context, id int) error {
+	result, err := r.DB.Exec(ctx, `UPDATE interest_waivers SET is_active = FALSE WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate interest waiver: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("interest waiver not found")
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// LedgerIntegrityRepository recomputes ledger balances and stores integrity check results
+type LedgerIntegrityRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewLedgerIntegrityRepository(db *pgxpool.Pool) *LedgerIntegrityRepository {
+	return &LedgerIntegrityRepository{DB: db}
+}
+
+// recomputedBalances recalculates every running balance from scratch, in the same
+// chronological order used by 003_migrate_payments_to_ledger.sql
+const recomputedBalances = `
+	WITH recomputed AS (
+		SELECT id, customer_phone, customer_name, created_at, running_balance,
+		       SUM(debit - credit) OVER (PARTITION BY customer_phone ORDER BY created_at, id) AS expected
+		FROM ledger_entries
+	)`
+
+// CountEntries returns the number of customers and ledger entries checked
+func (r *LedgerIntegrityRepository) CountEntries(ctx context.Context) (customers, entries int, err error) {
+	err = r.DB.QueryRow(ctx, `
+		SELECT COUNT(DISTINCT customer_phone), COUNT(*) FROM ledger_entries
+	`).Scan(&customers, &entries)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to count ledger entries: %w", err)
+	}
+	return customers, entries, nil
+}
+
+// FindRunningBalanceMismatches returns, per customer, the first entry whose stored
+// running balance differs from the recomputed one and how many entries are wrong
+func (r *LedgerIntegrityRepository) FindRunningBalanceMismatches(ctx context.Context) ([]models.LedgerDiscrepancy, error) {
+	rows, err := r.DB.Query(ctx, recomputedBalances+`
+		SELECT customer_phone, customer_name, id, expected, running_balance, wrong_count
+		FROM (
+			SELECT *,
+			       COUNT(*) OVER (PARTITION BY customer_phone) AS wrong_count,
+			       ROW_NUMBER() OVER (PARTITION BY customer_phone ORDER BY created_at, id) AS rn
+			FROM recomputed
+			WHERE ABS(running_balance - expected) >= 0.01
+		) wrong
+		WHERE rn = 1
+		ORDER BY customer_name, customer_phone
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check running balances: %w", err)
+	}
+	defer rows.Close()
+
+	var found []models.LedgerDiscrepancy
+	for rows.Next() {
+		d := models.LedgerDiscrepancy{Type: models.LedgerDiscrepancyRunningBalance}
+		var entryID int
+		if err := rows.Scan(&d.CustomerPhone, &d.CustomerName, &entryID, &d.Expected, &d.Actual, &d.EntryCount); err != nil {
+			return nil, fmt.Errorf("failed to scan running balance mismatch: %w", err)
+		}
+		d.EntryID = &entryID
+		found = append(found, d)
+	}
+	return found, nil
+}
+
+// FindRentPaymentMismatches compares, per customer, rent_payments with the PAYMENT
+// entries that reference them. Voided entries still count: voiding corrects the
+// ledger, it does not remove the payment record. Cheques only count once cleared.
+func (r *LedgerIntegrityRepository) FindRentPaymentMismatches(ctx context.Context) ([]models.LedgerDiscrepancy, error) {
+	return r.findTotalMismatches(ctx, models.LedgerDiscrepancyRentPayments, `
+		WITH source AS (
+			SELECT customer_phone, MAX(customer_name) AS customer_name, SUM(amount_paid) AS total
+			FROM rent_payments
+			WHERE amount_paid > 0 AND (payment_mode <> 'cheque' OR cleared_at IS NOT NULL)
+			GROUP BY customer_phone
+		), ledger AS (
+			SELECT customer_phone, MAX(customer_name) AS customer_name, SUM(credit) AS total
+			FROM ledger_entries
+			WHERE entry_type = 'PAYMENT' AND reference_type = 'payment'
+			GROUP BY customer_phone
+		)`)
+}
+
+// FindOnlinePaymentMismatches compares, per customer, successful online transactions
+// with ONLINE_PAYMENT ledger entries
+func (r *LedgerIntegrityRepository) FindOnlinePaymentMismatches(ctx context.Context) ([]models.LedgerDiscrepancy, error) {
+	return r.findTotalMismatches(ctx, models.LedgerDiscrepancyOnlinePayments, `
+		WITH source AS (
+			SELECT customer_phone, MAX(customer_name) AS customer_name, SUM(amount) AS total
+			FROM online_transactions
+			WHERE status IN ('success', 'refunded')
+			GROUP BY customer_phone
+		), ledger AS (
+			SELECT customer_phone, MAX(customer_name) AS customer_name, SUM(credit) AS total
+			FROM ledger_entries
+			WHERE entry_type = 'ONLINE_PAYMENT'
+			GROUP BY customer_phone
+		)`)
+}
+
+// findTotalMismatches compares the per-customer totals of the source and ledger CTEs
+func (r *LedgerIntegrityRepository) findTotalMismatches(ctx context.Context, kind, totals string) ([]models.LedgerDiscrepancy, error) {
+	rows, err := r.DB.Query(ctx, totals+`
+		SELECT COALESCE(s.customer_phone, l.customer_phone),
+		       COALESCE(s.customer_name, l.customer_name, ''),
+		       COALESCE(s.total, 0), COALESCE(l.total, 0)
+		FROM source s
+		FULL OUTER JOIN ledger l ON l.customer_phone = s.customer_phone
+		WHERE ABS(COALESCE(s.total, 0) - COALESCE(l.total, 0)) >= 0.01
+		ORDER BY 2, 1
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cross-check %s: %w", kind, err)
+	}
+	defer rows.Close()
+
+	var found []models.LedgerDiscrepancy
+	for rows.Next() {
+		d := models.LedgerDiscrepancy{Type: kind}
+		if err := rows.Scan(&d.CustomerPhone, &d.CustomerName, &d.Expected, &d.Actual); err != nil {
+			return nil, fmt.Errorf("failed to scan %s mismatch: %w", kind, err)
+		}
+		found = append(found, d)
+	}
+	return found, nil
+}
+
+// RepairRunningBalances rewrites the running balances of the given customers in one
+// transaction. The table is locked against new entries while balances are rewritten.
+func (r *LedgerIntegrityRepository) RepairRunningBalances(ctx context.Context, phones []string) (int, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `LOCK TABLE ledger_entries IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return 0, fmt.Errorf("failed to lock ledger: %w", err)
+	}
+
+	tag, err := tx.Exec(ctx, recomputedBalances+`
+		UPDATE ledger_entries le
+		SET running_balance = rc.expected
+		FROM recomputed rc
+		WHERE le.id = rc.id
+		  AND rc.customer_phone = ANY($1)
+		  AND ABS(le.running_balance - rc.expected) >= 0.01
+	`, phones)
+	if err != nil {
+		return 0, fmt.Errorf("failed to repair running balances: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit repair: %w", err)
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+// CreateRun stores the result of an integrity check
+func (r *LedgerIntegrityRepository) CreateRun(ctx context.Context, run *models.LedgerIntegrityRun) error {
+	discrepancies, err := json.Marshal(run.Discrepancies)
+	if err != nil {
+		return fmt.Errorf("failed to encode discrepancies: %w", err)
+	}
+
+	err = r.DB.QueryRow(ctx, `
+		INSERT INTO ledger_integrity_runs (
+			trigger, customers_checked, entries_checked, discrepancy_count,
+			repaired_entries, discrepancies, run_by_user_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING id, created_at
+	`, run.Trigger, run.CustomersChecked, run.EntriesChecked, run.DiscrepancyCount,
+		run.RepairedEntries, discrepancies, run.RunByUserID).Scan(&run.ID, &run.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create ledger integrity run: %w", err)
+	}
+	return nil
+}
+
+const ledgerIntegrityRunColumns = `lr.id, lr.trigger, lr.customers_checked, lr.entries_checked,
+	lr.discrepancy_count, lr.repaired_entries, lr.discrepancies, lr.run_by_user_id,
+	COALESCE(u.name, ''), lr.created_at`
+
+func scanLedgerIntegrityRun(row pgx.Row) (*models.LedgerIntegrityRun, error) {
+	run := &models.LedgerIntegrityRun{}
+	var discrepancies []byte
+	err := row.Scan(&run.ID, &run.Trigger, &run.CustomersChecked, &run.EntriesChecked,
+		&run.DiscrepancyCount, &run.RepairedEntries, &discrepancies, &run.RunByUserID,
+		&run.RunByName, &run.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(discrepancies, &run.Discrepancies); err != nil {
+		return nil, fmt.Errorf("failed to decode discrepancies: %w", err)
+	}
+	return run, nil
+}
+
+// GetRun returns a stored integrity check with its full report
+func (r *LedgerIntegrityRepository) GetRun(ctx context.Context, id int) (*models.LedgerIntegrityRun, error) {
+	run, err := scanLedgerIntegrityRun(r.DB.QueryRow(ctx, `
+		SELECT `+ledgerIntegrityRunColumns+`
+		FROM ledger_integrity_runs lr
+		LEFT JOIN users u ON u.id = lr.run_by_user_id
+		WHERE lr.id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger integrity run: %w", err)
+	}
+	return run, nil
+}
+
+// GetLatestRun returns the most recent run with the given trigger (nil if none)
+func (r *LedgerIntegrityRepository) GetLatestRun(ctx context.Context, trigger string) (*models.LedgerIntegrityRun, error) {
+	run, err := scanLedgerIntegrityRun(r.DB.QueryRow(ctx, `
+		SELECT `+ledgerIntegrityRunColumns+`
+		FROM ledger_integrity_runs lr
+		LEFT JOIN users u ON u.id = lr.run_by_user_id
+		WHERE lr.trigger = $1
+		ORDER BY lr.created_at DESC, lr.id DESC
+		LIMIT 1`, trigger))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest ledger integrity run: %w", err)
+	}
+	return run, nil
+}
+
+// ListRuns returns integrity checks, newest first
+func (r *LedgerIntegrityRepository) ListRuns(ctx context.Context, limit int) ([]*models.LedgerIntegrityRun, error) {
+	if limit <= 0 {
+		limit = 30
+	}
+	rows, err := r.DB.Query(ctx, `
+		SELECT `+ledgerIntegrityRunColumns+`
+		FROM ledger_integrity_runs lr
+		LEFT JOIN users u ON u.id = lr.run_by_user_id
+		ORDER BY lr.created_at DESC, lr.id DESC
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger integrity runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []*models.LedgerIntegrityRun
+	for rows.Next() {
+		run, err := scanLedgerIntegrityRun(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan ledger integrity run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
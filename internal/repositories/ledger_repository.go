@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
@@ -9,9 +10,14 @@ import (
 	"cold-backend/internal/models"
 
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// ErrDuplicateLedgerReference is returned when an entry's reference has already been
+// posted (a pickup's rent charge - see idx_ledger_entries_pickup_charge)
+var ErrDuplicateLedgerReference = errors.New("ledger entry already posted for this reference")
+
 type LedgerRepository struct {
 	DB *pgxpool.Pool
 }
@@ -20,23 +26,47 @@ func NewLedgerRepository(db *pgxpool.Pool) *LedgerRepository {
 	return &LedgerRepository{DB: db}
 }
 
+// notVoidedPair excludes voided entries and the reversals that cancel them
+const notVoidedPair = `voided_at IS NULL AND reversal_of_id IS NULL`
+
 // Create creates a new ledger entry and calculates running balance
 func (r *LedgerRepository) Create(ctx context.Context, entry *models.CreateLedgerEntryRequest) (*models.LedgerEntry, error) {
-	// Get current balance for customer
-	currentBalance, err := r.GetBalance(ctx, entry.CustomerPhone)
+	tx, err := r.DB.Begin(ctx)
 	if err != nil {
-		currentBalance = 0 // First entry for this customer
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	created, err := insertLedgerEntry(ctx, tx, entry)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit ledger entry: %w", err)
 	}
+	return created, nil
+}
 
-	// Calculate new running balance
-	runningBalance := currentBalance + entry.Debit - entry.Credit
+// insertLedgerEntry writes a ledger entry inside tx, so repositories can post it in the
+// same transaction as the records it belongs to.
+//
+// Running balances follow (created_at, id) order, as the integrity check recomputes
+// them. A back-dated entry (EntryDate) lands before entries already posted after that
+// date, so its balance is the one at its own position and the later entries' balances
+// are moved by its amount in the same transaction.
+func insertLedgerEntry(ctx context.Context, tx pgx.Tx, entry *models.CreateLedgerEntryRequest) (*models.LedgerEntry, error) {
+	// One posting per customer at a time, so balances are worked out against settled rows
+	_, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('ledger_entries:' || $1))`, entry.CustomerPhone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock customer ledger: %w", err)
+	}
 
 	// Get user name (ID 0 = System for automated entries like online payments)
 	var createdByName string
 	if entry.CreatedByUserID == 0 {
 		createdByName = "System"
 	} else {
-		err = r.DB.QueryRow(ctx, "SELECT name FROM users WHERE id = $1", entry.CreatedByUserID).Scan(&createdByName)
+		err = tx.QueryRow(ctx, "SELECT name FROM users WHERE id = $1", entry.CreatedByUserID).Scan(&createdByName)
 		if err != nil {
 			createdByName = "Unknown"
 		}
@@ -47,14 +77,14 @@ func (r *LedgerRepository) Create(ctx context.Context, entry *models.CreateLedge
 			customer_phone, customer_name, customer_so, entry_type, description,
 			debit, credit, running_balance, reference_id, reference_type,
 			family_member_id, family_member_name,
-			created_by_user_id, created_by_name, notes
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+			created_by_user_id, created_by_name, notes, created_at, reversal_of_id, payment_mode, thock_number, cash_session_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, COALESCE($16::timestamp, CURRENT_TIMESTAMP), $17, NULLIF($18, ''), NULLIF($19, ''), $20)
 		RETURNING id, created_at
 	`
 
 	var id int
 	var createdAt time.Time
-	err = r.DB.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
 		entry.CustomerPhone,
 		entry.CustomerName,
 		entry.CustomerSO,
@@ -62,7 +92,7 @@ func (r *LedgerRepository) Create(ctx context.Context, entry *models.CreateLedge
 		entry.Description,
 		entry.Debit,
 		entry.Credit,
-		runningBalance,
+		0, // running_balance, set below once the entry's position is known
 		entry.ReferenceID,
 		entry.ReferenceType,
 		entry.FamilyMemberID,
@@ -70,12 +100,44 @@ func (r *LedgerRepository) Create(ctx context.Context, entry *models.CreateLedge
 		entry.CreatedByUserID,
 		createdByName,
 		entry.Notes,
+		entry.EntryDate,
+		entry.ReversalOfID,
+		entry.PaymentMode,
+		entry.ThockNumber,
+		entry.CashSessionID,
 	).Scan(&id, &createdAt)
 
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" && pgErr.ConstraintName == "idx_ledger_entries_pickup_charge" {
+		return nil, ErrDuplicateLedgerReference
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create ledger entry: %w", err)
 	}
 
+	// Balance of everything before this entry, then shift everything after it
+	var previousBalance float64
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(debit) - SUM(credit), 0) FROM ledger_entries
+		WHERE customer_phone = $1 AND (created_at, id) < ($2::timestamp, $3::int)
+	`, entry.CustomerPhone, createdAt, id).Scan(&previousBalance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get customer balance: %w", err)
+	}
+	runningBalance := previousBalance + entry.Debit - entry.Credit
+
+	_, err = tx.Exec(ctx, `UPDATE ledger_entries SET running_balance = $2 WHERE id = $1`, id, runningBalance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set running balance: %w", err)
+	}
+	_, err = tx.Exec(ctx, `
+		UPDATE ledger_entries SET running_balance = running_balance + $4
+		WHERE customer_phone = $1 AND (created_at, id) > ($2::timestamp, $3::int)
+	`, entry.CustomerPhone, createdAt, id, entry.Debit-entry.Credit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update later running balances: %w", err)
+	}
+
 	return &models.LedgerEntry{
 		ID:               id,
 		CustomerPhone:    entry.CustomerPhone,
@@ -94,9 +156,133 @@ func (r *LedgerRepository) Create(ctx context.Context, entry *models.CreateLedge
 		CreatedByName:    createdByName,
 		CreatedAt:        createdAt,
 		Notes:            entry.Notes,
+		ReversalOfID:     entry.ReversalOfID,
+		PaymentMode:      entry.PaymentMode,
+		ThockNumber:      entry.ThockNumber,
 	}, nil
 }
 
+// GetByID returns a ledger entry with its void/reversal links
+func (r *LedgerRepository) GetByID(ctx context.Context, id int) (*models.LedgerEntry, error) {
+	query := `
+		SELECT id, customer_phone, customer_name, COALESCE(customer_so, '') as customer_so,
+			entry_type, COALESCE(description, '') as description, debit, credit, running_balance,
+			reference_id, COALESCE(reference_type, '') as reference_type,
+			family_member_id, COALESCE(family_member_name, '') as family_member_name,
+			created_by_user_id, COALESCE(created_by_name, '') as created_by_name,
+			created_at, COALESCE(notes, '') as notes,
+			reversal_of_id, reversed_by_id, voided_at, COALESCE(void_reason, '') as void_reason,
+			COALESCE(payment_mode, '') as payment_mode
+		FROM ledger_entries
+		WHERE id = $1
+	`
+
+	var e models.LedgerEntry
+	err := r.DB.QueryRow(ctx, query, id).Scan(
+		&e.ID, &e.CustomerPhone, &e.CustomerName, &e.CustomerSO,
+		&e.EntryType, &e.Description, &e.Debit, &e.Credit, &e.RunningBalance,
+		&e.ReferenceID, &e.ReferenceType,
+		&e.FamilyMemberID, &e.FamilyMemberName,
+		&e.CreatedByUserID, &e.CreatedByName,
+		&e.CreatedAt, &e.Notes,
+		&e.ReversalOfID, &e.ReversedByID, &e.VoidedAt, &e.VoidReason,
+		&e.PaymentMode,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger entry: %w", err)
+	}
+	return &e, nil
+}
+
+// Void marks the entry reversal.ReversalOfID voided and posts the REVERSAL that cancels
+// it in one transaction, linking the two. Fails if the entry is already voided.
+func (r *LedgerRepository) Void(ctx context.Context, reversal *models.CreateLedgerEntryRequest, userID int, reason string) (*models.LedgerEntry, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	created, err := voidLedgerEntry(ctx, tx, reversal, userID, reason)
+	if err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit void: %w", err)
+	}
+	return created, nil
+}
+
+// voidLedgerEntry marks *reversal.ReversalOfID voided within tx, posts the reversal and
+// links the two
+func voidLedgerEntry(ctx context.Context, tx pgx.Tx, reversal *models.CreateLedgerEntryRequest, userID int, reason string) (*models.LedgerEntry, error) {
+	id := *reversal.ReversalOfID
+	result, err := tx.Exec(ctx, `
+		UPDATE ledger_entries
+		SET voided_at = NOW(), voided_by_user_id = $2, void_reason = $3
+		WHERE id = $1 AND voided_at IS NULL
+	`, id, userID, reason)
+	if err != nil {
+		return nil, fmt.Errorf("failed to mark ledger entry voided: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return nil, fmt.Errorf("ledger entry %d is already voided", id)
+	}
+
+	created, err := insertLedgerEntry(ctx, tx, reversal)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE ledger_entries SET reversed_by_id = $2 WHERE id = $1`, id, created.ID); err != nil {
+		return nil, fmt.Errorf("failed to link reversal: %w", err)
+	}
+	return created, nil
+}
+
+// GetByReference returns the first ledger entry of a type linked to a reference (nil if none)
+func (r *LedgerRepository) GetByReference(ctx context.Context, entryType models.LedgerEntryType, referenceType string, referenceID int) (*models.LedgerEntry, error) {
+	return r.getByReference(ctx, entryType, referenceType, referenceID, false)
+}
+
+// GetActiveByReference is GetByReference leaving out voided entries, so a reference
+// whose entry was voided can be posted again
+func (r *LedgerRepository) GetActiveByReference(ctx context.Context, entryType models.LedgerEntryType, referenceType string, referenceID int) (*models.LedgerEntry, error) {
+	return r.getByReference(ctx, entryType, referenceType, referenceID, true)
+}
+
+func (r *LedgerRepository) getByReference(ctx context.Context, entryType models.LedgerEntryType, referenceType string, referenceID int, activeOnly bool) (*models.LedgerEntry, error) {
+	query := `
+		SELECT id, customer_phone, customer_name, COALESCE(customer_so, '') as customer_so,
+			entry_type, COALESCE(description, '') as description, debit, credit, running_balance,
+			reference_id, COALESCE(reference_type, '') as reference_type,
+			family_member_id, COALESCE(family_member_name, '') as family_member_name,
+			created_by_user_id, COALESCE(created_by_name, '') as created_by_name,
+			created_at, COALESCE(notes, '') as notes
+		FROM ledger_entries
+		WHERE entry_type = $1 AND reference_type = $2 AND reference_id = $3
+		  AND ($4 = FALSE OR ` + notVoidedPair + `)
+		ORDER BY id
+		LIMIT 1
+	`
+
+	var e models.LedgerEntry
+	err := r.DB.QueryRow(ctx, query, entryType, referenceType, referenceID, activeOnly).Scan(
+		&e.ID, &e.CustomerPhone, &e.CustomerName, &e.CustomerSO,
+		&e.EntryType, &e.Description, &e.Debit, &e.Credit, &e.RunningBalance,
+		&e.ReferenceID, &e.ReferenceType,
+		&e.FamilyMemberID, &e.FamilyMemberName,
+		&e.CreatedByUserID, &e.CreatedByName,
+		&e.CreatedAt, &e.Notes,
+	)
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger entry by reference: %w", err)
+	}
+	return &e, nil
+}
+
 // GetBalance returns the current balance for a customer
 func (r *LedgerRepository) GetBalance(ctx context.Context, customerPhone string) (float64, error) {
 	query := `
@@ -124,7 +310,9 @@ func (r *LedgerRepository) GetByCustomer(ctx context.Context, customerPhone stri
 			entry_type, COALESCE(description, '') as description, debit, credit, running_balance,
 			reference_id, COALESCE(reference_type, '') as reference_type,
 			created_by_user_id, COALESCE(created_by_name, '') as created_by_name,
-			created_at, COALESCE(notes, '') as notes
+			created_at, COALESCE(notes, '') as notes,
+			reversal_of_id, reversed_by_id, voided_at, COALESCE(void_reason, '') as void_reason,
+			COALESCE(payment_mode, '') as payment_mode
 		FROM ledger_entries
 		WHERE customer_phone = $1
 		ORDER BY created_at DESC, id DESC
@@ -146,6 +334,8 @@ func (r *LedgerRepository) GetByCustomer(ctx context.Context, customerPhone stri
 			&e.EntryType, &e.Description, &e.Debit, &e.Credit, &e.RunningBalance,
 			&refID, &e.ReferenceType,
 			&e.CreatedByUserID, &e.CreatedByName, &e.CreatedAt, &e.Notes,
+			&e.ReversalOfID, &e.ReversedByID, &e.VoidedAt, &e.VoidReason,
+			&e.PaymentMode,
 		)
 		if err != nil {
 			return nil, err
@@ -202,7 +392,9 @@ func (r *LedgerRepository) GetAll(ctx context.Context, filter *models.LedgerFilt
 			entry_type, COALESCE(description, '') as description, debit, credit, running_balance,
 			reference_id, COALESCE(reference_type, '') as reference_type,
 			created_by_user_id, COALESCE(created_by_name, '') as created_by_name,
-			created_at, COALESCE(notes, '') as notes
+			created_at, COALESCE(notes, '') as notes,
+			reversal_of_id, reversed_by_id, voided_at, COALESCE(void_reason, '') as void_reason,
+			COALESCE(payment_mode, '') as payment_mode
 		FROM ledger_entries
 		%s
 		ORDER BY created_at DESC, id DESC
@@ -226,6 +418,8 @@ func (r *LedgerRepository) GetAll(ctx context.Context, filter *models.LedgerFilt
 			&e.EntryType, &e.Description, &e.Debit, &e.Credit, &e.RunningBalance,
 			&refID, &e.ReferenceType,
 			&e.CreatedByUserID, &e.CreatedByName, &e.CreatedAt, &e.Notes,
+			&e.ReversalOfID, &e.ReversedByID, &e.VoidedAt, &e.VoidReason,
+			&e.PaymentMode,
 		)
 		if err != nil {
 			return nil, err
@@ -237,8 +431,9 @@ func (r *LedgerRepository) GetAll(ctx context.Context, filter *models.LedgerFilt
 	return entries, nil
 }
 
-// GetSummaryByCustomer returns balance summary for a customer
-func (r *LedgerRepository) GetSummaryByCustomer(ctx context.Context, customerPhone string) (*models.LedgerSummary, error) {
+// GetSummaryByCustomer returns balance summary for a customer.
+// Voided entries and their reversals are left out unless includeVoided is set.
+func (r *LedgerRepository) GetSummaryByCustomer(ctx context.Context, customerPhone string, includeVoided bool) (*models.LedgerSummary, error) {
 	query := `
 		SELECT
 			customer_phone,
@@ -249,12 +444,12 @@ func (r *LedgerRepository) GetSummaryByCustomer(ctx context.Context, customerPho
 			COALESCE(SUM(debit) - SUM(credit), 0) as current_balance,
 			COUNT(*) as entry_count
 		FROM ledger_entries
-		WHERE customer_phone = $1
+		WHERE customer_phone = $1 AND ($2 = TRUE OR ` + notVoidedPair + `)
 		GROUP BY customer_phone
 	`
 
 	var s models.LedgerSummary
-	err := r.DB.QueryRow(ctx, query, customerPhone).Scan(
+	err := r.DB.QueryRow(ctx, query, customerPhone, includeVoided).Scan(
 		&s.CustomerPhone, &s.CustomerName, &s.CustomerSO,
 		&s.TotalDebit, &s.TotalCredit, &s.CurrentBalance, &s.EntryCount,
 	)
@@ -268,7 +463,7 @@ func (r *LedgerRepository) GetSummaryByCustomer(ctx context.Context, customerPho
 }
 
 // GetAllCustomerBalances returns balance summaries for all customers
-func (r *LedgerRepository) GetAllCustomerBalances(ctx context.Context) ([]models.LedgerSummary, error) {
+func (r *LedgerRepository) GetAllCustomerBalances(ctx context.Context, includeVoided bool) ([]models.LedgerSummary, error) {
 	query := `
 		SELECT
 			customer_phone,
@@ -279,11 +474,12 @@ func (r *LedgerRepository) GetAllCustomerBalances(ctx context.Context) ([]models
 			COALESCE(SUM(debit) - SUM(credit), 0) as current_balance,
 			COUNT(*) as entry_count
 		FROM ledger_entries
+		WHERE $1 = TRUE OR ` + notVoidedPair + `
 		GROUP BY customer_phone
 		ORDER BY current_balance DESC
 	`
 
-	rows, err := r.DB.Query(ctx, query)
+	rows, err := r.DB.Query(ctx, query, includeVoided)
 	if err != nil {
 		return nil, err
 	}
@@ -306,7 +502,7 @@ func (r *LedgerRepository) GetAllCustomerBalances(ctx context.Context) ([]models
 }
 
 // GetDebtors returns customers with positive balance (they owe money)
-func (r *LedgerRepository) GetDebtors(ctx context.Context) ([]models.LedgerSummary, error) {
+func (r *LedgerRepository) GetDebtors(ctx context.Context, includeVoided bool) ([]models.LedgerSummary, error) {
 	query := `
 		SELECT
 			customer_phone,
@@ -317,12 +513,13 @@ func (r *LedgerRepository) GetDebtors(ctx context.Context) ([]models.LedgerSumma
 			COALESCE(SUM(debit) - SUM(credit), 0) as current_balance,
 			COUNT(*) as entry_count
 		FROM ledger_entries
+		WHERE $1 = TRUE OR ` + notVoidedPair + `
 		GROUP BY customer_phone
 		HAVING SUM(debit) - SUM(credit) > 0
 		ORDER BY current_balance DESC
 	`
 
-	rows, err := r.DB.Query(ctx, query)
+	rows, err := r.DB.Query(ctx, query, includeVoided)
 	if err != nil {
 		return nil, err
 	}
@@ -359,10 +556,11 @@ func (r *LedgerRepository) GetTotalsByType(ctx context.Context) (map[models.Ledg
 	query := `
 		SELECT entry_type,
 			CASE
-				WHEN entry_type IN ('CHARGE', 'REFUND') THEN SUM(debit)
+				WHEN entry_type IN ('CHARGE', 'REFUND', 'INTEREST', 'LOAN_DISBURSAL') THEN SUM(debit)
 				ELSE SUM(credit)
 			END as total
 		FROM ledger_entries
+		WHERE ` + notVoidedPair + `
 		GROUP BY entry_type
 	`
 
@@ -385,11 +583,12 @@ func (r *LedgerRepository) GetTotalsByType(ctx context.Context) (map[models.Ledg
 	return totals, nil
 }
 
-// GetTotalCredit returns total payments (credits) for a customer
+// GetTotalCredit returns total payments (credits) for a customer.
+// Crop loan repayments are excluded - they settle the loan, not rent - as are voided pairs.
 func (r *LedgerRepository) GetTotalCredit(ctx context.Context, customerPhone string) (float64, error) {
 	var total float64
 	err := r.DB.QueryRow(ctx,
-		"SELECT COALESCE(SUM(credit), 0) FROM ledger_entries WHERE customer_phone = $1",
+		"SELECT COALESCE(SUM(credit), 0) FROM ledger_entries WHERE customer_phone = $1 AND entry_type <> 'LOAN_REPAYMENT' AND "+notVoidedPair,
 		customerPhone).Scan(&total)
 	return total, err
 }
@@ -399,6 +598,7 @@ func (r *LedgerRepository) GetAllTotalCredits(ctx context.Context) (map[string]f
 	query := `
 		SELECT customer_phone, COALESCE(SUM(credit), 0) as total_credit
 		FROM ledger_entries
+		WHERE entry_type <> 'LOAN_REPAYMENT' AND ` + notVoidedPair + `
 		GROUP BY customer_phone
 	`
 
@@ -421,13 +621,71 @@ func (r *LedgerRepository) GetAllTotalCredits(ctx context.Context) (map[string]f
 	return result, nil
 }
 
+// GetAllChargesByThock returns the rent charged to each thock so far (bulk query)
+func (r *LedgerRepository) GetAllChargesByThock(ctx context.Context) (map[string]float64, error) {
+	query := `
+		SELECT thock_number, COALESCE(SUM(debit), 0) as total_charged
+		FROM ledger_entries
+		WHERE entry_type = 'CHARGE' AND thock_number IS NOT NULL AND ` + notVoidedPair + `
+		GROUP BY thock_number
+	`
+
+	rows, err := r.DB.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[string]float64)
+	for rows.Next() {
+		var thockNumber string
+		var total float64
+		if err := rows.Scan(&thockNumber, &total); err != nil {
+			return nil, err
+		}
+		result[thockNumber] = total
+	}
+
+	return result, rows.Err()
+}
+
+// GetAllPickupChargesByGatePass returns the rent charged on each gate pass's pickups (bulk query)
+func (r *LedgerRepository) GetAllPickupChargesByGatePass(ctx context.Context) (map[int]float64, error) {
+	query := `
+		SELECT p.gate_pass_id, COALESCE(SUM(le.debit), 0) as total_charged
+		FROM ledger_entries le
+		JOIN gate_pass_pickups p ON le.reference_id = p.id
+		WHERE le.entry_type = 'CHARGE' AND le.reference_type = 'gate_pass_pickup'
+		  AND le.voided_at IS NULL AND le.reversal_of_id IS NULL
+		GROUP BY p.gate_pass_id
+	`
+
+	rows, err := r.DB.Query(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make(map[int]float64)
+	for rows.Next() {
+		var gatePassID int
+		var total float64
+		if err := rows.Scan(&gatePassID, &total); err != nil {
+			return nil, err
+		}
+		result[gatePassID] = total
+	}
+
+	return result, rows.Err()
+}
+
 // GetAllPaymentHistory returns payment history for all customers (bulk query)
 func (r *LedgerRepository) GetAllPaymentHistory(ctx context.Context) (map[string][]PaymentHistoryItem, error) {
 	query := `
 		SELECT customer_phone, id, credit, entry_type, COALESCE(description, ''), COALESCE(notes, ''),
 		       family_member_id, COALESCE(family_member_name, ''), created_at
 		FROM ledger_entries
-		WHERE credit > 0
+		WHERE credit > 0 AND ` + notVoidedPair + `
 		ORDER BY created_at DESC
 	`
 
@@ -476,7 +734,7 @@ func (r *LedgerRepository) GetCreditsByFamilyMember(ctx context.Context, custome
 		SELECT family_member_id, COALESCE(family_member_name, '') as family_member_name,
 		       COALESCE(SUM(credit), 0) as total_credit
 		FROM ledger_entries
-		WHERE customer_phone = $1 AND credit > 0
+		WHERE customer_phone = $1 AND credit > 0 AND entry_type <> 'LOAN_REPAYMENT' AND ` + notVoidedPair + `
 		GROUP BY family_member_id, family_member_name
 		ORDER BY total_credit DESC
 	`
@@ -521,7 +779,7 @@ func (r *LedgerRepository) GetPaymentHistory(ctx context.Context, customerPhone
 		SELECT id, credit, entry_type, COALESCE(description, ''), COALESCE(notes, ''),
 		       family_member_id, COALESCE(family_member_name, ''), created_at
 		FROM ledger_entries
-		WHERE customer_phone = $1 AND credit > 0
+		WHERE customer_phone = $1 AND credit > 0 AND ` + notVoidedPair + `
 		ORDER BY created_at DESC
 		LIMIT $2
 	`
@@ -0,0 +1,259 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// OnlineRefundRepository stores refunds of online payments and keeps the refund state of
+// their transactions up to date
+type OnlineRefundRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewOnlineRefundRepository(db *pgxpool.Pool) *OnlineRefundRepository {
+	return &OnlineRefundRepository{DB: db}
+}
+
+const onlineRefundColumns = `
+	rf.id, rf.online_transaction_id, rf.amount, rf.reason, rf.status,
+	COALESCE(rf.razorpay_refund_id, ''), COALESCE(rf.failure_reason, ''), rf.ledger_entry_id,
+	rf.requested_by_user_id, rf.approved_by_user_id, rf.approved_at, rf.created_at, rf.processed_at,
+	COALESCE(ot.razorpay_payment_id, ''), ot.customer_phone, ot.customer_name`
+
+func scanOnlineRefund(row pgx.Row) (*models.OnlineRefund, error) {
+	rf := &models.OnlineRefund{}
+	err := row.Scan(&rf.ID, &rf.OnlineTransactionID, &rf.Amount, &rf.Reason, &rf.Status,
+		&rf.RazorpayRefundID, &rf.FailureReason, &rf.LedgerEntryID,
+		&rf.RequestedByUserID, &rf.ApprovedByUserID, &rf.ApprovedAt, &rf.CreatedAt, &rf.ProcessedAt,
+		&rf.RazorpayPaymentID, &rf.CustomerPhone, &rf.CustomerName)
+	return rf, err
+}
+
+// RefundableAmount returns how much of a transaction is not yet refunded or being refunded
+func (r *OnlineRefundRepository) RefundableAmount(ctx context.Context, transactionID int) (float64, error) {
+	var refundable float64
+	err := r.DB.QueryRow(ctx, `
+		SELECT ot.amount - COALESCE((
+			SELECT SUM(amount) FROM online_refunds
+			WHERE online_transaction_id = ot.id AND status IN ('pending_approval', 'processing', 'processed')
+		), 0)
+		FROM online_transactions ot
+		WHERE ot.id = $1
+	`, transactionID).Scan(&refundable)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get refundable amount: %w", err)
+	}
+	return refundable, nil
+}
+
+// Create saves a refund, rejecting it if it would refund more than the transaction's
+// unrefunded amount. It goes out straight away (processing) unless it takes the
+// transaction's refunds - processed, processing and awaiting approval - past
+// approvalThreshold, when it waits for approval.
+func (r *OnlineRefundRepository) Create(ctx context.Context, rf *models.OnlineRefund, approvalThreshold float64) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var paid, committed float64
+	err = tx.QueryRow(ctx, `SELECT amount FROM online_transactions WHERE id = $1 FOR UPDATE`, rf.OnlineTransactionID).Scan(&paid)
+	if err != nil {
+		return fmt.Errorf("failed to lock online transaction: %w", err)
+	}
+	err = tx.QueryRow(ctx, `
+		SELECT COALESCE(SUM(amount), 0) FROM online_refunds
+		WHERE online_transaction_id = $1 AND status IN ('pending_approval', 'processing', 'processed')
+	`, rf.OnlineTransactionID).Scan(&committed)
+	if err != nil {
+		return fmt.Errorf("failed to get refunded amount: %w", err)
+	}
+	if rf.Amount > paid-committed+0.005 {
+		return fmt.Errorf("refund exceeds the unrefunded amount of ₹%.2f", paid-committed)
+	}
+	rf.Status = models.OnlineRefundProcessing
+	if committed+rf.Amount > approvalThreshold+0.005 {
+		rf.Status = models.OnlineRefundPendingApproval
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO online_refunds (online_transaction_id, amount, reason, status, requested_by_user_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, rf.OnlineTransactionID, rf.Amount, rf.Reason, rf.Status, rf.RequestedByUserID).Scan(&rf.ID, &rf.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create online refund: %w", err)
+	}
+	return tx.Commit(ctx)
+}
+
+// Get returns a refund by ID (nil if not found)
+func (r *OnlineRefundRepository) Get(ctx context.Context, id int) (*models.OnlineRefund, error) {
+	rf, err := scanOnlineRefund(r.DB.QueryRow(ctx, `
+		SELECT `+onlineRefundColumns+`
+		FROM online_refunds rf
+		JOIN online_transactions ot ON ot.id = rf.online_transaction_id
+		WHERE rf.id = $1`, id))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get online refund: %w", err)
+	}
+	return rf, nil
+}
+
+// GetByRazorpayRefundID returns a refund by its Razorpay refund ID (nil if not found)
+func (r *OnlineRefundRepository) GetByRazorpayRefundID(ctx context.Context, razorpayRefundID string) (*models.OnlineRefund, error) {
+	rf, err := scanOnlineRefund(r.DB.QueryRow(ctx, `
+		SELECT `+onlineRefundColumns+`
+		FROM online_refunds rf
+		JOIN online_transactions ot ON ot.id = rf.online_transaction_id
+		WHERE rf.razorpay_refund_id = $1`, razorpayRefundID))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get online refund: %w", err)
+	}
+	return rf, nil
+}
+
+// List returns refunds, newest first, filtered by status and transaction when set
+func (r *OnlineRefundRepository) List(ctx context.Context, status string, transactionID int) ([]*models.OnlineRefund, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT `+onlineRefundColumns+`
+		FROM online_refunds rf
+		JOIN online_transactions ot ON ot.id = rf.online_transaction_id
+		WHERE ($1 = '' OR rf.status = $1) AND ($2 = 0 OR rf.online_transaction_id = $2)
+		ORDER BY rf.created_at DESC
+		LIMIT 500`, status, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list online refunds: %w", err)
+	}
+	defer rows.Close()
+
+	var refunds []*models.OnlineRefund
+	for rows.Next() {
+		rf, err := scanOnlineRefund(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan online refund: %w", err)
+		}
+		refunds = append(refunds, rf)
+	}
+	return refunds, rows.Err()
+}
+
+// Approve moves a refund awaiting approval to processing. Returns false if it was not awaiting approval.
+func (r *OnlineRefundRepository) Approve(ctx context.Context, id, userID int) (bool, error) {
+	tag, err := r.DB.Exec(ctx, `
+		UPDATE online_refunds
+		SET status = 'processing', approved_by_user_id = $2, approved_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending_approval'
+	`, id, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to approve online refund: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// Reject turns down a refund awaiting approval. Returns false if it was not awaiting approval.
+func (r *OnlineRefundRepository) Reject(ctx context.Context, id, userID int, reason string) (bool, error) {
+	tag, err := r.DB.Exec(ctx, `
+		UPDATE online_refunds
+		SET status = 'rejected', approved_by_user_id = $2, approved_at = CURRENT_TIMESTAMP, failure_reason = $3
+		WHERE id = $1 AND status = 'pending_approval'
+	`, id, userID, reason)
+	if err != nil {
+		return false, fmt.Errorf("failed to reject online refund: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// SetRazorpayRefundID records the refund ID Razorpay assigned
+func (r *OnlineRefundRepository) SetRazorpayRefundID(ctx context.Context, id int, razorpayRefundID string) error {
+	_, err := r.DB.Exec(ctx, `UPDATE online_refunds SET razorpay_refund_id = $2 WHERE id = $1`, id, razorpayRefundID)
+	if err != nil {
+		return fmt.Errorf("failed to save razorpay refund id: %w", err)
+	}
+	return nil
+}
+
+// Complete marks a processing refund processed and posts its REFUND ledger entry in one
+// transaction, linking the two. Returns false and writes nothing if it was not processing.
+func (r *OnlineRefundRepository) Complete(ctx context.Context, id int, entry *models.CreateLedgerEntryRequest) (*models.LedgerEntry, bool, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE online_refunds SET status = 'processed', processed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'processing'
+	`, id)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to mark online refund processed: %w", err)
+	}
+	if tag.RowsAffected() == 0 {
+		return nil, false, nil
+	}
+
+	created, err := insertLedgerEntry(ctx, tx, entry)
+	if err != nil {
+		return nil, false, err
+	}
+	if _, err := tx.Exec(ctx, `UPDATE online_refunds SET ledger_entry_id = $2 WHERE id = $1`, id, created.ID); err != nil {
+		return nil, false, fmt.Errorf("failed to link online refund to ledger: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to commit online refund: %w", err)
+	}
+	return created, true, nil
+}
+
+// MarkFailed marks a processing refund as failed. Returns false if it was not processing.
+func (r *OnlineRefundRepository) MarkFailed(ctx context.Context, id int, reason string) (bool, error) {
+	tag, err := r.DB.Exec(ctx, `
+		UPDATE online_refunds SET status = 'failed', failure_reason = $2, processed_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'processing'
+	`, id, reason)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark online refund failed: %w", err)
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// UpdateTransactionRefundState recomputes a transaction's refunded amount and refund
+// status; a fully refunded transaction becomes 'refunded'
+func (r *OnlineRefundRepository) UpdateTransactionRefundState(ctx context.Context, transactionID int) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE online_transactions t
+		SET refunded_amount = rf.processed,
+		    refund_status = CASE
+		        WHEN rf.open > 0 THEN 'pending'
+		        WHEN rf.processed >= t.amount - 0.005 THEN 'full'
+		        WHEN rf.processed > 0 THEN 'partial'
+		    END,
+		    status = CASE WHEN rf.processed >= t.amount - 0.005 THEN 'refunded' ELSE t.status END
+		FROM (
+			SELECT COALESCE(SUM(amount) FILTER (WHERE status = 'processed'), 0) AS processed,
+			       COUNT(*) FILTER (WHERE status IN ('pending_approval', 'processing')) AS open
+			FROM online_refunds
+			WHERE online_transaction_id = $1
+		) rf
+		WHERE t.id = $1
+	`, transactionID)
+	if err != nil {
+		return fmt.Errorf("failed to update transaction refund state: %w", err)
+	}
+	return nil
+}
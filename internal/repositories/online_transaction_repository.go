@@ -24,9 +24,9 @@ func (r *OnlineTransactionRepository) Create(ctx context.Context, tx *models.Onl
 		INSERT INTO online_transactions (
 			razorpay_order_id, customer_id, customer_phone, customer_name,
 			entry_id, family_member_id, thock_number, family_member_name, payment_scope,
-			amount, fee_amount, total_amount, status
+			amount, fee_amount, total_amount, status, gateway, payment_link_id
 		)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
 		RETURNING id, created_at
 	`
 
@@ -44,6 +44,8 @@ func (r *OnlineTransactionRepository) Create(ctx context.Context, tx *models.Onl
 		tx.FeeAmount,
 		tx.TotalAmount,
 		models.OnlineTxStatusPending,
+		tx.Gateway,
+		tx.PaymentLinkID,
 	).Scan(&tx.ID, &tx.CreatedAt)
 
 	if err != nil {
@@ -54,10 +56,10 @@ func (r *OnlineTransactionRepository) Create(ctx context.Context, tx *models.Onl
 	return nil
 }
 
-// GetByOrderID retrieves a transaction by Razorpay order ID
+// GetByOrderID retrieves a transaction by gateway order ID
 func (r *OnlineTransactionRepository) GetByOrderID(ctx context.Context, orderID string) (*models.OnlineTransaction, error) {
 	query := `
-		SELECT id, razorpay_order_id, COALESCE(razorpay_payment_id, ''), COALESCE(razorpay_signature, ''),
+		SELECT id, gateway, razorpay_order_id, COALESCE(razorpay_payment_id, ''), COALESCE(razorpay_signature, ''),
 		       customer_id, customer_phone, customer_name,
 		       entry_id, family_member_id, COALESCE(thock_number, ''), COALESCE(family_member_name, ''), payment_scope,
 		       amount, fee_amount, total_amount,
@@ -65,6 +67,7 @@ func (r *OnlineTransactionRepository) GetByOrderID(ctx context.Context, orderID
 		       COALESCE(card_last4, ''), COALESCE(card_network, ''),
 		       status, COALESCE(failure_reason, ''),
 		       rent_payment_id, ledger_entry_id,
+		       refunded_amount, COALESCE(refund_status, ''), payment_link_id,
 		       created_at, completed_at
 		FROM online_transactions
 		WHERE razorpay_order_id = $1
@@ -72,7 +75,7 @@ func (r *OnlineTransactionRepository) GetByOrderID(ctx context.Context, orderID
 
 	tx := &models.OnlineTransaction{}
 	err := r.DB.QueryRow(ctx, query, orderID).Scan(
-		&tx.ID, &tx.RazorpayOrderID, &tx.RazorpayPaymentID, &tx.RazorpaySignature,
+		&tx.ID, &tx.Gateway, &tx.RazorpayOrderID, &tx.RazorpayPaymentID, &tx.RazorpaySignature,
 		&tx.CustomerID, &tx.CustomerPhone, &tx.CustomerName,
 		&tx.EntryID, &tx.FamilyMemberID, &tx.ThockNumber, &tx.FamilyMemberName, &tx.PaymentScope,
 		&tx.Amount, &tx.FeeAmount, &tx.TotalAmount,
@@ -80,6 +83,7 @@ func (r *OnlineTransactionRepository) GetByOrderID(ctx context.Context, orderID
 		&tx.CardLast4, &tx.CardNetwork,
 		&tx.Status, &tx.FailureReason,
 		&tx.RentPaymentID, &tx.LedgerEntryID,
+		&tx.RefundedAmount, &tx.RefundStatus, &tx.PaymentLinkID,
 		&tx.CreatedAt, &tx.CompletedAt,
 	)
 
@@ -93,7 +97,7 @@ func (r *OnlineTransactionRepository) GetByOrderID(ctx context.Context, orderID
 // GetByPaymentID retrieves a transaction by Razorpay payment ID
 func (r *OnlineTransactionRepository) GetByPaymentID(ctx context.Context, paymentID string) (*models.OnlineTransaction, error) {
 	query := `
-		SELECT id, razorpay_order_id, COALESCE(razorpay_payment_id, ''), COALESCE(razorpay_signature, ''),
+		SELECT id, gateway, razorpay_order_id, COALESCE(razorpay_payment_id, ''), COALESCE(razorpay_signature, ''),
 		       customer_id, customer_phone, customer_name,
 		       entry_id, family_member_id, COALESCE(thock_number, ''), COALESCE(family_member_name, ''), payment_scope,
 		       amount, fee_amount, total_amount,
@@ -101,6 +105,7 @@ func (r *OnlineTransactionRepository) GetByPaymentID(ctx context.Context, paymen
 		       COALESCE(card_last4, ''), COALESCE(card_network, ''),
 		       status, COALESCE(failure_reason, ''),
 		       rent_payment_id, ledger_entry_id,
+		       refunded_amount, COALESCE(refund_status, ''), payment_link_id,
 		       created_at, completed_at
 		FROM online_transactions
 		WHERE razorpay_payment_id = $1
@@ -108,7 +113,7 @@ func (r *OnlineTransactionRepository) GetByPaymentID(ctx context.Context, paymen
 
 	tx := &models.OnlineTransaction{}
 	err := r.DB.QueryRow(ctx, query, paymentID).Scan(
-		&tx.ID, &tx.RazorpayOrderID, &tx.RazorpayPaymentID, &tx.RazorpaySignature,
+		&tx.ID, &tx.Gateway, &tx.RazorpayOrderID, &tx.RazorpayPaymentID, &tx.RazorpaySignature,
 		&tx.CustomerID, &tx.CustomerPhone, &tx.CustomerName,
 		&tx.EntryID, &tx.FamilyMemberID, &tx.ThockNumber, &tx.FamilyMemberName, &tx.PaymentScope,
 		&tx.Amount, &tx.FeeAmount, &tx.TotalAmount,
@@ -116,6 +121,45 @@ func (r *OnlineTransactionRepository) GetByPaymentID(ctx context.Context, paymen
 		&tx.CardLast4, &tx.CardNetwork,
 		&tx.Status, &tx.FailureReason,
 		&tx.RentPaymentID, &tx.LedgerEntryID,
+		&tx.RefundedAmount, &tx.RefundStatus, &tx.PaymentLinkID,
+		&tx.CreatedAt, &tx.CompletedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return tx, nil
+}
+
+// GetByID retrieves a transaction by ID
+func (r *OnlineTransactionRepository) GetByID(ctx context.Context, id int) (*models.OnlineTransaction, error) {
+	query := `
+		SELECT id, gateway, razorpay_order_id, COALESCE(razorpay_payment_id, ''), COALESCE(razorpay_signature, ''),
+		       customer_id, customer_phone, customer_name,
+		       entry_id, family_member_id, COALESCE(thock_number, ''), COALESCE(family_member_name, ''), payment_scope,
+		       amount, fee_amount, total_amount,
+		       COALESCE(utr_number, ''), COALESCE(payment_method, ''), COALESCE(bank, ''), COALESCE(vpa, ''),
+		       COALESCE(card_last4, ''), COALESCE(card_network, ''),
+		       status, COALESCE(failure_reason, ''),
+		       rent_payment_id, ledger_entry_id,
+		       refunded_amount, COALESCE(refund_status, ''), payment_link_id,
+		       created_at, completed_at
+		FROM online_transactions
+		WHERE id = $1
+	`
+
+	tx := &models.OnlineTransaction{}
+	err := r.DB.QueryRow(ctx, query, id).Scan(
+		&tx.ID, &tx.Gateway, &tx.RazorpayOrderID, &tx.RazorpayPaymentID, &tx.RazorpaySignature,
+		&tx.CustomerID, &tx.CustomerPhone, &tx.CustomerName,
+		&tx.EntryID, &tx.FamilyMemberID, &tx.ThockNumber, &tx.FamilyMemberName, &tx.PaymentScope,
+		&tx.Amount, &tx.FeeAmount, &tx.TotalAmount,
+		&tx.UTRNumber, &tx.PaymentMethod, &tx.Bank, &tx.VPA,
+		&tx.CardLast4, &tx.CardNetwork,
+		&tx.Status, &tx.FailureReason,
+		&tx.RentPaymentID, &tx.LedgerEntryID,
+		&tx.RefundedAmount, &tx.RefundStatus, &tx.PaymentLinkID,
 		&tx.CreatedAt, &tx.CompletedAt,
 	)
 
@@ -152,13 +196,14 @@ func (r *OnlineTransactionRepository) UpdatePaymentSuccess(ctx context.Context,
 	return err
 }
 
-// UpdatePaymentFailed marks the transaction as failed
+// UpdatePaymentFailed marks the transaction as failed. A captured or refunded transaction
+// is left alone (a late failure webhook for an earlier attempt).
 func (r *OnlineTransactionRepository) UpdatePaymentFailed(ctx context.Context, orderID, reason string) error {
 	now := time.Now()
 	query := `
 		UPDATE online_transactions
 		SET status = $2, failure_reason = $3, completed_at = $4
-		WHERE razorpay_order_id = $1
+		WHERE razorpay_order_id = $1 AND status IN ('pending', 'failed')
 	`
 
 	_, err := r.DB.Exec(ctx, query, orderID, models.OnlineTxStatusFailed, reason, now)
@@ -184,12 +229,13 @@ func (r *OnlineTransactionRepository) GetByCustomer(ctx context.Context, custome
 	}
 
 	query := `
-		SELECT id, razorpay_order_id, COALESCE(razorpay_payment_id, ''),
+		SELECT id, gateway, razorpay_order_id, COALESCE(razorpay_payment_id, ''),
 		       customer_id, customer_phone, customer_name,
 		       entry_id, family_member_id, COALESCE(thock_number, ''), COALESCE(family_member_name, ''), payment_scope,
 		       amount, fee_amount, total_amount,
 		       COALESCE(utr_number, ''), COALESCE(payment_method, ''),
 		       status, COALESCE(failure_reason, ''),
+		       refunded_amount, COALESCE(refund_status, ''), payment_link_id,
 		       created_at, completed_at
 		FROM online_transactions
 		WHERE customer_id = $1
@@ -207,12 +253,13 @@ func (r *OnlineTransactionRepository) GetByCustomer(ctx context.Context, custome
 	for rows.Next() {
 		tx := &models.OnlineTransaction{}
 		err := rows.Scan(
-			&tx.ID, &tx.RazorpayOrderID, &tx.RazorpayPaymentID,
+			&tx.ID, &tx.Gateway, &tx.RazorpayOrderID, &tx.RazorpayPaymentID,
 			&tx.CustomerID, &tx.CustomerPhone, &tx.CustomerName,
 			&tx.EntryID, &tx.FamilyMemberID, &tx.ThockNumber, &tx.FamilyMemberName, &tx.PaymentScope,
 			&tx.Amount, &tx.FeeAmount, &tx.TotalAmount,
 			&tx.UTRNumber, &tx.PaymentMethod,
 			&tx.Status, &tx.FailureReason,
+			&tx.RefundedAmount, &tx.RefundStatus, &tx.PaymentLinkID,
 			&tx.CreatedAt, &tx.CompletedAt,
 		)
 		if err != nil {
@@ -281,12 +328,13 @@ func (r *OnlineTransactionRepository) GetAll(ctx context.Context, filter *models
 
 	// Get data
 	query := fmt.Sprintf(`
-		SELECT id, razorpay_order_id, COALESCE(razorpay_payment_id, ''),
+		SELECT id, gateway, razorpay_order_id, COALESCE(razorpay_payment_id, ''),
 		       customer_id, customer_phone, customer_name,
 		       entry_id, family_member_id, COALESCE(thock_number, ''), COALESCE(family_member_name, ''), payment_scope,
 		       amount, fee_amount, total_amount,
 		       COALESCE(utr_number, ''), COALESCE(payment_method, ''), COALESCE(bank, ''), COALESCE(vpa, ''),
 		       status, COALESCE(failure_reason, ''),
+		       refunded_amount, COALESCE(refund_status, ''), payment_link_id,
 		       created_at, completed_at
 		FROM online_transactions
 		%s
@@ -306,12 +354,13 @@ func (r *OnlineTransactionRepository) GetAll(ctx context.Context, filter *models
 	for rows.Next() {
 		tx := &models.OnlineTransaction{}
 		err := rows.Scan(
-			&tx.ID, &tx.RazorpayOrderID, &tx.RazorpayPaymentID,
+			&tx.ID, &tx.Gateway, &tx.RazorpayOrderID, &tx.RazorpayPaymentID,
 			&tx.CustomerID, &tx.CustomerPhone, &tx.CustomerName,
 			&tx.EntryID, &tx.FamilyMemberID, &tx.ThockNumber, &tx.FamilyMemberName, &tx.PaymentScope,
 			&tx.Amount, &tx.FeeAmount, &tx.TotalAmount,
 			&tx.UTRNumber, &tx.PaymentMethod, &tx.Bank, &tx.VPA,
 			&tx.Status, &tx.FailureReason,
+			&tx.RefundedAmount, &tx.RefundStatus, &tx.PaymentLinkID,
 			&tx.CreatedAt, &tx.CompletedAt,
 		)
 		if err != nil {
@@ -391,16 +440,17 @@ func (r *OnlineTransactionRepository) IsPaymentProcessed(ctx context.Context, or
 	if err != nil {
 		return false, err
 	}
-	return status == string(models.OnlineTxStatusSuccess), nil
+	// A refunded payment was processed before it was refunded
+	return status == string(models.OnlineTxStatusSuccess) || status == string(models.OnlineTxStatusRefunded), nil
 }
 
 // GetUnreconciledTransactions returns successful transactions without ledger entries
 func (r *OnlineTransactionRepository) GetUnreconciledTransactions(ctx context.Context) ([]*models.OnlineTransaction, error) {
 	query := `
 		SELECT ot.id, ot.customer_id, ot.customer_phone, ot.customer_name,
-			ot.razorpay_order_id, ot.razorpay_payment_id, ot.amount, ot.fee_amount,
+			ot.gateway, ot.razorpay_order_id, ot.razorpay_payment_id, ot.amount, ot.fee_amount,
 			ot.status, ot.payment_method, ot.payment_scope, ot.thock_number,
-			ot.family_member_name, ot.utr_number, ot.created_at, ot.completed_at
+			ot.family_member_name, ot.utr_number, ot.payment_link_id, ot.created_at, ot.completed_at
 		FROM online_transactions ot
 		WHERE ot.status = 'success'
 		AND ot.rent_payment_id IS NULL
@@ -419,9 +469,9 @@ func (r *OnlineTransactionRepository) GetUnreconciledTransactions(ctx context.Co
 		var completedAt *time.Time
 		err := rows.Scan(
 			&tx.ID, &tx.CustomerID, &tx.CustomerPhone, &tx.CustomerName,
-			&tx.RazorpayOrderID, &tx.RazorpayPaymentID, &tx.Amount, &tx.FeeAmount,
+			&tx.Gateway, &tx.RazorpayOrderID, &tx.RazorpayPaymentID, &tx.Amount, &tx.FeeAmount,
 			&tx.Status, &tx.PaymentMethod, &tx.PaymentScope, &tx.ThockNumber,
-			&tx.FamilyMemberName, &tx.UTRNumber, &tx.CreatedAt, &completedAt,
+			&tx.FamilyMemberName, &tx.UTRNumber, &tx.PaymentLinkID, &tx.CreatedAt, &completedAt,
 		)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,338 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PaymentAllocationRepository applies payment ledger entries to the charges they settle
+type PaymentAllocationRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewPaymentAllocationRepository(db *pgxpool.Pool) *PaymentAllocationRepository {
+	return &PaymentAllocationRepository{DB: db}
+}
+
+// Entries that can be allocated. Crop loan postings are settled through the loan, and
+// voided entries and their reversals cancel out.
+const (
+	allocatableCharge = `le.entry_type IN ('CHARGE', 'INTEREST', 'REFUND') AND le.debit > 0
+		AND le.voided_at IS NULL AND le.reversal_of_id IS NULL AND COALESCE(le.reference_type, '') <> 'crop_loan'`
+	allocatablePayment = `le.entry_type IN ('PAYMENT', 'ONLINE_PAYMENT', 'CREDIT') AND le.credit > 0
+		AND le.voided_at IS NULL AND le.reversal_of_id IS NULL`
+	// Wallet deposits pay wallet refunds; no other payment may
+	walletItem = `COALESCE(le.reference_type, '') IN ('wallet_deposit', 'wallet_refund')`
+)
+
+// openItem is a charge or payment with an unallocated remainder
+type openItem struct {
+	id             int
+	familyMemberID *int
+	remaining      float64
+	wallet         bool // Wallet deposit or wallet refund
+}
+
+func roundAllocation(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// lockCustomer serialises allocation changes for a customer within a transaction
+func lockCustomer(ctx context.Context, tx pgx.Tx, phone string) error {
+	if _, err := tx.Exec(ctx, `SELECT pg_advisory_xact_lock(hashtext('payment_allocation:' || $1))`, phone); err != nil {
+		return fmt.Errorf("failed to lock customer allocations: %w", err)
+	}
+	return nil
+}
+
+// openCharges returns a customer's charges with an outstanding remainder, oldest first
+func openCharges(ctx context.Context, tx pgx.Tx, phone, thockNumber string) ([]*openItem, error) {
+	return queryOpenItems(ctx, tx, `
+		SELECT le.id, le.family_member_id, le.debit - COALESCE(SUM(pa.amount), 0), `+walletItem+`
+		FROM ledger_entries le
+		LEFT JOIN payment_allocations pa ON pa.charge_entry_id = le.id
+		WHERE le.customer_phone = $1 AND ($2 = '' OR le.thock_number = $2) AND `+allocatableCharge+`
+		GROUP BY le.id
+		HAVING le.debit - COALESCE(SUM(pa.amount), 0) > 0.005
+		ORDER BY le.created_at, le.id`, phone, thockNumber)
+}
+
+// openPayments returns a customer's payments with an unallocated remainder, oldest first.
+// Wallet deposits come last so they are only drawn on for what other payments do not cover.
+func openPayments(ctx context.Context, tx pgx.Tx, phone string) ([]*openItem, error) {
+	return queryOpenItems(ctx, tx, `
+		SELECT le.id, le.family_member_id, le.credit - COALESCE(SUM(pa.amount), 0), `+walletItem+`
+		FROM ledger_entries le
+		LEFT JOIN payment_allocations pa ON pa.payment_entry_id = le.id
+		WHERE le.customer_phone = $1 AND `+allocatablePayment+`
+		GROUP BY le.id
+		HAVING le.credit - COALESCE(SUM(pa.amount), 0) > 0.005
+		ORDER BY `+walletItem+`, le.created_at, le.id`, phone)
+}
+
+func queryOpenItems(ctx context.Context, tx pgx.Tx, query string, args ...interface{}) ([]*openItem, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load open ledger entries: %w", err)
+	}
+	defer rows.Close()
+
+	var items []*openItem
+	for rows.Next() {
+		item := &openItem{}
+		if err := rows.Scan(&item.id, &item.familyMemberID, &item.remaining, &item.wallet); err != nil {
+			return nil, fmt.Errorf("failed to scan open ledger entry: %w", err)
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+func insertAllocation(ctx context.Context, tx pgx.Tx, paymentID, chargeID int, amount float64, method string, userID *int) error {
+	_, err := tx.Exec(ctx, `
+		INSERT INTO payment_allocations (payment_entry_id, charge_entry_id, amount, method, allocated_by_user_id)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (payment_entry_id, charge_entry_id)
+		DO UPDATE SET amount = payment_allocations.amount + EXCLUDED.amount, method = EXCLUDED.method
+	`, paymentID, chargeID, amount, method, userID)
+	if err != nil {
+		return fmt.Errorf("failed to save allocation: %w", err)
+	}
+	return nil
+}
+
+// AllocateFIFO applies a customer's unallocated payments to their outstanding charges,
+// oldest first. A payment made for a family member settles that member's charges before
+// anyone else's. Allocations of voided entries are released first. Returns the number
+// of allocations made.
+func (r *PaymentAllocationRepository) AllocateFIFO(ctx context.Context, phone string) (int, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := lockCustomer(ctx, tx, phone); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, `
+		DELETE FROM payment_allocations pa
+		USING ledger_entries le
+		WHERE le.id IN (pa.payment_entry_id, pa.charge_entry_id)
+		  AND le.customer_phone = $1 AND le.voided_at IS NOT NULL
+	`, phone); err != nil {
+		return 0, fmt.Errorf("failed to release voided allocations: %w", err)
+	}
+
+	payments, err := openPayments(ctx, tx, phone)
+	if err != nil {
+		return 0, err
+	}
+	if len(payments) == 0 {
+		return 0, tx.Commit(ctx)
+	}
+	charges, err := openCharges(ctx, tx, phone, "")
+	if err != nil {
+		return 0, err
+	}
+
+	made := 0
+	for _, p := range payments {
+		for pass := 0; pass < 2 && p.remaining > 0.005; pass++ {
+			if pass == 0 && p.familyMemberID == nil {
+				continue
+			}
+			for _, c := range charges {
+				if p.remaining <= 0.005 {
+					break
+				}
+				if c.remaining <= 0.005 || (c.wallet && !p.wallet) {
+					continue
+				}
+				if pass == 0 && (c.familyMemberID == nil || *c.familyMemberID != *p.familyMemberID) {
+					continue
+				}
+				amount := roundAllocation(math.Min(p.remaining, c.remaining))
+				if err := insertAllocation(ctx, tx, p.id, c.id, amount, models.AllocationMethodFIFO, nil); err != nil {
+					return 0, err
+				}
+				p.remaining -= amount
+				c.remaining -= amount
+				made++
+			}
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return 0, fmt.Errorf("failed to commit allocations: %w", err)
+	}
+	return made, nil
+}
+
+// ReplacePaymentAllocations replaces a payment's allocations with the given manual ones.
+// A thock allocation settles that thock's charges oldest first. Returns the customer phone.
+func (r *PaymentAllocationRepository) ReplacePaymentAllocations(ctx context.Context, paymentEntryID int, items []models.ManualAllocation, userID int) (string, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var phone string
+	var credit float64
+	var wallet bool
+	err = tx.QueryRow(ctx, `SELECT le.customer_phone, le.credit, `+walletItem+` FROM ledger_entries le WHERE le.id = $1 AND `+allocatablePayment,
+		paymentEntryID).Scan(&phone, &credit, &wallet)
+	if err == pgx.ErrNoRows {
+		return "", fmt.Errorf("ledger entry #%d is not a payment that can be allocated", paymentEntryID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get payment entry: %w", err)
+	}
+
+	if err := lockCustomer(ctx, tx, phone); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM payment_allocations WHERE payment_entry_id = $1`, paymentEntryID); err != nil {
+		return "", fmt.Errorf("failed to clear allocations: %w", err)
+	}
+
+	remaining := credit
+	for _, item := range items {
+		if item.Amount > remaining+0.005 {
+			return "", fmt.Errorf("allocations exceed the payment of ₹%.2f", credit)
+		}
+
+		var charges []*openItem
+		if item.ChargeEntryID != nil {
+			charges, err = queryOpenItems(ctx, tx, `
+				SELECT le.id, le.family_member_id, le.debit - COALESCE(SUM(pa.amount), 0), `+walletItem+`
+				FROM ledger_entries le
+				LEFT JOIN payment_allocations pa ON pa.charge_entry_id = le.id
+				WHERE le.id = $1 AND le.customer_phone = $2 AND `+allocatableCharge+`
+				GROUP BY le.id`, *item.ChargeEntryID, phone)
+		} else {
+			charges, err = openCharges(ctx, tx, phone, item.ThockNumber)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		left := item.Amount
+		for _, c := range charges {
+			if left <= 0.005 {
+				break
+			}
+			if c.remaining <= 0.005 || (c.wallet && !wallet) {
+				continue
+			}
+			amount := roundAllocation(math.Min(left, c.remaining))
+			if err := insertAllocation(ctx, tx, paymentEntryID, c.id, amount, models.AllocationMethodManual, &userID); err != nil {
+				return "", err
+			}
+			left -= amount
+		}
+		if left > 0.005 {
+			if item.ChargeEntryID != nil {
+				return "", fmt.Errorf("charge #%d has less than ₹%.2f outstanding", *item.ChargeEntryID, item.Amount)
+			}
+			return "", fmt.Errorf("thock %s has less than ₹%.2f outstanding", item.ThockNumber, item.Amount)
+		}
+		remaining -= item.Amount
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", fmt.Errorf("failed to commit allocations: %w", err)
+	}
+	return phone, nil
+}
+
+// ListByPayment returns the charges a payment has been applied to
+func (r *PaymentAllocationRepository) ListByPayment(ctx context.Context, paymentEntryID int) ([]*models.PaymentAllocation, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT pa.id, pa.payment_entry_id, pa.charge_entry_id, pa.amount, pa.method,
+		       le.entry_type, COALESCE(le.description, ''), le.created_at,
+		       COALESCE(le.thock_number, ''), COALESCE(le.family_member_name, ''), pa.created_at
+		FROM payment_allocations pa
+		JOIN ledger_entries le ON le.id = pa.charge_entry_id
+		WHERE pa.payment_entry_id = $1
+		ORDER BY le.created_at, le.id
+	`, paymentEntryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list allocations: %w", err)
+	}
+	defer rows.Close()
+
+	var allocations []*models.PaymentAllocation
+	for rows.Next() {
+		a := &models.PaymentAllocation{}
+		if err := rows.Scan(&a.ID, &a.PaymentEntryID, &a.ChargeEntryID, &a.Amount, &a.Method,
+			&a.ChargeType, &a.ChargeDescription, &a.ChargeDate,
+			&a.ThockNumber, &a.FamilyMemberName, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan allocation: %w", err)
+		}
+		allocations = append(allocations, a)
+	}
+	return allocations, nil
+}
+
+// ListThockOutstanding returns charged, allocated and outstanding amounts per thock for a
+// customer (one thock when thockNumber is set)
+func (r *PaymentAllocationRepository) ListThockOutstanding(ctx context.Context, phone, thockNumber string) ([]models.ThockOutstanding, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT le.thock_number, COALESCE(MAX(le.family_member_name), ''),
+		       SUM(le.debit), COALESCE(SUM(a.allocated), 0)
+		FROM ledger_entries le
+		LEFT JOIN (
+			SELECT charge_entry_id, SUM(amount) AS allocated FROM payment_allocations GROUP BY charge_entry_id
+		) a ON a.charge_entry_id = le.id
+		WHERE le.customer_phone = $1 AND le.thock_number IS NOT NULL
+		  AND ($2 = '' OR le.thock_number = $2) AND `+allocatableCharge+`
+		GROUP BY le.thock_number
+		ORDER BY MIN(le.created_at)
+	`, phone, thockNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thock outstanding: %w", err)
+	}
+	defer rows.Close()
+
+	var thocks []models.ThockOutstanding
+	for rows.Next() {
+		var t models.ThockOutstanding
+		if err := rows.Scan(&t.ThockNumber, &t.FamilyMemberName, &t.Charged, &t.Paid); err != nil {
+			return nil, fmt.Errorf("failed to scan thock outstanding: %w", err)
+		}
+		t.Outstanding = roundAllocation(t.Charged - t.Paid)
+		thocks = append(thocks, t)
+	}
+	return thocks, nil
+}
+
+// GetUnallocatedTotals returns a customer's outstanding charges not tied to a thock and
+// their payments not yet applied to any charge
+func (r *PaymentAllocationRepository) GetUnallocatedTotals(ctx context.Context, phone string) (float64, float64, error) {
+	var otherOutstanding, unallocatedCredit float64
+	err := r.DB.QueryRow(ctx, `
+		SELECT
+			COALESCE((SELECT SUM(le.debit - COALESCE(a.allocated, 0))
+			          FROM ledger_entries le
+			          LEFT JOIN (SELECT charge_entry_id, SUM(amount) AS allocated FROM payment_allocations GROUP BY charge_entry_id) a
+			            ON a.charge_entry_id = le.id
+			          WHERE le.customer_phone = $1 AND le.thock_number IS NULL AND `+allocatableCharge+`), 0),
+			COALESCE((SELECT SUM(le.credit - COALESCE(a.allocated, 0))
+			          FROM ledger_entries le
+			          LEFT JOIN (SELECT payment_entry_id, SUM(amount) AS allocated FROM payment_allocations GROUP BY payment_entry_id) a
+			            ON a.payment_entry_id = le.id
+			          WHERE le.customer_phone = $1 AND `+allocatablePayment+`), 0)
+	`, phone).Scan(&otherOutstanding, &unallocatedCredit)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get unallocated totals: %w", err)
+	}
+	return roundAllocation(otherOutstanding), roundAllocation(unallocatedCredit), nil
+}
@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// PaymentLinkRepository stores payment links sent to customers for their outstanding balance
+type PaymentLinkRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewPaymentLinkRepository(db *pgxpool.Pool) *PaymentLinkRepository {
+	return &PaymentLinkRepository{DB: db}
+}
+
+// Sent and opened links past their expiry read as expired
+const paymentLinkColumns = `
+	id, token, customer_id, customer_phone, customer_name, amount,
+	CASE WHEN status IN ('sent', 'opened') AND expires_at < NOW() THEN 'expired' ELSE status END,
+	online_transaction_id, created_by_user_id, expires_at, opened_at, paid_at, created_at`
+
+func scanPaymentLink(row pgx.Row) (*models.PaymentLink, error) {
+	l := &models.PaymentLink{}
+	err := row.Scan(&l.ID, &l.Token, &l.CustomerID, &l.CustomerPhone, &l.CustomerName, &l.Amount,
+		&l.Status, &l.OnlineTransactionID, &l.CreatedByUserID, &l.ExpiresAt, &l.OpenedAt, &l.PaidAt, &l.CreatedAt)
+	return l, err
+}
+
+// Create saves a link, cancelling the customer's earlier unpaid links so only the newest
+// one can be paid
+func (r *PaymentLinkRepository) Create(ctx context.Context, l *models.PaymentLink) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		UPDATE payment_links SET status = 'cancelled'
+		WHERE customer_phone = $1 AND status IN ('sent', 'opened')
+	`, l.CustomerPhone)
+	if err != nil {
+		return fmt.Errorf("failed to cancel earlier payment links: %w", err)
+	}
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO payment_links (token, customer_id, customer_phone, customer_name, amount, status, created_by_user_id, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id, created_at
+	`, l.Token, l.CustomerID, l.CustomerPhone, l.CustomerName, l.Amount, models.PaymentLinkStatusSent,
+		l.CreatedByUserID, l.ExpiresAt).Scan(&l.ID, &l.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create payment link: %w", err)
+	}
+	l.Status = models.PaymentLinkStatusSent
+
+	return tx.Commit(ctx)
+}
+
+// GetByToken returns the link with a token, or nil if there is none
+func (r *PaymentLinkRepository) GetByToken(ctx context.Context, token string) (*models.PaymentLink, error) {
+	l, err := scanPaymentLink(r.DB.QueryRow(ctx, `SELECT `+paymentLinkColumns+` FROM payment_links WHERE token = $1`, token))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment link: %w", err)
+	}
+	return l, nil
+}
+
+// ListLatest returns each customer's most recent link
+func (r *PaymentLinkRepository) ListLatest(ctx context.Context) ([]*models.PaymentLink, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT DISTINCT ON (customer_phone) `+paymentLinkColumns+`
+		FROM payment_links
+		ORDER BY customer_phone, created_at DESC, id DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payment links: %w", err)
+	}
+	defer rows.Close()
+
+	var links []*models.PaymentLink
+	for rows.Next() {
+		l, err := scanPaymentLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payment link: %w", err)
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// MarkOpened records the first time the customer opens a link
+func (r *PaymentLinkRepository) MarkOpened(ctx context.Context, id int) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE payment_links SET status = 'opened', opened_at = NOW()
+		WHERE id = $1 AND status = 'sent'
+	`, id)
+	if err != nil {
+		return fmt.Errorf("failed to mark payment link opened: %w", err)
+	}
+	return nil
+}
+
+// MarkPaid marks the link an online transaction was created from as paid by it. A link
+// cancelled or expired after checkout started is still marked paid, since the money came
+// in through it. Returns false if the transaction has no unpaid link.
+func (r *PaymentLinkRepository) MarkPaid(ctx context.Context, transactionID int) (bool, error) {
+	result, err := r.DB.Exec(ctx, `
+		UPDATE payment_links pl SET status = 'paid', paid_at = NOW(), online_transaction_id = ot.id
+		FROM online_transactions ot
+		WHERE ot.id = $1 AND pl.id = ot.payment_link_id AND pl.status <> 'paid'
+	`, transactionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark payment link paid: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// Cancel cancels an unpaid link. Returns false if it is already paid or cancelled.
+func (r *PaymentLinkRepository) Cancel(ctx context.Context, id int) (bool, error) {
+	result, err := r.DB.Exec(ctx, `
+		UPDATE payment_links SET status = 'cancelled'
+		WHERE id = $1 AND status IN ('sent', 'opened')
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel payment link: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RateContractRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRateContractRepository(db *pgxpool.Pool) *RateContractRepository {
+	return &RateContractRepository{DB: db}
+}
+
+const rateContractColumns = `
+	c.id, c.name, c.customer_id, COALESCE(cu.name, ''), COALESCE(cu.phone, ''),
+	c.family_member_id, COALESCE(fm.name, ''),
+	COALESCE(c.thock_category, ''), COALESCE(c.variety, ''), COALESCE(c.bag_size, ''),
+	c.rate_per_item, c.extra_month_rate, c.valid_from, c.valid_to,
+	c.status, COALESCE(c.status_reason, ''), COALESCE(c.notes, ''),
+	c.created_by_user_id, COALESCE(cb.name, ''), c.approved_by_user_id, COALESCE(ab.name, ''), c.approved_at,
+	c.created_at, c.updated_at`
+
+const rateContractJoins = `
+	FROM customer_rate_contracts c
+	LEFT JOIN customers cu ON c.customer_id = cu.id
+	LEFT JOIN family_members fm ON c.family_member_id = fm.id
+	LEFT JOIN users cb ON c.created_by_user_id = cb.id
+	LEFT JOIN users ab ON c.approved_by_user_id = ab.id`
+
+func scanRateContract(row pgx.Row) (*models.RateContract, error) {
+	c := &models.RateContract{}
+	err := row.Scan(
+		&c.ID, &c.Name, &c.CustomerID, &c.CustomerName, &c.CustomerPhone,
+		&c.FamilyMemberID, &c.FamilyMemberName,
+		&c.ThockCategory, &c.Variety, &c.BagSize,
+		&c.RatePerItem, &c.ExtraMonthRate, &c.ValidFrom, &c.ValidTo,
+		&c.Status, &c.StatusReason, &c.Notes,
+		&c.CreatedByUserID, &c.CreatedByName, &c.ApprovedByUserID, &c.ApprovedByName, &c.ApprovedAt,
+		&c.CreatedAt, &c.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Create inserts a new (pending) rate contract
+func (r *RateContractRepository) Create(ctx context.Context, c *models.RateContract) error {
+	query := `
+		INSERT INTO customer_rate_contracts (name, customer_id, family_member_id, thock_category, variety, bag_size,
+		                                     rate_per_item, extra_month_rate, valid_from, valid_to, status, notes,
+		                                     created_by_user_id)
+		VALUES ($1, $2, $3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9, $10, $11, $12, $13)
+		RETURNING id, created_at, updated_at
+	`
+	err := r.DB.QueryRow(ctx, query,
+		c.Name, c.CustomerID, c.FamilyMemberID, c.ThockCategory, c.Variety, c.BagSize,
+		c.RatePerItem, c.ExtraMonthRate, c.ValidFrom, c.ValidTo, c.Status, c.Notes,
+		c.CreatedByUserID,
+	).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create rate contract: %w", err)
+	}
+	return nil
+}
+
+// Get returns a rate contract by ID
+func (r *RateContractRepository) Get(ctx context.Context, id int) (*models.RateContract, error) {
+	query := `SELECT ` + rateContractColumns + rateContractJoins + ` WHERE c.id = $1`
+	c, err := scanRateContract(r.DB.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rate contract: %w", err)
+	}
+	return c, nil
+}
+
+// List returns rate contracts, optionally filtered by customer and status
+func (r *RateContractRepository) List(ctx context.Context, customerID int, status string) ([]*models.RateContract, error) {
+	query := `SELECT ` + rateContractColumns + rateContractJoins + `
+		WHERE ($1 = 0 OR c.customer_id = $1)
+		  AND ($2 = '' OR c.status = $2)
+		ORDER BY c.created_at DESC, c.id DESC`
+
+	rows, err := r.DB.Query(ctx, query, customerID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rate contracts: %w", err)
+	}
+	defer rows.Close()
+
+	var contracts []*models.RateContract
+	for rows.Next() {
+		c, err := scanRateContract(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rate contract: %w", err)
+		}
+		contracts = append(contracts, c)
+	}
+	return contracts, nil
+}
+
+// ListActive returns all approved contracts (used to build the rent rate card)
+func (r *RateContractRepository) ListActive(ctx context.Context) ([]*models.RateContract, error) {
+	return r.List(ctx, 0, models.RateContractActive)
+}
+
+// Approve activates a pending contract and records the approver
+func (r *RateContractRepository) Approve(ctx context.Context, id int, userID int) error {
+	result, err := r.DB.Exec(ctx, `
+		UPDATE customer_rate_contracts
+		SET status = 'active', approved_by_user_id = $2, approved_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'pending'`, id, userID)
+	if err != nil {
+		return fmt.Errorf("failed to approve rate contract: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("rate contract not found or not pending")
+	}
+	return nil
+}
+
+// UpdateStatus moves a contract from one status to another with a reason
+func (r *RateContractRepository) UpdateStatus(ctx context.Context, id int, fromStatus, toStatus, reason string) error {
+	result, err := r.DB.Exec(ctx, `
+		UPDATE customer_rate_contracts
+		SET status = $3, status_reason = NULLIF($4, ''), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = $2`, id, fromStatus, toStatus, reason)
+	if err != nil {
+		return fmt.Errorf("failed to update rate contract: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("rate contract not found or not %s", fromStatus)
+	}
+	return nil
+}
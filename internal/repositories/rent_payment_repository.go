@@ -2,8 +2,13 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"time"
+
 	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -60,13 +65,31 @@ func (r *RentPaymentRepository) Create(ctx context.Context, payment *models.Rent
 		return err
 	}
 
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	// A cash payment joins the open cash session of the user recording it
+	var cashSessionID *int
+	if payment.PaymentMode == models.PaymentModeCash {
+		id, err := lockOpenCashSession(ctx, tx, payment.ProcessedByUserID)
+		if err != nil {
+			return err
+		}
+		cashSessionID = &id
+	}
+
 	query := `
-		INSERT INTO rent_payments (receipt_number, entry_id, family_member_id, family_member_name, customer_name, customer_phone, total_rent, amount_paid, balance, processed_by_user_id, notes)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-		RETURNING id, payment_date, created_at
+		INSERT INTO rent_payments (receipt_number, entry_id, family_member_id, family_member_name, customer_name, customer_phone, total_rent, amount_paid, balance, processed_by_user_id, notes, cash_session_id,
+			payment_mode, cheque_number, bank_name, cheque_date, utr_number, clearance_status)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $18,
+			$12, NULLIF($13, ''), NULLIF($14, ''), $15, NULLIF($16, ''), NULLIF($17, ''))
+		RETURNING id, payment_date, created_at, cash_session_id
 	`
 
-	err = r.DB.QueryRow(ctx, query,
+	err = tx.QueryRow(ctx, query,
 		receiptNumber,
 		payment.EntryID,
 		payment.FamilyMemberID,
@@ -78,11 +101,21 @@ func (r *RentPaymentRepository) Create(ctx context.Context, payment *models.Rent
 		payment.Balance,
 		payment.ProcessedByUserID,
 		payment.Notes,
-	).Scan(&payment.ID, &payment.PaymentDate, &payment.CreatedAt)
+		payment.PaymentMode,
+		payment.ChequeNumber,
+		payment.BankName,
+		payment.ChequeDate,
+		payment.UTRNumber,
+		payment.ClearanceStatus,
+		cashSessionID,
+	).Scan(&payment.ID, &payment.PaymentDate, &payment.CreatedAt, &payment.CashSessionID)
 
 	if err != nil {
 		return err
 	}
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit payment: %w", err)
+	}
 
 	payment.ReceiptNumber = receiptNumber
 	return nil
@@ -92,7 +125,8 @@ func (r *RentPaymentRepository) GetByEntryID(ctx context.Context, entryID int) (
 	query := `
 		SELECT id, receipt_number, entry_id, family_member_id, COALESCE(family_member_name, ''),
 		       customer_name, customer_phone, total_rent, amount_paid, balance,
-		       payment_date, COALESCE(processed_by_user_id, 0), COALESCE(notes, ''), created_at
+		       payment_date, COALESCE(processed_by_user_id, 0), COALESCE(notes, ''), created_at,
+		       payment_mode, COALESCE(clearance_status, '')
 		FROM rent_payments
 		WHERE entry_id = $1
 		ORDER BY payment_date DESC
@@ -122,6 +156,8 @@ func (r *RentPaymentRepository) GetByEntryID(ctx context.Context, entryID int) (
 			&payment.ProcessedByUserID,
 			&payment.Notes,
 			&payment.CreatedAt,
+			&payment.PaymentMode,
+			&payment.ClearanceStatus,
 		)
 		if err != nil {
 			return nil, err
@@ -136,7 +172,8 @@ func (r *RentPaymentRepository) GetByPhone(ctx context.Context, phone string) ([
 	query := `
 		SELECT id, receipt_number, entry_id, family_member_id, COALESCE(family_member_name, ''),
 		       customer_name, customer_phone, total_rent, amount_paid, balance,
-		       payment_date, COALESCE(processed_by_user_id, 0), COALESCE(notes, ''), created_at
+		       payment_date, COALESCE(processed_by_user_id, 0), COALESCE(notes, ''), created_at,
+		       payment_mode, COALESCE(clearance_status, '')
 		FROM rent_payments
 		WHERE customer_phone = $1
 		ORDER BY payment_date DESC
@@ -166,6 +203,8 @@ func (r *RentPaymentRepository) GetByPhone(ctx context.Context, phone string) ([
 			&payment.ProcessedByUserID,
 			&payment.Notes,
 			&payment.CreatedAt,
+			&payment.PaymentMode,
+			&payment.ClearanceStatus,
 		)
 		if err != nil {
 			return nil, err
@@ -180,7 +219,8 @@ func (r *RentPaymentRepository) GetByFamilyMemberID(ctx context.Context, familyM
 	query := `
 		SELECT id, receipt_number, entry_id, family_member_id, COALESCE(family_member_name, ''),
 		       customer_name, customer_phone, total_rent, amount_paid, balance,
-		       payment_date, COALESCE(processed_by_user_id, 0), COALESCE(notes, ''), created_at
+		       payment_date, COALESCE(processed_by_user_id, 0), COALESCE(notes, ''), created_at,
+		       payment_mode, COALESCE(clearance_status, '')
 		FROM rent_payments
 		WHERE family_member_id = $1
 		ORDER BY payment_date DESC
@@ -210,6 +250,8 @@ func (r *RentPaymentRepository) GetByFamilyMemberID(ctx context.Context, familyM
 			&payment.ProcessedByUserID,
 			&payment.Notes,
 			&payment.CreatedAt,
+			&payment.PaymentMode,
+			&payment.ClearanceStatus,
 		)
 		if err != nil {
 			return nil, err
@@ -227,7 +269,8 @@ func (r *RentPaymentRepository) List(ctx context.Context) ([]*models.RentPayment
 		       rp.customer_name, rp.customer_phone,
 		       rp.total_rent, rp.amount_paid, rp.balance, rp.payment_date,
 		       COALESCE(rp.processed_by_user_id, 0), COALESCE(u.name, 'Unknown'),
-		       COALESCE(rp.notes, ''), rp.created_at
+		       COALESCE(rp.notes, ''), rp.created_at,
+		       rp.payment_mode, COALESCE(rp.clearance_status, '')
 		FROM rent_payments rp
 		LEFT JOIN users u ON rp.processed_by_user_id = u.id
 		ORDER BY rp.payment_date DESC
@@ -258,6 +301,8 @@ func (r *RentPaymentRepository) List(ctx context.Context) ([]*models.RentPayment
 			&payment.ProcessedByName,
 			&payment.Notes,
 			&payment.CreatedAt,
+			&payment.PaymentMode,
+			&payment.ClearanceStatus,
 		)
 		if err != nil {
 			return nil, err
@@ -271,7 +316,8 @@ func (r *RentPaymentRepository) List(ctx context.Context) ([]*models.RentPayment
 func (r *RentPaymentRepository) GetByReceiptNumber(ctx context.Context, receiptNumber string) (*models.RentPayment, error) {
 	query := `
 		SELECT id, receipt_number, entry_id, customer_name, customer_phone, total_rent, amount_paid, balance,
-		       payment_date, COALESCE(processed_by_user_id, 0), COALESCE(notes, ''), created_at
+		       payment_date, COALESCE(processed_by_user_id, 0), COALESCE(notes, ''), created_at,
+		       payment_mode, COALESCE(clearance_status, '')
 		FROM rent_payments
 		WHERE receipt_number = $1
 	`
@@ -290,6 +336,8 @@ func (r *RentPaymentRepository) GetByReceiptNumber(ctx context.Context, receiptN
 		&payment.ProcessedByUserID,
 		&payment.Notes,
 		&payment.CreatedAt,
+		&payment.PaymentMode,
+		&payment.ClearanceStatus,
 	)
 	if err != nil {
 		return nil, err
@@ -297,3 +345,151 @@ func (r *RentPaymentRepository) GetByReceiptNumber(ctx context.Context, receiptN
 
 	return payment, nil
 }
+
+const chequeColumns = `rp.id, rp.receipt_number, rp.entry_id, rp.family_member_id, COALESCE(rp.family_member_name, ''),
+	rp.customer_name, rp.customer_phone, rp.total_rent, rp.amount_paid, rp.balance, rp.payment_date,
+	COALESCE(rp.processed_by_user_id, 0), COALESCE(u.name, 'Unknown'), COALESCE(rp.notes, ''), rp.created_at,
+	rp.payment_mode, COALESCE(rp.cheque_number, ''), COALESCE(rp.bank_name, ''), rp.cheque_date,
+	COALESCE(rp.utr_number, ''), COALESCE(rp.clearance_status, ''), rp.clearing_date,
+	rp.bounced_at, COALESCE(rp.bounce_reason, ''), rp.bounce_penalty`
+
+func scanCheque(row pgx.Row) (*models.RentPayment, error) {
+	p := &models.RentPayment{}
+	err := row.Scan(&p.ID, &p.ReceiptNumber, &p.EntryID, &p.FamilyMemberID, &p.FamilyMemberName,
+		&p.CustomerName, &p.CustomerPhone, &p.TotalRent, &p.AmountPaid, &p.Balance, &p.PaymentDate,
+		&p.ProcessedByUserID, &p.ProcessedByName, &p.Notes, &p.CreatedAt,
+		&p.PaymentMode, &p.ChequeNumber, &p.BankName, &p.ChequeDate,
+		&p.UTRNumber, &p.ClearanceStatus, &p.ClearingDate,
+		&p.BouncedAt, &p.BounceReason, &p.BouncePenalty)
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Get returns a payment with its payment-mode details
+func (r *RentPaymentRepository) Get(ctx context.Context, id int) (*models.RentPayment, error) {
+	p, err := scanCheque(r.DB.QueryRow(ctx, `
+		SELECT `+chequeColumns+`
+		FROM rent_payments rp
+		LEFT JOIN users u ON rp.processed_by_user_id = u.id
+		WHERE rp.id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+	return p, nil
+}
+
+// ListCheques returns cheque payments, optionally by clearance status, oldest first
+func (r *RentPaymentRepository) ListCheques(ctx context.Context, status string) ([]*models.RentPayment, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT `+chequeColumns+`
+		FROM rent_payments rp
+		LEFT JOIN users u ON rp.processed_by_user_id = u.id
+		WHERE rp.payment_mode = 'cheque'
+		  AND ($1 = '' OR rp.clearance_status = $1)
+		ORDER BY rp.payment_date, rp.id`, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list cheques: %w", err)
+	}
+	defer rows.Close()
+
+	var cheques []*models.RentPayment
+	for rows.Next() {
+		p, err := scanCheque(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan cheque: %w", err)
+		}
+		cheques = append(cheques, p)
+	}
+	return cheques, nil
+}
+
+// Clear moves a pending cheque to cleared in one transaction with its ledger credit.
+// Returns false and writes nothing if the cheque is no longer pending.
+func (r *RentPaymentRepository) Clear(ctx context.Context, id int, clearingDate time.Time, userID int, credit *models.CreateLedgerEntryRequest) (*models.LedgerEntry, bool, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	err = tx.QueryRow(ctx, `SELECT COALESCE(clearance_status, '') FROM rent_payments WHERE id = $1 AND payment_mode = 'cheque' FOR UPDATE`, id).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && status != models.ChequeStatusPending) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to lock cheque: %w", err)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE rent_payments
+		SET clearance_status = 'cleared', clearing_date = $2, cleared_at = CURRENT_TIMESTAMP, cleared_by_user_id = $3
+		WHERE id = $1
+	`, id, clearingDate, userID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to clear cheque: %w", err)
+	}
+
+	entry, err := insertLedgerEntry(ctx, tx, credit)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to commit cheque clearance: %w", err)
+	}
+	return entry, true, nil
+}
+
+// Bounce moves a cheque from the given status to bounced in one transaction with its
+// ledger postings: reversal (if not nil) voids the cleared credit, with its notes as the
+// void reason, and charge (if not nil) is the penalty. Returns false and writes nothing
+// if the cheque's status changed meanwhile.
+func (r *RentPaymentRepository) Bounce(ctx context.Context, id int, fromStatus, reason string, penalty float64, reversal, charge *models.CreateLedgerEntryRequest) ([]*models.LedgerEntry, bool, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	var status string
+	err = tx.QueryRow(ctx, `SELECT COALESCE(clearance_status, '') FROM rent_payments WHERE id = $1 AND payment_mode = 'cheque' FOR UPDATE`, id).Scan(&status)
+	if errors.Is(err, pgx.ErrNoRows) || (err == nil && status != fromStatus) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to lock cheque: %w", err)
+	}
+
+	var entries []*models.LedgerEntry
+	if reversal != nil {
+		e, err := voidLedgerEntry(ctx, tx, reversal, reversal.CreatedByUserID, reversal.Notes)
+		if err != nil {
+			return nil, false, err
+		}
+		entries = append(entries, e)
+	}
+	if charge != nil {
+		e, err := insertLedgerEntry(ctx, tx, charge)
+		if err != nil {
+			return nil, false, err
+		}
+		entries = append(entries, e)
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE rent_payments
+		SET clearance_status = 'bounced', bounced_at = CURRENT_TIMESTAMP, bounce_reason = $2, bounce_penalty = $3
+		WHERE id = $1
+	`, id, reason, penalty)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to mark cheque bounced: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, false, fmt.Errorf("failed to commit cheque bounce: %w", err)
+	}
+	return entries, true, nil
+}
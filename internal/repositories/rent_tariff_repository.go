@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type RentTariffRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewRentTariffRepository(db *pgxpool.Pool) *RentTariffRepository {
+	return &RentTariffRepository{DB: db}
+}
+
+const rentTariffColumns = `
+	t.id, t.name, COALESCE(t.thock_category, ''), COALESCE(t.variety, ''), COALESCE(t.bag_size, ''),
+	COALESCE(t.season, ''), t.rate_per_item, t.effective_from, t.effective_to,
+	t.billing_mode, t.min_months, t.season_end_date, t.extra_month_rate, t.priority, t.is_active,
+	COALESCE(t.notes, ''), t.created_by_user_id, COALESCE(u.name, ''), t.created_at, t.updated_at`
+
+func scanRentTariff(row pgx.Row) (*models.RentTariff, error) {
+	t := &models.RentTariff{}
+	err := row.Scan(
+		&t.ID, &t.Name, &t.ThockCategory, &t.Variety, &t.BagSize,
+		&t.Season, &t.RatePerItem, &t.EffectiveFrom, &t.EffectiveTo,
+		&t.BillingMode, &t.MinMonths, &t.SeasonEndDate, &t.ExtraMonthRate, &t.Priority, &t.IsActive,
+		&t.Notes, &t.CreatedByUserID, &t.CreatedByName, &t.CreatedAt, &t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Create inserts a new rate card
+func (r *RentTariffRepository) Create(ctx context.Context, t *models.RentTariff) error {
+	query := `
+		INSERT INTO rent_tariffs (name, thock_category, variety, bag_size, season, rate_per_item,
+		                          effective_from, effective_to, billing_mode, min_months, season_end_date, extra_month_rate,
+		                          priority, is_active, notes, created_by_user_id)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), $6, $7, $8,
+		        $9, $10, $11, $12, $13, $14, $15, $16)
+		RETURNING id, created_at, updated_at
+	`
+	err := r.DB.QueryRow(ctx, query,
+		t.Name, t.ThockCategory, t.Variety, t.BagSize, t.Season, t.RatePerItem,
+		t.EffectiveFrom, t.EffectiveTo, t.BillingMode, t.MinMonths, t.SeasonEndDate, t.ExtraMonthRate,
+		t.Priority, t.IsActive, t.Notes, t.CreatedByUserID,
+	).Scan(&t.ID, &t.CreatedAt, &t.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create rent tariff: %w", err)
+	}
+	return nil
+}
+
+// Update modifies an existing rate card
+func (r *RentTariffRepository) Update(ctx context.Context, t *models.RentTariff) error {
+	query := `
+		UPDATE rent_tariffs
+		SET name = $1, thock_category = NULLIF($2, ''), variety = NULLIF($3, ''), bag_size = NULLIF($4, ''),
+		    season = NULLIF($5, ''), rate_per_item = $6, effective_from = $7, effective_to = $8,
+		    billing_mode = $9, min_months = $10, season_end_date = $11, extra_month_rate = $12,
+		    priority = $13, is_active = $14, notes = $15, updated_at = CURRENT_TIMESTAMP
+		WHERE id = $16
+	`
+	result, err := r.DB.Exec(ctx, query,
+		t.Name, t.ThockCategory, t.Variety, t.BagSize, t.Season, t.RatePerItem,
+		t.EffectiveFrom, t.EffectiveTo, t.BillingMode, t.MinMonths, t.SeasonEndDate, t.ExtraMonthRate,
+		t.Priority, t.IsActive, t.Notes, t.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update rent tariff: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("rent tariff not found")
+	}
+	return nil
+}
+
+// Deactivate disables a rate card (rate cards are never hard deleted so history stays explainable)
+func (r *RentTariffRepository) Deactivate(ctx context.Context, id int) error {
+	result, err := r.DB.Exec(ctx,
+		`UPDATE rent_tariffs SET is_active = FALSE, updated_at = CURRENT_TIMESTAMP WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate rent tariff: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("rent tariff not found")
+	}
+	return nil
+}
+
+// Get returns a rate card by ID
+func (r *RentTariffRepository) Get(ctx context.Context, id int) (*models.RentTariff, error) {
+	query := `SELECT ` + rentTariffColumns + `
+		FROM rent_tariffs t
+		LEFT JOIN users u ON t.created_by_user_id = u.id
+		WHERE t.id = $1`
+	t, err := scanRentTariff(r.DB.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rent tariff: %w", err)
+	}
+	return t, nil
+}
+
+// List returns rate cards, optionally only active ones
+func (r *RentTariffRepository) List(ctx context.Context, activeOnly bool) ([]*models.RentTariff, error) {
+	query := `SELECT ` + rentTariffColumns + `
+		FROM rent_tariffs t
+		LEFT JOIN users u ON t.created_by_user_id = u.id
+		WHERE ($1 = FALSE OR t.is_active = TRUE)
+		ORDER BY t.effective_from DESC, t.priority DESC, t.id DESC`
+
+	rows, err := r.DB.Query(ctx, query, activeOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list rent tariffs: %w", err)
+	}
+	defer rows.Close()
+
+	var tariffs []*models.RentTariff
+	for rows.Next() {
+		t, err := scanRentTariff(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan rent tariff: %w", err)
+		}
+		tariffs = append(tariffs, t)
+	}
+	return tariffs, nil
+}
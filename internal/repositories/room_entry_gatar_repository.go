@@ -124,6 +124,74 @@ func (r *RoomEntryGatarRepository) GetStockByRoomFloor(ctx context.Context, room
 	return stocks, nil
 }
 
+// stockedGatarRows selects the room entry gatar rows holding stock, leaving out deleted
+// entries. Every room entry has rows: its per-gatar quantities, or its quantity spread
+// over gate_no (migration 050 backfilled the entries saved before that).
+const stockedGatarRows = `room_entry_gatars reg
+         JOIN room_entries re ON reg.room_entry_id = re.id
+         LEFT JOIN entries e ON re.entry_id = e.id
+         WHERE reg.quantity > 0 AND COALESCE(e.status, 'active') != 'deleted'`
+
+// GetQuantitiesByGatar returns the bags stored in each of the given gatars, leaving out
+// one room entry (pass 0 to count all)
+func (r *RoomEntryGatarRepository) GetQuantitiesByGatar(ctx context.Context, gatarNos []int, excludeRoomEntryID int) (map[int]int, error) {
+	rows, err := r.DB.Query(ctx,
+		`SELECT reg.gatar_no, SUM(reg.quantity)
+         FROM `+stockedGatarRows+`
+           AND reg.gatar_no = ANY($1) AND reg.room_entry_id != $2
+         GROUP BY reg.gatar_no`, gatarNos, excludeRoomEntryID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	quantities := make(map[int]int)
+	for rows.Next() {
+		var gatarNo, quantity int
+		if err := rows.Scan(&gatarNo, &quantity); err != nil {
+			return nil, err
+		}
+		quantities[gatarNo] = quantity
+	}
+	return quantities, rows.Err()
+}
+
+// GatarOccupant summarises what a gatar holds: its bags and whose thocks they are
+type GatarOccupant struct {
+	GatarNo     int
+	Quantity    int
+	CustomerIDs []int
+	Categories  []string // Thock categories (seed/sell) stored in the gatar
+}
+
+// GetOccupants returns every gatar holding stock, gate_no-only room entries included
+func (r *RoomEntryGatarRepository) GetOccupants(ctx context.Context) (map[int]*GatarOccupant, error) {
+	rows, err := r.DB.Query(ctx,
+		`SELECT reg.gatar_no, SUM(reg.quantity),
+		        ARRAY_AGG(DISTINCT COALESCE(e.customer_id, 0)),
+		        ARRAY_AGG(DISTINCT COALESCE(e.thock_category, ''))
+         FROM `+stockedGatarRows+`
+         GROUP BY reg.gatar_no`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	occupants := make(map[int]*GatarOccupant)
+	for rows.Next() {
+		o := &GatarOccupant{}
+		var customerIDs []int32
+		if err := rows.Scan(&o.GatarNo, &o.Quantity, &customerIDs, &o.Categories); err != nil {
+			return nil, err
+		}
+		for _, id := range customerIDs {
+			o.CustomerIDs = append(o.CustomerIDs, int(id))
+		}
+		occupants[o.GatarNo] = o
+	}
+	return occupants, rows.Err()
+}
+
 // ReduceQuantity reduces the quantity in a specific gatar entry
 func (r *RoomEntryGatarRepository) ReduceQuantity(ctx context.Context, id int, amount int) error {
 	_, err := r.DB.Exec(ctx,
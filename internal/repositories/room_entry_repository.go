@@ -2,8 +2,13 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
 
 	"cold-backend/internal/models"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
@@ -24,6 +29,141 @@ func (r *RoomEntryRepository) Create(ctx context.Context, re *models.RoomEntry)
 	).Scan(&re.ID, &re.CreatedAt, &re.UpdatedAt)
 }
 
+// CreateWithGatars inserts a room entry and its gatar rows in one transaction, after
+// checking the gatars' capacity under lock (see lockGatarCapacity)
+func (r *RoomEntryRepository) CreateWithGatars(ctx context.Context, re *models.RoomEntry, gatars []models.GatarInput, capacities map[int]int, blockOverflow bool) ([]models.GatarUtilization, []string, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	utilization, warnings, err := lockGatarCapacity(ctx, tx, gatars, capacities, 0, blockOverflow)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = tx.QueryRow(ctx,
+		`INSERT INTO room_entries(entry_id, thock_number, room_no, floor, gate_no, remark, quantity, quantity_breakdown, created_by_user_id)
+         VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9)
+         RETURNING id, created_at, updated_at`,
+		re.EntryID, re.ThockNumber, re.RoomNo, re.Floor, re.GateNo, re.Remark, re.Quantity, re.QuantityBreakdown, re.CreatedByUserID,
+	).Scan(&re.ID, &re.CreatedAt, &re.UpdatedAt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create room entry: %w", err)
+	}
+	if err := insertRoomEntryGatars(ctx, tx, re.ID, gatars); err != nil {
+		return nil, nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit room entry: %w", err)
+	}
+	return utilization, warnings, nil
+}
+
+// UpdateWithGatars saves a room entry in one transaction with its gatar rows, which are
+// replaced when replaceGatars is set. The gatars' capacity is checked under lock first,
+// leaving out the room entry's own stock (see lockGatarCapacity).
+func (r *RoomEntryRepository) UpdateWithGatars(ctx context.Context, id int, re *models.RoomEntry, gatars []models.GatarInput, replaceGatars bool, capacities map[int]int, blockOverflow bool) ([]models.GatarUtilization, []string, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	utilization, warnings, err := lockGatarCapacity(ctx, tx, gatars, capacities, id, blockOverflow)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = tx.QueryRow(ctx,
+		`UPDATE room_entries
+         SET room_no=$1, floor=$2, gate_no=$3, remark=$4, quantity=$5, quantity_breakdown=$6, updated_at=NOW()
+         WHERE id=$7
+         RETURNING updated_at`,
+		re.RoomNo, re.Floor, re.GateNo, re.Remark, re.Quantity, re.QuantityBreakdown, id,
+	).Scan(&re.UpdatedAt)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to update room entry: %w", err)
+	}
+	if replaceGatars {
+		if _, err := tx.Exec(ctx, `DELETE FROM room_entry_gatars WHERE room_entry_id = $1`, id); err != nil {
+			return nil, nil, fmt.Errorf("failed to clear room entry gatars: %w", err)
+		}
+		if err := insertRoomEntryGatars(ctx, tx, id, gatars); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to commit room entry: %w", err)
+	}
+	return utilization, warnings, nil
+}
+
+// insertRoomEntryGatars writes a room entry's gatar rows inside tx
+func insertRoomEntryGatars(ctx context.Context, tx pgx.Tx, roomEntryID int, gatars []models.GatarInput) error {
+	for _, g := range gatars {
+		_, err := tx.Exec(ctx,
+			`INSERT INTO room_entry_gatars(room_entry_id, gatar_no, quantity, quality, remark)
+             VALUES($1, $2, $3, $4, $5)`,
+			roomEntryID, g.GatarNo, g.Quantity, g.Quality, g.Remark)
+		if err != nil {
+			return fmt.Errorf("failed to save gatar %d: %w", g.GatarNo, err)
+		}
+	}
+	return nil
+}
+
+// lockGatarCapacity locks the gatars a room entry puts bags in, lowest number first like
+// gatar transfers, and works out each one's stock once they are in against its capacity.
+// The room entry's own rows (excludeRoomEntryID) are left out. Gatars pushed past
+// capacity are returned as warnings, or fail when blockOverflow is set. Nil capacities
+// (no warehouse layout) skip the check.
+func lockGatarCapacity(ctx context.Context, tx pgx.Tx, gatars []models.GatarInput, capacities map[int]int, excludeRoomEntryID int, blockOverflow bool) ([]models.GatarUtilization, []string, error) {
+	if capacities == nil || len(gatars) == 0 {
+		return nil, nil, nil
+	}
+	planned := make(map[int]int)
+	var order []int
+	for _, g := range gatars {
+		if _, ok := planned[g.GatarNo]; !ok {
+			order = append(order, g.GatarNo)
+		}
+		planned[g.GatarNo] += g.Quantity
+	}
+
+	locks := append([]int(nil), order...)
+	sort.Ints(locks)
+	for _, g := range locks {
+		if err := lockGatar(ctx, tx, g); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var utilization []models.GatarUtilization
+	var warnings []string
+	for _, g := range order {
+		stored, err := lockGatarStock(ctx, tx, g, excludeRoomEntryID)
+		if err != nil {
+			return nil, nil, err
+		}
+		u := models.GatarUtilization{GatarNo: g, Capacity: capacities[g], Used: stored + planned[g]}
+		u.Free = u.Capacity - u.Used
+		if u.Free < 0 {
+			warnings = append(warnings, fmt.Sprintf("gatar %d would hold %d bags, %d over its capacity of %d",
+				g, u.Used, -u.Free, u.Capacity))
+		}
+		utilization = append(utilization, u)
+	}
+
+	if len(warnings) > 0 && blockOverflow {
+		return nil, nil, errors.New("gatar capacity exceeded: " + strings.Join(warnings, "; "))
+	}
+	return utilization, warnings, nil
+}
+
 func (r *RoomEntryRepository) Get(ctx context.Context, id int) (*models.RoomEntry, error) {
 	row := r.DB.QueryRow(ctx,
 		`SELECT re.id, re.entry_id, re.thock_number, re.room_no, re.floor, re.gate_no, re.remark, re.quantity,
@@ -190,6 +190,7 @@ func (r *SMSLogRepository) GetByCustomer(ctx context.Context, customerID int, li
 }
 
 // GetCustomersWithBalance returns customers with balance for payment reminders
+// (ledger debits less credits, as on the debtors list)
 func (r *SMSLogRepository) GetCustomersWithBalance(ctx context.Context, minBalance float64) ([]map[string]interface{}, error) {
 	query := `
 		WITH customer_balances AS (
@@ -197,15 +198,9 @@ func (r *SMSLogRepository) GetCustomersWithBalance(ctx context.Context, minBalan
 				c.id as customer_id,
 				c.name,
 				c.phone,
-				COALESCE(SUM(
-					CASE
-						WHEN l.transaction_type = 'rent_charge' THEN l.amount
-						WHEN l.transaction_type = 'payment' THEN -l.amount
-						ELSE 0
-					END
-				), 0) as balance
+				COALESCE(SUM(le.debit) - SUM(le.credit), 0) as balance
 			FROM customers c
-			LEFT JOIN ledger l ON c.id = l.customer_id
+			LEFT JOIN ledger_entries le ON le.customer_phone = c.phone AND le.voided_at IS NULL AND le.reversal_of_id IS NULL
 			WHERE c.deleted_at IS NULL
 			GROUP BY c.id, c.name, c.phone
 		)
@@ -0,0 +1,178 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// TallyRepository tracks Tally Prime export batches and the ledger entries they contain
+type TallyRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewTallyRepository(db *pgxpool.Pool) *TallyRepository {
+	return &TallyRepository{DB: db}
+}
+
+const tallyExportColumns = `t.id, t.from_date, t.to_date, t.voucher_count, t.ledger_count,
+	t.created_by_user_id, COALESCE(u.name, ''), t.created_at`
+
+func scanTallyExport(row pgx.Row) (*models.TallyExport, error) {
+	t := &models.TallyExport{}
+	err := row.Scan(&t.ID, &t.FromDate, &t.ToDate, &t.VoucherCount, &t.LedgerCount,
+		&t.CreatedByUserID, &t.CreatedByName, &t.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// CreateExport records an export batch and marks its ledger entries as exported
+func (r *TallyRepository) CreateExport(ctx context.Context, export *models.TallyExport, ledgerEntryIDs []int) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	err = tx.QueryRow(ctx, `
+		INSERT INTO tally_exports (from_date, to_date, voucher_count, ledger_count, created_by_user_id)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, export.FromDate, export.ToDate, export.VoucherCount, export.LedgerCount, export.CreatedByUserID).
+		Scan(&export.ID, &export.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create tally export: %w", err)
+	}
+
+	// Entries resent from an earlier batch keep their original batch
+	_, err = tx.Exec(ctx, `
+		UPDATE ledger_entries SET tally_export_id = $1
+		WHERE id = ANY($2) AND tally_export_id IS NULL
+	`, export.ID, ledgerEntryIDs)
+	if err != nil {
+		return fmt.Errorf("failed to mark ledger entries exported: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
+// GetExport returns an export batch by ID
+func (r *TallyRepository) GetExport(ctx context.Context, id int) (*models.TallyExport, error) {
+	t, err := scanTallyExport(r.DB.QueryRow(ctx, `
+		SELECT `+tallyExportColumns+`
+		FROM tally_exports t LEFT JOIN users u ON u.id = t.created_by_user_id
+		WHERE t.id = $1`, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tally export: %w", err)
+	}
+	return t, nil
+}
+
+// ListExports returns export batches, newest first
+func (r *TallyRepository) ListExports(ctx context.Context, limit int) ([]*models.TallyExport, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := r.DB.Query(ctx, `
+		SELECT `+tallyExportColumns+`
+		FROM tally_exports t LEFT JOIN users u ON u.id = t.created_by_user_id
+		ORDER BY t.created_at DESC, t.id DESC
+		LIMIT $1`, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tally exports: %w", err)
+	}
+	defer rows.Close()
+
+	var exports []*models.TallyExport
+	for rows.Next() {
+		t, err := scanTallyExport(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tally export: %w", err)
+		}
+		exports = append(exports, t)
+	}
+	return exports, nil
+}
+
+const tallyLedgerEntrySelect = `
+	SELECT le.id, le.customer_phone, le.customer_name, COALESCE(le.customer_so, ''),
+	       le.entry_type, COALESCE(le.description, ''), le.debit, le.credit,
+	       le.reference_id, COALESCE(le.reference_type, ''), COALESCE(le.family_member_name, ''),
+	       le.created_by_user_id, le.created_at, COALESCE(le.notes, ''),
+	       COALESCE(rp.receipt_number, ''), le.tally_export_id, COALESCE(orig.entry_type, ''),
+	       COALESCE(le.payment_mode, '')
+	FROM ledger_entries le
+	LEFT JOIN rent_payments rp ON le.reference_type = 'payment' AND rp.id = le.reference_id
+	LEFT JOIN ledger_entries orig ON orig.id = le.reversal_of_id
+`
+
+// ListEntriesForExport returns money-moving ledger entries in [from, to], oldest first.
+// Entries already sent to Tally are skipped unless includeExported is set.
+func (r *TallyRepository) ListEntriesForExport(ctx context.Context, from, to time.Time, includeExported bool) ([]models.TallyLedgerEntry, error) {
+	query := tallyLedgerEntrySelect + `
+		WHERE le.created_at >= $1 AND le.created_at <= $2
+		  AND (le.debit > 0 OR le.credit > 0)
+		  AND ($3 = TRUE OR le.tally_export_id IS NULL)
+		ORDER BY le.created_at, le.id`
+	return r.queryEntries(ctx, query, from, to, includeExported)
+}
+
+// ListEntriesByExport returns the ledger entries first exported in a batch
+func (r *TallyRepository) ListEntriesByExport(ctx context.Context, exportID int) ([]models.TallyLedgerEntry, error) {
+	query := tallyLedgerEntrySelect + `
+		WHERE le.tally_export_id = $1
+		ORDER BY le.created_at, le.id`
+	return r.queryEntries(ctx, query, exportID)
+}
+
+func (r *TallyRepository) queryEntries(ctx context.Context, query string, args ...interface{}) ([]models.TallyLedgerEntry, error) {
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ledger entries for tally: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.TallyLedgerEntry
+	for rows.Next() {
+		var e models.TallyLedgerEntry
+		if err := rows.Scan(&e.ID, &e.CustomerPhone, &e.CustomerName, &e.CustomerSO,
+			&e.EntryType, &e.Description, &e.Debit, &e.Credit,
+			&e.ReferenceID, &e.ReferenceType, &e.FamilyMemberName,
+			&e.CreatedByUserID, &e.CreatedAt, &e.Notes,
+			&e.ReceiptNumber, &e.TallyExportID, &e.ReversedEntryType, &e.PaymentMode); err != nil {
+			return nil, fmt.Errorf("failed to scan ledger entry: %w", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries, nil
+}
+
+// ListCustomersByPhones returns customer records for the given phone numbers
+func (r *TallyRepository) ListCustomersByPhones(ctx context.Context, phones []string) ([]*models.Customer, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT id, name, phone, COALESCE(so, ''), COALESCE(village, ''), COALESCE(address, '')
+		FROM customers
+		WHERE phone = ANY($1)
+		ORDER BY name`, phones)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customers: %w", err)
+	}
+	defer rows.Close()
+
+	var customers []*models.Customer
+	for rows.Next() {
+		c := &models.Customer{}
+		if err := rows.Scan(&c.ID, &c.Name, &c.Phone, &c.SO, &c.Village, &c.Address); err != nil {
+			return nil, fmt.Errorf("failed to scan customer: %w", err)
+		}
+		customers = append(customers, c)
+	}
+	return customers, nil
+}
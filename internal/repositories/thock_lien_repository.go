@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type ThockLienRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewThockLienRepository(db *pgxpool.Pool) *ThockLienRepository {
+	return &ThockLienRepository{DB: db}
+}
+
+const thockLienColumns = `
+	l.id, l.receipt_number, l.verification_code, l.entry_id, l.thock_number, l.customer_id,
+	COALESCE(cu.name, ''), COALESCE(cu.phone, ''), COALESCE(cu.so, ''), COALESCE(cu.village, ''),
+	COALESCE(e.remark, ''),
+	l.bank_name, COALESCE(l.bank_branch, ''), COALESCE(l.loan_account_number, ''),
+	l.pledged_quantity, l.lien_amount, l.status, COALESCE(l.notes, ''),
+	l.marked_by_user_id, COALESCE(mb.name, ''), l.marked_at,
+	l.released_by_user_id, COALESCE(rb.name, ''), l.released_at, COALESCE(l.release_reference, ''),
+	l.created_at, l.updated_at`
+
+const thockLienJoins = `
+	FROM thock_liens l
+	LEFT JOIN customers cu ON l.customer_id = cu.id
+	LEFT JOIN entries e ON l.entry_id = e.id
+	LEFT JOIN users mb ON l.marked_by_user_id = mb.id
+	LEFT JOIN users rb ON l.released_by_user_id = rb.id`
+
+func scanThockLien(row pgx.Row) (*models.ThockLien, error) {
+	l := &models.ThockLien{}
+	err := row.Scan(
+		&l.ID, &l.ReceiptNumber, &l.VerificationCode, &l.EntryID, &l.ThockNumber, &l.CustomerID,
+		&l.CustomerName, &l.CustomerPhone, &l.CustomerSO, &l.CustomerVillage,
+		&l.Variety,
+		&l.BankName, &l.BankBranch, &l.LoanAccountNumber,
+		&l.PledgedQuantity, &l.LienAmount, &l.Status, &l.Notes,
+		&l.MarkedByUserID, &l.MarkedByName, &l.MarkedAt,
+		&l.ReleasedByUserID, &l.ReleasedByName, &l.ReleasedAt, &l.ReleaseReference,
+		&l.CreatedAt, &l.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// Create inserts an active lien. The receipt number is derived from the new ID (WR-YYYY-NNNNNN).
+func (r *ThockLienRepository) Create(ctx context.Context, l *models.ThockLien) error {
+	query := `
+		WITH seq AS (SELECT nextval(pg_get_serial_sequence('thock_liens', 'id')) AS id)
+		INSERT INTO thock_liens (id, receipt_number, verification_code, entry_id, thock_number, customer_id,
+		                         bank_name, bank_branch, loan_account_number, pledged_quantity, lien_amount,
+		                         status, notes, marked_by_user_id)
+		SELECT seq.id, 'WR-' || to_char(CURRENT_DATE, 'YYYY') || '-' || LPAD(seq.id::text, 6, '0'),
+		       $1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), $8, $9, $10, NULLIF($11, ''), $12
+		FROM seq
+		RETURNING id, receipt_number, marked_at, created_at, updated_at
+	`
+	err := r.DB.QueryRow(ctx, query,
+		l.VerificationCode, l.EntryID, l.ThockNumber, l.CustomerID,
+		l.BankName, l.BankBranch, l.LoanAccountNumber, l.PledgedQuantity, l.LienAmount,
+		l.Status, l.Notes, l.MarkedByUserID,
+	).Scan(&l.ID, &l.ReceiptNumber, &l.MarkedAt, &l.CreatedAt, &l.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create thock lien: %w", err)
+	}
+	return nil
+}
+
+// Get returns a lien by ID
+func (r *ThockLienRepository) Get(ctx context.Context, id int) (*models.ThockLien, error) {
+	query := `SELECT ` + thockLienColumns + thockLienJoins + ` WHERE l.id = $1`
+	l, err := scanThockLien(r.DB.QueryRow(ctx, query, id))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thock lien: %w", err)
+	}
+	return l, nil
+}
+
+// GetByVerificationCode returns the lien for a receipt verification code (nil if unknown)
+func (r *ThockLienRepository) GetByVerificationCode(ctx context.Context, code string) (*models.ThockLien, error) {
+	query := `SELECT ` + thockLienColumns + thockLienJoins + ` WHERE l.verification_code = $1`
+	l, err := scanThockLien(r.DB.QueryRow(ctx, query, code))
+	if err == pgx.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get thock lien: %w", err)
+	}
+	return l, nil
+}
+
+// List returns liens, optionally filtered by status, thock number and customer phone
+func (r *ThockLienRepository) List(ctx context.Context, status, thockNumber, customerPhone string) ([]*models.ThockLien, error) {
+	query := `SELECT ` + thockLienColumns + thockLienJoins + `
+		WHERE ($1 = '' OR l.status = $1)
+		  AND ($2 = '' OR l.thock_number = $2)
+		  AND ($3 = '' OR cu.phone = $3)
+		ORDER BY l.marked_at DESC, l.id DESC`
+
+	rows, err := r.DB.Query(ctx, query, status, thockNumber, customerPhone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list thock liens: %w", err)
+	}
+	defer rows.Close()
+
+	var liens []*models.ThockLien
+	for rows.Next() {
+		l, err := scanThockLien(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan thock lien: %w", err)
+		}
+		liens = append(liens, l)
+	}
+	return liens, nil
+}
+
+// GetPledgedQuantityByThock returns the bags of a thock held under active liens
+func (r *ThockLienRepository) GetPledgedQuantityByThock(ctx context.Context, thockNumber string) (int, error) {
+	var pledged int
+	err := r.DB.QueryRow(ctx,
+		`SELECT COALESCE(SUM(pledged_quantity), 0) FROM thock_liens WHERE thock_number = $1 AND status = 'active'`,
+		thockNumber).Scan(&pledged)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pledged quantity: %w", err)
+	}
+	return pledged, nil
+}
+
+// Release marks an active lien as released
+func (r *ThockLienRepository) Release(ctx context.Context, id int, userID int, releaseReference string) error {
+	result, err := r.DB.Exec(ctx, `
+		UPDATE thock_liens
+		SET status = 'released', released_by_user_id = $2, released_at = CURRENT_TIMESTAMP,
+		    release_reference = NULLIF($3, ''), updated_at = CURRENT_TIMESTAMP
+		WHERE id = $1 AND status = 'active'`, id, userID, releaseReference)
+	if err != nil {
+		return fmt.Errorf("failed to release thock lien: %w", err)
+	}
+	if result.RowsAffected() == 0 {
+		return fmt.Errorf("lien not found or already released")
+	}
+	return nil
+}
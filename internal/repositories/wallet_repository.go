@@ -0,0 +1,155 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"cold-backend/internal/models"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WalletRepository reads customer wallets from wallet deposit ledger entries and their
+// payment allocations
+type WalletRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewWalletRepository(db *pgxpool.Pool) *WalletRepository {
+	return &WalletRepository{DB: db}
+}
+
+const walletDeposit = `le.reference_type = 'wallet_deposit' AND le.voided_at IS NULL AND le.reversal_of_id IS NULL`
+
+// Post writes a wallet deposit or refund. Cash goes through the open cash session of the
+// user handling it, which is locked until the entry is in; without one it is refused. A
+// refund is checked against the wallet balance under the customer's ledger lock, so two
+// refunds at once cannot pay out more than the wallet holds.
+func (r *WalletRepository) Post(ctx context.Context, entry *models.CreateLedgerEntryRequest) (*models.LedgerEntry, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if entry.PaymentMode == models.PaymentModeCash {
+		id, err := lockOpenCashSession(ctx, tx, entry.CreatedByUserID)
+		if err != nil {
+			return nil, err
+		}
+		entry.CashSessionID = &id
+	}
+	created, err := insertLedgerEntry(ctx, tx, entry)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.ReferenceType == "wallet_refund" {
+		// Refunds count by their debit, as their allocation to deposits comes after commit
+		var balance float64
+		err = tx.QueryRow(ctx, `
+			SELECT COALESCE(SUM(le.credit) FILTER (WHERE `+walletDeposit+`), 0)
+			       - COALESCE((SELECT SUM(pa.amount)
+			                   FROM payment_allocations pa
+			                   JOIN ledger_entries d ON d.id = pa.payment_entry_id
+			                   JOIN ledger_entries c ON c.id = pa.charge_entry_id
+			                   WHERE d.customer_phone = $1 AND d.reference_type = 'wallet_deposit'
+			                     AND d.voided_at IS NULL AND d.reversal_of_id IS NULL
+			                     AND COALESCE(c.reference_type, '') <> 'wallet_refund'), 0)
+			       - COALESCE(SUM(le.debit) FILTER (WHERE le.reference_type = 'wallet_refund'
+			                                        AND le.voided_at IS NULL AND le.reversal_of_id IS NULL), 0)
+			FROM ledger_entries le
+			WHERE le.customer_phone = $1
+		`, entry.CustomerPhone).Scan(&balance)
+		if err != nil {
+			return nil, fmt.Errorf("failed to check wallet balance: %w", err)
+		}
+		if balance < -0.005 {
+			return nil, fmt.Errorf("refund exceeds the wallet balance of ₹%.2f", roundAllocation(balance+entry.Debit))
+		}
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, fmt.Errorf("failed to commit wallet posting: %w", err)
+	}
+	return created, nil
+}
+
+// ListTotals returns deposited, applied and refunded totals per customer (one customer
+// when phone is set)
+func (r *WalletRepository) ListTotals(ctx context.Context, phone string) ([]*models.Wallet, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT le.customer_phone, MAX(le.customer_name), SUM(le.credit),
+		       COALESCE(SUM(a.applied), 0), COALESCE(SUM(a.refunded), 0)
+		FROM ledger_entries le
+		LEFT JOIN (
+			SELECT pa.payment_entry_id,
+			       SUM(pa.amount) FILTER (WHERE COALESCE(c.reference_type, '') <> 'wallet_refund') AS applied,
+			       SUM(pa.amount) FILTER (WHERE c.reference_type = 'wallet_refund') AS refunded
+			FROM payment_allocations pa
+			JOIN ledger_entries c ON c.id = pa.charge_entry_id
+			GROUP BY pa.payment_entry_id
+		) a ON a.payment_entry_id = le.id
+		WHERE ($1 = '' OR le.customer_phone = $1) AND `+walletDeposit+`
+		GROUP BY le.customer_phone
+		ORDER BY MAX(le.customer_name)
+	`, phone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet totals: %w", err)
+	}
+	defer rows.Close()
+
+	var wallets []*models.Wallet
+	for rows.Next() {
+		w := &models.Wallet{}
+		if err := rows.Scan(&w.CustomerPhone, &w.CustomerName, &w.Deposited, &w.Applied, &w.Refunded); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet totals: %w", err)
+		}
+		w.Balance = roundAllocation(w.Deposited - w.Applied - w.Refunded)
+		wallets = append(wallets, w)
+	}
+	return wallets, rows.Err()
+}
+
+// ListStatement returns a customer's wallet deposits and what they were applied to, in
+// date order. An application is dated when both the deposit and the charge existed.
+func (r *WalletRepository) ListStatement(ctx context.Context, phone string) ([]models.WalletStatementLine, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT s.date, s.kind, s.ledger_entry_id, s.deposit_entry_id, s.description, s.thock_number, s.credit, s.debit
+		FROM (
+			SELECT le.created_at AS date, 'deposit' AS kind, le.id AS ledger_entry_id, le.id AS deposit_entry_id,
+			       COALESCE(le.description, '') AS description, '' AS thock_number,
+			       le.credit AS credit, 0::DECIMAL AS debit
+			FROM ledger_entries le
+			WHERE le.customer_phone = $1 AND `+walletDeposit+`
+			UNION ALL
+			SELECT GREATEST(le.created_at, c.created_at),
+			       CASE WHEN c.reference_type = 'wallet_refund' THEN 'refund' ELSE 'applied' END,
+			       c.id, le.id, COALESCE(c.description, ''), COALESCE(c.thock_number, ''),
+			       0, pa.amount
+			FROM payment_allocations pa
+			JOIN ledger_entries le ON le.id = pa.payment_entry_id
+			JOIN ledger_entries c ON c.id = pa.charge_entry_id
+			WHERE le.customer_phone = $1 AND `+walletDeposit+`
+		) s
+		ORDER BY s.date, s.kind <> 'deposit', s.ledger_entry_id
+	`, phone)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet statement: %w", err)
+	}
+	defer rows.Close()
+
+	var lines []models.WalletStatementLine
+	balance := 0.0
+	for rows.Next() {
+		var l models.WalletStatementLine
+		if err := rows.Scan(&l.Date, &l.Kind, &l.LedgerEntryID, &l.DepositEntryID, &l.Description,
+			&l.ThockNumber, &l.Credit, &l.Debit); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet statement line: %w", err)
+		}
+		balance = roundAllocation(balance + l.Credit - l.Debit)
+		l.Balance = balance
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
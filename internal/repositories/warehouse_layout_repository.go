@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"cold-backend/internal/models"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// WarehouseLayoutRepository stores the warehouse layout: rooms, floors and gatar ranges
+type WarehouseLayoutRepository struct {
+	DB *pgxpool.Pool
+}
+
+func NewWarehouseLayoutRepository(db *pgxpool.Pool) *WarehouseLayoutRepository {
+	return &WarehouseLayoutRepository{DB: db}
+}
+
+// Load reads the whole layout, rooms in sort order and each floor's ranges in plan order
+func (r *WarehouseLayoutRepository) Load(ctx context.Context) (*models.WarehouseLayout, error) {
+	layout := &models.WarehouseLayout{Capacities: make(map[int]int)}
+	rooms := make(map[string]*models.LayoutRoom)
+	floors := make(map[[2]string]*models.LayoutFloor)
+
+	rows, err := r.DB.Query(ctx, `
+		SELECT room_no, name, room_type, sort_order
+		FROM warehouse_rooms
+		ORDER BY sort_order, room_no
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load warehouse rooms: %w", err)
+	}
+	for rows.Next() {
+		room := &models.LayoutRoom{Floors: []*models.LayoutFloor{}}
+		if err := rows.Scan(&room.RoomNo, &room.Name, &room.RoomType, &room.SortOrder); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan warehouse room: %w", err)
+		}
+		layout.Rooms = append(layout.Rooms, room)
+		rooms[room.RoomNo] = room
+	}
+	rows.Close()
+
+	rows, err = r.DB.Query(ctx, `
+		SELECT room_no, floor, grid_rows, gatar_capacity
+		FROM warehouse_floors
+		ORDER BY room_no, floor
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load warehouse floors: %w", err)
+	}
+	for rows.Next() {
+		var roomNo string
+		f := &models.LayoutFloor{Ranges: []models.LayoutGatarRange{}}
+		if err := rows.Scan(&roomNo, &f.Floor, &f.GridRows, &f.GatarCapacity); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan warehouse floor: %w", err)
+		}
+		if room, ok := rooms[roomNo]; ok {
+			room.Floors = append(room.Floors, f)
+			floors[[2]string{roomNo, f.Floor}] = f
+		}
+	}
+	rows.Close()
+
+	rows, err = r.DB.Query(ctx, `
+		SELECT room_no, floor, start_gatar, end_gatar, grid_cols
+		FROM warehouse_gatar_ranges
+		ORDER BY room_no, floor, sort_order, start_gatar
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load gatar ranges: %w", err)
+	}
+	for rows.Next() {
+		var roomNo, floor string
+		var g models.LayoutGatarRange
+		if err := rows.Scan(&roomNo, &floor, &g.StartGatar, &g.EndGatar, &g.GridCols); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan gatar range: %w", err)
+		}
+		if f, ok := floors[[2]string{roomNo, floor}]; ok {
+			f.Ranges = append(f.Ranges, g)
+		}
+	}
+	rows.Close()
+
+	rows, err = r.DB.Query(ctx, `SELECT gatar_no, capacity FROM warehouse_gatar_capacities`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load gatar capacities: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var gatarNo, capacity int
+		if err := rows.Scan(&gatarNo, &capacity); err != nil {
+			return nil, fmt.Errorf("failed to scan gatar capacity: %w", err)
+		}
+		layout.Capacities[gatarNo] = capacity
+	}
+	return layout, rows.Err()
+}
+
+// SaveRoom creates or replaces a room with its floors and gatar ranges. Floors missing
+// from the room are removed.
+func (r *WarehouseLayoutRepository) SaveRoom(ctx context.Context, room *models.LayoutRoom) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx, `
+		INSERT INTO warehouse_rooms (room_no, name, room_type, sort_order)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (room_no) DO UPDATE
+		SET name = EXCLUDED.name, room_type = EXCLUDED.room_type, sort_order = EXCLUDED.sort_order, updated_at = NOW()
+	`, room.RoomNo, room.Name, room.RoomType, room.SortOrder)
+	if err != nil {
+		return fmt.Errorf("failed to save warehouse room: %w", err)
+	}
+
+	// Ranges are rewritten whole; dropping them first keeps the no-overlap constraint
+	// from tripping over the room's own old ranges
+	if _, err := tx.Exec(ctx, `DELETE FROM warehouse_gatar_ranges WHERE room_no = $1`, room.RoomNo); err != nil {
+		return fmt.Errorf("failed to clear gatar ranges: %w", err)
+	}
+
+	keep := make([]string, 0, len(room.Floors))
+	for _, f := range room.Floors {
+		keep = append(keep, f.Floor)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM warehouse_floors WHERE room_no = $1 AND NOT (floor = ANY($2))`, room.RoomNo, keep); err != nil {
+		return fmt.Errorf("failed to remove warehouse floors: %w", err)
+	}
+
+	for _, f := range room.Floors {
+		_, err = tx.Exec(ctx, `
+			INSERT INTO warehouse_floors (room_no, floor, grid_rows, gatar_capacity)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (room_no, floor) DO UPDATE
+			SET grid_rows = EXCLUDED.grid_rows, gatar_capacity = EXCLUDED.gatar_capacity
+		`, room.RoomNo, f.Floor, f.GridRows, f.GatarCapacity)
+		if err != nil {
+			return fmt.Errorf("failed to save warehouse floor: %w", err)
+		}
+		for i, g := range f.Ranges {
+			_, err = tx.Exec(ctx, `
+				INSERT INTO warehouse_gatar_ranges (room_no, floor, start_gatar, end_gatar, grid_cols, sort_order)
+				VALUES ($1, $2, $3, $4, $5, $6)
+			`, room.RoomNo, f.Floor, g.StartGatar, g.EndGatar, g.GridCols, i)
+			if err != nil {
+				return fmt.Errorf("failed to save gatar range %d-%d: %w", g.StartGatar, g.EndGatar, err)
+			}
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
+// DeleteRoom removes a room with its floors and gatar ranges
+func (r *WarehouseLayoutRepository) DeleteRoom(ctx context.Context, roomNo string) (bool, error) {
+	result, err := r.DB.Exec(ctx, `DELETE FROM warehouse_rooms WHERE room_no = $1`, roomNo)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete warehouse room: %w", err)
+	}
+	return result.RowsAffected() > 0, nil
+}
+
+// SetGatarCapacity overrides a gatar's capacity; capacity 0 removes the override
+func (r *WarehouseLayoutRepository) SetGatarCapacity(ctx context.Context, gatarNo, capacity int) error {
+	var err error
+	if capacity == 0 {
+		_, err = r.DB.Exec(ctx, `DELETE FROM warehouse_gatar_capacities WHERE gatar_no = $1`, gatarNo)
+	} else {
+		_, err = r.DB.Exec(ctx, `
+			INSERT INTO warehouse_gatar_capacities (gatar_no, capacity)
+			VALUES ($1, $2)
+			ON CONFLICT (gatar_no) DO UPDATE SET capacity = EXCLUDED.capacity, updated_at = NOW()
+		`, gatarNo, capacity)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set gatar capacity: %w", err)
+	}
+	return nil
+}
+
+// FloorsInUse returns the floors of a room that have room entries
+func (r *WarehouseLayoutRepository) FloorsInUse(ctx context.Context, roomNo string) ([]string, error) {
+	rows, err := r.DB.Query(ctx, `SELECT DISTINCT floor FROM room_entries WHERE room_no = $1 ORDER BY floor`, roomNo)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check room entries: %w", err)
+	}
+	defer rows.Close()
+
+	var floors []string
+	for rows.Next() {
+		var floor string
+		if err := rows.Scan(&floor); err != nil {
+			return nil, fmt.Errorf("failed to scan floor: %w", err)
+		}
+		floors = append(floors, floor)
+	}
+	return floors, rows.Err()
+}
@@ -0,0 +1,285 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/timeutil"
+)
+
+// ErrPeriodClosed is returned for postings dated in a closed accounting period
+var ErrPeriodClosed = errors.New("accounting period is closed")
+
+// AccountingPeriodService closes monthly periods of the April-March fiscal year.
+// Closed periods always run from the first ledger month up to the latest closed
+// month, so the ledger is locked for every date on or before that month's end.
+type AccountingPeriodService struct {
+	Repo *repositories.AccountingPeriodRepository
+}
+
+func NewAccountingPeriodService(repo *repositories.AccountingPeriodRepository) *AccountingPeriodService {
+	return &AccountingPeriodService{Repo: repo}
+}
+
+// fiscalYearStart returns the calendar year the fiscal year containing t starts in (April)
+func fiscalYearStart(t time.Time) int {
+	if t.Month() >= time.April {
+		return t.Year()
+	}
+	return t.Year() - 1
+}
+
+// fiscalYearLabel formats a fiscal year as e.g. 2025-26
+func fiscalYearLabel(startYear int) string {
+	return fmt.Sprintf("%d-%02d", startYear, (startYear+1)%100)
+}
+
+// parseFiscalYear accepts 2025-26 or 2025 and returns the start year
+func parseFiscalYear(s string) (int, error) {
+	s = strings.TrimSpace(s)
+	if i := strings.Index(s, "-"); i > 0 {
+		s = s[:i]
+	}
+	year, err := strconv.Atoi(s)
+	if err != nil || year < 2000 || year > 2100 {
+		return 0, errors.New("invalid fiscal year. Use e.g. 2025-26")
+	}
+	return year, nil
+}
+
+// newPeriod builds the period for the month starting at monthStart
+func newPeriod(monthStart time.Time) *models.AccountingPeriod {
+	fy := fiscalYearStart(monthStart)
+	number := int(monthStart.Month()) - int(time.April) + 1
+	if number <= 0 {
+		number += 12
+	}
+	return &models.AccountingPeriod{
+		FiscalYear:   fiscalYearLabel(fy),
+		PeriodNumber: number,
+		StartDate:    monthStart,
+		EndDate:      monthStart.AddDate(0, 1, -1),
+		Status:       models.AccountingPeriodStatusOpen,
+	}
+}
+
+func monthStartOf(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// ListPeriods returns all twelve months of a fiscal year, closed or not
+func (s *AccountingPeriodService) ListPeriods(ctx context.Context, fiscalYear string) ([]*models.AccountingPeriod, error) {
+	startYear := fiscalYearStart(timeutil.Now())
+	if fiscalYear != "" {
+		var err error
+		if startYear, err = parseFiscalYear(fiscalYear); err != nil {
+			return nil, err
+		}
+	}
+
+	stored, err := s.Repo.ListByFiscalYear(ctx, fiscalYearLabel(startYear))
+	if err != nil {
+		return nil, err
+	}
+	byNumber := make(map[int]*models.AccountingPeriod, len(stored))
+	for _, p := range stored {
+		byNumber[p.PeriodNumber] = p
+	}
+	lockedThrough, err := s.LockedThrough(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	periods := make([]*models.AccountingPeriod, 0, 12)
+	month := time.Date(startYear, time.April, 1, 0, 0, 0, 0, time.UTC)
+	for i := 1; i <= 12; i++ {
+		if p, ok := byNumber[i]; ok {
+			periods = append(periods, p)
+		} else {
+			// Months before the first ledger entry are locked along with the closed periods
+			p := newPeriod(month)
+			if lockedThrough != nil && !p.EndDate.After(*lockedThrough) {
+				p.Status = models.AccountingPeriodStatusClosed
+			}
+			periods = append(periods, p)
+		}
+		month = month.AddDate(0, 1, 0)
+	}
+	return periods, nil
+}
+
+// GetPeriod returns a stored period
+func (s *AccountingPeriodService) GetPeriod(ctx context.Context, id int) (*models.AccountingPeriod, error) {
+	return s.Repo.Get(ctx, id)
+}
+
+// GetBalances returns the customer balance snapshot taken at close
+func (s *AccountingPeriodService) GetBalances(ctx context.Context, periodID int) ([]models.AccountingPeriodBalance, error) {
+	return s.Repo.ListBalances(ctx, periodID)
+}
+
+// LockedThrough returns the last locked date (nil when nothing is closed)
+func (s *AccountingPeriodService) LockedThrough(ctx context.Context) (*time.Time, error) {
+	latest, err := s.Repo.GetLatestClosed(ctx)
+	if err != nil || latest == nil {
+		return nil, err
+	}
+	return &latest.EndDate, nil
+}
+
+// CheckPostingDate rejects ledger postings dated in a closed period
+func (s *AccountingPeriodService) CheckPostingDate(ctx context.Context, date time.Time) error {
+	lockedThrough, err := s.LockedThrough(ctx)
+	if err != nil {
+		return err
+	}
+	if lockedThrough == nil {
+		return nil
+	}
+	if timeutil.FormatIST(date, "2006-01-02") <= lockedThrough.Format("2006-01-02") {
+		return fmt.Errorf("%w: books are closed through %s; post a reversal or correction in the current period instead",
+			ErrPeriodClosed, lockedThrough.Format("02 Jan 2006"))
+	}
+	return nil
+}
+
+// ClosePeriods closes every open month up to and including the requested month (or fiscal year end)
+func (s *AccountingPeriodService) ClosePeriods(ctx context.Context, req *models.ClosePeriodRequest, userID int) ([]*models.AccountingPeriod, error) {
+	var target time.Time
+	switch {
+	case req.Period != "":
+		t, err := time.Parse("2006-01", strings.TrimSpace(req.Period))
+		if err != nil {
+			return nil, errors.New("invalid period. Use YYYY-MM")
+		}
+		target = monthStartOf(t)
+	case req.FiscalYear != "":
+		startYear, err := parseFiscalYear(req.FiscalYear)
+		if err != nil {
+			return nil, err
+		}
+		target = time.Date(startYear+1, time.March, 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return nil, errors.New("period or fiscal_year is required")
+	}
+
+	// A month can only be closed once it has ended
+	today := timeutil.FormatIST(timeutil.Now(), "2006-01-02")
+	if newPeriod(target).EndDate.Format("2006-01-02") >= today {
+		return nil, fmt.Errorf("%s has not ended yet", target.Format("January 2006"))
+	}
+
+	latest, err := s.Repo.GetLatestClosed(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var from time.Time
+	if latest != nil {
+		if !target.After(latest.StartDate) {
+			return nil, fmt.Errorf("books are already closed through %s", latest.EndDate.Format("02 Jan 2006"))
+		}
+		from = monthStartOf(latest.StartDate).AddDate(0, 1, 0)
+	} else {
+		// First close covers every month since the ledger began
+		from = target
+		first, err := s.Repo.GetFirstLedgerDate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if first != nil {
+			if m := monthStartOf(timeutil.ToIST(*first)); m.Before(from) {
+				from = m
+			}
+		}
+	}
+
+	var closed []*models.AccountingPeriod
+	for month := from; !month.After(target); month = month.AddDate(0, 1, 0) {
+		p := newPeriod(month)
+		if err := s.Repo.Close(ctx, p, userID); err != nil {
+			return closed, err
+		}
+		closed = append(closed, p)
+	}
+	return closed, nil
+}
+
+// RequestReopen asks for a second admin to reopen the latest closed period
+func (s *AccountingPeriodService) RequestReopen(ctx context.Context, periodID int, reason string, userID int) (*models.PeriodReopenRequest, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+	if err := s.requireLatestClosed(ctx, periodID); err != nil {
+		return nil, err
+	}
+	pending, err := s.Repo.HasPendingReopen(ctx, periodID)
+	if err != nil {
+		return nil, err
+	}
+	if pending {
+		return nil, errors.New("there is already a pending reopen request for this period")
+	}
+
+	q := &models.PeriodReopenRequest{PeriodID: periodID, Reason: reason, RequestedBy: userID}
+	if err := s.Repo.CreateReopenRequest(ctx, q); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetReopenRequest(ctx, q.ID)
+}
+
+// ListReopenRequests returns reopen requests (expiring stale ones first)
+func (s *AccountingPeriodService) ListReopenRequests(ctx context.Context, status string, limit int) ([]*models.PeriodReopenRequest, error) {
+	_ = s.Repo.ExpireOldReopenRequests(ctx)
+	return s.Repo.ListReopenRequests(ctx, status, limit)
+}
+
+// GetReopenRequest returns a reopen request
+func (s *AccountingPeriodService) GetReopenRequest(ctx context.Context, id int) (*models.PeriodReopenRequest, error) {
+	return s.Repo.GetReopenRequest(ctx, id)
+}
+
+// ApproveReopen reopens the period; the approver must not be the requester
+func (s *AccountingPeriodService) ApproveReopen(ctx context.Context, id, approverID int) (*models.PeriodReopenRequest, error) {
+	q, err := s.Repo.GetReopenRequest(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if q.Status != models.PeriodReopenStatusPending {
+		return nil, errors.New("reopen request has already been processed")
+	}
+	if q.RequestedBy == approverID {
+		return nil, errors.New("you cannot approve your own request. Another admin must approve")
+	}
+	// Later periods may have been closed since the request was made
+	if err := s.requireLatestClosed(ctx, q.PeriodID); err != nil {
+		return nil, err
+	}
+	if err := s.Repo.ApproveReopen(ctx, id, approverID); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetReopenRequest(ctx, id)
+}
+
+// RejectReopen rejects a pending reopen request
+func (s *AccountingPeriodService) RejectReopen(ctx context.Context, id, reviewerID int, reason string) error {
+	return s.Repo.RejectReopen(ctx, id, reviewerID, strings.TrimSpace(reason))
+}
+
+// requireLatestClosed ensures periods are reopened newest first, keeping closed periods a prefix
+func (s *AccountingPeriodService) requireLatestClosed(ctx context.Context, periodID int) error {
+	latest, err := s.Repo.GetLatestClosed(ctx)
+	if err != nil {
+		return err
+	}
+	if latest == nil || latest.ID != periodID {
+		return errors.New("only the most recently closed period can be reopened")
+	}
+	return nil
+}
@@ -0,0 +1,376 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/timeutil"
+)
+
+// Ledger entries posted per batch when backfilling the journal
+const journalSyncBatchSize = 500
+
+// AccountingService keeps the double-entry journal behind the customer ledger.
+// Every money-moving ledger entry is posted as a balanced journal entry against the
+// Customer Receivables control account, and manual journals cover expenses and capital.
+type AccountingService struct {
+	Repo          *repositories.AccountingRepository
+	PeriodService *AccountingPeriodService
+}
+
+func NewAccountingService(repo *repositories.AccountingRepository) *AccountingService {
+	return &AccountingService{Repo: repo}
+}
+
+// SetPeriodService rejects manual journals dated in closed accounting periods
+func (s *AccountingService) SetPeriodService(periodService *AccountingPeriodService) {
+	s.PeriodService = periodService
+}
+
+// ledgerPostingAccounts returns the debit and credit accounts for a customer ledger entry type
+func ledgerPostingAccounts(entryType models.LedgerEntryType) (debit, credit string, ok bool) {
+	switch entryType {
+	case models.LedgerEntryTypeCharge:
+		return models.AccountCodeReceivables, models.AccountCodeRentIncome, true
+	case models.LedgerEntryTypeInterest:
+		return models.AccountCodeReceivables, models.AccountCodeInterestIncome, true
+	case models.LedgerEntryTypeRefund, models.LedgerEntryTypeLoanDisbursal:
+		return models.AccountCodeReceivables, models.AccountCodeCash, true
+	case models.LedgerEntryTypePayment, models.LedgerEntryTypeLoanRepayment:
+		return models.AccountCodeCash, models.AccountCodeReceivables, true
+	case models.LedgerEntryTypeOnlinePayment:
+		return models.AccountCodeRazorpayClearing, models.AccountCodeReceivables, true
+	case models.LedgerEntryTypeCredit:
+		return models.AccountCodeDiscounts, models.AccountCodeReceivables, true
+	}
+	return "", "", false
+}
+
+// ledgerEntryAccounts is ledgerPostingAccounts with non-cash counter payments
+// (cheque, NEFT/RTGS, UPI to bank) received into Bank instead of Cash, and refunds
+// sent by bank transfer or through a payment gateway paid out of Bank or Razorpay Clearing
+func ledgerEntryAccounts(entryType models.LedgerEntryType, paymentMode string) (debit, credit string, ok bool) {
+	debit, credit, ok = ledgerPostingAccounts(entryType)
+	if paymentMode == "" || paymentMode == models.PaymentModeCash {
+		return debit, credit, ok
+	}
+	switch entryType {
+	case models.LedgerEntryTypePayment:
+		debit = models.AccountCodeBank
+	case models.LedgerEntryTypeRefund:
+		credit = models.AccountCodeBank
+		if models.IsGatewayPaymentMode(paymentMode) {
+			credit = models.AccountCodeRazorpayClearing
+		}
+	}
+	return debit, credit, ok
+}
+
+// PostLedgerEntry posts a customer ledger entry to the journal.
+// Entries with no money movement (e.g. DEBT_APPROVAL) are skipped; re-posting is a no-op.
+func (s *AccountingService) PostLedgerEntry(ctx context.Context, entry *models.LedgerEntry) (bool, error) {
+	if entry.EntryType == models.LedgerEntryTypeReversal {
+		return s.postReversal(ctx, entry)
+	}
+
+	amount := roundMoney(entry.Debit + entry.Credit)
+	debitCode, creditCode, ok := ledgerEntryAccounts(entry.EntryType, entry.PaymentMode)
+	if !ok || amount <= 0 {
+		return false, nil
+	}
+
+	debitAccount, err := s.Repo.GetAccountByCode(ctx, debitCode)
+	if err != nil {
+		return false, err
+	}
+	creditAccount, err := s.Repo.GetAccountByCode(ctx, creditCode)
+	if err != nil {
+		return false, err
+	}
+
+	description := entry.Description
+	if description == "" {
+		description = string(entry.EntryType)
+	}
+	sourceID := entry.ID
+	journal := &models.JournalEntry{
+		EntryDate:       entry.CreatedAt,
+		Description:     fmt.Sprintf("%s - %s (%s)", description, entry.CustomerName, entry.CustomerPhone),
+		SourceType:      models.JournalSourceLedgerEntry,
+		SourceID:        &sourceID,
+		CustomerPhone:   entry.CustomerPhone,
+		CreatedByUserID: entry.CreatedByUserID,
+		Lines: []models.JournalLine{
+			{AccountID: debitAccount.ID, Debit: amount},
+			{AccountID: creditAccount.ID, Credit: amount},
+		},
+	}
+	return s.Repo.CreateJournal(ctx, journal)
+}
+
+// postReversal posts a REVERSAL ledger entry as the mirror image of the voided entry's journal
+func (s *AccountingService) postReversal(ctx context.Context, entry *models.LedgerEntry) (bool, error) {
+	if entry.ReversalOfID == nil {
+		return false, nil
+	}
+	original, err := s.Repo.GetJournalBySource(ctx, models.JournalSourceLedgerEntry, *entry.ReversalOfID)
+	if err != nil {
+		return false, err
+	}
+	if original == nil {
+		return false, fmt.Errorf("voided ledger entry #%d has no journal posting to reverse", *entry.ReversalOfID)
+	}
+
+	sourceID := entry.ID
+	journal := &models.JournalEntry{
+		EntryDate:       entry.CreatedAt,
+		Description:     fmt.Sprintf("%s - %s (%s)", entry.Description, entry.CustomerName, entry.CustomerPhone),
+		SourceType:      models.JournalSourceLedgerEntry,
+		SourceID:        &sourceID,
+		CustomerPhone:   entry.CustomerPhone,
+		CreatedByUserID: entry.CreatedByUserID,
+	}
+	for _, line := range original.Lines {
+		journal.Lines = append(journal.Lines, models.JournalLine{
+			AccountID: line.AccountID,
+			Debit:     line.Credit,
+			Credit:    line.Debit,
+		})
+	}
+	return s.Repo.CreateJournal(ctx, journal)
+}
+
+// SyncLedger posts every ledger entry that is not yet in the journal (backfill / repair)
+func (s *AccountingService) SyncLedger(ctx context.Context) (*models.JournalSyncResult, error) {
+	result := &models.JournalSyncResult{}
+	afterID := 0
+	for {
+		entries, err := s.Repo.ListUnpostedLedgerEntries(ctx, afterID, journalSyncBatchSize)
+		if err != nil {
+			return result, err
+		}
+		if len(entries) == 0 {
+			return result, nil
+		}
+		for i := range entries {
+			posted, err := s.PostLedgerEntry(ctx, &entries[i])
+			if err != nil {
+				return result, fmt.Errorf("ledger entry #%d: %w", entries[i].ID, err)
+			}
+			if posted {
+				result.Posted++
+			} else {
+				result.Skipped++
+			}
+			afterID = entries[i].ID
+		}
+	}
+}
+
+// ListAccounts returns the chart of accounts
+func (s *AccountingService) ListAccounts(ctx context.Context, activeOnly bool) ([]*models.Account, error) {
+	return s.Repo.ListAccounts(ctx, activeOnly)
+}
+
+// CreateAccount adds a (non-system) account, e.g. a new expense head
+func (s *AccountingService) CreateAccount(ctx context.Context, req *models.CreateAccountRequest) (*models.Account, error) {
+	account := &models.Account{
+		Code:        strings.TrimSpace(req.Code),
+		Name:        strings.TrimSpace(req.Name),
+		AccountType: strings.ToLower(strings.TrimSpace(req.AccountType)),
+		Description: strings.TrimSpace(req.Description),
+	}
+	if account.Code == "" || account.Name == "" {
+		return nil, errors.New("code and name are required")
+	}
+	switch account.AccountType {
+	case models.AccountTypeAsset, models.AccountTypeLiability, models.AccountTypeEquity,
+		models.AccountTypeIncome, models.AccountTypeExpense:
+	default:
+		return nil, errors.New("account type must be asset, liability, equity, income or expense")
+	}
+	if err := s.Repo.CreateAccount(ctx, account); err != nil {
+		return nil, err
+	}
+	return account, nil
+}
+
+// ListJournals returns journal entries
+func (s *AccountingService) ListJournals(ctx context.Context, filter *models.JournalFilter) ([]*models.JournalEntry, error) {
+	return s.Repo.ListJournals(ctx, filter)
+}
+
+// GetJournal returns a journal entry with its lines
+func (s *AccountingService) GetJournal(ctx context.Context, id int) (*models.JournalEntry, error) {
+	return s.Repo.GetJournal(ctx, id)
+}
+
+// CreateManualJournal records a balanced manual journal (expenses, capital, adjustments)
+func (s *AccountingService) CreateManualJournal(ctx context.Context, req *models.CreateJournalRequest, userID int) (*models.JournalEntry, error) {
+	description := strings.TrimSpace(req.Description)
+	if description == "" {
+		return nil, errors.New("description is required")
+	}
+	if len(req.Lines) < 2 {
+		return nil, errors.New("a journal needs at least two lines")
+	}
+
+	entryDate := timeutil.Now()
+	if req.EntryDate != "" {
+		parsed, err := timeutil.ParseInIST("2006-01-02", req.EntryDate)
+		if err != nil {
+			return nil, errors.New("invalid entry_date. Use YYYY-MM-DD")
+		}
+		entryDate = parsed
+	}
+	if s.PeriodService != nil {
+		if err := s.PeriodService.CheckPostingDate(ctx, entryDate); err != nil {
+			return nil, err
+		}
+	}
+
+	journal := &models.JournalEntry{
+		EntryDate:       entryDate,
+		Description:     description,
+		SourceType:      models.JournalSourceManual,
+		CreatedByUserID: userID,
+	}
+	var totalDebit, totalCredit float64
+	for i, in := range req.Lines {
+		debit, credit := roundMoney(in.Debit), roundMoney(in.Credit)
+		if debit < 0 || credit < 0 || (debit > 0) == (credit > 0) {
+			return nil, fmt.Errorf("line %d must have either a debit or a credit amount", i+1)
+		}
+		account, err := s.Repo.GetAccountByCode(ctx, strings.TrimSpace(in.AccountCode))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: account %q not found", i+1, in.AccountCode)
+		}
+		if !account.IsActive {
+			return nil, fmt.Errorf("line %d: account %s is inactive", i+1, account.Code)
+		}
+		journal.Lines = append(journal.Lines, models.JournalLine{
+			AccountID:   account.ID,
+			AccountCode: account.Code,
+			AccountName: account.Name,
+			Debit:       debit,
+			Credit:      credit,
+			Description: strings.TrimSpace(in.Description),
+		})
+		totalDebit += debit
+		totalCredit += credit
+	}
+	if math.Abs(totalDebit-totalCredit) > 0.005 {
+		return nil, fmt.Errorf("journal is not balanced: debits ₹%.2f, credits ₹%.2f", totalDebit, totalCredit)
+	}
+
+	if _, err := s.Repo.CreateJournal(ctx, journal); err != nil {
+		return nil, err
+	}
+	return s.Repo.GetJournal(ctx, journal.ID)
+}
+
+// TrialBalance returns every account's totals up to asOf
+func (s *AccountingService) TrialBalance(ctx context.Context, asOf time.Time) (*models.TrialBalance, error) {
+	balances, err := s.Repo.GetAccountBalances(ctx, nil, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	tb := &models.TrialBalance{AsOf: asOf, Accounts: []models.AccountBalance{}}
+	for _, b := range balances {
+		if b.Debit == 0 && b.Credit == 0 {
+			continue
+		}
+		// Show each account's net balance on its debit or credit side
+		net := roundMoney(b.Debit - b.Credit)
+		b.Debit, b.Credit = math.Max(net, 0), math.Max(-net, 0)
+		b.Balance = normalBalance(b)
+		tb.TotalDebit += b.Debit
+		tb.TotalCredit += b.Credit
+		tb.Accounts = append(tb.Accounts, b)
+	}
+	tb.TotalDebit = roundMoney(tb.TotalDebit)
+	tb.TotalCredit = roundMoney(tb.TotalCredit)
+	tb.Balanced = math.Abs(tb.TotalDebit-tb.TotalCredit) < 0.005
+	return tb, nil
+}
+
+// ProfitAndLoss returns income less expenses between from and to
+func (s *AccountingService) ProfitAndLoss(ctx context.Context, from, to time.Time) (*models.ProfitAndLoss, error) {
+	balances, err := s.Repo.GetAccountBalances(ctx, &from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	pl := &models.ProfitAndLoss{From: from, To: to, Income: []models.AccountBalance{}, Expenses: []models.AccountBalance{}}
+	for _, b := range balances {
+		b.Balance = normalBalance(b)
+		switch b.AccountType {
+		case models.AccountTypeIncome:
+			pl.Income = append(pl.Income, b)
+			pl.TotalIncome += b.Balance
+		case models.AccountTypeExpense:
+			pl.Expenses = append(pl.Expenses, b)
+			pl.TotalExpenses += b.Balance
+		}
+	}
+	pl.TotalIncome = roundMoney(pl.TotalIncome)
+	pl.TotalExpenses = roundMoney(pl.TotalExpenses)
+	pl.NetProfit = roundMoney(pl.TotalIncome - pl.TotalExpenses)
+	return pl, nil
+}
+
+// BalanceSheet returns assets against liabilities and equity at asOf.
+// Income and expense to date appear as retained earnings.
+func (s *AccountingService) BalanceSheet(ctx context.Context, asOf time.Time) (*models.BalanceSheet, error) {
+	balances, err := s.Repo.GetAccountBalances(ctx, nil, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	bs := &models.BalanceSheet{
+		AsOf:        asOf,
+		Assets:      []models.AccountBalance{},
+		Liabilities: []models.AccountBalance{},
+		Equity:      []models.AccountBalance{},
+	}
+	for _, b := range balances {
+		b.Balance = normalBalance(b)
+		switch b.AccountType {
+		case models.AccountTypeAsset:
+			bs.Assets = append(bs.Assets, b)
+			bs.TotalAssets += b.Balance
+		case models.AccountTypeLiability:
+			bs.Liabilities = append(bs.Liabilities, b)
+			bs.TotalLiabilities += b.Balance
+		case models.AccountTypeEquity:
+			bs.Equity = append(bs.Equity, b)
+			bs.TotalEquity += b.Balance
+		case models.AccountTypeIncome:
+			bs.RetainedEarnings += b.Balance
+		case models.AccountTypeExpense:
+			bs.RetainedEarnings -= b.Balance
+		}
+	}
+	bs.RetainedEarnings = roundMoney(bs.RetainedEarnings)
+	bs.TotalAssets = roundMoney(bs.TotalAssets)
+	bs.TotalLiabilities = roundMoney(bs.TotalLiabilities)
+	bs.TotalEquity = roundMoney(bs.TotalEquity + bs.RetainedEarnings)
+	bs.Balanced = math.Abs(bs.TotalAssets-bs.TotalLiabilities-bs.TotalEquity) < 0.005
+	return bs, nil
+}
+
+// normalBalance returns an account's balance in its normal direction:
+// debit - credit for assets and expenses, credit - debit for everything else
+func normalBalance(b models.AccountBalance) float64 {
+	if b.AccountType == models.AccountTypeAsset || b.AccountType == models.AccountTypeExpense {
+		return roundMoney(b.Debit - b.Credit)
+	}
+	return roundMoney(b.Credit - b.Debit)
+}
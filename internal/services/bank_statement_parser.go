@@ -0,0 +1,312 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"cold-backend/internal/models"
+)
+
+// statementColumns holds the column index of each field in a statement (-1 when absent)
+type statementColumns struct {
+	date, valueDate, narration, reference, credit, debit, amount, drcr, balance int
+}
+
+// ParseBankStatement reads the credits from a bank statement export (.csv or .xlsx).
+// The header row is found by its column names, so the preamble most banks put above
+// the table (account number, period, ...) is skipped.
+func ParseBankStatement(fileName string, data []byte) ([]*models.BankStatementLine, error) {
+	var rows [][]string
+	var err error
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".csv", ".txt":
+		rows, err = readCSVRows(data)
+	case ".xlsx":
+		rows, err = readXLSXRows(data)
+	case ".xls":
+		return nil, errors.New("old .xls files are not supported, save the statement as .xlsx or .csv")
+	default:
+		return nil, errors.New("unsupported file type, upload a .csv or .xlsx statement")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	headerRow, cols := -1, statementColumns{}
+	for i := 0; i < len(rows) && i < 40; i++ {
+		if c, ok := detectStatementColumns(rows[i]); ok {
+			headerRow, cols = i, c
+			break
+		}
+	}
+	if headerRow < 0 {
+		return nil, errors.New("could not find the statement header row (need a date and a credit/deposit column)")
+	}
+
+	var lines []*models.BankStatementLine
+	for _, row := range rows[headerRow+1:] {
+		date, ok := parseStatementDate(cell(row, cols.date))
+		if !ok {
+			date, ok = parseStatementDate(cell(row, cols.valueDate))
+		}
+		if !ok {
+			continue // Blank, opening balance or footer rows
+		}
+
+		var amount float64
+		if cols.credit >= 0 {
+			amount = parseStatementAmount(cell(row, cols.credit))
+		} else {
+			amount = parseStatementAmount(cell(row, cols.amount))
+			if !strings.HasPrefix(strings.ToUpper(strings.TrimSpace(cell(row, cols.drcr))), "C") {
+				continue
+			}
+		}
+		if amount <= 0 {
+			continue // Debits
+		}
+
+		line := &models.BankStatementLine{
+			TxnDate:   date,
+			Narration: strings.Join(strings.Fields(cell(row, cols.narration)), " "),
+			Reference: strings.TrimSpace(cell(row, cols.reference)),
+			Amount:    roundMoney(amount),
+		}
+		sum := sha256.Sum256([]byte(strings.Join([]string{
+			date.Format("2006-01-02"), strconv.FormatFloat(line.Amount, 'f', 2, 64),
+			line.Narration, line.Reference, strings.TrimSpace(cell(row, cols.balance)),
+		}, "|")))
+		line.Fingerprint = hex.EncodeToString(sum[:])
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return nil, errors.New("no credits found in the statement")
+	}
+	return lines, nil
+}
+
+// detectStatementColumns maps the column names of a candidate header row
+func detectStatementColumns(row []string) (statementColumns, bool) {
+	c := statementColumns{-1, -1, -1, -1, -1, -1, -1, -1, -1}
+	set := func(idx *int, i int) {
+		if *idx < 0 {
+			*idx = i
+		}
+	}
+	for i, raw := range row {
+		h := strings.ToLower(strings.Join(strings.Fields(raw), " "))
+		switch {
+		case h == "":
+		case strings.Contains(h, "balance"):
+			set(&c.balance, i)
+		case strings.Contains(h, "withdrawal") || strings.Contains(h, "debit") || h == "dr":
+			set(&c.debit, i)
+		case strings.Contains(h, "deposit") || strings.Contains(h, "credit") || h == "cr":
+			set(&c.credit, i)
+		case h == "dr/cr" || h == "cr/dr" || h == "dr / cr" || h == "cr / dr" || h == "type":
+			set(&c.drcr, i)
+		case strings.Contains(h, "narration") || strings.Contains(h, "description") || strings.Contains(h, "particulars") ||
+			strings.Contains(h, "remarks") || strings.Contains(h, "details"):
+			set(&c.narration, i)
+		case strings.Contains(h, "ref") || strings.Contains(h, "chq") || strings.Contains(h, "cheque") || strings.Contains(h, "utr"):
+			set(&c.reference, i)
+		case strings.Contains(h, "value"):
+			set(&c.valueDate, i)
+		case strings.Contains(h, "date") || h == "dt" || strings.HasSuffix(h, " dt"):
+			set(&c.date, i)
+		case strings.Contains(h, "amount") || strings.Contains(h, "amt"):
+			set(&c.amount, i)
+		}
+	}
+	if c.date < 0 {
+		c.date = c.valueDate
+	}
+	ok := c.date >= 0 && (c.credit >= 0 || (c.amount >= 0 && c.drcr >= 0))
+	return c, ok
+}
+
+func cell(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return row[i]
+}
+
+var statementDateLayouts = []string{
+	"02/01/2006", "02-01-2006", "02.01.2006", "02/01/06", "02-01-06",
+	"02-Jan-2006", "02 Jan 2006", "02-Jan-06", "02 Jan 06", "2 Jan 2006",
+	"2006-01-02", "2006/01/02",
+	"02/01/2006 15:04:05", "02-01-2006 15:04:05", "2006-01-02 15:04:05",
+}
+
+// parseStatementDate parses the day-first dates Indian banks export, and Excel serial dates
+func parseStatementDate(v string) (time.Time, bool) {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		return time.Time{}, false
+	}
+	for _, layout := range statementDateLayouts {
+		if t, err := time.Parse(layout, v); err == nil {
+			return t, true
+		}
+	}
+	// Excel stores dates as days since 1899-12-30
+	if serial, err := strconv.ParseFloat(v, 64); err == nil && serial > 30000 && serial < 80000 {
+		return time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC).AddDate(0, 0, int(serial)), true
+	}
+	return time.Time{}, false
+}
+
+// parseStatementAmount parses "1,25,000.00", "500.00 Cr" and similar; "Dr" amounts are negative
+func parseStatementAmount(v string) float64 {
+	v = strings.ToUpper(strings.TrimSpace(v))
+	sign := 1.0
+	if strings.HasSuffix(v, "DR") {
+		sign = -1
+	}
+	v = strings.NewReplacer(",", "", " ", "", "INR", "", "RS.", "", "₹", "", "CR", "", "DR", "").Replace(v)
+	if v == "" || v == "-" {
+		return 0
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		return 0
+	}
+	return sign * f
+}
+
+func readCSVRows(data []byte) ([][]string, error) {
+	r := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+	rows, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV: %w", err)
+	}
+	return rows, nil
+}
+
+// readXLSXRows reads the first worksheet of an .xlsx workbook as text cells
+func readXLSXRows(data []byte) ([][]string, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open XLSX: %w", err)
+	}
+
+	var sheet, shared *zip.File
+	for _, f := range zr.File {
+		switch {
+		case f.Name == "xl/sharedStrings.xml":
+			shared = f
+		case strings.HasPrefix(f.Name, "xl/worksheets/sheet") && strings.HasSuffix(f.Name, ".xml"):
+			if sheet == nil || f.Name == "xl/worksheets/sheet1.xml" {
+				sheet = f
+			}
+		}
+	}
+	if sheet == nil {
+		return nil, errors.New("XLSX has no worksheet")
+	}
+
+	var strs []string
+	if shared != nil {
+		var sst struct {
+			Items []struct {
+				T    string `xml:"t"`
+				Runs []struct {
+					T string `xml:"t"`
+				} `xml:"r"`
+			} `xml:"si"`
+		}
+		if err := decodeZipXML(shared, &sst); err != nil {
+			return nil, err
+		}
+		for _, si := range sst.Items {
+			s := si.T
+			for _, r := range si.Runs {
+				s += r.T
+			}
+			strs = append(strs, s)
+		}
+	}
+
+	var ws struct {
+		Rows []struct {
+			Cells []struct {
+				Ref    string `xml:"r,attr"`
+				Type   string `xml:"t,attr"`
+				Value  string `xml:"v"`
+				Inline string `xml:"is>t"`
+			} `xml:"c"`
+		} `xml:"sheetData>row"`
+	}
+	if err := decodeZipXML(sheet, &ws); err != nil {
+		return nil, err
+	}
+
+	rows := make([][]string, 0, len(ws.Rows))
+	for _, wr := range ws.Rows {
+		var row []string
+		for i, c := range wr.Cells {
+			col := xlsxColumn(c.Ref)
+			if col < 0 {
+				col = i
+			}
+			for len(row) <= col {
+				row = append(row, "")
+			}
+			switch c.Type {
+			case "s":
+				if n, err := strconv.Atoi(c.Value); err == nil && n >= 0 && n < len(strs) {
+					row[col] = strs[n]
+				}
+			case "inlineStr":
+				row[col] = c.Inline
+			default:
+				row[col] = c.Value
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func decodeZipXML(f *zip.File, v interface{}) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+	if err := xml.NewDecoder(io.LimitReader(rc, 64<<20)).Decode(v); err != nil {
+		return fmt.Errorf("failed to parse %s: %w", f.Name, err)
+	}
+	return nil
+}
+
+// xlsxColumn converts the letters of a cell reference ("C12") to a zero-based column index
+func xlsxColumn(ref string) int {
+	col := 0
+	n := 0
+	for _, ch := range ref {
+		if ch < 'A' || ch > 'Z' {
+			break
+		}
+		col = col*26 + int(ch-'A'+1)
+		n++
+	}
+	if n == 0 {
+		return -1
+	}
+	return col - 1
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/timeutil"
+)
+
+// Ledger reference type for credits assigned from the reconciliation queue
+const BankCreditReferenceType = "bank_credit"
+
+// BankStatementService imports bank statements, matches their credits to known payments
+// and runs the reconciliation queue for the ones it cannot match
+type BankStatementService struct {
+	Repo               *repositories.BankStatementRepository
+	RentPaymentService *RentPaymentService
+	LedgerService      *LedgerService
+	CustomerRepo       *repositories.CustomerRepository
+}
+
+func NewBankStatementService(repo *repositories.BankStatementRepository, rentPaymentService *RentPaymentService, ledgerService *LedgerService, customerRepo *repositories.CustomerRepository) *BankStatementService {
+	return &BankStatementService{
+		Repo:               repo,
+		RentPaymentService: rentPaymentService,
+		LedgerService:      ledgerService,
+		CustomerRepo:       customerRepo,
+	}
+}
+
+// Import parses a statement file, stores its new credits and matches each one.
+// Rows imported from an earlier statement are counted as duplicates and skipped.
+func (s *BankStatementService) Import(ctx context.Context, fileName string, data []byte, userID int) (*models.BankStatementImport, []*models.BankStatementLine, error) {
+	parsed, err := ParseBankStatement(fileName, data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	id, err := s.Repo.CreateImport(ctx, fileName, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	imp := &models.BankStatementImport{ID: id, FileName: fileName, CreditCount: len(parsed), ImportedByUserID: userID}
+
+	var lines []*models.BankStatementLine
+	for _, line := range parsed {
+		line.ImportID = id
+		inserted, err := s.Repo.InsertLine(ctx, line)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !inserted {
+			imp.DuplicateCount++
+			continue
+		}
+		if err := s.match(ctx, line, userID); err != nil {
+			// Leave it in the queue; the accountant can still assign it by hand
+			log.Printf("[BankStatement] Failed to match line %d: %v", line.ID, err)
+		}
+		if line.Status == models.BankLineStatusMatched {
+			imp.MatchedCount++
+		} else {
+			imp.UnmatchedCount++
+		}
+		lines = append(lines, line)
+	}
+
+	if err := s.Repo.FinishImport(ctx, imp); err != nil {
+		return nil, nil, err
+	}
+	return imp, lines, nil
+}
+
+// match reconciles a credit by UTR against online transactions and bank-transfer payments,
+// then by amount with the cheque number or customer phone against pending cheques. A
+// matching pending cheque is cleared as of the statement date. Unmatched credits keep the
+// customer whose phone appears in the narration as a suggestion.
+func (s *BankStatementService) match(ctx context.Context, line *models.BankStatementLine, userID int) error {
+	refs, phones := narrationTokens(line.Narration + " " + line.Reference)
+
+	if len(refs) > 0 {
+		tx, err := s.Repo.FindOnlineTransactionByUTR(ctx, refs, line.Amount)
+		if err != nil {
+			return err
+		}
+		if tx != nil {
+			line.MatchType = models.BankMatchOnlineTransaction
+			line.OnlineTransactionID = &tx.ID
+			return s.setMatched(ctx, line, tx.CustomerPhone, tx.CustomerName)
+		}
+
+		payment, err := s.Repo.FindRentPaymentByUTR(ctx, refs, line.Amount)
+		if err != nil {
+			return err
+		}
+		if payment != nil {
+			line.MatchType = models.BankMatchRentPayment
+			line.RentPaymentID = &payment.ID
+			return s.setMatched(ctx, line, payment.CustomerPhone, payment.CustomerName)
+		}
+	}
+
+	if s.RentPaymentService != nil {
+		cheques, err := s.Repo.ListPendingChequesByAmount(ctx, line.Amount)
+		if err != nil {
+			return err
+		}
+		if cheque := pickCheque(cheques, refs, phones); cheque != nil {
+			if _, err := s.RentPaymentService.ClearCheque(ctx, cheque.ID, line.TxnDate.Format("2006-01-02"), userID); err != nil {
+				log.Printf("[BankStatement] Could not clear cheque %s for line %d: %v", cheque.ChequeNumber, line.ID, err)
+			} else {
+				line.MatchType = models.BankMatchCheque
+				line.RentPaymentID = &cheque.ID
+				return s.setMatched(ctx, line, cheque.CustomerPhone, cheque.CustomerName)
+			}
+		}
+	}
+
+	if len(phones) > 0 {
+		customers, err := s.Repo.FindCustomersByPhone(ctx, phones)
+		if err != nil {
+			return err
+		}
+		if len(customers) == 1 {
+			line.SuggestedPhone = customers[0].Phone
+			line.SuggestedName = customers[0].Name
+			return s.Repo.SetSuggestion(ctx, line.ID, line.SuggestedPhone, line.SuggestedName)
+		}
+	}
+	return nil
+}
+
+func (s *BankStatementService) setMatched(ctx context.Context, line *models.BankStatementLine, phone, name string) error {
+	line.CustomerPhone = phone
+	line.CustomerName = name
+	if err := s.Repo.SetMatched(ctx, line); err != nil {
+		return err
+	}
+	line.Status = models.BankLineStatusMatched
+	return nil
+}
+
+// pickCheque returns the only pending cheque whose number, or failing that whose
+// customer's phone, appears in the narration
+func pickCheque(cheques []*models.RentPayment, refs, phones []string) *models.RentPayment {
+	var byNumber, byPhone []*models.RentPayment
+	for _, c := range cheques {
+		number := strings.TrimLeft(strings.ToUpper(c.ChequeNumber), "0")
+		for _, ref := range refs {
+			if number != "" && strings.TrimLeft(ref, "0") == number {
+				byNumber = append(byNumber, c)
+				break
+			}
+		}
+		for _, phone := range phones {
+			if c.CustomerPhone == phone {
+				byPhone = append(byPhone, c)
+				break
+			}
+		}
+	}
+	if len(byNumber) == 1 {
+		return byNumber[0]
+	}
+	if len(byNumber) == 0 && len(byPhone) == 1 {
+		return byPhone[0]
+	}
+	return nil
+}
+
+// narrationTokens extracts candidate UTR/cheque references (uppercase, at least six
+// characters with a digit) and 10-digit mobile numbers from a narration
+func narrationTokens(text string) (refs, phones []string) {
+	seen := make(map[string]bool)
+	for _, tok := range strings.FieldsFunc(strings.ToUpper(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if seen[tok] {
+			continue
+		}
+		seen[tok] = true
+
+		if len(tok) >= 6 && strings.ContainsAny(tok, "0123456789") {
+			refs = append(refs, tok)
+		}
+		if isDigits(tok) {
+			switch {
+			case len(tok) == 10 && tok[0] >= '6':
+				phones = append(phones, tok)
+			case len(tok) == 12 && strings.HasPrefix(tok, "91") && tok[2] >= '6':
+				phones = append(phones, tok[2:])
+			}
+		}
+	}
+	return refs, phones
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// transferMode guesses how a bank credit was sent from its narration
+func transferMode(narration string) string {
+	n := strings.ToUpper(narration)
+	switch {
+	case strings.Contains(n, "UPI"):
+		return models.PaymentModeUPI
+	case strings.Contains(n, "RTGS"):
+		return models.PaymentModeRTGS
+	case strings.Contains(n, "CHQ") || strings.Contains(n, "CHEQUE") || strings.Contains(n, "CLG"):
+		return models.PaymentModeCheque
+	}
+	return models.PaymentModeNEFT
+}
+
+// Assign posts an unmatched credit to a customer's ledger as a PAYMENT dated on the statement day
+func (s *BankStatementService) Assign(ctx context.Context, id int, req *models.AssignBankLineRequest, userID int) (*models.BankStatementLine, error) {
+	if s.LedgerService == nil || s.CustomerRepo == nil {
+		return nil, errors.New("ledger is not available")
+	}
+	phone := strings.TrimSpace(req.CustomerPhone)
+	if phone == "" {
+		return nil, errors.New("customer phone is required")
+	}
+	customer, err := s.CustomerRepo.GetByPhone(ctx, phone)
+	if err != nil || customer == nil {
+		return nil, fmt.Errorf("customer with phone %s not found", phone)
+	}
+
+	line, err := s.Repo.GetLine(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if line.Status != models.BankLineStatusUnmatched {
+		return nil, fmt.Errorf("bank credit is already %s", line.Status)
+	}
+
+	var entryDate *time.Time
+	if day, err := timeutil.ParseInIST("2006-01-02", line.TxnDate.Format("2006-01-02")); err == nil &&
+		day.Before(timeutil.StartOfDay(timeutil.Now())) {
+		entryDate = &day
+	}
+	description := "Bank credit received"
+	if line.Reference != "" {
+		description += " - Ref " + line.Reference
+	}
+	payment := &models.CreateLedgerEntryRequest{
+		CustomerPhone:    customer.Phone,
+		CustomerName:     customer.Name,
+		CustomerSO:       customer.SO,
+		EntryType:        models.LedgerEntryTypePayment,
+		Description:      description,
+		Credit:           line.Amount,
+		ReferenceID:      &line.ID,
+		ReferenceType:    BankCreditReferenceType,
+		FamilyMemberID:   req.FamilyMemberID,
+		FamilyMemberName: req.FamilyMemberName,
+		CreatedByUserID:  userID,
+		Notes:            line.Narration,
+		EntryDate:        entryDate,
+		PaymentMode:      transferMode(line.Narration),
+	}
+	if err := s.LedgerService.PrepareEntry(ctx, payment); err != nil {
+		return nil, err
+	}
+
+	// The line is claimed, credited and linked together, so it is never left assigned
+	// without its PAYMENT
+	entry, ok, err := s.Repo.Assign(ctx, id, customer.Phone, customer.Name, userID, strings.TrimSpace(req.Notes), payment)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("bank credit is no longer in the queue")
+	}
+	s.LedgerService.EntriesPosted(ctx, entry)
+	return s.Repo.GetLine(ctx, id)
+}
+
+// Ignore removes a credit that is not a customer payment from the queue
+func (s *BankStatementService) Ignore(ctx context.Context, id int, notes string, userID int) (*models.BankStatementLine, error) {
+	notes = strings.TrimSpace(notes)
+	if notes == "" {
+		return nil, errors.New("a note explaining the credit is required")
+	}
+	ok, err := s.Repo.MarkIgnored(ctx, id, userID, notes)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("bank credit is not in the queue")
+	}
+	return s.Repo.GetLine(ctx, id)
+}
+
+// ListLines returns statement credits by status and/or import
+func (s *BankStatementService) ListLines(ctx context.Context, status string, importID int) ([]*models.BankStatementLine, error) {
+	return s.Repo.ListLines(ctx, status, importID)
+}
+
+// ListImports returns recent statement imports
+func (s *BankStatementService) ListImports(ctx context.Context, limit int) ([]*models.BankStatementImport, error) {
+	if limit <= 0 || limit > 200 {
+		limit = 30
+	}
+	return s.Repo.ListImports(ctx, limit)
+}
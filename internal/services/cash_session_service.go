@@ -0,0 +1,116 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+)
+
+// CashSessionService runs each accountant's cash drawer: open with a float, take
+// payments against the session, close with a denomination count
+type CashSessionService struct {
+	Repo *repositories.CashSessionRepository
+}
+
+func NewCashSessionService(repo *repositories.CashSessionRepository) *CashSessionService {
+	return &CashSessionService{Repo: repo}
+}
+
+// Open opens the user's drawer with an opening float
+func (s *CashSessionService) Open(ctx context.Context, userID int, openingFloat float64) (*models.CashSession, error) {
+	if openingFloat < 0 {
+		return nil, errors.New("opening float cannot be negative")
+	}
+	current, err := s.Repo.GetOpenByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if current != nil {
+		return nil, fmt.Errorf("you already have a cash session open since %s", current.OpenedAt.Format("02 Jan 2006 03:04 PM"))
+	}
+
+	id, err := s.Repo.Create(ctx, userID, roundMoney(openingFloat))
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.Get(ctx, id)
+}
+
+// GetCurrent returns the user's open session with live totals (nil if none)
+func (s *CashSessionService) GetCurrent(ctx context.Context, userID int) (*models.CashSession, error) {
+	return s.Repo.GetOpenByUser(ctx, userID)
+}
+
+// Close counts the user's drawer and records the difference from expected cash
+func (s *CashSessionService) Close(ctx context.Context, userID int, req *models.CloseCashSessionRequest) (*models.CashSession, error) {
+	current, err := s.Repo.GetOpenByUser(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if current == nil {
+		return nil, errors.New("you have no open cash session")
+	}
+
+	denominations, counted, err := countDenominations(req.Denominations)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.Close(ctx, current.ID, counted, denominations, strings.TrimSpace(req.Notes)); err != nil {
+		return nil, err
+	}
+	return s.Repo.Get(ctx, current.ID)
+}
+
+// countDenominations validates a closing count and returns it merged by value, largest first
+func countDenominations(counts []models.CashDenomination) ([]models.CashDenomination, float64, error) {
+	byValue := make(map[int]int)
+	for _, d := range counts {
+		if d.Count < 0 {
+			return nil, 0, fmt.Errorf("count for ₹%d cannot be negative", d.Value)
+		}
+		valid := false
+		for _, v := range models.CashDenominationValues {
+			if d.Value == v {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, 0, fmt.Errorf("invalid denomination: ₹%d", d.Value)
+		}
+		byValue[d.Value] += d.Count
+	}
+
+	var merged []models.CashDenomination
+	var total float64
+	for _, v := range models.CashDenominationValues {
+		if n := byValue[v]; n > 0 {
+			merged = append(merged, models.CashDenomination{Value: v, Count: n})
+			total += float64(v * n)
+		}
+	}
+	return merged, total, nil
+}
+
+// Get returns a session with the payments taken in it
+func (s *CashSessionService) Get(ctx context.Context, id int) (*models.CashSession, []*models.RentPayment, error) {
+	session, err := s.Repo.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	payments, err := s.Repo.ListPayments(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return session, payments, nil
+}
+
+// List returns sessions active in [from, to], optionally for one user
+func (s *CashSessionService) List(ctx context.Context, from, to time.Time, userID int) ([]*models.CashSession, error) {
+	return s.Repo.List(ctx, from, to, userID)
+}
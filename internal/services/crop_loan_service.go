@@ -0,0 +1,505 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/timeutil"
+)
+
+// Ledger reference type for crop loan disbursals, repayments and loan interest
+const CropLoanReferenceType = "crop_loan"
+
+// Days in a loan interest month (monthly rate / 30 per day)
+const loanDaysPerMonth = 30
+
+// CropLoanService manages cash advances against stored stock.
+// The loan is a LOAN_DISBURSAL debit, interest accrues daily on the outstanding principal
+// and is posted as INTEREST, and repayments settle interest first, then principal.
+// A pledged thock cannot be released until the loan is settled or a debt request is approved.
+type CropLoanService struct {
+	LoanRepo      *repositories.CropLoanRepository
+	EntryRepo     *repositories.EntryRepository
+	RoomEntryRepo *repositories.RoomEntryRepository
+	PickupRepo    *repositories.GatePassPickupRepository
+	DebtRepo      *repositories.DebtRequestRepository
+	LedgerService *LedgerService
+	SettingRepo   *repositories.SystemSettingRepository
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	loanMu   sync.Mutex
+}
+
+func NewCropLoanService(
+	loanRepo *repositories.CropLoanRepository,
+	entryRepo *repositories.EntryRepository,
+	roomEntryRepo *repositories.RoomEntryRepository,
+	pickupRepo *repositories.GatePassPickupRepository,
+	debtRepo *repositories.DebtRequestRepository,
+	ledgerService *LedgerService,
+	settingRepo *repositories.SystemSettingRepository,
+) *CropLoanService {
+	return &CropLoanService{
+		LoanRepo:      loanRepo,
+		EntryRepo:     entryRepo,
+		RoomEntryRepo: roomEntryRepo,
+		PickupRepo:    pickupRepo,
+		DebtRepo:      debtRepo,
+		LedgerService: ledgerService,
+		SettingRepo:   settingRepo,
+		stopChan:      make(chan struct{}),
+	}
+}
+
+// ListLoans returns loans, optionally filtered by status, customer phone and thock
+func (s *CropLoanService) ListLoans(ctx context.Context, status, customerPhone, thockNumber string) ([]*models.CropLoan, error) {
+	return s.LoanRepo.List(ctx, status, customerPhone, thockNumber)
+}
+
+// GetLoan returns a loan with its repayments
+func (s *CropLoanService) GetLoan(ctx context.Context, id int) (*models.CropLoanDetail, error) {
+	loan, err := s.LoanRepo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	repayments, err := s.LoanRepo.ListRepayments(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if repayments == nil {
+		repayments = []*models.CropLoanRepayment{}
+	}
+	return &models.CropLoanDetail{Loan: loan, Repayments: repayments}, nil
+}
+
+// ListRepayments returns a loan's repayments
+func (s *CropLoanService) ListRepayments(ctx context.Context, loanID int) ([]*models.CropLoanRepayment, error) {
+	return s.LoanRepo.ListRepayments(ctx, loanID)
+}
+
+// GetEligibility works out how much can still be lent against a thock
+func (s *CropLoanService) GetEligibility(ctx context.Context, thockNumber string) (*models.CropLoanEligibility, error) {
+	thockNumber = strings.TrimSpace(thockNumber)
+	if thockNumber == "" {
+		return nil, errors.New("thock number is required")
+	}
+
+	stored, err := storedThockQuantity(ctx, s.RoomEntryRepo, s.PickupRepo, thockNumber)
+	if err != nil {
+		return nil, err
+	}
+	active, err := s.LoanRepo.ListActiveByThock(ctx, thockNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &models.CropLoanEligibility{
+		ThockNumber:    thockNumber,
+		StoredQuantity: stored,
+		ValuePerBag:    s.floatSetting(ctx, "loan_value_per_bag", 0),
+		MaxLTVPercent:  s.floatSetting(ctx, "loan_max_ltv_percent", 60),
+	}
+	for _, loan := range active {
+		e.ExistingOutstanding += loan.PrincipalOutstanding
+	}
+	e.StockValue = roundMoney(float64(stored) * e.ValuePerBag)
+	e.MaxLoan = roundMoney(e.StockValue * e.MaxLTVPercent / 100)
+	e.ExistingOutstanding = roundMoney(e.ExistingOutstanding)
+	e.AvailableLoan = roundMoney(math.Max(e.MaxLoan-e.ExistingOutstanding, 0))
+	return e, nil
+}
+
+// Disburse checks the loan-to-value limit, records the loan and posts the LOAN_DISBURSAL debit
+func (s *CropLoanService) Disburse(ctx context.Context, req *models.CreateCropLoanRequest, userID int) (*models.CropLoan, error) {
+	s.loanMu.Lock()
+	defer s.loanMu.Unlock()
+
+	if req.Principal <= 0 {
+		return nil, errors.New("principal must be greater than zero")
+	}
+	if req.PledgedQuantity < 0 {
+		return nil, errors.New("pledged quantity cannot be negative")
+	}
+	if req.InterestRateMonthly != nil && *req.InterestRateMonthly < 0 {
+		return nil, errors.New("interest rate cannot be negative")
+	}
+
+	entry, err := s.EntryRepo.GetByThockNumber(ctx, strings.TrimSpace(req.ThockNumber))
+	if err != nil {
+		return nil, errors.New("thock not found")
+	}
+
+	eligibility, err := s.GetEligibility(ctx, entry.ThockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if eligibility.ValuePerBag <= 0 {
+		return nil, errors.New("loan value per bag is not configured (loan_value_per_bag setting)")
+	}
+	if eligibility.StoredQuantity <= 0 {
+		return nil, errors.New("no stock stored against this thock")
+	}
+
+	pledged := req.PledgedQuantity
+	if pledged == 0 {
+		pledged = eligibility.StoredQuantity
+	}
+	if pledged > eligibility.StoredQuantity {
+		return nil, fmt.Errorf("pledged quantity (%d) exceeds stored stock (%d)", pledged, eligibility.StoredQuantity)
+	}
+
+	pledgedLimit := roundMoney(float64(pledged) * eligibility.ValuePerBag * eligibility.MaxLTVPercent / 100)
+	principal := roundMoney(req.Principal)
+	if principal > pledgedLimit {
+		return nil, fmt.Errorf("loan of ₹%.2f exceeds %.0f%% of the pledged stock value (max ₹%.2f)",
+			principal, eligibility.MaxLTVPercent, pledgedLimit)
+	}
+	if principal > eligibility.AvailableLoan {
+		return nil, fmt.Errorf("loan of ₹%.2f exceeds the available limit for this thock (₹%.2f after ₹%.2f already outstanding)",
+			principal, eligibility.AvailableLoan, eligibility.ExistingOutstanding)
+	}
+
+	rate := s.floatSetting(ctx, "loan_interest_rate_monthly", 0)
+	if req.InterestRateMonthly != nil {
+		rate = *req.InterestRateMonthly
+	}
+
+	loan := &models.CropLoan{
+		CustomerID:           entry.CustomerID,
+		CustomerPhone:        entry.Phone,
+		CustomerName:         entry.Name,
+		CustomerSO:           entry.SO,
+		FamilyMemberID:       entry.FamilyMemberID,
+		FamilyMemberName:     entry.FamilyMemberName,
+		EntryID:              entry.ID,
+		ThockNumber:          entry.ThockNumber,
+		PledgedQuantity:      pledged,
+		ValuePerBag:          eligibility.ValuePerBag,
+		MaxLTVPercent:        eligibility.MaxLTVPercent,
+		Principal:            principal,
+		InterestRateMonthly:  rate,
+		PrincipalOutstanding: principal,
+		InterestAccruedTo:    timeutil.StartOfDay(timeutil.Now()),
+		Status:               models.CropLoanStatusActive,
+		Notes:                strings.TrimSpace(req.Notes),
+		DisbursedByUserID:    userID,
+	}
+	// The loan and its ledger debit are written together - no loan without the debit
+	disbursal := func(loanID int) *models.CreateLedgerEntryRequest {
+		return &models.CreateLedgerEntryRequest{
+			CustomerPhone:    loan.CustomerPhone,
+			CustomerName:     loan.CustomerName,
+			CustomerSO:       loan.CustomerSO,
+			EntryType:        models.LedgerEntryTypeLoanDisbursal,
+			Description:      fmt.Sprintf("Crop loan #%d against thock %s (%d bags pledged)", loanID, loan.ThockNumber, pledged),
+			Debit:            principal,
+			ReferenceID:      &loanID,
+			ReferenceType:    CropLoanReferenceType,
+			FamilyMemberID:   loan.FamilyMemberID,
+			FamilyMemberName: loan.FamilyMemberName,
+			CreatedByUserID:  userID,
+			Notes:            fmt.Sprintf("Interest %.2f%% per month", rate),
+		}
+	}
+	if err := s.LedgerService.PrepareEntry(ctx, disbursal(0)); err != nil {
+		return nil, err
+	}
+	ledgerEntry, err := s.LoanRepo.Disburse(ctx, loan, disbursal)
+	if err != nil {
+		return nil, err
+	}
+	s.LedgerService.EntriesPosted(ctx, ledgerEntry)
+	return s.LoanRepo.Get(ctx, loan.ID)
+}
+
+// Repay accrues interest to today and applies a repayment to interest first, then principal
+func (s *CropLoanService) Repay(ctx context.Context, loanID int, req *models.CropLoanRepaymentRequest, userID int) (*models.CropLoan, *models.CropLoanRepayment, error) {
+	s.loanMu.Lock()
+	defer s.loanMu.Unlock()
+
+	amount := roundMoney(req.Amount)
+	if amount <= 0 {
+		return nil, nil, errors.New("amount must be greater than zero")
+	}
+
+	loan, err := s.LoanRepo.Get(ctx, loanID)
+	if err != nil {
+		return nil, nil, errors.New("crop loan not found")
+	}
+	if loan.Status != models.CropLoanStatusActive {
+		return nil, nil, errors.New("crop loan is already settled")
+	}
+
+	if err := s.accrue(ctx, loan, timeutil.StartOfDay(timeutil.Now()), userID); err != nil {
+		return nil, nil, err
+	}
+
+	outstanding := roundMoney(loan.PrincipalOutstanding + loan.InterestOutstanding)
+	if amount > outstanding {
+		return nil, nil, fmt.Errorf("amount exceeds the outstanding loan balance (₹%.2f)", outstanding)
+	}
+
+	repayment := &models.CropLoanRepayment{
+		LoanID:           loan.ID,
+		Amount:           amount,
+		InterestPortion:  roundMoney(math.Min(amount, loan.InterestOutstanding)),
+		ReceivedByUserID: userID,
+		Notes:            strings.TrimSpace(req.Notes),
+	}
+	repayment.PrincipalPortion = roundMoney(amount - repayment.InterestPortion)
+
+	entry := &models.CreateLedgerEntryRequest{
+		CustomerPhone:    loan.CustomerPhone,
+		CustomerName:     loan.CustomerName,
+		CustomerSO:       loan.CustomerSO,
+		EntryType:        models.LedgerEntryTypeLoanRepayment,
+		Description:      fmt.Sprintf("Crop loan #%d repayment (thock %s)", loan.ID, loan.ThockNumber),
+		Credit:           amount,
+		ReferenceID:      &loan.ID,
+		ReferenceType:    CropLoanReferenceType,
+		FamilyMemberID:   loan.FamilyMemberID,
+		FamilyMemberName: loan.FamilyMemberName,
+		CreatedByUserID:  userID,
+		Notes: fmt.Sprintf("Interest ₹%.2f, principal ₹%.2f. %s",
+			repayment.InterestPortion, repayment.PrincipalPortion, repayment.Notes),
+	}
+	if err := s.LedgerService.PrepareEntry(ctx, entry); err != nil {
+		return nil, nil, err
+	}
+
+	loan.InterestOutstanding = roundMoney(loan.InterestOutstanding - repayment.InterestPortion)
+	loan.PrincipalOutstanding = roundMoney(loan.PrincipalOutstanding - repayment.PrincipalPortion)
+	loan.TotalRepaid = roundMoney(loan.TotalRepaid + amount)
+	if loan.PrincipalOutstanding <= 0 && loan.InterestOutstanding <= 0 {
+		now := timeutil.Now()
+		loan.PrincipalOutstanding = 0
+		loan.InterestOutstanding = 0
+		loan.Status = models.CropLoanStatusSettled
+		loan.SettledAt = &now
+	}
+	// The ledger credit, the repayment and the new balances are written together
+	ledgerEntry, err := s.LoanRepo.Repay(ctx, loan, repayment, entry)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.LedgerService.EntriesPosted(ctx, ledgerEntry)
+	return loan, repayment, nil
+}
+
+// AccrueAll posts interest on every active loan up to the given date.
+// Returns the number of loans charged and the total interest posted.
+func (s *CropLoanService) AccrueAll(ctx context.Context, to time.Time, userID int) (int, float64, error) {
+	s.loanMu.Lock()
+	defer s.loanMu.Unlock()
+
+	loans, err := s.LoanRepo.List(ctx, models.CropLoanStatusActive, "", "")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	count, total := 0, 0.0
+	for _, loan := range loans {
+		before := loan.InterestOutstanding
+		if err := s.accrue(ctx, loan, to, userID); err != nil {
+			return count, roundMoney(total), fmt.Errorf("crop loan #%d: %w", loan.ID, err)
+		}
+		if charged := loan.InterestOutstanding - before; charged > 0 {
+			count++
+			total += charged
+		}
+	}
+	return count, roundMoney(total), nil
+}
+
+// IsPledged reports whether a thock has an active crop loan against it
+func (s *CropLoanService) IsPledged(ctx context.Context, thockNumber string) bool {
+	loans, err := s.LoanRepo.ListActiveByThock(ctx, thockNumber)
+	return err == nil && len(loans) > 0
+}
+
+// ActiveLoanID returns the newest active crop loan against a thock, or nil
+func (s *CropLoanService) ActiveLoanID(ctx context.Context, thockNumber string) *int {
+	loans, err := s.LoanRepo.ListActiveByThock(ctx, thockNumber)
+	if err != nil || len(loans) == 0 {
+		return nil
+	}
+	return &loans[0].ID
+}
+
+// CheckThockRelease blocks a gate pass for a pledged thock. Stock can only leave while a loan
+// is active if an admin approved a debt request raised against that loan (gatePassID also
+// accepts an approval already used by that gate pass). Approvals from before the loan, or
+// for another loan, do not count.
+func (s *CropLoanService) CheckThockRelease(ctx context.Context, thockNumber string, gatePassID *int) error {
+	loans, err := s.LoanRepo.ListActiveByThock(ctx, thockNumber)
+	if err != nil {
+		return err
+	}
+	if len(loans) == 0 {
+		return nil
+	}
+	loan := loans[0]
+
+	requests, err := s.DebtRepo.GetByCustomer(ctx, loan.CustomerPhone)
+	if err != nil {
+		return err
+	}
+	active := make(map[int]bool)
+	for _, l := range loans {
+		active[l.ID] = true
+	}
+	for _, dr := range requests {
+		if dr.ThockNumber != thockNumber || dr.CropLoanID == nil || !active[*dr.CropLoanID] {
+			continue
+		}
+		if dr.Status == models.DebtRequestStatusApproved {
+			return nil
+		}
+		if dr.Status == models.DebtRequestStatusUsed && gatePassID != nil && dr.GatePassID != nil && *dr.GatePassID == *gatePassID {
+			return nil
+		}
+	}
+
+	outstanding := 0.0
+	for _, l := range loans {
+		outstanding += l.PrincipalOutstanding + l.InterestOutstanding
+	}
+	return fmt.Errorf("thock %s is pledged against crop loan #%d (₹%.2f outstanding) - settle the loan or get a debt request approved before releasing stock",
+		thockNumber, loan.ID, roundMoney(outstanding))
+}
+
+// Start runs the monthly scheduler that posts loan interest up to the end of the previous month
+func (s *CropLoanService) Start() {
+	log.Println("[CropLoan] Starting crop loan interest scheduler...")
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.autoAccrue()
+			case <-s.stopChan:
+				log.Println("[CropLoan] Stopping crop loan interest scheduler...")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler
+func (s *CropLoanService) Stop() {
+	close(s.stopChan)
+	s.wg.Wait()
+}
+
+// autoAccrue posts interest through the end of last month for loans not yet accrued that far
+func (s *CropLoanService) autoAccrue() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	now := timeutil.Now()
+	monthStart := timeutil.StartOfDay(now.AddDate(0, 0, 1-now.Day()))
+	count, total, err := s.AccrueAll(ctx, monthStart, 0)
+	if err != nil {
+		log.Printf("[CropLoan] Interest accrual failed: %v", err)
+		return
+	}
+	if count > 0 {
+		log.Printf("[CropLoan] Posted interest on %d loans: ₹%.2f", count, total)
+	}
+}
+
+// accrue posts simple interest on the outstanding principal from the last accrual date to `to`
+func (s *CropLoanService) accrue(ctx context.Context, loan *models.CropLoan, to time.Time, userID int) error {
+	days := daysBetween(loan.InterestAccruedTo, to)
+	if days <= 0 {
+		return nil
+	}
+
+	interest := roundMoney(loan.PrincipalOutstanding * loan.InterestRateMonthly / 100 / loanDaysPerMonth * float64(days))
+	var entry *models.CreateLedgerEntryRequest
+	if interest > 0 {
+		entry = &models.CreateLedgerEntryRequest{
+			CustomerPhone:    loan.CustomerPhone,
+			CustomerName:     loan.CustomerName,
+			CustomerSO:       loan.CustomerSO,
+			EntryType:        models.LedgerEntryTypeInterest,
+			Description:      fmt.Sprintf("Crop loan #%d interest (thock %s)", loan.ID, loan.ThockNumber),
+			Debit:            interest,
+			ReferenceID:      &loan.ID,
+			ReferenceType:    CropLoanReferenceType,
+			FamilyMemberID:   loan.FamilyMemberID,
+			FamilyMemberName: loan.FamilyMemberName,
+			CreatedByUserID:  userID,
+			Notes: fmt.Sprintf("%d days on ₹%.2f at %.2f%% per month (%s to %s)",
+				days, loan.PrincipalOutstanding, loan.InterestRateMonthly,
+				timeutil.FormatIST(loan.InterestAccruedTo, "02-01-2006"), timeutil.FormatIST(to, "02-01-2006")),
+		}
+		if err := s.LedgerService.PrepareEntry(ctx, entry); err != nil {
+			return err
+		}
+	}
+
+	// The interest debit and the loan's new balances are written together
+	accrued := *loan
+	if interest > 0 {
+		accrued.InterestOutstanding = roundMoney(loan.InterestOutstanding + interest)
+		accrued.TotalInterest = roundMoney(loan.TotalInterest + interest)
+	}
+	accrued.InterestAccruedTo = to
+	ledgerEntry, err := s.LoanRepo.Accrue(ctx, &accrued, entry)
+	if err != nil {
+		return err
+	}
+	*loan = accrued
+	if ledgerEntry != nil {
+		s.LedgerService.EntriesPosted(ctx, ledgerEntry)
+	}
+	return nil
+}
+
+// storedThockQuantity returns the bags of a thock still in storage (stored - picked up)
+func storedThockQuantity(ctx context.Context, roomEntryRepo *repositories.RoomEntryRepository, pickupRepo *repositories.GatePassPickupRepository, thockNumber string) (int, error) {
+	stored, err := roomEntryRepo.GetTotalQuantityByThockNumber(ctx, thockNumber)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get stored quantity: %w", err)
+	}
+	pickups, err := pickupRepo.GetPickupsByThockNumber(ctx, thockNumber)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pickups: %w", err)
+	}
+	for _, p := range pickups {
+		stored -= p.PickupQuantity
+	}
+	if stored < 0 {
+		stored = 0
+	}
+	return stored, nil
+}
+
+// floatSetting reads a numeric setting, falling back to def when unset or invalid
+func (s *CropLoanService) floatSetting(ctx context.Context, key string, def float64) float64 {
+	setting, err := s.SettingRepo.Get(ctx, key)
+	if err != nil || setting == nil {
+		return def
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(setting.SettingValue), 64)
+	if err != nil || v < 0 {
+		return def
+	}
+	return v
+}
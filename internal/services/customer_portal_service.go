@@ -3,7 +3,6 @@ package services
 import (
 	"context"
 	"fmt"
-	"strconv"
 
 	"cold-backend/internal/models"
 	"cold-backend/internal/repositories"
@@ -18,6 +17,8 @@ type CustomerPortalService struct {
 	SystemSettingRepo  *repositories.SystemSettingRepository
 	GatePassPickupRepo *repositories.GatePassPickupRepository
 	LedgerRepo         *repositories.LedgerRepository
+	TariffService      *RentTariffService
+	WalletService      *WalletService
 }
 
 func NewCustomerPortalService(
@@ -39,9 +40,20 @@ func NewCustomerPortalService(
 		SystemSettingRepo:  systemSettingRepo,
 		GatePassPickupRepo: gatePassPickupRepo,
 		LedgerRepo:         ledgerRepo,
+		TariffService:      NewRentTariffService(nil, systemSettingRepo), // Default rate only until SetTariffService
 	}
 }
 
+// SetTariffService sets the rent tariff service used to price thocks
+func (s *CustomerPortalService) SetTariffService(tariffService *RentTariffService) {
+	s.TariffService = tariffService
+}
+
+// SetWalletService shows the customer's advance deposit wallet on the dashboard
+func (s *CustomerPortalService) SetWalletService(walletService *WalletService) {
+	s.WalletService = walletService
+}
+
 // ThockInfo represents dashboard data for a single truck
 type ThockInfo struct {
 	ThockNumber      string  `json:"thock_number"`
@@ -75,6 +87,7 @@ type DashboardData struct {
 	TotalRent    float64                  `json:"total_rent"`
 	TotalPaid    float64                  `json:"total_paid"`
 	TotalBalance float64                  `json:"total_balance"`
+	Wallet       *models.Wallet           `json:"wallet,omitempty"` // Only for customers who have deposited
 }
 
 // GetDashboardData returns all dashboard data for a customer
@@ -85,16 +98,8 @@ func (s *CustomerPortalService) GetDashboardData(ctx context.Context, customerID
 		return nil, fmt.Errorf("failed to get customer: %w", err)
 	}
 
-	// Get rent_per_item setting from system settings
-	var systemRentPerItem float64 = 0.0 // No hardcoded fallback - use API only
-	if s.SystemSettingRepo != nil {
-		rentSetting, err := s.SystemSettingRepo.Get(ctx, "rent_per_item")
-		if err == nil && rentSetting != nil {
-			if parsed, parseErr := strconv.ParseFloat(rentSetting.SettingValue, 64); parseErr == nil {
-				systemRentPerItem = parsed
-			}
-		}
-	}
+	// Load rent rate cards (falls back to the default rent_per_item rate)
+	rateCard, _ := s.TariffService.LoadRateCard(ctx)
 
 	// Get all entries for this customer
 	entries, err := s.EntryRepo.ListByCustomer(ctx, customerID)
@@ -140,8 +145,6 @@ func (s *CustomerPortalService) GetDashboardData(ctx context.Context, customerID
 	var totalRent, totalPaid, totalBalance float64
 
 	// For each entry, calculate truck info
-	rentPerItem := systemRentPerItem
-
 	for _, entry := range entries {
 		// Get family member ID (0 if not assigned)
 		fmID := 0
@@ -175,7 +178,8 @@ func (s *CustomerPortalService) GetDashboardData(ctx context.Context, customerID
 		// Get pending quantity from gate passes
 		pendingQty, _ := s.GatePassRepo.GetPendingQuantityForEntry(ctx, entry.ID)
 
-		// Calculate rent for this entry
+		// Calculate rent for this entry from its rate card
+		rentPerItem := rateCard.RateForEntry(entry)
 		entryTotalRent := float64(originalEntered) * rentPerItem
 
 		// Calculate effective available inventory
@@ -272,6 +276,14 @@ func (s *CustomerPortalService) GetDashboardData(ctx context.Context, customerID
 		}
 	}
 
+	// Get wallet balance and statement (advance deposits)
+	var wallet *models.Wallet
+	if s.WalletService != nil {
+		if w, err := s.WalletService.GetWallet(ctx, customer.Phone, true); err == nil {
+			wallet = w
+		}
+	}
+
 	return &DashboardData{
 		Customer:     customer,
 		Trucks:       trucks,
@@ -280,6 +292,7 @@ func (s *CustomerPortalService) GetDashboardData(ctx context.Context, customerID
 		TotalRent:    totalRent,
 		TotalPaid:    totalPaid,
 		TotalBalance: totalBalance,
+		Wallet:       wallet,
 	}, nil
 }
 
@@ -301,16 +314,8 @@ func (s *CustomerPortalService) CreateGatePassRequest(ctx context.Context, custo
 		return nil, fmt.Errorf("customer not found")
 	}
 
-	// Get rent_per_item setting
-	var rentPerItem float64 = 0.0
-	if s.SystemSettingRepo != nil {
-		rentSetting, err := s.SystemSettingRepo.Get(ctx, "rent_per_item")
-		if err == nil && rentSetting != nil {
-			if parsed, parseErr := strconv.ParseFloat(rentSetting.SettingValue, 64); parseErr == nil {
-				rentPerItem = parsed
-			}
-		}
-	}
+	// Get rent rate for this thock from its rate card
+	rentPerItem := s.TariffService.QuoteEntry(ctx, entry).SeasonRate()
 
 	// Get ORIGINAL entered quantity from room entries
 	originalEntered, err := s.RoomEntryRepo.GetTotalQuantityByThockNumber(ctx, request.ThockNumber)
@@ -9,8 +9,10 @@ import (
 )
 
 type DebtService struct {
-	DebtRepo      *repositories.DebtRequestRepository
-	LedgerService *LedgerService
+	DebtRepo          *repositories.DebtRequestRepository
+	LedgerService     *LedgerService
+	CropLoanService   *CropLoanService
+	AllocationService *PaymentAllocationService
 }
 
 func NewDebtService(debtRepo *repositories.DebtRequestRepository, ledgerService *LedgerService) *DebtService {
@@ -20,6 +22,37 @@ func NewDebtService(debtRepo *repositories.DebtRequestRepository, ledgerService
 	}
 }
 
+// SetCropLoanService makes thocks pledged against a crop loan require debt approval
+func (s *DebtService) SetCropLoanService(cropLoanService *CropLoanService) {
+	s.CropLoanService = cropLoanService
+}
+
+// SetAllocationService makes gate pass checks use the outstanding of the thock being
+// withdrawn instead of the customer's whole balance
+func (s *DebtService) SetAllocationService(allocationService *PaymentAllocationService) {
+	s.AllocationService = allocationService
+}
+
+// outstandingBalance returns the balance that blocks a withdrawal. When payments are
+// allocated per thock it is the thock's own outstanding plus the customer's charges not
+// tied to any thock (interest and the like). A thock that has never been charged - rent
+// is charged at pickup - falls back to the customer's whole balance.
+func (s *DebtService) outstandingBalance(ctx context.Context, customerPhone, thockNumber string) (bool, float64, error) {
+	if s.AllocationService != nil && thockNumber != "" {
+		summary, err := s.AllocationService.GetCustomerSummary(ctx, customerPhone)
+		if err != nil {
+			return false, 0, err
+		}
+		for _, t := range summary.Thocks {
+			if t.ThockNumber == thockNumber {
+				balance := roundMoney(t.Outstanding + summary.OtherOutstanding)
+				return balance > 0, balance, nil
+			}
+		}
+	}
+	return s.LedgerService.HasOutstandingBalance(ctx, customerPhone)
+}
+
 // CreateRequest creates a new debt request
 func (s *DebtService) CreateRequest(ctx context.Context, req *models.CreateDebtRequestRequest, requestedByUserID int, requestedByName string) (*models.DebtRequest, error) {
 	// Try to get balance from ledger first
@@ -34,6 +67,11 @@ func (s *DebtService) CreateRequest(ctx context.Context, req *models.CreateDebtR
 	// If ledger has no balance but frontend sent balance > 0, trust it
 	// (balance might come from rent system which is separate from ledger)
 
+	// An approval for pledged stock is an approval against its loan
+	if s.CropLoanService != nil {
+		req.CropLoanID = s.CropLoanService.ActiveLoanID(ctx, req.ThockNumber)
+	}
+
 	return s.DebtRepo.Create(ctx, req, requestedByUserID, requestedByName)
 }
 
@@ -145,19 +183,22 @@ func (s *DebtService) GetApprovedForCustomerAndThock(ctx context.Context, custom
 
 // CanCreateGatePass checks if a gate pass can be created (either no balance or has debt approval)
 func (s *DebtService) CanCreateGatePass(ctx context.Context, customerPhone, thockNumber string) (bool, *models.DebtRequest, float64, error) {
-	// Check if customer has outstanding balance
-	hasBalance, balance, err := s.LedgerService.HasOutstandingBalance(ctx, customerPhone)
-	if err != nil {
+	// Thocks pledged against an active crop loan always need approval
+	pledged := s.CropLoanService != nil && s.CropLoanService.IsPledged(ctx, thockNumber)
+
+	// Check if the thock (or customer) has outstanding balance
+	hasBalance, balance, err := s.outstandingBalance(ctx, customerPhone, thockNumber)
+	if err != nil && !pledged {
 		// No ledger entries = no balance
 		return true, nil, 0, nil
 	}
 
-	if !hasBalance {
+	if !hasBalance && !pledged {
 		// No outstanding balance, can create gate pass
 		return true, nil, 0, nil
 	}
 
-	// Has balance - check for approved debt request
+	// Has balance (or pledged stock) - check for approved debt request
 	debtReq, err := s.GetApprovedForCustomerAndThock(ctx, customerPhone, thockNumber)
 	if err != nil {
 		return false, nil, balance, err
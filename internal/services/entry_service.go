@@ -153,6 +153,7 @@ func (s *EntryService) CreateEntry(ctx context.Context, req *models.CreateEntryR
 		ExpectedQuantity: req.ExpectedQuantity,
 		ThockCategory:    req.ThockCategory,
 		Remark:           req.Remark,
+		BagSize:          req.BagSize,
 		CreatedByUserID:  userID,
 	}
 
@@ -271,6 +272,9 @@ func (s *EntryService) UpdateEntry(ctx context.Context, id int, req *models.Upda
 	if req.ThockCategory != "" {
 		entry.ThockCategory = req.ThockCategory
 	}
+	if req.BagSize != "" {
+		entry.BagSize = req.BagSize
+	}
 
 	return s.EntryRepo.Update(ctx, entry, oldCategory, oldQty)
 }
@@ -0,0 +1,325 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+)
+
+// ErrMaintenanceScheduleNotFound is returned for an unknown schedule ID
+var ErrMaintenanceScheduleNotFound = errors.New("maintenance schedule not found")
+
+// GatarMaintenanceService schedules bag-turning, ventilation and inspection per room,
+// floor or gatar and works out which stocked gatars are due. A gatar's task falls due
+// interval_days after it was last done there - or, if it never was, after the later of
+// the gatar's oldest stock coming in and the schedule being created.
+type GatarMaintenanceService struct {
+	Repo          *repositories.GatarMaintenanceRepository
+	LayoutService *WarehouseLayoutService
+}
+
+func NewGatarMaintenanceService(repo *repositories.GatarMaintenanceRepository, layoutService *WarehouseLayoutService) *GatarMaintenanceService {
+	return &GatarMaintenanceService{
+		Repo:          repo,
+		LayoutService: layoutService,
+	}
+}
+
+// ListSchedules returns every schedule, active or not
+func (s *GatarMaintenanceService) ListSchedules(ctx context.Context) ([]*models.GatarMaintenanceSchedule, error) {
+	schedules, err := s.Repo.ListSchedules(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	if schedules == nil {
+		schedules = []*models.GatarMaintenanceSchedule{}
+	}
+	return schedules, nil
+}
+
+// CreateSchedule adds a schedule for a room, floor or gatar. A gatar's room and floor
+// are taken from the warehouse layout.
+func (s *GatarMaintenanceService) CreateSchedule(ctx context.Context, schedule *models.GatarMaintenanceSchedule, userID int) error {
+	schedule.RoomNo = strings.TrimSpace(schedule.RoomNo)
+	schedule.Floor = strings.TrimSpace(schedule.Floor)
+	if !validMaintenanceTask(schedule.Task) {
+		return fmt.Errorf("invalid task %q: use %s", schedule.Task, strings.Join(models.MaintenanceTasks, ", "))
+	}
+	if err := validateMaintenanceInterval(schedule.IntervalDays); err != nil {
+		return err
+	}
+
+	layout, err := s.LayoutService.Layout(ctx)
+	if err != nil {
+		return err
+	}
+	if schedule.GatarNo != 0 {
+		room, floor := layout.Locate(schedule.GatarNo)
+		if room == "" {
+			return fmt.Errorf("gatar %d is not in the warehouse layout", schedule.GatarNo)
+		}
+		if (schedule.RoomNo != "" && schedule.RoomNo != room) || (schedule.Floor != "" && schedule.Floor != floor) {
+			return fmt.Errorf("gatar %d is in room %s floor %s", schedule.GatarNo, room, floor)
+		}
+		schedule.RoomNo, schedule.Floor = room, floor
+	}
+	room := layout.Room(schedule.RoomNo)
+	if room == nil {
+		return fmt.Errorf("room %s is not in the warehouse layout", schedule.RoomNo)
+	}
+	if schedule.Floor != "" && room.Floor(schedule.Floor) == nil {
+		return fmt.Errorf("room %s has no floor %s", schedule.RoomNo, schedule.Floor)
+	}
+
+	existing, err := s.Repo.ListSchedules(ctx, false)
+	if err != nil {
+		return err
+	}
+	for _, e := range existing {
+		if e.RoomNo == schedule.RoomNo && e.Floor == schedule.Floor && e.GatarNo == schedule.GatarNo && e.Task == schedule.Task {
+			return fmt.Errorf("%s is already scheduled there (schedule %d)", schedule.Task, e.ID)
+		}
+	}
+
+	schedule.Active = true
+	schedule.CreatedByUserID = userID
+	return s.Repo.CreateSchedule(ctx, schedule)
+}
+
+// UpdateSchedule changes a schedule's interval, crew, notes and active flag. Its place
+// and task stay as they are.
+func (s *GatarMaintenanceService) UpdateSchedule(ctx context.Context, id int, update *models.GatarMaintenanceSchedule) (*models.GatarMaintenanceSchedule, error) {
+	schedule, err := s.Repo.GetSchedule(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if schedule == nil {
+		return nil, ErrMaintenanceScheduleNotFound
+	}
+	if err := validateMaintenanceInterval(update.IntervalDays); err != nil {
+		return nil, err
+	}
+
+	schedule.IntervalDays = update.IntervalDays
+	schedule.AssignedCrew = strings.TrimSpace(update.AssignedCrew)
+	schedule.Notes = strings.TrimSpace(update.Notes)
+	schedule.Active = update.Active
+	if err := s.Repo.UpdateSchedule(ctx, schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// DeleteSchedule removes a schedule
+func (s *GatarMaintenanceService) DeleteSchedule(ctx context.Context, id int) error {
+	schedule, err := s.Repo.GetSchedule(ctx, id)
+	if err != nil {
+		return err
+	}
+	if schedule == nil {
+		return ErrMaintenanceScheduleNotFound
+	}
+	return s.Repo.DeleteSchedule(ctx, id)
+}
+
+// Status returns when each stocked gatar is next due for each scheduled task, soonest
+// first. roomNo and floor narrow it down; blank means all.
+func (s *GatarMaintenanceService) Status(ctx context.Context, roomNo, floor string) ([]*models.GatarMaintenanceStatus, error) {
+	layout, err := s.LayoutService.Layout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := s.Repo.ListSchedules(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+	stocked, err := s.Repo.GetStockedSince(ctx)
+	if err != nil {
+		return nil, err
+	}
+	lastDone, err := s.Repo.GetLastDone(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	statuses := []*models.GatarMaintenanceStatus{}
+	for gatarNo, since := range stocked {
+		room, fl := layout.Locate(gatarNo)
+		if room == "" || (roomNo != "" && room != roomNo) || (floor != "" && fl != floor) {
+			continue
+		}
+		for _, schedule := range gatarSchedules(schedules, room, fl, gatarNo) {
+			status := &models.GatarMaintenanceStatus{
+				GatarNo:      gatarNo,
+				RoomNo:       room,
+				Floor:        fl,
+				Task:         schedule.Task,
+				ScheduleID:   schedule.ID,
+				IntervalDays: schedule.IntervalDays,
+				AssignedCrew: schedule.AssignedCrew,
+			}
+			from := since
+			if schedule.CreatedAt.After(from) {
+				from = schedule.CreatedAt
+			}
+			if done, ok := lastDone[gatarNo][schedule.Task]; ok {
+				status.LastDoneAt = &done
+				from = done
+			}
+			status.DueAt = from.AddDate(0, 0, schedule.IntervalDays)
+			if now.After(status.DueAt) {
+				status.Overdue = true
+				status.DaysOverdue = int(now.Sub(status.DueAt).Hours() / 24)
+			}
+			statuses = append(statuses, status)
+		}
+	}
+
+	sort.Slice(statuses, func(i, j int) bool {
+		if !statuses[i].DueAt.Equal(statuses[j].DueAt) {
+			return statuses[i].DueAt.Before(statuses[j].DueAt)
+		}
+		if statuses[i].GatarNo != statuses[j].GatarNo {
+			return statuses[i].GatarNo < statuses[j].GatarNo
+		}
+		return statuses[i].Task < statuses[j].Task
+	})
+	return statuses, nil
+}
+
+// Overdue returns the overdue tasks of each gatar, narrowed down like Status
+func (s *GatarMaintenanceService) Overdue(ctx context.Context, roomNo, floor string) (map[int][]string, error) {
+	statuses, err := s.Status(ctx, roomNo, floor)
+	if err != nil {
+		return nil, err
+	}
+	overdue := make(map[int][]string)
+	for _, status := range statuses {
+		if status.Overdue {
+			overdue[status.GatarNo] = append(overdue[status.GatarNo], status.Task)
+		}
+	}
+	return overdue, nil
+}
+
+// Complete records a task done in a gatar against the room entry gatar rows it covered.
+// A quality observed is set on the one room entry gatar it was seen on, so it needs
+// RoomEntryGatarID. The crew defaults to the schedule's.
+func (s *GatarMaintenanceService) Complete(ctx context.Context, req *models.CompleteMaintenanceRequest, userID int) ([]*models.GatarMaintenanceLog, error) {
+	if req.GatarNo <= 0 {
+		return nil, errors.New("gatar number is required")
+	}
+	if !validMaintenanceTask(req.Task) {
+		return nil, fmt.Errorf("invalid task %q: use %s", req.Task, strings.Join(models.MaintenanceTasks, ", "))
+	}
+	req.Quality = strings.ToUpper(strings.TrimSpace(req.Quality))
+	switch req.Quality {
+	case "", models.QualityNormal, models.QualityUnka, models.QualityDamaged, models.QualityGood:
+	default:
+		return nil, fmt.Errorf("invalid quality %q: use N, U, D or G", req.Quality)
+	}
+	if req.Quality != "" && req.RoomEntryGatarID == 0 {
+		return nil, errors.New("pick the room entry gatar whose quality was observed")
+	}
+
+	layout, err := s.LayoutService.Layout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	room, floor := layout.Locate(req.GatarNo)
+	if room == "" {
+		return nil, fmt.Errorf("gatar %d is not in the warehouse layout", req.GatarNo)
+	}
+	schedules, err := s.Repo.ListSchedules(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	log := &models.GatarMaintenanceLog{
+		GatarNo:         req.GatarNo,
+		Task:            req.Task,
+		ObservedQuality: req.Quality,
+		Crew:            strings.TrimSpace(req.Crew),
+		Notes:           strings.TrimSpace(req.Notes),
+		DoneByUserID:    userID,
+	}
+	for _, schedule := range gatarSchedules(schedules, room, floor, req.GatarNo) {
+		if schedule.Task == req.Task {
+			log.ScheduleID = schedule.ID
+			if log.Crew == "" {
+				log.Crew = schedule.AssignedCrew
+			}
+		}
+	}
+	return s.Repo.Complete(ctx, log, req.RoomEntryGatarID)
+}
+
+// ListLogs returns a gatar's maintenance log (every gatar when gatarNo is 0), newest first
+func (s *GatarMaintenanceService) ListLogs(ctx context.Context, gatarNo, limit int) ([]*models.GatarMaintenanceLog, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	logs, err := s.Repo.ListLogs(ctx, gatarNo, limit)
+	if err != nil {
+		return nil, err
+	}
+	if logs == nil {
+		logs = []*models.GatarMaintenanceLog{}
+	}
+	return logs, nil
+}
+
+// gatarSchedules returns the schedule that applies to a gatar for each task: a gatar's
+// own schedule over its floor's, and its floor's over its room's
+func gatarSchedules(schedules []*models.GatarMaintenanceSchedule, roomNo, floor string, gatarNo int) []*models.GatarMaintenanceSchedule {
+	best := make(map[string]*models.GatarMaintenanceSchedule)
+	rank := func(s *models.GatarMaintenanceSchedule) int {
+		r := 0
+		if s.Floor != "" {
+			r++
+		}
+		if s.GatarNo != 0 {
+			r += 2
+		}
+		return r
+	}
+	for _, s := range schedules {
+		if s.RoomNo != roomNo || (s.Floor != "" && s.Floor != floor) || (s.GatarNo != 0 && s.GatarNo != gatarNo) {
+			continue
+		}
+		if current, ok := best[s.Task]; !ok || rank(s) > rank(current) {
+			best[s.Task] = s
+		}
+	}
+
+	var applied []*models.GatarMaintenanceSchedule
+	for _, task := range models.MaintenanceTasks {
+		if s, ok := best[task]; ok {
+			applied = append(applied, s)
+		}
+	}
+	return applied
+}
+
+func validMaintenanceTask(task string) bool {
+	for _, t := range models.MaintenanceTasks {
+		if t == task {
+			return true
+		}
+	}
+	return false
+}
+
+func validateMaintenanceInterval(days int) error {
+	if days <= 0 || days > 365 {
+		return errors.New("interval must be between 1 and 365 days")
+	}
+	return nil
+}
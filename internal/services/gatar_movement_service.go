@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+)
+
+// GatarMovementService shifts bags of a thock between gatars (palti) and keeps each
+// gatar's movement ledger
+type GatarMovementService struct {
+	MovementRepo   *repositories.GatarMovementRepository
+	GatarRepo      *repositories.RoomEntryGatarRepository
+	EntryEventRepo *repositories.EntryEventRepository
+	LayoutService  *WarehouseLayoutService
+	SettingRepo    *repositories.SystemSettingRepository
+}
+
+func NewGatarMovementService(movementRepo *repositories.GatarMovementRepository, gatarRepo *repositories.RoomEntryGatarRepository, entryEventRepo *repositories.EntryEventRepository, layoutService *WarehouseLayoutService, settingRepo *repositories.SystemSettingRepository) *GatarMovementService {
+	return &GatarMovementService{
+		MovementRepo:   movementRepo,
+		GatarRepo:      gatarRepo,
+		EntryEventRepo: entryEventRepo,
+		LayoutService:  layoutService,
+		SettingRepo:    settingRepo,
+	}
+}
+
+// Transfer moves bags of a thock from one gatar to another. The destination must be in
+// the warehouse layout; overfilling it is a warning, or an error when the
+// gatar_overflow_policy setting is "block".
+func (s *GatarMovementService) Transfer(ctx context.Context, req *models.GatarTransferRequest, userID int) (*models.GatarMovement, error) {
+	req.ThockNumber = strings.TrimSpace(req.ThockNumber)
+	if req.ThockNumber == "" {
+		return nil, errors.New("thock number is required")
+	}
+	if req.FromGatar <= 0 || req.ToGatar <= 0 {
+		return nil, errors.New("from and to gatar are required")
+	}
+	if req.FromGatar == req.ToGatar {
+		return nil, errors.New("from and to gatar must be different")
+	}
+	if req.Quantity <= 0 {
+		return nil, errors.New("quantity must be positive")
+	}
+	if req.Reason == "" {
+		req.Reason = models.MovementReasonRestacking
+	}
+	switch req.Reason {
+	case models.MovementReasonVentilation, models.MovementReasonRestacking, models.MovementReasonConsolidation, models.MovementReasonOther:
+	default:
+		return nil, fmt.Errorf("invalid reason %q: use ventilation, restacking, consolidation or other", req.Reason)
+	}
+
+	layout, err := s.LayoutService.Layout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	toRoom, toFloor := layout.Locate(req.ToGatar)
+	if toRoom == "" {
+		return nil, fmt.Errorf("gatar %d is not in the warehouse layout", req.ToGatar)
+	}
+
+	movement := &models.GatarMovement{
+		ThockNumber:   req.ThockNumber,
+		FromGatar:     req.FromGatar,
+		ToRoomNo:      toRoom,
+		ToFloor:       toFloor,
+		ToGatar:       req.ToGatar,
+		Quantity:      req.Quantity,
+		Reason:        req.Reason,
+		Notes:         strings.TrimSpace(req.Notes),
+		MovedByUserID: userID,
+	}
+	blockOverflow := gatarOverflowPolicy(ctx, s.SettingRepo) == models.GatarOverflowBlock
+	if err := s.MovementRepo.Transfer(ctx, movement, layout.Capacity(req.ToGatar), blockOverflow); err != nil {
+		return nil, err
+	}
+
+	// Create event (don't fail if this fails)
+	notes := fmt.Sprintf("Moved %d bags from Room %s, Floor %s, Gatar %d to Room %s, Floor %s, Gatar %d (%s)",
+		movement.Quantity, movement.FromRoomNo, movement.FromFloor, movement.FromGatar,
+		movement.ToRoomNo, movement.ToFloor, movement.ToGatar, movement.Reason)
+	if movement.Notes != "" {
+		notes += ": " + movement.Notes
+	}
+	s.EntryEventRepo.Create(ctx, &models.EntryEvent{
+		EntryID:         movement.EntryID,
+		EventType:       models.EventTypeGatarMoved,
+		Status:          models.StatusInStorage,
+		Notes:           notes,
+		CreatedByUserID: userID,
+	})
+
+	return movement, nil
+}
+
+// Ledger returns a gatar's current stock and its movements in and out, newest first.
+// Zero times leave the range open.
+func (s *GatarMovementService) Ledger(ctx context.Context, gatarNo int, from, to time.Time, limit int) (*models.GatarLedger, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	layout, err := s.LayoutService.Layout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := s.GatarRepo.GetQuantitiesByGatar(ctx, []int{gatarNo}, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load gatar stock: %w", err)
+	}
+	movements, err := s.MovementRepo.ListByGatar(ctx, gatarNo, from, to, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger := &models.GatarLedger{
+		GatarNo:   gatarNo,
+		Stock:     stored[gatarNo],
+		Capacity:  layout.Capacity(gatarNo),
+		Movements: []*models.GatarLedgerLine{},
+	}
+	ledger.RoomNo, ledger.Floor = layout.Locate(gatarNo)
+	for _, m := range movements {
+		line := &models.GatarLedgerLine{GatarMovement: m, Direction: "in", OtherGatar: m.FromGatar, Change: m.Quantity}
+		if m.FromGatar == gatarNo {
+			line.Direction, line.OtherGatar, line.Change = "out", m.ToGatar, -m.Quantity
+		}
+		ledger.Movements = append(ledger.Movements, line)
+	}
+	return ledger, nil
+}
+
+// ListByThock returns a thock's movements, newest first
+func (s *GatarMovementService) ListByThock(ctx context.Context, thockNumber string, limit int) ([]*models.GatarMovement, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+	movements, err := s.MovementRepo.ListByThock(ctx, strings.TrimSpace(thockNumber), limit)
+	if err != nil {
+		return nil, err
+	}
+	if movements == nil {
+		movements = []*models.GatarMovement{}
+	}
+	return movements, nil
+}
@@ -3,6 +3,8 @@ package services
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
 	"strconv"
 	"time"
 
@@ -11,12 +13,19 @@ import (
 	"cold-backend/internal/timeutil"
 )
 
+// ErrPickupNotCharged is returned by RecordPickup when the pickup was recorded but its
+// rent could not be posted; it must be charged from /api/rent-charges/pickups/{id}
+var ErrPickupNotCharged = errors.New("pickup recorded but rent was not charged")
+
 type GatePassService struct {
 	GatePassRepo       *repositories.GatePassRepository
 	EntryRepo          *repositories.EntryRepository
 	EntryEventRepo     *repositories.EntryEventRepository
 	PickupRepo         *repositories.GatePassPickupRepository
 	RoomEntryRepo      *repositories.RoomEntryRepository
+	RentChargeService  *RentChargeService
+	CropLoanService    *CropLoanService
+	LienRepo           *repositories.ThockLienRepository
 }
 
 func NewGatePassService(
@@ -35,6 +44,58 @@ func NewGatePassService(
 	}
 }
 
+// SetRentChargeService enables posting duration-based rent for each pickup
+func (s *GatePassService) SetRentChargeService(rentChargeService *RentChargeService) {
+	s.RentChargeService = rentChargeService
+}
+
+// SetCropLoanService blocks gate passes for thocks pledged against an unsettled crop loan
+func (s *GatePassService) SetCropLoanService(cropLoanService *CropLoanService) {
+	s.CropLoanService = cropLoanService
+}
+
+// SetLienRepo keeps bags pledged to a bank from being withdrawn
+func (s *GatePassService) SetLienRepo(lienRepo *repositories.ThockLienRepository) {
+	s.LienRepo = lienRepo
+}
+
+// pledgedQuantity returns the bags of a thock under an active bank lien
+func (s *GatePassService) pledgedQuantity(ctx context.Context, thockNumber string) (int, error) {
+	if s.LienRepo == nil {
+		return 0, nil
+	}
+	return s.LienRepo.GetPledgedQuantityByThock(ctx, thockNumber)
+}
+
+// checkLien rejects taking quantity bags of a thock out when that would dip into stock
+// under an active bank lien. The free stock is what is still stored less what other gate
+// passes (all but excludeID) are already approved to take; it is worked out per thock,
+// so it holds whether or not the gate pass names an entry.
+func (s *GatePassService) checkLien(ctx context.Context, thockNumber string, quantity, excludeID int) error {
+	pledged, err := s.pledgedQuantity(ctx, thockNumber)
+	if err != nil {
+		return errors.New("failed to check bank liens")
+	}
+	if pledged <= 0 {
+		return nil
+	}
+
+	stored, err := storedThockQuantity(ctx, s.RoomEntryRepo, s.PickupRepo, thockNumber)
+	if err != nil {
+		return errors.New("failed to check bank liens")
+	}
+	open, err := s.GatePassRepo.GetOpenQuantityForThock(ctx, thockNumber, excludeID)
+	if err != nil {
+		return errors.New("failed to check bank liens")
+	}
+	unpledged := max(stored-open-pledged, 0)
+	if quantity > unpledged {
+		return errors.New("quantity (" + strconv.Itoa(quantity) + ") exceeds unpledged stock (" +
+			strconv.Itoa(unpledged) + ") - " + strconv.Itoa(pledged) + " items are under bank lien")
+	}
+	return nil
+}
+
 // CreateGatePass creates a gate pass and logs the event
 func (s *GatePassService) CreateGatePass(ctx context.Context, req *models.CreateGatePassRequest, userID int) (*models.GatePass, error) {
 	// Verify payment if required
@@ -42,6 +103,18 @@ func (s *GatePassService) CreateGatePass(ctx context.Context, req *models.Create
 		return nil, errors.New("payment must be verified before issuing gate pass")
 	}
 
+	// Pledged stock needs the loan settled or a debt approval
+	if s.CropLoanService != nil {
+		if err := s.CropLoanService.CheckThockRelease(ctx, req.ThockNumber, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	// Bags pledged to a bank cannot be withdrawn until the lien is released
+	if err := s.checkLien(ctx, req.ThockNumber, req.RequestedQuantity, 0); err != nil {
+		return nil, err
+	}
+
 	// CRITICAL FIX: Verify customer has enough stock if entry_id is provided
 	// Check both total entry quantity AND previously approved gate passes
 	if req.EntryID != nil {
@@ -140,6 +213,20 @@ func (s *GatePassService) ApproveGatePass(ctx context.Context, id int, req *mode
 		return errors.New("gate pass has expired - not approved within 30 hours")
 	}
 
+	// Pledged stock needs the loan settled or a debt approval
+	if req.Status == "approved" && s.CropLoanService != nil {
+		if err := s.CropLoanService.CheckThockRelease(ctx, gatePass.ThockNumber, &gatePass.ID); err != nil {
+			return err
+		}
+	}
+
+	// Bags under bank lien stay in storage
+	if req.Status == "approved" {
+		if err := s.checkLien(ctx, gatePass.ThockNumber, req.ApprovedQuantity, gatePass.ID); err != nil {
+			return err
+		}
+	}
+
 	// Validate approved quantity against available inventory
 	if req.Status == "approved" && gatePass.EntryID != nil {
 		// Get current inventory from room entries
@@ -385,6 +472,16 @@ func (s *GatePassService) RecordPickup(ctx context.Context, req *models.RecordPi
 	// Current inventory is calculated as: room_entries.quantity - total_picked_up
 	// This prevents double-counting in account reports where outgoing is shown separately
 
+	// Step 3: Post duration-based rent for this lot to the ledger
+	// The pickup stands either way, so a failure is reported with ErrPickupNotCharged
+	// rather than undone - the caller must not retry the pickup itself
+	if s.RentChargeService != nil && s.RentChargeService.IsChargeOnPickupEnabled(ctx) {
+		if _, err := s.RentChargeService.ChargePickup(ctx, pickup.ID, userID); err != nil {
+			log.Printf("[GatePass] Failed to post rent charge for pickup %d: %v", pickup.ID, err)
+			return fmt.Errorf("%w (pickup %d): %v", ErrPickupNotCharged, pickup.ID, err)
+		}
+	}
+
 	return nil
 }
 
@@ -0,0 +1,368 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/timeutil"
+)
+
+// Ledger reference type for interest posted by a monthly run
+const InterestReferenceType = "interest_run"
+
+// InterestService accrues monthly interest on overdue customer balances.
+// Interest is previewed per period (YYYY-MM) and posted once as INTEREST ledger entries.
+type InterestService struct {
+	InterestRepo  *repositories.InterestRepository
+	LedgerService *LedgerService
+	SettingRepo   *repositories.SystemSettingRepository
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	postMu   sync.Mutex
+}
+
+func NewInterestService(interestRepo *repositories.InterestRepository, ledgerService *LedgerService, settingRepo *repositories.SystemSettingRepository) *InterestService {
+	return &InterestService{
+		InterestRepo:  interestRepo,
+		LedgerService: ledgerService,
+		SettingRepo:   settingRepo,
+		stopChan:      make(chan struct{}),
+	}
+}
+
+// GetConfig reads the interest_* settings
+func (s *InterestService) GetConfig(ctx context.Context) models.InterestConfig {
+	cfg := models.InterestConfig{
+		Method:    models.InterestMethodSimple,
+		GraceDays: 30,
+	}
+	get := func(key string) string {
+		setting, err := s.SettingRepo.Get(ctx, key)
+		if err != nil || setting == nil {
+			return ""
+		}
+		return strings.TrimSpace(setting.SettingValue)
+	}
+
+	cfg.Enabled = get("interest_enabled") == "true"
+	cfg.AutoPost = get("interest_auto_post") == "true"
+	if method := strings.ToLower(get("interest_method")); method == models.InterestMethodCompound {
+		cfg.Method = models.InterestMethodCompound
+	}
+	if v, err := strconv.ParseFloat(get("interest_rate_monthly"), 64); err == nil && v > 0 {
+		cfg.MonthlyRatePercent = v
+	}
+	if v, err := strconv.Atoi(get("interest_grace_days")); err == nil && v >= 0 {
+		cfg.GraceDays = v
+	}
+	if v, err := strconv.ParseFloat(get("interest_min_balance"), 64); err == nil && v > 0 {
+		cfg.MinBalance = v
+	}
+	return cfg
+}
+
+// Preview calculates the interest for a period without posting it
+func (s *InterestService) Preview(ctx context.Context, period string) (*models.InterestPreview, error) {
+	periodEnd, err := interestPeriodEnd(period)
+	if err != nil {
+		return nil, err
+	}
+	cfg := s.GetConfig(ctx)
+	cutoff := periodEnd.AddDate(0, 0, -cfg.GraceDays)
+
+	balances, err := s.InterestRepo.GetOverdueBalances(ctx, cutoff, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+	waivers, err := s.InterestRepo.ListWaivers(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &models.InterestPreview{
+		Period:        period,
+		PeriodEnd:     periodEnd,
+		OverdueBefore: cutoff,
+		Config:        cfg,
+		Lines:         make([]models.InterestLine, 0, len(balances)),
+	}
+	for _, b := range balances {
+		line := calculateInterest(b, cfg)
+		if w := activeWaiver(waivers, b.CustomerPhone, periodEnd); w != nil {
+			line.Waived = true
+			line.WaiverReason = w.Reason
+			line.Interest = 0
+		}
+		if line.OverdueBalance <= 0 {
+			continue
+		}
+		if line.Interest > 0 {
+			preview.CustomerCount++
+			preview.TotalInterest += line.Interest
+		}
+		preview.Lines = append(preview.Lines, line)
+	}
+	preview.TotalInterest = roundMoney(preview.TotalInterest)
+
+	run, err := s.InterestRepo.GetRunByPeriod(ctx, period)
+	if err != nil {
+		return nil, err
+	}
+	if run != nil {
+		preview.Posted = true
+		preview.RunID = &run.ID
+	}
+	return preview, nil
+}
+
+// Post calculates and posts INTEREST ledger entries for a period.
+// Each period can only be posted once. userID 0 means the scheduler posted it.
+func (s *InterestService) Post(ctx context.Context, period string, userID int) (*models.InterestPreview, error) {
+	s.postMu.Lock()
+	defer s.postMu.Unlock()
+
+	preview, err := s.Preview(ctx, period)
+	if err != nil {
+		return nil, err
+	}
+	if preview.Posted {
+		return nil, fmt.Errorf("interest for %s has already been posted", period)
+	}
+	if !preview.Config.Enabled {
+		return nil, errors.New("interest accrual is disabled (interest_enabled setting)")
+	}
+	if preview.Config.MonthlyRatePercent <= 0 {
+		return nil, errors.New("interest rate is not set (interest_rate_monthly setting)")
+	}
+	if !preview.PeriodEnd.Before(timeutil.Now()) {
+		return nil, fmt.Errorf("cannot post interest for %s before the month has ended", period)
+	}
+
+	run := &models.InterestRun{
+		Period:             period,
+		PeriodEnd:          preview.PeriodEnd,
+		Method:             preview.Config.Method,
+		MonthlyRatePercent: preview.Config.MonthlyRatePercent,
+		GraceDays:          preview.Config.GraceDays,
+	}
+	if userID > 0 {
+		run.PostedByUserID = &userID
+	}
+
+	// Every line is posted in one transaction with the run, so a failure leaves the
+	// month unposted and it can simply be posted again
+	monthLabel := timeutil.ToIST(preview.PeriodEnd).Format("Jan 2006")
+	var entries []*models.CreateLedgerEntryRequest
+	var lines []*models.InterestLine
+	for i := range preview.Lines {
+		line := &preview.Lines[i]
+		if line.Interest <= 0 {
+			continue
+		}
+		entry := &models.CreateLedgerEntryRequest{
+			CustomerPhone:   line.CustomerPhone,
+			CustomerName:    line.CustomerName,
+			CustomerSO:      line.CustomerSO,
+			EntryType:       models.LedgerEntryTypeInterest,
+			Description:     fmt.Sprintf("Interest for %s @ %.2f%% per month", monthLabel, preview.Config.MonthlyRatePercent),
+			Debit:           line.Interest,
+			ReferenceType:   InterestReferenceType,
+			CreatedByUserID: userID,
+			Notes: fmt.Sprintf("%s interest on overdue ₹%.2f (grace %d days)",
+				preview.Config.Method, line.InterestBase, preview.Config.GraceDays),
+		}
+		if err := s.LedgerService.PrepareEntry(ctx, entry); err != nil {
+			return nil, fmt.Errorf("cannot post interest for %s: %w", line.CustomerPhone, err)
+		}
+		entries = append(entries, entry)
+		lines = append(lines, line)
+	}
+
+	posted, err := s.InterestRepo.PostRun(ctx, run, entries)
+	if err != nil {
+		return nil, err
+	}
+	s.LedgerService.EntriesPosted(ctx, posted...)
+	for i, entry := range posted {
+		lines[i].LedgerEntryID = &entry.ID
+	}
+
+	preview.Posted = true
+	preview.RunID = &run.ID
+	preview.CustomerCount = run.CustomerCount
+	preview.TotalInterest = roundMoney(run.TotalInterest)
+	return preview, nil
+}
+
+// ListRuns returns all posted months
+func (s *InterestService) ListRuns(ctx context.Context) ([]*models.InterestRun, error) {
+	return s.InterestRepo.ListRuns(ctx)
+}
+
+// ListWaivers returns interest waivers
+func (s *InterestService) ListWaivers(ctx context.Context, activeOnly bool) ([]*models.InterestWaiver, error) {
+	return s.InterestRepo.ListWaivers(ctx, activeOnly)
+}
+
+// CreateWaiver validates and records an interest waiver
+func (s *InterestService) CreateWaiver(ctx context.Context, req *models.CreateInterestWaiverRequest, userID int) (*models.InterestWaiver, error) {
+	phone := strings.TrimSpace(req.CustomerPhone)
+	if phone == "" {
+		return nil, errors.New("customer phone is required")
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.New("reason is required")
+	}
+
+	waiver := &models.InterestWaiver{
+		CustomerPhone: phone,
+		Reason:        reason,
+		ValidFrom:     timeutil.StartOfDay(timeutil.Now()),
+	}
+	if req.ValidFrom != "" {
+		parsed, err := time.Parse("2006-01-02", req.ValidFrom)
+		if err != nil {
+			return nil, errors.New("invalid valid_from date. Use YYYY-MM-DD")
+		}
+		waiver.ValidFrom = parsed
+	}
+	if req.ValidTo != "" {
+		parsed, err := time.Parse("2006-01-02", req.ValidTo)
+		if err != nil {
+			return nil, errors.New("invalid valid_to date. Use YYYY-MM-DD")
+		}
+		if parsed.Before(waiver.ValidFrom) {
+			return nil, errors.New("valid_to cannot be before valid_from")
+		}
+		waiver.ValidTo = &parsed
+	}
+	if userID > 0 {
+		waiver.CreatedByUserID = &userID
+	}
+	if err := s.InterestRepo.CreateWaiver(ctx, waiver); err != nil {
+		return nil, err
+	}
+	return waiver, nil
+}
+
+// DeactivateWaiver ends an interest waiver
+func (s *InterestService) DeactivateWaiver(ctx context.Context, id int) error {
+	return s.InterestRepo.DeactivateWaiver(ctx, id)
+}
+
+// Start runs the monthly scheduler. When interest_auto_post is on, the previous
+// month is posted automatically once it has ended.
+func (s *InterestService) Start() {
+	log.Println("[Interest] Starting interest scheduler...")
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.autoPost()
+			case <-s.stopChan:
+				log.Println("[Interest] Stopping interest scheduler...")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler
+func (s *InterestService) Stop() {
+	close(s.stopChan)
+	s.wg.Wait()
+}
+
+// autoPost posts last month's interest if auto posting is enabled and it is not yet posted
+func (s *InterestService) autoPost() {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	cfg := s.GetConfig(ctx)
+	if !cfg.Enabled || !cfg.AutoPost || cfg.MonthlyRatePercent <= 0 {
+		return
+	}
+
+	period := timeutil.Now().AddDate(0, 0, -timeutil.Now().Day()).Format("2006-01")
+	run, err := s.InterestRepo.GetRunByPeriod(ctx, period)
+	if err != nil || run != nil {
+		return
+	}
+
+	preview, err := s.Post(ctx, period, 0)
+	if err != nil {
+		log.Printf("[Interest] Auto-post for %s failed: %v", period, err)
+		return
+	}
+	log.Printf("[Interest] Posted interest for %s: %d customers, ₹%.2f", period, preview.CustomerCount, preview.TotalInterest)
+}
+
+// calculateInterest works out one customer's interest for the period.
+// Payments settle the oldest dues first, so only overdue amounts not yet paid accrue interest.
+func calculateInterest(b repositories.OverdueBalance, cfg models.InterestConfig) models.InterestLine {
+	line := models.InterestLine{
+		CustomerPhone: b.CustomerPhone,
+		CustomerName:  b.CustomerName,
+		CustomerSO:    b.CustomerSO,
+		Balance:       roundMoney(b.Balance),
+	}
+
+	overdue := math.Min(math.Max(b.OverdueCharges+b.OverdueInterest-b.Credits, 0), b.Balance)
+	line.OverdueBalance = roundMoney(overdue)
+
+	base := overdue
+	if cfg.Method != models.InterestMethodCompound {
+		// Simple interest: never charge interest on interest
+		base = math.Min(math.Max(b.OverdueCharges-b.Credits, 0), b.Balance)
+	}
+	line.InterestBase = roundMoney(base)
+
+	if base > 0 && base >= cfg.MinBalance {
+		line.Interest = roundMoney(base * cfg.MonthlyRatePercent / 100)
+	}
+	return line
+}
+
+// activeWaiver returns the customer's waiver covering a date (nil if none)
+func activeWaiver(waivers []*models.InterestWaiver, phone string, date time.Time) *models.InterestWaiver {
+	day := timeutil.ToIST(date).Format("2006-01-02")
+	for _, w := range waivers {
+		if !w.IsActive || w.CustomerPhone != phone {
+			continue
+		}
+		if w.ValidFrom.Format("2006-01-02") > day {
+			continue
+		}
+		if w.ValidTo != nil && w.ValidTo.Format("2006-01-02") < day {
+			continue
+		}
+		return w
+	}
+	return nil
+}
+
+// interestPeriodEnd parses YYYY-MM and returns the end of that month (IST)
+func interestPeriodEnd(period string) (time.Time, error) {
+	start, err := timeutil.ParseInIST("2006-01", period)
+	if err != nil {
+		return time.Time{}, errors.New("invalid period. Use YYYY-MM")
+	}
+	return timeutil.EndOfDay(start.AddDate(0, 1, -1)), nil
+}
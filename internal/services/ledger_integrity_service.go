@@ -0,0 +1,219 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/timeutil"
+)
+
+// Hour (IST) after which the nightly integrity check runs
+const ledgerIntegrityNightlyHour = 2
+
+// LedgerIntegrityService recomputes running balances from scratch, cross-checks
+// ledger payment totals against rent_payments and online_transactions, and records
+// a discrepancy report. Auto-repair only rewrites running balances; payment total
+// mismatches need a person to post the missing entry or void the wrong one.
+type LedgerIntegrityService struct {
+	Repo        *repositories.LedgerIntegrityRepository
+	SettingRepo *repositories.SystemSettingRepository
+	AlertRepo   *repositories.MetricsRepository // Optional - alerts need TimescaleDB
+
+	stopChan chan struct{}
+	wg       sync.WaitGroup
+	runMu    sync.Mutex
+}
+
+func NewLedgerIntegrityService(repo *repositories.LedgerIntegrityRepository, settingRepo *repositories.SystemSettingRepository) *LedgerIntegrityService {
+	return &LedgerIntegrityService{
+		Repo:        repo,
+		SettingRepo: settingRepo,
+		stopChan:    make(chan struct{}),
+	}
+}
+
+// SetAlertRepo enables monitoring alerts for discrepancies found by the nightly check
+func (s *LedgerIntegrityService) SetAlertRepo(repo *repositories.MetricsRepository) {
+	s.AlertRepo = repo
+}
+
+// Run checks the whole ledger and stores the report. With repair set, wrong running
+// balances are rewritten in a single transaction.
+func (s *LedgerIntegrityService) Run(ctx context.Context, repair bool, trigger string, userID int) (*models.LedgerIntegrityRun, error) {
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+
+	run := &models.LedgerIntegrityRun{Trigger: trigger}
+	if userID > 0 {
+		run.RunByUserID = &userID
+	}
+
+	var err error
+	if run.CustomersChecked, run.EntriesChecked, err = s.Repo.CountEntries(ctx); err != nil {
+		return nil, err
+	}
+
+	balances, err := s.Repo.FindRunningBalanceMismatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rentPayments, err := s.Repo.FindRentPaymentMismatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+	onlinePayments, err := s.Repo.FindOnlinePaymentMismatches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if repair && len(balances) > 0 {
+		phones := make([]string, len(balances))
+		for i, d := range balances {
+			phones[i] = d.CustomerPhone
+		}
+		if run.RepairedEntries, err = s.Repo.RepairRunningBalances(ctx, phones); err != nil {
+			return nil, err
+		}
+		for i := range balances {
+			balances[i].Repaired = true
+		}
+	}
+
+	run.Discrepancies = make([]models.LedgerDiscrepancy, 0, len(balances)+len(rentPayments)+len(onlinePayments))
+	run.Discrepancies = append(run.Discrepancies, balances...)
+	run.Discrepancies = append(run.Discrepancies, rentPayments...)
+	run.Discrepancies = append(run.Discrepancies, onlinePayments...)
+	for i := range run.Discrepancies {
+		d := &run.Discrepancies[i]
+		d.Difference = roundMoney(d.Actual - d.Expected)
+	}
+	run.DiscrepancyCount = len(run.Discrepancies)
+
+	if err := s.Repo.CreateRun(ctx, run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// GetRun returns a stored report
+func (s *LedgerIntegrityService) GetRun(ctx context.Context, id int) (*models.LedgerIntegrityRun, error) {
+	return s.Repo.GetRun(ctx, id)
+}
+
+// ListRuns returns past checks, newest first
+func (s *LedgerIntegrityService) ListRuns(ctx context.Context, limit int) ([]*models.LedgerIntegrityRun, error) {
+	return s.Repo.ListRuns(ctx, limit)
+}
+
+// Start runs the nightly scheduler. Once a day after 2 AM IST, when
+// ledger_integrity_nightly is on, the ledger is checked and an alert is raised
+// if anything is wrong.
+func (s *LedgerIntegrityService) Start() {
+	log.Println("[LedgerIntegrity] Starting nightly ledger integrity scheduler...")
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(time.Hour)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				s.nightlyCheck()
+			case <-s.stopChan:
+				log.Println("[LedgerIntegrity] Stopping ledger integrity scheduler...")
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the scheduler
+func (s *LedgerIntegrityService) Stop() {
+	close(s.stopChan)
+	s.wg.Wait()
+}
+
+// setting reads a ledger_integrity_* setting
+func (s *LedgerIntegrityService) setting(ctx context.Context, key string) string {
+	setting, err := s.SettingRepo.Get(ctx, key)
+	if err != nil || setting == nil {
+		return ""
+	}
+	return strings.TrimSpace(setting.SettingValue)
+}
+
+// nightlyCheck runs the scheduled check if it has not run yet today
+func (s *LedgerIntegrityService) nightlyCheck() {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+	defer cancel()
+
+	now := timeutil.Now()
+	if now.Hour() < ledgerIntegrityNightlyHour || s.setting(ctx, "ledger_integrity_nightly") == "false" {
+		return
+	}
+	last, err := s.Repo.GetLatestRun(ctx, models.LedgerIntegrityTriggerScheduled)
+	if err != nil {
+		log.Printf("[LedgerIntegrity] Failed to read last run: %v", err)
+		return
+	}
+	if last != nil && !last.CreatedAt.Before(timeutil.StartOfDay(now)) {
+		return
+	}
+
+	repair := s.setting(ctx, "ledger_integrity_auto_repair") == "true"
+	run, err := s.Run(ctx, repair, models.LedgerIntegrityTriggerScheduled, 0)
+	if err != nil {
+		log.Printf("[LedgerIntegrity] Nightly check failed: %v", err)
+		return
+	}
+	log.Printf("[LedgerIntegrity] Checked %d entries for %d customers: %d discrepancies, %d entries repaired",
+		run.EntriesChecked, run.CustomersChecked, run.DiscrepancyCount, run.RepairedEntries)
+
+	if run.DiscrepancyCount > 0 {
+		s.raiseAlert(ctx, run)
+	}
+}
+
+// raiseAlert records a monitoring alert for a run that found discrepancies
+func (s *LedgerIntegrityService) raiseAlert(ctx context.Context, run *models.LedgerIntegrityRun) {
+	if s.AlertRepo == nil {
+		return
+	}
+
+	counts := make(map[string]int)
+	for _, d := range run.Discrepancies {
+		counts[d.Type]++
+	}
+	// Payment mismatches mean money is missing from a customer's account
+	severity := "warning"
+	if counts[models.LedgerDiscrepancyRentPayments]+counts[models.LedgerDiscrepancyOnlinePayments] > 0 {
+		severity = "critical"
+	}
+
+	message := fmt.Sprintf("Ledger integrity run #%d: %d customers with wrong running balances (%d entries repaired), %d rent payment and %d online payment total mismatches",
+		run.ID, counts[models.LedgerDiscrepancyRunningBalance], run.RepairedEntries,
+		counts[models.LedgerDiscrepancyRentPayments], counts[models.LedgerDiscrepancyOnlinePayments])
+	metric := "ledger_discrepancies"
+	value := float64(run.DiscrepancyCount)
+
+	err := s.AlertRepo.InsertAlert(ctx, &models.MonitoringAlert{
+		AlertType:   "ledger_integrity",
+		Severity:    severity,
+		Source:      "ledger_integrity",
+		Title:       fmt.Sprintf("Ledger integrity check found %d discrepancies", run.DiscrepancyCount),
+		Message:     message,
+		MetricName:  &metric,
+		MetricValue: &value,
+	})
+	if err != nil {
+		log.Printf("[LedgerIntegrity] Failed to raise alert: %v", err)
+	}
+}
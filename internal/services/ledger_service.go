@@ -3,13 +3,19 @@ package services
 import (
 	"context"
 	"fmt"
+	"log"
+	"strings"
 
 	"cold-backend/internal/models"
 	"cold-backend/internal/repositories"
+	"cold-backend/internal/timeutil"
 )
 
 type LedgerService struct {
-	LedgerRepo *repositories.LedgerRepository
+	LedgerRepo        *repositories.LedgerRepository
+	AccountingService *AccountingService
+	PeriodService     *AccountingPeriodService
+	AllocationService *PaymentAllocationService
 }
 
 func NewLedgerService(ledgerRepo *repositories.LedgerRepository) *LedgerService {
@@ -18,30 +24,125 @@ func NewLedgerService(ledgerRepo *repositories.LedgerRepository) *LedgerService
 	}
 }
 
+// SetAccountingService enables automatic double-entry journal postings for every ledger entry
+func (s *LedgerService) SetAccountingService(accountingService *AccountingService) {
+	s.AccountingService = accountingService
+}
+
+// SetPeriodService rejects ledger postings dated in closed accounting periods
+func (s *LedgerService) SetPeriodService(periodService *AccountingPeriodService) {
+	s.PeriodService = periodService
+}
+
+// SetAllocationService keeps payments allocated to the charges they settle as entries are posted
+func (s *LedgerService) SetAllocationService(allocationService *PaymentAllocationService) {
+	s.AllocationService = allocationService
+}
+
+// allocate brings a customer's payment allocations up to date. Failures are logged;
+// the customer's next posting allocates whatever was missed.
+func (s *LedgerService) allocate(ctx context.Context, customerPhone string) {
+	if s.AllocationService == nil {
+		return
+	}
+	if _, err := s.AllocationService.AllocateCustomer(ctx, customerPhone); err != nil {
+		log.Printf("[Allocation] Failed to allocate payments for %s: %v", customerPhone, err)
+	}
+}
+
+// create saves a ledger entry and posts it to the double-entry journal.
+// A failed journal posting is logged, not returned - the accounting sync backfills it.
+func (s *LedgerService) create(ctx context.Context, entry *models.CreateLedgerEntryRequest) (*models.LedgerEntry, error) {
+	if err := s.checkPeriod(ctx, entry); err != nil {
+		return nil, err
+	}
+
+	ledgerEntry, err := s.LedgerRepo.Create(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	s.EntriesPosted(ctx, ledgerEntry)
+	return ledgerEntry, nil
+}
+
+// checkPeriod rejects an entry dated in a closed accounting period
+func (s *LedgerService) checkPeriod(ctx context.Context, entry *models.CreateLedgerEntryRequest) error {
+	if s.PeriodService == nil {
+		return nil
+	}
+	postingDate := timeutil.Now()
+	if entry.EntryDate != nil {
+		postingDate = *entry.EntryDate
+	}
+	return s.PeriodService.CheckPostingDate(ctx, postingDate)
+}
+
+// journal posts a saved ledger entry to the double-entry journal, logging failures
+func (s *LedgerService) journal(ctx context.Context, ledgerEntry *models.LedgerEntry) {
+	if s.AccountingService == nil {
+		return
+	}
+	if _, err := s.AccountingService.PostLedgerEntry(ctx, ledgerEntry); err != nil {
+		log.Printf("[Accounting] Failed to post ledger entry #%d to journal: %v", ledgerEntry.ID, err)
+	}
+}
+
+// PrepareEntry validates an entry that a repository will write in its own transaction,
+// alongside the records it belongs to, and checks its date is in an open period.
+// Call EntriesPosted once that transaction commits.
+func (s *LedgerService) PrepareEntry(ctx context.Context, entry *models.CreateLedgerEntryRequest) error {
+	if err := validateLedgerEntry(entry); err != nil {
+		return err
+	}
+	return s.checkPeriod(ctx, entry)
+}
+
+// EntriesPosted journals and allocates entries written in a repository transaction
+func (s *LedgerService) EntriesPosted(ctx context.Context, entries ...*models.LedgerEntry) {
+	allocated := make(map[string]bool)
+	for _, e := range entries {
+		s.journal(ctx, e)
+		if !allocated[e.CustomerPhone] {
+			allocated[e.CustomerPhone] = true
+			s.allocate(ctx, e.CustomerPhone)
+		}
+	}
+}
+
 // CreateEntry creates a new ledger entry
 func (s *LedgerService) CreateEntry(ctx context.Context, entry *models.CreateLedgerEntryRequest) (*models.LedgerEntry, error) {
+	if err := validateLedgerEntry(entry); err != nil {
+		return nil, err
+	}
+	return s.create(ctx, entry)
+}
+
+// validateLedgerEntry checks the entry type and puts the amount on the side it belongs
+func validateLedgerEntry(entry *models.CreateLedgerEntryRequest) error {
 	// Validate entry type
 	switch entry.EntryType {
 	case models.LedgerEntryTypeCharge, models.LedgerEntryTypePayment,
 		models.LedgerEntryTypeCredit, models.LedgerEntryTypeRefund,
-		models.LedgerEntryTypeDebtApproval:
+		models.LedgerEntryTypeDebtApproval, models.LedgerEntryTypeInterest,
+		models.LedgerEntryTypeLoanDisbursal, models.LedgerEntryTypeLoanRepayment:
 		// Valid
 	default:
-		return nil, fmt.Errorf("invalid entry type: %s", entry.EntryType)
+		return fmt.Errorf("invalid entry type: %s", entry.EntryType)
 	}
 
 	// Validate debit/credit based on entry type
 	switch entry.EntryType {
-	case models.LedgerEntryTypeCharge, models.LedgerEntryTypeRefund:
+	case models.LedgerEntryTypeCharge, models.LedgerEntryTypeRefund, models.LedgerEntryTypeInterest,
+		models.LedgerEntryTypeLoanDisbursal:
 		// These should have debit (money owed)
 		if entry.Debit <= 0 {
-			return nil, fmt.Errorf("%s entry must have positive debit amount", entry.EntryType)
+			return fmt.Errorf("%s entry must have positive debit amount", entry.EntryType)
 		}
 		entry.Credit = 0
-	case models.LedgerEntryTypePayment, models.LedgerEntryTypeCredit:
+	case models.LedgerEntryTypePayment, models.LedgerEntryTypeCredit, models.LedgerEntryTypeLoanRepayment:
 		// These should have credit (money paid/credited)
 		if entry.Credit <= 0 {
-			return nil, fmt.Errorf("%s entry must have positive credit amount", entry.EntryType)
+			return fmt.Errorf("%s entry must have positive credit amount", entry.EntryType)
 		}
 		entry.Debit = 0
 	case models.LedgerEntryTypeDebtApproval:
@@ -49,8 +150,7 @@ func (s *LedgerService) CreateEntry(ctx context.Context, entry *models.CreateLed
 		entry.Debit = 0
 		entry.Credit = 0
 	}
-
-	return s.LedgerRepo.Create(ctx, entry)
+	return nil
 }
 
 // CreateChargeEntry creates a CHARGE ledger entry (rent charged)
@@ -68,7 +168,7 @@ func (s *LedgerService) CreateChargeEntry(ctx context.Context, customerPhone, cu
 		CreatedByUserID: userID,
 		Notes:           notes,
 	}
-	return s.LedgerRepo.Create(ctx, entry)
+	return s.create(ctx, entry)
 }
 
 // CreatePaymentEntry creates a PAYMENT ledger entry (customer payment received)
@@ -86,7 +186,7 @@ func (s *LedgerService) CreatePaymentEntry(ctx context.Context, customerPhone, c
 		CreatedByUserID: userID,
 		Notes:           notes,
 	}
-	return s.LedgerRepo.Create(ctx, entry)
+	return s.create(ctx, entry)
 }
 
 // CreateCreditEntry creates a CREDIT ledger entry (discount/adjustment)
@@ -102,7 +202,7 @@ func (s *LedgerService) CreateCreditEntry(ctx context.Context, customerPhone, cu
 		CreatedByUserID: userID,
 		Notes:           notes,
 	}
-	return s.LedgerRepo.Create(ctx, entry)
+	return s.create(ctx, entry)
 }
 
 // CreateRefundEntry creates a REFUND ledger entry (money returned to customer)
@@ -118,7 +218,7 @@ func (s *LedgerService) CreateRefundEntry(ctx context.Context, customerPhone, cu
 		CreatedByUserID: userID,
 		Notes:           notes,
 	}
-	return s.LedgerRepo.Create(ctx, entry)
+	return s.create(ctx, entry)
 }
 
 // CreateDebtApprovalEntry creates a DEBT_APPROVAL ledger entry (audit record)
@@ -136,7 +236,75 @@ func (s *LedgerService) CreateDebtApprovalEntry(ctx context.Context, customerPho
 		CreatedByUserID: userID,
 		Notes:           notes,
 	}
-	return s.LedgerRepo.Create(ctx, entry)
+	return s.create(ctx, entry)
+}
+
+// VoidEntry voids a ledger entry by posting an equal and opposite REVERSAL entry.
+// The original row is never edited beyond its void marker, so running balances stay intact.
+func (s *LedgerService) VoidEntry(ctx context.Context, id int, reason string, userID int) (*models.LedgerEntry, error) {
+	entry, err := s.PrepareVoid(ctx, id, reason, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The void marker and the reversal are written together
+	reversal, err := s.LedgerRepo.Void(ctx, entry, userID, entry.Notes)
+	if err != nil {
+		return nil, err
+	}
+	s.EntriesPosted(ctx, reversal)
+	return reversal, nil
+}
+
+// PrepareVoid checks that entry id may be voided and returns the REVERSAL that would
+// cancel it, with the reason in its notes, for a caller voiding it inside its own
+// repository transaction
+func (s *LedgerService) PrepareVoid(ctx context.Context, id int, reason string, userID int) (*models.CreateLedgerEntryRequest, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to void a ledger entry")
+	}
+
+	original, err := s.LedgerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case original.VoidedAt != nil:
+		return nil, fmt.Errorf("ledger entry #%d is already voided", id)
+	case original.EntryType == models.LedgerEntryTypeReversal:
+		return nil, fmt.Errorf("a reversal cannot itself be voided")
+	case original.EntryType == models.LedgerEntryTypeOnlinePayment:
+		return nil, fmt.Errorf("online payments must be refunded, not voided")
+	case original.ReferenceType == OnlineRefundReferenceType:
+		return nil, fmt.Errorf("refunds sent through Razorpay cannot be voided")
+	case original.ReferenceType == CropLoanReferenceType:
+		return nil, fmt.Errorf("crop loan entries are corrected through the loan, not voided")
+	case original.Debit == 0 && original.Credit == 0:
+		return nil, fmt.Errorf("%s entries carry no amount to reverse", original.EntryType)
+	}
+
+	entry := &models.CreateLedgerEntryRequest{
+		CustomerPhone:    original.CustomerPhone,
+		CustomerName:     original.CustomerName,
+		CustomerSO:       original.CustomerSO,
+		EntryType:        models.LedgerEntryTypeReversal,
+		Description:      fmt.Sprintf("Reversal of #%d: %s", original.ID, original.Description),
+		Debit:            original.Credit,
+		Credit:           original.Debit,
+		ReferenceID:      &original.ID,
+		ReferenceType:    "ledger_entry",
+		FamilyMemberID:   original.FamilyMemberID,
+		FamilyMemberName: original.FamilyMemberName,
+		CreatedByUserID:  userID,
+		Notes:            reason,
+		ReversalOfID:     &original.ID,
+		PaymentMode:      original.PaymentMode,
+	}
+	if err := s.checkPeriod(ctx, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
 }
 
 // GetBalance returns the current balance for a customer
@@ -155,18 +323,18 @@ func (s *LedgerService) GetAllEntries(ctx context.Context, filter *models.Ledger
 }
 
 // GetCustomerSummary returns balance summary for a customer
-func (s *LedgerService) GetCustomerSummary(ctx context.Context, customerPhone string) (*models.LedgerSummary, error) {
-	return s.LedgerRepo.GetSummaryByCustomer(ctx, customerPhone)
+func (s *LedgerService) GetCustomerSummary(ctx context.Context, customerPhone string, includeVoided bool) (*models.LedgerSummary, error) {
+	return s.LedgerRepo.GetSummaryByCustomer(ctx, customerPhone, includeVoided)
 }
 
 // GetAllCustomerBalances returns balance summaries for all customers
-func (s *LedgerService) GetAllCustomerBalances(ctx context.Context) ([]models.LedgerSummary, error) {
-	return s.LedgerRepo.GetAllCustomerBalances(ctx)
+func (s *LedgerService) GetAllCustomerBalances(ctx context.Context, includeVoided bool) ([]models.LedgerSummary, error) {
+	return s.LedgerRepo.GetAllCustomerBalances(ctx, includeVoided)
 }
 
 // GetDebtors returns customers with positive balance (they owe money)
-func (s *LedgerService) GetDebtors(ctx context.Context) ([]models.LedgerSummary, error) {
-	return s.LedgerRepo.GetDebtors(ctx)
+func (s *LedgerService) GetDebtors(ctx context.Context, includeVoided bool) ([]models.LedgerSummary, error) {
+	return s.LedgerRepo.GetDebtors(ctx, includeVoided)
 }
 
 // GetTotalsByType returns sum of amounts by entry type
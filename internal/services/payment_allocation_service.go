@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+)
+
+// PaymentAllocationService splits customer payments across the charges they settle, so
+// each thock's outstanding is known exactly
+type PaymentAllocationService struct {
+	Repo *repositories.PaymentAllocationRepository
+}
+
+func NewPaymentAllocationService(repo *repositories.PaymentAllocationRepository) *PaymentAllocationService {
+	return &PaymentAllocationService{Repo: repo}
+}
+
+// AllocateCustomer applies the customer's unallocated payments to outstanding charges, oldest first
+func (s *PaymentAllocationService) AllocateCustomer(ctx context.Context, phone string) (int, error) {
+	return s.Repo.AllocateFIFO(ctx, phone)
+}
+
+// AllocatePayment replaces a payment's allocations with manual ones, then allocates
+// whatever is left of the payment (and any charges it released) FIFO
+func (s *PaymentAllocationService) AllocatePayment(ctx context.Context, paymentEntryID int, req *models.AllocatePaymentRequest, userID int) ([]*models.PaymentAllocation, error) {
+	for i := range req.Allocations {
+		a := &req.Allocations[i]
+		a.ThockNumber = strings.TrimSpace(a.ThockNumber)
+		if a.Amount <= 0 {
+			return nil, errors.New("allocation amount must be positive")
+		}
+		if (a.ChargeEntryID == nil) == (a.ThockNumber == "") {
+			return nil, errors.New("each allocation needs either a charge entry or a thock number")
+		}
+		a.Amount = roundMoney(a.Amount)
+	}
+
+	phone, err := s.Repo.ReplacePaymentAllocations(ctx, paymentEntryID, req.Allocations, userID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.Repo.AllocateFIFO(ctx, phone); err != nil {
+		return nil, err
+	}
+	return s.Repo.ListByPayment(ctx, paymentEntryID)
+}
+
+// GetPaymentAllocations returns the charges a payment has been applied to
+func (s *PaymentAllocationService) GetPaymentAllocations(ctx context.Context, paymentEntryID int) ([]*models.PaymentAllocation, error) {
+	return s.Repo.ListByPayment(ctx, paymentEntryID)
+}
+
+// GetCustomerSummary returns a customer's outstanding per thock. Allocations are kept up
+// to date as ledger entries are posted, so reading them writes nothing.
+func (s *PaymentAllocationService) GetCustomerSummary(ctx context.Context, phone string) (*models.CustomerAllocationSummary, error) {
+	thocks, err := s.Repo.ListThockOutstanding(ctx, phone, "")
+	if err != nil {
+		return nil, err
+	}
+	other, unallocated, err := s.Repo.GetUnallocatedTotals(ctx, phone)
+	if err != nil {
+		return nil, err
+	}
+	if thocks == nil {
+		thocks = []models.ThockOutstanding{}
+	}
+
+	summary := &models.CustomerAllocationSummary{
+		CustomerPhone:     phone,
+		Thocks:            thocks,
+		OtherOutstanding:  other,
+		UnallocatedCredit: unallocated,
+	}
+	total := other
+	for _, t := range thocks {
+		total += t.Outstanding
+	}
+	summary.TotalOutstanding = roundMoney(total)
+	return summary, nil
+}
+
+// ThockOutstanding returns what is still owed on one thock of a customer
+func (s *PaymentAllocationService) ThockOutstanding(ctx context.Context, phone, thockNumber string) (float64, error) {
+	thocks, err := s.Repo.ListThockOutstanding(ctx, phone, thockNumber)
+	if err != nil {
+		return 0, err
+	}
+	if len(thocks) == 0 {
+		return 0, nil
+	}
+	return thocks[0].Outstanding, nil
+}
@@ -0,0 +1,266 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/sms"
+)
+
+var (
+	// ErrPaymentLinkNotFound is returned for unknown link tokens
+	ErrPaymentLinkNotFound = errors.New("payment link not found")
+	// ErrPaymentLinkClosed is returned when a paid, cancelled or expired link is used to pay
+	ErrPaymentLinkClosed = errors.New("payment link is no longer valid")
+	// ErrPaymentLinksDisabled is returned when no portal URL is set for links
+	ErrPaymentLinksDisabled = errors.New("payment links are disabled (set payment_link_base_url)")
+)
+
+// PaymentLinkService sends customers short-lived links into the portal checkout for their
+// outstanding balance. The link token is the credential: whoever holds it can pay that
+// customer's dues, nothing else. Orders created through a link carry its ID, and the
+// payment service marks the link paid once the payment is posted.
+type PaymentLinkService struct {
+	repo              *repositories.PaymentLinkRepository
+	customerRepo      *repositories.CustomerRepository
+	systemSettingRepo *repositories.SystemSettingRepository
+	smsService        sms.SMSProvider        // Sends links (employee server)
+	razorpayService   *RazorpayService       // Takes payments (customer portal server)
+	portalService     *CustomerPortalService // Caps the amount at the current outstanding
+}
+
+func NewPaymentLinkService(
+	repo *repositories.PaymentLinkRepository,
+	customerRepo *repositories.CustomerRepository,
+	systemSettingRepo *repositories.SystemSettingRepository,
+) *PaymentLinkService {
+	return &PaymentLinkService{
+		repo:              repo,
+		customerRepo:      customerRepo,
+		systemSettingRepo: systemSettingRepo,
+	}
+}
+
+// SetSMSService enables sending links by SMS/WhatsApp
+func (s *PaymentLinkService) SetSMSService(smsService sms.SMSProvider) {
+	s.smsService = smsService
+}
+
+// SetRazorpayService enables paying through links
+func (s *PaymentLinkService) SetRazorpayService(razorpayService *RazorpayService) {
+	s.razorpayService = razorpayService
+}
+
+// SetPortalService caps link payments at the customer's current outstanding rent
+func (s *PaymentLinkService) SetPortalService(portalService *CustomerPortalService) {
+	s.portalService = portalService
+}
+
+func (s *PaymentLinkService) baseURL(ctx context.Context) string {
+	setting, err := s.systemSettingRepo.Get(ctx, models.SettingPaymentLinkBaseURL)
+	if err != nil || setting == nil {
+		return ""
+	}
+	return strings.TrimRight(strings.TrimSpace(setting.SettingValue), "/")
+}
+
+// Enabled reports whether links can be sent (a portal URL is configured)
+func (s *PaymentLinkService) Enabled(ctx context.Context) bool {
+	return s.baseURL(ctx) != ""
+}
+
+// expiry returns how long new links stay valid (default 72 hours)
+func (s *PaymentLinkService) expiry(ctx context.Context) time.Duration {
+	setting, err := s.systemSettingRepo.Get(ctx, models.SettingPaymentLinkExpiryHours)
+	if err == nil && setting != nil {
+		if hours, err := strconv.Atoi(setting.SettingValue); err == nil && hours > 0 {
+			return time.Duration(hours) * time.Hour
+		}
+	}
+	return 72 * time.Hour
+}
+
+// CreateLink creates a link for a customer's outstanding amount, replacing their earlier
+// unpaid links
+func (s *PaymentLinkService) CreateLink(ctx context.Context, phone string, amount float64, userID int) (*models.PaymentLink, error) {
+	baseURL := s.baseURL(ctx)
+	if baseURL == "" {
+		return nil, ErrPaymentLinksDisabled
+	}
+	amount = math.Round(amount*100) / 100
+	if amount <= 0 {
+		return nil, fmt.Errorf("amount must be greater than 0")
+	}
+	customer, err := s.customerRepo.GetByPhone(ctx, phone)
+	if err != nil || customer == nil {
+		return nil, fmt.Errorf("customer %s not found", phone)
+	}
+
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return nil, fmt.Errorf("failed to generate link token: %w", err)
+	}
+	link := &models.PaymentLink{
+		Token:         hex.EncodeToString(token),
+		CustomerID:    customer.ID,
+		CustomerPhone: customer.Phone,
+		CustomerName:  customer.Name,
+		Amount:        amount,
+		ExpiresAt:     time.Now().Add(s.expiry(ctx)),
+	}
+	if userID > 0 {
+		link.CreatedByUserID = &userID
+	}
+	if err := s.repo.Create(ctx, link); err != nil {
+		return nil, err
+	}
+	link.URL = baseURL + "/pay/" + link.Token
+	return link, nil
+}
+
+// SendLink creates a link and sends it to the customer after message (a balance reminder
+// when blank)
+func (s *PaymentLinkService) SendLink(ctx context.Context, phone string, amount float64, message string, userID int) (*models.PaymentLink, error) {
+	if s.smsService == nil {
+		return nil, fmt.Errorf("SMS service not configured")
+	}
+	link, err := s.CreateLink(ctx, phone, amount, userID)
+	if err != nil {
+		return nil, err
+	}
+	if message == "" {
+		message = fmt.Sprintf("Dear %s, your pending balance at Cold Storage is Rs.%.2f.", link.CustomerName, link.Amount)
+	}
+	if err := s.smsService.SendSMS(link.CustomerPhone, message+" Pay online: "+link.URL, models.SMSTypePaymentReminder, link.CustomerID); err != nil {
+		return link, fmt.Errorf("failed to send payment link: %w", err)
+	}
+	return link, nil
+}
+
+// ListLatest returns each customer's most recent link
+func (s *PaymentLinkService) ListLatest(ctx context.Context) ([]*models.PaymentLink, error) {
+	return s.repo.ListLatest(ctx)
+}
+
+// Cancel cancels an unpaid link
+func (s *PaymentLinkService) Cancel(ctx context.Context, id int) error {
+	ok, err := s.repo.Cancel(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPaymentLinkClosed
+	}
+	return nil
+}
+
+func (s *PaymentLinkService) getLink(ctx context.Context, token string) (*models.PaymentLink, error) {
+	link, err := s.repo.GetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if link == nil {
+		return nil, ErrPaymentLinkNotFound
+	}
+	return link, nil
+}
+
+// payableAmount returns the link amount, capped at what the customer owes now (they may
+// have paid part of it at the counter since the link was sent)
+func (s *PaymentLinkService) payableAmount(ctx context.Context, link *models.PaymentLink) (float64, error) {
+	if s.portalService == nil {
+		return link.Amount, nil
+	}
+	dashboard, err := s.portalService.GetDashboardData(ctx, link.CustomerID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to calculate outstanding rent: %w", err)
+	}
+	return math.Max(0, math.Min(link.Amount, math.Round(dashboard.TotalBalance*100)/100)), nil
+}
+
+// Checkout returns what the link's checkout page shows, recording that the link was opened
+func (s *PaymentLinkService) Checkout(ctx context.Context, token string) (*models.PaymentLinkCheckout, error) {
+	link, err := s.getLink(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if link.Status == models.PaymentLinkStatusSent {
+		if err := s.repo.MarkOpened(ctx, link.ID); err != nil {
+			log.Printf("[PaymentLink] %v", err)
+		}
+		link.Status = models.PaymentLinkStatusOpened
+	}
+
+	checkout := &models.PaymentLinkCheckout{
+		CustomerName: link.CustomerName,
+		Amount:       link.Amount,
+		Status:       link.Status,
+		ExpiresAt:    link.ExpiresAt,
+	}
+	if link.IsPayable() {
+		if checkout.Amount, err = s.payableAmount(ctx, link); err != nil {
+			return nil, err
+		}
+		if s.razorpayService != nil {
+			checkout.Payment = s.razorpayService.GetPaymentStatus(ctx)
+		}
+	}
+	return checkout, nil
+}
+
+// CreateOrder starts checkout for a link's payable amount
+func (s *PaymentLinkService) CreateOrder(ctx context.Context, token string) (*models.CreateOrderResponse, error) {
+	if s.razorpayService == nil {
+		return nil, fmt.Errorf("online payments are not available")
+	}
+	link, err := s.getLink(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if !link.IsPayable() {
+		return nil, ErrPaymentLinkClosed
+	}
+	amount, err := s.payableAmount(ctx, link)
+	if err != nil {
+		return nil, err
+	}
+	if amount <= 0 {
+		return nil, fmt.Errorf("nothing is outstanding on this account")
+	}
+	customer, err := s.customerRepo.Get(ctx, link.CustomerID)
+	if err != nil {
+		return nil, fmt.Errorf("customer not found: %w", err)
+	}
+
+	return s.razorpayService.CreateOrder(ctx, customer, &models.CreateOnlinePaymentRequest{
+		Amount:        amount,
+		PaymentScope:  string(models.PaymentScopeAccount),
+		PaymentLinkID: &link.ID,
+	})
+}
+
+// VerifyPayment confirms the checkout of an order created through the link
+func (s *PaymentLinkService) VerifyPayment(ctx context.Context, token string, req *models.VerifyPaymentRequest) (*models.OnlineTransaction, error) {
+	if s.razorpayService == nil {
+		return nil, fmt.Errorf("online payments are not available")
+	}
+	link, err := s.getLink(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := s.razorpayService.GetTransactionByOrderID(ctx, req.RazorpayOrderID)
+	if err != nil || tx.PaymentLinkID == nil || *tx.PaymentLinkID != link.ID {
+		return nil, fmt.Errorf("order does not belong to this payment link")
+	}
+	return s.razorpayService.VerifyPayment(ctx, req)
+}
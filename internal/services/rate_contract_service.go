@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/timeutil"
+)
+
+// RateContractService manages customer-specific negotiated rent rates.
+// Contracts start as pending and only affect rent once an admin approves them.
+type RateContractService struct {
+	ContractRepo     *repositories.RateContractRepository
+	CustomerRepo     *repositories.CustomerRepository
+	FamilyMemberRepo *repositories.FamilyMemberRepository
+}
+
+func NewRateContractService(contractRepo *repositories.RateContractRepository, customerRepo *repositories.CustomerRepository, familyMemberRepo *repositories.FamilyMemberRepository) *RateContractService {
+	return &RateContractService{
+		ContractRepo:     contractRepo,
+		CustomerRepo:     customerRepo,
+		FamilyMemberRepo: familyMemberRepo,
+	}
+}
+
+// ListContracts returns contracts, optionally filtered by customer and status
+func (s *RateContractService) ListContracts(ctx context.Context, customerID int, status string) ([]*models.RateContract, error) {
+	return s.ContractRepo.List(ctx, customerID, status)
+}
+
+// GetContract returns a single contract
+func (s *RateContractService) GetContract(ctx context.Context, id int) (*models.RateContract, error) {
+	return s.ContractRepo.Get(ctx, id)
+}
+
+// CreateContract validates and records a pending contract
+func (s *RateContractService) CreateContract(ctx context.Context, req *models.CreateRateContractRequest, userID int) (*models.RateContract, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("name is required")
+	}
+	if req.CustomerID <= 0 {
+		return nil, errors.New("customer is required")
+	}
+	if req.RatePerItem < 0 {
+		return nil, errors.New("rate per item cannot be negative")
+	}
+	if req.ExtraMonthRate != nil && *req.ExtraMonthRate < 0 {
+		return nil, errors.New("extra month rate cannot be negative")
+	}
+	category := strings.ToLower(strings.TrimSpace(req.ThockCategory))
+	if category != "" && category != "seed" && category != "sell" {
+		return nil, errors.New("thock category must be 'seed', 'sell' or empty")
+	}
+
+	if _, err := s.CustomerRepo.Get(ctx, req.CustomerID); err != nil {
+		return nil, errors.New("customer not found")
+	}
+	if req.FamilyMemberID != nil {
+		fm, err := s.FamilyMemberRepo.Get(ctx, *req.FamilyMemberID)
+		if err != nil || fm.CustomerID != req.CustomerID {
+			return nil, errors.New("family member does not belong to this customer")
+		}
+	}
+
+	validFrom := timeutil.StartOfDay(timeutil.Now())
+	if req.ValidFrom != "" {
+		parsed, err := time.Parse("2006-01-02", req.ValidFrom)
+		if err != nil {
+			return nil, errors.New("invalid valid_from date. Use YYYY-MM-DD")
+		}
+		validFrom = parsed
+	}
+	var validTo *time.Time
+	if req.ValidTo != "" {
+		parsed, err := time.Parse("2006-01-02", req.ValidTo)
+		if err != nil {
+			return nil, errors.New("invalid valid_to date. Use YYYY-MM-DD")
+		}
+		if parsed.Before(validFrom) {
+			return nil, errors.New("valid_to cannot be before valid_from")
+		}
+		validTo = &parsed
+	}
+
+	contract := &models.RateContract{
+		Name:           name,
+		CustomerID:     req.CustomerID,
+		FamilyMemberID: req.FamilyMemberID,
+		ThockCategory:  category,
+		Variety:        strings.TrimSpace(req.Variety),
+		BagSize:        strings.TrimSpace(req.BagSize),
+		RatePerItem:    req.RatePerItem,
+		ExtraMonthRate: req.ExtraMonthRate,
+		ValidFrom:      validFrom,
+		ValidTo:        validTo,
+		Status:         models.RateContractPending,
+		Notes:          req.Notes,
+	}
+	if userID > 0 {
+		contract.CreatedByUserID = &userID
+	}
+	if err := s.ContractRepo.Create(ctx, contract); err != nil {
+		return nil, err
+	}
+	return s.ContractRepo.Get(ctx, contract.ID)
+}
+
+// ApproveContract activates a pending contract
+func (s *RateContractService) ApproveContract(ctx context.Context, id int, userID int) (*models.RateContract, error) {
+	if err := s.ContractRepo.Approve(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	return s.ContractRepo.Get(ctx, id)
+}
+
+// RejectContract rejects a pending contract
+func (s *RateContractService) RejectContract(ctx context.Context, id int, reason string) (*models.RateContract, error) {
+	if err := s.ContractRepo.UpdateStatus(ctx, id, models.RateContractPending, models.RateContractRejected, reason); err != nil {
+		return nil, err
+	}
+	return s.ContractRepo.Get(ctx, id)
+}
+
+// RevokeContract stops an active contract from applying to rent
+func (s *RateContractService) RevokeContract(ctx context.Context, id int, reason string) (*models.RateContract, error) {
+	if err := s.ContractRepo.UpdateStatus(ctx, id, models.RateContractActive, models.RateContractRevoked, reason); err != nil {
+		return nil, err
+	}
+	return s.ContractRepo.Get(ctx, id)
+}
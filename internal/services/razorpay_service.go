@@ -2,26 +2,35 @@ package services
 
 import (
 	"context"
-	"crypto/hmac"
-	"crypto/sha256"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"cold-backend/internal/models"
+	"cold-backend/internal/payment"
 	"cold-backend/internal/repositories"
-
-	razorpay "github.com/razorpay/razorpay-go"
 )
 
+// OnlineRefundReferenceType is the ledger reference type of REFUND entries for online payment refunds
+const OnlineRefundReferenceType = "online_refund"
+
+// RazorpayService takes customer portal payments through the payment gateway selected
+// in settings (Razorpay unless payment_gateway says otherwise)
 type RazorpayService struct {
 	transactionRepo   *repositories.OnlineTransactionRepository
 	rentPaymentRepo   *repositories.RentPaymentRepository
 	ledgerRepo        *repositories.LedgerRepository
 	customerRepo      *repositories.CustomerRepository
 	systemSettingRepo *repositories.SystemSettingRepository
+	ledgerService     *LedgerService // Journals and allocates online payments and refunds
+	refundRepo        *repositories.OnlineRefundRepository
+	fakeGateway       *payment.FakeGateway // Local test gateway, only when enabled at startup
+	paymentLinkRepo   *repositories.PaymentLinkRepository
 	// Fallback credentials from environment (used if DB credentials not set)
 	envKeyID         string
 	envKeySecret     string
@@ -48,6 +57,27 @@ func NewRazorpayService(
 	}
 }
 
+// SetLedgerService posts online payments and refunds to the double-entry journal and
+// allocates them to the customer's charges
+func (s *RazorpayService) SetLedgerService(ledgerService *LedgerService) {
+	s.ledgerService = ledgerService
+}
+
+// SetRefundRepo enables refunds of online payments through their gateway
+func (s *RazorpayService) SetRefundRepo(refundRepo *repositories.OnlineRefundRepository) {
+	s.refundRepo = refundRepo
+}
+
+// SetPaymentLinkRepo marks payment links paid when an order created from one is paid
+func (s *RazorpayService) SetPaymentLinkRepo(paymentLinkRepo *repositories.PaymentLinkRepository) {
+	s.paymentLinkRepo = paymentLinkRepo
+}
+
+// SetFakeGateway makes the in-memory test gateway selectable (payment_gateway = fake)
+func (s *RazorpayService) SetFakeGateway(fakeGateway *payment.FakeGateway) {
+	s.fakeGateway = fakeGateway
+}
+
 // getCredentials returns the Razorpay credentials (from DB first, then env fallback)
 func (s *RazorpayService) getCredentials(ctx context.Context) (keyID, keySecret, webhookSecret string) {
 	// Try to get from database first
@@ -75,31 +105,44 @@ func (s *RazorpayService) getCredentials(ctx context.Context) (keyID, keySecret,
 	return keyID, keySecret, webhookSecret
 }
 
-// getClient returns a Razorpay client with current credentials
-func (s *RazorpayService) getClient(ctx context.Context) *razorpay.Client {
-	keyID, keySecret, _ := s.getCredentials(ctx)
-	if keyID == "" || keySecret == "" {
-		return nil
+// getSetting returns a system setting's value ("" if not set)
+func (s *RazorpayService) getSetting(ctx context.Context, key string) string {
+	if setting, err := s.systemSettingRepo.Get(ctx, key); err == nil && setting != nil {
+		return strings.TrimSpace(setting.SettingValue)
 	}
-	return razorpay.NewClient(keyID, keySecret)
+	return ""
 }
 
-// getKeyID returns the current key ID for frontend
-func (s *RazorpayService) getKeyID(ctx context.Context) string {
-	keyID, _, _ := s.getCredentials(ctx)
-	return keyID
-}
-
-// getKeySecret returns the current key secret for signature verification
-func (s *RazorpayService) getKeySecret(ctx context.Context) string {
-	_, keySecret, _ := s.getCredentials(ctx)
-	return keySecret
+// activeGateway returns the gateway new payments go through (payment_gateway setting)
+func (s *RazorpayService) activeGateway(ctx context.Context) string {
+	if name := s.getSetting(ctx, "payment_gateway"); name != "" {
+		return name
+	}
+	return payment.GatewayRazorpay
 }
 
-// getWebhookSecret returns the current webhook secret
-func (s *RazorpayService) getWebhookSecret(ctx context.Context) string {
-	_, _, webhookSecret := s.getCredentials(ctx)
-	return webhookSecret
+// getGateway returns a gateway with its current credentials
+func (s *RazorpayService) getGateway(ctx context.Context, name string) (payment.PaymentGateway, error) {
+	switch name {
+	case "", payment.GatewayRazorpay:
+		keyID, keySecret, webhookSecret := s.getCredentials(ctx)
+		if keyID == "" || keySecret == "" {
+			return nil, fmt.Errorf("razorpay: %w", payment.ErrNotConfigured)
+		}
+		return payment.NewRazorpayGateway(keyID, keySecret, webhookSecret), nil
+	case payment.GatewayCashfree:
+		appID, secretKey := s.getSetting(ctx, "cashfree_app_id"), s.getSetting(ctx, "cashfree_secret_key")
+		if appID == "" || secretKey == "" {
+			return nil, fmt.Errorf("cashfree: %w", payment.ErrNotConfigured)
+		}
+		return payment.NewCashfreeGateway(appID, secretKey, s.getSetting(ctx, "cashfree_environment")), nil
+	case payment.GatewayFake:
+		if s.fakeGateway == nil {
+			return nil, fmt.Errorf("the test payment gateway is not enabled on this server")
+		}
+		return s.fakeGateway, nil
+	}
+	return nil, fmt.Errorf("unknown payment gateway: %s", name)
 }
 
 // IsEnabled checks if online payments are enabled in system settings
@@ -137,25 +180,26 @@ func (s *RazorpayService) CalculateFee(amount float64, feePercent float64) float
 
 // GetPaymentStatus returns payment status info for frontend
 func (s *RazorpayService) GetPaymentStatus(ctx context.Context) *models.PaymentStatusResponse {
-	enabled := s.IsEnabled(ctx)
-	feePercent := s.GetFeePercent(ctx)
-
-	return &models.PaymentStatusResponse{
-		Enabled:    enabled,
-		FeePercent: feePercent,
-		KeyID:      s.getKeyID(ctx),
+	status := &models.PaymentStatusResponse{
+		Enabled:    s.IsEnabled(ctx),
+		Gateway:    s.activeGateway(ctx),
+		FeePercent: s.GetFeePercent(ctx),
+	}
+	if gateway, err := s.getGateway(ctx, status.Gateway); err == nil {
+		status.KeyID = gateway.CheckoutKey()
 	}
+	return status
 }
 
-// CreateOrder creates a Razorpay order and stores transaction record
+// CreateOrder creates an order at the active gateway and stores transaction record
 func (s *RazorpayService) CreateOrder(ctx context.Context, customer *models.Customer, req *models.CreateOnlinePaymentRequest) (*models.CreateOrderResponse, error) {
 	if !s.IsEnabled(ctx) {
 		return nil, fmt.Errorf("online payments are currently disabled")
 	}
 
-	client := s.getClient(ctx)
-	if client == nil {
-		return nil, fmt.Errorf("razorpay client not configured")
+	gateway, err := s.getGateway(ctx, s.activeGateway(ctx))
+	if err != nil {
+		return nil, err
 	}
 
 	// Calculate fee
@@ -163,31 +207,25 @@ func (s *RazorpayService) CreateOrder(ctx context.Context, customer *models.Cust
 	feeAmount := s.CalculateFee(req.Amount, feePercent)
 	totalAmount := req.Amount + feeAmount
 
-	// Convert to paise (Razorpay uses paise)
-	amountPaise := int(totalAmount * 100)
-
-	// Create Razorpay order
-	orderData := map[string]interface{}{
-		"amount":   amountPaise,
-		"currency": "INR",
-		"receipt":  fmt.Sprintf("rcpt_%d_%d", customer.ID, time.Now().Unix()),
-		"notes": map[string]interface{}{
-			"customer_id":    customer.ID,
-			"customer_phone": customer.Phone,
-			"payment_scope":  req.PaymentScope,
-		},
-	}
+	// Convert to paise (gateways take paise)
+	amountPaise := int64(math.Round(totalAmount * 100))
 
-	order, err := client.Order.Create(orderData, nil)
+	order, err := gateway.CreateOrder(ctx, &payment.OrderRequest{
+		Amount:        amountPaise,
+		Receipt:       fmt.Sprintf("rcpt_%d_%d", customer.ID, time.Now().Unix()),
+		CustomerID:    customer.ID,
+		CustomerName:  customer.Name,
+		CustomerPhone: customer.Phone,
+		Notes:         map[string]string{"payment_scope": req.PaymentScope},
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to create razorpay order: %w", err)
+		return nil, err
 	}
 
-	orderID := order["id"].(string)
-
 	// Store transaction record
 	tx := &models.OnlineTransaction{
-		RazorpayOrderID:  orderID,
+		Gateway:          gateway.Name(),
+		RazorpayOrderID:  order.ID,
 		CustomerID:       customer.ID,
 		CustomerPhone:    customer.Phone,
 		CustomerName:     customer.Name,
@@ -196,6 +234,7 @@ func (s *RazorpayService) CreateOrder(ctx context.Context, customer *models.Cust
 		ThockNumber:      req.ThockNumber,
 		FamilyMemberName: req.FamilyMemberName,
 		PaymentScope:     req.PaymentScope,
+		PaymentLinkID:    req.PaymentLinkID,
 		Amount:           req.Amount,
 		FeeAmount:        feeAmount,
 		TotalAmount:      totalAmount,
@@ -206,27 +245,22 @@ func (s *RazorpayService) CreateOrder(ctx context.Context, customer *models.Cust
 	}
 
 	return &models.CreateOrderResponse{
-		OrderID:       orderID,
+		Gateway:       gateway.Name(),
+		OrderID:       order.ID,
+		SessionID:     order.SessionID,
 		Amount:        int(req.Amount * 100),
 		FeeAmount:     int(feeAmount * 100),
-		TotalAmount:   amountPaise,
+		TotalAmount:   int(amountPaise),
 		Currency:      "INR",
-		KeyID:         s.getKeyID(ctx),
+		KeyID:         gateway.CheckoutKey(),
 		CustomerName:  customer.Name,
 		CustomerPhone: customer.Phone,
 		FeePercent:    feePercent,
 	}, nil
 }
 
-// VerifyPayment verifies the payment signature and marks as success/failed
+// VerifyPayment confirms a checkout with the transaction's gateway and marks it success/failed
 func (s *RazorpayService) VerifyPayment(ctx context.Context, req *models.VerifyPaymentRequest) (*models.OnlineTransaction, error) {
-	// Verify signature
-	if !s.verifySignature(ctx, req.RazorpayOrderID, req.RazorpayPaymentID, req.RazorpaySignature) {
-		// Mark as failed
-		_ = s.transactionRepo.UpdatePaymentFailed(ctx, req.RazorpayOrderID, "Invalid signature")
-		return nil, fmt.Errorf("invalid payment signature")
-	}
-
 	// Get transaction
 	tx, err := s.transactionRepo.GetByOrderID(ctx, req.RazorpayOrderID)
 	if err != nil {
@@ -234,73 +268,49 @@ func (s *RazorpayService) VerifyPayment(ctx context.Context, req *models.VerifyP
 	}
 
 	// Check if already processed
-	if tx.Status == models.OnlineTxStatusSuccess {
+	if tx.Status == models.OnlineTxStatusSuccess || tx.Status == models.OnlineTxStatusRefunded {
 		return tx, nil // Already processed, return existing
 	}
 
-	// Fetch payment details from Razorpay
-	client := s.getClient(ctx)
-	var payment map[string]interface{}
-	if client != nil {
-		payment, err = client.Payment.Fetch(req.RazorpayPaymentID, nil, nil)
-		if err != nil {
-			log.Printf("[Razorpay] Failed to fetch payment details: %v", err)
-		}
+	gateway, err := s.getGateway(ctx, tx.Gateway)
+	if err != nil {
+		return nil, err
+	}
+	details, err := gateway.VerifyPayment(ctx, req.RazorpayOrderID, req.RazorpayPaymentID, req.RazorpaySignature)
+	if errors.Is(err, payment.ErrInvalidSignature) {
+		// Mark as failed
+		_ = s.transactionRepo.UpdatePaymentFailed(ctx, req.RazorpayOrderID, "Invalid signature")
+		return nil, fmt.Errorf("invalid payment signature")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify payment: %w", err)
 	}
 
-	// Extract payment details
-	utr := ""
-	method := ""
-	bank := ""
-	vpa := ""
-	cardLast4 := ""
-	cardNetwork := ""
-
-	if payment != nil {
-		if v, ok := payment["acquirer_data"].(map[string]interface{}); ok {
-			if u, ok := v["upi_transaction_id"].(string); ok {
-				utr = u
-			}
-			if u, ok := v["bank_transaction_id"].(string); ok && utr == "" {
-				utr = u
-			}
-			if u, ok := v["rrn"].(string); ok && utr == "" {
-				utr = u
-			}
-		}
-
-		if m, ok := payment["method"].(string); ok {
-			method = m
-		}
-		if b, ok := payment["bank"].(string); ok {
-			bank = b
-		}
-		if v, ok := payment["vpa"].(string); ok {
-			vpa = v
-		}
-		if card, ok := payment["card"].(map[string]interface{}); ok {
-			if last4, ok := card["last4"].(string); ok {
-				cardLast4 = last4
-			}
-			if network, ok := card["network"].(string); ok {
-				cardNetwork = network
-			}
+	switch details.Status {
+	case payment.StatusFailed:
+		reason := details.FailureReason
+		if reason == "" {
+			reason = "Payment failed"
 		}
+		_ = s.transactionRepo.UpdatePaymentFailed(ctx, req.RazorpayOrderID, reason)
+		return nil, fmt.Errorf("payment failed: %s", reason)
+	case payment.StatusPending:
+		return nil, fmt.Errorf("payment has not been completed yet")
 	}
 
 	// Update transaction as successful
 	err = s.transactionRepo.UpdatePaymentSuccess(
-		ctx, req.RazorpayOrderID, req.RazorpayPaymentID, req.RazorpaySignature,
-		utr, method, bank, vpa, cardLast4, cardNetwork,
+		ctx, req.RazorpayOrderID, details.PaymentID, req.RazorpaySignature,
+		details.UTR, details.Method, details.Bank, details.VPA, details.CardLast4, details.CardNetwork,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update transaction: %w", err)
 	}
 
 	// Create rent payment and ledger entry
-	err = s.createRentPaymentAndLedgerEntry(ctx, tx, utr, req.RazorpayPaymentID)
+	err = s.createRentPaymentAndLedgerEntry(ctx, tx, details.UTR, details.PaymentID)
 	if err != nil {
-		log.Printf("[Razorpay] Failed to create rent payment: %v", err)
+		log.Printf("[Payment] Failed to create rent payment: %v", err)
 		// Don't fail the verification, payment is still successful
 	}
 
@@ -310,30 +320,14 @@ func (s *RazorpayService) VerifyPayment(ctx context.Context, req *models.VerifyP
 	return tx, nil
 }
 
-// verifySignature verifies the Razorpay payment signature
-func (s *RazorpayService) verifySignature(ctx context.Context, orderID, paymentID, signature string) bool {
-	keySecret := s.getKeySecret(ctx)
-	if keySecret == "" {
+// VerifyWebhook checks a webhook's signature with the gateway it was sent by
+func (s *RazorpayService) VerifyWebhook(ctx context.Context, gatewayName string, body []byte, header http.Header) bool {
+	gateway, err := s.getGateway(ctx, gatewayName)
+	if err != nil {
+		log.Printf("[Payment] Webhook for unavailable gateway: %v", err)
 		return false
 	}
-	data := orderID + "|" + paymentID
-	h := hmac.New(sha256.New, []byte(keySecret))
-	h.Write([]byte(data))
-	expectedSignature := hex.EncodeToString(h.Sum(nil))
-	return hmac.Equal([]byte(expectedSignature), []byte(signature))
-}
-
-// VerifyWebhookSignature verifies the webhook signature
-func (s *RazorpayService) VerifyWebhookSignature(ctx context.Context, body []byte, signature string) bool {
-	webhookSecret := s.getWebhookSecret(ctx)
-	if webhookSecret == "" {
-		return true // Skip verification if not configured
-	}
-
-	h := hmac.New(sha256.New, []byte(webhookSecret))
-	h.Write(body)
-	expectedSignature := hex.EncodeToString(h.Sum(nil))
-	return hmac.Equal([]byte(expectedSignature), []byte(signature))
+	return gateway.VerifyWebhook(body, header)
 }
 
 // createRentPaymentAndLedgerEntry creates ledger entry after successful payment
@@ -364,7 +358,7 @@ func (s *RazorpayService) createRentPaymentAndLedgerEntry(ctx context.Context, t
 		ReferenceType:    "online_transaction",
 		FamilyMemberID:   tx.FamilyMemberID,
 		FamilyMemberName: tx.FamilyMemberName,
-		Notes:            fmt.Sprintf("Razorpay Payment ID: %s, Fee: ₹%.2f", paymentID, tx.FeeAmount),
+		Notes:            fmt.Sprintf("%s Payment ID: %s, Fee: ₹%.2f", payment.DisplayName(tx.Gateway), paymentID, tx.FeeAmount),
 		CreatedByUserID:  0, // System - Online payment
 	})
 	if err != nil {
@@ -374,109 +368,114 @@ func (s *RazorpayService) createRentPaymentAndLedgerEntry(ctx context.Context, t
 	// Link transaction to ledger entry
 	_ = s.transactionRepo.LinkToRentPayment(ctx, tx.RazorpayOrderID, 0, ledgerEntry.ID)
 
+	if tx.PaymentLinkID != nil && s.paymentLinkRepo != nil {
+		if _, err := s.paymentLinkRepo.MarkPaid(ctx, tx.ID); err != nil {
+			log.Printf("[Payment] Failed to mark payment link #%d paid: %v", *tx.PaymentLinkID, err)
+		}
+	}
+
+	// Dr Razorpay Clearing / Cr Customer Receivables, then settle the customer's charges
+	if s.ledgerService != nil {
+		s.ledgerService.EntriesPosted(ctx, ledgerEntry)
+	}
+
 	return nil
 }
 
-// ProcessWebhook processes Razorpay webhook events
-func (s *RazorpayService) ProcessWebhook(ctx context.Context, event string, paymentData map[string]interface{}) error {
-	switch event {
-	case "payment.captured":
-		return s.handlePaymentCaptured(ctx, paymentData)
-	case "payment.failed":
-		return s.handlePaymentFailed(ctx, paymentData)
+// ProcessWebhook processes a verified webhook from the named gateway
+func (s *RazorpayService) ProcessWebhook(ctx context.Context, gatewayName string, body []byte) error {
+	gateway, err := s.getGateway(ctx, gatewayName)
+	if err != nil {
+		return err
+	}
+	event, err := gateway.ParseWebhook(body)
+	if err != nil {
+		return err
+	}
+
+	switch event.Type {
+	case payment.EventPaymentCaptured:
+		return s.handlePaymentCaptured(ctx, gateway.Name(), event.Payment)
+	case payment.EventPaymentFailed:
+		return s.handlePaymentFailed(ctx, gateway.Name(), event.Payment)
+	case payment.EventRefundProcessed:
+		return s.handleRefundEvent(ctx, event.Refund, true)
+	case payment.EventRefundFailed:
+		return s.handleRefundEvent(ctx, event.Refund, false)
 	default:
-		log.Printf("[Razorpay] Unhandled webhook event: %s", event)
 		return nil
 	}
 }
 
-func (s *RazorpayService) handlePaymentCaptured(ctx context.Context, paymentData map[string]interface{}) error {
-	paymentEntity, ok := paymentData["payment"].(map[string]interface{})
-	if !ok {
-		paymentEntity = paymentData
+// webhookTransaction returns the transaction a payment webhook is about, or nil if it
+// belongs to another gateway
+func (s *RazorpayService) webhookTransaction(ctx context.Context, gatewayName string, p *payment.PaymentDetails) (*models.OnlineTransaction, error) {
+	if p == nil || p.OrderID == "" {
+		return nil, fmt.Errorf("missing order_id in webhook")
 	}
-	entity, ok := paymentEntity["entity"].(map[string]interface{})
-	if !ok {
-		entity = paymentEntity
+	tx, err := s.transactionRepo.GetByOrderID(ctx, p.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("transaction not found: %w", err)
 	}
+	if tx.Gateway != gatewayName {
+		log.Printf("[Payment] Ignoring %s webhook for %s order %s", gatewayName, tx.Gateway, p.OrderID)
+		return nil, nil
+	}
+	return tx, nil
+}
 
-	orderID, _ := entity["order_id"].(string)
-	paymentID, _ := entity["id"].(string)
-
-	if orderID == "" {
-		return fmt.Errorf("missing order_id in webhook")
+func (s *RazorpayService) handlePaymentCaptured(ctx context.Context, gatewayName string, p *payment.PaymentDetails) error {
+	tx, err := s.webhookTransaction(ctx, gatewayName, p)
+	if err != nil || tx == nil {
+		return err
 	}
 
 	// Check if already processed
-	processed, _ := s.transactionRepo.IsPaymentProcessed(ctx, orderID)
+	processed, _ := s.transactionRepo.IsPaymentProcessed(ctx, p.OrderID)
 	if processed {
-		log.Printf("[Razorpay] Payment already processed: %s", orderID)
+		log.Printf("[Payment] Payment already processed: %s", p.OrderID)
 		return nil
 	}
 
-	// Get transaction
-	tx, err := s.transactionRepo.GetByOrderID(ctx, orderID)
-	if err != nil {
-		return fmt.Errorf("transaction not found: %w", err)
-	}
-
-	// Extract payment details
-	utr := ""
-	method := ""
-	bank := ""
-	vpa := ""
-
-	if acquirerData, ok := entity["acquirer_data"].(map[string]interface{}); ok {
-		if u, ok := acquirerData["upi_transaction_id"].(string); ok {
-			utr = u
-		}
-		if u, ok := acquirerData["bank_transaction_id"].(string); ok && utr == "" {
-			utr = u
-		}
-	}
-
-	if m, ok := entity["method"].(string); ok {
-		method = m
-	}
-	if b, ok := entity["bank"].(string); ok {
-		bank = b
-	}
-	if v, ok := entity["vpa"].(string); ok {
-		vpa = v
-	}
-
 	// Update transaction
-	err = s.transactionRepo.UpdatePaymentSuccess(ctx, orderID, paymentID, "", utr, method, bank, vpa, "", "")
+	err = s.transactionRepo.UpdatePaymentSuccess(ctx, p.OrderID, p.PaymentID, "", p.UTR, p.Method, p.Bank, p.VPA, p.CardLast4, p.CardNetwork)
 	if err != nil {
 		return fmt.Errorf("failed to update transaction: %w", err)
 	}
 
 	// Create rent payment and ledger entry
-	return s.createRentPaymentAndLedgerEntry(ctx, tx, utr, paymentID)
+	return s.createRentPaymentAndLedgerEntry(ctx, tx, p.UTR, p.PaymentID)
 }
 
-func (s *RazorpayService) handlePaymentFailed(ctx context.Context, paymentData map[string]interface{}) error {
-	paymentEntity, ok := paymentData["payment"].(map[string]interface{})
-	if !ok {
-		paymentEntity = paymentData
-	}
-	entity, ok := paymentEntity["entity"].(map[string]interface{})
-	if !ok {
-		entity = paymentEntity
+func (s *RazorpayService) handlePaymentFailed(ctx context.Context, gatewayName string, p *payment.PaymentDetails) error {
+	tx, err := s.webhookTransaction(ctx, gatewayName, p)
+	if err != nil || tx == nil {
+		return err
 	}
 
-	orderID, _ := entity["order_id"].(string)
-	reason := "Payment failed"
-
-	if errorData, ok := entity["error_description"].(string); ok {
-		reason = errorData
+	reason := p.FailureReason
+	if reason == "" {
+		reason = "Payment failed"
 	}
+	return s.transactionRepo.UpdatePaymentFailed(ctx, p.OrderID, reason)
+}
 
-	if orderID != "" {
-		return s.transactionRepo.UpdatePaymentFailed(ctx, orderID, reason)
+// FetchSettlements returns what a gateway settled on a day, for gateways that report it
+func (s *RazorpayService) FetchSettlements(ctx context.Context, gatewayName string, day time.Time) ([]*payment.SettlementLine, error) {
+	gateway, err := s.getGateway(ctx, gatewayName)
+	if err != nil {
+		return nil, err
 	}
+	reporter, ok := gateway.(payment.SettlementReporter)
+	if !ok {
+		return nil, fmt.Errorf("%s does not report settlements", payment.DisplayName(gatewayName))
+	}
+	return reporter.SettlementRecon(ctx, day)
+}
 
-	return nil
+// GetTransactionByOrderID returns the transaction for a gateway order
+func (s *RazorpayService) GetTransactionByOrderID(ctx context.Context, orderID string) (*models.OnlineTransaction, error) {
+	return s.transactionRepo.GetByOrderID(ctx, orderID)
 }
 
 // GetTransactionHistory returns transaction history for a customer
@@ -511,12 +510,303 @@ func (s *RazorpayService) ReconcilePayments(ctx context.Context) (int, error) {
 
 		err := s.createRentPaymentAndLedgerEntry(ctx, tx, utr, tx.RazorpayPaymentID)
 		if err != nil {
-			log.Printf("[Razorpay] Failed to reconcile transaction %s: %v", tx.RazorpayOrderID, err)
+			log.Printf("[Payment] Failed to reconcile transaction %s: %v", tx.RazorpayOrderID, err)
 			continue
 		}
 		reconciled++
-		log.Printf("[Razorpay] Reconciled transaction %s for customer %s, amount: %.2f", tx.RazorpayOrderID, tx.CustomerPhone, tx.Amount)
+		log.Printf("[Payment] Reconciled transaction %s for customer %s, amount: %.2f", tx.RazorpayOrderID, tx.CustomerPhone, tx.Amount)
 	}
 
 	return reconciled, nil
 }
+
+// GetRefundApprovalThreshold returns the refund amount above which admin approval is needed (default ₹5000)
+func (s *RazorpayService) GetRefundApprovalThreshold(ctx context.Context) float64 {
+	const defaultThreshold = 5000
+
+	setting, err := s.systemSettingRepo.Get(ctx, "online_refund_approval_threshold")
+	if err != nil || setting == nil {
+		return defaultThreshold
+	}
+
+	threshold, err := strconv.ParseFloat(setting.SettingValue, 64)
+	if err != nil || threshold < 0 {
+		return defaultThreshold
+	}
+
+	return threshold
+}
+
+// RequestRefund refunds part or all of a successful online payment. While the payment's
+// refunds in total stay within the approval threshold they go to the gateway straight
+// away; one that takes them past it waits for an admin.
+func (s *RazorpayService) RequestRefund(ctx context.Context, transactionID int, req *models.CreateOnlineRefundRequest, userID int) (*models.OnlineRefund, error) {
+	if s.refundRepo == nil {
+		return nil, fmt.Errorf("online refunds are not configured")
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to refund a payment")
+	}
+
+	tx, err := s.transactionRepo.GetByID(ctx, transactionID)
+	if err != nil {
+		return nil, fmt.Errorf("transaction not found: %w", err)
+	}
+	if tx.Status != models.OnlineTxStatusSuccess || tx.RazorpayPaymentID == "" {
+		return nil, fmt.Errorf("only successful payments can be refunded (status: %s)", tx.Status)
+	}
+
+	amount := roundMoney(req.Amount)
+	if amount < 0 {
+		return nil, fmt.Errorf("refund amount cannot be negative")
+	}
+	if amount == 0 {
+		if amount, err = s.refundRepo.RefundableAmount(ctx, tx.ID); err != nil {
+			return nil, err
+		}
+		if amount <= 0 {
+			return nil, fmt.Errorf("payment has already been refunded in full")
+		}
+	}
+
+	refund := &models.OnlineRefund{
+		OnlineTransactionID: tx.ID,
+		Amount:              amount,
+		Reason:              reason,
+		RequestedByUserID:   userID,
+	}
+	if err := s.refundRepo.Create(ctx, refund, s.GetRefundApprovalThreshold(ctx)); err != nil {
+		return nil, err
+	}
+
+	if refund.Status == models.OnlineRefundProcessing {
+		err = s.sendRefund(ctx, refund, tx)
+	}
+	s.updateRefundState(ctx, tx.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.refundRepo.Get(ctx, refund.ID)
+}
+
+// ApproveRefund sends a refund that was waiting for admin approval to the gateway
+func (s *RazorpayService) ApproveRefund(ctx context.Context, refundID, userID int) (*models.OnlineRefund, error) {
+	if s.refundRepo == nil {
+		return nil, fmt.Errorf("online refunds are not configured")
+	}
+	refund, err := s.refundRepo.Get(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+	if refund == nil {
+		return nil, fmt.Errorf("refund not found")
+	}
+	if refund.RequestedByUserID == userID {
+		return nil, fmt.Errorf("a refund cannot be approved by the person who requested it")
+	}
+	ok, err := s.refundRepo.Approve(ctx, refundID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("refund is not awaiting approval")
+	}
+
+	tx, err := s.transactionRepo.GetByID(ctx, refund.OnlineTransactionID)
+	if err != nil {
+		return nil, fmt.Errorf("transaction not found: %w", err)
+	}
+	err = s.sendRefund(ctx, refund, tx)
+	s.updateRefundState(ctx, tx.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.refundRepo.Get(ctx, refundID)
+}
+
+// RejectRefund turns down a refund that was waiting for admin approval
+func (s *RazorpayService) RejectRefund(ctx context.Context, refundID, userID int, reason string) (*models.OnlineRefund, error) {
+	if s.refundRepo == nil {
+		return nil, fmt.Errorf("online refunds are not configured")
+	}
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, fmt.Errorf("a reason is required to reject a refund")
+	}
+	ok, err := s.refundRepo.Reject(ctx, refundID, userID, reason)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, fmt.Errorf("refund is not awaiting approval")
+	}
+
+	refund, err := s.refundRepo.Get(ctx, refundID)
+	if err != nil {
+		return nil, err
+	}
+	s.updateRefundState(ctx, refund.OnlineTransactionID)
+	return refund, nil
+}
+
+// ListRefunds returns online refunds filtered by status and transaction (all when blank/0)
+func (s *RazorpayService) ListRefunds(ctx context.Context, status string, transactionID int) ([]*models.OnlineRefund, error) {
+	if s.refundRepo == nil {
+		return nil, fmt.Errorf("online refunds are not configured")
+	}
+	return s.refundRepo.List(ctx, status, transactionID)
+}
+
+// sendRefund asks the transaction's gateway to refund a processing refund. Gateways
+// confirm most refunds later through their refund webhooks.
+func (s *RazorpayService) sendRefund(ctx context.Context, refund *models.OnlineRefund, tx *models.OnlineTransaction) error {
+	gateway, err := s.getGateway(ctx, tx.Gateway)
+	if err != nil {
+		s.failRefund(ctx, refund.ID, err.Error())
+		return err
+	}
+
+	result, err := gateway.Refund(ctx, &payment.RefundRequest{
+		OrderID:     tx.RazorpayOrderID,
+		PaymentID:   tx.RazorpayPaymentID,
+		Amount:      int64(math.Round(refund.Amount * 100)),
+		ReferenceID: refund.ID,
+		Notes: map[string]string{
+			"customer_phone": tx.CustomerPhone,
+			"reason":         refund.Reason,
+		},
+	})
+	if err != nil {
+		s.failRefund(ctx, refund.ID, err.Error())
+		return err
+	}
+
+	if result.ID != "" {
+		if err := s.refundRepo.SetRazorpayRefundID(ctx, refund.ID, result.ID); err != nil {
+			log.Printf("[Payment] Refund #%d sent as %s but not saved: %v", refund.ID, result.ID, err)
+		}
+	}
+	switch result.Status {
+	case payment.RefundProcessed:
+		return s.completeRefund(ctx, refund.ID)
+	case payment.RefundFailed:
+		s.failRefund(ctx, refund.ID, result.FailureReason)
+		return fmt.Errorf("refund failed: %s", result.FailureReason)
+	}
+	return nil
+}
+
+// completeRefund marks a refund processed and posts its REFUND ledger entry together.
+// Safe to call more than once - only the first call posts.
+func (s *RazorpayService) completeRefund(ctx context.Context, refundID int) error {
+	if s.ledgerService == nil {
+		return fmt.Errorf("ledger is not available to post refunds")
+	}
+	refund, err := s.refundRepo.Get(ctx, refundID)
+	if err != nil {
+		return err
+	}
+	if refund == nil || refund.Status != models.OnlineRefundProcessing {
+		return nil
+	}
+	tx, err := s.transactionRepo.GetByID(ctx, refund.OnlineTransactionID)
+	if err != nil {
+		return fmt.Errorf("transaction not found: %w", err)
+	}
+	defer s.updateRefundState(ctx, tx.ID)
+
+	customer, _ := s.customerRepo.Get(ctx, tx.CustomerID)
+	customerSO := ""
+	if customer != nil {
+		customerSO = customer.SO
+	}
+
+	entry := &models.CreateLedgerEntryRequest{
+		CustomerPhone:    tx.CustomerPhone,
+		CustomerName:     tx.CustomerName,
+		CustomerSO:       customerSO,
+		EntryType:        models.LedgerEntryTypeRefund,
+		Description:      fmt.Sprintf("Online Refund | %s Refund: %s", payment.DisplayName(tx.Gateway), refund.RazorpayRefundID),
+		Debit:            refund.Amount,
+		ReferenceID:      &refund.ID,
+		ReferenceType:    OnlineRefundReferenceType,
+		FamilyMemberID:   tx.FamilyMemberID,
+		FamilyMemberName: tx.FamilyMemberName,
+		Notes:            fmt.Sprintf("%s Payment ID: %s, Reason: %s", payment.DisplayName(tx.Gateway), tx.RazorpayPaymentID, refund.Reason),
+		CreatedByUserID:  refund.RequestedByUserID,
+		PaymentMode:      tx.Gateway,
+	}
+	if err := s.ledgerService.PrepareEntry(ctx, entry); err != nil {
+		return fmt.Errorf("refund ledger entry cannot be posted: %w", err)
+	}
+
+	// The refund stays processing unless its ledger entry is posted with it
+	ledgerEntry, ok, err := s.refundRepo.Complete(ctx, refund.ID, entry)
+	if err != nil || !ok {
+		return err
+	}
+
+	// Dr Customer Receivables / Cr Razorpay Clearing
+	s.ledgerService.EntriesPosted(ctx, ledgerEntry)
+
+	log.Printf("[Payment] Refund #%d of ₹%.2f processed for %s", refund.ID, refund.Amount, tx.CustomerPhone)
+	return nil
+}
+
+// failRefund marks a processing refund as failed
+func (s *RazorpayService) failRefund(ctx context.Context, refundID int, reason string) {
+	if _, err := s.refundRepo.MarkFailed(ctx, refundID, reason); err != nil {
+		log.Printf("[Payment] %v", err)
+	}
+}
+
+// updateRefundState recomputes the refund state of a transaction
+func (s *RazorpayService) updateRefundState(ctx context.Context, transactionID int) {
+	if err := s.refundRepo.UpdateTransactionRefundState(ctx, transactionID); err != nil {
+		log.Printf("[Payment] %v", err)
+	}
+}
+
+// handleRefundEvent completes or fails a refund from a refund webhook. Refunds are
+// matched by gateway refund ID, or by our refund ID if the webhook arrives before the
+// gateway's ID was saved.
+func (s *RazorpayService) handleRefundEvent(ctx context.Context, rf *payment.Refund, processed bool) error {
+	if s.refundRepo == nil {
+		return nil
+	}
+	if rf == nil || rf.ID == "" {
+		return fmt.Errorf("missing refund id in webhook")
+	}
+
+	refund, err := s.refundRepo.GetByRazorpayRefundID(ctx, rf.ID)
+	if err != nil {
+		return err
+	}
+	if refund == nil && rf.ReferenceID > 0 {
+		if refund, err = s.refundRepo.Get(ctx, rf.ReferenceID); err != nil {
+			return err
+		}
+		if refund != nil && refund.RazorpayRefundID == "" {
+			if err := s.refundRepo.SetRazorpayRefundID(ctx, refund.ID, rf.ID); err != nil {
+				return err
+			}
+		}
+	}
+	if refund == nil {
+		log.Printf("[Payment] Webhook for unknown refund %s (issued outside the app?)", rf.ID)
+		return nil
+	}
+
+	if processed {
+		return s.completeRefund(ctx, refund.ID)
+	}
+
+	reason := rf.FailureReason
+	if reason == "" {
+		reason = "Refund failed"
+	}
+	s.failRefund(ctx, refund.ID, reason)
+	s.updateRefundState(ctx, refund.OnlineTransactionID)
+	return nil
+}
@@ -0,0 +1,337 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/timeutil"
+)
+
+// Ledger reference type for rent charged on a gate pass pickup
+const RentChargeReferenceType = "gate_pass_pickup"
+
+// Days in a storage month for duration-based rent
+const rentDaysPerMonth = 30
+
+// RentChargeService calculates duration-based rent for stock leaving storage
+// and posts it to the ledger as a CHARGE for each pickup lot.
+type RentChargeService struct {
+	TariffService *RentTariffService
+	EntryRepo     *repositories.EntryRepository
+	RoomEntryRepo *repositories.RoomEntryRepository
+	GatePassRepo  *repositories.GatePassRepository
+	PickupRepo    *repositories.GatePassPickupRepository
+	CustomerRepo  *repositories.CustomerRepository
+	LedgerService *LedgerService
+	SettingRepo   *repositories.SystemSettingRepository
+}
+
+func NewRentChargeService(
+	tariffService *RentTariffService,
+	entryRepo *repositories.EntryRepository,
+	roomEntryRepo *repositories.RoomEntryRepository,
+	gatePassRepo *repositories.GatePassRepository,
+	pickupRepo *repositories.GatePassPickupRepository,
+	customerRepo *repositories.CustomerRepository,
+	ledgerService *LedgerService,
+	settingRepo *repositories.SystemSettingRepository,
+) *RentChargeService {
+	return &RentChargeService{
+		TariffService: tariffService,
+		EntryRepo:     entryRepo,
+		RoomEntryRepo: roomEntryRepo,
+		GatePassRepo:  gatePassRepo,
+		PickupRepo:    pickupRepo,
+		CustomerRepo:  customerRepo,
+		LedgerService: ledgerService,
+		SettingRepo:   settingRepo,
+	}
+}
+
+// IsChargeOnPickupEnabled checks the rent_charge_on_pickup setting (defaults to enabled)
+func (s *RentChargeService) IsChargeOnPickupEnabled(ctx context.Context) bool {
+	if s.SettingRepo == nil {
+		return true
+	}
+	setting, err := s.SettingRepo.Get(ctx, "rent_charge_on_pickup")
+	if err != nil || setting == nil {
+		return true
+	}
+	return strings.TrimSpace(setting.SettingValue) != "false"
+}
+
+// PreviewPickupCharge calculates the rent for a pickup without posting it
+func (s *RentChargeService) PreviewPickupCharge(ctx context.Context, pickupID int) (*models.RentCharge, error) {
+	charge, _, _, err := s.calculatePickupCharge(ctx, pickupID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.LedgerService.LedgerRepo.GetActiveByReference(ctx, models.LedgerEntryTypeCharge, RentChargeReferenceType, pickupID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		charge.AlreadyCharged = true
+		charge.LedgerEntryID = &existing.ID
+	}
+	return charge, nil
+}
+
+// ChargePickup calculates the rent for a pickup and posts it as a CHARGE ledger entry.
+// A pickup is only ever charged once - if a charge already exists it is returned instead,
+// including when a concurrent request posted it first (the ledger's unique index). A
+// voided charge does not count, so the pickup can be charged again after a void.
+func (s *RentChargeService) ChargePickup(ctx context.Context, pickupID int, userID int) (*models.RentCharge, error) {
+	charge, gatePass, entry, err := s.calculatePickupCharge(ctx, pickupID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.LedgerService.LedgerRepo.GetActiveByReference(ctx, models.LedgerEntryTypeCharge, RentChargeReferenceType, pickupID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		charge.AlreadyCharged = true
+		charge.LedgerEntryID = &existing.ID
+		return charge, nil
+	}
+
+	// Nothing to post for zero-rated stock
+	if charge.Amount <= 0 {
+		return charge, nil
+	}
+
+	customerPhone, customerName, customerSO := entry.Phone, entry.Name, entry.SO
+	if s.CustomerRepo != nil {
+		if customer, err := s.CustomerRepo.Get(ctx, gatePass.CustomerID); err == nil {
+			customerPhone, customerName, customerSO = customer.Phone, customer.Name, customer.SO
+		}
+	}
+
+	ledgerEntry, err := s.LedgerService.CreateEntry(ctx, &models.CreateLedgerEntryRequest{
+		CustomerPhone:    customerPhone,
+		CustomerName:     customerName,
+		CustomerSO:       customerSO,
+		EntryType:        models.LedgerEntryTypeCharge,
+		Description:      fmt.Sprintf("Rent - Thock %s, %d items (gate pass #%d)", charge.ThockNumber, charge.Quantity, charge.GatePassID),
+		Debit:            charge.Amount,
+		ReferenceID:      &pickupID,
+		ReferenceType:    RentChargeReferenceType,
+		ThockNumber:      charge.ThockNumber,
+		FamilyMemberID:   gatePass.FamilyMemberID,
+		FamilyMemberName: gatePass.FamilyMemberName,
+		CreatedByUserID:  userID,
+		Notes:            describeRentCharge(charge),
+	})
+	if errors.Is(err, repositories.ErrDuplicateLedgerReference) {
+		existing, err := s.LedgerService.LedgerRepo.GetActiveByReference(ctx, models.LedgerEntryTypeCharge, RentChargeReferenceType, pickupID)
+		if err != nil {
+			return nil, err
+		}
+		if existing != nil {
+			charge.AlreadyCharged = true
+			charge.LedgerEntryID = &existing.ID
+			return charge, nil
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to post rent charge: %w", err)
+	}
+
+	charge.LedgerEntryID = &ledgerEntry.ID
+	return charge, nil
+}
+
+// EstimateCharge calculates the rent for taking quantity items of a thock out on a date
+func (s *RentChargeService) EstimateCharge(ctx context.Context, thockNumber string, quantity int, removedAt time.Time) (*models.RentCharge, error) {
+	if quantity <= 0 {
+		return nil, errors.New("quantity must be greater than zero")
+	}
+	entry, err := s.EntryRepo.GetByThockNumber(ctx, thockNumber)
+	if err != nil {
+		return nil, errors.New("thock not found")
+	}
+	if removedAt.IsZero() {
+		removedAt = timeutil.Now()
+	}
+
+	storedAt := s.storedAt(ctx, entry, "", "")
+	card, _ := s.TariffService.LoadRateCard(ctx)
+	return CalculateRentCharge(card.QuoteEntry(entry), entry.ThockNumber, quantity, storedAt, removedAt), nil
+}
+
+// calculatePickupCharge loads a pickup with its gate pass and entry and prices it
+func (s *RentChargeService) calculatePickupCharge(ctx context.Context, pickupID int) (*models.RentCharge, *models.GatePass, *models.Entry, error) {
+	pickup, err := s.PickupRepo.GetPickupByID(ctx, pickupID)
+	if err != nil {
+		return nil, nil, nil, errors.New("pickup not found")
+	}
+	gatePass, err := s.GatePassRepo.GetGatePass(ctx, pickup.GatePassID)
+	if err != nil {
+		return nil, nil, nil, errors.New("gate pass not found")
+	}
+
+	var entry *models.Entry
+	if gatePass.EntryID != nil {
+		entry, err = s.EntryRepo.Get(ctx, *gatePass.EntryID)
+	} else {
+		entry, err = s.EntryRepo.GetByThockNumber(ctx, gatePass.ThockNumber)
+	}
+	if err != nil {
+		return nil, nil, nil, errors.New("entry not found for thock " + gatePass.ThockNumber)
+	}
+
+	roomNo, floor := "", ""
+	if pickup.RoomNo != nil {
+		roomNo = *pickup.RoomNo
+	}
+	if pickup.Floor != nil {
+		floor = *pickup.Floor
+	}
+
+	storedAt := s.storedAt(ctx, entry, roomNo, floor)
+	card, _ := s.TariffService.LoadRateCard(ctx)
+	charge := CalculateRentCharge(card.QuoteEntry(entry), gatePass.ThockNumber, pickup.PickupQuantity, storedAt, pickup.PickupTime)
+	charge.PickupID = pickup.ID
+	charge.GatePassID = gatePass.ID
+	return charge, gatePass, entry, nil
+}
+
+// storedAt returns when the stock went into storage: the earliest room entry for the
+// thock (preferring the room/floor it was picked up from), else the entry date
+func (s *RentChargeService) storedAt(ctx context.Context, entry *models.Entry, roomNo, floor string) time.Time {
+	roomEntries, err := s.RoomEntryRepo.ListByThockNumber(ctx, entry.ThockNumber)
+	if err != nil || len(roomEntries) == 0 {
+		return entry.CreatedAt
+	}
+
+	var earliest, earliestInRoom time.Time
+	for _, re := range roomEntries {
+		if earliest.IsZero() || re.CreatedAt.Before(earliest) {
+			earliest = re.CreatedAt
+		}
+		if re.RoomNo == roomNo && re.Floor == floor {
+			if earliestInRoom.IsZero() || re.CreatedAt.Before(earliestInRoom) {
+				earliestInRoom = re.CreatedAt
+			}
+		}
+	}
+	if !earliestInRoom.IsZero() {
+		return earliestInRoom
+	}
+	return earliest
+}
+
+// CalculateRentCharge prices a lot of bags for the time it spent in storage.
+//
+//   - flat:    rate per item for the season
+//   - monthly: rate per item per storage month, part months round up
+//   - prorata: rate per item per storage month, charged by day
+//
+// Monthly and prorata billing never charge less than the tariff's minimum-season
+// slab. Storage past the season end date is charged extra_month_rate per item for
+// every month (or part) in all modes.
+func CalculateRentCharge(quote models.RentTariffQuote, thockNumber string, quantity int, storedAt, removedAt time.Time) *models.RentCharge {
+	charge := &models.RentCharge{
+		ThockNumber:    thockNumber,
+		Quantity:       quantity,
+		StoredAt:       storedAt,
+		RemovedAt:      removedAt,
+		TariffName:     quote.TariffName,
+		BillingMode:    quote.BillingMode,
+		RatePerItem:    quote.RatePerItem,
+		ExtraMonthRate: quote.ExtraMonthRate,
+		ContractID:     quote.ContractID,
+		ContractName:   quote.ContractName,
+	}
+	if charge.BillingMode == "" {
+		charge.BillingMode = models.RentBillingFlat
+	}
+	charge.StorageDays = storageDays(storedAt, removedAt)
+
+	// Days within the season (storage up to the season end date)
+	seasonDays := charge.StorageDays
+	if quote.SeasonEndDate != nil {
+		seasonDays = storageDays(storedAt, *quote.SeasonEndDate)
+		if seasonDays > charge.StorageDays {
+			seasonDays = charge.StorageDays
+		}
+		charge.ExtraMonths = int(math.Ceil(float64(daysBetween(*quote.SeasonEndDate, removedAt)) / rentDaysPerMonth))
+		if charge.ExtraMonths < 0 {
+			charge.ExtraMonths = 0
+		}
+	}
+
+	qty := float64(quantity)
+	switch charge.BillingMode {
+	case models.RentBillingMonthly, models.RentBillingProrata:
+		months := float64(seasonDays) / rentDaysPerMonth
+		if charge.BillingMode == models.RentBillingMonthly {
+			months = math.Ceil(months)
+		} else {
+			months = roundMoney(months)
+		}
+		if months < float64(quote.MinMonths) {
+			months = float64(quote.MinMonths)
+		}
+		charge.ChargedMonths = months
+		charge.BaseAmount = roundMoney(quote.RatePerItem * months * qty)
+	default:
+		charge.BaseAmount = roundMoney(quote.RatePerItem * qty)
+	}
+
+	charge.ExtraAmount = roundMoney(quote.ExtraMonthRate * float64(charge.ExtraMonths) * qty)
+	charge.Amount = roundMoney(charge.BaseAmount + charge.ExtraAmount)
+	return charge
+}
+
+// storageDays counts calendar days in storage (IST), including the day stock came in
+func storageDays(from, to time.Time) int {
+	days := daysBetween(from, to) + 1
+	if days < 0 {
+		return 0
+	}
+	return days
+}
+
+// daysBetween returns the number of IST calendar days from one date to another
+func daysBetween(from, to time.Time) int {
+	f := timeutil.ToIST(from)
+	t := timeutil.ToIST(to)
+	fromDay := time.Date(f.Year(), f.Month(), f.Day(), 0, 0, 0, 0, time.UTC)
+	toDay := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	return int(toDay.Sub(fromDay).Hours() / 24)
+}
+
+// roundMoney rounds to paise
+func roundMoney(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// describeRentCharge explains how a charge was calculated (stored in the ledger notes)
+func describeRentCharge(c *models.RentCharge) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Stored %s to %s (%d days), tariff %s (%s)",
+		timeutil.FormatIST(c.StoredAt, "02-01-2006"), timeutil.FormatIST(c.RemovedAt, "02-01-2006"),
+		c.StorageDays, c.TariffName, c.BillingMode)
+	if c.ContractID != nil {
+		fmt.Fprintf(&b, ", contract #%d %s", *c.ContractID, c.ContractName)
+	}
+	if c.BillingMode == models.RentBillingFlat {
+		fmt.Fprintf(&b, ": %d x ₹%.2f = ₹%.2f", c.Quantity, c.RatePerItem, c.BaseAmount)
+	} else {
+		fmt.Fprintf(&b, ": %d x ₹%.2f x %.2f months = ₹%.2f", c.Quantity, c.RatePerItem, c.ChargedMonths, c.BaseAmount)
+	}
+	if c.ExtraMonths > 0 {
+		fmt.Fprintf(&b, "; past season end: %d x ₹%.2f x %d months = ₹%.2f", c.Quantity, c.ExtraMonthRate, c.ExtraMonths, c.ExtraAmount)
+	}
+	return b.String()
+}
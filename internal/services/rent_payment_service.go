@@ -2,18 +2,46 @@ package services
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
 	"cold-backend/internal/models"
 	"cold-backend/internal/repositories"
+	"cold-backend/internal/timeutil"
 )
 
+// Ledger reference type for cheque bounce penalties
+const ChequeBounceReferenceType = "cheque_bounce"
+
 type RentPaymentService struct {
-	Repo *repositories.RentPaymentRepository
+	Repo          *repositories.RentPaymentRepository
+	LedgerService *LedgerService
+	SettingRepo   *repositories.SystemSettingRepository
+	CustomerRepo  *repositories.CustomerRepository
 }
 
 func NewRentPaymentService(repo *repositories.RentPaymentRepository) *RentPaymentService {
 	return &RentPaymentService{Repo: repo}
 }
 
+// SetLedgerService enables cheque clearance and bounce postings
+func (s *RentPaymentService) SetLedgerService(ledgerService *LedgerService) {
+	s.LedgerService = ledgerService
+}
+
+// SetSettingRepo sets the settings repository (cheque_bounce_penalty)
+func (s *RentPaymentService) SetSettingRepo(settingRepo *repositories.SystemSettingRepository) {
+	s.SettingRepo = settingRepo
+}
+
+// SetCustomerRepo sets the customer repository for S/O lookup on cheque postings
+func (s *RentPaymentService) SetCustomerRepo(customerRepo *repositories.CustomerRepository) {
+	s.CustomerRepo = customerRepo
+}
+
 func (s *RentPaymentService) CreatePayment(ctx context.Context, payment *models.RentPayment) error {
 	return s.Repo.Create(ctx, payment)
 }
@@ -33,3 +61,218 @@ func (s *RentPaymentService) ListPayments(ctx context.Context) ([]*models.RentPa
 func (s *RentPaymentService) GetPaymentByReceiptNumber(ctx context.Context, receiptNumber string) (*models.RentPayment, error) {
 	return s.Repo.GetByReceiptNumber(ctx, receiptNumber)
 }
+
+// ApplyPaymentMode validates the mode-specific details of a request and copies them to the payment.
+// Cheques start pending; every other mode is received immediately.
+func (s *RentPaymentService) ApplyPaymentMode(payment *models.RentPayment, req *models.CreateRentPaymentRequest) error {
+	mode := strings.ToLower(strings.TrimSpace(req.PaymentMode))
+	if mode == "" {
+		mode = models.PaymentModeCash
+	}
+	payment.PaymentMode = mode
+
+	switch mode {
+	case models.PaymentModeCash:
+		return nil
+	case models.PaymentModeCheque:
+		payment.ChequeNumber = strings.TrimSpace(req.ChequeNumber)
+		payment.BankName = strings.TrimSpace(req.BankName)
+		if payment.ChequeNumber == "" || payment.BankName == "" {
+			return errors.New("cheque number and bank are required for cheque payments")
+		}
+		if req.ChequeDate != "" {
+			d, err := time.Parse("2006-01-02", req.ChequeDate)
+			if err != nil {
+				return errors.New("invalid cheque date. Use YYYY-MM-DD")
+			}
+			payment.ChequeDate = &d
+		}
+		payment.ClearanceStatus = models.ChequeStatusPending
+		return nil
+	case models.PaymentModeNEFT, models.PaymentModeRTGS, models.PaymentModeUPI:
+		payment.UTRNumber = strings.TrimSpace(req.UTRNumber)
+		payment.BankName = strings.TrimSpace(req.BankName)
+		if payment.UTRNumber == "" {
+			return fmt.Errorf("UTR / reference number is required for %s payments", strings.ToUpper(mode))
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid payment mode: %s", req.PaymentMode)
+}
+
+// ListCheques returns cheque payments by clearance status (all when blank)
+func (s *RentPaymentService) ListCheques(ctx context.Context, status string) ([]*models.RentPayment, error) {
+	return s.Repo.ListCheques(ctx, status)
+}
+
+// ClearCheque marks a pending cheque as cleared and only now credits the customer's ledger
+func (s *RentPaymentService) ClearCheque(ctx context.Context, id int, clearingDate string, userID int) (*models.RentPayment, error) {
+	if s.LedgerService == nil {
+		return nil, errors.New("ledger is not available")
+	}
+
+	date := timeutil.StartOfDay(timeutil.Now())
+	if clearingDate != "" {
+		d, err := timeutil.ParseInIST("2006-01-02", clearingDate)
+		if err != nil {
+			return nil, errors.New("invalid clearing date. Use YYYY-MM-DD")
+		}
+		if d.After(timeutil.Now()) {
+			return nil, errors.New("clearing date cannot be in the future")
+		}
+		date = d
+	}
+
+	payment, err := s.Repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.PaymentMode != models.PaymentModeCheque {
+		return nil, errors.New("payment is not a cheque")
+	}
+	if payment.ClearanceStatus != models.ChequeStatusPending {
+		return nil, fmt.Errorf("cheque is already %s", payment.ClearanceStatus)
+	}
+
+	// Credit dated today when cleared today, otherwise on the bank's clearing date
+	var entryDate *time.Time
+	if date.Before(timeutil.StartOfDay(timeutil.Now())) {
+		entryDate = &date
+	}
+	credit := &models.CreateLedgerEntryRequest{
+		CustomerPhone:    payment.CustomerPhone,
+		CustomerName:     payment.CustomerName,
+		CustomerSO:       s.customerSO(ctx, payment.CustomerPhone),
+		EntryType:        models.LedgerEntryTypePayment,
+		Description:      fmt.Sprintf("Cheque %s (%s) cleared", payment.ChequeNumber, payment.BankName),
+		Credit:           payment.AmountPaid,
+		ReferenceID:      &payment.ID,
+		ReferenceType:    "payment",
+		FamilyMemberID:   payment.FamilyMemberID,
+		FamilyMemberName: payment.FamilyMemberName,
+		CreatedByUserID:  userID,
+		Notes:            payment.Notes,
+		EntryDate:        entryDate,
+		PaymentMode:      models.PaymentModeCheque,
+	}
+	if err := s.LedgerService.PrepareEntry(ctx, credit); err != nil {
+		return nil, err
+	}
+
+	// The status change and the credit are written together, so a bounce racing the
+	// clearance sees either a pending cheque or a cleared one with its credit
+	entry, ok, err := s.Repo.Clear(ctx, id, date, userID, credit)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("cheque is no longer pending")
+	}
+	s.LedgerService.EntriesPosted(ctx, entry)
+	return s.Repo.Get(ctx, id)
+}
+
+// BounceCheque marks a cheque as bounced. A cheque that had already cleared has its
+// ledger credit reversed; a penalty is charged either way.
+func (s *RentPaymentService) BounceCheque(ctx context.Context, id int, req *models.BounceChequeRequest, userID int) (*models.RentPayment, error) {
+	if s.LedgerService == nil {
+		return nil, errors.New("ledger is not available")
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, errors.New("bounce reason is required")
+	}
+	penalty := s.defaultBouncePenalty(ctx)
+	if req.Penalty != nil {
+		if *req.Penalty < 0 {
+			return nil, errors.New("penalty cannot be negative")
+		}
+		penalty = roundMoney(*req.Penalty)
+	}
+
+	payment, err := s.Repo.Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if payment.PaymentMode != models.PaymentModeCheque {
+		return nil, errors.New("payment is not a cheque")
+	}
+	if payment.ClearanceStatus == models.ChequeStatusBounced {
+		return nil, errors.New("cheque has already bounced")
+	}
+
+	var reversal, charge *models.CreateLedgerEntryRequest
+	if payment.ClearanceStatus == models.ChequeStatusCleared {
+		credit, err := s.LedgerService.LedgerRepo.GetByReference(ctx, models.LedgerEntryTypePayment, "payment", payment.ID)
+		if err != nil {
+			return nil, err
+		}
+		if credit != nil {
+			if credit, err = s.LedgerService.LedgerRepo.GetByID(ctx, credit.ID); err != nil {
+				return nil, err
+			}
+		}
+		if credit != nil && credit.VoidedAt == nil {
+			if reversal, err = s.LedgerService.PrepareVoid(ctx, credit.ID, "Cheque bounced: "+reason, userID); err != nil {
+				return nil, fmt.Errorf("the cheque's ledger credit cannot be reversed: %w", err)
+			}
+		}
+	}
+
+	if penalty > 0 {
+		charge = &models.CreateLedgerEntryRequest{
+			CustomerPhone:    payment.CustomerPhone,
+			CustomerName:     payment.CustomerName,
+			CustomerSO:       s.customerSO(ctx, payment.CustomerPhone),
+			EntryType:        models.LedgerEntryTypeCharge,
+			Description:      fmt.Sprintf("Cheque bounce penalty - cheque %s (%s)", payment.ChequeNumber, payment.BankName),
+			Debit:            penalty,
+			ReferenceID:      &payment.ID,
+			ReferenceType:    ChequeBounceReferenceType,
+			FamilyMemberID:   payment.FamilyMemberID,
+			FamilyMemberName: payment.FamilyMemberName,
+			CreatedByUserID:  userID,
+			Notes:            reason,
+		}
+		if err := s.LedgerService.PrepareEntry(ctx, charge); err != nil {
+			return nil, fmt.Errorf("the bounce penalty cannot be charged: %w", err)
+		}
+	}
+
+	// The status change, the credit's reversal and the penalty are written together
+	entries, ok, err := s.Repo.Bounce(ctx, id, payment.ClearanceStatus, reason, penalty, reversal, charge)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, errors.New("cheque status changed, please retry")
+	}
+	s.LedgerService.EntriesPosted(ctx, entries...)
+	return s.Repo.Get(ctx, id)
+}
+
+// defaultBouncePenalty reads the cheque_bounce_penalty setting
+func (s *RentPaymentService) defaultBouncePenalty(ctx context.Context) float64 {
+	if s.SettingRepo == nil {
+		return 0
+	}
+	setting, err := s.SettingRepo.Get(ctx, "cheque_bounce_penalty")
+	if err != nil || setting == nil {
+		return 0
+	}
+	v, err := strconv.ParseFloat(strings.TrimSpace(setting.SettingValue), 64)
+	if err != nil || v < 0 {
+		return 0
+	}
+	return roundMoney(v)
+}
+
+func (s *RentPaymentService) customerSO(ctx context.Context, phone string) string {
+	if s.CustomerRepo == nil {
+		return ""
+	}
+	if customer, err := s.CustomerRepo.GetByPhone(ctx, phone); err == nil && customer != nil {
+		return customer.SO
+	}
+	return ""
+}
@@ -0,0 +1,433 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/timeutil"
+)
+
+// RentTariffService resolves rent rates from rate cards and customer rate contracts.
+// It is the single source of rent rates for the account summary, customer portal,
+// PDF reports and online payment orders.
+type RentTariffService struct {
+	TariffRepo   *repositories.RentTariffRepository
+	SettingRepo  *repositories.SystemSettingRepository
+	ContractRepo *repositories.RateContractRepository
+}
+
+func NewRentTariffService(tariffRepo *repositories.RentTariffRepository, settingRepo *repositories.SystemSettingRepository) *RentTariffService {
+	return &RentTariffService{
+		TariffRepo:  tariffRepo,
+		SettingRepo: settingRepo,
+	}
+}
+
+// SetContractRepo enables customer-specific negotiated rates
+func (s *RentTariffService) SetContractRepo(contractRepo *repositories.RateContractRepository) {
+	s.ContractRepo = contractRepo
+}
+
+// RentRateCard is a snapshot of all active rate cards and rate contracts.
+// Load it once and price many entries without a query per entry.
+type RentRateCard struct {
+	Tariffs     []*models.RentTariff   `json:"tariffs"`
+	Contracts   []*models.RateContract `json:"contracts"`
+	DefaultRate float64                `json:"default_rate"`
+	Season      string                 `json:"season"`
+}
+
+// GetDefaultRate returns the fallback rent rate (rent_per_item setting)
+func (s *RentTariffService) GetDefaultRate(ctx context.Context) float64 {
+	if s.SettingRepo == nil {
+		return 0
+	}
+	setting, err := s.SettingRepo.Get(ctx, "rent_per_item")
+	if err != nil || setting == nil {
+		return 0
+	}
+	// The value might be stored as a string like "10" or as JSON
+	var val float64
+	if err := json.Unmarshal([]byte(setting.SettingValue), &val); err != nil {
+		fmt.Sscanf(setting.SettingValue, "%f", &val)
+	}
+	return val
+}
+
+// GetCurrentSeason returns the current season label (current_season setting)
+func (s *RentTariffService) GetCurrentSeason(ctx context.Context) string {
+	if s.SettingRepo == nil {
+		return ""
+	}
+	setting, err := s.SettingRepo.Get(ctx, "current_season")
+	if err != nil || setting == nil {
+		return ""
+	}
+	return strings.TrimSpace(setting.SettingValue)
+}
+
+// LoadRateCard loads all active rate cards and contracts. The returned card is always
+// usable: if rate cards cannot be loaded it falls back to the default rate.
+func (s *RentTariffService) LoadRateCard(ctx context.Context) (*RentRateCard, error) {
+	card := &RentRateCard{
+		DefaultRate: s.GetDefaultRate(ctx),
+		Season:      s.GetCurrentSeason(ctx),
+	}
+	if s.TariffRepo != nil {
+		tariffs, err := s.TariffRepo.List(ctx, true)
+		if err != nil {
+			return card, err
+		}
+		card.Tariffs = tariffs
+	}
+	if s.ContractRepo != nil {
+		contracts, err := s.ContractRepo.ListActive(ctx)
+		if err != nil {
+			return card, err
+		}
+		card.Contracts = contracts
+	}
+	return card, nil
+}
+
+// QuoteEntry resolves the rent rate for a single entry
+func (s *RentTariffService) QuoteEntry(ctx context.Context, entry *models.Entry) models.RentTariffQuote {
+	card, _ := s.LoadRateCard(ctx)
+	return card.QuoteEntry(entry)
+}
+
+// Quote resolves the rent rate for an arbitrary query
+func (s *RentTariffService) Quote(ctx context.Context, q models.RentTariffQuery) models.RentTariffQuote {
+	card, _ := s.LoadRateCard(ctx)
+	return card.Quote(q)
+}
+
+// RateForEntry returns the rent per item for an entry for one season of storage.
+// Duration-based charges for bags actually taken out come from RentChargeService.
+func (c *RentRateCard) RateForEntry(entry *models.Entry) float64 {
+	return c.QuoteEntry(entry).SeasonRate()
+}
+
+// QuoteEntry resolves the rate for an entry (priced on the date it was created)
+func (c *RentRateCard) QuoteEntry(entry *models.Entry) models.RentTariffQuote {
+	if entry == nil {
+		return c.Quote(models.RentTariffQuery{})
+	}
+	return c.Quote(models.RentTariffQuery{
+		ThockCategory:  entry.ThockCategory,
+		Variety:        entry.Remark,
+		BagSize:        entry.BagSize,
+		Season:         entry.Season,
+		Date:           entry.CreatedAt,
+		CustomerID:     entry.CustomerID,
+		FamilyMemberID: entry.FamilyMemberID,
+	})
+}
+
+// Quote resolves the rate from the rate cards, then applies the customer's
+// negotiated rate contract if one matches.
+func (c *RentRateCard) Quote(q models.RentTariffQuery) models.RentTariffQuote {
+	quote := c.quoteTariff(q)
+
+	contract := c.contractFor(q)
+	if contract == nil {
+		return quote
+	}
+	id := contract.ID
+	quote.RatePerItem = contract.RatePerItem
+	if contract.ExtraMonthRate != nil {
+		quote.ExtraMonthRate = *contract.ExtraMonthRate
+	}
+	quote.ContractID = &id
+	quote.ContractName = contract.Name
+	quote.Source = models.RentRateSourceContract
+	return quote
+}
+
+// contractFor picks the most specific active contract for the customer (nil if none).
+// A family member contract beats a customer-wide one; ties go to the latest valid_from.
+func (c *RentRateCard) contractFor(q models.RentTariffQuery) *models.RateContract {
+	if q.CustomerID == 0 || len(c.Contracts) == 0 {
+		return nil
+	}
+	day := quoteDay(q.Date)
+
+	var best *models.RateContract
+	bestScore := -1
+	for _, k := range c.Contracts {
+		if k.Status != models.RateContractActive || k.CustomerID != q.CustomerID {
+			continue
+		}
+		if k.ValidFrom.Format("2006-01-02") > day {
+			continue
+		}
+		if k.ValidTo != nil && k.ValidTo.Format("2006-01-02") < day {
+			continue
+		}
+
+		score := 0
+		if k.FamilyMemberID != nil {
+			if q.FamilyMemberID == nil || *q.FamilyMemberID != *k.FamilyMemberID {
+				continue
+			}
+			score++
+		}
+		if k.ThockCategory != "" {
+			if !strings.EqualFold(k.ThockCategory, q.ThockCategory) {
+				continue
+			}
+			score++
+		}
+		if k.BagSize != "" {
+			if normalizeTariffValue(k.BagSize) != normalizeTariffValue(q.BagSize) {
+				continue
+			}
+			score++
+		}
+		if k.Variety != "" {
+			if !varietyMatches(q.Variety, k.Variety) {
+				continue
+			}
+			score++
+		}
+
+		if best == nil || score > bestScore || (score == bestScore && k.ValidFrom.After(best.ValidFrom)) {
+			best = k
+			bestScore = score
+		}
+	}
+	return best
+}
+
+// quoteDay returns the IST date (YYYY-MM-DD) a quote is priced on
+func quoteDay(date time.Time) string {
+	if date.IsZero() {
+		date = timeutil.Now()
+	}
+	return timeutil.ToIST(date).Format("2006-01-02")
+}
+
+// quoteTariff picks the most specific matching rate card.
+// Ties are broken by priority, then by the most recent effective date.
+func (c *RentRateCard) quoteTariff(q models.RentTariffQuery) models.RentTariffQuote {
+	day := quoteDay(q.Date)
+	// Season-specific cards match the stock's own season; stock without one (ad-hoc
+	// quotes, entries from before seasons were recorded) is taken as the current season
+	season := q.Season
+	if season == "" {
+		season = c.Season
+	}
+
+	var best *models.RentTariff
+	bestScore := -1
+	for _, t := range c.Tariffs {
+		if !t.IsActive {
+			continue
+		}
+		if t.EffectiveFrom.Format("2006-01-02") > day {
+			continue
+		}
+		if t.EffectiveTo != nil && t.EffectiveTo.Format("2006-01-02") < day {
+			continue
+		}
+
+		score := 0
+		if t.ThockCategory != "" {
+			if !strings.EqualFold(t.ThockCategory, q.ThockCategory) {
+				continue
+			}
+			score++
+		}
+		if t.Season != "" {
+			if !strings.EqualFold(t.Season, season) {
+				continue
+			}
+			score++
+		}
+		if t.BagSize != "" {
+			if normalizeTariffValue(t.BagSize) != normalizeTariffValue(q.BagSize) {
+				continue
+			}
+			score++
+		}
+		if t.Variety != "" {
+			if !varietyMatches(q.Variety, t.Variety) {
+				continue
+			}
+			score++
+		}
+
+		if best == nil || score > bestScore ||
+			(score == bestScore && t.Priority > best.Priority) ||
+			(score == bestScore && t.Priority == best.Priority && t.EffectiveFrom.After(best.EffectiveFrom)) {
+			best = t
+			bestScore = score
+		}
+	}
+
+	if best == nil {
+		return models.RentTariffQuote{
+			RatePerItem: c.DefaultRate,
+			TariffName:  "Default",
+			Source:      models.RentRateSourceDefault,
+			BillingMode: models.RentBillingFlat,
+		}
+	}
+
+	id := best.ID
+	return models.RentTariffQuote{
+		RatePerItem:    best.RatePerItem,
+		TariffID:       &id,
+		TariffName:     best.Name,
+		Source:         models.RentRateSourceTariff,
+		BillingMode:    best.BillingMode,
+		MinMonths:      best.MinMonths,
+		SeasonEndDate:  best.SeasonEndDate,
+		ExtraMonthRate: best.ExtraMonthRate,
+	}
+}
+
+// normalizeTariffValue lowercases and strips spaces ("50 Kg" == "50kg")
+func normalizeTariffValue(v string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(v), " ", ""))
+}
+
+// varietyMatches checks if the rate card variety is one of the entry's comma-separated varieties
+func varietyMatches(entryVarieties, tariffVariety string) bool {
+	want := normalizeTariffValue(tariffVariety)
+	for _, v := range strings.Split(entryVarieties, ",") {
+		if normalizeTariffValue(v) == want {
+			return true
+		}
+	}
+	return false
+}
+
+// ListTariffs returns all rate cards
+func (s *RentTariffService) ListTariffs(ctx context.Context, activeOnly bool) ([]*models.RentTariff, error) {
+	return s.TariffRepo.List(ctx, activeOnly)
+}
+
+// GetTariff returns a single rate card
+func (s *RentTariffService) GetTariff(ctx context.Context, id int) (*models.RentTariff, error) {
+	return s.TariffRepo.Get(ctx, id)
+}
+
+// CreateTariff validates and creates a rate card
+func (s *RentTariffService) CreateTariff(ctx context.Context, req *models.CreateRentTariffRequest, userID int) (*models.RentTariff, error) {
+	tariff := &models.RentTariff{IsActive: true}
+	if err := applyTariffRequest(tariff, req); err != nil {
+		return nil, err
+	}
+	if userID > 0 {
+		tariff.CreatedByUserID = &userID
+	}
+	if err := s.TariffRepo.Create(ctx, tariff); err != nil {
+		return nil, err
+	}
+	return tariff, nil
+}
+
+// UpdateTariff validates and updates a rate card
+func (s *RentTariffService) UpdateTariff(ctx context.Context, id int, req *models.CreateRentTariffRequest) (*models.RentTariff, error) {
+	tariff, err := s.TariffRepo.Get(ctx, id)
+	if err != nil {
+		return nil, errors.New("rent tariff not found")
+	}
+	if err := applyTariffRequest(tariff, req); err != nil {
+		return nil, err
+	}
+	if err := s.TariffRepo.Update(ctx, tariff); err != nil {
+		return nil, err
+	}
+	return tariff, nil
+}
+
+// DeactivateTariff disables a rate card
+func (s *RentTariffService) DeactivateTariff(ctx context.Context, id int) error {
+	return s.TariffRepo.Deactivate(ctx, id)
+}
+
+// applyTariffRequest validates a request and copies it onto the rate card
+func applyTariffRequest(t *models.RentTariff, req *models.CreateRentTariffRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return errors.New("name is required")
+	}
+	if req.RatePerItem < 0 {
+		return errors.New("rate per item cannot be negative")
+	}
+	category := strings.ToLower(strings.TrimSpace(req.ThockCategory))
+	if category != "" && category != "seed" && category != "sell" {
+		return errors.New("thock category must be 'seed', 'sell' or empty")
+	}
+
+	effectiveFrom := timeutil.StartOfDay(timeutil.Now())
+	if req.EffectiveFrom != "" {
+		parsed, err := time.Parse("2006-01-02", req.EffectiveFrom)
+		if err != nil {
+			return errors.New("invalid effective_from date. Use YYYY-MM-DD")
+		}
+		effectiveFrom = parsed
+	}
+
+	var effectiveTo *time.Time
+	if req.EffectiveTo != "" {
+		parsed, err := time.Parse("2006-01-02", req.EffectiveTo)
+		if err != nil {
+			return errors.New("invalid effective_to date. Use YYYY-MM-DD")
+		}
+		if parsed.Before(effectiveFrom) {
+			return errors.New("effective_to cannot be before effective_from")
+		}
+		effectiveTo = &parsed
+	}
+
+	billingMode := strings.ToLower(strings.TrimSpace(req.BillingMode))
+	if billingMode == "" {
+		billingMode = models.RentBillingFlat
+	}
+	if billingMode != models.RentBillingFlat && billingMode != models.RentBillingMonthly && billingMode != models.RentBillingProrata {
+		return errors.New("billing mode must be 'flat', 'monthly' or 'prorata'")
+	}
+	if req.MinMonths < 0 {
+		return errors.New("minimum months cannot be negative")
+	}
+	if req.ExtraMonthRate < 0 {
+		return errors.New("extra month rate cannot be negative")
+	}
+
+	var seasonEnd *time.Time
+	if req.SeasonEndDate != "" {
+		parsed, err := time.Parse("2006-01-02", req.SeasonEndDate)
+		if err != nil {
+			return errors.New("invalid season_end_date. Use YYYY-MM-DD")
+		}
+		seasonEnd = &parsed
+	}
+
+	t.Name = name
+	t.ThockCategory = category
+	t.Variety = strings.TrimSpace(req.Variety)
+	t.BagSize = strings.TrimSpace(req.BagSize)
+	t.Season = strings.TrimSpace(req.Season)
+	t.RatePerItem = req.RatePerItem
+	t.EffectiveFrom = effectiveFrom
+	t.EffectiveTo = effectiveTo
+	t.BillingMode = billingMode
+	t.MinMonths = req.MinMonths
+	t.SeasonEndDate = seasonEnd
+	t.ExtraMonthRate = req.ExtraMonthRate
+	t.Priority = req.Priority
+	t.Notes = req.Notes
+	if req.IsActive != nil {
+		t.IsActive = *req.IsActive
+	}
+	return nil
+}
@@ -6,7 +6,6 @@ import (
 	"context"
 	"encoding/csv"
 	"fmt"
-	"strconv"
 	"sync"
 	"time"
 
@@ -41,6 +40,8 @@ type DailySummaryData struct {
 	SellQty     int
 	TotalQty    int
 	TotalTrucks int
+
+	CashClosings []*models.CashSession // Cash drawer sessions closed on the day, per employee
 }
 
 // ReportService handles report generation
@@ -51,6 +52,8 @@ type ReportService struct {
 	RoomEntryRepo   *repositories.RoomEntryRepository
 	RentPaymentRepo *repositories.RentPaymentRepository
 	SettingsRepo    *repositories.SystemSettingRepository
+	TariffService   *RentTariffService
+	CashSessionRepo *repositories.CashSessionRepository
 }
 
 // NewReportService creates a new report service
@@ -69,24 +72,28 @@ func NewReportService(
 		RoomEntryRepo:   roomEntryRepo,
 		RentPaymentRepo: rentPaymentRepo,
 		SettingsRepo:    settingsRepo,
+		TariffService:   NewRentTariffService(nil, settingsRepo), // Default rate only until SetTariffService
 	}
 }
 
-// GetRentRate fetches the current rent rate from settings
-func (s *ReportService) GetRentRate(ctx context.Context) (float64, error) {
-	setting, err := s.SettingsRepo.Get(ctx, "rent_rate_per_bag")
-	if err != nil {
-		return 2.50, nil // Default rate
-	}
-	rate, err := strconv.ParseFloat(setting.SettingValue, 64)
-	if err != nil {
-		return 2.50, nil
-	}
-	return rate, nil
+// SetTariffService sets the rent tariff service used to price thocks
+func (s *ReportService) SetTariffService(tariffService *RentTariffService) {
+	s.TariffService = tariffService
+}
+
+// SetCashSessionRepo enables per-employee cash closings in the daily summary
+func (s *ReportService) SetCashSessionRepo(repo *repositories.CashSessionRepository) {
+	s.CashSessionRepo = repo
+}
+
+// GetRateCard loads the current rent rate cards (same rates as account summary and portal)
+func (s *ReportService) GetRateCard(ctx context.Context) *RentRateCard {
+	card, _ := s.TariffService.LoadRateCard(ctx)
+	return card
 }
 
 // GetCustomerReportData fetches all data for a customer
-func (s *ReportService) GetCustomerReportData(ctx context.Context, phone string, rentRate float64) (*CustomerReportData, error) {
+func (s *ReportService) GetCustomerReportData(ctx context.Context, phone string, rateCard *RentRateCard) (*CustomerReportData, error) {
 	customer, err := s.CustomerRepo.GetByPhone(ctx, phone)
 	if err != nil {
 		return nil, fmt.Errorf("customer not found: %w", err)
@@ -102,7 +109,7 @@ func (s *ReportService) GetCustomerReportData(ctx context.Context, phone string,
 	var roomEntries []*models.RoomEntry
 	var payments []*models.RentPayment
 	var totalQty int
-	var totalPaid float64
+	var totalRent, totalPaid float64
 	thockSet := make(map[string]bool)
 
 	for _, entry := range entries {
@@ -110,8 +117,10 @@ func (s *ReportService) GetCustomerReportData(ctx context.Context, phone string,
 		reList, err := s.RoomEntryRepo.ListByThockNumber(ctx, entry.ThockNumber)
 		if err == nil {
 			roomEntries = append(roomEntries, reList...)
+			rate := rateCard.RateForEntry(entry)
 			for _, re := range reList {
 				totalQty += re.Quantity
+				totalRent += float64(re.Quantity) * rate
 			}
 		}
 		thockSet[entry.ThockNumber] = true
@@ -121,12 +130,15 @@ func (s *ReportService) GetCustomerReportData(ctx context.Context, phone string,
 		if err == nil && payments == nil {
 			payments = pList
 			for _, p := range pList {
+				// Pending and bounced cheques have not been paid
+				if p.PaymentMode == models.PaymentModeCheque && p.ClearanceStatus != models.ChequeStatusCleared {
+					continue
+				}
 				totalPaid += p.AmountPaid
 			}
 		}
 	}
 
-	totalRent := float64(totalQty) * rentRate
 	balance := totalRent - totalPaid
 
 	return &CustomerReportData{
@@ -144,7 +156,7 @@ func (s *ReportService) GetCustomerReportData(ctx context.Context, phone string,
 
 // GetAllCustomerReportData fetches data for all customers
 func (s *ReportService) GetAllCustomerReportData(ctx context.Context, filter string) ([]*CustomerReportData, error) {
-	rentRate, _ := s.GetRentRate(ctx)
+	rateCard := s.GetRateCard(ctx)
 
 	customers, err := s.CustomerRepo.List(ctx)
 	if err != nil {
@@ -172,7 +184,7 @@ func (s *ReportService) GetAllCustomerReportData(ctx context.Context, filter str
 		go func() {
 			defer wg.Done()
 			for job := range jobs {
-				data, err := s.GetCustomerReportData(ctx, job.customer.Phone, rentRate)
+				data, err := s.GetCustomerReportData(ctx, job.customer.Phone, rateCard)
 				results <- result{index: job.index, data: data, err: err}
 			}
 		}()
@@ -491,15 +503,24 @@ func (s *ReportService) GetDailySummaryData(ctx context.Context, date time.Time)
 		}
 	}
 
+	var closings []*models.CashSession
+	if s.CashSessionRepo != nil {
+		closings, err = s.CashSessionRepo.ListClosed(ctx, startOfDay, endOfDay)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &DailySummaryData{
-		Date:        date,
-		Entries:     dateEntries,
-		TotalSeed:   seedCount,
-		TotalSell:   sellCount,
-		SeedQty:     seedQty,
-		SellQty:     sellQty,
-		TotalQty:    seedQty + sellQty,
-		TotalTrucks: seedCount + sellCount,
+		Date:         date,
+		Entries:      dateEntries,
+		TotalSeed:    seedCount,
+		TotalSell:    sellCount,
+		SeedQty:      seedQty,
+		SellQty:      sellQty,
+		TotalQty:     seedQty + sellQty,
+		TotalTrucks:  seedCount + sellCount,
+		CashClosings: closings,
 	}, nil
 }
 
@@ -586,6 +607,10 @@ func (s *ReportService) GenerateDailySummaryPDF(data *DailySummaryData) ([]byte,
 		pdf.CellFormat(35, 6, variety, "1", 1, "L", true, 0, "")
 	}
 
+	if len(data.CashClosings) > 0 {
+		addCashClosingsTable(pdf, data.CashClosings)
+	}
+
 	var buf bytes.Buffer
 	err := pdf.Output(&buf)
 	if err != nil {
@@ -594,6 +619,81 @@ func (s *ReportService) GenerateDailySummaryPDF(data *DailySummaryData) ([]byte,
 	return buf.Bytes(), nil
 }
 
+// addCashClosingsTable adds each employee's cash drawer closing to the daily summary
+func addCashClosingsTable(pdf *gofpdf.Fpdf, closings []*models.CashSession) {
+	pdf.Ln(5)
+	pdf.SetFillColor(240, 240, 240)
+	pdf.SetFont("Arial", "B", 12)
+	pdf.CellFormat(277, 8, "Cash Closings", "1", 1, "L", true, 0, "")
+
+	pdf.SetFont("Arial", "B", 9)
+	pdf.SetFillColor(200, 200, 200)
+	pdf.CellFormat(10, 7, "#", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(55, 7, "Employee", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(30, 7, "Opened", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(30, 7, "Closed", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(20, 7, "Payments", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(28, 7, "Opening Float", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(28, 7, "Cash Received", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(28, 7, "Expected", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(24, 7, "Counted", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(24, 7, "Difference", "1", 1, "C", true, 0, "")
+
+	pdf.SetFont("Arial", "", 9)
+	var received, expected, counted, difference float64
+	for i, c := range closings {
+		if i%2 == 0 {
+			pdf.SetFillColor(255, 255, 255)
+		} else {
+			pdf.SetFillColor(245, 245, 245)
+		}
+
+		name := c.UserName
+		if len(name) > 26 {
+			name = name[:23] + "..."
+		}
+		closedAt := ""
+		if c.ClosedAt != nil {
+			closedAt = timeutil.ToIST(*c.ClosedAt).Format("03:04 PM")
+		}
+		var cnt, diff float64
+		if c.CountedCash != nil {
+			cnt = *c.CountedCash
+		}
+		if c.Difference != nil {
+			diff = *c.Difference
+		}
+
+		pdf.CellFormat(10, 6, fmt.Sprintf("%d", i+1), "1", 0, "C", true, 0, "")
+		pdf.CellFormat(55, 6, name, "1", 0, "L", true, 0, "")
+		pdf.CellFormat(30, 6, timeutil.ToIST(c.OpenedAt).Format("02-Jan 03:04 PM"), "1", 0, "C", true, 0, "")
+		pdf.CellFormat(30, 6, closedAt, "1", 0, "C", true, 0, "")
+		pdf.CellFormat(20, 6, fmt.Sprintf("%d", c.PaymentCount), "1", 0, "C", true, 0, "")
+		pdf.CellFormat(28, 6, fmt.Sprintf("%.2f", c.OpeningFloat), "1", 0, "R", true, 0, "")
+		pdf.CellFormat(28, 6, fmt.Sprintf("%.2f", c.CashReceived), "1", 0, "R", true, 0, "")
+		pdf.CellFormat(28, 6, fmt.Sprintf("%.2f", c.ExpectedCash), "1", 0, "R", true, 0, "")
+		pdf.CellFormat(24, 6, fmt.Sprintf("%.2f", cnt), "1", 0, "R", true, 0, "")
+		if diff < 0 {
+			pdf.SetTextColor(200, 0, 0) // Short
+		}
+		pdf.CellFormat(24, 6, fmt.Sprintf("%.2f", diff), "1", 1, "R", true, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+
+		received += c.CashReceived
+		expected += c.ExpectedCash
+		counted += cnt
+		difference += diff
+	}
+
+	pdf.SetFont("Arial", "B", 9)
+	pdf.SetFillColor(240, 240, 240)
+	pdf.CellFormat(173, 7, "Total (Rs.)", "1", 0, "R", true, 0, "")
+	pdf.CellFormat(28, 7, fmt.Sprintf("%.2f", received), "1", 0, "R", true, 0, "")
+	pdf.CellFormat(28, 7, fmt.Sprintf("%.2f", expected), "1", 0, "R", true, 0, "")
+	pdf.CellFormat(24, 7, fmt.Sprintf("%.2f", counted), "1", 0, "R", true, 0, "")
+	pdf.CellFormat(24, 7, fmt.Sprintf("%.2f", difference), "1", 1, "R", true, 0, "")
+}
+
 // GenerateDailySummaryCSV generates a CSV file for daily summary
 func (s *ReportService) GenerateDailySummaryCSV(ctx context.Context, date time.Time) ([]byte, error) {
 	data, err := s.GetDailySummaryData(ctx, date)
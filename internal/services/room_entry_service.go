@@ -15,6 +15,8 @@ type RoomEntryService struct {
 	EntryRepo          *repositories.EntryRepository
 	EntryEventRepo     *repositories.EntryEventRepository
 	PrinterService     *PrinterService
+	LayoutService      *WarehouseLayoutService
+	SettingRepo        *repositories.SystemSettingRepository
 }
 
 func NewRoomEntryService(roomEntryRepo *repositories.RoomEntryRepository, roomEntryGatarRepo *repositories.RoomEntryGatarRepository, entryRepo *repositories.EntryRepository, entryEventRepo *repositories.EntryEventRepository, printerService *PrinterService) *RoomEntryService {
@@ -27,6 +29,95 @@ func NewRoomEntryService(roomEntryRepo *repositories.RoomEntryRepository, roomEn
 	}
 }
 
+// SetLayoutService enables checking room entry locations against the warehouse layout
+func (s *RoomEntryService) SetLayoutService(layoutService *WarehouseLayoutService) {
+	s.LayoutService = layoutService
+}
+
+// SetSettingRepo enables the gatar_overflow_policy setting (default: warn)
+func (s *RoomEntryService) SetSettingRepo(settingRepo *repositories.SystemSettingRepository) {
+	s.SettingRepo = settingRepo
+}
+
+// validateLocation checks the room, floor and every gatar (gate_no and per-gatar
+// quantities) against the warehouse layout
+func (s *RoomEntryService) validateLocation(ctx context.Context, roomNo, floor, gateNo string, gatarInputs []models.GatarInput) error {
+	if s.LayoutService == nil {
+		return nil
+	}
+	gatars, err := parseGatarList(gateNo)
+	if err != nil {
+		return err
+	}
+	for _, g := range gatarInputs {
+		gatars = append(gatars, g.GatarNo)
+	}
+	return s.LayoutService.ValidateLocation(ctx, roomNo, floor, gatars)
+}
+
+// roomEntryGatars returns the bags a room entry puts in each gatar: the per-gatar
+// quantities when given, otherwise its quantity spread over gate_no by the quantity
+// breakdown, the way the room visualization shows it (models.DistributeQuantity)
+func (s *RoomEntryService) roomEntryGatars(ctx context.Context, gateNo string, quantity int, breakdown string, gatarInputs []models.GatarInput) ([]models.GatarInput, error) {
+	if len(gatarInputs) > 0 {
+		return gatarInputs, nil
+	}
+	listed, err := parseGatarList(gateNo)
+	if err != nil || len(listed) == 0 {
+		return nil, err
+	}
+
+	var layout *models.WarehouseLayout
+	if s.LayoutService != nil {
+		if layout, err = s.LayoutService.Layout(ctx); err != nil {
+			return nil, err
+		}
+	}
+	capacities := make([]int, len(listed))
+	for i, g := range listed {
+		capacities[i] = models.DefaultGatarCapacity
+		if layout != nil && layout.Capacity(g) > 0 {
+			capacities[i] = layout.Capacity(g)
+		}
+	}
+
+	quantities := models.DistributeQuantity(quantity, capacities, models.ParseQuantityBreakdown(breakdown))
+	gatars := make([]models.GatarInput, len(listed))
+	for i, g := range listed {
+		gatars[i] = models.GatarInput{GatarNo: g, Quantity: quantities[i]}
+	}
+	return gatars, nil
+}
+
+// gatarCapacities returns the capacity of each gatar a room entry puts bags in, from the
+// warehouse layout. Nil without a layout, which leaves capacity unchecked.
+func (s *RoomEntryService) gatarCapacities(ctx context.Context, entryGatars []models.GatarInput) (map[int]int, error) {
+	if s.LayoutService == nil {
+		return nil, nil
+	}
+	layout, err := s.LayoutService.Layout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	capacities := make(map[int]int)
+	for _, g := range entryGatars {
+		capacities[g.GatarNo] = layout.Capacity(g.GatarNo)
+	}
+	return capacities, nil
+}
+
+// gatarOverflowPolicy returns the gatar_overflow_policy setting (warn unless set to block)
+func gatarOverflowPolicy(ctx context.Context, settingRepo *repositories.SystemSettingRepository) string {
+	if settingRepo == nil {
+		return models.GatarOverflowWarn
+	}
+	setting, err := settingRepo.Get(ctx, models.SettingGatarOverflowPolicy)
+	if err != nil || setting == nil || setting.SettingValue != models.GatarOverflowBlock {
+		return models.GatarOverflowWarn
+	}
+	return models.GatarOverflowBlock
+}
+
 func (s *RoomEntryService) CreateRoomEntry(ctx context.Context, req *models.CreateRoomEntryRequest, userID int) (*models.RoomEntry, error) {
 	// Validate required fields
 	if req.ThockNumber == "" {
@@ -44,6 +135,17 @@ func (s *RoomEntryService) CreateRoomEntry(ctx context.Context, req *models.Crea
 	if req.Quantity < 1 {
 		return nil, errors.New("quantity must be at least 1")
 	}
+	if err := s.validateLocation(ctx, req.RoomNo, req.Floor, req.GateNo, req.Gatars); err != nil {
+		return nil, err
+	}
+	gatars, err := s.roomEntryGatars(ctx, req.GateNo, req.Quantity, req.QuantityBreakdown, req.Gatars)
+	if err != nil {
+		return nil, err
+	}
+	capacities, err := s.gatarCapacities(ctx, gatars)
+	if err != nil {
+		return nil, err
+	}
 
 	// Check if entry exists
 	entry, err := s.EntryRepo.Get(ctx, req.EntryID)
@@ -86,17 +188,16 @@ func (s *RoomEntryService) CreateRoomEntry(ctx context.Context, req *models.Crea
 		CreatedByUserID:   userID,
 	}
 
-	if err := s.RoomEntryRepo.Create(ctx, roomEntry); err != nil {
+	// Saved with its per-gatar quantities (spread over gate_no if not provided), once
+	// their gatars are locked and checked against capacity
+	blockOverflow := gatarOverflowPolicy(ctx, s.SettingRepo) == models.GatarOverflowBlock
+	capacity, warnings, err := s.RoomEntryRepo.CreateWithGatars(ctx, roomEntry, gatars, capacities, blockOverflow)
+	if err != nil {
 		return nil, err
 	}
 
-	// Save per-gatar quantities if provided
-	if len(req.Gatars) > 0 {
-		if err := s.RoomEntryGatarRepo.CreateBatch(ctx, roomEntry.ID, req.Gatars); err != nil {
-			// Log error but don't fail the whole operation
-			// The main room entry is already created
-		}
-	}
+	roomEntry.GatarCapacity = capacity
+	roomEntry.CapacityWarnings = warnings
 
 	// Create event to track room entry completion
 	event := &models.EntryEvent{
@@ -179,6 +280,26 @@ func (s *RoomEntryService) UpdateRoomEntry(ctx context.Context, id int, req *mod
 	if req.Quantity < 1 {
 		return nil, errors.New("quantity must be at least 1")
 	}
+	if err := s.validateLocation(ctx, req.RoomNo, req.Floor, req.GateNo, req.Gatars); err != nil {
+		return nil, err
+	}
+	gatars, err := s.roomEntryGatars(ctx, req.GateNo, req.Quantity, req.QuantityBreakdown, req.Gatars)
+	if err != nil {
+		return nil, err
+	}
+	capacities, err := s.gatarCapacities(ctx, gatars)
+	if err != nil {
+		return nil, err
+	}
+
+	// Gatar rows are replaced when per-gatar quantities are given, when the gate_no spread
+	// changes, or when none were saved yet
+	respread := len(req.Gatars) > 0 || roomEntry.GateNo != req.GateNo || roomEntry.Quantity != req.Quantity ||
+		roomEntry.QuantityBreakdown != req.QuantityBreakdown
+	if !respread {
+		saved, err := s.RoomEntryGatarRepo.GetByRoomEntryID(ctx, id)
+		respread = err == nil && len(saved) == 0
+	}
 
 	// Update fields
 	roomEntry.RoomNo = req.RoomNo
@@ -188,17 +309,15 @@ func (s *RoomEntryService) UpdateRoomEntry(ctx context.Context, id int, req *mod
 	roomEntry.Quantity = req.Quantity
 	roomEntry.QuantityBreakdown = req.QuantityBreakdown
 
-	// Update in database
-	if err := s.RoomEntryRepo.Update(ctx, id, roomEntry); err != nil {
+	// Update in database with its per-gatar quantities (spread over gate_no if not
+	// provided), once their gatars are locked and checked against capacity
+	blockOverflow := gatarOverflowPolicy(ctx, s.SettingRepo) == models.GatarOverflowBlock
+	capacity, warnings, err := s.RoomEntryRepo.UpdateWithGatars(ctx, id, roomEntry, gatars, respread && len(gatars) > 0, capacities, blockOverflow)
+	if err != nil {
 		return nil, err
 	}
 
-	// Update per-gatar quantities if provided
-	if len(req.Gatars) > 0 {
-		if err := s.RoomEntryGatarRepo.UpdateBatch(ctx, id, req.Gatars); err != nil {
-			// Log error but don't fail the whole operation
-		}
-	}
-
+	roomEntry.GatarCapacity = capacity
+	roomEntry.CapacityWarnings = warnings
 	return roomEntry, nil
 }
@@ -0,0 +1,267 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"time"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/payment"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/timeutil"
+)
+
+// SettlementService reconciles payment gateway settlements with online transactions.
+// ReconcilePayments makes sure captured payments reach the ledger; this checks that the
+// gateway then paid them out to the bank, less no more than the fee the customer was
+// charged for them.
+type SettlementService struct {
+	Repo            *repositories.GatewaySettlementRepository
+	TransactionRepo *repositories.OnlineTransactionRepository
+	RazorpayService *RazorpayService
+	SettingRepo     *repositories.SystemSettingRepository
+}
+
+func NewSettlementService(repo *repositories.GatewaySettlementRepository, transactionRepo *repositories.OnlineTransactionRepository, razorpayService *RazorpayService, settingRepo *repositories.SystemSettingRepository) *SettlementService {
+	return &SettlementService{
+		Repo:            repo,
+		TransactionRepo: transactionRepo,
+		RazorpayService: razorpayService,
+		SettingRepo:     settingRepo,
+	}
+}
+
+// Fetch imports a day's settlement report from the gateway's API
+func (s *SettlementService) Fetch(ctx context.Context, gateway string, day time.Time, userID int) (*models.GatewaySettlementImport, []*models.GatewaySettlementLine, error) {
+	lines, err := s.RazorpayService.FetchSettlements(ctx, gateway, day)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.importLines(ctx, gateway, "api", day.Format("2006-01-02"), lines, userID)
+}
+
+// ImportCSV imports a settlement report downloaded from the Razorpay dashboard
+func (s *SettlementService) ImportCSV(ctx context.Context, fileName string, data []byte, userID int) (*models.GatewaySettlementImport, []*models.GatewaySettlementLine, error) {
+	lines, err := payment.ParseRazorpaySettlementCSV(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return s.importLines(ctx, payment.GatewayRazorpay, "csv", fileName, lines, userID)
+}
+
+// importLines matches and stores a report's lines, after matching the gateway's flagged
+// lines again. Lines imported from an earlier report are counted as duplicates and
+// skipped, unless they were flagged - then the new report's line replaces them.
+func (s *SettlementService) importLines(ctx context.Context, gateway, source, fileName string, parsed []*payment.SettlementLine, userID int) (*models.GatewaySettlementImport, []*models.GatewaySettlementLine, error) {
+	if err := s.rematchFlagged(ctx, gateway); err != nil {
+		return nil, nil, err
+	}
+
+	id, err := s.Repo.CreateImport(ctx, gateway, source, fileName, userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	imp := &models.GatewaySettlementImport{ID: id, Gateway: gateway, Source: source, FileName: fileName, LineCount: len(parsed), ImportedByUserID: userID}
+
+	var lines []*models.GatewaySettlementLine
+	for _, p := range parsed {
+		line := s.match(ctx, gateway, p)
+		line.ImportID = id
+		inserted, err := s.Repo.InsertLine(ctx, line)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !inserted {
+			imp.DuplicateCount++
+			continue
+		}
+		switch line.Status {
+		case models.SettlementStatusMatched:
+			imp.MatchedCount++
+		case models.SettlementStatusShort, models.SettlementStatusUnmatched:
+			imp.FlaggedCount++
+			log.Printf("[Settlement] %s %s flagged %s: %s", payment.DisplayName(gateway), line.EntityID, line.Status, line.Notes)
+		}
+		lines = append(lines, line)
+	}
+
+	if err := s.Repo.FinishImport(ctx, imp); err != nil {
+		return nil, nil, err
+	}
+	return imp, lines, nil
+}
+
+// match links a settlement line to its online transaction and checks a settled payment
+// against it: the gateway must have captured the order total and settled at least the
+// transaction amount, i.e. kept no more fee (with GST) than CalculateFee charged the customer
+func (s *SettlementService) match(ctx context.Context, gateway string, p *payment.SettlementLine) *models.GatewaySettlementLine {
+	line := &models.GatewaySettlementLine{
+		Gateway:       gateway,
+		LineType:      p.Type,
+		EntityID:      p.EntityID,
+		OrderID:       p.OrderID,
+		PaymentID:     p.PaymentID,
+		SettlementID:  p.SettlementID,
+		SettlementUTR: p.SettlementUTR,
+		Amount:        paiseToRupees(p.Amount),
+		Fee:           paiseToRupees(p.Fee),
+		Tax:           paiseToRupees(p.Tax),
+		SettledAmount: paiseToRupees(p.Credit - p.Debit),
+		Settled:       p.Settled,
+		SettledAt:     p.SettledAt,
+	}
+
+	var tx *models.OnlineTransaction
+	if p.PaymentID != "" {
+		tx, _ = s.TransactionRepo.GetByPaymentID(ctx, p.PaymentID)
+	}
+	if tx == nil && p.OrderID != "" {
+		tx, _ = s.TransactionRepo.GetByOrderID(ctx, p.OrderID)
+	}
+	if tx != nil && tx.Gateway != gateway {
+		tx = nil
+	}
+	if tx != nil {
+		line.OnlineTransactionID = &tx.ID
+	}
+
+	if p.Type != payment.SettlementPayment {
+		line.Status = models.SettlementStatusAdjustment
+		return line
+	}
+	if tx == nil {
+		line.Status = models.SettlementStatusUnmatched
+		line.Notes = fmt.Sprintf("No online transaction for payment %s", p.PaymentID)
+		return line
+	}
+	if tx.Status != models.OnlineTxStatusSuccess && tx.Status != models.OnlineTxStatusRefunded {
+		line.Status = models.SettlementStatusUnmatched
+		line.Notes = fmt.Sprintf("Gateway settled the payment but the transaction is %s in the app", tx.Status)
+		return line
+	}
+
+	expectedFee, expectedAmount := tx.FeeAmount, tx.Amount
+	line.ExpectedFee = &expectedFee
+	line.ExpectedAmount = &expectedAmount
+
+	switch {
+	case !p.Settled:
+		line.Status = models.SettlementStatusShort
+		line.Notes = "Payment is on hold at the gateway and was not settled"
+	case math.Abs(line.Amount-tx.TotalAmount) > 0.01:
+		line.Status = models.SettlementStatusShort
+		line.Notes = fmt.Sprintf("Gateway captured ₹%.2f but the order total was ₹%.2f", line.Amount, tx.TotalAmount)
+	case line.SettledAmount < expectedAmount-0.01:
+		line.Status = models.SettlementStatusShort
+		line.Notes = fmt.Sprintf("Fee ₹%.2f (GST ₹%.2f) exceeds the ₹%.2f charged to the customer; settled ₹%.2f short",
+			line.Fee, line.Tax, expectedFee, expectedAmount-line.SettledAmount)
+	default:
+		line.Status = models.SettlementStatusMatched
+	}
+	return line
+}
+
+// rematchFlagged matches a gateway's short and unmatched lines again, clearing the
+// ones whose transaction has since been reconciled or corrected
+func (s *SettlementService) rematchFlagged(ctx context.Context, gateway string) error {
+	flagged, err := s.Repo.ListFlaggedLines(ctx, gateway)
+	if err != nil {
+		return err
+	}
+	for _, stored := range flagged {
+		line := s.match(ctx, gateway, &payment.SettlementLine{
+			Type:          stored.LineType,
+			EntityID:      stored.EntityID,
+			OrderID:       stored.OrderID,
+			PaymentID:     stored.PaymentID,
+			SettlementID:  stored.SettlementID,
+			SettlementUTR: stored.SettlementUTR,
+			Amount:        rupeesToPaise(stored.Amount),
+			Fee:           rupeesToPaise(stored.Fee),
+			Tax:           rupeesToPaise(stored.Tax),
+			Credit:        rupeesToPaise(stored.SettledAmount),
+			Settled:       stored.Settled,
+			SettledAt:     stored.SettledAt,
+		})
+		if line.Status == stored.Status && line.Notes == stored.Notes {
+			continue
+		}
+		line.ID = stored.ID
+		if err := s.Repo.UpdateMatch(ctx, line); err != nil {
+			return err
+		}
+		log.Printf("[Settlement] %s %s rematched: %s -> %s", payment.DisplayName(gateway), line.EntityID, stored.Status, line.Status)
+	}
+	return nil
+}
+
+func paiseToRupees(paise int64) float64 {
+	return float64(paise) / 100
+}
+
+func rupeesToPaise(rupees float64) int64 {
+	return int64(math.Round(rupees * 100))
+}
+
+// graceDays returns how long a captured payment may stay unsettled (default 3 days)
+func (s *SettlementService) graceDays(ctx context.Context) int {
+	setting, err := s.SettingRepo.Get(ctx, models.SettingSettlementGraceDays)
+	if err == nil && setting != nil {
+		if days, err := strconv.Atoi(setting.SettingValue); err == nil && days >= 0 {
+			return days
+		}
+	}
+	return 3
+}
+
+// Report summarises a gateway's settlements between from and to (inclusive dates) and
+// lists short and unmatched lines, and payments captured in the period that are still
+// unsettled after the grace period
+func (s *SettlementService) Report(ctx context.Context, gateway string, from, to time.Time) (*models.SettlementReport, error) {
+	report := &models.SettlementReport{From: timeutil.StartOfDay(from), To: timeutil.StartOfDay(to).AddDate(0, 0, 1)}
+	if err := s.Repo.Totals(ctx, gateway, report); err != nil {
+		return nil, err
+	}
+	report.FeeDifference = roundMoney(report.FeeDeducted - report.FeeCharged)
+
+	var err error
+	if report.Flagged, err = s.Repo.ListFlagged(ctx, gateway, report.From, report.To); err != nil {
+		return nil, err
+	}
+
+	dueBy := timeutil.Now().AddDate(0, 0, -s.graceDays(ctx))
+	if dueBy.After(report.To) {
+		dueBy = report.To
+	}
+	if dueBy.After(report.From) {
+		if report.Missing, err = s.Repo.ListUnsettled(ctx, gateway, report.From, dueBy); err != nil {
+			return nil, err
+		}
+	}
+	for _, tx := range report.Missing {
+		report.MissingAmount += tx.TotalAmount
+	}
+	report.MissingCount = len(report.Missing)
+	report.MissingAmount = roundMoney(report.MissingAmount)
+
+	if report.Flagged == nil {
+		report.Flagged = []*models.GatewaySettlementLine{}
+	}
+	if report.Missing == nil {
+		report.Missing = []*models.OnlineTransaction{}
+	}
+	return report, nil
+}
+
+// ListLines returns settlement lines by status and/or import
+func (s *SettlementService) ListLines(ctx context.Context, status string, importID int) ([]*models.GatewaySettlementLine, error) {
+	return s.Repo.ListLines(ctx, status, importID)
+}
+
+// ListImports returns recent settlement imports
+func (s *SettlementService) ListImports(ctx context.Context, limit int) ([]*models.GatewaySettlementImport, error) {
+	return s.Repo.ListImports(ctx, limit)
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+)
+
+// SlotAllocationService proposes gatars for an incoming thock. A thock is kept together
+// in adjacent gatars on one floor, seed and sell go to rooms of their own type (and never
+// into a gatar holding the other), floors fill bottom-up, and no gatar is filled past its
+// capacity. Floors where the customer already has stock are tried first.
+type SlotAllocationService struct {
+	LayoutService *WarehouseLayoutService
+	EntryRepo     *repositories.EntryRepository
+	GatarRepo     *repositories.RoomEntryGatarRepository
+}
+
+func NewSlotAllocationService(layoutService *WarehouseLayoutService, entryRepo *repositories.EntryRepository, gatarRepo *repositories.RoomEntryGatarRepository) *SlotAllocationService {
+	return &SlotAllocationService{
+		LayoutService: layoutService,
+		EntryRepo:     entryRepo,
+		GatarRepo:     gatarRepo,
+	}
+}
+
+// slotFloor is a floor the thock may go to, with the free space of each usable gatar
+type slotFloor struct {
+	room          *models.LayoutRoom
+	floor         *models.LayoutFloor
+	roomIndex     int
+	level         int
+	customerHere  bool
+	gatars        []int
+	free          map[int]int
+	customerGatar []int // Gatars on the floor already holding the customer's stock
+}
+
+// Suggest proposes up to limit places for an entry's bags. quantity 0 means the bags
+// not yet assigned to a room.
+func (s *SlotAllocationService) Suggest(ctx context.Context, entryID, quantity, limit int) (*models.SlotSuggestions, error) {
+	entry, err := s.EntryRepo.Get(ctx, entryID)
+	if err != nil {
+		return nil, errors.New("entry not found")
+	}
+	if quantity <= 0 {
+		quantity = entry.ExpectedQuantity - entry.ActualQuantity
+	}
+	if quantity <= 0 {
+		return nil, fmt.Errorf("thock %s is already fully stored", entry.ThockNumber)
+	}
+	if limit <= 0 {
+		limit = 3
+	}
+
+	result := &models.SlotSuggestions{
+		EntryID:       entry.ID,
+		ThockNumber:   entry.ThockNumber,
+		ThockCategory: entry.ThockCategory,
+		Variety:       entry.Remark,
+		Quantity:      quantity,
+		Suggestions:   []*models.SlotSuggestion{},
+	}
+
+	layout, err := s.LayoutService.Layout(ctx)
+	if err != nil {
+		return nil, err
+	}
+	occupants, err := s.GatarRepo.GetOccupants(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load gatar stock: %w", err)
+	}
+
+	floors := s.candidateFloors(layout, occupants, entry)
+
+	// Adjacent gatars on one floor, at most one suggestion per floor
+	for _, f := range floors {
+		if len(result.Suggestions) >= limit {
+			break
+		}
+		if window := f.bestWindow(quantity); window != nil {
+			result.Suggestions = append(result.Suggestions, f.suggest(window, quantity, true))
+		}
+	}
+
+	// No floor has enough adjacent space: split the thock over the first floor that fits it
+	if len(result.Suggestions) == 0 {
+		for _, f := range floors {
+			total := 0
+			for _, g := range f.gatars {
+				total += f.free[g]
+			}
+			if total >= quantity {
+				result.Suggestions = append(result.Suggestions, f.suggest(f.gatars, quantity, false))
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// candidateFloors returns the floors of rooms for the entry's category, best first:
+// floors holding the customer's stock, then lower floors, then layout room order
+func (s *SlotAllocationService) candidateFloors(layout *models.WarehouseLayout, occupants map[int]*repositories.GatarOccupant, entry *models.Entry) []*slotFloor {
+	typed := false
+	for _, room := range layout.Rooms {
+		if room.RoomType == entry.ThockCategory {
+			typed = true
+			break
+		}
+	}
+
+	var floors []*slotFloor
+	for i, room := range layout.Rooms {
+		if typed && room.RoomType != entry.ThockCategory {
+			continue
+		}
+		for j, f := range room.Floors {
+			level, err := strconv.Atoi(f.Floor)
+			if err != nil {
+				level = j
+			}
+			sf := &slotFloor{room: room, floor: f, roomIndex: i, level: level, free: make(map[int]int)}
+			for _, g := range f.Gatars() {
+				used := 0
+				if o, ok := occupants[g]; ok {
+					used = o.Quantity
+					if containsInt(o.CustomerIDs, entry.CustomerID) {
+						sf.customerHere = true
+						sf.customerGatar = append(sf.customerGatar, g)
+					}
+					if holdsOtherCategory(o.Categories, entry.ThockCategory) {
+						continue
+					}
+				}
+				if free := layout.Capacity(g) - used; free > 0 {
+					sf.gatars = append(sf.gatars, g)
+					sf.free[g] = free
+				}
+			}
+			floors = append(floors, sf)
+		}
+	}
+
+	sort.SliceStable(floors, func(a, b int) bool {
+		if floors[a].customerHere != floors[b].customerHere {
+			return floors[a].customerHere
+		}
+		if floors[a].level != floors[b].level {
+			return floors[a].level < floors[b].level
+		}
+		return floors[a].roomIndex < floors[b].roomIndex
+	})
+	return floors
+}
+
+// bestWindow returns the shortest run of consecutively numbered gatars, starting at each
+// usable gatar, that holds the quantity: the one nearest the customer's stock, else the
+// lowest-numbered. nil if no run is big enough.
+func (f *slotFloor) bestWindow(quantity int) []int {
+	var best []int
+	bestDistance := -1
+	for i := range f.gatars {
+		total := 0
+		for j := i; j < len(f.gatars); j++ {
+			if j > i && f.gatars[j] != f.gatars[j-1]+1 {
+				break
+			}
+			total += f.free[f.gatars[j]]
+			if total < quantity {
+				continue
+			}
+			window := f.gatars[i : j+1]
+			distance := f.distanceToCustomer(window)
+			if best == nil || (distance >= 0 && (bestDistance < 0 || distance < bestDistance)) {
+				best, bestDistance = window, distance
+			}
+			break
+		}
+	}
+	return best
+}
+
+// distanceToCustomer returns how far a run of gatars is from the customer's nearest
+// gatar on the floor (-1 if the customer has none)
+func (f *slotFloor) distanceToCustomer(window []int) int {
+	distance := -1
+	for _, c := range f.customerGatar {
+		d := 0
+		if c < window[0] {
+			d = window[0] - c
+		} else if c > window[len(window)-1] {
+			d = c - window[len(window)-1]
+		}
+		if distance < 0 || d < distance {
+			distance = d
+		}
+	}
+	return distance
+}
+
+// suggest fills the given gatars in order until the quantity is placed
+func (f *slotFloor) suggest(gatars []int, quantity int, together bool) *models.SlotSuggestion {
+	sg := &models.SlotSuggestion{RoomNo: f.room.RoomNo, Floor: f.floor.Floor, Together: together}
+	remaining := quantity
+	var numbers []string
+	for _, g := range gatars {
+		if remaining == 0 {
+			break
+		}
+		qty := f.free[g]
+		if qty > remaining {
+			qty = remaining
+		}
+		remaining -= qty
+		sg.FreeAfter += f.free[g] - qty
+		sg.Gatars = append(sg.Gatars, models.GatarInput{GatarNo: g, Quantity: qty})
+		numbers = append(numbers, strconv.Itoa(g))
+	}
+	sg.GateNo = strings.Join(numbers, ", ")
+
+	var reasons []string
+	if f.customerHere {
+		reasons = append(reasons, "customer already has stock on this floor")
+	}
+	if together {
+		reasons = append(reasons, fmt.Sprintf("%d adjacent gatars hold all %d bags", len(sg.Gatars), quantity))
+	} else {
+		reasons = append(reasons, fmt.Sprintf("no adjacent space for %d bags; split over %d gatars", quantity, len(sg.Gatars)))
+	}
+	sg.Reason = f.room.Name + " floor " + f.floor.Floor + ": " + strings.Join(reasons, ", ")
+	return sg
+}
+
+// holdsOtherCategory reports whether a gatar holds thocks of a category other than this one
+func holdsOtherCategory(categories []string, category string) bool {
+	for _, c := range categories {
+		if c != "" && category != "" && c != category {
+			return true
+		}
+	}
+	return false
+}
+
+func containsInt(values []int, v int) bool {
+	for _, x := range values {
+		if x == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,399 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strings"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/timeutil"
+)
+
+// TallyService exports the customer ledger as Tally Prime import XML.
+// Each money-moving ledger entry becomes one voucher against the customer's
+// Sundry Debtors ledger and the chart of accounts ledger it posts to.
+type TallyService struct {
+	Repo           *repositories.TallyRepository
+	AccountingRepo *repositories.AccountingRepository
+	CustomerRepo   *repositories.CustomerRepository
+	SettingRepo    *repositories.SystemSettingRepository
+}
+
+func NewTallyService(repo *repositories.TallyRepository, accountingRepo *repositories.AccountingRepository, customerRepo *repositories.CustomerRepository, settingRepo *repositories.SystemSettingRepository) *TallyService {
+	return &TallyService{
+		Repo:           repo,
+		AccountingRepo: accountingRepo,
+		CustomerRepo:   customerRepo,
+		SettingRepo:    settingRepo,
+	}
+}
+
+// Tally import XML (ENVELOPE > BODY > IMPORTDATA > REQUESTDATA > TALLYMESSAGE)
+type tallyEnvelope struct {
+	XMLName      xml.Name `xml:"ENVELOPE"`
+	TallyRequest string   `xml:"HEADER>TALLYREQUEST"`
+	ImportData   struct {
+		RequestDesc struct {
+			ReportName      string                `xml:"REPORTNAME"`
+			StaticVariables *tallyStaticVariables `xml:"STATICVARIABLES,omitempty"`
+		} `xml:"REQUESTDESC"`
+		Messages []tallyMessage `xml:"REQUESTDATA>TALLYMESSAGE"`
+	} `xml:"BODY>IMPORTDATA"`
+}
+
+type tallyStaticVariables struct {
+	CurrentCompany string `xml:"SVCURRENTCOMPANY"`
+}
+
+type tallyMessage struct {
+	Ledger  *tallyLedger  `xml:"LEDGER,omitempty"`
+	Voucher *tallyVoucher `xml:"VOUCHER,omitempty"`
+}
+
+type tallyLedger struct {
+	Name         string   `xml:"NAME,attr"`
+	Action       string   `xml:"ACTION,attr"`
+	NameList     []string `xml:"NAME.LIST>NAME"`
+	Parent       string   `xml:"PARENT"`
+	Address      []string `xml:"ADDRESS.LIST>ADDRESS,omitempty"`
+	Phone        string   `xml:"LEDGERPHONE,omitempty"`
+	IsBillWiseOn string   `xml:"ISBILLWISEON"`
+}
+
+type tallyVoucher struct {
+	RemoteID        string             `xml:"REMOTEID,attr"`
+	VchType         string             `xml:"VCHTYPE,attr"`
+	Action          string             `xml:"ACTION,attr"`
+	Date            string             `xml:"DATE"`
+	VoucherTypeName string             `xml:"VOUCHERTYPENAME"`
+	VoucherNumber   string             `xml:"VOUCHERNUMBER"`
+	PartyLedgerName string             `xml:"PARTYLEDGERNAME"`
+	Narration       string             `xml:"NARRATION"`
+	Entries         []tallyLedgerEntry `xml:"ALLLEDGERENTRIES.LIST"`
+}
+
+type tallyLedgerEntry struct {
+	LedgerName       string `xml:"LEDGERNAME"`
+	IsDeemedPositive string `xml:"ISDEEMEDPOSITIVE"` // Yes = debit
+	Amount           string `xml:"AMOUNT"`           // Negative for debits, positive for credits
+}
+
+// tallyVoucherType returns the Tally voucher type for a ledger entry type
+func tallyVoucherType(entryType models.LedgerEntryType) (string, bool) {
+	switch entryType {
+	case models.LedgerEntryTypePayment, models.LedgerEntryTypeOnlinePayment, models.LedgerEntryTypeLoanRepayment:
+		return "Receipt", true
+	case models.LedgerEntryTypeCharge:
+		return "Sales", true
+	case models.LedgerEntryTypeInterest, models.LedgerEntryTypeReversal:
+		return "Journal", true
+	case models.LedgerEntryTypeCredit:
+		return "Credit Note", true
+	case models.LedgerEntryTypeRefund, models.LedgerEntryTypeLoanDisbursal:
+		return "Payment", true
+	}
+	return "", false
+}
+
+// tallyParentGroup returns the predefined Tally group for a chart of accounts entry
+func tallyParentGroup(a *models.Account) string {
+	switch a.Code {
+	case models.AccountCodeCash:
+		return "Cash-in-Hand"
+	case models.AccountCodeBank:
+		return "Bank Accounts"
+	case models.AccountCodeReceivables:
+		return "Sundry Debtors"
+	case models.AccountCodeRentIncome:
+		return "Sales Accounts"
+	}
+	switch a.AccountType {
+	case models.AccountTypeLiability:
+		return "Current Liabilities"
+	case models.AccountTypeEquity:
+		return "Capital Account"
+	case models.AccountTypeIncome:
+		return "Indirect Incomes"
+	case models.AccountTypeExpense:
+		return "Indirect Expenses"
+	}
+	return "Current Assets"
+}
+
+// tallyCustomerLedgerName is the customer's ledger name in Tally; the phone keeps namesakes apart
+func tallyCustomerLedgerName(name, phone string) string {
+	return fmt.Sprintf("%s (%s)", strings.TrimSpace(name), phone)
+}
+
+// ListExports returns previous export batches
+func (s *TallyService) ListExports(ctx context.Context, limit int) ([]*models.TallyExport, error) {
+	return s.Repo.ListExports(ctx, limit)
+}
+
+// CreateExport builds vouchers for ledger entries in the date range and marks them exported
+func (s *TallyService) CreateExport(ctx context.Context, req *models.CreateTallyExportRequest, userID int) (*models.TallyExport, []byte, error) {
+	from, err := timeutil.ParseInIST("2006-01-02", req.FromDate)
+	if err != nil {
+		return nil, nil, errors.New("invalid from_date. Use YYYY-MM-DD")
+	}
+	to, err := timeutil.ParseInIST("2006-01-02", req.ToDate)
+	if err != nil {
+		return nil, nil, errors.New("invalid to_date. Use YYYY-MM-DD")
+	}
+	if to.Before(from) {
+		return nil, nil, errors.New("to_date must not be before from_date")
+	}
+
+	entries, err := s.Repo.ListEntriesForExport(ctx, timeutil.StartOfDay(from), timeutil.EndOfDay(to), req.IncludeExported)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, exported, ledgerCount, err := s.buildVoucherXML(ctx, entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(exported) == 0 {
+		return nil, nil, errors.New("no ledger entries to export in this date range")
+	}
+
+	export := &models.TallyExport{
+		FromDate:        from,
+		ToDate:          to,
+		VoucherCount:    len(exported),
+		LedgerCount:     ledgerCount,
+		CreatedByUserID: userID,
+	}
+	if err := s.Repo.CreateExport(ctx, export, exported); err != nil {
+		return nil, nil, err
+	}
+	return export, data, nil
+}
+
+// DownloadExport rebuilds the XML for an earlier export batch
+func (s *TallyService) DownloadExport(ctx context.Context, id int) (*models.TallyExport, []byte, error) {
+	export, err := s.Repo.GetExport(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	entries, err := s.Repo.ListEntriesByExport(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, _, _, err := s.buildVoucherXML(ctx, entries)
+	if err != nil {
+		return nil, nil, err
+	}
+	return export, data, nil
+}
+
+// ExportMasters returns ledger masters for every active customer and account
+func (s *TallyService) ExportMasters(ctx context.Context) ([]byte, error) {
+	customers, err := s.CustomerRepo.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list customers: %w", err)
+	}
+	accounts, err := s.AccountingRepo.ListAccounts(ctx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	env := s.newEnvelope(ctx, "All Masters")
+	for _, a := range accounts {
+		if a.Code == models.AccountCodeReceivables {
+			continue // Customers have their own Sundry Debtors ledgers
+		}
+		env.ImportData.Messages = append(env.ImportData.Messages, accountLedgerMessage(a))
+	}
+	for _, c := range customers {
+		if c.Status == "merged" {
+			continue
+		}
+		env.ImportData.Messages = append(env.ImportData.Messages, customerLedgerMessage(c))
+	}
+	return marshalTallyEnvelope(env)
+}
+
+// buildVoucherXML returns the import XML, the IDs of ledger entries turned into vouchers
+// and the number of customer ledger masters included
+func (s *TallyService) buildVoucherXML(ctx context.Context, entries []models.TallyLedgerEntry) ([]byte, []int, int, error) {
+	accounts, err := s.AccountingRepo.ListAccounts(ctx, false)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	accountsByCode := make(map[string]*models.Account, len(accounts))
+	for _, a := range accounts {
+		accountsByCode[a.Code] = a
+	}
+
+	var vouchers []tallyMessage
+	var exported []int
+	var phones []string
+	names := make(map[string]string) // phone -> name from the ledger, for customers with no record
+	usedAccounts := make(map[string]bool)
+	for _, e := range entries {
+		vchType, ok := tallyVoucherType(e.EntryType)
+		if !ok {
+			continue
+		}
+		debitCode, creditCode, ok := ledgerEntryAccounts(e.EntryType, e.PaymentMode)
+		if e.EntryType == models.LedgerEntryTypeReversal {
+			// A reversal mirrors the voided entry's posting
+			creditCode, debitCode, ok = ledgerEntryAccounts(e.ReversedEntryType, e.PaymentMode)
+		}
+		if !ok {
+			continue
+		}
+		amount := roundMoney(e.Debit + e.Credit)
+		if amount <= 0 {
+			continue
+		}
+
+		// One side of every customer posting is the Receivables control account,
+		// which Tally holds as the customer's own Sundry Debtors ledger
+		party := tallyCustomerLedgerName(e.CustomerName, e.CustomerPhone)
+		debitLedger, creditLedger := party, party
+		if debitCode != models.AccountCodeReceivables {
+			a, ok := accountsByCode[debitCode]
+			if !ok {
+				return nil, nil, 0, fmt.Errorf("account %s not found", debitCode)
+			}
+			debitLedger = a.Name
+			usedAccounts[debitCode] = true
+		}
+		if creditCode != models.AccountCodeReceivables {
+			a, ok := accountsByCode[creditCode]
+			if !ok {
+				return nil, nil, 0, fmt.Errorf("account %s not found", creditCode)
+			}
+			creditLedger = a.Name
+			usedAccounts[creditCode] = true
+		}
+
+		voucherNumber := e.ReceiptNumber
+		if voucherNumber == "" {
+			voucherNumber = fmt.Sprintf("CS-%d", e.ID)
+		}
+		narration := e.Description
+		if e.FamilyMemberName != "" {
+			narration += " - " + e.FamilyMemberName
+		}
+		if e.Notes != "" {
+			narration += " | " + e.Notes
+		}
+
+		vouchers = append(vouchers, tallyMessage{Voucher: &tallyVoucher{
+			RemoteID:        fmt.Sprintf("cold-storage-ledger-%d", e.ID),
+			VchType:         vchType,
+			Action:          "Create",
+			Date:            timeutil.FormatIST(e.CreatedAt, "20060102"),
+			VoucherTypeName: vchType,
+			VoucherNumber:   voucherNumber,
+			PartyLedgerName: party,
+			Narration:       narration,
+			Entries: []tallyLedgerEntry{
+				{LedgerName: debitLedger, IsDeemedPositive: "Yes", Amount: fmt.Sprintf("-%.2f", amount)},
+				{LedgerName: creditLedger, IsDeemedPositive: "No", Amount: fmt.Sprintf("%.2f", amount)},
+			},
+		}})
+		exported = append(exported, e.ID)
+		if _, seen := names[e.CustomerPhone]; !seen {
+			phones = append(phones, e.CustomerPhone)
+		}
+		names[e.CustomerPhone] = e.CustomerName
+	}
+
+	// Masters go ahead of the vouchers so every ledger exists when Tally imports them
+	env := s.newEnvelope(ctx, "Vouchers")
+	for _, a := range accounts {
+		if usedAccounts[a.Code] {
+			env.ImportData.Messages = append(env.ImportData.Messages, accountLedgerMessage(a))
+		}
+	}
+	ledgerCount := 0
+	if len(phones) > 0 {
+		customers, err := s.Repo.ListCustomersByPhones(ctx, phones)
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		for _, c := range customers {
+			// Keep the ledger name in step with the vouchers
+			c.Name = names[c.Phone]
+			delete(names, c.Phone)
+			env.ImportData.Messages = append(env.ImportData.Messages, customerLedgerMessage(c))
+			ledgerCount++
+		}
+		for _, phone := range phones {
+			if name, ok := names[phone]; ok {
+				env.ImportData.Messages = append(env.ImportData.Messages, customerLedgerMessage(&models.Customer{Name: name, Phone: phone}))
+				ledgerCount++
+			}
+		}
+	}
+	env.ImportData.Messages = append(env.ImportData.Messages, vouchers...)
+
+	data, err := marshalTallyEnvelope(env)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	return data, exported, ledgerCount, nil
+}
+
+func (s *TallyService) newEnvelope(ctx context.Context, reportName string) *tallyEnvelope {
+	env := &tallyEnvelope{TallyRequest: "Import Data"}
+	env.ImportData.RequestDesc.ReportName = reportName
+	if s.SettingRepo != nil {
+		setting, err := s.SettingRepo.Get(ctx, "tally_company_name")
+		if err == nil && setting != nil && strings.TrimSpace(setting.SettingValue) != "" {
+			env.ImportData.RequestDesc.StaticVariables = &tallyStaticVariables{CurrentCompany: strings.TrimSpace(setting.SettingValue)}
+		}
+	}
+	return env
+}
+
+func accountLedgerMessage(a *models.Account) tallyMessage {
+	return tallyMessage{Ledger: &tallyLedger{
+		Name:         a.Name,
+		Action:       "Create",
+		NameList:     []string{a.Name},
+		Parent:       tallyParentGroup(a),
+		IsBillWiseOn: "No",
+	}}
+}
+
+func customerLedgerMessage(c *models.Customer) tallyMessage {
+	name := tallyCustomerLedgerName(c.Name, c.Phone)
+	var address []string
+	for _, line := range []string{c.Address, c.Village} {
+		if line = strings.TrimSpace(line); line != "" {
+			address = append(address, line)
+		}
+	}
+	if c.SO != "" {
+		address = append([]string{"S/O " + c.SO}, address...)
+	}
+	return tallyMessage{Ledger: &tallyLedger{
+		Name:         name,
+		Action:       "Create",
+		NameList:     []string{name},
+		Parent:       "Sundry Debtors",
+		Address:      address,
+		Phone:        c.Phone,
+		IsBillWiseOn: "No",
+	}}
+}
+
+func marshalTallyEnvelope(env *tallyEnvelope) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(env); err != nil {
+		return nil, fmt.Errorf("failed to encode tally XML: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,280 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+	"cold-backend/internal/timeutil"
+
+	"github.com/jung-kurt/gofpdf/v2"
+)
+
+// ThockLienService manages bank liens on stored stock and their warehouse receipts.
+// Bags under an active lien are excluded from the quantity a gate pass can withdraw.
+type ThockLienService struct {
+	LienRepo      *repositories.ThockLienRepository
+	EntryRepo     *repositories.EntryRepository
+	RoomEntryRepo *repositories.RoomEntryRepository
+	PickupRepo    *repositories.GatePassPickupRepository
+}
+
+func NewThockLienService(
+	lienRepo *repositories.ThockLienRepository,
+	entryRepo *repositories.EntryRepository,
+	roomEntryRepo *repositories.RoomEntryRepository,
+	pickupRepo *repositories.GatePassPickupRepository,
+) *ThockLienService {
+	return &ThockLienService{
+		LienRepo:      lienRepo,
+		EntryRepo:     entryRepo,
+		RoomEntryRepo: roomEntryRepo,
+		PickupRepo:    pickupRepo,
+	}
+}
+
+// ListLiens returns liens, optionally filtered by status, thock and customer phone
+func (s *ThockLienService) ListLiens(ctx context.Context, status, thockNumber, customerPhone string) ([]*models.ThockLien, error) {
+	return s.LienRepo.List(ctx, status, thockNumber, customerPhone)
+}
+
+// GetLien returns a single lien
+func (s *ThockLienService) GetLien(ctx context.Context, id int) (*models.ThockLien, error) {
+	return s.LienRepo.Get(ctx, id)
+}
+
+// CreateLien marks bags of a thock as pledged to a bank
+func (s *ThockLienService) CreateLien(ctx context.Context, req *models.CreateThockLienRequest, userID int) (*models.ThockLien, error) {
+	bankName := strings.TrimSpace(req.BankName)
+	if bankName == "" {
+		return nil, errors.New("bank name is required")
+	}
+	if req.PledgedQuantity <= 0 {
+		return nil, errors.New("pledged quantity must be greater than zero")
+	}
+	if req.LienAmount < 0 {
+		return nil, errors.New("lien amount cannot be negative")
+	}
+
+	entry, err := s.EntryRepo.GetByThockNumber(ctx, strings.TrimSpace(req.ThockNumber))
+	if err != nil {
+		return nil, errors.New("thock not found")
+	}
+
+	stored, err := storedThockQuantity(ctx, s.RoomEntryRepo, s.PickupRepo, entry.ThockNumber)
+	if err != nil {
+		return nil, err
+	}
+	pledged, err := s.LienRepo.GetPledgedQuantityByThock(ctx, entry.ThockNumber)
+	if err != nil {
+		return nil, err
+	}
+	if unpledged := stored - pledged; req.PledgedQuantity > unpledged {
+		return nil, fmt.Errorf("pledged quantity (%d) exceeds unpledged stock (%d of %d bags already under lien)",
+			req.PledgedQuantity, max(unpledged, 0), pledged)
+	}
+
+	code, err := newVerificationCode()
+	if err != nil {
+		return nil, err
+	}
+
+	lien := &models.ThockLien{
+		VerificationCode:  code,
+		EntryID:           entry.ID,
+		ThockNumber:       entry.ThockNumber,
+		CustomerID:        entry.CustomerID,
+		BankName:          bankName,
+		BankBranch:        strings.TrimSpace(req.BankBranch),
+		LoanAccountNumber: strings.TrimSpace(req.LoanAccountNumber),
+		PledgedQuantity:   req.PledgedQuantity,
+		LienAmount:        roundMoney(req.LienAmount),
+		Status:            models.ThockLienStatusActive,
+		Notes:             strings.TrimSpace(req.Notes),
+		MarkedByUserID:    userID,
+	}
+	if err := s.LienRepo.Create(ctx, lien); err != nil {
+		return nil, err
+	}
+	return s.LienRepo.Get(ctx, lien.ID)
+}
+
+// ReleaseLien frees the pledged bags once the bank has issued a release
+func (s *ThockLienService) ReleaseLien(ctx context.Context, id int, req *models.ReleaseThockLienRequest, userID int) (*models.ThockLien, error) {
+	if err := s.LienRepo.Release(ctx, id, userID, strings.TrimSpace(req.ReleaseReference)); err != nil {
+		return nil, err
+	}
+	return s.LienRepo.Get(ctx, id)
+}
+
+// VerifyReceipt looks up a receipt by its verification code for a bank.
+// Unknown codes return Valid=false rather than an error.
+func (s *ThockLienService) VerifyReceipt(ctx context.Context, code string) (*models.WarehouseReceiptVerification, error) {
+	code = normalizeVerificationCode(code)
+	if code == "" {
+		return &models.WarehouseReceiptVerification{Valid: false}, nil
+	}
+
+	lien, err := s.LienRepo.GetByVerificationCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+	if lien == nil {
+		return &models.WarehouseReceiptVerification{Valid: false}, nil
+	}
+
+	return &models.WarehouseReceiptVerification{
+		Valid:           true,
+		ReceiptNumber:   lien.ReceiptNumber,
+		Status:          lien.Status,
+		BankName:        lien.BankName,
+		CustomerName:    lien.CustomerName,
+		ThockNumber:     lien.ThockNumber,
+		PledgedQuantity: lien.PledgedQuantity,
+		LienAmount:      lien.LienAmount,
+		MarkedAt:        &lien.MarkedAt,
+		ReleasedAt:      lien.ReleasedAt,
+	}, nil
+}
+
+// GenerateReceiptPDF generates the warehouse receipt for a lien
+func (s *ThockLienService) GenerateReceiptPDF(lien *models.ThockLien) ([]byte, error) {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(10, 10, 10)
+	pdf.AddPage()
+
+	// Header
+	pdf.SetFont("Arial", "B", 16)
+	pdf.CellFormat(190, 10, "Cold Storage - Warehouse Receipt", "", 1, "C", false, 0, "")
+	pdf.SetFont("Arial", "", 10)
+	pdf.CellFormat(190, 6, fmt.Sprintf("Receipt No: %s    Issued: %s", lien.ReceiptNumber,
+		timeutil.FormatIST(lien.MarkedAt, "02-Jan-2006 03:04 PM")), "", 1, "C", false, 0, "")
+	pdf.Ln(5)
+
+	// Depositor Info Box
+	pdf.SetFillColor(240, 240, 240)
+	pdf.SetFont("Arial", "B", 12)
+	pdf.CellFormat(190, 8, "Depositor", "1", 1, "L", true, 0, "")
+
+	pdf.SetFont("Arial", "", 11)
+	pdf.CellFormat(95, 7, fmt.Sprintf("Name: %s", lien.CustomerName), "LB", 0, "L", false, 0, "")
+	pdf.CellFormat(95, 7, fmt.Sprintf("Phone: %s", lien.CustomerPhone), "RB", 1, "L", false, 0, "")
+	pdf.CellFormat(95, 7, fmt.Sprintf("Village: %s", lien.CustomerVillage), "LB", 0, "L", false, 0, "")
+	if lien.CustomerSO != "" {
+		pdf.CellFormat(95, 7, fmt.Sprintf("S/O: %s", lien.CustomerSO), "RB", 1, "L", false, 0, "")
+	} else {
+		pdf.CellFormat(95, 7, "", "RB", 1, "L", false, 0, "")
+	}
+	pdf.Ln(5)
+
+	// Pledged Stock
+	pdf.SetFont("Arial", "B", 12)
+	pdf.CellFormat(190, 8, "Pledged Stock", "1", 1, "L", true, 0, "")
+
+	pdf.SetFont("Arial", "B", 10)
+	pdf.SetFillColor(200, 200, 200)
+	pdf.CellFormat(50, 7, "Thock No", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(90, 7, "Variety", "1", 0, "C", true, 0, "")
+	pdf.CellFormat(50, 7, "Pledged Qty", "1", 1, "C", true, 0, "")
+
+	pdf.SetFont("Arial", "", 10)
+	variety := lien.Variety
+	if len(variety) > 40 {
+		variety = variety[:37] + "..."
+	}
+	pdf.CellFormat(50, 6, lien.ThockNumber, "1", 0, "C", false, 0, "")
+	pdf.CellFormat(90, 6, variety, "1", 0, "L", false, 0, "")
+	pdf.CellFormat(50, 6, fmt.Sprintf("%d bags", lien.PledgedQuantity), "1", 1, "C", false, 0, "")
+	pdf.Ln(5)
+
+	// Lien Details
+	pdf.SetFillColor(240, 240, 240)
+	pdf.SetFont("Arial", "B", 12)
+	pdf.CellFormat(190, 8, "Lien in Favour Of", "1", 1, "L", true, 0, "")
+
+	pdf.SetFont("Arial", "", 11)
+	pdf.CellFormat(95, 7, fmt.Sprintf("Bank: %s", lien.BankName), "LB", 0, "L", false, 0, "")
+	pdf.CellFormat(95, 7, fmt.Sprintf("Branch: %s", lien.BankBranch), "RB", 1, "L", false, 0, "")
+	pdf.CellFormat(95, 7, fmt.Sprintf("Loan A/C: %s", lien.LoanAccountNumber), "LB", 0, "L", false, 0, "")
+	pdf.CellFormat(95, 7, fmt.Sprintf("Lien Amount: Rs. %.2f", lien.LienAmount), "RB", 1, "L", false, 0, "")
+	pdf.Ln(5)
+
+	// Status - highlight active lien vs released
+	if lien.Status == models.ThockLienStatusActive {
+		pdf.SetFillColor(255, 230, 180) // Light amber while stock is held
+	} else {
+		pdf.SetFillColor(200, 255, 200) // Light green once released
+	}
+	pdf.SetFont("Arial", "B", 14)
+	statusText := "LIEN ACTIVE - STOCK HELD FOR BANK"
+	if lien.Status != models.ThockLienStatusActive {
+		statusText = "LIEN RELEASED"
+		if lien.ReleasedAt != nil {
+			statusText += " ON " + timeutil.FormatIST(*lien.ReleasedAt, "02-Jan-2006")
+		}
+	}
+	pdf.CellFormat(190, 10, statusText, "1", 1, "C", true, 0, "")
+	pdf.Ln(5)
+
+	// Undertaking
+	pdf.SetFont("Arial", "", 10)
+	pdf.MultiCell(190, 5, fmt.Sprintf(
+		"We confirm that the above stock is stored in our cold storage and is held under lien in favour of %s. "+
+			"The pledged bags will not be delivered to the depositor or any other person without a written release from the bank.",
+		lien.BankName), "", "L", false)
+	pdf.Ln(5)
+
+	// Verification
+	pdf.SetFillColor(240, 240, 240)
+	pdf.SetFont("Arial", "B", 12)
+	pdf.CellFormat(190, 8, fmt.Sprintf("Verification Code: %s", formatVerificationCode(lien.VerificationCode)), "1", 1, "C", true, 0, "")
+	pdf.SetFont("Arial", "", 9)
+	pdf.CellFormat(190, 6, "Banks can verify this receipt online at /api/verify-receipt/<verification code>", "", 1, "C", false, 0, "")
+	pdf.Ln(15)
+
+	// Signatures
+	pdf.SetFont("Arial", "", 10)
+	pdf.CellFormat(95, 6, "____________________", "", 0, "L", false, 0, "")
+	pdf.CellFormat(95, 6, "____________________", "", 1, "R", false, 0, "")
+	pdf.CellFormat(95, 6, "Depositor", "", 0, "L", false, 0, "")
+	pdf.CellFormat(95, 6, "Authorised Signatory", "", 1, "R", false, 0, "")
+
+	var buf bytes.Buffer
+	err := pdf.Output(&buf)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// newVerificationCode returns a random 12-character receipt verification code
+func newVerificationCode() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate verification code: %w", err)
+	}
+	return strings.ToUpper(hex.EncodeToString(b)), nil
+}
+
+// normalizeVerificationCode accepts codes typed with dashes, spaces or lower case
+func normalizeVerificationCode(code string) string {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	code = strings.ReplaceAll(code, "-", "")
+	return strings.ReplaceAll(code, " ", "")
+}
+
+// formatVerificationCode prints a code in groups of four (XXXX-XXXX-XXXX)
+func formatVerificationCode(code string) string {
+	var groups []string
+	for len(code) > 4 {
+		groups = append(groups, code[:4])
+		code = code[4:]
+	}
+	return strings.Join(append(groups, code), "-")
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+)
+
+// Ledger reference types of wallet postings. A deposit is a PAYMENT entry, so payment
+// allocation applies it to charges like any other payment; a refund is a REFUND entry
+// only deposits can settle.
+const (
+	WalletDepositReferenceType = "wallet_deposit"
+	WalletRefundReferenceType  = "wallet_refund"
+)
+
+// WalletService manages customer advance deposits: taking deposits, applying them to
+// charges and refunding the unused balance at season end
+type WalletService struct {
+	Repo          *repositories.WalletRepository
+	LedgerService *LedgerService
+	CustomerRepo  *repositories.CustomerRepository
+}
+
+func NewWalletService(repo *repositories.WalletRepository, customerRepo *repositories.CustomerRepository) *WalletService {
+	return &WalletService{
+		Repo:         repo,
+		CustomerRepo: customerRepo,
+	}
+}
+
+// SetLedgerService enables deposits and refunds; without it wallets are read-only
+func (s *WalletService) SetLedgerService(ledgerService *LedgerService) {
+	s.LedgerService = ledgerService
+}
+
+// GetWallet returns a customer's wallet totals, with the statement when withStatement is
+// set. Returns nil if the customer has never deposited.
+func (s *WalletService) GetWallet(ctx context.Context, phone string, withStatement bool) (*models.Wallet, error) {
+	wallets, err := s.Repo.ListTotals(ctx, phone)
+	if err != nil {
+		return nil, err
+	}
+	if len(wallets) == 0 {
+		return nil, nil
+	}
+	wallet := wallets[0]
+	if withStatement {
+		if wallet.Statement, err = s.Repo.ListStatement(ctx, phone); err != nil {
+			return nil, err
+		}
+	}
+	return wallet, nil
+}
+
+// ListBalances returns the customers with an unused wallet balance - the season-end refund list
+func (s *WalletService) ListBalances(ctx context.Context) ([]*models.Wallet, error) {
+	wallets, err := s.Repo.ListTotals(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	var balances []*models.Wallet
+	for _, w := range wallets {
+		if w.Balance > 0.005 {
+			balances = append(balances, w)
+		}
+	}
+	return balances, nil
+}
+
+// walletPaymentMode validates how money moved in or out of a wallet. Cheques are taken as
+// rent payments, which credit the ledger only once cleared.
+func walletPaymentMode(mode, utr string) (string, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case "", models.PaymentModeCash:
+		return models.PaymentModeCash, nil
+	case models.PaymentModeNEFT, models.PaymentModeRTGS, models.PaymentModeUPI:
+		if strings.TrimSpace(utr) == "" {
+			return "", fmt.Errorf("UTR / reference number is required for %s", strings.ToUpper(mode))
+		}
+		return mode, nil
+	case models.PaymentModeCheque:
+		return "", errors.New("cheques cannot be taken as wallet deposits or refunds")
+	}
+	return "", fmt.Errorf("invalid payment mode: %s", mode)
+}
+
+// walletPosting builds a ledger entry for a wallet deposit or refund
+func (s *WalletService) walletPosting(ctx context.Context, phone, mode, utr, notes string, userID int) (*models.CreateLedgerEntryRequest, error) {
+	if s.LedgerService == nil {
+		return nil, errors.New("wallet postings are not available")
+	}
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return nil, errors.New("customer phone is required")
+	}
+
+	customer, err := s.CustomerRepo.GetByPhone(ctx, phone)
+	if err != nil {
+		return nil, fmt.Errorf("customer %s not found", phone)
+	}
+
+	entry := &models.CreateLedgerEntryRequest{
+		CustomerPhone:   phone,
+		CustomerName:    customer.Name,
+		CustomerSO:      customer.SO,
+		CreatedByUserID: userID,
+		PaymentMode:     mode,
+		Notes:           strings.TrimSpace(notes),
+	}
+	if utr = strings.TrimSpace(utr); utr != "" {
+		entry.Notes = strings.TrimSpace(fmt.Sprintf("UTR: %s %s", utr, entry.Notes))
+	}
+	return entry, nil
+}
+
+// Deposit credits money to a customer's wallet. It settles any outstanding charges
+// not covered by other payments, then new charges as they are posted.
+func (s *WalletService) Deposit(ctx context.Context, req *models.WalletDepositRequest, userID int) (*models.LedgerEntry, error) {
+	amount := roundMoney(req.Amount)
+	if amount <= 0 {
+		return nil, errors.New("deposit amount must be positive")
+	}
+	mode, err := walletPaymentMode(req.PaymentMode, req.UTRNumber)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.walletPosting(ctx, req.CustomerPhone, mode, req.UTRNumber, req.Notes, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	entry.EntryType = models.LedgerEntryTypePayment
+	entry.Credit = amount
+	entry.ReferenceType = WalletDepositReferenceType
+	entry.Description = fmt.Sprintf("Wallet deposit (%s)", strings.ToUpper(mode))
+	return s.post(ctx, entry)
+}
+
+// Refund pays back unused wallet balance; an amount of 0 refunds all of it
+func (s *WalletService) Refund(ctx context.Context, req *models.WalletRefundRequest, userID int) (*models.LedgerEntry, error) {
+	mode, err := walletPaymentMode(req.PaymentMode, req.UTRNumber)
+	if err != nil {
+		return nil, err
+	}
+	entry, err := s.walletPosting(ctx, req.CustomerPhone, mode, req.UTRNumber, req.Notes, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Repo.Post checks the balance again under lock; this one works out a full refund
+	wallet, err := s.GetWallet(ctx, entry.CustomerPhone, false)
+	if err != nil {
+		return nil, err
+	}
+	if wallet == nil || wallet.Balance <= 0.005 {
+		return nil, errors.New("customer has no wallet balance to refund")
+	}
+	amount := roundMoney(req.Amount)
+	if amount < 0 {
+		return nil, errors.New("refund amount cannot be negative")
+	}
+	if amount == 0 {
+		amount = wallet.Balance
+	}
+	if amount > wallet.Balance+0.005 {
+		return nil, fmt.Errorf("refund exceeds the wallet balance of ₹%.2f", wallet.Balance)
+	}
+
+	entry.EntryType = models.LedgerEntryTypeRefund
+	entry.Debit = amount
+	entry.ReferenceType = WalletRefundReferenceType
+	entry.Description = fmt.Sprintf("Wallet refund (%s)", strings.ToUpper(mode))
+	return s.post(ctx, entry)
+}
+
+// post writes a wallet deposit or refund, tying cash to the user's open cash session
+func (s *WalletService) post(ctx context.Context, entry *models.CreateLedgerEntryRequest) (*models.LedgerEntry, error) {
+	if err := s.LedgerService.PrepareEntry(ctx, entry); err != nil {
+		return nil, err
+	}
+	posted, err := s.Repo.Post(ctx, entry)
+	if err != nil {
+		return nil, err
+	}
+	s.LedgerService.EntriesPosted(ctx, posted)
+	return posted, nil
+}
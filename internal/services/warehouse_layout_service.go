@@ -0,0 +1,245 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"cold-backend/internal/models"
+	"cold-backend/internal/repositories"
+)
+
+var ErrLayoutRoomNotFound = errors.New("room not found in warehouse layout")
+
+// layoutCacheTTL bounds how long other servers keep serving a layout edited elsewhere
+const layoutCacheTTL = time.Minute
+
+// WarehouseLayoutService serves the warehouse layout (rooms, floors, gatar ranges and
+// capacities). It is read on every floor plan and room entry but edited rarely, so it
+// is cached; edits made through this service refresh it immediately.
+type WarehouseLayoutService struct {
+	Repo *repositories.WarehouseLayoutRepository
+
+	mu       sync.Mutex
+	layout   *models.WarehouseLayout
+	loadedAt time.Time
+}
+
+func NewWarehouseLayoutService(repo *repositories.WarehouseLayoutRepository) *WarehouseLayoutService {
+	return &WarehouseLayoutService{Repo: repo}
+}
+
+// Layout returns the current layout. Callers must not modify it.
+func (s *WarehouseLayoutService) Layout(ctx context.Context) (*models.WarehouseLayout, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.layout != nil && time.Since(s.loadedAt) < layoutCacheTTL {
+		return s.layout, nil
+	}
+	layout, err := s.Repo.Load(ctx)
+	if err != nil {
+		if s.layout != nil {
+			log.Printf("[WarehouseLayout] Reload failed, serving cached layout: %v", err)
+			return s.layout, nil
+		}
+		return nil, err
+	}
+	s.layout = layout
+	s.loadedAt = time.Now()
+	return layout, nil
+}
+
+func (s *WarehouseLayoutService) invalidate() {
+	s.mu.Lock()
+	s.layout = nil
+	s.mu.Unlock()
+}
+
+// SaveRoom creates or replaces a room. Gatar ranges may not overlap any other floor's,
+// and floors holding stock cannot be removed.
+func (s *WarehouseLayoutService) SaveRoom(ctx context.Context, room *models.LayoutRoom) (*models.LayoutRoom, error) {
+	room.RoomNo = strings.TrimSpace(room.RoomNo)
+	room.Name = strings.TrimSpace(room.Name)
+	if room.RoomNo == "" || len(room.RoomNo) > 10 {
+		return nil, errors.New("room number is required (up to 10 characters)")
+	}
+	if room.Name == "" {
+		room.Name = "Room " + room.RoomNo
+	}
+	if room.RoomType != models.RoomTypeSeed && room.RoomType != models.RoomTypeSell {
+		return nil, errors.New("room type must be seed or sell")
+	}
+	if len(room.Floors) == 0 {
+		return nil, errors.New("room needs at least one floor")
+	}
+
+	seen := make(map[string]bool)
+	for _, f := range room.Floors {
+		f.Floor = strings.TrimSpace(f.Floor)
+		if f.Floor == "" || len(f.Floor) > 10 {
+			return nil, errors.New("floor is required (up to 10 characters)")
+		}
+		if seen[f.Floor] {
+			return nil, fmt.Errorf("floor %s is listed twice", f.Floor)
+		}
+		seen[f.Floor] = true
+		if f.GridRows <= 0 {
+			f.GridRows = 10
+		}
+		if f.GatarCapacity <= 0 {
+			f.GatarCapacity = models.DefaultGatarCapacity
+		}
+		if len(f.Ranges) == 0 {
+			return nil, fmt.Errorf("floor %s needs at least one gatar range", f.Floor)
+		}
+		for _, g := range f.Ranges {
+			if g.StartGatar <= 0 || g.EndGatar < g.StartGatar {
+				return nil, fmt.Errorf("floor %s: invalid gatar range %d-%d", f.Floor, g.StartGatar, g.EndGatar)
+			}
+			if g.GridCols <= 0 {
+				return nil, fmt.Errorf("floor %s: gatar range %d-%d needs grid columns", f.Floor, g.StartGatar, g.EndGatar)
+			}
+		}
+	}
+
+	layout, err := s.Repo.Load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkRangeOverlaps(layout, room); err != nil {
+		return nil, err
+	}
+
+	inUse, err := s.Repo.FloorsInUse(ctx, room.RoomNo)
+	if err != nil {
+		return nil, err
+	}
+	for _, floor := range inUse {
+		if !seen[floor] {
+			return nil, fmt.Errorf("floor %s of room %s has stock and cannot be removed", floor, room.RoomNo)
+		}
+	}
+
+	if err := s.Repo.SaveRoom(ctx, room); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return room, nil
+}
+
+// checkRangeOverlaps rejects a room whose gatar ranges overlap each other or another room's
+func checkRangeOverlaps(layout *models.WarehouseLayout, room *models.LayoutRoom) error {
+	type placed struct {
+		models.LayoutGatarRange
+		where string
+	}
+	var ranges []placed
+	for _, other := range layout.Rooms {
+		if other.RoomNo == room.RoomNo {
+			continue
+		}
+		for _, f := range other.Floors {
+			for _, g := range f.Ranges {
+				ranges = append(ranges, placed{g, fmt.Sprintf("room %s floor %s", other.RoomNo, f.Floor)})
+			}
+		}
+	}
+	for _, f := range room.Floors {
+		for _, g := range f.Ranges {
+			for _, p := range ranges {
+				if g.StartGatar <= p.EndGatar && p.StartGatar <= g.EndGatar {
+					return fmt.Errorf("gatars %d-%d overlap %d-%d on %s", g.StartGatar, g.EndGatar, p.StartGatar, p.EndGatar, p.where)
+				}
+			}
+			ranges = append(ranges, placed{g, fmt.Sprintf("room %s floor %s", room.RoomNo, f.Floor)})
+		}
+	}
+	return nil
+}
+
+// DeleteRoom removes an empty room from the layout
+func (s *WarehouseLayoutService) DeleteRoom(ctx context.Context, roomNo string) error {
+	inUse, err := s.Repo.FloorsInUse(ctx, roomNo)
+	if err != nil {
+		return err
+	}
+	if len(inUse) > 0 {
+		return fmt.Errorf("room %s has stock and cannot be removed", roomNo)
+	}
+	deleted, err := s.Repo.DeleteRoom(ctx, roomNo)
+	if err != nil {
+		return err
+	}
+	if !deleted {
+		return ErrLayoutRoomNotFound
+	}
+	s.invalidate()
+	return nil
+}
+
+// SetGatarCapacity sets a gatar's capacity; 0 resets it to its floor's gatar capacity
+func (s *WarehouseLayoutService) SetGatarCapacity(ctx context.Context, gatarNo, capacity int) error {
+	if capacity < 0 {
+		return errors.New("capacity cannot be negative")
+	}
+	layout, err := s.Layout(ctx)
+	if err != nil {
+		return err
+	}
+	if roomNo, _ := layout.Locate(gatarNo); roomNo == "" {
+		return fmt.Errorf("gatar %d is not in the warehouse layout", gatarNo)
+	}
+	if err := s.Repo.SetGatarCapacity(ctx, gatarNo, capacity); err != nil {
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// ValidateLocation checks that a room and floor exist and every gatar is on that floor
+func (s *WarehouseLayoutService) ValidateLocation(ctx context.Context, roomNo, floor string, gatars []int) error {
+	layout, err := s.Layout(ctx)
+	if err != nil {
+		return err
+	}
+	room := layout.Room(roomNo)
+	if room == nil {
+		return fmt.Errorf("room %s is not in the warehouse layout", roomNo)
+	}
+	f := room.Floor(floor)
+	if f == nil {
+		return fmt.Errorf("room %s has no floor %s", roomNo, floor)
+	}
+	for _, g := range gatars {
+		if !f.Contains(g) {
+			if otherRoom, otherFloor := layout.Locate(g); otherRoom != "" {
+				return fmt.Errorf("gatar %d is in room %s floor %s, not room %s floor %s", g, otherRoom, otherFloor, roomNo, floor)
+			}
+			return fmt.Errorf("gatar %d is not in the warehouse layout", g)
+		}
+	}
+	return nil
+}
+
+// parseGatarList parses a room entry's comma-separated gate_no ("112, 114, 129")
+func parseGatarList(gateNo string) ([]int, error) {
+	var gatars []int
+	for _, part := range strings.Split(gateNo, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		g, err := strconv.Atoi(part)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gatar number %q", part)
+		}
+		gatars = append(gatars, g)
+	}
+	return gatars, nil
+}
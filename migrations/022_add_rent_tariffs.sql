@@ -0,0 +1,51 @@
+-- Migration: 022_add_rent_tariffs.sql
+-- Purpose: Configurable rent tariff engine (rate cards) replacing the flat
+-- rent_per_item / rent_rate_per_bag settings
+
+-- Bag size on entries (used to pick a bag-size specific rate card)
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS bag_size VARCHAR(20);
+
+CREATE TABLE IF NOT EXISTS rent_tariffs (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+
+    -- Matching dimensions (NULL/empty = applies to all)
+    thock_category VARCHAR(10),   -- seed, sell
+    variety VARCHAR(100),         -- matched against entries.remark varieties
+    bag_size VARCHAR(20),         -- matched against entries.bag_size
+    season VARCHAR(100),          -- matched against current_season setting
+
+    -- Rate
+    rate_per_item DECIMAL(10,2) NOT NULL CHECK (rate_per_item >= 0),
+
+    -- Validity (matched against the date stock entered storage)
+    effective_from DATE NOT NULL DEFAULT CURRENT_DATE,
+    effective_to DATE,
+
+    priority INTEGER NOT NULL DEFAULT 0,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    notes TEXT,
+
+    created_by_user_id INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_rent_tariff_category CHECK (thock_category IS NULL OR thock_category IN ('seed', 'sell')),
+    CONSTRAINT chk_rent_tariff_dates CHECK (effective_to IS NULL OR effective_to >= effective_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rent_tariffs_active ON rent_tariffs(is_active, effective_from);
+CREATE INDEX IF NOT EXISTS idx_rent_tariffs_category ON rent_tariffs(thock_category);
+
+COMMENT ON TABLE rent_tariffs IS 'Rent rate cards by thock category, variety, bag size and season with effective dates';
+COMMENT ON COLUMN rent_tariffs.priority IS 'Tie-breaker when two equally specific rate cards match (higher wins)';
+
+-- Current season label (rate cards with a season only apply while it matches)
+INSERT INTO system_settings (setting_key, setting_value, description)
+VALUES ('current_season', '', 'Current storage season label used for rent tariff lookup (e.g. 2025-26)')
+ON CONFLICT (setting_key) DO NOTHING;
+
+-- rent_per_item remains the fallback rate when no rate card matches
+INSERT INTO system_settings (setting_key, setting_value, description)
+VALUES ('rent_per_item', '0', 'Default rent per item (used when no rent tariff matches)')
+ON CONFLICT (setting_key) DO NOTHING;
@@ -0,0 +1,28 @@
+-- Migration: 023_add_duration_rent.sql
+-- Purpose: Duration-based (monthly / pro-rata) rent on rate cards.
+-- Rent for each pickup lot is posted to the ledger as a CHARGE referencing the pickup.
+
+-- billing_mode:
+--   flat    - rate_per_item covers the whole season
+--   monthly - rate_per_item per storage month (part months round up)
+--   prorata - rate_per_item per storage month, charged by day (30-day month)
+ALTER TABLE rent_tariffs ADD COLUMN IF NOT EXISTS billing_mode VARCHAR(10) NOT NULL DEFAULT 'flat';
+ALTER TABLE rent_tariffs ADD COLUMN IF NOT EXISTS min_months INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE rent_tariffs ADD COLUMN IF NOT EXISTS season_end_date DATE;
+ALTER TABLE rent_tariffs ADD COLUMN IF NOT EXISTS extra_month_rate DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'chk_rent_tariff_billing_mode') THEN
+        ALTER TABLE rent_tariffs ADD CONSTRAINT chk_rent_tariff_billing_mode
+            CHECK (billing_mode IN ('flat', 'monthly', 'prorata'));
+    END IF;
+END $$;
+
+COMMENT ON COLUMN rent_tariffs.min_months IS 'Minimum-season slab: months always charged for monthly/prorata billing';
+COMMENT ON COLUMN rent_tariffs.season_end_date IS 'Season end date - storage past this date is charged extra_month_rate per month';
+COMMENT ON COLUMN rent_tariffs.extra_month_rate IS 'Extra rent per item for each month (or part) past season_end_date';
+
+INSERT INTO system_settings (setting_key, setting_value, description)
+VALUES ('rent_charge_on_pickup', 'true', 'Post a duration-based rent CHARGE to the ledger for every gate pass pickup')
+ON CONFLICT (setting_key) DO NOTHING;
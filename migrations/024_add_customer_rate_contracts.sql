@@ -0,0 +1,46 @@
+-- Migration: 024_add_customer_rate_contracts.sql
+-- Purpose: Customer-specific negotiated rent rates. An active contract overrides the
+-- rate card for the customer's (or one family member's) stock.
+
+CREATE TABLE IF NOT EXISTS customer_rate_contracts (
+    id SERIAL PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+
+    -- Who the contract is for (family_member_id NULL = all of the customer's stock)
+    customer_id INTEGER NOT NULL REFERENCES customers(id),
+    family_member_id INTEGER REFERENCES family_members(id),
+
+    -- Optional scope (NULL/empty = applies to all)
+    thock_category VARCHAR(10),
+    variety VARCHAR(100),
+    bag_size VARCHAR(20),
+
+    -- Rate overrides (billing mode, minimum slab and season end still come from the rate card)
+    rate_per_item DECIMAL(10,2) NOT NULL CHECK (rate_per_item >= 0),
+    extra_month_rate DECIMAL(10,2) CHECK (extra_month_rate IS NULL OR extra_month_rate >= 0),
+
+    -- Validity (matched against the date stock entered storage)
+    valid_from DATE NOT NULL DEFAULT CURRENT_DATE,
+    valid_to DATE,
+
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',   -- pending, active, rejected, revoked
+    status_reason TEXT,
+    notes TEXT,
+
+    created_by_user_id INTEGER REFERENCES users(id),
+    approved_by_user_id INTEGER REFERENCES users(id),
+    approved_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_rate_contract_status CHECK (status IN ('pending', 'active', 'rejected', 'revoked')),
+    CONSTRAINT chk_rate_contract_category CHECK (thock_category IS NULL OR thock_category IN ('seed', 'sell')),
+    CONSTRAINT chk_rate_contract_dates CHECK (valid_to IS NULL OR valid_to >= valid_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_rate_contracts_customer ON customer_rate_contracts(customer_id);
+CREATE INDEX IF NOT EXISTS idx_rate_contracts_status ON customer_rate_contracts(status);
+
+COMMENT ON TABLE customer_rate_contracts IS 'Negotiated per-customer rent rates that override rent_tariffs once approved';
+COMMENT ON COLUMN customer_rate_contracts.status IS 'pending=awaiting admin, active=applied to rent, rejected=denied, revoked=no longer applied';
+COMMENT ON COLUMN customer_rate_contracts.extra_month_rate IS 'Overrides the rate card extra month rate when set';
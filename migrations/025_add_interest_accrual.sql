@@ -0,0 +1,60 @@
+-- Migration: 025_add_interest_accrual.sql
+-- Purpose: Monthly interest on overdue customer balances (INTEREST ledger entries),
+-- per-customer waivers and a record of each monthly run.
+
+-- Allow INTEREST ledger entries
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM pg_constraint
+        WHERE conname = 'chk_entry_type'
+        AND conrelid = 'ledger_entries'::regclass
+    ) THEN
+        ALTER TABLE ledger_entries DROP CONSTRAINT chk_entry_type;
+    END IF;
+END $$;
+
+ALTER TABLE ledger_entries ADD CONSTRAINT chk_entry_type
+    CHECK (entry_type IN ('CHARGE', 'PAYMENT', 'CREDIT', 'REFUND', 'DEBT_APPROVAL', 'ONLINE_PAYMENT', 'INTEREST'));
+
+-- One row per posted month
+CREATE TABLE IF NOT EXISTS interest_runs (
+    id SERIAL PRIMARY KEY,
+    period VARCHAR(7) NOT NULL UNIQUE,            -- YYYY-MM
+    period_end DATE NOT NULL,
+    method VARCHAR(10) NOT NULL,                  -- simple, compound
+    monthly_rate_percent DECIMAL(6,3) NOT NULL,
+    grace_days INTEGER NOT NULL,
+    customer_count INTEGER NOT NULL DEFAULT 0,
+    total_interest DECIMAL(12,2) NOT NULL DEFAULT 0,
+    posted_by_user_id INTEGER REFERENCES users(id),  -- NULL = posted by scheduler
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Customers exempt from interest (matched on ledger customer_phone)
+CREATE TABLE IF NOT EXISTS interest_waivers (
+    id SERIAL PRIMARY KEY,
+    customer_phone VARCHAR(15) NOT NULL,
+    reason TEXT NOT NULL,
+    valid_from DATE NOT NULL DEFAULT CURRENT_DATE,
+    valid_to DATE,
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by_user_id INTEGER REFERENCES users(id),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_interest_waiver_dates CHECK (valid_to IS NULL OR valid_to >= valid_from)
+);
+
+CREATE INDEX IF NOT EXISTS idx_interest_waivers_phone ON interest_waivers(customer_phone, is_active);
+
+COMMENT ON TABLE interest_runs IS 'Monthly interest postings - one per period so a month is never charged twice';
+COMMENT ON TABLE interest_waivers IS 'Customers whose overdue balance does not accrue interest';
+
+INSERT INTO system_settings (setting_key, setting_value, description) VALUES
+    ('interest_enabled', 'false', 'Accrue monthly interest on overdue customer balances'),
+    ('interest_method', 'simple', 'Interest method: simple (on rent only) or compound (on rent and unpaid interest)'),
+    ('interest_rate_monthly', '0', 'Interest rate in percent per month on overdue balances'),
+    ('interest_grace_days', '30', 'Days after a charge before it becomes overdue and accrues interest'),
+    ('interest_min_balance', '0', 'Overdue balances below this amount do not accrue interest'),
+    ('interest_auto_post', 'false', 'Post interest automatically at the start of each month (otherwise post after preview)')
+ON CONFLICT (setting_key) DO NOTHING;
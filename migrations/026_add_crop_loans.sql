@@ -0,0 +1,88 @@
+-- Migration: 026_add_crop_loans.sql
+-- Purpose: Crop loans / cash advances against stored stock.
+-- Disbursal is a LOAN_DISBURSAL ledger debit, repayments are LOAN_REPAYMENT credits and
+-- accrued interest is an INTEREST debit - all with reference_type 'crop_loan'.
+
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM pg_constraint
+        WHERE conname = 'chk_entry_type'
+        AND conrelid = 'ledger_entries'::regclass
+    ) THEN
+        ALTER TABLE ledger_entries DROP CONSTRAINT chk_entry_type;
+    END IF;
+END $$;
+
+ALTER TABLE ledger_entries ADD CONSTRAINT chk_entry_type
+    CHECK (entry_type IN ('CHARGE', 'PAYMENT', 'CREDIT', 'REFUND', 'DEBT_APPROVAL', 'ONLINE_PAYMENT', 'INTEREST',
+                          'LOAN_DISBURSAL', 'LOAN_REPAYMENT'));
+
+CREATE TABLE IF NOT EXISTS crop_loans (
+    id SERIAL PRIMARY KEY,
+
+    -- Borrower
+    customer_id INTEGER NOT NULL REFERENCES customers(id),
+    customer_phone VARCHAR(15) NOT NULL,
+    customer_name VARCHAR(100) NOT NULL,
+    customer_so VARCHAR(100),
+    family_member_id INTEGER REFERENCES family_members(id),
+    family_member_name VARCHAR(100),
+
+    -- Security (pledged stock)
+    entry_id INTEGER NOT NULL REFERENCES entries(id),
+    thock_number VARCHAR(50) NOT NULL,
+    pledged_quantity INTEGER NOT NULL CHECK (pledged_quantity > 0),
+    value_per_bag DECIMAL(10,2) NOT NULL,        -- Snapshot of loan_value_per_bag at disbursal
+    max_ltv_percent DECIMAL(5,2) NOT NULL,       -- Snapshot of loan_max_ltv_percent at disbursal
+
+    -- Amounts
+    principal DECIMAL(12,2) NOT NULL CHECK (principal > 0),
+    interest_rate_monthly DECIMAL(6,3) NOT NULL DEFAULT 0,
+    principal_outstanding DECIMAL(12,2) NOT NULL,
+    interest_outstanding DECIMAL(12,2) NOT NULL DEFAULT 0,
+    total_interest DECIMAL(12,2) NOT NULL DEFAULT 0,
+    total_repaid DECIMAL(12,2) NOT NULL DEFAULT 0,
+    interest_accrued_to DATE NOT NULL,
+
+    status VARCHAR(20) NOT NULL DEFAULT 'active',  -- active, settled
+    ledger_entry_id INTEGER,                       -- LOAN_DISBURSAL ledger entry
+    notes TEXT,
+
+    disbursed_by_user_id INTEGER NOT NULL,
+    disbursed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    settled_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_crop_loan_status CHECK (status IN ('active', 'settled')),
+    CONSTRAINT chk_crop_loan_outstanding CHECK (principal_outstanding >= 0 AND interest_outstanding >= 0)
+);
+
+CREATE INDEX IF NOT EXISTS idx_crop_loans_thock ON crop_loans(thock_number, status);
+CREATE INDEX IF NOT EXISTS idx_crop_loans_customer ON crop_loans(customer_phone);
+CREATE INDEX IF NOT EXISTS idx_crop_loans_status ON crop_loans(status);
+
+CREATE TABLE IF NOT EXISTS crop_loan_repayments (
+    id SERIAL PRIMARY KEY,
+    loan_id INTEGER NOT NULL REFERENCES crop_loans(id),
+    amount DECIMAL(12,2) NOT NULL CHECK (amount > 0),
+    interest_portion DECIMAL(12,2) NOT NULL DEFAULT 0,
+    principal_portion DECIMAL(12,2) NOT NULL DEFAULT 0,
+    ledger_entry_id INTEGER,
+    received_by_user_id INTEGER NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_crop_loan_repayments_loan ON crop_loan_repayments(loan_id);
+
+COMMENT ON TABLE crop_loans IS 'Cash advances against stored stock - the pledged thock cannot leave until settled or debt-approved';
+COMMENT ON COLUMN crop_loans.interest_accrued_to IS 'Interest has been posted to the ledger up to this date';
+COMMENT ON TABLE crop_loan_repayments IS 'Loan repayments - allocated to outstanding interest first, then principal';
+
+INSERT INTO system_settings (setting_key, setting_value, description) VALUES
+    ('loan_value_per_bag', '0', 'Market value per stored bag used for crop loan loan-to-value checks'),
+    ('loan_max_ltv_percent', '60', 'Maximum crop loan as a percent of the pledged stock value'),
+    ('loan_interest_rate_monthly', '1.5', 'Default crop loan interest rate in percent per month')
+ON CONFLICT (setting_key) DO NOTHING;
@@ -0,0 +1,41 @@
+-- Migration: 027_add_thock_liens.sql
+-- Purpose: Bank pledge / lien marking on thocks (e.g. for KCC loans).
+-- Pledged bags cannot be withdrawn until the lien is released. Each lien gets a
+-- warehouse receipt number and a verification code the bank can check.
+
+CREATE TABLE IF NOT EXISTS thock_liens (
+    id SERIAL PRIMARY KEY,
+    receipt_number VARCHAR(30) NOT NULL UNIQUE,   -- WR-YYYY-NNNNNN, printed on the receipt
+    verification_code VARCHAR(20) NOT NULL UNIQUE, -- Random code the bank uses to verify the receipt
+
+    entry_id INTEGER NOT NULL REFERENCES entries(id),
+    thock_number VARCHAR(50) NOT NULL,
+    customer_id INTEGER NOT NULL REFERENCES customers(id),
+
+    -- Bank details
+    bank_name VARCHAR(100) NOT NULL,
+    bank_branch VARCHAR(100),
+    loan_account_number VARCHAR(50),
+
+    pledged_quantity INTEGER NOT NULL CHECK (pledged_quantity > 0),
+    lien_amount DECIMAL(12,2) NOT NULL DEFAULT 0 CHECK (lien_amount >= 0),
+
+    status VARCHAR(20) NOT NULL DEFAULT 'active',  -- active, released
+    notes TEXT,
+    marked_by_user_id INTEGER NOT NULL,
+    marked_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    released_by_user_id INTEGER,
+    released_at TIMESTAMP,
+    release_reference VARCHAR(100),                -- Bank NOC / release letter number
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_thock_lien_status CHECK (status IN ('active', 'released'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_thock_liens_thock ON thock_liens(thock_number, status);
+CREATE INDEX IF NOT EXISTS idx_thock_liens_entry ON thock_liens(entry_id);
+CREATE INDEX IF NOT EXISTS idx_thock_liens_customer ON thock_liens(customer_id);
+
+COMMENT ON TABLE thock_liens IS 'Bank liens on stored stock - pledged bags cannot be withdrawn until released';
+COMMENT ON COLUMN thock_liens.verification_code IS 'Checked by banks through the public /api/verify-receipt endpoint';
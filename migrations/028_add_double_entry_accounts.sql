@@ -0,0 +1,64 @@
+-- Migration: 028_add_double_entry_accounts.sql
+-- Purpose: Double-entry chart of accounts and journal behind the customer ledger.
+-- Every money-moving ledger_entries row is posted as a balanced journal entry
+-- (e.g. rent CHARGE = Dr Customer Receivables / Cr Rent Income), giving a cash book,
+-- bank book, trial balance, P&L and balance sheet.
+
+CREATE TABLE IF NOT EXISTS accounts (
+    id SERIAL PRIMARY KEY,
+    code VARCHAR(20) NOT NULL UNIQUE,
+    name VARCHAR(100) NOT NULL,
+    account_type VARCHAR(20) NOT NULL,            -- asset, liability, equity, income, expense
+    is_system BOOLEAN NOT NULL DEFAULT FALSE,     -- Used by automatic postings, cannot be deactivated
+    is_active BOOLEAN NOT NULL DEFAULT TRUE,
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_account_type CHECK (account_type IN ('asset', 'liability', 'equity', 'income', 'expense'))
+);
+
+CREATE TABLE IF NOT EXISTS journal_entries (
+    id SERIAL PRIMARY KEY,
+    entry_date TIMESTAMP NOT NULL,
+    description TEXT NOT NULL,
+    source_type VARCHAR(20) NOT NULL,             -- ledger_entry, manual
+    source_id INTEGER,                            -- ledger_entries.id for automatic postings
+    customer_phone VARCHAR(15),
+    created_by_user_id INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- A ledger entry is posted to the journal at most once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_journal_entries_source
+    ON journal_entries(source_type, source_id) WHERE source_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_journal_entries_date ON journal_entries(entry_date);
+
+CREATE TABLE IF NOT EXISTS journal_lines (
+    id SERIAL PRIMARY KEY,
+    journal_entry_id INTEGER NOT NULL REFERENCES journal_entries(id) ON DELETE CASCADE,
+    account_id INTEGER NOT NULL REFERENCES accounts(id),
+    debit DECIMAL(12,2) NOT NULL DEFAULT 0,
+    credit DECIMAL(12,2) NOT NULL DEFAULT 0,
+    description TEXT,
+
+    CONSTRAINT chk_journal_line_amount CHECK (debit >= 0 AND credit >= 0 AND (debit = 0 OR credit = 0))
+);
+
+CREATE INDEX IF NOT EXISTS idx_journal_lines_entry ON journal_lines(journal_entry_id);
+CREATE INDEX IF NOT EXISTS idx_journal_lines_account ON journal_lines(account_id);
+
+INSERT INTO accounts (code, name, account_type, is_system, description) VALUES
+    ('1000', 'Cash', 'asset', TRUE, 'Cash in hand - counter payments, refunds and loan disbursals'),
+    ('1010', 'Bank', 'asset', TRUE, 'Bank account'),
+    ('1020', 'Razorpay Clearing', 'asset', TRUE, 'Online payments received but not yet settled to the bank'),
+    ('1100', 'Customer Receivables', 'asset', TRUE, 'Control account for the customer ledger'),
+    ('3000', 'Owner Capital', 'equity', TRUE, 'Owner capital and opening balances'),
+    ('4000', 'Rent Income', 'income', TRUE, 'Storage rent charged to customers'),
+    ('4010', 'Interest Income', 'income', TRUE, 'Interest on overdue balances and crop loans'),
+    ('5000', 'Discounts and Write-offs', 'expense', TRUE, 'Credits and discounts given to customers'),
+    ('5010', 'Payment Gateway Charges', 'expense', TRUE, 'Razorpay fees')
+ON CONFLICT (code) DO NOTHING;
+
+COMMENT ON TABLE accounts IS 'Chart of accounts for the double-entry journal';
+COMMENT ON TABLE journal_entries IS 'Double-entry journal - automatic postings from ledger_entries plus manual journals';
+COMMENT ON TABLE journal_lines IS 'Journal lines - debits equal credits within each journal entry';
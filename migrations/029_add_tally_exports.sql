@@ -0,0 +1,25 @@
+-- Migration: 029_add_tally_exports.sql
+-- Purpose: Track Tally Prime XML exports so the CA's books are not re-typed by hand.
+-- Each export batch records its date range; exported ledger_entries rows point at
+-- their batch so later exports skip them and never duplicate vouchers in Tally.
+
+CREATE TABLE IF NOT EXISTS tally_exports (
+    id SERIAL PRIMARY KEY,
+    from_date DATE NOT NULL,
+    to_date DATE NOT NULL,
+    voucher_count INTEGER NOT NULL DEFAULT 0,
+    ledger_count INTEGER NOT NULL DEFAULT 0,      -- Customer ledger masters included
+    created_by_user_id INTEGER NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS tally_export_id INTEGER REFERENCES tally_exports(id);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_tally_export ON ledger_entries(tally_export_id);
+
+COMMENT ON TABLE tally_exports IS 'Batches of ledger entries exported to Tally Prime as XML vouchers';
+COMMENT ON COLUMN ledger_entries.tally_export_id IS 'Tally export batch this entry was sent in (NULL = not yet exported)';
+
+INSERT INTO system_settings (setting_key, setting_value, description) VALUES
+    ('tally_company_name', '', 'Tally company to import into (blank = company currently open in Tally)')
+ON CONFLICT (setting_key) DO NOTHING;
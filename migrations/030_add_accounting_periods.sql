@@ -0,0 +1,57 @@
+-- Migration: 030_add_accounting_periods.sql
+-- Purpose: Monthly accounting periods on the April-March fiscal year with period close.
+-- Closing a month snapshots every customer's balance and locks the ledger up to the
+-- month end; closed periods always form a prefix, so only the latest can be reopened.
+-- Reopening needs a second admin's approval (like pending_setting_changes).
+
+CREATE TABLE IF NOT EXISTS accounting_periods (
+    id SERIAL PRIMARY KEY,
+    fiscal_year VARCHAR(7) NOT NULL,              -- e.g. 2025-26
+    period_number INTEGER NOT NULL,               -- 1 = April ... 12 = March
+    start_date DATE NOT NULL UNIQUE,
+    end_date DATE NOT NULL,
+    status VARCHAR(10) NOT NULL DEFAULT 'closed', -- closed, open (reopened)
+    closed_at TIMESTAMP,
+    closed_by_user_id INTEGER,
+    reopened_at TIMESTAMP,
+    reopened_by_user_id INTEGER,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_period_status CHECK (status IN ('closed', 'open')),
+    CONSTRAINT chk_period_number CHECK (period_number BETWEEN 1 AND 12)
+);
+
+CREATE INDEX IF NOT EXISTS idx_accounting_periods_status ON accounting_periods(status, end_date);
+
+CREATE TABLE IF NOT EXISTS accounting_period_balances (
+    id SERIAL PRIMARY KEY,
+    period_id INTEGER NOT NULL REFERENCES accounting_periods(id) ON DELETE CASCADE,
+    customer_phone VARCHAR(15) NOT NULL,
+    customer_name VARCHAR(100) NOT NULL,
+    total_debit DECIMAL(12,2) NOT NULL DEFAULT 0,   -- Cumulative up to period end
+    total_credit DECIMAL(12,2) NOT NULL DEFAULT 0,
+    closing_balance DECIMAL(12,2) NOT NULL DEFAULT 0,
+
+    UNIQUE (period_id, customer_phone)
+);
+
+CREATE TABLE IF NOT EXISTS accounting_period_reopen_requests (
+    id SERIAL PRIMARY KEY,
+    period_id INTEGER NOT NULL REFERENCES accounting_periods(id),
+    reason TEXT NOT NULL,
+    requested_by INTEGER NOT NULL,
+    requested_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    status VARCHAR(10) NOT NULL DEFAULT 'pending', -- pending, approved, rejected, expired
+    reviewed_by INTEGER,
+    reviewed_at TIMESTAMP,
+    rejection_reason TEXT,
+    expires_at TIMESTAMP DEFAULT (CURRENT_TIMESTAMP + INTERVAL '24 hours'),
+
+    CONSTRAINT chk_reopen_status CHECK (status IN ('pending', 'approved', 'rejected', 'expired'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_period_reopen_pending ON accounting_period_reopen_requests(period_id) WHERE status = 'pending';
+
+COMMENT ON TABLE accounting_periods IS 'Closed (or reopened) monthly accounting periods; ledger postings on or before the latest closed end_date are rejected';
+COMMENT ON TABLE accounting_period_balances IS 'Per-customer balance snapshot taken when a period is closed';
+COMMENT ON TABLE accounting_period_reopen_requests IS 'Reopen requests for a closed period, approved by a second admin';
@@ -0,0 +1,32 @@
+-- Migration: 031_add_ledger_reversals.sql
+-- Purpose: Void ledger entries by posting an equal and opposite REVERSAL entry
+-- instead of editing or deleting rows, so the running balance chain stays intact.
+-- The original row is marked voided and both rows point at each other.
+
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM pg_constraint
+        WHERE conname = 'chk_entry_type'
+        AND conrelid = 'ledger_entries'::regclass
+    ) THEN
+        ALTER TABLE ledger_entries DROP CONSTRAINT chk_entry_type;
+    END IF;
+END $$;
+
+ALTER TABLE ledger_entries ADD CONSTRAINT chk_entry_type
+    CHECK (entry_type IN ('CHARGE', 'PAYMENT', 'CREDIT', 'REFUND', 'DEBT_APPROVAL', 'ONLINE_PAYMENT', 'INTEREST',
+                          'LOAN_DISBURSAL', 'LOAN_REPAYMENT', 'REVERSAL'));
+
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS reversal_of_id INTEGER REFERENCES ledger_entries(id);
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS reversed_by_id INTEGER REFERENCES ledger_entries(id);
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS voided_at TIMESTAMP;
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS voided_by_user_id INTEGER;
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS void_reason TEXT;
+
+-- An entry can only be reversed once
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_entries_reversal_of ON ledger_entries(reversal_of_id) WHERE reversal_of_id IS NOT NULL;
+
+COMMENT ON COLUMN ledger_entries.reversal_of_id IS 'For REVERSAL entries: the voided entry this reverses';
+COMMENT ON COLUMN ledger_entries.reversed_by_id IS 'For voided entries: the REVERSAL entry that cancels it';
+COMMENT ON COLUMN ledger_entries.void_reason IS 'Why the entry was voided (required)';
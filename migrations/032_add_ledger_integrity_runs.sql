@@ -0,0 +1,27 @@
+-- Migration: 032_add_ledger_integrity_runs.sql
+-- Purpose: Record ledger integrity checks - running balances recomputed from scratch
+-- and ledger payment totals cross-checked against rent_payments and online_transactions.
+
+CREATE TABLE IF NOT EXISTS ledger_integrity_runs (
+    id SERIAL PRIMARY KEY,
+    trigger VARCHAR(10) NOT NULL DEFAULT 'manual',      -- manual, scheduled
+    customers_checked INTEGER NOT NULL DEFAULT 0,
+    entries_checked INTEGER NOT NULL DEFAULT 0,
+    discrepancy_count INTEGER NOT NULL DEFAULT 0,
+    repaired_entries INTEGER NOT NULL DEFAULT 0,
+    discrepancies JSONB NOT NULL DEFAULT '[]',
+    run_by_user_id INTEGER REFERENCES users(id),        -- NULL = run by scheduler
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_ledger_integrity_trigger CHECK (trigger IN ('manual', 'scheduled'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_ledger_integrity_runs_created ON ledger_integrity_runs(created_at DESC);
+
+COMMENT ON TABLE ledger_integrity_runs IS 'Ledger integrity check results with the discrepancy report of each run';
+COMMENT ON COLUMN ledger_integrity_runs.repaired_entries IS 'Ledger rows whose running_balance was rewritten by auto-repair';
+
+INSERT INTO system_settings (setting_key, setting_value, description) VALUES
+    ('ledger_integrity_nightly', 'true', 'Run the ledger integrity check every night and raise an alert on discrepancies'),
+    ('ledger_integrity_auto_repair', 'false', 'Let the nightly ledger integrity check rewrite wrong running balances')
+ON CONFLICT (setting_key) DO NOTHING;
@@ -0,0 +1,32 @@
+-- Migration: 033_add_cash_sessions.sql
+-- Purpose: Cash drawer sessions per accountant. A session opens with a float, rent
+-- payments recorded by that user are tied to their open session, and closing records
+-- the denomination count with the difference between expected and counted cash.
+
+CREATE TABLE IF NOT EXISTS cash_sessions (
+    id SERIAL PRIMARY KEY,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    status VARCHAR(10) NOT NULL DEFAULT 'open',          -- open, closed
+    opening_float DECIMAL(12,2) NOT NULL DEFAULT 0,
+    opened_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    closed_at TIMESTAMP,
+    cash_received DECIMAL(12,2),                         -- Payments recorded in the session, set at close
+    expected_cash DECIMAL(12,2),                         -- opening_float + cash_received
+    counted_cash DECIMAL(12,2),
+    difference DECIMAL(12,2),                            -- counted - expected (negative = short)
+    denominations JSONB,                                 -- [{"value": 500, "count": 12}, ...]
+    closing_notes TEXT,
+
+    CONSTRAINT chk_cash_session_status CHECK (status IN ('open', 'closed')),
+    CONSTRAINT chk_cash_session_float CHECK (opening_float >= 0)
+);
+
+-- One open drawer per user
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cash_sessions_open_user ON cash_sessions(user_id) WHERE status = 'open';
+CREATE INDEX IF NOT EXISTS idx_cash_sessions_closed_at ON cash_sessions(closed_at);
+
+ALTER TABLE rent_payments ADD COLUMN IF NOT EXISTS cash_session_id INTEGER REFERENCES cash_sessions(id);
+CREATE INDEX IF NOT EXISTS idx_rent_payments_cash_session ON rent_payments(cash_session_id) WHERE cash_session_id IS NOT NULL;
+
+COMMENT ON TABLE cash_sessions IS 'Cash drawer sessions - opening float, payments taken and the end-of-day denomination count';
+COMMENT ON COLUMN rent_payments.cash_session_id IS 'Open cash session of the user who recorded the payment';
@@ -0,0 +1,42 @@
+-- Migration: 034_add_payment_modes.sql
+-- Purpose: Record how a counter payment was made (cash, cheque, NEFT/RTGS, UPI to bank)
+-- with mode-specific details. Cheques wait for clearance before crediting the ledger;
+-- bounced cheques are reversed and charged a penalty.
+
+ALTER TABLE rent_payments ADD COLUMN IF NOT EXISTS payment_mode VARCHAR(10) NOT NULL DEFAULT 'cash';
+ALTER TABLE rent_payments ADD COLUMN IF NOT EXISTS cheque_number VARCHAR(20);
+ALTER TABLE rent_payments ADD COLUMN IF NOT EXISTS bank_name VARCHAR(100);
+ALTER TABLE rent_payments ADD COLUMN IF NOT EXISTS cheque_date DATE;
+ALTER TABLE rent_payments ADD COLUMN IF NOT EXISTS utr_number VARCHAR(100);          -- NEFT/RTGS UTR or UPI reference
+ALTER TABLE rent_payments ADD COLUMN IF NOT EXISTS clearance_status VARCHAR(10);      -- Cheques only: pending, cleared, bounced
+ALTER TABLE rent_payments ADD COLUMN IF NOT EXISTS clearing_date DATE;
+ALTER TABLE rent_payments ADD COLUMN IF NOT EXISTS cleared_at TIMESTAMP;
+ALTER TABLE rent_payments ADD COLUMN IF NOT EXISTS cleared_by_user_id INTEGER REFERENCES users(id);
+ALTER TABLE rent_payments ADD COLUMN IF NOT EXISTS bounced_at TIMESTAMP;
+ALTER TABLE rent_payments ADD COLUMN IF NOT EXISTS bounce_reason TEXT;
+ALTER TABLE rent_payments ADD COLUMN IF NOT EXISTS bounce_penalty DECIMAL(10,2);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'chk_rent_payment_mode') THEN
+        ALTER TABLE rent_payments ADD CONSTRAINT chk_rent_payment_mode
+            CHECK (payment_mode IN ('cash', 'cheque', 'neft', 'rtgs', 'upi'));
+    END IF;
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'chk_rent_payment_clearance') THEN
+        ALTER TABLE rent_payments ADD CONSTRAINT chk_rent_payment_clearance
+            CHECK (clearance_status IS NULL OR clearance_status IN ('pending', 'cleared', 'bounced'));
+    END IF;
+END $$;
+
+CREATE INDEX IF NOT EXISTS idx_rent_payments_clearance ON rent_payments(clearance_status) WHERE clearance_status IS NOT NULL;
+
+-- Ledger payments carry the mode so non-cash receipts post to Bank instead of Cash
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS payment_mode VARCHAR(10);
+
+COMMENT ON COLUMN rent_payments.payment_mode IS 'cash, cheque, neft, rtgs or upi (direct to bank, not Razorpay)';
+COMMENT ON COLUMN rent_payments.clearance_status IS 'Cheques only - the ledger is credited when the cheque clears';
+COMMENT ON COLUMN ledger_entries.payment_mode IS 'For PAYMENT entries: how the money was received';
+
+INSERT INTO system_settings (setting_key, setting_value, description) VALUES
+    ('cheque_bounce_penalty', '500', 'Penalty charged to the customer when a cheque bounces')
+ON CONFLICT (setting_key) DO NOTHING;
@@ -0,0 +1,52 @@
+-- Migration: 035_add_bank_statements.sql
+-- Purpose: Bank statement import. Credits from an uploaded CSV/XLSX export are matched
+-- by UTR, amount and phone number against online transactions, bank-transfer payments
+-- and pending cheques; the rest wait in a reconciliation queue for the accountant to
+-- assign to a customer, which posts a PAYMENT ledger entry.
+
+CREATE TABLE IF NOT EXISTS bank_statement_imports (
+    id SERIAL PRIMARY KEY,
+    file_name VARCHAR(255) NOT NULL,
+    credit_count INTEGER NOT NULL DEFAULT 0,             -- Credits found in the file
+    duplicate_count INTEGER NOT NULL DEFAULT 0,          -- Credits already imported from an earlier file
+    matched_count INTEGER NOT NULL DEFAULT 0,
+    unmatched_count INTEGER NOT NULL DEFAULT 0,
+    imported_by_user_id INTEGER REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS bank_statement_lines (
+    id SERIAL PRIMARY KEY,
+    import_id INTEGER NOT NULL REFERENCES bank_statement_imports(id),
+    txn_date DATE NOT NULL,
+    narration TEXT NOT NULL DEFAULT '',
+    reference VARCHAR(100),                              -- Cheque/ref/UTR column of the statement
+    amount DECIMAL(12,2) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,                    -- SHA-256 of the statement row, stops re-imports
+    status VARCHAR(10) NOT NULL DEFAULT 'unmatched',     -- matched, unmatched, assigned, ignored
+    match_type VARCHAR(20),                              -- online_transaction, rent_payment, cheque, manual
+    online_transaction_id INTEGER REFERENCES online_transactions(id),
+    rent_payment_id INTEGER REFERENCES rent_payments(id),
+    ledger_entry_id INTEGER REFERENCES ledger_entries(id),
+    customer_phone VARCHAR(15),                          -- Matched or assigned customer
+    customer_name VARCHAR(100),
+    suggested_phone VARCHAR(15),                         -- Customer whose phone appears in the narration
+    suggested_name VARCHAR(100),
+    resolved_by_user_id INTEGER REFERENCES users(id),
+    resolved_at TIMESTAMP,
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_bank_line_status CHECK (status IN ('matched', 'unmatched', 'assigned', 'ignored')),
+    CONSTRAINT chk_bank_line_amount CHECK (amount > 0)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bank_lines_fingerprint ON bank_statement_lines(fingerprint);
+CREATE INDEX IF NOT EXISTS idx_bank_lines_import ON bank_statement_lines(import_id);
+CREATE INDEX IF NOT EXISTS idx_bank_lines_status ON bank_statement_lines(status);
+CREATE INDEX IF NOT EXISTS idx_bank_lines_online_tx ON bank_statement_lines(online_transaction_id) WHERE online_transaction_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_bank_lines_rent_payment ON bank_statement_lines(rent_payment_id) WHERE rent_payment_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_rent_payments_utr ON rent_payments(utr_number) WHERE utr_number IS NOT NULL;
+
+COMMENT ON TABLE bank_statement_imports IS 'Uploaded bank statement files with match counts';
+COMMENT ON TABLE bank_statement_lines IS 'Bank credits from imported statements and the record each was reconciled to';
@@ -0,0 +1,36 @@
+-- Migration: 036_add_payment_allocations.sql
+-- Purpose: Allocate customer payments to the charges they settle, so the outstanding
+-- balance of each thock is exact. Payments are allocated FIFO by default (a family
+-- member's payment settles that member's charges first) or manually by the accountant.
+
+-- Thock a charge belongs to (rent charges per gate pass pickup)
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS thock_number VARCHAR(50);
+
+UPDATE ledger_entries le
+SET thock_number = gp.thock_number
+FROM gate_pass_pickups p
+JOIN gate_passes gp ON gp.id = p.gate_pass_id
+WHERE le.reference_type = 'gate_pass_pickup'
+  AND le.reference_id = p.id
+  AND le.thock_number IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_ledger_thock ON ledger_entries(customer_phone, thock_number) WHERE thock_number IS NOT NULL;
+
+CREATE TABLE IF NOT EXISTS payment_allocations (
+    id SERIAL PRIMARY KEY,
+    payment_entry_id INTEGER NOT NULL REFERENCES ledger_entries(id),  -- PAYMENT, ONLINE_PAYMENT or CREDIT
+    charge_entry_id INTEGER NOT NULL REFERENCES ledger_entries(id),   -- CHARGE, INTEREST or REFUND
+    amount DECIMAL(12,2) NOT NULL,
+    method VARCHAR(10) NOT NULL DEFAULT 'fifo',                       -- fifo, manual
+    allocated_by_user_id INTEGER REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_allocation_amount CHECK (amount > 0),
+    CONSTRAINT chk_allocation_method CHECK (method IN ('fifo', 'manual')),
+    CONSTRAINT uq_allocation_pair UNIQUE (payment_entry_id, charge_entry_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_allocations_charge ON payment_allocations(charge_entry_id);
+
+COMMENT ON TABLE payment_allocations IS 'Portion of a payment ledger entry applied to a charge ledger entry';
+COMMENT ON COLUMN ledger_entries.thock_number IS 'Thock a charge belongs to, for per-thock outstanding';
@@ -0,0 +1,8 @@
+-- Migration: 037_add_customer_wallet.sql
+-- Purpose: Customer advance deposit wallet. A deposit is a PAYMENT ledger entry with
+-- reference_type 'wallet_deposit' and is allocated to charges like any other payment,
+-- after the customer's other payments. A season-end refund of the unused balance is a
+-- REFUND entry with reference_type 'wallet_refund' that only deposits can settle.
+
+CREATE INDEX IF NOT EXISTS idx_ledger_wallet ON ledger_entries(customer_phone, reference_type)
+    WHERE reference_type IN ('wallet_deposit', 'wallet_refund');
@@ -0,0 +1,39 @@
+-- Migration: 038_add_online_refunds.sql
+-- Purpose: Refund online (Razorpay) payments through the Razorpay refunds API instead of
+-- paying cash back and entering the REFUND by hand. Refunds may be partial; large ones
+-- wait for admin approval. The REFUND ledger entry is posted when Razorpay confirms.
+
+CREATE TABLE IF NOT EXISTS online_refunds (
+    id SERIAL PRIMARY KEY,
+    online_transaction_id INTEGER NOT NULL REFERENCES online_transactions(id),
+    amount DECIMAL(12,2) NOT NULL,
+    reason TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending_approval', -- pending_approval, processing, processed, failed, rejected
+    razorpay_refund_id VARCHAR(50) UNIQUE,
+    failure_reason TEXT,
+    ledger_entry_id INTEGER REFERENCES ledger_entries(id),
+
+    requested_by_user_id INTEGER NOT NULL REFERENCES users(id),
+    approved_by_user_id INTEGER REFERENCES users(id),
+    approved_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    processed_at TIMESTAMP,
+
+    CONSTRAINT chk_online_refund_amount CHECK (amount > 0),
+    CONSTRAINT chk_online_refund_status CHECK (status IN ('pending_approval', 'processing', 'processed', 'failed', 'rejected'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_online_refunds_tx ON online_refunds(online_transaction_id);
+CREATE INDEX IF NOT EXISTS idx_online_refunds_status ON online_refunds(status);
+
+-- Refund state on the transaction (status becomes 'refunded' once fully refunded)
+ALTER TABLE online_transactions ADD COLUMN IF NOT EXISTS refunded_amount DECIMAL(12,2) NOT NULL DEFAULT 0;
+ALTER TABLE online_transactions ADD COLUMN IF NOT EXISTS refund_status VARCHAR(20); -- pending, partial, full
+
+COMMENT ON TABLE online_refunds IS 'Refunds of online payments issued through Razorpay';
+COMMENT ON COLUMN online_transactions.refunded_amount IS 'Sum of processed Razorpay refunds';
+
+INSERT INTO system_settings (setting_key, setting_value, description)
+VALUES
+    ('online_refund_approval_threshold', '5000', 'Online refunds above this amount (Rs.) need admin approval')
+ON CONFLICT (setting_key) DO NOTHING;
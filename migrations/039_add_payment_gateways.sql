@@ -0,0 +1,27 @@
+-- Migration: 039_add_payment_gateways.sql
+-- Purpose: Take online payments through a choice of gateway (Razorpay or Cashfree),
+-- selected by the payment_gateway setting. Each transaction remembers the gateway that
+-- took it, so verification, webhooks and refunds go back to the same gateway after the
+-- setting changes. The razorpay_* columns hold the IDs of whichever gateway was used.
+
+ALTER TABLE online_transactions ADD COLUMN IF NOT EXISTS gateway VARCHAR(20) NOT NULL DEFAULT 'razorpay';
+
+COMMENT ON COLUMN online_transactions.gateway IS 'Payment gateway that took the payment: razorpay or cashfree';
+COMMENT ON COLUMN online_transactions.razorpay_order_id IS 'Order ID at the transaction''s gateway';
+COMMENT ON COLUMN online_transactions.razorpay_payment_id IS 'Payment ID at the transaction''s gateway';
+COMMENT ON COLUMN online_refunds.razorpay_refund_id IS 'Refund ID at the transaction''s gateway';
+
+INSERT INTO system_settings (setting_key, setting_value, description)
+VALUES
+    ('payment_gateway', 'razorpay', 'Gateway for new online payments: razorpay or cashfree'),
+    ('cashfree_app_id', '', 'Cashfree App ID (x-client-id)'),
+    ('cashfree_secret_key', '', 'Cashfree Secret Key (encrypted) - also verifies webhooks'),
+    ('cashfree_environment', 'production', 'Cashfree environment: production or sandbox')
+ON CONFLICT (setting_key) DO NOTHING;
+
+INSERT INTO protected_settings (setting_key, description) VALUES
+    ('payment_gateway', 'Online payment gateway - requires dual admin approval'),
+    ('cashfree_app_id', 'Cashfree App ID - requires dual admin approval'),
+    ('cashfree_secret_key', 'Cashfree Secret Key - requires dual admin approval'),
+    ('cashfree_environment', 'Cashfree environment - requires dual admin approval')
+ON CONFLICT (setting_key) DO NOTHING;
@@ -0,0 +1,42 @@
+-- Migration: 040_add_payment_links.sql
+-- Purpose: Payment links sent with balance reminders. Each link is a short-lived signed
+-- URL into the customer portal checkout for a customer's outstanding amount. Orders paid
+-- through a link carry its ID, so the link is marked paid however the payment is
+-- confirmed (checkout callback, webhook or reconciliation).
+
+CREATE TABLE IF NOT EXISTS payment_links (
+    id SERIAL PRIMARY KEY,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    customer_id INTEGER NOT NULL REFERENCES customers(id),
+    customer_phone VARCHAR(15) NOT NULL,
+    customer_name VARCHAR(100) NOT NULL DEFAULT '',
+    amount DECIMAL(12,2) NOT NULL CHECK (amount > 0),
+    status VARCHAR(20) NOT NULL DEFAULT 'sent',
+    online_transaction_id INTEGER REFERENCES online_transactions(id),
+    created_by_user_id INTEGER REFERENCES users(id),
+    expires_at TIMESTAMP NOT NULL,
+    opened_at TIMESTAMP,
+    paid_at TIMESTAMP,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_links_phone ON payment_links(customer_phone, created_at DESC);
+
+COMMENT ON TABLE payment_links IS 'Signed portal checkout links sent to customers for their outstanding balance';
+COMMENT ON COLUMN payment_links.token IS 'Random URL token (/pay/{token}); the link itself is the credential';
+COMMENT ON COLUMN payment_links.status IS 'sent, opened, paid or cancelled (expiry is computed from expires_at)';
+COMMENT ON COLUMN payment_links.online_transaction_id IS 'Online transaction that paid the link';
+
+ALTER TABLE online_transactions ADD COLUMN IF NOT EXISTS payment_link_id INTEGER REFERENCES payment_links(id);
+
+COMMENT ON COLUMN online_transactions.payment_link_id IS 'Payment link the order was created from, if any';
+
+INSERT INTO system_settings (setting_key, setting_value, description)
+VALUES
+    ('payment_link_base_url', '', 'Customer portal URL used in payment links (blank disables payment links)'),
+    ('payment_link_expiry_hours', '72', 'Hours a payment link stays valid')
+ON CONFLICT (setting_key) DO NOTHING;
+
+INSERT INTO protected_settings (setting_key, description) VALUES
+    ('payment_link_base_url', 'Portal URL in payment links sent to customers - requires dual admin approval')
+ON CONFLICT (setting_key) DO NOTHING;
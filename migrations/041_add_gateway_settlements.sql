@@ -0,0 +1,55 @@
+-- Migration: 041_add_gateway_settlements.sql
+-- Purpose: Reconcile payment gateway settlements. Settlement reports (fetched from the
+-- gateway API or uploaded as CSV) are matched to online transactions, recording the fee
+-- and GST the gateway actually deducted next to the fee charged to the customer, and
+-- flagging settlements that came in short or that the app cannot match.
+
+CREATE TABLE IF NOT EXISTS gateway_settlement_imports (
+    id SERIAL PRIMARY KEY,
+    gateway VARCHAR(20) NOT NULL,
+    source VARCHAR(10) NOT NULL,                         -- api or csv
+    file_name VARCHAR(255) NOT NULL DEFAULT '',          -- Uploaded file, or the report date fetched
+    line_count INTEGER NOT NULL DEFAULT 0,
+    duplicate_count INTEGER NOT NULL DEFAULT 0,          -- Lines already imported earlier
+    matched_count INTEGER NOT NULL DEFAULT 0,
+    flagged_count INTEGER NOT NULL DEFAULT 0,            -- Short or unmatched
+    imported_by_user_id INTEGER REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS gateway_settlement_lines (
+    id SERIAL PRIMARY KEY,
+    import_id INTEGER NOT NULL REFERENCES gateway_settlement_imports(id),
+    gateway VARCHAR(20) NOT NULL,
+    line_type VARCHAR(20) NOT NULL,                      -- payment, refund, adjustment
+    entity_id VARCHAR(50) NOT NULL,                      -- Payment/refund/adjustment ID at the gateway
+    order_id VARCHAR(100),
+    payment_id VARCHAR(100),
+    settlement_id VARCHAR(50),
+    settlement_utr VARCHAR(50),                          -- Bank reference of the payout
+    amount DECIMAL(12,2) NOT NULL DEFAULT 0,             -- Gross amount
+    fee DECIMAL(12,2) NOT NULL DEFAULT 0,                -- Gateway fee deducted, including GST
+    tax DECIMAL(12,2) NOT NULL DEFAULT 0,                -- GST part of the fee
+    settled_amount DECIMAL(12,2) NOT NULL DEFAULT 0,     -- Credit less debit to the payout
+    settled BOOLEAN NOT NULL DEFAULT FALSE,
+    settled_at TIMESTAMP,
+    online_transaction_id INTEGER REFERENCES online_transactions(id),
+    expected_fee DECIMAL(12,2),                          -- Fee charged to the customer (CalculateFee at checkout)
+    expected_amount DECIMAL(12,2),                       -- What should reach the bank: the transaction amount
+    status VARCHAR(15) NOT NULL,                         -- matched, short, unmatched, adjustment
+    notes TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+
+    CONSTRAINT chk_settlement_line_status CHECK (status IN ('matched', 'short', 'unmatched', 'adjustment'))
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_settlement_lines_entity ON gateway_settlement_lines(gateway, line_type, entity_id);
+CREATE INDEX IF NOT EXISTS idx_settlement_lines_import ON gateway_settlement_lines(import_id);
+CREATE INDEX IF NOT EXISTS idx_settlement_lines_status ON gateway_settlement_lines(status);
+CREATE INDEX IF NOT EXISTS idx_settlement_lines_online_tx ON gateway_settlement_lines(online_transaction_id) WHERE online_transaction_id IS NOT NULL;
+
+COMMENT ON TABLE gateway_settlement_lines IS 'Payment gateway settlement report lines matched to online transactions';
+
+INSERT INTO system_settings (setting_key, setting_value, description)
+VALUES ('settlement_grace_days', '3', 'Days after capture before an unsettled online payment is flagged missing')
+ON CONFLICT (setting_key) DO NOTHING;
@@ -0,0 +1,86 @@
+-- Migration: 042_add_warehouse_layout.sql
+-- Purpose: Keep the warehouse layout (rooms, floors and the gatar numbers on each floor)
+-- in the database instead of the hard-coded gatarRanges map, so a new chamber can be
+-- added from the admin API without a deploy. Seeded with the current layout, including
+-- the split range on Room 4 floor 0 and Room 4 floor 4 starting at 2601.
+
+CREATE TABLE IF NOT EXISTS warehouse_rooms (
+    room_no VARCHAR(10) PRIMARY KEY,                -- As stored in room_entries.room_no
+    name VARCHAR(50) NOT NULL,
+    room_type VARCHAR(10) NOT NULL DEFAULT 'sell'   -- seed or sell thocks
+        CHECK (room_type IN ('seed', 'sell')),
+    sort_order INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS warehouse_floors (
+    room_no VARCHAR(10) NOT NULL REFERENCES warehouse_rooms(room_no) ON DELETE CASCADE,
+    floor VARCHAR(10) NOT NULL,                     -- As stored in room_entries.floor
+    grid_rows INT NOT NULL DEFAULT 10,              -- Rows of the floor plan grid
+    gatar_capacity INT NOT NULL DEFAULT 200         -- Bags per gatar on this floor
+        CHECK (gatar_capacity > 0),
+    PRIMARY KEY (room_no, floor)
+);
+
+-- Gatar numbers on a floor. Most floors have one range; a floor built in two parts has
+-- one range per part, laid side by side on the floor plan.
+CREATE TABLE IF NOT EXISTS warehouse_gatar_ranges (
+    id SERIAL PRIMARY KEY,
+    room_no VARCHAR(10) NOT NULL,
+    floor VARCHAR(10) NOT NULL,
+    start_gatar INT NOT NULL,
+    end_gatar INT NOT NULL,
+    grid_cols INT NOT NULL,                         -- Columns of the floor plan grid this range takes
+    sort_order INT NOT NULL DEFAULT 0,
+    FOREIGN KEY (room_no, floor) REFERENCES warehouse_floors(room_no, floor) ON DELETE CASCADE,
+    CHECK (end_gatar >= start_gatar AND start_gatar > 0 AND grid_cols > 0),
+    EXCLUDE USING gist (int4range(start_gatar, end_gatar, '[]') WITH &&)
+);
+
+CREATE INDEX IF NOT EXISTS idx_warehouse_gatar_ranges_floor ON warehouse_gatar_ranges(room_no, floor);
+
+-- Capacity of individual gatars that differ from their floor's gatar_capacity
+CREATE TABLE IF NOT EXISTS warehouse_gatar_capacities (
+    gatar_no INT PRIMARY KEY,
+    capacity INT NOT NULL CHECK (capacity > 0),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+COMMENT ON TABLE warehouse_rooms IS 'Storage rooms (chambers); room_type decides whether seed or sell thocks go there';
+COMMENT ON TABLE warehouse_floors IS 'Floors of each room with their floor plan rows and bags per gatar';
+COMMENT ON TABLE warehouse_gatar_ranges IS 'Gatar numbers on each floor; ranges never overlap';
+COMMENT ON TABLE warehouse_gatar_capacities IS 'Per-gatar capacity overrides';
+
+INSERT INTO warehouse_rooms (room_no, name, room_type, sort_order) VALUES
+    ('1', 'Room 1', 'seed', 1),
+    ('2', 'Room 2', 'seed', 2),
+    ('3', 'Room 3', 'sell', 3),
+    ('4', 'Room 4', 'sell', 4),
+    ('G', 'Gallery', 'sell', 5)
+ON CONFLICT (room_no) DO NOTHING;
+
+INSERT INTO warehouse_floors (room_no, floor, grid_rows) VALUES
+    ('1', '0', 10), ('1', '1', 10), ('1', '2', 10), ('1', '3', 10), ('1', '4', 10),
+    ('2', '0', 10), ('2', '1', 10), ('2', '2', 10), ('2', '3', 10), ('2', '4', 10),
+    ('3', '0', 10), ('3', '1', 10), ('3', '2', 10), ('3', '3', 10), ('3', '4', 10),
+    ('4', '0', 10), ('4', '1', 10), ('4', '2', 10), ('4', '3', 10), ('4', '4', 10),
+    ('G', '0', 15), ('G', '1', 14), ('G', '2', 14), ('G', '3', 14), ('G', '4', 14)
+ON CONFLICT (room_no, floor) DO NOTHING;
+
+INSERT INTO warehouse_gatar_ranges (room_no, floor, start_gatar, end_gatar, grid_cols, sort_order)
+SELECT v.room_no, v.floor, v.start_gatar, v.end_gatar, v.grid_cols, v.sort_order
+FROM (VALUES
+    ('1', '0', 1, 140, 14, 0), ('1', '1', 141, 280, 14, 0), ('1', '2', 281, 420, 14, 0),
+    ('1', '3', 421, 560, 14, 0), ('1', '4', 561, 680, 12, 0),
+    ('2', '0', 681, 820, 14, 0), ('2', '1', 821, 960, 14, 0), ('2', '2', 961, 1100, 14, 0),
+    ('2', '3', 1101, 1240, 14, 0), ('2', '4', 1241, 1360, 12, 0),
+    ('3', '0', 1361, 1500, 14, 0), ('3', '1', 1501, 1640, 14, 0), ('3', '2', 1641, 1780, 14, 0),
+    ('3', '3', 1781, 1920, 14, 0), ('3', '4', 1921, 2040, 12, 0),
+    ('4', '0', 2041, 2120, 8, 0), ('4', '0', 2869, 2928, 6, 1),
+    ('4', '1', 2121, 2260, 14, 0), ('4', '2', 2261, 2400, 14, 0),
+    ('4', '3', 2401, 2540, 14, 0), ('4', '4', 2601, 2720, 12, 0),
+    ('G', '0', 2727, 2756, 2, 0), ('G', '1', 2757, 2784, 2, 0), ('G', '2', 2785, 2812, 2, 0),
+    ('G', '3', 2813, 2840, 2, 0), ('G', '4', 2841, 2868, 2, 0)
+) AS v(room_no, floor, start_gatar, end_gatar, grid_cols, sort_order)
+WHERE NOT EXISTS (SELECT 1 FROM warehouse_gatar_ranges);
@@ -0,0 +1,8 @@
+-- Migration: 043_add_gatar_overflow_policy.sql
+-- Purpose: Room entries are checked against each gatar's capacity (warehouse layout).
+-- gatar_overflow_policy decides whether a room entry that overfills a gatar is saved
+-- with a warning (warn) or rejected (block).
+
+INSERT INTO system_settings (setting_key, setting_value, description)
+VALUES ('gatar_overflow_policy', 'warn', 'Room entries that overfill a gatar: warn (save with a warning) or block (reject)')
+ON CONFLICT (setting_key) DO NOTHING;
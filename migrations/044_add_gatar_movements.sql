@@ -0,0 +1,33 @@
+-- Migration: 044_add_gatar_movements.sql
+-- Purpose: Record internal stock shifting (palti) - bags of a thock moved from one gatar
+-- to another for ventilation, restacking or to free up space. room_entry_gatars holds
+-- where the bags are now; gatar_movements is the history, queried per gatar as its
+-- movement ledger.
+
+CREATE TABLE IF NOT EXISTS gatar_movements (
+    id SERIAL PRIMARY KEY,
+    entry_id INTEGER NOT NULL REFERENCES entries(id),
+    thock_number VARCHAR(50) NOT NULL,
+    from_room_entry_id INTEGER REFERENCES room_entries(id) ON DELETE SET NULL,
+    from_room_no VARCHAR(10) NOT NULL,
+    from_floor VARCHAR(10) NOT NULL,
+    from_gatar INTEGER NOT NULL,
+    to_room_entry_id INTEGER REFERENCES room_entries(id) ON DELETE SET NULL,
+    to_room_no VARCHAR(10) NOT NULL,
+    to_floor VARCHAR(10) NOT NULL,
+    to_gatar INTEGER NOT NULL,
+    quantity INTEGER NOT NULL CHECK (quantity > 0),
+    reason VARCHAR(20) NOT NULL DEFAULT 'restacking'
+        CHECK (reason IN ('ventilation', 'restacking', 'consolidation', 'other')),
+    notes TEXT,
+    moved_by_user_id INTEGER REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    CHECK (from_gatar <> to_gatar)
+);
+
+CREATE INDEX IF NOT EXISTS idx_gatar_movements_from_gatar ON gatar_movements(from_gatar, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_gatar_movements_to_gatar ON gatar_movements(to_gatar, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_gatar_movements_entry_id ON gatar_movements(entry_id);
+
+COMMENT ON TABLE gatar_movements IS 'Bags moved between gatars (palti); the movement ledger of each gatar';
+COMMENT ON COLUMN gatar_movements.to_room_entry_id IS 'Room entry holding the bags after the move - the same one unless they changed room or floor';
@@ -0,0 +1,46 @@
+-- Migration: 045_add_gatar_maintenance.sql
+-- Purpose: Schedule periodic bag-turning, ventilation and inspection of stored potatoes
+-- instead of tracking it on paper. A schedule covers a whole room, one floor of it or a
+-- single gatar; the most specific schedule for a task wins. Each completed round is
+-- logged against the room entry gatar rows it covered, with the quality seen, which
+-- also updates room_entry_gatars.quality.
+
+CREATE TABLE IF NOT EXISTS gatar_maintenance_schedules (
+    id SERIAL PRIMARY KEY,
+    room_no VARCHAR(10) NOT NULL,
+    floor VARCHAR(10) NOT NULL DEFAULT '',          -- '' = every floor of the room
+    gatar_no INTEGER NOT NULL DEFAULT 0,            -- 0 = every gatar on the floor
+    task VARCHAR(20) NOT NULL
+        CHECK (task IN ('turning', 'ventilation', 'inspection')),
+    interval_days INTEGER NOT NULL CHECK (interval_days > 0),
+    assigned_crew VARCHAR(100),
+    notes TEXT,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_by_user_id INTEGER REFERENCES users(id),
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    UNIQUE (room_no, floor, gatar_no, task)
+);
+
+CREATE TABLE IF NOT EXISTS gatar_maintenance_logs (
+    id SERIAL PRIMARY KEY,
+    schedule_id INTEGER REFERENCES gatar_maintenance_schedules(id) ON DELETE SET NULL,
+    gatar_no INTEGER NOT NULL,
+    room_entry_gatar_id INTEGER REFERENCES room_entry_gatars(id) ON DELETE SET NULL,
+    room_entry_id INTEGER REFERENCES room_entries(id) ON DELETE SET NULL,
+    thock_number VARCHAR(50) NOT NULL,
+    task VARCHAR(20) NOT NULL,
+    previous_quality VARCHAR(10),
+    observed_quality VARCHAR(10)                    -- N, U, D, G; NULL = not graded
+        CHECK (observed_quality IN ('N', 'U', 'D', 'G')),
+    crew VARCHAR(100),
+    notes TEXT,
+    done_by_user_id INTEGER REFERENCES users(id),
+    done_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_gatar_maintenance_logs_gatar ON gatar_maintenance_logs(gatar_no, task, done_at DESC);
+CREATE INDEX IF NOT EXISTS idx_gatar_maintenance_logs_room_entry_gatar ON gatar_maintenance_logs(room_entry_gatar_id);
+
+COMMENT ON TABLE gatar_maintenance_schedules IS 'How often the bags in a room, floor or gatar are turned, ventilated or inspected';
+COMMENT ON TABLE gatar_maintenance_logs IS 'Completed maintenance per room entry gatar, with the quality observed';
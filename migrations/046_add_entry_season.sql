@@ -0,0 +1,13 @@
+-- Migration: 046_add_entry_season.sql
+-- Purpose: Record the storage season each entry came in, so season-specific rate cards
+-- price it by its own season rather than whatever current_season says today. New
+-- entries take current_season when created; existing entries are stamped with the
+-- season in force now.
+
+ALTER TABLE entries ADD COLUMN IF NOT EXISTS season VARCHAR(100);
+
+UPDATE entries
+SET season = (SELECT NULLIF(TRIM(setting_value), '') FROM system_settings WHERE setting_key = 'current_season')
+WHERE season IS NULL;
+
+COMMENT ON COLUMN entries.season IS 'Storage season the entry came in (current_season setting when created); matched against rent_tariffs.season';
@@ -0,0 +1,21 @@
+-- Migration: 047_add_ledger_pickup_charge_unique.sql
+-- Purpose: Charge each gate pass pickup at most once. Two requests posting the same
+-- pickup's rent at the same time both passed the "already charged?" lookup; the index
+-- makes the second insert fail so the service returns the first charge instead.
+-- Other reference types (loan repayments, interest runs) legitimately repeat a
+-- reference, so the index only covers rent charges on pickups.
+
+-- Any pickup already charged twice keeps its first CHARGE; the later ones are retagged
+-- so they stay in the ledger (and the balance) but can be found and voided by hand.
+UPDATE ledger_entries le
+SET reference_type = 'gate_pass_pickup_duplicate'
+WHERE le.entry_type = 'CHARGE' AND le.reference_type = 'gate_pass_pickup'
+  AND EXISTS (
+      SELECT 1 FROM ledger_entries first
+      WHERE first.entry_type = le.entry_type AND first.reference_type = le.reference_type
+        AND first.reference_id = le.reference_id AND first.id < le.id
+  );
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_entries_pickup_charge
+    ON ledger_entries(entry_type, reference_type, reference_id)
+    WHERE entry_type = 'CHARGE' AND reference_type = 'gate_pass_pickup';
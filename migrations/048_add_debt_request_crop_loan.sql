@@ -0,0 +1,8 @@
+-- Migration: 048_add_debt_request_crop_loan.sql
+-- Purpose: Tie a debt request to the crop loan its thock was pledged to when it was
+-- raised. Pledged stock is only released against an approval for that loan - an older
+-- approval for rent arrears on the same thock no longer unlocks it.
+
+ALTER TABLE debt_requests ADD COLUMN IF NOT EXISTS crop_loan_id INTEGER REFERENCES crop_loans(id);
+
+COMMENT ON COLUMN debt_requests.crop_loan_id IS 'Active crop loan on the thock when the request was raised; required to release pledged stock';
@@ -0,0 +1,11 @@
+-- Migration: 049_add_ledger_cash_session.sql
+-- Purpose: Tie cash wallet deposits and refunds to the open cash session of the user
+-- who handled them, so the drawer count at closing includes them. Deposits add to the
+-- cash received; refunds are cash paid out of the drawer.
+
+ALTER TABLE ledger_entries ADD COLUMN IF NOT EXISTS cash_session_id INTEGER REFERENCES cash_sessions(id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_cash_session ON ledger_entries(cash_session_id) WHERE cash_session_id IS NOT NULL;
+
+ALTER TABLE cash_sessions ADD COLUMN IF NOT EXISTS cash_paid_out DECIMAL(12,2); -- Wallet refunds paid in cash, set at close
+
+COMMENT ON COLUMN ledger_entries.cash_session_id IS 'Open cash session of the user who took or paid out the cash (wallet deposits and refunds)';
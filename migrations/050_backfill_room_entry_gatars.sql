@@ -0,0 +1,100 @@
+-- Migration: 050_backfill_room_entry_gatars.sql
+-- Purpose: Gatar capacity checks, occupancy and maintenance read stock from
+-- room_entry_gatars, but room entries saved with only gate_no (no per-gatar quantities)
+-- never got rows there. Give every such room entry rows spread over its gate_no the way
+-- the room visualization shows it (models.DistributeQuantity):
+--   1. A single gatar takes every bag
+--   2. A quantity_breakdown with one count per gatar maps 1:1
+--   3. A longer breakdown fills the gatars in order up to their capacity, the last
+--      gatar taking any overflow
+--   4. Otherwise the bags are split evenly, the first gatars taking the remainder
+-- Rows carry the room entry's created_at, which is when the stock came in.
+
+DO $$
+DECLARE
+    re RECORD;
+    gatars TEXT[];
+    breakdown INT[];
+    capacities INT[];
+    quantities INT[];
+    num_gatars INT;
+    num_breakdown INT;
+    current_gatar INT;
+    current_gatar_bags INT;
+    remaining_capacity INT;
+    bags INT;
+    i INT;
+BEGIN
+    FOR re IN
+        SELECT r.id, COALESCE(r.gate_no, '') AS gate_no, r.quantity,
+               COALESCE(r.quantity_breakdown, '') AS quantity_breakdown, r.created_at
+        FROM room_entries r
+        WHERE NOT EXISTS (SELECT 1 FROM room_entry_gatars reg WHERE reg.room_entry_id = r.id)
+        ORDER BY r.id
+    LOOP
+        SELECT array_agg(btrim(p) ORDER BY n) INTO gatars
+        FROM unnest(string_to_array(re.gate_no, ',')) WITH ORDINALITY AS t(p, n)
+        WHERE btrim(p) <> '';
+        num_gatars := COALESCE(array_length(gatars, 1), 0);
+        CONTINUE WHEN num_gatars = 0;
+
+        SELECT array_agg(btrim(p)::INT ORDER BY n) INTO breakdown
+        FROM unnest(string_to_array(re.quantity_breakdown, ',')) WITH ORDINALITY AS t(p, n)
+        WHERE btrim(p) ~ '^[+-]?[0-9]{1,9}$';
+        num_breakdown := COALESCE(array_length(breakdown, 1), 0);
+
+        -- Capacity from the warehouse layout, the default for gatars not in it
+        capacities := array_fill(200, ARRAY[num_gatars]);
+        FOR i IN 1..num_gatars LOOP
+            IF gatars[i] ~ '^[0-9]{1,9}$' THEN
+                capacities[i] := COALESCE(
+                    (SELECT c.capacity FROM warehouse_gatar_capacities c WHERE c.gatar_no = gatars[i]::INT),
+                    (SELECT f.gatar_capacity
+                     FROM warehouse_gatar_ranges gr
+                     JOIN warehouse_floors f ON f.room_no = gr.room_no AND f.floor = gr.floor
+                     WHERE gatars[i]::INT BETWEEN gr.start_gatar AND gr.end_gatar
+                     LIMIT 1),
+                    200);
+            END IF;
+        END LOOP;
+
+        quantities := array_fill(0, ARRAY[num_gatars]);
+        IF num_gatars = 1 THEN
+            quantities[1] := re.quantity;
+        ELSIF num_breakdown = num_gatars THEN
+            quantities := breakdown;
+        ELSIF num_breakdown > num_gatars THEN
+            current_gatar := 1;
+            current_gatar_bags := 0;
+            FOREACH bags IN ARRAY breakdown LOOP
+                remaining_capacity := capacities[current_gatar] - current_gatar_bags;
+                IF bags <= remaining_capacity OR current_gatar = num_gatars THEN
+                    quantities[current_gatar] := quantities[current_gatar] + bags;
+                    current_gatar_bags := current_gatar_bags + bags;
+                ELSE
+                    quantities[current_gatar] := quantities[current_gatar] + remaining_capacity;
+                    current_gatar := current_gatar + 1;
+                    quantities[current_gatar] := quantities[current_gatar] + bags - remaining_capacity;
+                    current_gatar_bags := bags - remaining_capacity;
+                END IF;
+                IF current_gatar < num_gatars AND current_gatar_bags >= capacities[current_gatar] THEN
+                    current_gatar := current_gatar + 1;
+                    current_gatar_bags := 0;
+                END IF;
+            END LOOP;
+        ELSE
+            FOR i IN 1..num_gatars LOOP
+                quantities[i] := re.quantity / num_gatars
+                    + CASE WHEN i <= re.quantity % num_gatars THEN 1 ELSE 0 END;
+            END LOOP;
+        END IF;
+
+        -- Only numbered gatars can be stored; others still take their share above
+        FOR i IN 1..num_gatars LOOP
+            IF gatars[i] ~ '^[0-9]{1,9}$' THEN
+                INSERT INTO room_entry_gatars (room_entry_id, gatar_no, quantity, created_at, updated_at)
+                VALUES (re.id, gatars[i]::INT, quantities[i], re.created_at, re.created_at);
+            END IF;
+        END LOOP;
+    END LOOP;
+END $$;
@@ -0,0 +1,10 @@
+-- Migration: 051_ledger_pickup_charge_unique_active.sql
+-- Purpose: Let a pickup be charged again once its rent charge is voided. The unique
+-- index from 047 also covered voided charges, so after an admin voided a wrong charge
+-- the correct one could never be posted. Only live charges are unique now.
+
+DROP INDEX IF EXISTS idx_ledger_entries_pickup_charge;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_ledger_entries_pickup_charge
+    ON ledger_entries(entry_type, reference_type, reference_id)
+    WHERE entry_type = 'CHARGE' AND reference_type = 'gate_pass_pickup' AND voided_at IS NULL;
@@ -0,0 +1,14 @@
+// Warehouse layout for the room pages, loaded from /api/warehouse-layout/grid.
+// gatarRanges: room -> floor -> { start, end, cols, rows }, or for a floor built in
+// several parts { ranges: [{ start, end, cols }], cols, rows }.
+let gatarRanges = {};
+
+const gatarRangesReady = fetch('/api/warehouse-layout/grid', {
+    headers: { 'Authorization': `Bearer ${localStorage.getItem('token')}` }
+})
+    .then(response => {
+        if (!response.ok) throw new Error('HTTP ' + response.status);
+        return response.json();
+    })
+    .then(grid => { gatarRanges = grid; })
+    .catch(error => console.error('Failed to load warehouse layout:', error));